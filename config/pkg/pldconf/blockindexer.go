@@ -29,6 +29,7 @@ type BlockIndexerConfig struct {
 	RequiredConfirmations *int               `json:"requiredConfirmations"`
 	ChainHeadCacheLen     *int               `json:"chainHeadCacheLen"`
 	BlockPollingInterval  *string            `json:"blockPollingInterval"`
+	BlockTrackingMode     *string            `json:"blockTrackingMode"`
 	EventStreams          EventStreamsConfig `json:"eventStreams"`
 	Retry                 RetryConfig        `json:"retry"`
 }
@@ -50,4 +51,15 @@ var BlockIndexerDefaults = &BlockIndexerConfig{
 	RequiredConfirmations: confutil.P(0),
 	ChainHeadCacheLen:     confutil.P(50),
 	BlockPollingInterval:  confutil.P("10s"),
+	BlockTrackingMode:     confutil.P(BlockTrackingModeSubscription),
 }
+
+// How the block indexer learns of new blocks, which in turn drives the confirmation of public transactions
+const (
+	// Subscribe to newHeads over the WebSocket, and check for new blocks as soon as we are notified.
+	// We still check every blockPollingInterval in case a notification is missed, and fall back to
+	// polling alone while the subscription cannot be established.
+	BlockTrackingModeSubscription = "subscription"
+	// Only check for new blocks every blockPollingInterval, for nodes that do not support subscriptions
+	BlockTrackingModePolling = "polling"
+)
@@ -28,6 +28,7 @@ type DomainManagerConfig struct {
 
 type DomainManagerManagerConfig struct {
 	ContractCache CacheConfig `json:"contractCache"`
+	TraceCalls    *bool       `json:"traceCalls"` // log the start, duration and outcome of every call into a domain
 }
 
 type DomainConfig struct {
@@ -17,8 +17,53 @@ package pldconf
 import "github.com/kaleido-io/paladin/config/pkg/confutil"
 
 type GroupManagerConfig struct {
-	Cache            CacheConfig      `json:"cache"`
-	MessageListeners MessageListeners `json:"messageListeners"`
+	Cache            CacheConfig                             `json:"cache"`
+	MessageListeners MessageListeners                        `json:"messageListeners"`
+	Encryption       map[string]GroupMessageEncryptionConfig `json:"encryption"` // keyed by domain name
+	BlobStore        GroupMessageBlobStoreConfig             `json:"blobStore"`
+	// When enabled, a message sent with a correlation ID is rejected unless the correlation ID
+	// is the ID of a message already in the group. Disabled by default, as a message might legitimately
+	// correlate to a message from another node that has not yet been received by this node.
+	ValidateCorrelationIDs *bool                      `json:"validateCorrelationIds"`
+	Search                 GroupMessageSearchConfig   `json:"search"`
+	InboundMessages        GroupInboundMessagesConfig `json:"inboundMessages"`
+}
+
+const (
+	UnknownGroupMessageReject     = "reject"     // the message is nack'd to the sender, and not stored
+	UnknownGroupMessageQuarantine = "quarantine" // the message is ack'd, and stored in a separate table for inspection
+)
+
+// Controls the handling of messages received from other nodes, for a domain or privacy group that
+// does not exist on this node - such as a misrouted message, or one that arrives before the group.
+type GroupInboundMessagesConfig struct {
+	UnknownGroupAction *string `json:"unknownGroupAction"`
+}
+
+// Enables the free text search of message topics and data. On PostgreSQL a full-text index is created
+// over the searched content, and results are ranked by relevance. Other databases fall back to a
+// case-insensitive substring match, ranked by the number of search terms that match the topic.
+// Data that is encrypted at rest, or held in the blob store, is not searchable.
+type GroupMessageSearchConfig struct {
+	Enabled *bool `json:"enabled"`
+	// Top-level fields of the JSON data to search, in addition to the topic. When empty the whole data is searched.
+	// The full-text index is built over these fields, so it must be dropped manually if they change.
+	DataFields   []string `json:"dataFields"`
+	DefaultLimit *int     `json:"defaultLimit"`
+}
+
+// Enables storage of large message payloads outside of the database. Payloads (after any encryption)
+// larger than the inline threshold are written to the blob store, and only a reference persisted
+// with the message. Disabled unless a path is configured.
+type GroupMessageBlobStoreConfig struct {
+	Path            string  `json:"path"`
+	InlineThreshold *string `json:"inlineThreshold"`
+}
+
+// Enables encryption at rest of privacy group message data for a domain.
+// The key is derived within the signing module of the wallet holding the key, so its key material is never exposed.
+type GroupMessageEncryptionConfig struct {
+	KeyIdentifier string `json:"keyIdentifier"` // key in the key manager, from which the AES-256 key for the domain is derived
 }
 
 type MessageListeners struct {
@@ -34,4 +79,15 @@ var GroupManagerDefaults = &GroupManagerConfig{
 		Retry:        GenericRetryDefaults.RetryConfig,
 		ReadPageSize: confutil.P(100),
 	},
+	BlobStore: GroupMessageBlobStoreConfig{
+		InlineThreshold: confutil.P("64Kb"),
+	},
+	ValidateCorrelationIDs: confutil.P(false),
+	Search: GroupMessageSearchConfig{
+		Enabled:      confutil.P(false),
+		DefaultLimit: confutil.P(25),
+	},
+	InboundMessages: GroupInboundMessagesConfig{
+		UnknownGroupAction: confutil.P(UnknownGroupMessageReject),
+	},
 }
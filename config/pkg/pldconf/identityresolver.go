@@ -17,11 +17,15 @@ package pldconf
 import "github.com/kaleido-io/paladin/config/pkg/confutil"
 
 type IdentityResolverConfig struct {
-	VerifierCache CacheConfig `json:"verifierCache"`
+	VerifierCache        CacheConfig `json:"verifierCache"`
+	VerifierCacheEnabled *bool       `json:"verifierCacheEnabled"`
+	VerifierCacheTTL     *string     `json:"verifierCacheTTL"` // resolutions older than this are re-run, even if they are still in the cache
 }
 
 var IdentityResolverDefaults = &IdentityResolverConfig{
 	VerifierCache: CacheConfig{
 		Capacity: confutil.P(1000),
 	},
+	VerifierCacheEnabled: confutil.P(true),
+	VerifierCacheTTL:     confutil.P("1m"),
 }
@@ -22,8 +22,31 @@ type PrivateTxManagerConfig struct {
 	StateDistributer               DistributerConfig               `json:"stateDistributer"`
 	PreparedTransactionDistributer DistributerConfig               `json:"preparedTransactionDistributer"`
 	RequestTimeout                 *string                         `json:"requestTimeout"`
+	// Development mode must never be enabled on a production network. It allows behaviors that bypass
+	// the security of the private transaction flow, purely to speed up local development and testing.
+	DevelopmentMode *bool `json:"developmentMode"`
+	// Endorsements requested from this node for contracts in these domains are approved without
+	// invoking the domain or signing. Requires developmentMode.
+	AutoApproveEndorsementDomains []string `json:"autoApproveEndorsementDomains"`
+	// The number of parties in each endorsement attestation request that must endorse before a transaction
+	// advances, keyed by domain name. Domains that are not listed require every party to endorse.
+	EndorsementQuorum map[string]int `json:"endorsementQuorum"`
+	// The signing identity used to submit the base ledger transactions of the contracts listed, keyed by contract
+	// address, in place of a randomly allocated key. Each must be an existing key on the local node - one that is
+	// not found at startup is not used, and the random key is used instead.
+	PinnedSigners map[string]string `json:"pinnedSigners"`
+	// What happens when an endorsement arrives from a party that is not in the expected endorser set of the
+	// attestation request it answers - see UntrustedEndorsementPolicy*
+	UntrustedEndorsementPolicy *string `json:"untrustedEndorsementPolicy"`
 }
 
+const (
+	// The endorsement is discarded, and the transaction continues to wait for the expected endorsers
+	UntrustedEndorsementPolicyDrop = "drop"
+	// The transaction is reverted, with a receipt giving the untrusted party
+	UntrustedEndorsementPolicyRevert = "revert"
+)
+
 type DistributerConfig struct {
 	AcknowledgementWriter FlushWriterConfig `json:"acknowledgementWriter"`
 	ReceivedObjectWriter  FlushWriterConfig `json:"receivedStateWriter"`
@@ -45,17 +68,45 @@ var PrivateTxManagerDefaults = &PrivateTxManagerConfig{
 		MaxPendingEvents:                    confutil.P(500),
 		RoundRobinCoordinatorBlockRangeSize: confutil.P(100),
 		AssembleRequestTimeout:              confutil.P("1s"),
+		MaxReassemblyAttempts:               confutil.P(10),
+		DelegationTimeout:                   confutil.P("0"),
+		EventDedupCache: CacheConfig{
+			Capacity: confutil.P(1000),
+		},
+		EventDedupTimeout: confutil.P("10m"),
+		AssembleRetry: RetryConfigWithMax{
+			RetryConfig: RetryConfig{
+				InitialDelay: confutil.P("250ms"),
+				MaxDelay:     confutil.P("10s"),
+				Factor:       confutil.P(2.0),
+			},
+			MaxAttempts: confutil.P(0),
+		},
+		MaxDispatchedPerSigner: confutil.P(0),
 	},
-	RequestTimeout: confutil.P("1s"),
+	RequestTimeout:             confutil.P("1s"),
+	UntrustedEndorsementPolicy: confutil.P(UntrustedEndorsementPolicyDrop),
 }
 
 type PrivateTxManagerSequencerConfig struct {
-	MaxConcurrentProcess                *int    `json:"maxConcurrentProcess,omitempty"`
-	MaxInflightTransactions             *int    `json:"maxInflightTransactions,omitempty"`
-	MaxPendingEvents                    *int    `json:"maxPendingEvents,omitempty"`
-	EvaluationInterval                  *string `json:"evalInterval,omitempty"`
-	PersistenceRetryTimeout             *string `json:"persistenceRetryTimeout,omitempty"`
-	StaleTimeout                        *string `json:"staleTimeout,omitempty"`
-	RoundRobinCoordinatorBlockRangeSize *int    `json:"roundRobinCoordinatorBlockRangeSize,omitempty"`
-	AssembleRequestTimeout              *string `json:"assembleRequestTimeout,omitempty"`
+	MaxConcurrentProcess                *int        `json:"maxConcurrentProcess,omitempty"`
+	MaxInflightTransactions             *int        `json:"maxInflightTransactions,omitempty"`
+	MaxPendingEvents                    *int        `json:"maxPendingEvents,omitempty"`
+	EvaluationInterval                  *string     `json:"evalInterval,omitempty"`
+	PersistenceRetryTimeout             *string     `json:"persistenceRetryTimeout,omitempty"`
+	StaleTimeout                        *string     `json:"staleTimeout,omitempty"`
+	RoundRobinCoordinatorBlockRangeSize *int        `json:"roundRobinCoordinatorBlockRangeSize,omitempty"`
+	AssembleRequestTimeout              *string     `json:"assembleRequestTimeout,omitempty"`
+	MaxReassemblyAttempts               *int        `json:"maxReassemblyAttempts,omitempty"` // 0 disables the limit
+	DelegationTimeout                   *string     `json:"delegationTimeout,omitempty"`     // how long to wait for a delegate to accept before reclaiming the transaction - 0 (the default) waits indefinitely
+	EventDedupCache                     CacheConfig `json:"eventDedupCache"`                 // recently applied endorsed/confirmed events, so a redelivered duplicate is discarded
+	EventDedupTimeout                   *string     `json:"eventDedupTimeout,omitempty"`     // how long an applied event is remembered for
+	// Backoff between attempts to assemble a transaction that failed to assemble, such as when the states it
+	// requires are not yet available locally. It is reverted after maxAttempts failures - 0 (the default) retries indefinitely
+	AssembleRetry RetryConfigWithMax `json:"assembleRetry"`
+	// The maximum number of transactions dispatched to the base ledger for a single signing identity that are yet
+	// to be confirmed. Further transactions wait in the sequencer, so a signing address is not handed transactions
+	// faster than its public transaction orchestrator can submit them - so this is best aligned with the maxInFlight
+	// of the public transaction manager orchestrators. 0 disables the limit
+	MaxDispatchedPerSigner *int `json:"maxDispatchedPerSigner,omitempty"`
 }
@@ -34,7 +34,10 @@ var PublicTxManagerDefaults = &PublicTxManagerConfig{
 		OrchestratorIdleTimeout:  confutil.P("1s"),
 		OrchestratorStaleTimeout: confutil.P("5m"),
 		OrchestratorSwapTimeout:  confutil.P("10m"),
+		OrchestratorPausePolicy:  confutil.P(OrchestratorPausePolicyOldest),
+		SignerKeyLossPolicy:      confutil.P(SignerKeyLossPolicyPause),
 		NonceCacheTimeout:        confutil.P("1h"),
+		ConfirmationDepth:        confutil.P(0),
 		Retry: RetryConfig{
 			InitialDelay: confutil.P("250ms"),
 			MaxDelay:     confutil.P("30s"),
@@ -53,6 +56,11 @@ var PublicTxManagerDefaults = &PublicTxManagerConfig{
 			},
 			RecordsPerTransaction: confutil.P(25),
 		},
+		Retention: PublicTxManagerRetentionConfig{
+			MaxAge:    nil, // retention is disabled unless a max age is configured
+			Interval:  confutil.P("1h"),
+			BatchSize: confutil.P(100),
+		},
 	},
 	Orchestrator: PublicTxManagerOrchestratorConfig{
 		MaxInFlight:          confutil.P(500),
@@ -61,6 +69,8 @@ var PublicTxManagerDefaults = &PublicTxManagerConfig{
 		StaleTimeout:         confutil.P("5m"),
 		StageRetryTime:       confutil.P("10s"),
 		PersistenceRetryTime: confutil.P("5s"),
+		BalanceCheck:         confutil.P(true),
+		ColdStartReconcile:   confutil.P(false),
 		SubmissionRetry: RetryConfigWithMax{
 			RetryConfig: RetryConfig{
 				InitialDelay: confutil.P("250ms"),
@@ -69,11 +79,31 @@ var PublicTxManagerDefaults = &PublicTxManagerConfig{
 			},
 			MaxAttempts: confutil.P(3),
 		},
+		StageTriggerRetry: RetryConfigWithMax{
+			RetryConfig: RetryConfig{
+				InitialDelay: confutil.P("250ms"),
+				MaxDelay:     confutil.P("30s"),
+				Factor:       confutil.P(2.0),
+			},
+			MaxAttempts: confutil.P(5),
+		},
 	},
 	GasPrice: GasPriceConfig{
 		IncreaseMax:        nil,
 		IncreasePercentage: confutil.P(0),
 		FixedGasPrice:      nil,
+		Strategies: GasPriceStrategiesConfig{
+			Aggressive:        confutil.P(1.5),
+			Normal:            confutil.P(1.0),
+			Economy:           confutil.P(0.8),
+			EconomyDeadline:   confutil.P("30m"),
+			EconomyEscalation: confutil.P("10m"),
+		},
+		History: GasPriceHistoryConfig{
+			Enabled:        confutil.P(false),
+			SampleInterval: confutil.P("1m"),
+			Retention:      confutil.P("168h"),
+		},
 		Cache: CacheConfig{
 			Capacity: confutil.P(100),
 			// TODO: Enable a KB based cache with TTL in Paladin
@@ -91,6 +121,7 @@ var PublicTxManagerDefaults = &PublicTxManagerConfig{
 			// Size:     confutil.P("5m"),
 			// TTL:      confutil.P("30s"),
 		},
+		CacheTTL: confutil.P("5s"),
 		AutoFueling: AutoFuelingConfig{
 			Source:                           nil,
 			SourceAddressMinBalance:          nil,
@@ -112,10 +143,39 @@ type PublicTxManagerManagerConfig struct {
 	OrchestratorIdleTimeout  *string                              `json:"orchestratorIdleTimeout"`  // idle orchestrators exit after this time
 	OrchestratorStaleTimeout *string                              `json:"orchestratorStaleTimeout"` // stale orchestrators exit after this time - TODO: Define stale
 	OrchestratorSwapTimeout  *string                              `json:"orchestratorSwapTimeout"`  // orchestrators are cycled out after this time, when all slots are full
+	OrchestratorWatchdog     *string                              `json:"orchestratorWatchdog"`     // orchestrators making no progress for this time are restarted, unless idle or stale - disabled if unset
+	OrchestratorPausePolicy  *string                              `json:"orchestratorPausePolicy"`  // which orchestrators are paused when all slots are full - see OrchestratorPausePolicy*
+	SigningAddressPriority   map[string]int                       `json:"signingAddressPriority"`   // priority of signing addresses for the lowestPriority pause policy - unlisted addresses are 0
+	SignerKeyLossPolicy      *string                              `json:"signerKeyLossPolicy"`      // what happens to a signing address whose key no longer exists - see SignerKeyLossPolicy*
 	NonceCacheTimeout        *string                              `json:"nonceCacheTimeout"`
+	ConfirmationDepth        *int                                 `json:"confirmationDepth"` // blocks that must be built on the inclusion block before a transaction is considered complete
+	MaxPendingBacklog        *int                                 `json:"maxPendingBacklog"` // new submissions are rejected while this many transactions are pending - disabled if unset or 0
 	ActivityRecords          PublicTxManagerActivityRecordsConfig `json:"activityRecords"`
 	SubmissionWriter         FlushWriterConfig                    `json:"submissionWriter"`
 	Retry                    RetryConfig                          `json:"retry"`
+	Retention                PublicTxManagerRetentionConfig       `json:"retention"`
+}
+
+const (
+	// Orchestrators that have held their slot for longer than the swap timeout are paused
+	OrchestratorPausePolicyOldest = "oldest"
+	// Orchestrators that have made no progress for longer than the swap timeout are paused
+	OrchestratorPausePolicyLeastProgressed = "leastProgressed"
+	// Of the orchestrators that have held their slot for longer than the swap timeout, those of the lowest priority are paused
+	OrchestratorPausePolicyLowestPriority = "lowestPriority"
+)
+
+const (
+	// The signing address is paused until the node is restarted, leaving its transactions pending for an operator to restore the key
+	SignerKeyLossPolicyPause = "pause"
+	// All pending transactions of the signing address are failed, with a receipt giving the reason
+	SignerKeyLossPolicyFail = "fail"
+)
+
+type PublicTxManagerRetentionConfig struct {
+	MaxAge    *string `json:"maxAge"`    // completed transactions older than this are purged - disabled if unset
+	Interval  *string `json:"interval"`  // how often the compaction job runs
+	BatchSize *int    `json:"batchSize"` // number of transactions deleted in each DB transaction
 }
 
 type PublicTxManagerActivityRecordsConfig struct {
@@ -133,29 +193,82 @@ const (
 
 type BalanceManagerConfig struct {
 	Cache       CacheConfig       `json:"cache"`
+	CacheTTL    *string           `json:"cacheTTL"` // cached balances are re-fetched from the chain once older than this
 	AutoFueling AutoFuelingConfig `json:"autoFueling"`
 }
 
 type AutoFuelingConfig struct {
-	Source                           *string `json:"source"` // key resolution string
-	SourceAddressMinBalance          *string `json:"sourceAddressMinBalance"`
-	ProactiveFuelingTransactionTotal *int    `json:"proactiveFuelingTransactionTotal"`
-	ProactiveCostEstimationMethod    *string `json:"proactiveCostEstimationMethod"`
-	MinDestBalance                   *string `json:"minDestBalance"`
-	MaxDestBalance                   *string `json:"maxDestBalance"`
-	MinThreshold                     *string `json:"minThreshold"`
+	Source                           *string                    `json:"source"`  // key resolution string
+	Sources                          []*AutoFuelingSourceConfig `json:"sources"` // weighted round-robin across multiple sources - combined with source if both are set
+	SourceAddressMinBalance          *string                    `json:"sourceAddressMinBalance"`
+	ProactiveFuelingTransactionTotal *int                       `json:"proactiveFuelingTransactionTotal"`
+	ProactiveCostEstimationMethod    *string                    `json:"proactiveCostEstimationMethod"`
+	MinDestBalance                   *string                    `json:"minDestBalance"`
+	MaxDestBalance                   *string                    `json:"maxDestBalance"`
+	MinThreshold                     *string                    `json:"minThreshold"`
+}
+
+type AutoFuelingSourceConfig struct {
+	Source *string `json:"source"` // key resolution string
+	Weight *int    `json:"weight"` // share of fueling transactions for this source - 0 excludes the source
+}
+
+var AutoFuelingSourceDefaults = &AutoFuelingSourceConfig{
+	Weight: confutil.P(1),
 }
 
 type GasPriceConfig struct {
-	IncreaseMax        *string            `json:"increaseMax"`
-	IncreasePercentage *int               `json:"increasePercentage"`
-	FixedGasPrice      any                `json:"fixedGasPrice"` // number or object
-	GasOracleAPI       GasOracleAPIConfig `json:"gasOracleAPI"`
-	Cache              CacheConfig        `json:"cache"`
+	IncreaseMax        *string               `json:"increaseMax"`
+	IncreasePercentage *int                  `json:"increasePercentage"`
+	FixedGasPrice      any                   `json:"fixedGasPrice"` // number or object
+	GasOracleAPI       GasOracleAPIConfig    `json:"gasOracleAPI"`
+	Cache              CacheConfig           `json:"cache"`
+	History            GasPriceHistoryConfig `json:"history"`
+	// Optional per-signing-address adjustments applied on top of the shared gas price, keyed by address
+	SignerOverrides map[string]GasPriceSignerOverrideConfig `json:"signerOverrides"`
+	// The multipliers of the named strategies that can be selected for a signer in its override
+	Strategies GasPriceStrategiesConfig `json:"strategies"`
+}
+
+const (
+	GasPriceStrategyAggressive = "aggressive"
+	GasPriceStrategyNormal     = "normal"
+	GasPriceStrategyEconomy    = "economy"
+)
+
+type GasPriceStrategiesConfig struct {
+	Aggressive *float64 `json:"aggressive"` // multiplier applied to the shared gas price
+	Normal     *float64 `json:"normal"`
+	Economy    *float64 `json:"economy"`
+	// Economy transactions are not left behind indefinitely. Over the escalation period before the deadline,
+	// measured from when the transaction was created, the multiplier rises to that of the aggressive strategy
+	EconomyDeadline   *string `json:"economyDeadline"`
+	EconomyEscalation *string `json:"economyEscalation"`
+}
+
+type GasPriceHistoryConfig struct {
+	Enabled        *bool   `json:"enabled"`        // record the suggested gas price periodically, for charting gas trends
+	SampleInterval *string `json:"sampleInterval"` // how often a sample is recorded
+	Retention      *string `json:"retention"`      // samples older than this are purged
+}
+
+type GasPriceSignerOverrideConfig struct {
+	Strategy   *string  `json:"strategy"`   // a named strategy providing the multiplier - cannot be combined with multiplier
+	Multiplier *float64 `json:"multiplier"` // applied to the shared gas price before the floor/ceiling
+	Floor      *string  `json:"floor"`      // minimum gas price (in wei) for this signer
+	Ceiling    *string  `json:"ceiling"`    // maximum gas price (in wei) for this signer
 }
 
 type GasLimitConfig struct {
-	GasEstimateFactor *float64 `json:"gasEstimateFactor"`
+	GasEstimateFactor *float64                                `json:"gasEstimateFactor"` // multiplier applied to the gas estimate from the node
+	Floor             *uint64                                 `json:"floor"`             // minimum gas limit after the multiplier is applied
+	BlockGasLimit     *uint64                                 `json:"blockGasLimit"`     // the gas limit of a block on the chain - the multiplied gas limit is capped at this value if set
+	SignerOverrides   map[string]GasLimitSignerOverrideConfig `json:"signerOverrides"`
+}
+
+type GasLimitSignerOverrideConfig struct {
+	GasEstimateFactor *float64 `json:"gasEstimateFactor"` // replaces the gasEstimateFactor for this signer
+	Floor             *uint64  `json:"floor"`             // replaces the floor for this signer
 }
 
 type GasOracleAPIConfig struct {
@@ -171,5 +284,10 @@ type PublicTxManagerOrchestratorConfig struct {
 	StageRetryTime            *string            `json:"stageRetryTime"`
 	PersistenceRetryTime      *string            `json:"persistenceRetryTime"`
 	UnavailableBalanceHandler *string            `json:"unavailableBalanceHandler"`
+	BalanceCheck              *bool              `json:"balanceCheck"` // hold transactions before signing while the address cannot cover their max cost
 	SubmissionRetry           RetryConfigWithMax `json:"submissionRetry"`
+	StageTriggerRetry         RetryConfigWithMax `json:"stageTriggerRetry"` // backoff between attempts to start a stage, and the attempts before the transaction is suspended
+	// When an orchestrator starts for an address with no completed transactions recorded, seed the completed nonce
+	// watermark from the confirmed nonce on the chain, so transactions that were already mined are not re-submitted
+	ColdStartReconcile *bool `json:"coldStartReconcile"`
 }
@@ -38,6 +38,8 @@ type KeyStoreConfig struct {
 	Type              string                   `json:"type"`
 	DisableKeyListing bool                     `json:"disableKeyListing"`
 	KeyStoreSigning   bool                     `json:"keyStoreSigning"` // if HD Wallet or ZKP based signing is required, in-memory keys are required (so this needs to be false)
+	ReadOnly          bool                     `json:"readOnly"`        // existing keys can be loaded and used, but new key material is never created - such as for a disaster recovery standby
+	ZeroKeyMaterial   bool                     `json:"zeroKeyMaterial"` // key material loaded into memory is overwritten with zeros as soon as each resolve or sign completes
 	FileSystem        FileSystemKeyStoreConfig `json:"filesystem"`
 	Static            StaticKeyStoreConfig     `json:"static"`
 }
@@ -99,10 +101,65 @@ type StaticKeyStoreConfig struct {
 }
 
 type FileSystemKeyStoreConfig struct {
-	Path     *string     `json:"path"`
-	Cache    CacheConfig `json:"cache"`
-	FileMode *string     `json:"fileMode"`
-	DirMode  *string     `json:"dirMode"`
+	Path             *string                `json:"path"`
+	Cache            CacheConfig            `json:"cache"`
+	FileMode         *string                `json:"fileMode"`
+	DirMode          *string                `json:"dirMode"`
+	KDF              KeyStoreKDFConfig      `json:"kdf"`
+	MasterKeyWrapper MasterKeyWrapperConfig `json:"masterKeyWrapper"`
+}
+
+const (
+	KeyStoreKDFScrypt = "scrypt"
+	KeyStoreKDFPBKDF2 = "pbkdf2"
+)
+
+// The key derivation function used to derive the encryption key for new key files from their password.
+// The parameters are recorded in each key file, so changing these only affects keys created afterwards.
+type KeyStoreKDFConfig struct {
+	Type             *string `json:"type"`             // scrypt or pbkdf2
+	ScryptN          *int    `json:"scryptN"`          // CPU/memory cost - must be a power of 2
+	ScryptR          *int    `json:"scryptR"`          // block size
+	ScryptP          *int    `json:"scryptP"`          // parallelization
+	PBKDF2Iterations *int    `json:"pbkdf2Iterations"` // iteration count for HMAC-SHA256
+}
+
+const (
+	MasterKeyWrapperNone         = "none"         // key file passwords are stored alongside the key files in the clear
+	MasterKeyWrapperAES          = "aes"          // AES-256-GCM with a locally held master key
+	MasterKeyWrapperAWSKMS       = "awskms"       // AWS KMS Encrypt/Decrypt with a symmetric KMS key
+	MasterKeyWrapperVaultTransit = "vaulttransit" // HashiCorp Vault Transit secrets engine
+)
+
+// The master key wrapper encrypts the password of each key file before it is written to disk,
+// so the key material cannot be loaded without access to the master key. Key files record the
+// wrapper they were written with, so existing unwrapped keys remain readable after one is enabled.
+type MasterKeyWrapperConfig struct {
+	Type         *string                            `json:"type"`
+	AES          AESMasterKeyWrapperConfig          `json:"aes"`
+	AWSKMS       AWSKMSMasterKeyWrapperConfig       `json:"awsKMS"`
+	VaultTransit VaultTransitMasterKeyWrapperConfig `json:"vaultTransit"`
+}
+
+type AESMasterKeyWrapperConfig struct {
+	KeyFile string `json:"keyFile"` // file containing the hex encoded 256bit master key
+	Key     string `json:"key"`     // hex encoded 256bit master key in-line in the config
+}
+
+type AWSKMSMasterKeyWrapperConfig struct {
+	HTTPClientConfig `json:",inline"` // URL of the KMS endpoint, such as https://kms.us-east-1.amazonaws.com
+	Region           string           `json:"region"`
+	KeyID            string           `json:"keyId"`
+	AccessKeyID      string           `json:"accessKeyId"`
+	SecretAccessKey  string           `json:"secretAccessKey"`
+	SessionToken     string           `json:"sessionToken"`
+}
+
+type VaultTransitMasterKeyWrapperConfig struct {
+	HTTPClientConfig `json:",inline"` // URL of the Vault server
+	MountPath        *string          `json:"mountPath"`
+	KeyName          string           `json:"keyName"`
+	Token            string           `json:"token"`
 }
 
 var FileSystemDefaults = &FileSystemKeyStoreConfig{
@@ -112,4 +169,17 @@ var FileSystemDefaults = &FileSystemKeyStoreConfig{
 	Cache: CacheConfig{
 		Capacity: confutil.P(100),
 	},
+	KDF: KeyStoreKDFConfig{
+		Type:             confutil.P(KeyStoreKDFScrypt),
+		ScryptN:          confutil.P(1024),
+		ScryptR:          confutil.P(8),
+		ScryptP:          confutil.P(1),
+		PBKDF2Iterations: confutil.P(262144),
+	},
+	MasterKeyWrapper: MasterKeyWrapperConfig{
+		Type: confutil.P(MasterKeyWrapperNone),
+		VaultTransit: VaultTransitMasterKeyWrapperConfig{
+			MountPath: confutil.P("transit"),
+		},
+	},
 }
@@ -37,6 +37,8 @@ type ReceiptListeners struct {
 	Retry                 RetryConfig `json:"retry"`
 	ReadPageSize          *int        `json:"readPageSize"`
 	StateGapCheckInterval *string     `json:"stateGapCheckInterval"`
+	GracefulCloseTimeout  *string     `json:"gracefulCloseTimeout"`
+	MaxSubscriptionNacks  *int        `json:"maxSubscriptionNacks"` // consecutive nacks before a JSON/RPC subscription is closed, or zero for no limit
 }
 
 var TxManagerDefaults = &TxManagerConfig{
@@ -54,5 +56,7 @@ var TxManagerDefaults = &TxManagerConfig{
 		Retry:                 GenericRetryDefaults.RetryConfig,
 		ReadPageSize:          confutil.P(100),
 		StateGapCheckInterval: confutil.P("1s"),
+		GracefulCloseTimeout:  confutil.P("1s"),
+		MaxSubscriptionNacks:  confutil.P(0),
 	},
 }
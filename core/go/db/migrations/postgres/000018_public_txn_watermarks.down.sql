@@ -0,0 +1,4 @@
+BEGIN;
+DROP INDEX public_completions_created;
+DROP TABLE public_txn_watermarks;
+COMMIT;
@@ -0,0 +1,14 @@
+BEGIN;
+
+-- Summary of the highest completed nonce per signing address, which is retained
+-- after the retention policy purges the underlying public_txns rows
+CREATE TABLE public_txn_watermarks (
+  "from"                      VARCHAR         NOT NULL,
+  "nonce"                     BIGINT          NOT NULL,
+  "updated"                   BIGINT          NOT NULL,
+  PRIMARY KEY ("from")
+);
+
+CREATE INDEX public_completions_created ON public_completions("created");
+
+COMMIT;
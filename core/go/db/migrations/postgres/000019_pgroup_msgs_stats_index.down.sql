@@ -0,0 +1,3 @@
+BEGIN;
+DROP INDEX pgroup_msgs_stats;
+COMMIT;
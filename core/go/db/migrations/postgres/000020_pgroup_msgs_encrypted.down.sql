@@ -0,0 +1,5 @@
+BEGIN;
+
+ALTER TABLE pgroup_msgs DROP COLUMN "encrypted";
+
+COMMIT;
@@ -0,0 +1,5 @@
+BEGIN;
+
+ALTER TABLE public_txns DROP COLUMN "raw_tx";
+
+COMMIT;
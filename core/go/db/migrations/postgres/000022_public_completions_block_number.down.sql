@@ -0,0 +1,5 @@
+BEGIN;
+
+ALTER TABLE public_completions DROP COLUMN "block_number";
+
+COMMIT;
@@ -0,0 +1,6 @@
+BEGIN;
+
+-- The inclusion block of the transaction, so the depth of the confirmation can be calculated
+ALTER TABLE public_completions ADD "block_number" BIGINT;
+
+COMMIT;
@@ -0,0 +1,6 @@
+BEGIN;
+
+DROP INDEX public_txns_group_id;
+ALTER TABLE public_txns DROP COLUMN "group_id";
+
+COMMIT;
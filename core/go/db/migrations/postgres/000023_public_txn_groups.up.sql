@@ -0,0 +1,7 @@
+BEGIN;
+
+-- Transactions submitted together as a group, which are assigned contiguous nonces
+ALTER TABLE public_txns ADD "group_id" UUID;
+CREATE INDEX public_txns_group_id ON public_txns("group_id");
+
+COMMIT;
@@ -0,0 +1,6 @@
+BEGIN;
+
+DROP INDEX public_txns_label;
+ALTER TABLE public_txns DROP COLUMN "label";
+
+COMMIT;
@@ -0,0 +1,7 @@
+BEGIN;
+
+-- Free-text label set by the submitter, for finding related transactions during triage
+ALTER TABLE public_txns ADD "label" TEXT;
+CREATE INDEX public_txns_label ON public_txns("label");
+
+COMMIT;
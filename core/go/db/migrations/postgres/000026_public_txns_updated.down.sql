@@ -0,0 +1,6 @@
+BEGIN;
+
+DROP INDEX public_txns_updated;
+ALTER TABLE public_txns DROP COLUMN "updated";
+
+COMMIT;
@@ -0,0 +1,6 @@
+BEGIN;
+
+-- Set when the message data is held in the external blob store, rather than inline in the "data" column
+ALTER TABLE pgroup_msgs ADD "blob_ref" TEXT;
+
+COMMIT;
@@ -0,0 +1,5 @@
+BEGIN;
+
+DROP TABLE public_gas_price_history;
+
+COMMIT;
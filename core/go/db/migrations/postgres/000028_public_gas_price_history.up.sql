@@ -0,0 +1,15 @@
+BEGIN;
+
+-- Samples of the gas price suggested for new submissions, taken periodically for charting
+-- gas trends over time. Purged after the configured retention window.
+CREATE TABLE public_gas_price_history (
+  "sequence"                  BIGINT          GENERATED ALWAYS AS IDENTITY,
+  "created"                   BIGINT          NOT NULL,
+  "gas_price"                 VARCHAR,
+  "max_fee_per_gas"           VARCHAR,
+  "max_priority_fee_per_gas"  VARCHAR,
+  PRIMARY KEY ("sequence")
+);
+CREATE INDEX public_gas_price_history_created ON public_gas_price_history("created");
+
+COMMIT;
@@ -0,0 +1,6 @@
+BEGIN;
+
+-- Category of the most recent failure of the transaction, derived from the error returned by the node
+ALTER TABLE public_txns ADD "failure_category" TEXT;
+
+COMMIT;
@@ -0,0 +1,5 @@
+BEGIN;
+
+DROP TABLE pgroup_msgs_quarantine;
+
+COMMIT;
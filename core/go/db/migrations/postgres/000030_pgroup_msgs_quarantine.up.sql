@@ -0,0 +1,19 @@
+BEGIN;
+
+-- Messages received for a domain or privacy group that does not exist on this node, when configured to quarantine them
+CREATE TABLE pgroup_msgs_quarantine (
+  "local_seq"                 BIGINT          GENERATED ALWAYS AS IDENTITY,
+  "domain"                    TEXT            NOT NULL,
+  "group"                     TEXT            NOT NULL,
+  "node"                      TEXT            NOT NULL,
+  "sent"                      BIGINT          NOT NULL,
+  "received"                  BIGINT          NOT NULL,
+  "id"                        UUID            NOT NULL,
+  "cid"                       UUID            ,
+  "topic"                     TEXT            NOT NULL,
+  "data"                      TEXT            NOT NULL,
+  "reason"                    TEXT            NOT NULL
+);
+CREATE UNIQUE INDEX pgroup_msgs_quarantine_id ON pgroup_msgs_quarantine ("id");
+
+COMMIT;
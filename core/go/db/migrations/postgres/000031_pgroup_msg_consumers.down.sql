@@ -0,0 +1,5 @@
+BEGIN;
+
+DROP TABLE pgroup_msg_consumers;
+
+COMMIT;
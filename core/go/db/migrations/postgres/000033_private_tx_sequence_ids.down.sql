@@ -0,0 +1,5 @@
+BEGIN;
+
+DROP TABLE private_tx_sequence_ids;
+
+COMMIT;
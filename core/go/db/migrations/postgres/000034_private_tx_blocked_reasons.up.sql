@@ -0,0 +1,11 @@
+BEGIN;
+
+-- What each private transaction that is not progressing is waiting on, until it is dispatched
+CREATE TABLE private_tx_blocked_reasons (
+    "transaction"       UUID    NOT NULL,
+    "reason"            TEXT    NOT NULL,
+    "updated"           BIGINT  NOT NULL,
+    PRIMARY KEY ("transaction")
+);
+
+COMMIT;
@@ -0,0 +1,2 @@
+DROP INDEX public_completions_created;
+DROP TABLE public_txn_watermarks;
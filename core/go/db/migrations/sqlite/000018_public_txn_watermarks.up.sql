@@ -0,0 +1,8 @@
+CREATE TABLE public_txn_watermarks (
+  "from"                      TEXT            NOT NULL,
+  "nonce"                     BIGINT          NOT NULL,
+  "updated"                   BIGINT          NOT NULL,
+  PRIMARY KEY ("from")
+);
+
+CREATE INDEX public_completions_created ON public_completions("created");
@@ -0,0 +1 @@
+DROP INDEX pgroup_msgs_stats;
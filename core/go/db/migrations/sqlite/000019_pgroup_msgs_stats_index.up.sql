@@ -0,0 +1,2 @@
+-- Covering index for per-group aggregate queries (count, latest sequence, latest sent)
+CREATE INDEX pgroup_msgs_stats ON pgroup_msgs("domain","group","local_seq","sent");
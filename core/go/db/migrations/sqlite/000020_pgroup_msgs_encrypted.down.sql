@@ -0,0 +1 @@
+ALTER TABLE pgroup_msgs DROP COLUMN "encrypted";
@@ -0,0 +1,2 @@
+-- Marks message data that has been encrypted at rest with a domain-scoped key
+ALTER TABLE pgroup_msgs ADD "encrypted" BOOLEAN NOT NULL DEFAULT FALSE;
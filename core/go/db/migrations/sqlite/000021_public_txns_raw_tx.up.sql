@@ -0,0 +1,2 @@
+-- Transactions submitted already signed by the caller, re-sent as-is rather than signed by Paladin
+ALTER TABLE public_txns ADD "raw_tx" VARCHAR;
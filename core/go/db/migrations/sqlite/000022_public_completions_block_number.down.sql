@@ -0,0 +1 @@
+ALTER TABLE public_completions DROP COLUMN "block_number";
@@ -0,0 +1,2 @@
+DROP INDEX public_txns_group_id;
+ALTER TABLE public_txns DROP COLUMN "group_id";
@@ -0,0 +1,2 @@
+DROP INDEX public_txns_label;
+ALTER TABLE public_txns DROP COLUMN "label";
@@ -0,0 +1,3 @@
+-- Free-text label set by the submitter, for finding related transactions during triage
+ALTER TABLE public_txns ADD "label" VARCHAR;
+CREATE INDEX public_txns_label ON public_txns("label");
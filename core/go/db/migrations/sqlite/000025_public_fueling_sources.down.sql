@@ -0,0 +1 @@
+DROP TABLE public_fueling_sources;
@@ -0,0 +1,8 @@
+-- Cumulative count of auto-fueling transactions submitted from each funding source, for auditing
+-- how fueling has been distributed across weighted sources
+CREATE TABLE public_fueling_sources (
+  "source"                    VARCHAR         NOT NULL,
+  "fueling_count"             BIGINT          NOT NULL,
+  "updated"                   BIGINT          NOT NULL,
+  PRIMARY KEY ("source")
+);
@@ -0,0 +1,2 @@
+DROP INDEX public_txns_updated;
+ALTER TABLE public_txns DROP COLUMN "updated";
@@ -0,0 +1,4 @@
+-- Time of the last change to the transaction, its submissions or its completion, for finding recent activity during triage
+ALTER TABLE public_txns ADD "updated" BIGINT;
+UPDATE public_txns SET "updated" = "created";
+CREATE INDEX public_txns_updated ON public_txns("updated");
@@ -0,0 +1 @@
+DROP TABLE public_gas_price_history;
@@ -0,0 +1,2 @@
+-- Category of the most recent failure of the transaction, derived from the error returned by the node
+ALTER TABLE public_txns ADD "failure_category" VARCHAR;
@@ -0,0 +1 @@
+DROP TABLE pgroup_msgs_quarantine;
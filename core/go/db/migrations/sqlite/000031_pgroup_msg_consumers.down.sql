@@ -0,0 +1 @@
+DROP TABLE pgroup_msg_consumers;
@@ -0,0 +1,7 @@
+-- The position of each pull consumer of privacy group messages, up to which it has acknowledged processing
+CREATE TABLE pgroup_msg_consumers (
+  "consumer"                  TEXT            NOT NULL,
+  "sequence"                  BIGINT          NOT NULL,
+  "updated"                   BIGINT          NOT NULL,
+  PRIMARY KEY ("consumer")
+);
@@ -0,0 +1,13 @@
+-- The reliable message used to distribute each locally sent privacy group message to each remote node
+CREATE TABLE pgroup_msg_distributions (
+  "msg"                       UUID            NOT NULL,
+  "node"                      TEXT            NOT NULL,
+  "reliable_msg"              UUID            NOT NULL,
+  PRIMARY KEY ("msg", "node"),
+  FOREIGN KEY ("msg") REFERENCES pgroup_msgs ("id") ON DELETE CASCADE
+);
+
+INSERT OR IGNORE INTO pgroup_msg_distributions ("msg", "node", "reliable_msg")
+  SELECT m."id", r."node", r."id" FROM reliable_msgs r
+  JOIN pgroup_msgs m ON m."id" = json_extract(r."metadata", '$.id')
+  WHERE r."msg_type" = 'privacy_group_message';
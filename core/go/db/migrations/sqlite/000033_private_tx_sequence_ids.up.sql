@@ -0,0 +1,10 @@
+-- The position of each private transaction in the dispatch order of the sequencer for its contract,
+-- so that the order is preserved when the sequencer is reloaded
+CREATE TABLE private_tx_sequence_ids (
+    "transaction"       UUID    NOT NULL,
+    "contract_address"  TEXT    NOT NULL,
+    "sequence_id"       BIGINT  NOT NULL,
+    PRIMARY KEY ("transaction")
+);
+
+CREATE UNIQUE INDEX private_tx_sequence_ids_contract_sequence ON private_tx_sequence_ids("contract_address","sequence_id");
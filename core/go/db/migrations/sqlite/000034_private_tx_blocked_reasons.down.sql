@@ -0,0 +1 @@
+DROP TABLE private_tx_blocked_reasons;
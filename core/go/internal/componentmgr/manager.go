@@ -27,6 +27,7 @@ import (
 	"github.com/kaleido-io/paladin/core/internal/groupmgr"
 	"github.com/kaleido-io/paladin/core/internal/identityresolver"
 	"github.com/kaleido-io/paladin/core/internal/keymanager"
+	"github.com/kaleido-io/paladin/core/internal/metrics"
 	"github.com/kaleido-io/paladin/core/internal/msgs"
 	"github.com/kaleido-io/paladin/core/internal/plugins"
 	"github.com/kaleido-io/paladin/core/internal/privatetxnmgr"
@@ -69,6 +70,7 @@ type componentManager struct {
 	persistence      persistence.Persistence
 	blockIndexer     blockindexer.BlockIndexer
 	rpcServer        rpcserver.RPCServer
+	metricsManager   metrics.Metrics
 
 	// managers
 	stateManager     components.StateManager
@@ -115,6 +117,7 @@ func NewComponentManager(bgCtx context.Context, grpcTarget string, instanceUUID
 		bgCtx:                 bgCtx,
 		conf:                  conf,
 		additionalManagers:    additionalManagers,
+		metricsManager:        metrics.NewMetricsManager(),
 		initResults:           make(map[string]*components.ManagerInitResult),
 		started:               make(map[string]stoppable),
 		opened:                make(map[string]closeable),
@@ -132,6 +135,7 @@ func (cm *componentManager) startDebugServer() (httpserver.Server, error) {
 	server, err := httpserver.NewDebugServer(cm.bgCtx, &cm.conf.DebugServer.HTTPServerConfig)
 	if err == nil {
 		server.Router().PathPrefix("/debug/javadump").HandlerFunc(http.HandlerFunc(cm.javaDump))
+		server.Router().Path("/metrics").Handler(cm.metricsManager.HTTPHandler())
 		err = server.Start()
 	}
 	return server, err
@@ -510,6 +514,10 @@ func (cm *componentManager) RPCServer() rpcserver.RPCServer {
 	return cm.rpcServer
 }
 
+func (cm *componentManager) MetricsManager() metrics.Metrics {
+	return cm.metricsManager
+}
+
 func (cm *componentManager) BlockIndexer() blockindexer.BlockIndexer {
 	return cm.blockIndexer
 }
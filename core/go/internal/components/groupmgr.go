@@ -61,6 +61,12 @@ type GroupManager interface {
 	ReceiveMessages(ctx context.Context, dbTX persistence.DBTX, msgs []*pldapi.PrivacyGroupMessage) (results map[uuid.UUID]error, err error)
 	QueryMessages(ctx context.Context, dbTX persistence.DBTX, jq *query.QueryJSON) ([]*pldapi.PrivacyGroupMessage, error)
 	GetMessageByID(ctx context.Context, dbTX persistence.DBTX, id uuid.UUID, failNotFound bool) (*pldapi.PrivacyGroupMessage, error)
+	SearchMessages(ctx context.Context, dbTX persistence.DBTX, search *pldapi.PrivacyGroupMessageSearch) ([]*pldapi.PrivacyGroupMessage, error)
+	GetGroupMessageStats(ctx context.Context, dbTX persistence.DBTX, domainName string, groupID tktypes.HexBytes) (*pldapi.PrivacyGroupMessageStats, error)
+	GetMessageDistributionStatus(ctx context.Context, dbTX persistence.DBTX, msgID uuid.UUID) ([]*pldapi.ReliableMessageRetryStatus, error)
+	RetryMessageDistribution(ctx context.Context, dbTX persistence.DBTX, msgID uuid.UUID) error
+	ConsumeMessages(ctx context.Context, dbTX persistence.DBTX, consumerID string, limit int) ([]*pldapi.PrivacyGroupMessage, error)
+	AckConsumed(ctx context.Context, dbTX persistence.DBTX, consumerID string, upToLocalSeq uint64) error
 
 	CreateMessageListener(ctx context.Context, spec *pldapi.PrivacyGroupMessageListener) error
 	AddMessageReceiver(ctx context.Context, name string, r PrivacyGroupMessageReceiver) (PrivacyGroupMessageReceiverCloser, error)
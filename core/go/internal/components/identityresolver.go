@@ -25,4 +25,5 @@ type IdentityResolver interface {
 	TransportClient
 	ResolveVerifier(ctx context.Context, lookup string, algorithm string, verifierType string) (string, error)
 	ResolveVerifierAsync(ctx context.Context, lookup string, algorithm string, verifierType string, resolved func(ctx context.Context, verifier string), failed func(ctx context.Context, err error))
+	InvalidateNode(node string)
 }
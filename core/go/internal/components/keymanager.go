@@ -56,5 +56,11 @@ type KeyManager interface {
 
 	ReverseKeyLookup(ctx context.Context, dbTX persistence.DBTX, algorithm, verifierType, verifier string) (mapping *pldapi.KeyMappingAndVerifier, err error)
 
+	// Resolves a key only if it already has a mapping and verifier, so nothing is created (and it is safe with NOTX())
+	ResolveExistingKey(ctx context.Context, dbTX persistence.DBTX, identifier, algorithm, verifierType string) (mapping *pldapi.KeyMappingAndVerifier, err error)
+
 	Sign(ctx context.Context, mapping *pldapi.KeyMappingAndVerifier, payloadType string, payload []byte) ([]byte, error)
+
+	// Returns an error if the signing module of the wallet holding the key reports it is currently unable to sign
+	SignerHealthCheck(ctx context.Context, mapping *pldapi.KeyMappingAndVerifier) error
 }
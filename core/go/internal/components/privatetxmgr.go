@@ -36,20 +36,56 @@ type TransactionDispatchedEvent struct {
 	SigningAddress  string `json:"signingAddress"`
 }
 
+// Emitted when a transaction delegated to another node is reclaimed, because the delegate never accepted it
+type TransactionDelegationReclaimedEvent struct {
+	TransactionID   string `json:"transactionId"`
+	ContractAddress string `json:"contractAddress"`
+	DelegateNode    string `json:"delegateNode"`
+}
+
 type PrivateTxEndorsementStatus struct {
 	Party               string `json:"party"`
 	RequestTime         string `json:"requestTime,omitempty"`
 	EndorsementReceived bool   `json:"endorsementReceived"`
 }
 
+// Summary of how far through gathering the endorsements in its attestation plan a transaction is
+type PrivateTxEndorsementProgress struct {
+	Gathered    int      `json:"gathered"`
+	Required    int      `json:"required"`
+	Outstanding []string `json:"outstanding"` // parties we are still waiting on
+}
+
 type PrivateTxStatus struct {
-	TxID           string                       `json:"transactionId"`
-	Status         string                       `json:"status"`
-	LatestEvent    string                       `json:"latestEvent"`
-	LatestError    string                       `json:"latestError"`
-	Endorsements   []PrivateTxEndorsementStatus `json:"endorsements"`
-	Transaction    *PrivateTransaction          `json:"transaction,omitempty"`
-	FailureMessage string                       `json:"failureMessage,omitempty"`
+	TxID                string                        `json:"transactionId"`
+	Status              string                        `json:"status"`
+	LatestEvent         string                        `json:"latestEvent"`
+	LatestError         string                        `json:"latestError"`
+	Endorsements        []PrivateTxEndorsementStatus  `json:"endorsements"`
+	EndorsementProgress *PrivateTxEndorsementProgress `json:"endorsementProgress,omitempty"`
+	BlockedReason       string                        `json:"blockedReason,omitempty"` // what the transaction is waiting on, until it is dispatched
+	Transaction         *PrivateTransaction           `json:"transaction,omitempty"`
+	FailureMessage      string                        `json:"failureMessage,omitempty"`
+}
+
+// The in-memory view a sequencer has of the dependencies between the private transactions it is coordinating,
+// where a transaction depends on another if it spends states the other mints. For debugging stalled transactions.
+type PrivateTxDependencyGraph struct {
+	Transactions []*PrivateTxGraphNode `json:"transactions"`
+	Dependencies []*PrivateTxGraphEdge `json:"dependencies"`
+}
+
+type PrivateTxGraphNode struct {
+	TxID        string `json:"transactionId"`
+	Status      string `json:"status"`
+	LatestEvent string `json:"latestEvent"`
+	Endorsed    bool   `json:"endorsed"`
+}
+
+type PrivateTxGraphEdge struct {
+	Dependency string   `json:"dependency"` // the transaction minting the states
+	Dependant  string   `json:"dependant"`  // the transaction spending the states
+	States     []string `json:"states"`
 }
 
 type StateDistributionSet struct {
@@ -83,6 +119,8 @@ type PrivateTxManager interface {
 	//Synchronous functions to submit a new private transaction
 	HandleNewTx(ctx context.Context, dbTX persistence.DBTX, tx *ValidatedTransaction) error
 	GetTxStatus(ctx context.Context, domainAddress string, txID uuid.UUID) (status PrivateTxStatus, err error)
+	GetTxDependencyGraph(ctx context.Context, domainAddress string) (*PrivateTxDependencyGraph, error)
+	GetTxDependencyGraphDOT(ctx context.Context, domainAddress string) (string, error)
 
 	// Synchronous function to call an existing deployed smart contract
 	CallPrivateSmartContract(ctx context.Context, call *ResolvedTransaction) (*abi.ComponentValue, error)
@@ -94,6 +132,7 @@ type PrivateTxManager interface {
 	NotifyFailedPublicTx(ctx context.Context, dbTX persistence.DBTX, confirms []*PublicTxMatch) error
 
 	PrivateTransactionConfirmed(ctx context.Context, receipt *TxCompletion)
+	PrivateTransactionsConfirmed(ctx context.Context, receipts []*TxCompletion)
 
 	BuildStateDistributions(ctx context.Context, tx *PrivateTransaction) (*StateDistributionSet, error)
 	BuildNullifier(ctx context.Context, kr KeyResolver, s *StateDistributionWithData) (*NullifierUpsert, error)
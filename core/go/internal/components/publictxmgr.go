@@ -17,8 +17,10 @@ package components
 
 import (
 	"context"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/hyperledger/firefly-signer/pkg/ethsigner"
 	"github.com/kaleido-io/paladin/core/internal/filters"
 	"github.com/kaleido-io/paladin/core/pkg/blockindexer"
 	"github.com/kaleido-io/paladin/core/pkg/persistence"
@@ -31,16 +33,45 @@ var PublicTxFilterFields filters.FieldSet = filters.FieldMap{
 	"localId":         filters.Int64Field(`"public_txns"."pub_txn_id"`),
 	"from":            filters.HexBytesField(`"from"`),
 	"nonce":           filters.Int64Field("nonce"),
-	"created":         filters.Int64Field("created"),
+	"created":         filters.Int64Field(`"public_txns"."created"`),
+	"updated":         filters.TimestampField(`"public_txns"."updated"`),
 	"completedAt":     filters.Int64Field(`"Completed"."created"`),
 	"transactionHash": filters.Int64Field(`"Completed"."tx_hash"`),
 	"success":         filters.BooleanField(`"Completed"."success"`),
 	"revertData":      filters.HexBytesField(`"Completed"."revert_data"`),
+	"label":           filters.StringField(`"public_txns"."label"`),
+	"failureCategory": filters.StringField(`"public_txns"."failure_category"`),
 }
 
 type PublicTxSubmission struct {
 	Bindings             []*PaladinTXReference
-	pldapi.PublicTxInput // the request to create the transaction
+	DryRun               bool // run the pre-submit checks and return the transaction that would be submitted, without writing or broadcasting it
+	pldapi.PublicTxInput      // the request to create the transaction
+}
+
+// A transaction signed outside of Paladin, by a key Paladin does not have access to.
+// The submitter owns the nonce, which must not clash with those Paladin assigns for the same address.
+type PublicRawTxSubmission struct {
+	Bindings       []*PaladinTXReference
+	From           tktypes.EthAddress
+	Nonce          uint64
+	RawTransaction tktypes.HexBytes
+}
+
+type PublicTxGroupStatus string
+
+const (
+	PublicTxGroupStatusPending   PublicTxGroupStatus = "pending"
+	PublicTxGroupStatusSucceeded PublicTxGroupStatus = "succeeded"
+	PublicTxGroupStatusFailed    PublicTxGroupStatus = "failed"
+)
+
+// A set of transactions from one signing address that are assigned contiguous nonces, and submitted in order.
+// If one fails, the transactions after it in the group are suspended and the group is failed.
+type PublicTxGroup struct {
+	ID           uuid.UUID
+	Status       PublicTxGroupStatus
+	Transactions []*pldapi.PublicTx
 }
 
 type PaladinTXReference struct {
@@ -53,6 +84,11 @@ type PublicTxMatch struct {
 	*blockindexer.IndexedTransactionNotify
 }
 
+// Invoked with each transaction immediately before it is signed, and may adjust the gas limit
+// of the transaction in place. Signing fails if the hook returns an error, or changes any other field.
+// The adjusted gas limit is persisted with the transaction, so it is used for any resubmission.
+type PublicTxPreSignHook func(ctx context.Context, from tktypes.EthAddress, ethTx *ethsigner.Transaction) error
+
 type PublicTxManager interface {
 	ManagerLifecycle
 
@@ -60,14 +96,31 @@ type PublicTxManager interface {
 	QueryPublicTxForTransactions(ctx context.Context, dbTX persistence.DBTX, boundToTxns []uuid.UUID, jq *query.QueryJSON) (map[uuid.UUID][]*pldapi.PublicTx, error)
 	QueryPublicTxWithBindings(ctx context.Context, dbTX persistence.DBTX, jq *query.QueryJSON) ([]*pldapi.PublicTxWithBinding, error)
 	GetPublicTransactionForHash(ctx context.Context, dbTX persistence.DBTX, hash tktypes.Bytes32) (*pldapi.PublicTxWithBinding, error)
+	// Min/max/avg of the recorded gas price samples in a time range, aggregated into buckets of the given size
+	QueryGasPriceHistory(ctx context.Context, dbTX persistence.DBTX, start, end tktypes.Timestamp, bucket time.Duration) ([]*pldapi.GasPriceHistoryEntry, error)
+	// The nonce that will be assigned to the next transaction submitted for the signing address
+	GetNextNonce(ctx context.Context, from tktypes.EthAddress) (uint64, error)
+	// Diagnostics of the orchestrators currently in flight, including the nonces each has in flight
+	GetEngineStatus(ctx context.Context) *pldapi.PublicTxEngineStatus
 
 	// Perform (potentially expensive) transaction level validation, such as gas estimation. Call before starting a DB transaction
 	ValidateTransaction(ctx context.Context, dbTX persistence.DBTX, transaction *PublicTxSubmission) error
 	// Write a set of validated transactions to the public TX mgr database, notifying the relevant orchestrator(s) to wake, assign nonces, and start the submission process
 	WriteNewTransactions(ctx context.Context, dbTX persistence.DBTX, transactions []*PublicTxSubmission) ([]*pldapi.PublicTx, error)
-	// Convenience function that does ValidateTransaction+WriteNewTransactions for a single Tx
+	// Convenience function that does ValidateTransaction+WriteNewTransactions for a single Tx.
+	// For a DryRun submission the returned transaction has the estimated gas, and the nonce and hash it would have if submitted now, but is not written
 	SingleTransactionSubmit(ctx context.Context, transaction *PublicTxSubmission) (*pldapi.PublicTx, error)
+	// Write a set of validated transactions for the same signing address as a group, which are assigned contiguous nonces in the order supplied
+	WriteNewTransactionGroup(ctx context.Context, dbTX persistence.DBTX, transactions []*PublicTxSubmission) (*PublicTxGroup, error)
+	// Get the transactions of a group, and the status of the group as a whole
+	GetTransactionGroup(ctx context.Context, dbTX persistence.DBTX, groupID uuid.UUID) (*PublicTxGroup, error)
+	// Decode and check a pre-signed transaction, then write it to the DB to be submitted and tracked as-is. Paladin cannot re-sign it to increase the gas price
+	WriteNewRawTransaction(ctx context.Context, dbTX persistence.DBTX, transaction *PublicRawTxSubmission) (*pldapi.PublicTx, error)
 
 	MatchUpdateConfirmedTransactions(ctx context.Context, dbTX persistence.DBTX, itxs []*blockindexer.IndexedTransactionNotify) ([]*PublicTxMatch, error)
 	NotifyConfirmPersisted(ctx context.Context, confirms []*PublicTxMatch)
+
+	// Installs the hook called before each transaction is signed, replacing any existing hook (nil removes it).
+	// Must be called before the manager is started
+	SetPreSignHook(hook PublicTxPreSignHook)
 }
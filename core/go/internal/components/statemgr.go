@@ -109,6 +109,14 @@ type DomainContext interface {
 	// The dbTX is passed in to allow re-use of a connection during read operations.
 	FindAvailableStates(dbTX persistence.DBTX, schemaID tktypes.Bytes32, query *query.QueryJSON) (Schema, []*pldapi.State, error)
 
+	// FindAvailableStatesPage is a paginated form of FindAvailableStates, allowing a domain to page through
+	// a large set of available states (such as for coin selection) without loading them all.
+	//
+	// The query must specify a limit, and must not specify a sort - states are returned in creation order.
+	// Pass an empty cursor for the first page, then the returned next cursor for each subsequent page.
+	// An empty next cursor means there are no more states.
+	FindAvailableStatesPage(dbTX persistence.DBTX, schemaID tktypes.Bytes32, query *query.QueryJSON, cursor string) (_ Schema, _ []*pldapi.State, next string, _ error)
+
 	// GetStatesByID retrieves a set of states by ID - regardless of whether they are:
 	// - Written to the DB or not (or just pending in the domain context)
 	// - Confirmed or not
@@ -17,6 +17,7 @@ package components
 
 import (
 	"context"
+	"time"
 
 	"github.com/google/uuid"
 
@@ -100,6 +101,13 @@ type TransportManager interface {
 	// at-most-once delivery semantics
 	Send(ctx context.Context, send *FireAndForgetMessageSend) error
 
+	// Send a message, then block until the remote node sends back a reply with a CorrelationID matching
+	// the MessageID of the request (which is allocated if not supplied).
+	// The reply is returned directly to the caller, and is not delivered to the component the reply was addressed to.
+	//
+	// Same at-most-once semantics as Send, in both directions, so the caller must handle the timeout error.
+	SendAndWait(ctx context.Context, send *FireAndForgetMessageSend, timeout time.Duration) (*ReceivedMessage, error)
+
 	// Sends a message with at-least-once delivery semantics
 	//
 	// Each reliable message type has special building code in the transport manager, which assembles the full
@@ -112,4 +120,8 @@ type TransportManager interface {
 
 	QueryReliableMessages(ctx context.Context, dbTX persistence.DBTX, jq *query.QueryJSON) ([]*pldapi.ReliableMessage, error)
 	QueryReliableMessageAcks(ctx context.Context, dbTX persistence.DBTX, jq *query.QueryJSON) ([]*pldapi.ReliableMessageAck, error)
+
+	// Retry state for an outstanding reliable message, and the ability to bypass the backoff (e.g. after a peer outage)
+	GetReliableMessageRetryStatus(ctx context.Context, dbTX persistence.DBTX, id uuid.UUID) (*pldapi.ReliableMessageRetryStatus, error)
+	RetryReliableMessageNow(ctx context.Context, dbTX persistence.DBTX, id uuid.UUID) error
 }
@@ -28,6 +28,7 @@ import (
 	"github.com/hyperledger/firefly-signer/pkg/ethsigner"
 	"github.com/hyperledger/firefly-signer/pkg/ethtypes"
 	"github.com/hyperledger/firefly-signer/pkg/secp256k1"
+	"github.com/kaleido-io/paladin/config/pkg/confutil"
 	"github.com/kaleido-io/paladin/config/pkg/pldconf"
 	"github.com/kaleido-io/paladin/core/internal/components"
 	"github.com/kaleido-io/paladin/core/internal/msgs"
@@ -88,7 +89,12 @@ func (dm *domainManager) newDomain(name string, conf *pldconf.DomainConfig, toDo
 		defaultGasLimit: pldconf.DefaultDefaultGasLimit,             // can be set by config below
 		initRetry:       retry.NewRetryIndefinite(&conf.Init.Retry), // indefinite retry
 		name:            name,
-		api:             toDomain,
+		api: &domainAPIObserver{
+			DomainManagerToDomain: toDomain,
+			domain:                name,
+			metrics:               dm.metrics,
+			traceCalls:            confutil.Bool(dm.conf.DomainManager.TraceCalls, false),
+		},
 		initDone:        make(chan struct{}),
 		registryAddress: tktypes.MustEthAddress(conf.RegistryAddress), // check earlier in startup
 
@@ -325,9 +331,18 @@ func (d *domain) FindAvailableStates(ctx context.Context, req *prototk.FindAvail
 	}
 
 	var states []*pldapi.State
-	if req.UseNullifiers != nil && *req.UseNullifiers {
+	var nextPageCursor *string
+	useNullifiers := req.UseNullifiers != nil && *req.UseNullifiers
+	switch {
+	case req.PageCursor != nil && useNullifiers:
+		return nil, i18n.NewError(ctx, msgs.MsgDomainPagedNullifierQuery)
+	case req.PageCursor != nil:
+		var next string
+		_, states, next, err = c.dCtx.FindAvailableStatesPage(c.dbTX, schemaID, &query, *req.PageCursor)
+		nextPageCursor = &next
+	case useNullifiers:
 		_, states, err = c.dCtx.FindAvailableNullifiers(c.dbTX, schemaID, &query)
-	} else {
+	default:
 		_, states, err = c.dCtx.FindAvailableStates(c.dbTX, schemaID, &query)
 	}
 	if err != nil {
@@ -335,7 +350,8 @@ func (d *domain) FindAvailableStates(ctx context.Context, req *prototk.FindAvail
 	}
 
 	return &prototk.FindAvailableStatesResponse{
-		States: toProtoStates(states),
+		States:         toProtoStates(states),
+		NextPageCursor: nextPageCursor,
 	}, nil
 
 }
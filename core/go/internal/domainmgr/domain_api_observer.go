@@ -0,0 +1,129 @@
+/*
+ * Copyright © 2024 Kaleido, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with
+ * the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package domainmgr
+
+import (
+	"context"
+	"time"
+
+	"github.com/kaleido-io/paladin/core/internal/components"
+	"github.com/kaleido-io/paladin/toolkit/pkg/log"
+	"github.com/kaleido-io/paladin/toolkit/pkg/prototk"
+)
+
+// Wraps the API of a domain, so every call into the domain is measured (and optionally traced in the log)
+// without each call site needing to be aware of it.
+type domainAPIObserver struct {
+	components.DomainManagerToDomain
+	domain     string
+	metrics    *domainManagerMetrics
+	traceCalls bool
+}
+
+func observeDomainCall[Req, Res any](ctx context.Context, o *domainAPIObserver, function string, req Req, call func(context.Context, Req) (Res, error)) (Res, error) {
+	if o.traceCalls {
+		ctx = log.WithLogField(ctx, "domainCall", o.domain+"."+function)
+		log.L(ctx).Infof("Domain call started")
+	}
+	start := time.Now()
+	res, err := call(ctx, req)
+	duration := time.Since(start)
+	o.metrics.recordDomainCall(o.domain, function, duration, err)
+	if o.traceCalls {
+		if err != nil {
+			log.L(ctx).Infof("Domain call failed after %s: %s", duration, err)
+		} else {
+			log.L(ctx).Infof("Domain call completed in %s", duration)
+		}
+	}
+	return res, err
+}
+
+func (o *domainAPIObserver) ConfigureDomain(ctx context.Context, req *prototk.ConfigureDomainRequest) (*prototk.ConfigureDomainResponse, error) {
+	return observeDomainCall(ctx, o, "ConfigureDomain", req, o.DomainManagerToDomain.ConfigureDomain)
+}
+
+func (o *domainAPIObserver) InitDomain(ctx context.Context, req *prototk.InitDomainRequest) (*prototk.InitDomainResponse, error) {
+	return observeDomainCall(ctx, o, "InitDomain", req, o.DomainManagerToDomain.InitDomain)
+}
+
+func (o *domainAPIObserver) InitDeploy(ctx context.Context, req *prototk.InitDeployRequest) (*prototk.InitDeployResponse, error) {
+	return observeDomainCall(ctx, o, "InitDeploy", req, o.DomainManagerToDomain.InitDeploy)
+}
+
+func (o *domainAPIObserver) PrepareDeploy(ctx context.Context, req *prototk.PrepareDeployRequest) (*prototk.PrepareDeployResponse, error) {
+	return observeDomainCall(ctx, o, "PrepareDeploy", req, o.DomainManagerToDomain.PrepareDeploy)
+}
+
+func (o *domainAPIObserver) InitContract(ctx context.Context, req *prototk.InitContractRequest) (*prototk.InitContractResponse, error) {
+	return observeDomainCall(ctx, o, "InitContract", req, o.DomainManagerToDomain.InitContract)
+}
+
+func (o *domainAPIObserver) InitTransaction(ctx context.Context, req *prototk.InitTransactionRequest) (*prototk.InitTransactionResponse, error) {
+	return observeDomainCall(ctx, o, "InitTransaction", req, o.DomainManagerToDomain.InitTransaction)
+}
+
+func (o *domainAPIObserver) AssembleTransaction(ctx context.Context, req *prototk.AssembleTransactionRequest) (*prototk.AssembleTransactionResponse, error) {
+	return observeDomainCall(ctx, o, "AssembleTransaction", req, o.DomainManagerToDomain.AssembleTransaction)
+}
+
+func (o *domainAPIObserver) EndorseTransaction(ctx context.Context, req *prototk.EndorseTransactionRequest) (*prototk.EndorseTransactionResponse, error) {
+	return observeDomainCall(ctx, o, "EndorseTransaction", req, o.DomainManagerToDomain.EndorseTransaction)
+}
+
+func (o *domainAPIObserver) PrepareTransaction(ctx context.Context, req *prototk.PrepareTransactionRequest) (*prototk.PrepareTransactionResponse, error) {
+	return observeDomainCall(ctx, o, "PrepareTransaction", req, o.DomainManagerToDomain.PrepareTransaction)
+}
+
+func (o *domainAPIObserver) HandleEventBatch(ctx context.Context, req *prototk.HandleEventBatchRequest) (*prototk.HandleEventBatchResponse, error) {
+	return observeDomainCall(ctx, o, "HandleEventBatch", req, o.DomainManagerToDomain.HandleEventBatch)
+}
+
+func (o *domainAPIObserver) Sign(ctx context.Context, req *prototk.SignRequest) (*prototk.SignResponse, error) {
+	return observeDomainCall(ctx, o, "Sign", req, o.DomainManagerToDomain.Sign)
+}
+
+func (o *domainAPIObserver) GetVerifier(ctx context.Context, req *prototk.GetVerifierRequest) (*prototk.GetVerifierResponse, error) {
+	return observeDomainCall(ctx, o, "GetVerifier", req, o.DomainManagerToDomain.GetVerifier)
+}
+
+func (o *domainAPIObserver) ValidateStateHashes(ctx context.Context, req *prototk.ValidateStateHashesRequest) (*prototk.ValidateStateHashesResponse, error) {
+	return observeDomainCall(ctx, o, "ValidateStateHashes", req, o.DomainManagerToDomain.ValidateStateHashes)
+}
+
+func (o *domainAPIObserver) InitCall(ctx context.Context, req *prototk.InitCallRequest) (*prototk.InitCallResponse, error) {
+	return observeDomainCall(ctx, o, "InitCall", req, o.DomainManagerToDomain.InitCall)
+}
+
+func (o *domainAPIObserver) ExecCall(ctx context.Context, req *prototk.ExecCallRequest) (*prototk.ExecCallResponse, error) {
+	return observeDomainCall(ctx, o, "ExecCall", req, o.DomainManagerToDomain.ExecCall)
+}
+
+func (o *domainAPIObserver) BuildReceipt(ctx context.Context, req *prototk.BuildReceiptRequest) (*prototk.BuildReceiptResponse, error) {
+	return observeDomainCall(ctx, o, "BuildReceipt", req, o.DomainManagerToDomain.BuildReceipt)
+}
+
+func (o *domainAPIObserver) ConfigurePrivacyGroup(ctx context.Context, req *prototk.ConfigurePrivacyGroupRequest) (*prototk.ConfigurePrivacyGroupResponse, error) {
+	return observeDomainCall(ctx, o, "ConfigurePrivacyGroup", req, o.DomainManagerToDomain.ConfigurePrivacyGroup)
+}
+
+func (o *domainAPIObserver) InitPrivacyGroup(ctx context.Context, req *prototk.InitPrivacyGroupRequest) (*prototk.InitPrivacyGroupResponse, error) {
+	return observeDomainCall(ctx, o, "InitPrivacyGroup", req, o.DomainManagerToDomain.InitPrivacyGroup)
+}
+
+func (o *domainAPIObserver) WrapPrivacyGroupEVMTX(ctx context.Context, req *prototk.WrapPrivacyGroupEVMTXRequest) (*prototk.WrapPrivacyGroupEVMTXResponse, error) {
+	return observeDomainCall(ctx, o, "WrapPrivacyGroupEVMTX", req, o.DomainManagerToDomain.WrapPrivacyGroupEVMTX)
+}
@@ -591,6 +591,60 @@ func TestDomainFindAvailableStatesOK(t *testing.T) {
 	assert.Len(t, states.States, 0)
 }
 
+func TestDomainFindAvailableStatesPaged(t *testing.T) {
+	td, done := newTestDomain(t, true /* use real state store for this one */, goodDomainConf())
+	defer done()
+	assert.Nil(t, td.d.initError.Load())
+
+	txID := uuid.New()
+	for i := 0; i < 3; i++ {
+		_ = storeTestState(t, td, txID, ethtypes.NewHexIntegerU64(100))
+	}
+
+	pageCursor := ""
+	states, err := td.d.FindAvailableStates(td.ctx, &prototk.FindAvailableStatesRequest{
+		StateQueryContext: td.c.id,
+		SchemaId:          td.tp.stateSchemas[0].Id,
+		QueryJson:         `{"limit": 2}`,
+		PageCursor:        &pageCursor,
+	})
+	require.NoError(t, err)
+	assert.Len(t, states.States, 2)
+	require.NotNil(t, states.NextPageCursor)
+	assert.NotEmpty(t, *states.NextPageCursor)
+
+	states, err = td.d.FindAvailableStates(td.ctx, &prototk.FindAvailableStatesRequest{
+		StateQueryContext: td.c.id,
+		SchemaId:          td.tp.stateSchemas[0].Id,
+		QueryJson:         `{"limit": 2}`,
+		PageCursor:        states.NextPageCursor,
+	})
+	require.NoError(t, err)
+	assert.Len(t, states.States, 1)
+	require.NotNil(t, states.NextPageCursor)
+	assert.Empty(t, *states.NextPageCursor)
+
+	// Nullifiers are not supported with pagination
+	useNullifiers := true
+	_, err = td.d.FindAvailableStates(td.ctx, &prototk.FindAvailableStatesRequest{
+		StateQueryContext: td.c.id,
+		SchemaId:          td.tp.stateSchemas[0].Id,
+		QueryJson:         `{"limit": 2}`,
+		PageCursor:        &pageCursor,
+		UseNullifiers:     &useNullifiers,
+	})
+	assert.Regexp(t, "PD011667", err)
+
+	// The query must have a limit
+	_, err = td.d.FindAvailableStates(td.ctx, &prototk.FindAvailableStatesRequest{
+		StateQueryContext: td.c.id,
+		SchemaId:          td.tp.stateSchemas[0].Id,
+		QueryJson:         `{}`,
+		PageCursor:        &pageCursor,
+	})
+	assert.Regexp(t, "PD010134", err)
+}
+
 func TestDomainInitDeployOK(t *testing.T) {
 	td, done := newTestDomain(t, false, goodDomainConf(), mockSchemas())
 	defer done()
@@ -123,10 +123,13 @@ func (dm *domainManager) registrationIndexer(ctx context.Context, dbTX persisten
 }
 
 func (dm *domainManager) notifyTransactions(txCompletions txCompletionsOrdered) {
-	for _, completion := range txCompletions {
-		// Private transaction manager needs to know about these to update its in-memory state
-		dm.privateTxManager.PrivateTransactionConfirmed(dm.bgCtx, completion)
+	// Private transaction manager needs to know about these to update its in-memory state,
+	// and processes all the confirmations from the batch together
+	if len(txCompletions) > 0 {
+		dm.privateTxManager.PrivateTransactionsConfirmed(dm.bgCtx, txCompletions)
+	}
 
+	for _, completion := range txCompletions {
 		// We also provide a direct waiter that's used by the testbed
 		inflight := dm.privateTxWaiter.GetInflight(completion.TransactionID)
 		log.L(dm.bgCtx).Infof("Notifying for private deployment TransactionID %s (waiter=%t)", completion.TransactionID, inflight != nil)
@@ -64,6 +64,7 @@ func NewDomainManager(bgCtx context.Context, conf *pldconf.DomainManagerConfig)
 		domainsByAddress: make(map[tktypes.EthAddress]*domain),
 		privateTxWaiter:  inflight.NewInflightManager[uuid.UUID, *components.ReceiptInput](uuid.Parse),
 		contractCache:    cache.NewCache[tktypes.EthAddress, *domainContract](&conf.DomainManager.ContractCache, pldconf.ContractCacheDefaults),
+		metrics:          newDomainManagerMetrics(),
 	}
 }
 
@@ -87,6 +88,7 @@ type domainManager struct {
 
 	privateTxWaiter *inflight.InflightManager[uuid.UUID, *components.ReceiptInput]
 	contractCache   cache.Cache[tktypes.EthAddress, *domainContract]
+	metrics         *domainManagerMetrics
 }
 
 type event_PaladinRegisterSmartContract_V0 struct {
@@ -97,6 +99,7 @@ type event_PaladinRegisterSmartContract_V0 struct {
 }
 
 func (dm *domainManager) PreInit(pic components.PreInitComponents) (*components.ManagerInitResult, error) {
+	dm.metrics.register(pic.MetricsManager().Registry())
 	return &components.ManagerInitResult{}, nil
 }
 
@@ -25,6 +25,7 @@ import (
 	"github.com/hyperledger/firefly-signer/pkg/abi"
 	"github.com/kaleido-io/paladin/config/pkg/pldconf"
 	"github.com/kaleido-io/paladin/core/internal/components"
+	"github.com/kaleido-io/paladin/core/internal/metrics"
 	"github.com/kaleido-io/paladin/core/internal/statemgr"
 	"github.com/kaleido-io/paladin/core/mocks/componentmocks"
 	"github.com/kaleido-io/paladin/core/mocks/ethclientmocks"
@@ -49,6 +50,7 @@ type mockComponents struct {
 	txManager        *componentmocks.TXManager
 	privateTxManager *componentmocks.PrivateTxManager
 	transportMgr     *componentmocks.TransportManager
+	metricsManager   metrics.Metrics
 }
 
 func newTestDomainManager(t *testing.T, realDB bool, conf *pldconf.DomainManagerConfig, extraSetup ...func(mc *mockComponents)) (context.Context, *domainManager, *mockComponents, func()) {
@@ -64,6 +66,7 @@ func newTestDomainManager(t *testing.T, realDB bool, conf *pldconf.DomainManager
 		txManager:        componentmocks.NewTXManager(t),
 		privateTxManager: componentmocks.NewPrivateTxManager(t),
 		transportMgr:     componentmocks.NewTransportManager(t),
+		metricsManager:   metrics.NewMetricsManager(),
 	}
 
 	// Blockchain stuff is always mocked
@@ -77,6 +80,7 @@ func newTestDomainManager(t *testing.T, realDB bool, conf *pldconf.DomainManager
 	componentMocks.On("TxManager").Return(mc.txManager)
 	componentMocks.On("PrivateTxManager").Return(mc.privateTxManager)
 	componentMocks.On("TransportManager").Return(mc.transportMgr)
+	componentMocks.On("MetricsManager").Return(mc.metricsManager).Maybe()
 	mc.transportMgr.On("LocalNodeName").Return("node1").Maybe()
 
 	var p persistence.Persistence
@@ -184,6 +188,7 @@ func TestDomainMissingRegistryAddress(t *testing.T) {
 		txManager:        componentmocks.NewTXManager(t),
 		privateTxManager: componentmocks.NewPrivateTxManager(t),
 		transportMgr:     componentmocks.NewTransportManager(t),
+		metricsManager:   metrics.NewMetricsManager(),
 	}
 	componentMocks := componentmocks.NewAllComponents(t)
 	componentMocks.On("EthClientFactory").Return(mc.ethClientFactory)
@@ -196,6 +201,7 @@ func TestDomainMissingRegistryAddress(t *testing.T) {
 	componentMocks.On("TxManager").Return(mc.txManager)
 	componentMocks.On("PrivateTxManager").Return(mc.privateTxManager)
 	componentMocks.On("TransportManager").Return(mc.transportMgr)
+	componentMocks.On("MetricsManager").Return(mc.metricsManager).Maybe()
 
 	mp, err := mockpersistence.NewSQLMockProvider()
 	require.NoError(t, err)
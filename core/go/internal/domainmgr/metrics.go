@@ -0,0 +1,63 @@
+/*
+ * Copyright © 2024 Kaleido, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with
+ * the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package domainmgr
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	metricsDomainCallDuration = "paladin_domainmgr_domain_call_duration_seconds"
+	metricsDomainCallFailures = "paladin_domainmgr_domain_call_failures_total"
+	metricsDomainLabel        = "domain"
+	metricsFunctionLabel      = "function"
+)
+
+type domainManagerMetrics struct {
+	callDuration *prometheus.HistogramVec
+	callFailures *prometheus.CounterVec
+}
+
+func newDomainManagerMetrics() *domainManagerMetrics {
+	m := &domainManagerMetrics{
+		callDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    metricsDomainCallDuration,
+			Help:    "Duration of calls from the domain manager into each domain, by function",
+			Buckets: prometheus.ExponentialBuckets(0.001, 4, 9), // 1ms to ~65s
+		}, []string{metricsDomainLabel, metricsFunctionLabel}),
+		callFailures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: metricsDomainCallFailures,
+			Help: "Number of calls from the domain manager into each domain that returned an error, by function",
+		}, []string{metricsDomainLabel, metricsFunctionLabel}),
+	}
+	return m
+}
+
+func (m *domainManagerMetrics) register(registry prometheus.Registerer) {
+	registry.MustRegister(m.callDuration, m.callFailures)
+}
+
+func (m *domainManagerMetrics) recordDomainCall(domain, function string, duration time.Duration, err error) {
+	if m == nil {
+		return
+	}
+	m.callDuration.WithLabelValues(domain, function).Observe(duration.Seconds())
+	if err != nil {
+		m.callFailures.WithLabelValues(domain, function).Inc()
+	}
+}
@@ -0,0 +1,100 @@
+/*
+ * Copyright © 2024 Kaleido, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with
+ * the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package domainmgr
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/kaleido-io/paladin/toolkit/pkg/prototk"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func gatherDomainCallMetrics(t *testing.T, g prometheus.Gatherer, domain, function string) (observed uint64, failures float64) {
+	families, err := g.Gather()
+	require.NoError(t, err)
+	for _, mf := range families {
+		for _, metric := range mf.GetMetric() {
+			labels := map[string]string{}
+			for _, l := range metric.GetLabel() {
+				labels[l.GetName()] = l.GetValue()
+			}
+			if labels[metricsDomainLabel] != domain || labels[metricsFunctionLabel] != function {
+				continue
+			}
+			switch mf.GetName() {
+			case metricsDomainCallDuration:
+				observed = metric.GetHistogram().GetSampleCount()
+			case metricsDomainCallFailures:
+				failures = metric.GetCounter().GetValue()
+			}
+		}
+	}
+	return observed, failures
+}
+
+func TestObserveDomainCall(t *testing.T) {
+	ctx := context.Background()
+	for _, traceCalls := range []bool{false, true} {
+		registry := prometheus.NewRegistry()
+		o := &domainAPIObserver{domain: "domain1", metrics: newDomainManagerMetrics(), traceCalls: traceCalls}
+		o.metrics.register(registry)
+
+		res, err := observeDomainCall(ctx, o, "Sign", "req1", func(ctx context.Context, req string) (string, error) {
+			return req + "-ok", nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "req1-ok", res)
+
+		observed, failures := gatherDomainCallMetrics(t, registry, "domain1", "Sign")
+		assert.Equal(t, uint64(1), observed)
+		assert.Zero(t, failures)
+
+		_, err = observeDomainCall(ctx, o, "Sign", "req2", func(ctx context.Context, req string) (string, error) {
+			return "", fmt.Errorf("pop")
+		})
+		assert.Regexp(t, "pop", err)
+
+		observed, failures = gatherDomainCallMetrics(t, registry, "domain1", "Sign")
+		assert.Equal(t, uint64(2), observed)
+		assert.Equal(t, float64(1), failures)
+	}
+}
+
+func TestDomainCallMetricsRecordedForDomain(t *testing.T) {
+	var mc *mockComponents
+	td, done := newTestDomain(t, false, goodDomainConf(), mockSchemas(), func(m *mockComponents) { mc = m })
+	defer done()
+	assert.Nil(t, td.d.initError.Load())
+
+	// Initialization calls are measured
+	observed, failures := gatherDomainCallMetrics(t, mc.metricsManager.Registry(), td.d.name, "ConfigureDomain")
+	assert.Equal(t, uint64(1), observed)
+	assert.Zero(t, failures)
+
+	td.tp.Functions.GetVerifier = func(ctx context.Context, req *prototk.GetVerifierRequest) (*prototk.GetVerifierResponse, error) {
+		return nil, fmt.Errorf("pop")
+	}
+	_, err := td.d.api.GetVerifier(td.ctx, &prototk.GetVerifierRequest{})
+	assert.Regexp(t, "pop", err)
+
+	observed, failures = gatherDomainCallMetrics(t, mc.metricsManager.Registry(), td.d.name, "GetVerifier")
+	assert.Equal(t, uint64(1), observed)
+	assert.Equal(t, float64(1), failures)
+}
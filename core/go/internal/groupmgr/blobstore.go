@@ -0,0 +1,119 @@
+/*
+ * Copyright © 2024 Kaleido, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with
+ * the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package groupmgr
+
+import (
+	"context"
+	"crypto/sha256"
+	"os"
+	"path/filepath"
+
+	"github.com/kaleido-io/paladin/config/pkg/confutil"
+	"github.com/kaleido-io/paladin/config/pkg/pldconf"
+	"github.com/kaleido-io/paladin/core/internal/msgs"
+	"github.com/kaleido-io/paladin/toolkit/pkg/i18n"
+	"github.com/kaleido-io/paladin/toolkit/pkg/log"
+	"github.com/kaleido-io/paladin/toolkit/pkg/tktypes"
+)
+
+// Large message payloads can be held outside of the database, in a content-addressed directory of
+// blobs named by the SHA-256 hash of the stored data. The message row then holds only the reference.
+//
+// The blob is written before the DB transaction commits. If the transaction rolls back the blob is
+// left unreferenced, and because it is content-addressed a retry of the same message re-uses it.
+
+func (gm *groupManager) initBlobStore(ctx context.Context) error {
+	gm.blobInlineThreshold = confutil.ByteSize(gm.conf.BlobStore.InlineThreshold, 0, *pldconf.GroupManagerDefaults.BlobStore.InlineThreshold)
+	if gm.conf.BlobStore.Path == "" {
+		return nil
+	}
+	if err := os.MkdirAll(gm.conf.BlobStore.Path, 0700); err != nil {
+		return i18n.WrapError(ctx, err, msgs.MsgPGroupsBlobStoreInitFailed, gm.conf.BlobStore.Path)
+	}
+	gm.blobStorePath = gm.conf.BlobStore.Path
+	return nil
+}
+
+// Encryption is applied first, so externalized data is protected at rest in the same way as inline data
+func (gm *groupManager) storeMessageData(ctx context.Context, pm *persistedMessage) error {
+	if err := gm.encryptMessageData(pm); err != nil {
+		return err
+	}
+	return gm.externalizeMessageData(ctx, pm)
+}
+
+func (gm *groupManager) loadMessageData(ctx context.Context, pm *persistedMessage) error {
+	if err := gm.resolveMessageData(ctx, pm); err != nil {
+		return err
+	}
+	return gm.decryptMessageData(ctx, pm)
+}
+
+// Moves the data to the blob store if it is enabled, and the data is larger than the inline threshold
+func (gm *groupManager) externalizeMessageData(ctx context.Context, pm *persistedMessage) error {
+	if gm.blobStorePath == "" || int64(len(pm.Data)) <= gm.blobInlineThreshold {
+		return nil
+	}
+	ref := tktypes.Bytes32(sha256.Sum256(pm.Data))
+	// Write to a temporary file and rename, so a partially written blob is never visible under its reference
+	tmpFile, err := os.CreateTemp(gm.blobStorePath, ".tmp-"+ref.HexString())
+	if err == nil {
+		_, err = tmpFile.Write(pm.Data)
+		if closeErr := tmpFile.Close(); err == nil {
+			err = closeErr
+		}
+		if err == nil {
+			err = os.Rename(tmpFile.Name(), gm.blobFilePath(ref))
+		}
+		if err != nil {
+			_ = os.Remove(tmpFile.Name())
+		}
+	}
+	if err != nil {
+		log.L(ctx).Errorf("Failed to write blob %s for message %s: %s", ref, pm.ID, err)
+		return i18n.WrapError(ctx, err, msgs.MsgPGroupsBlobWriteFailed, pm.ID)
+	}
+	log.L(ctx).Debugf("Message %s data (%d bytes) stored in blob %s", pm.ID, len(pm.Data), ref)
+	pm.BlobRef = &ref
+	pm.Data = tktypes.RawJSON(`null`)
+	return nil
+}
+
+// Restores the data in-place from the blob store, if the message was externalized. This is driven
+// by the reference on the message rather than the current configuration, and the content is
+// verified against the reference.
+func (gm *groupManager) resolveMessageData(ctx context.Context, pm *persistedMessage) error {
+	if pm.BlobRef == nil {
+		return nil
+	}
+	if gm.blobStorePath == "" {
+		return i18n.NewError(ctx, msgs.MsgPGroupsBlobReadFailed, pm.ID, pm.BlobRef)
+	}
+	data, err := os.ReadFile(gm.blobFilePath(*pm.BlobRef))
+	if err == nil && tktypes.Bytes32(sha256.Sum256(data)) != *pm.BlobRef {
+		err = i18n.NewError(ctx, msgs.MsgPGroupsBlobReadFailed, pm.ID, pm.BlobRef)
+	}
+	if err != nil {
+		return i18n.WrapError(ctx, err, msgs.MsgPGroupsBlobReadFailed, pm.ID, pm.BlobRef)
+	}
+	pm.Data = data
+	pm.BlobRef = nil
+	return nil
+}
+
+func (gm *groupManager) blobFilePath(ref tktypes.Bytes32) string {
+	return filepath.Join(gm.blobStorePath, ref.HexString())
+}
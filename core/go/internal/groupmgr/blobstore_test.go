@@ -0,0 +1,180 @@
+/*
+ * Copyright © 2024 Kaleido, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with
+ * the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package groupmgr
+
+import (
+	"context"
+	"os"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/kaleido-io/paladin/config/pkg/confutil"
+	"github.com/kaleido-io/paladin/config/pkg/pldconf"
+	"github.com/kaleido-io/paladin/core/pkg/persistence"
+	"github.com/kaleido-io/paladin/toolkit/pkg/pldapi"
+	"github.com/kaleido-io/paladin/toolkit/pkg/query"
+	"github.com/kaleido-io/paladin/toolkit/pkg/tktypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func blobStoreConf(t *testing.T) *pldconf.GroupManagerConfig {
+	return &pldconf.GroupManagerConfig{
+		BlobStore: pldconf.GroupMessageBlobStoreConfig{
+			Path:            t.TempDir(),
+			InlineThreshold: confutil.P("1Kb"),
+		},
+	}
+}
+
+func TestMessageBlobStoreInlineAndExternal(t *testing.T) {
+	conf := blobStoreConf(t)
+	ctx, gm, mc, done := newTestGroupManager(t, true, conf)
+	defer done()
+
+	// Small payloads stay inline
+	smallID := sendTestMessage(t, ctx, mc, gm, map[string]string{"small": "data"})
+	pm := readRawMessage(t, ctx, gm, smallID)
+	assert.Nil(t, pm.BlobRef)
+	assert.JSONEq(t, `{"small": "data"}`, pm.Data.String())
+
+	// Large payloads are externalized, with only the reference in the DB
+	largeValue := strings.Repeat("a", 2048)
+	largeID := sendTestMessage(t, ctx, mc, gm, map[string]string{"large": largeValue})
+	pm = readRawMessage(t, ctx, gm, largeID)
+	require.NotNil(t, pm.BlobRef)
+	assert.Equal(t, `null`, pm.Data.String())
+	blobData, err := os.ReadFile(path.Join(conf.BlobStore.Path, pm.BlobRef.HexString()))
+	require.NoError(t, err)
+	assert.Contains(t, string(blobData), largeValue)
+
+	// Both are resolved transparently on read
+	msg, err := gm.GetMessageByID(ctx, gm.p.NOTX(), largeID, true)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"large": "`+largeValue+`"}`, msg.Data.String())
+
+	// Received messages have the same treatment on this node
+	rxMsg := &pldapi.PrivacyGroupMessage{
+		ID:                       uuid.New(),
+		Node:                     "node2",
+		Sent:                     tktypes.TimestampNow(),
+		PrivacyGroupMessageInput: msg.PrivacyGroupMessageInput,
+	}
+	rxMsg.Data = tktypes.JSONString(map[string]string{"received": largeValue})
+	err = gm.p.Transaction(ctx, func(ctx context.Context, dbTX persistence.DBTX) error {
+		results, err := gm.ReceiveMessages(ctx, dbTX, []*pldapi.PrivacyGroupMessage{rxMsg})
+		require.NoError(t, err)
+		return results[rxMsg.ID]
+	})
+	require.NoError(t, err)
+	pm = readRawMessage(t, ctx, gm, rxMsg.ID)
+	assert.NotNil(t, pm.BlobRef)
+
+	msgs, err := gm.QueryMessages(ctx, gm.p.NOTX(), query.NewQueryBuilder().Limit(10).Sort("localSequence").Query())
+	require.NoError(t, err)
+	require.Len(t, msgs, 3)
+	assert.JSONEq(t, `{"small": "data"}`, msgs[0].Data.String())
+	assert.JSONEq(t, `{"large": "`+largeValue+`"}`, msgs[1].Data.String())
+	assert.JSONEq(t, `{"received": "`+largeValue+`"}`, msgs[2].Data.String())
+}
+
+func TestMessageBlobStoreWithEncryption(t *testing.T) {
+	conf := blobStoreConf(t)
+	conf.Encryption = encryptedDomain1Conf().Encryption
+	ctx, gm, mc, done := newTestGroupManager(t, true, conf, mockEncryptionKey)
+	defer done()
+
+	largeValue := strings.Repeat("secret", 500)
+	msgID := sendTestMessage(t, ctx, mc, gm, largeValue)
+
+	// The externalized blob is the encrypted form
+	pm := readRawMessage(t, ctx, gm, msgID)
+	assert.True(t, pm.Encrypted)
+	require.NotNil(t, pm.BlobRef)
+	blobData, err := os.ReadFile(path.Join(conf.BlobStore.Path, pm.BlobRef.HexString()))
+	require.NoError(t, err)
+	assert.NotContains(t, string(blobData), "secret")
+
+	msg, err := gm.GetMessageByID(ctx, gm.p.NOTX(), msgID, true)
+	require.NoError(t, err)
+	assert.Equal(t, tktypes.JSONString(largeValue).String(), msg.Data.String())
+}
+
+func TestMessageBlobStoreDisabled(t *testing.T) {
+	ctx, gm, mc, done := newTestGroupManager(t, true, &pldconf.GroupManagerConfig{})
+	defer done()
+
+	largeValue := strings.Repeat("a", 128*1024)
+	msgID := sendTestMessage(t, ctx, mc, gm, largeValue)
+	pm := readRawMessage(t, ctx, gm, msgID)
+	assert.Nil(t, pm.BlobRef)
+	assert.Equal(t, tktypes.JSONString(largeValue).String(), pm.Data.String())
+}
+
+func TestMessageBlobMissingOrCorrupt(t *testing.T) {
+	conf := blobStoreConf(t)
+	ctx, gm, _, done := newTestGroupManager(t, false, conf, mockEmptyMessageListeners)
+	defer done()
+
+	pm := &persistedMessage{ID: uuid.New(), Data: tktypes.JSONString(strings.Repeat("a", 2048))}
+	err := gm.externalizeMessageData(ctx, pm)
+	require.NoError(t, err)
+	ref := *pm.BlobRef
+
+	// Corrupt the blob
+	err = os.WriteFile(gm.blobFilePath(ref), []byte(`"wrong"`), 0600)
+	require.NoError(t, err)
+	err = gm.resolveMessageData(ctx, &persistedMessage{ID: pm.ID, BlobRef: &ref})
+	assert.Regexp(t, "PD012529", err)
+
+	// Remove the blob
+	err = os.Remove(gm.blobFilePath(ref))
+	require.NoError(t, err)
+	err = gm.resolveMessageData(ctx, &persistedMessage{ID: pm.ID, BlobRef: &ref})
+	assert.Regexp(t, "PD012529", err)
+
+	// Blob store no longer configured
+	gm.blobStorePath = ""
+	err = gm.resolveMessageData(ctx, &persistedMessage{ID: pm.ID, BlobRef: &ref})
+	assert.Regexp(t, "PD012529", err)
+}
+
+func TestMessageBlobWriteFail(t *testing.T) {
+	conf := blobStoreConf(t)
+	ctx, gm, _, done := newTestGroupManager(t, false, conf, mockEmptyMessageListeners)
+	defer done()
+
+	gm.blobStorePath = path.Join(conf.BlobStore.Path, "does-not-exist")
+	err := gm.externalizeMessageData(ctx, &persistedMessage{ID: uuid.New(), Data: tktypes.JSONString(strings.Repeat("a", 2048))})
+	assert.Regexp(t, "PD012528", err)
+}
+
+func TestMessageBlobStoreInitFail(t *testing.T) {
+	blockingFile := path.Join(t.TempDir(), "file")
+	err := os.WriteFile(blockingFile, []byte{}, 0600)
+	require.NoError(t, err)
+
+	gm := NewGroupManager(context.Background(), &pldconf.GroupManagerConfig{
+		BlobStore: pldconf.GroupMessageBlobStoreConfig{Path: path.Join(blockingFile, "blobs")},
+	})
+	mc := newMockComponents(t, false)
+	_, err = gm.PreInit(mc.c)
+	require.NoError(t, err)
+	err = gm.PostInit(mc.c)
+	assert.Regexp(t, "PD012527", err)
+}
@@ -0,0 +1,127 @@
+/*
+ * Copyright © 2024 Kaleido, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with
+ * the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package groupmgr
+
+import (
+	"context"
+
+	"github.com/kaleido-io/paladin/core/internal/msgs"
+	"github.com/kaleido-io/paladin/core/pkg/persistence"
+	"github.com/kaleido-io/paladin/toolkit/pkg/i18n"
+	"github.com/kaleido-io/paladin/toolkit/pkg/log"
+	"github.com/kaleido-io/paladin/toolkit/pkg/pldapi"
+	"github.com/kaleido-io/paladin/toolkit/pkg/query"
+	"github.com/kaleido-io/paladin/toolkit/pkg/tktypes"
+	"gorm.io/gorm/clause"
+)
+
+// A pull consumer of messages, as an alternative to a message listener pushing them. The consumer is given
+// the messages after the position it has acknowledged, in localSequence order, until it acknowledges them.
+// So any messages it has not acknowledged when it restarts are redelivered (at-least-once).
+type persistedMessageConsumer struct {
+	Consumer string            `gorm:"column:consumer;primaryKey"`
+	Sequence uint64            `gorm:"column:sequence"`
+	Updated  tktypes.Timestamp `gorm:"column:updated"`
+}
+
+func (persistedMessageConsumer) TableName() string {
+	return "pgroup_msg_consumers"
+}
+
+// The local sequence the consumer has acknowledged up to, which is zero for a new consumer
+func (gm *groupManager) getConsumerPosition(ctx context.Context, dbTX persistence.DBTX, consumerID string) (uint64, error) {
+	var consumers []*persistedMessageConsumer
+	err := dbTX.DB().
+		WithContext(ctx).
+		Where(`"consumer" = ?`, consumerID).
+		Limit(1).
+		Find(&consumers).
+		Error
+	if err != nil || len(consumers) == 0 {
+		return 0, err
+	}
+	return consumers[0].Sequence, nil
+}
+
+// Returns up to limit messages after the position the consumer has acknowledged, without moving that position
+func (gm *groupManager) ConsumeMessages(ctx context.Context, dbTX persistence.DBTX, consumerID string, limit int) ([]*pldapi.PrivacyGroupMessage, error) {
+	if err := tktypes.ValidateSafeCharsStartEndAlphaNum(ctx, consumerID, tktypes.DefaultNameMaxLen, "consumerId"); err != nil {
+		return nil, err
+	}
+	if limit <= 0 || limit > gm.messagesReadPageSize {
+		limit = gm.messagesReadPageSize
+	}
+	position, err := gm.getConsumerPosition(ctx, dbTX, consumerID)
+	if err != nil {
+		return nil, err
+	}
+	return gm.QueryMessages(ctx, dbTX, query.NewQueryBuilder().
+		GreaterThan("localSequence", position).
+		Sort("localSequence").
+		Limit(limit).
+		Query())
+}
+
+// Moves the position of the consumer forwards to upToLocalSeq, so the messages up to and including it are not
+// returned to the consumer again. Acknowledging a position before the current one has no effect.
+func (gm *groupManager) AckConsumed(ctx context.Context, dbTX persistence.DBTX, consumerID string, upToLocalSeq uint64) error {
+	if err := tktypes.ValidateSafeCharsStartEndAlphaNum(ctx, consumerID, tktypes.DefaultNameMaxLen, "consumerId"); err != nil {
+		return err
+	}
+
+	// A consumer must not skip messages that have not been received yet
+	var latest struct {
+		LatestLocalSeq *uint64 `gorm:"column:latest_local_seq"`
+	}
+	err := dbTX.DB().
+		WithContext(ctx).
+		Table("pgroup_msgs").
+		Select(`MAX("local_seq") AS "latest_local_seq"`).
+		Scan(&latest).
+		Error
+	if err != nil {
+		return err
+	}
+	if latest.LatestLocalSeq == nil || upToLocalSeq > *latest.LatestLocalSeq {
+		latestLocalSeq := uint64(0)
+		if latest.LatestLocalSeq != nil {
+			latestLocalSeq = *latest.LatestLocalSeq
+		}
+		return i18n.NewError(ctx, msgs.MsgPGroupsConsumeAckBeyondReceived, upToLocalSeq, consumerID, latestLocalSeq)
+	}
+
+	position, err := gm.getConsumerPosition(ctx, dbTX, consumerID)
+	if err != nil {
+		return err
+	}
+	if upToLocalSeq <= position {
+		log.L(ctx).Debugf("Consumer '%s' already acknowledged up to %d (ack=%d)", consumerID, position, upToLocalSeq)
+		return nil
+	}
+	log.L(ctx).Debugf("Consumer '%s' acknowledged up to %d", consumerID, upToLocalSeq)
+	return dbTX.DB().
+		WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "consumer"}},
+			DoUpdates: clause.AssignmentColumns([]string{"sequence", "updated"}),
+		}).
+		Create(&persistedMessageConsumer{
+			Consumer: consumerID,
+			Sequence: upToLocalSeq,
+			Updated:  tktypes.TimestampNow(),
+		}).
+		Error
+}
@@ -0,0 +1,186 @@
+/*
+ * Copyright © 2024 Kaleido, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with
+ * the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package groupmgr
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/kaleido-io/paladin/config/pkg/confutil"
+	"github.com/kaleido-io/paladin/config/pkg/pldconf"
+	"github.com/kaleido-io/paladin/core/internal/components"
+	"github.com/kaleido-io/paladin/core/pkg/persistence"
+	"github.com/kaleido-io/paladin/toolkit/pkg/pldapi"
+	"github.com/kaleido-io/paladin/toolkit/pkg/tktypes"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func sendTestMessages(t *testing.T, ctx context.Context, mc *mockComponents, gm *groupManager, count int) []uint64 {
+	mc.registryManager.On("GetNodeTransports", mock.Anything, "node2").
+		Return([]*components.RegistryNodeTransportEntry{ /* contents not checked */ }, nil).Maybe()
+	mc.transportManager.On("SendReliable", mock.Anything, mock.Anything, mock.MatchedBy(func(rm *pldapi.ReliableMessage) bool {
+		return rm.MessageType.V() == pldapi.RMTPrivacyGroupMessage
+	})).Return(nil).Maybe()
+
+	groupIDs := createTestGroups(t, ctx, mc, gm,
+		&pldapi.PrivacyGroupInput{
+			Domain:  "domain1",
+			Members: []string{"me@node1", "you@node2"},
+		},
+	)
+
+	localSeqs := make([]uint64, count)
+	err := gm.p.Transaction(ctx, func(ctx context.Context, dbTX persistence.DBTX) error {
+		for i := range localSeqs {
+			msgID, err := gm.SendMessage(ctx, dbTX, &pldapi.PrivacyGroupMessageInput{
+				Domain: "domain1",
+				Group:  groupIDs[0],
+				Topic:  fmt.Sprintf("topic.%d", i),
+				Data:   tktypes.JSONString("some data"),
+			})
+			require.NoError(t, err)
+			msg, err := gm.GetMessageByID(ctx, dbTX, *msgID, true)
+			require.NoError(t, err)
+			localSeqs[i] = msg.LocalSequence
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	return localSeqs
+}
+
+func consumeLocalSeqs(t *testing.T, ctx context.Context, gm *groupManager, consumerID string, limit int) []uint64 {
+	msgs, err := gm.ConsumeMessages(ctx, gm.p.NOTX(), consumerID, limit)
+	require.NoError(t, err)
+	localSeqs := make([]uint64, len(msgs))
+	for i, msg := range msgs {
+		localSeqs[i] = msg.LocalSequence
+	}
+	return localSeqs
+}
+
+func ackConsumed(t *testing.T, ctx context.Context, gm *groupManager, consumerID string, upToLocalSeq uint64) error {
+	return gm.p.Transaction(ctx, func(ctx context.Context, dbTX persistence.DBTX) error {
+		return gm.AckConsumed(ctx, dbTX, consumerID, upToLocalSeq)
+	})
+}
+
+func TestConsumeAndAck(t *testing.T) {
+	ctx, gm, mc, done := newTestGroupManager(t, true, &pldconf.GroupManagerConfig{})
+	defer done()
+
+	localSeqs := sendTestMessages(t, ctx, mc, gm, 5)
+
+	// Messages are returned in localSequence order, and unacknowledged messages are returned again
+	require.Equal(t, localSeqs[0:2], consumeLocalSeqs(t, ctx, gm, "consumer1", 2))
+	require.Equal(t, localSeqs[0:2], consumeLocalSeqs(t, ctx, gm, "consumer1", 2))
+
+	require.NoError(t, ackConsumed(t, ctx, gm, "consumer1", localSeqs[1]))
+	require.Equal(t, localSeqs[2:], consumeLocalSeqs(t, ctx, gm, "consumer1", 10))
+
+	require.NoError(t, ackConsumed(t, ctx, gm, "consumer1", localSeqs[4]))
+	require.Empty(t, consumeLocalSeqs(t, ctx, gm, "consumer1", 10))
+
+	// Each consumer has its own position
+	require.Equal(t, localSeqs, consumeLocalSeqs(t, ctx, gm, "consumer2", 10))
+
+	// Acknowledging an earlier position does not move the consumer backwards
+	require.NoError(t, ackConsumed(t, ctx, gm, "consumer1", localSeqs[0]))
+	require.Empty(t, consumeLocalSeqs(t, ctx, gm, "consumer1", 10))
+}
+
+func TestConsumeResumeAfterCrash(t *testing.T) {
+	ctx, gm, mc, done := newTestGroupManager(t, true, &pldconf.GroupManagerConfig{})
+	defer done()
+
+	localSeqs := sendTestMessages(t, ctx, mc, gm, 4)
+
+	// The consumer processes the first two messages, then crashes before acknowledging the second
+	require.Equal(t, localSeqs[0:2], consumeLocalSeqs(t, ctx, gm, "consumer1", 2))
+	require.NoError(t, ackConsumed(t, ctx, gm, "consumer1", localSeqs[0]))
+
+	// The position is persisted, so when it resumes the unacknowledged message is redelivered first
+	var position []*persistedMessageConsumer
+	err := gm.p.DB().Where(`"consumer" = ?`, "consumer1").Find(&position).Error
+	require.NoError(t, err)
+	require.Len(t, position, 1)
+	require.Equal(t, localSeqs[0], position[0].Sequence)
+	require.Equal(t, localSeqs[1:], consumeLocalSeqs(t, ctx, gm, "consumer1", 10))
+}
+
+func TestConsumeLimitDefaultsToReadPageSize(t *testing.T) {
+	ctx, gm, mc, done := newTestGroupManager(t, true, &pldconf.GroupManagerConfig{
+		MessageListeners: pldconf.MessageListeners{
+			ReadPageSize: confutil.P(2),
+		},
+	})
+	defer done()
+
+	localSeqs := sendTestMessages(t, ctx, mc, gm, 3)
+
+	require.Equal(t, localSeqs[0:2], consumeLocalSeqs(t, ctx, gm, "consumer1", 0))
+	require.Equal(t, localSeqs[0:2], consumeLocalSeqs(t, ctx, gm, "consumer1", 100))
+}
+
+func TestAckConsumedBeyondReceived(t *testing.T) {
+	ctx, gm, mc, done := newTestGroupManager(t, true, &pldconf.GroupManagerConfig{})
+	defer done()
+
+	err := ackConsumed(t, ctx, gm, "consumer1", 1)
+	require.Regexp(t, "PD012536.*consumer1", err)
+
+	localSeqs := sendTestMessages(t, ctx, mc, gm, 1)
+	err = ackConsumed(t, ctx, gm, "consumer1", localSeqs[0]+1)
+	require.Regexp(t, "PD012536", err)
+	require.Equal(t, localSeqs, consumeLocalSeqs(t, ctx, gm, "consumer1", 10))
+}
+
+func TestConsumeBadConsumerID(t *testing.T) {
+	ctx, gm, _, done := newTestGroupManager(t, false, &pldconf.GroupManagerConfig{}, mockEmptyMessageListeners)
+	defer done()
+
+	_, err := gm.ConsumeMessages(ctx, gm.p.NOTX(), "$wrong", 10)
+	require.Regexp(t, "PD020005", err)
+
+	err = gm.AckConsumed(ctx, gm.p.NOTX(), "$wrong", 1)
+	require.Regexp(t, "PD020005", err)
+}
+
+func TestConsumeMessagesFail(t *testing.T) {
+	ctx, gm, mc, done := newTestGroupManager(t, false, &pldconf.GroupManagerConfig{}, mockEmptyMessageListeners)
+	defer done()
+
+	mc.db.Mock.ExpectQuery("SELECT.*pgroup_msg_consumers").WillReturnError(fmt.Errorf("pop"))
+
+	_, err := gm.ConsumeMessages(ctx, gm.p.NOTX(), "consumer1", 10)
+	require.Regexp(t, "pop", err)
+}
+
+func TestAckConsumedFail(t *testing.T) {
+	ctx, gm, mc, done := newTestGroupManager(t, false, &pldconf.GroupManagerConfig{}, mockEmptyMessageListeners)
+	defer done()
+
+	mc.db.Mock.ExpectQuery("SELECT.*pgroup_msgs").WillReturnError(fmt.Errorf("pop"))
+	err := gm.AckConsumed(ctx, gm.p.NOTX(), "consumer1", 1)
+	require.Regexp(t, "pop", err)
+
+	mc.db.Mock.ExpectQuery("SELECT.*pgroup_msgs").WillReturnRows(mc.db.Mock.NewRows([]string{"latest_local_seq"}).AddRow(10))
+	mc.db.Mock.ExpectQuery("SELECT.*pgroup_msg_consumers").WillReturnError(fmt.Errorf("pop"))
+	err = gm.AckConsumed(ctx, gm.p.NOTX(), "consumer1", 1)
+	require.Regexp(t, "pop", err)
+}
@@ -0,0 +1,158 @@
+/*
+ * Copyright © 2024 Kaleido, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with
+ * the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package groupmgr
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"io"
+	"strings"
+
+	"github.com/kaleido-io/paladin/core/internal/msgs"
+	"github.com/kaleido-io/paladin/toolkit/pkg/i18n"
+	"github.com/kaleido-io/paladin/toolkit/pkg/log"
+	"github.com/kaleido-io/paladin/toolkit/pkg/tktypes"
+	"golang.org/x/crypto/hkdf"
+)
+
+// The AES-256 key for each domain is derived by the signing module of the wallet that holds the
+// configured key, using the in-memory signer we register for the "pgroup" algorithm prefix.
+// The signer applies HKDF-SHA256 to the key material with the domain name in the info, so the key
+// material is never exposed, the derived key is stable for any wallet that loads key material,
+// and one key can be shared by several domains without them sharing an encryption key.
+const (
+	encryptionKeySignerPrefix      = "pgroup"
+	encryptionKeyAlgorithm         = "pgroup:aes256"
+	encryptionKeyVerifierType      = "pgroup:key_check"
+	encryptionKeyPayloadType       = "pgroup:hkdf_sha256"
+	encryptionKeyDerivationPrefix  = "paladin.pgroup_msgs.encryption:"
+	encryptionKeyCheckDerivationID = "paladin.pgroup_msgs.key_check"
+)
+
+type encryptionKeySigner struct{}
+
+func deriveEncryptionKey(keyMaterial, info []byte) []byte {
+	key := make([]byte, 32)
+	_, _ = io.ReadFull(hkdf.New(sha256.New, keyMaterial, nil, info), key) // cannot fail for a 32 byte output
+	return key
+}
+
+func (es *encryptionKeySigner) checkAlgorithm(ctx context.Context, algorithm string) error {
+	if !strings.EqualFold(algorithm, encryptionKeyAlgorithm) {
+		return i18n.NewError(ctx, msgs.MsgPGroupsEncryptionAlgorithmInvalid, algorithm)
+	}
+	return nil
+}
+
+func (es *encryptionKeySigner) GetMinimumKeyLen(ctx context.Context, algorithm string) (int, error) {
+	if err := es.checkAlgorithm(ctx, algorithm); err != nil {
+		return -1, err
+	}
+	return 32, nil
+}
+
+// The verifier is a key check value, which identifies the key without revealing anything about it
+func (es *encryptionKeySigner) GetVerifier(ctx context.Context, algorithm, verifierType string, privateKey []byte) (string, error) {
+	if err := es.checkAlgorithm(ctx, algorithm); err != nil {
+		return "", err
+	}
+	if verifierType != encryptionKeyVerifierType {
+		return "", i18n.NewError(ctx, msgs.MsgPGroupsEncryptionVerifierInvalid, verifierType)
+	}
+	return tktypes.HexBytes(deriveEncryptionKey(privateKey, []byte(encryptionKeyCheckDerivationID))).String(), nil
+}
+
+func (es *encryptionKeySigner) Sign(ctx context.Context, algorithm, payloadType string, privateKey, payload []byte) ([]byte, error) {
+	if err := es.checkAlgorithm(ctx, algorithm); err != nil {
+		return nil, err
+	}
+	if payloadType != encryptionKeyPayloadType {
+		return nil, i18n.NewError(ctx, msgs.MsgPGroupsEncryptionPayloadInvalid, payloadType)
+	}
+	return deriveEncryptionKey(privateKey, payload), nil
+}
+
+func (gm *groupManager) initEncryption(ctx context.Context) error {
+	for domainName, ec := range gm.conf.Encryption {
+		if ec.KeyIdentifier == "" {
+			return i18n.NewError(ctx, msgs.MsgPGroupsEncryptionKeyMissing, domainName)
+		}
+		mapping, err := gm.keyManager.ResolveKeyNewDatabaseTX(ctx, ec.KeyIdentifier, encryptionKeyAlgorithm, encryptionKeyVerifierType)
+		var domainKey []byte
+		if err == nil {
+			domainKey, err = gm.keyManager.Sign(ctx, mapping, encryptionKeyPayloadType, []byte(encryptionKeyDerivationPrefix+domainName))
+		}
+		var block cipher.Block
+		if err == nil {
+			block, err = aes.NewCipher(domainKey)
+		}
+		if err == nil {
+			gm.encryptionKeys[domainName], err = cipher.NewGCM(block)
+		}
+		if err != nil {
+			log.L(ctx).Errorf("Unable to derive encryption key from '%s' for domain '%s': %s", ec.KeyIdentifier, domainName, err)
+			return i18n.WrapError(ctx, err, msgs.MsgPGroupsEncryptionKeyUnavailable, domainName)
+		}
+	}
+	return nil
+}
+
+// Encrypts the data in-place prior to persistence, if encryption is enabled for the domain.
+// The encrypted form is stored as a JSON string of the hex encoded nonce and ciphertext.
+func (gm *groupManager) encryptMessageData(pm *persistedMessage) error {
+	aead := gm.encryptionKeys[pm.Domain]
+	if aead == nil {
+		return nil
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+	sealed := aead.Seal(nonce, nonce, pm.Data, pm.ID[:])
+	pm.Data = tktypes.JSONString(tktypes.HexBytes(sealed))
+	pm.Encrypted = true
+	return nil
+}
+
+// Decrypts the data in-place after reading from the DB. Note this is driven by the flag on the
+// message, rather than the current configuration, so that messages stored before encryption was
+// enabled remain readable - and we fail clearly if the key has since been removed.
+func (gm *groupManager) decryptMessageData(ctx context.Context, pm *persistedMessage) error {
+	if !pm.Encrypted {
+		return nil
+	}
+	aead := gm.encryptionKeys[pm.Domain]
+	if aead == nil {
+		return i18n.NewError(ctx, msgs.MsgPGroupsEncryptionKeyUnavailable, pm.Domain)
+	}
+	sealed, err := tktypes.ParseHexBytes(ctx, pm.Data.StringValue())
+	if err == nil && len(sealed) < aead.NonceSize() {
+		err = i18n.NewError(ctx, msgs.MsgPGroupsMessageDecryptFailed, pm.ID)
+	}
+	var data []byte
+	if err == nil {
+		data, err = aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], pm.ID[:])
+	}
+	if err != nil {
+		return i18n.WrapError(ctx, err, msgs.MsgPGroupsMessageDecryptFailed, pm.ID)
+	}
+	pm.Data = data
+	pm.Encrypted = false
+	return nil
+}
@@ -0,0 +1,335 @@
+/*
+ * Copyright © 2024 Kaleido, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with
+ * the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package groupmgr
+
+import (
+	"context"
+	"crypto/cipher"
+	"crypto/sha256"
+	"fmt"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/kaleido-io/paladin/config/pkg/confutil"
+	"github.com/kaleido-io/paladin/config/pkg/pldconf"
+	"github.com/kaleido-io/paladin/core/internal/components"
+	"github.com/kaleido-io/paladin/core/pkg/persistence"
+	"github.com/kaleido-io/paladin/toolkit/pkg/pldapi"
+	"github.com/kaleido-io/paladin/toolkit/pkg/query"
+	"github.com/kaleido-io/paladin/toolkit/pkg/signer"
+	"github.com/kaleido-io/paladin/toolkit/pkg/signerapi"
+	"github.com/kaleido-io/paladin/toolkit/pkg/tktypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func encryptedDomain1Conf() *pldconf.GroupManagerConfig {
+	return &pldconf.GroupManagerConfig{
+		Encryption: map[string]pldconf.GroupMessageEncryptionConfig{
+			"domain1": {KeyIdentifier: "pgroups.encryption"},
+		},
+	}
+}
+
+// Resolves any key identifier to a mapping, and signs with our real signer using key material
+// derived from the identifier - as the signing module of the wallet would with its stored key material
+func mockEncryptionKey(mc *mockComponents, conf *pldconf.GroupManagerConfig) {
+	mc.keyManager.On("ResolveKeyNewDatabaseTX", mock.Anything, mock.Anything, encryptionKeyAlgorithm, encryptionKeyVerifierType).
+		Return(func(ctx context.Context, identifier, algorithm, verifierType string) (*pldapi.KeyMappingAndVerifier, error) {
+			return &pldapi.KeyMappingAndVerifier{
+				KeyMappingWithPath: &pldapi.KeyMappingWithPath{KeyMapping: &pldapi.KeyMapping{Identifier: identifier}},
+				Verifier:           &pldapi.KeyVerifier{Algorithm: algorithm, Type: verifierType},
+			}, nil
+		}).Maybe()
+	mc.keyManager.On("Sign", mock.Anything, mock.Anything, encryptionKeyPayloadType, mock.Anything).
+		Return(func(ctx context.Context, mapping *pldapi.KeyMappingAndVerifier, payloadType string, payload []byte) ([]byte, error) {
+			keyMaterial := sha256.Sum256([]byte(mapping.Identifier))
+			return (&encryptionKeySigner{}).Sign(ctx, mapping.Verifier.Algorithm, payloadType, keyMaterial[:], payload)
+		}).Maybe()
+}
+
+func sendTestMessage(t *testing.T, ctx context.Context, mc *mockComponents, gm *groupManager, data any) uuid.UUID {
+	mc.registryManager.On("GetNodeTransports", mock.Anything, "node2").
+		Return([]*components.RegistryNodeTransportEntry{ /* contents not checked */ }, nil).Maybe()
+	mc.transportManager.On("SendReliable", mock.Anything, mock.Anything, mock.MatchedBy(func(rm *pldapi.ReliableMessage) bool {
+		return rm.MessageType.V() == pldapi.RMTPrivacyGroupMessage
+	})).Return(nil).Maybe()
+
+	groupIDs := createTestGroups(t, ctx, mc, gm,
+		&pldapi.PrivacyGroupInput{
+			Domain:  "domain1",
+			Members: []string{"me@node1", "you@node2"},
+		},
+	)
+
+	var msgID *uuid.UUID
+	err := gm.p.Transaction(ctx, func(ctx context.Context, dbTX persistence.DBTX) (err error) {
+		msgID, err = gm.SendMessage(ctx, dbTX, &pldapi.PrivacyGroupMessageInput{
+			Domain: "domain1",
+			Group:  groupIDs[0],
+			Topic:  "my/topic",
+			Data:   tktypes.JSONString(data),
+		})
+		return err
+	})
+	require.NoError(t, err)
+	return *msgID
+}
+
+func readRawMessage(t *testing.T, ctx context.Context, gm *groupManager, msgID uuid.UUID) *persistedMessage {
+	var pm persistedMessage
+	err := gm.p.DB().WithContext(ctx).Where("id = ?", msgID).First(&pm).Error
+	require.NoError(t, err)
+	return &pm
+}
+
+func TestMessageEncryptionRoundTrip(t *testing.T) {
+	conf := encryptedDomain1Conf()
+	ctx, gm, mc, done := newTestGroupManager(t, true, conf, mockEncryptionKey)
+	defer done()
+
+	msgID := sendTestMessage(t, ctx, mc, gm, map[string]string{"secret": "stuff"})
+
+	// Not readable at rest
+	pm := readRawMessage(t, ctx, gm, msgID)
+	assert.True(t, pm.Encrypted)
+	assert.NotContains(t, pm.Data.String(), "secret")
+
+	// Decrypted on read
+	msg, err := gm.GetMessageByID(ctx, gm.p.NOTX(), msgID, true)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"secret": "stuff"}`, msg.Data.String())
+
+	// Received messages are also encrypted
+	rxMsg := &pldapi.PrivacyGroupMessage{
+		ID:                       uuid.New(),
+		Node:                     "node2",
+		Sent:                     tktypes.TimestampNow(),
+		PrivacyGroupMessageInput: msg.PrivacyGroupMessageInput,
+	}
+	rxMsg.Data = tktypes.RawJSON(`{"received": "secret"}`)
+	err = gm.p.Transaction(ctx, func(ctx context.Context, dbTX persistence.DBTX) error {
+		results, err := gm.ReceiveMessages(ctx, dbTX, []*pldapi.PrivacyGroupMessage{rxMsg})
+		require.NoError(t, err)
+		return results[rxMsg.ID]
+	})
+	require.NoError(t, err)
+	pm = readRawMessage(t, ctx, gm, rxMsg.ID)
+	assert.True(t, pm.Encrypted)
+	assert.NotContains(t, pm.Data.String(), "received")
+
+	// A fresh manager derives the same key from the configured key
+	gm.encryptionKeys = map[string]cipher.AEAD{}
+	require.NoError(t, gm.initEncryption(ctx))
+	msgs, err := gm.QueryMessages(ctx, gm.p.NOTX(), query.NewQueryBuilder().Limit(10).Sort("localSequence").Query())
+	require.NoError(t, err)
+	require.Len(t, msgs, 2)
+	assert.JSONEq(t, `{"secret": "stuff"}`, msgs[0].Data.String())
+	assert.JSONEq(t, `{"received": "secret"}`, msgs[1].Data.String())
+}
+
+func TestMessageNoEncryptionRoundTrip(t *testing.T) {
+	ctx, gm, mc, done := newTestGroupManager(t, true, &pldconf.GroupManagerConfig{})
+	defer done()
+
+	msgID := sendTestMessage(t, ctx, mc, gm, map[string]string{"not": "secret"})
+
+	pm := readRawMessage(t, ctx, gm, msgID)
+	assert.False(t, pm.Encrypted)
+	assert.JSONEq(t, `{"not": "secret"}`, pm.Data.String())
+
+	msg, err := gm.GetMessageByID(ctx, gm.p.NOTX(), msgID, true)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"not": "secret"}`, msg.Data.String())
+}
+
+func TestMessageEncryptionKeyRemoved(t *testing.T) {
+	conf := encryptedDomain1Conf()
+	ctx, gm, mc, done := newTestGroupManager(t, true, conf, mockEncryptionKey)
+	defer done()
+
+	msgID := sendTestMessage(t, ctx, mc, gm, "secret")
+
+	// Encryption disabled after the message was written
+	gm.conf.Encryption = nil
+	gm.encryptionKeys = map[string]cipher.AEAD{}
+	_, err := gm.GetMessageByID(ctx, gm.p.NOTX(), msgID, true)
+	assert.Regexp(t, "PD012525", err)
+}
+
+func TestMessageEncryptionKeyPerDomain(t *testing.T) {
+	conf := encryptedDomain1Conf()
+	conf.Encryption["domain2"] = conf.Encryption["domain1"]
+	ctx, gm, _, done := newTestGroupManager(t, false, conf, mockEmptyMessageListeners, mockEncryptionKey)
+	defer done()
+
+	// The same key gives each domain its own encryption key
+	pm := &persistedMessage{ID: uuid.New(), Domain: "domain1", Data: tktypes.RawJSON(`{"some":"data"}`)}
+	err := gm.encryptMessageData(pm)
+	require.NoError(t, err)
+	pm.Domain = "domain2"
+	err = gm.decryptMessageData(ctx, pm)
+	assert.Regexp(t, "PD012526", err)
+}
+
+func TestMessageDecryptBadData(t *testing.T) {
+	conf := encryptedDomain1Conf()
+	ctx, gm, _, done := newTestGroupManager(t, false, conf, mockEmptyMessageListeners, mockEncryptionKey)
+	defer done()
+
+	pm := &persistedMessage{ID: uuid.New(), Domain: "domain1", Data: tktypes.RawJSON(`{"some":"data"}`)}
+	err := gm.encryptMessageData(pm)
+	require.NoError(t, err)
+
+	// Bound to the message ID
+	pmOtherID := *pm
+	pmOtherID.ID = uuid.New()
+	err = gm.decryptMessageData(ctx, &pmOtherID)
+	assert.Regexp(t, "PD012526", err)
+
+	err = gm.decryptMessageData(ctx, &persistedMessage{Domain: "domain1", Encrypted: true, Data: tktypes.JSONString("0x1234")})
+	assert.Regexp(t, "PD012526", err)
+
+	err = gm.decryptMessageData(ctx, &persistedMessage{Domain: "domain1", Encrypted: true, Data: tktypes.JSONString("not hex")})
+	assert.Regexp(t, "PD012526", err)
+
+	err = gm.decryptMessageData(ctx, pm)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"some":"data"}`, pm.Data.String())
+}
+
+func TestMessageEncryptionConfigMissingKey(t *testing.T) {
+	gm := NewGroupManager(context.Background(), &pldconf.GroupManagerConfig{
+		Encryption: map[string]pldconf.GroupMessageEncryptionConfig{"domain1": {}},
+	})
+	mc := newMockComponents(t, false)
+	_, err := gm.PreInit(mc.c)
+	require.NoError(t, err)
+	err = gm.PostInit(mc.c)
+	assert.Regexp(t, "PD012524", err)
+}
+
+func TestMessageEncryptionKeyResolveFail(t *testing.T) {
+	gm := NewGroupManager(context.Background(), encryptedDomain1Conf())
+	mc := newMockComponents(t, false)
+	mc.keyManager.On("ResolveKeyNewDatabaseTX", mock.Anything, "pgroups.encryption", encryptionKeyAlgorithm, encryptionKeyVerifierType).
+		Return(nil, fmt.Errorf("pop"))
+	_, err := gm.PreInit(mc.c)
+	require.NoError(t, err)
+	err = gm.PostInit(mc.c)
+	assert.Regexp(t, "PD012525.*pop", err)
+}
+
+func TestMessageEncryptionKeySignFail(t *testing.T) {
+	gm := NewGroupManager(context.Background(), encryptedDomain1Conf())
+	mc := newMockComponents(t, false)
+	mc.keyManager.On("ResolveKeyNewDatabaseTX", mock.Anything, "pgroups.encryption", encryptionKeyAlgorithm, encryptionKeyVerifierType).
+		Return(&pldapi.KeyMappingAndVerifier{}, nil)
+	mc.keyManager.On("Sign", mock.Anything, mock.Anything, encryptionKeyPayloadType, []byte(encryptionKeyDerivationPrefix+"domain1")).
+		Return(nil, fmt.Errorf("pop"))
+	_, err := gm.PreInit(mc.c)
+	require.NoError(t, err)
+	err = gm.PostInit(mc.c)
+	assert.Regexp(t, "PD012525.*pop", err)
+}
+
+func TestMessageEncryptionKeyBadLength(t *testing.T) {
+	gm := NewGroupManager(context.Background(), encryptedDomain1Conf())
+	mc := newMockComponents(t, false)
+	mc.keyManager.On("ResolveKeyNewDatabaseTX", mock.Anything, "pgroups.encryption", encryptionKeyAlgorithm, encryptionKeyVerifierType).
+		Return(&pldapi.KeyMappingAndVerifier{}, nil)
+	mc.keyManager.On("Sign", mock.Anything, mock.Anything, encryptionKeyPayloadType, mock.Anything).
+		Return([]byte{0x01}, nil)
+	_, err := gm.PreInit(mc.c)
+	require.NoError(t, err)
+	err = gm.PostInit(mc.c)
+	assert.Regexp(t, "PD012525", err)
+}
+
+func TestEncryptionKeySignerInSigningModule(t *testing.T) {
+	ctx := context.Background()
+	sm, err := signer.NewSigningModule(ctx, &signerapi.ConfigNoExt{
+		KeyStore: pldconf.KeyStoreConfig{
+			Type: pldconf.KeyStoreTypeFilesystem,
+			FileSystem: pldconf.FileSystemKeyStoreConfig{
+				Path: confutil.P(t.TempDir()),
+			},
+		},
+	})
+	require.NoError(t, err)
+	defer sm.Close()
+	sm.AddInMemorySigner(encryptionKeySignerPrefix, &encryptionKeySigner{})
+
+	resolveKey := func(name string) *signerapi.ResolveKeyResponse {
+		res, err := sm.Resolve(ctx, &signerapi.ResolveKeyRequest{
+			Name:                name,
+			RequiredIdentifiers: []*signerapi.PublicKeyIdentifierType{{Algorithm: encryptionKeyAlgorithm, VerifierType: encryptionKeyVerifierType}},
+		})
+		require.NoError(t, err)
+		return res
+	}
+	deriveKey := func(keyHandle, domainName string) []byte {
+		res, err := sm.Sign(ctx, &signerapi.SignRequest{
+			KeyHandle:   keyHandle,
+			Algorithm:   encryptionKeyAlgorithm,
+			PayloadType: encryptionKeyPayloadType,
+			Payload:     []byte(encryptionKeyDerivationPrefix + domainName),
+		})
+		require.NoError(t, err)
+		require.Len(t, res.Payload, 32)
+		return res.Payload
+	}
+
+	key1 := resolveKey("key1")
+	assert.Len(t, key1.Identifiers[0].Verifier, 66)
+	assert.Equal(t, key1, resolveKey("key1"))
+	key2 := resolveKey("key2")
+	assert.NotEqual(t, key1.Identifiers[0].Verifier, key2.Identifiers[0].Verifier)
+
+	// Stable for each key and domain, and distinct otherwise
+	domain1Key := deriveKey(key1.KeyHandle, "domain1")
+	assert.Equal(t, domain1Key, deriveKey(key1.KeyHandle, "domain1"))
+	assert.NotEqual(t, domain1Key, deriveKey(key1.KeyHandle, "domain2"))
+	assert.NotEqual(t, domain1Key, deriveKey(key2.KeyHandle, "domain1"))
+	// The key check value is not the key for any domain
+	assert.NotEqual(t, key1.Identifiers[0].Verifier, tktypes.HexBytes(domain1Key).String())
+}
+
+func TestEncryptionKeySignerBadRequests(t *testing.T) {
+	ctx := context.Background()
+	es := &encryptionKeySigner{}
+	keyMaterial := make([]byte, 32)
+
+	keyLen, err := es.GetMinimumKeyLen(ctx, "PGROUP:AES256")
+	require.NoError(t, err)
+	assert.Equal(t, 32, keyLen)
+
+	_, err = es.GetMinimumKeyLen(ctx, "pgroup:wrong")
+	assert.Regexp(t, "PD012537", err)
+
+	_, err = es.GetVerifier(ctx, "pgroup:wrong", encryptionKeyVerifierType, keyMaterial)
+	assert.Regexp(t, "PD012537", err)
+
+	_, err = es.GetVerifier(ctx, encryptionKeyAlgorithm, "wrong", keyMaterial)
+	assert.Regexp(t, "PD012538", err)
+
+	_, err = es.Sign(ctx, "pgroup:wrong", encryptionKeyPayloadType, keyMaterial, []byte("payload"))
+	assert.Regexp(t, "PD012537", err)
+
+	_, err = es.Sign(ctx, encryptionKeyAlgorithm, "wrong", keyMaterial, []byte("payload"))
+	assert.Regexp(t, "PD012539", err)
+}
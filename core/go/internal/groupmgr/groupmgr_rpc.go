@@ -49,6 +49,11 @@ func (gm *groupManager) initRPC() {
 		Add("pgroup_sendMessage", gm.rpcSendMessage()).
 		Add("pgroup_getMessageById", gm.rpcGetMessageByID()).
 		Add("pgroup_queryMessages", gm.rpcQueryMessages()).
+		Add("pgroup_searchMessages", gm.rpcSearchMessages()).
+		Add("pgroup_consumeMessages", gm.rpcConsumeMessages()).
+		Add("pgroup_ackConsumedMessages", gm.rpcAckConsumedMessages()).
+		Add("pgroup_getMessageDistributionStatus", gm.rpcGetMessageDistributionStatus()).
+		Add("pgroup_retryMessageDistribution", gm.rpcRetryMessageDistribution()).
 		AddAsync(gm.rpcEventStreams)
 }
 
@@ -125,6 +130,40 @@ func (gm *groupManager) rpcQueryMessages() rpcserver.RPCHandler {
 	})
 }
 
+func (gm *groupManager) rpcSearchMessages() rpcserver.RPCHandler {
+	return rpcserver.RPCMethod1(func(ctx context.Context, search pldapi.PrivacyGroupMessageSearch) (msgs []*pldapi.PrivacyGroupMessage, err error) {
+		return gm.SearchMessages(ctx, gm.p.NOTX(), &search)
+	})
+}
+
+func (gm *groupManager) rpcConsumeMessages() rpcserver.RPCHandler {
+	return rpcserver.RPCMethod2(func(ctx context.Context, consumerID string, limit int) (msgs []*pldapi.PrivacyGroupMessage, err error) {
+		return gm.ConsumeMessages(ctx, gm.p.NOTX(), consumerID, limit)
+	})
+}
+
+func (gm *groupManager) rpcAckConsumedMessages() rpcserver.RPCHandler {
+	return rpcserver.RPCMethod2(func(ctx context.Context, consumerID string, upToLocalSeq uint64) (bool, error) {
+		err := gm.p.Transaction(ctx, func(ctx context.Context, dbTX persistence.DBTX) error {
+			return gm.AckConsumed(ctx, dbTX, consumerID, upToLocalSeq)
+		})
+		return err == nil, err
+	})
+}
+
+func (gm *groupManager) rpcGetMessageDistributionStatus() rpcserver.RPCHandler {
+	return rpcserver.RPCMethod1(func(ctx context.Context, id uuid.UUID) ([]*pldapi.ReliableMessageRetryStatus, error) {
+		return gm.GetMessageDistributionStatus(ctx, gm.p.NOTX(), id)
+	})
+}
+
+func (gm *groupManager) rpcRetryMessageDistribution() rpcserver.RPCHandler {
+	return rpcserver.RPCMethod1(func(ctx context.Context, id uuid.UUID) (bool, error) {
+		err := gm.RetryMessageDistribution(ctx, gm.p.NOTX(), id)
+		return err == nil, err
+	})
+}
+
 func (gm *groupManager) rpcCreateMessageListener() rpcserver.RPCHandler {
 	return rpcserver.RPCMethod1(func(ctx context.Context,
 		listener *pldapi.PrivacyGroupMessageListener,
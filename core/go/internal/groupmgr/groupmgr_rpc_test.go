@@ -384,3 +384,61 @@ func TestRCPMessageListenersCRUDRealDB(t *testing.T) {
 	require.NotNil(t, gm.messageListeners["listener1"].done)
 
 }
+
+func TestRPCMessageDistributionStatusAndRetryRealDB(t *testing.T) {
+	ctx, gm, mc, done := newTestGroupManager(t, true, &pldconf.GroupManagerConfig{})
+	defer done()
+
+	client := newTestRPCServer(t, ctx, gm)
+	pgroupRPC := pldclient.Wrap(client).PrivacyGroups()
+
+	mc.registryManager.On("GetNodeTransports", mock.Anything, "node2").
+		Return([]*components.RegistryNodeTransportEntry{ /* contents not checked */ }, nil)
+
+	groupIDs := createTestGroups(t, ctx, mc, gm,
+		&pldapi.PrivacyGroupInput{
+			Domain:  "domain1",
+			Members: []string{"me@node1", "you@node2"},
+		},
+	)
+
+	rmNode2 := &pldapi.ReliableMessage{ID: uuid.New(), Node: "node2"}
+	mc.transportManager.On("SendReliable", mock.Anything, mock.Anything, mock.MatchedBy(func(rm *pldapi.ReliableMessage) bool {
+		return rm.MessageType.V() == pldapi.RMTPrivacyGroupMessage
+	})).Run(func(args mock.Arguments) {
+		args[2].(*pldapi.ReliableMessage).ID = rmNode2.ID
+	}).Return(nil)
+
+	msgID, err := pgroupRPC.SendMessage(ctx, &pldapi.PrivacyGroupMessageInput{
+		Domain: "domain1",
+		Group:  groupIDs[0],
+		Topic:  "my/topic",
+		Data:   tktypes.JSONString("some data"),
+	})
+	require.NoError(t, err)
+
+	mc.transportManager.On("QueryReliableMessages", mock.Anything, mock.Anything, mock.Anything).
+		Return([]*pldapi.ReliableMessage{rmNode2}, nil)
+	mc.transportManager.On("GetReliableMessageRetryStatus", mock.Anything, mock.Anything, rmNode2.ID).
+		Return(&pldapi.ReliableMessageRetryStatus{MessageID: rmNode2.ID, Node: "node2", Attempts: 3, RetryFailures: 2}, nil)
+	mc.transportManager.On("RetryReliableMessageNow", mock.Anything, mock.Anything, rmNode2.ID).Return(nil).Once()
+
+	statuses, err := pgroupRPC.GetMessageDistributionStatus(ctx, msgID)
+	require.NoError(t, err)
+	require.Len(t, statuses, 1)
+	assert.Equal(t, rmNode2.ID, statuses[0].MessageID)
+	assert.Equal(t, "node2", statuses[0].Node)
+	assert.Equal(t, 3, statuses[0].Attempts)
+	assert.Equal(t, 2, statuses[0].RetryFailures)
+
+	ok, err := pgroupRPC.RetryMessageDistribution(ctx, msgID)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	mc.transportManager.AssertNumberOfCalls(t, "RetryReliableMessageNow", 1)
+
+	// Unknown messages are reported as such
+	_, err = pgroupRPC.GetMessageDistributionStatus(ctx, uuid.New())
+	assert.Regexp(t, "PD012513", err)
+	_, err = pgroupRPC.RetryMessageDistribution(ctx, uuid.New())
+	assert.Regexp(t, "PD012513", err)
+}
@@ -17,6 +17,7 @@ package groupmgr
 
 import (
 	"context"
+	"crypto/cipher"
 	"encoding/json"
 	"fmt"
 	"sync"
@@ -62,6 +63,7 @@ type groupManager struct {
 	domainManager    components.DomainManager
 	transportManager components.TransportManager
 	registryManager  components.RegistryManager
+	keyManager       components.KeyManager
 	p                persistence.Persistence
 	rpcEventStreams  *rpcEventStreams
 
@@ -70,6 +72,24 @@ type groupManager struct {
 	messageListenersLoadPageSize int
 	messageListenerLock          sync.Mutex
 	messageListeners             map[string]*messageListener
+	ephemeralListeners           map[string]*messageListener
+
+	encryptionKeys map[string]cipher.AEAD // derived at init for each domain with encryption enabled
+
+	blobStorePath       string
+	blobInlineThreshold int64
+
+	validateCorrelationIDs bool
+	unknownGroupAction     string
+
+	metrics *groupManagerMetrics
+
+	searchEnabled      bool
+	searchFullText     bool   // the DB supports full-text search
+	searchDocumentSQL  string // the SQL expression for the searched content of a message
+	searchDefaultLimit int
+
+	testOnlyLocalNodeName string // Note: this can never be set in normal code path, exposed for testing only
 }
 
 type referencedReceipt struct {
@@ -114,6 +134,8 @@ func NewGroupManager(bgCtx context.Context, conf *pldconf.GroupManagerConfig) co
 		conf:             conf,
 		deployedPGCache:  cache.NewCache[string, *pldapi.PrivacyGroup](&conf.Cache, &pldconf.GroupManagerDefaults.Cache),
 		messageListeners: make(map[string]*messageListener),
+		encryptionKeys:   make(map[string]cipher.AEAD),
+		metrics:          newGroupManagerMetrics(),
 	}
 	gm.messagesInit()
 	gm.rpcEventStreams = newRPCEventStreams(gm)
@@ -123,6 +145,7 @@ func NewGroupManager(bgCtx context.Context, conf *pldconf.GroupManagerConfig) co
 
 func (gm *groupManager) PreInit(pic components.PreInitComponents) (*components.ManagerInitResult, error) {
 	gm.initRPC()
+	gm.metrics.register(pic.MetricsManager().Registry())
 	return &components.ManagerInitResult{
 		RPCModules: []*rpcserver.RPCModule{gm.rpcModule},
 	}, nil
@@ -135,6 +158,22 @@ func (gm *groupManager) PostInit(c components.AllComponents) error {
 	gm.p = c.Persistence()
 	gm.transportManager = c.TransportManager()
 	gm.registryManager = c.RegistryManager()
+	gm.keyManager = c.KeyManager()
+
+	// Register ourselves as a signer on the key manager, to derive the message encryption keys
+	gm.keyManager.AddInMemorySigner(encryptionKeySignerPrefix, &encryptionKeySigner{})
+	if err := gm.initEncryption(gm.bgCtx); err != nil {
+		return err
+	}
+	if err := gm.initBlobStore(gm.bgCtx); err != nil {
+		return err
+	}
+	if err := gm.initSearch(gm.bgCtx); err != nil {
+		return err
+	}
+	if err := gm.initInboundMessages(gm.bgCtx); err != nil {
+		return err
+	}
 	return gm.loadMessageListeners()
 }
 
@@ -149,8 +188,17 @@ func (gm *groupManager) Stop() {
 	gm.cancelCtx()
 }
 
+// Tests can simulate several nodes exchanging messages in one process, by overriding the name
+// used to stamp the messages we send, and to determine which messages are local
+func (gm *groupManager) localNodeName() string {
+	if gm.testOnlyLocalNodeName != "" {
+		return gm.testOnlyLocalNodeName
+	}
+	return gm.transportManager.LocalNodeName()
+}
+
 func (gm *groupManager) validateMembers(ctx context.Context, members []string, checkConnectivity bool) (remoteMembers map[string][]string, err error) {
-	localNode := gm.transportManager.LocalNodeName()
+	localNode := gm.localNodeName()
 	remoteMembers = make(map[string][]string)
 	if len(members) == 0 {
 		return nil, i18n.NewError(ctx, msgs.MsgPGroupsNoMembers)
@@ -25,6 +25,7 @@ import (
 	"github.com/google/uuid"
 	"github.com/kaleido-io/paladin/config/pkg/pldconf"
 	"github.com/kaleido-io/paladin/core/internal/components"
+	"github.com/kaleido-io/paladin/core/internal/metrics"
 	"github.com/kaleido-io/paladin/core/internal/statemgr"
 	"github.com/kaleido-io/paladin/core/mocks/componentmocks"
 	"github.com/kaleido-io/paladin/core/pkg/persistence"
@@ -49,6 +50,8 @@ type mockComponents struct {
 	domain           *componentmocks.Domain
 	registryManager  *componentmocks.RegistryManager
 	transportManager *componentmocks.TransportManager
+	keyManager       *componentmocks.KeyManager
+	metricsManager   metrics.Metrics
 }
 
 func newMockComponents(t *testing.T, realDB bool) *mockComponents {
@@ -58,11 +61,15 @@ func newMockComponents(t *testing.T, realDB bool) *mockComponents {
 	mc.registryManager = componentmocks.NewRegistryManager(t)
 	mc.transportManager = componentmocks.NewTransportManager(t)
 	mc.txManager = componentmocks.NewTXManager(t)
+	mc.keyManager = componentmocks.NewKeyManager(t)
+	mc.metricsManager = metrics.NewMetricsManager()
 
 	mc.c.On("DomainManager").Return(mc.domainManager).Maybe()
 	mc.c.On("TransportManager").Return(mc.transportManager).Maybe()
 	mc.c.On("RegistryManager").Return(mc.registryManager).Maybe()
 	mc.c.On("TxManager").Return(mc.txManager).Maybe()
+	mc.c.On("KeyManager").Return(mc.keyManager).Maybe()
+	mc.c.On("MetricsManager").Return(mc.metricsManager).Maybe()
 
 	if realDB {
 		p, cleanup, err := persistence.NewUnitTestPersistence(context.Background(), "groupmgr")
@@ -97,6 +104,7 @@ func newMockComponents(t *testing.T, realDB bool) *mockComponents {
 	mc.domain.On("Name").Return("domain1").Maybe()
 	mc.txManager.On("NotifyStatesDBChanged", mock.Anything).Return().Maybe()
 	mc.transportManager.On("LocalNodeName").Return("node1").Maybe()
+	mc.keyManager.On("AddInMemorySigner", encryptionKeySignerPrefix, mock.Anything).Maybe()
 
 	return mc
 }
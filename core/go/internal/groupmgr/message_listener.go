@@ -75,6 +75,7 @@ type messageListener struct {
 	spec       *pldapi.PrivacyGroupMessageListener
 	topicMatch *regexp.Regexp
 	checkpoint *uint64
+	ephemeral  bool // not persisted, with the checkpoint held only in memory
 
 	newMessages chan bool
 
@@ -100,7 +101,9 @@ func (gm *groupManager) messagesInit() {
 	gm.messagesRetry = retry.NewRetryIndefinite(&gm.conf.MessageListeners.Retry, &pldconf.GroupManagerDefaults.MessageListeners.Retry)
 	gm.messagesReadPageSize = confutil.IntMin(gm.conf.MessageListeners.ReadPageSize, 1, *pldconf.GroupManagerDefaults.MessageListeners.ReadPageSize)
 	gm.messageListeners = make(map[string]*messageListener)
+	gm.ephemeralListeners = make(map[string]*messageListener)
 	gm.messageListenersLoadPageSize = 100 /* not currently tunable */
+	gm.validateCorrelationIDs = confutil.Bool(gm.conf.ValidateCorrelationIDs, *pldconf.GroupManagerDefaults.ValidateCorrelationIDs)
 }
 
 func (pm *persistedMessage) mapToAPI() *pldapi.PrivacyGroupMessage {
@@ -161,6 +164,9 @@ func (gm *groupManager) CreateMessageListener(ctx context.Context, spec *pldapi.
 
 func (rr *registeredMessageReceiver) Close() {
 	rr.l.removeReceiver(rr.id)
+	if rr.l.ephemeral {
+		rr.l.gm.removeEphemeralMessageListener(rr.l)
+	}
 }
 
 func (gm *groupManager) AddMessageReceiver(ctx context.Context, name string, r components.PrivacyGroupMessageReceiver) (components.PrivacyGroupMessageReceiverCloser, error) {
@@ -175,6 +181,65 @@ func (gm *groupManager) AddMessageReceiver(ctx context.Context, name string, r c
 	return l.addReceiver(r), nil
 }
 
+// Creates an in-memory listener with a single receiver, that lives only as long as that receiver.
+// Unless a starting sequence is supplied, only messages that arrive after this call are delivered.
+func (gm *groupManager) addEphemeralMessageReceiver(ctx context.Context, id string, filters *pldapi.PrivacyGroupMessageListenerFilters, r components.PrivacyGroupMessageReceiver) (components.PrivacyGroupMessageReceiverCloser, error) {
+	l := &messageListener{
+		gm: gm,
+		spec: &pldapi.PrivacyGroupMessageListener{
+			Name:    id,
+			Created: tktypes.TimestampNow(),
+			Started: confutil.P(true),
+			Filters: *filters,
+		},
+		ephemeral:    true,
+		newReceivers: make(chan bool, 1),
+		newMessages:  make(chan bool, 1),
+	}
+	var err error
+	l.topicMatch, err = gm.validateListenerSpec(ctx, l.spec)
+	if err != nil {
+		return nil, err
+	}
+
+	if filters.SequenceAbove != nil {
+		l.checkpoint = filters.SequenceAbove
+	} else {
+		var latest []*persistedMessage
+		err := gm.p.DB().
+			WithContext(ctx).
+			Select("local_seq").
+			Order("local_seq DESC").
+			Limit(1).
+			Find(&latest).
+			Error
+		if err != nil {
+			return nil, err
+		}
+		if len(latest) > 0 {
+			l.checkpoint = &latest[0].LocalSeq
+		}
+	}
+
+	gm.messageListenerLock.Lock()
+	defer gm.messageListenerLock.Unlock()
+	if gm.ephemeralListeners[id] != nil {
+		return nil, i18n.NewError(ctx, msgs.MsgPGroupsDuplicateMessageListenerName, id)
+	}
+	gm.ephemeralListeners[id] = l
+	rr := l.addReceiver(r)
+	l.start()
+	return rr, nil
+}
+
+func (gm *groupManager) removeEphemeralMessageListener(l *messageListener) {
+	gm.messageListenerLock.Lock()
+	defer gm.messageListenerLock.Unlock()
+
+	l.stop()
+	delete(gm.ephemeralListeners, l.spec.Name)
+}
+
 func (gm *groupManager) GetMessageListener(ctx context.Context, name string) *pldapi.PrivacyGroupMessageListener {
 
 	gm.messageListenerLock.Lock()
@@ -319,10 +384,13 @@ func (gm *groupManager) getMessageListenerList() []*messageListener {
 	gm.messageListenerLock.Lock()
 	defer gm.messageListenerLock.Unlock()
 
-	listeners := make([]*messageListener, 0, len(gm.messageListeners))
+	listeners := make([]*messageListener, 0, len(gm.messageListeners)+len(gm.ephemeralListeners))
 	for _, l := range gm.messageListeners {
 		listeners = append(listeners, l)
 	}
+	for _, l := range gm.ephemeralListeners {
+		listeners = append(listeners, l)
+	}
 	return listeners
 }
 
@@ -346,6 +414,9 @@ func (gm *groupManager) stopMessageListeners() {
 	for _, l := range gm.messageListeners {
 		l.stop()
 	}
+	for _, l := range gm.ephemeralListeners {
+		l.stop()
+	}
 }
 
 func (gm *groupManager) validateListenerSpec(ctx context.Context, spec *pldapi.PrivacyGroupMessageListener) (topicMatch *regexp.Regexp, err error) {
@@ -376,7 +447,7 @@ func (gm *groupManager) buildListenerDBQuery(spec *pldapi.PrivacyGroupMessageLis
 	}
 
 	if spec.Options.ExcludeLocal {
-		q = q.Where("node <> ?", gm.transportManager.LocalNodeName())
+		q = q.Where("node <> ?", gm.localNodeName())
 	}
 
 	// Note we do post-filter on topic (no DB filter) as it's a regular expression
@@ -409,7 +480,7 @@ func (l *messageListener) checkMatch(r *persistedMessage) bool {
 		matches = matches && (l.topicMatch.MatchString(r.Topic))
 	}
 	if spec.Options.ExcludeLocal {
-		matches = matches && (l.gm.transportManager.LocalNodeName() != r.Node)
+		matches = matches && (l.gm.localNodeName() != r.Node)
 	}
 
 	// Note we don't factor sequence into the tap - as the notification does not contain the DB-generated sequence
@@ -520,6 +591,10 @@ func (l *messageListener) removeReceiver(rid uuid.UUID) {
 }
 
 func (l *messageListener) loadCheckpoint() error {
+	if l.ephemeral {
+		// Determined when the listener was created
+		return nil
+	}
 	var checkpoints []*persistedMessageCheckpoint
 	err := l.gm.p.DB().
 		WithContext(l.ctx).
@@ -553,7 +628,16 @@ func (l *messageListener) readPage() ([]*persistedMessage, error) {
 		if l.checkpoint != nil {
 			q = q.Where(`"pgroup_msgs"."local_seq" > ?`, *l.checkpoint)
 		}
-		return true, q.Find(&messages).Error
+		if err := q.Find(&messages).Error; err != nil {
+			return true, err
+		}
+		// Messages are not skipped if the encryption key or blob is unavailable - we retry until it is
+		for _, pm := range messages {
+			if err := l.gm.loadMessageData(l.ctx, pm); err != nil {
+				return true, err
+			}
+		}
+		return true, nil
 	})
 	return messages, err
 }
@@ -602,6 +686,10 @@ func (l *messageListener) deliverBatch(b *messageDeliveryBatch) error {
 }
 
 func (l *messageListener) updateCheckpoint(newSequence uint64) error {
+	if l.ephemeral {
+		l.checkpoint = &newSequence
+		return nil
+	}
 	return l.gm.p.Transaction(l.ctx, func(ctx context.Context, dbTX persistence.DBTX) error {
 		err := dbTX.DB().
 			WithContext(ctx).
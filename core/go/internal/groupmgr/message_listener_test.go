@@ -733,3 +733,26 @@ func TestLoadCheckpoint(t *testing.T) {
 	require.Equal(t, uint64(400), *l.checkpoint)
 
 }
+
+func TestAddEphemeralMessageReceiverQueryFail(t *testing.T) {
+	ctx, gm, mc, done := newTestGroupManager(t, false, &pldconf.GroupManagerConfig{}, mockEmptyMessageListeners)
+	defer done()
+
+	mc.db.Mock.ExpectQuery("SELECT.*pgroup_msgs").WillReturnError(fmt.Errorf("pop"))
+
+	_, err := gm.addEphemeralMessageReceiver(ctx, "sub1", &pldapi.PrivacyGroupMessageListenerFilters{}, newTestMessageReceiver(nil))
+	require.Regexp(t, "pop", err)
+	require.Empty(t, gm.ephemeralListeners)
+}
+
+func TestAddEphemeralMessageReceiverDup(t *testing.T) {
+	ctx, gm, _, done := newTestGroupManager(t, false, &pldconf.GroupManagerConfig{}, mockEmptyMessageListeners)
+	defer done()
+
+	gm.ephemeralListeners["sub1"] = &messageListener{}
+
+	_, err := gm.addEphemeralMessageReceiver(ctx, "sub1", &pldapi.PrivacyGroupMessageListenerFilters{
+		SequenceAbove: confutil.P(uint64(10)),
+	}, newTestMessageReceiver(nil))
+	require.Regexp(t, "PD012507", err)
+}
@@ -19,6 +19,8 @@ import (
 	"context"
 
 	"github.com/google/uuid"
+	"github.com/kaleido-io/paladin/config/pkg/confutil"
+	"github.com/kaleido-io/paladin/config/pkg/pldconf"
 	"github.com/kaleido-io/paladin/core/internal/components"
 	"github.com/kaleido-io/paladin/core/internal/filters"
 	"github.com/kaleido-io/paladin/core/internal/msgs"
@@ -31,7 +33,31 @@ import (
 	"gorm.io/gorm/clause"
 )
 
+// Upper bound on the number of remote nodes we look up distributions for, for a single message
+const maxMessageDistributions = 1000
+
 type persistedMessage struct {
+	LocalSeq  uint64            `gorm:"column:local_seq;autoIncrement;primaryKey"`
+	Domain    string            `gorm:"column:domain"`
+	Group     tktypes.HexBytes  `gorm:"column:group"`
+	Node      string            `gorm:"column:node"`
+	Sent      tktypes.Timestamp `gorm:"column:sent"`
+	Received  tktypes.Timestamp `gorm:"column:received"`
+	ID        uuid.UUID         `gorm:"column:id"`
+	CID       *uuid.UUID        `gorm:"column:cid"`
+	Topic     string            `gorm:"column:topic"`
+	Data      tktypes.RawJSON   `gorm:"column:data"`
+	Encrypted bool              `gorm:"column:encrypted"`
+	BlobRef   *tktypes.Bytes32  `gorm:"column:blob_ref"`
+}
+
+func (persistedMessage) TableName() string {
+	return "pgroup_msgs"
+}
+
+// A message received for a domain or privacy group that does not exist on this node,
+// stored as received (no encryption or blob storage applies) for later inspection
+type quarantinedMessage struct {
 	LocalSeq uint64            `gorm:"column:local_seq;autoIncrement;primaryKey"`
 	Domain   string            `gorm:"column:domain"`
 	Group    tktypes.HexBytes  `gorm:"column:group"`
@@ -42,10 +68,23 @@ type persistedMessage struct {
 	CID      *uuid.UUID        `gorm:"column:cid"`
 	Topic    string            `gorm:"column:topic"`
 	Data     tktypes.RawJSON   `gorm:"column:data"`
+	Reason   string            `gorm:"column:reason"`
 }
 
-func (persistedMessage) TableName() string {
-	return "pgroup_msgs"
+func (quarantinedMessage) TableName() string {
+	return "pgroup_msgs_quarantine"
+}
+
+// The reliable message distributing a locally sent message to one remote node, so the
+// distributions of a message can be found without searching the reliable message metadata
+type persistedMessageDistribution struct {
+	Msg         uuid.UUID `gorm:"column:msg;primaryKey"`
+	Node        string    `gorm:"column:node;primaryKey"`
+	ReliableMsg uuid.UUID `gorm:"column:reliable_msg"`
+}
+
+func (persistedMessageDistribution) TableName() string {
+	return "pgroup_msg_distributions"
 }
 
 var messageFilters = filters.FieldMap{
@@ -85,6 +124,11 @@ func (gm *groupManager) SendMessage(ctx context.Context, dbTX persistence.DBTX,
 	if pg == nil {
 		return nil, i18n.NewError(ctx, msgs.MsgPGroupsGroupNotFound, msg.Group)
 	}
+	if gm.validateCorrelationIDs && msg.CorrelationID != nil {
+		if err := gm.checkCorrelationID(ctx, dbTX, msg.Domain, msg.Group, *msg.CorrelationID); err != nil {
+			return nil, err
+		}
+	}
 
 	// Build and insert the message
 	now := tktypes.TimestampNow()
@@ -94,7 +138,7 @@ func (gm *groupManager) SendMessage(ctx context.Context, dbTX persistence.DBTX,
 		Group:    msg.Group,
 		Sent:     now,
 		Received: now,
-		Node:     gm.transportManager.LocalNodeName(),
+		Node:     gm.localNodeName(),
 		ID:       msgID,
 		CID:      msg.CorrelationID,
 		Topic:    msg.Topic,
@@ -103,6 +147,9 @@ func (gm *groupManager) SendMessage(ctx context.Context, dbTX persistence.DBTX,
 	if err := pMsg.preValidate(ctx); err != nil {
 		return nil, err
 	}
+	if err := gm.storeMessageData(ctx, pMsg); err != nil {
+		return nil, err
+	}
 	if err := dbTX.DB().WithContext(ctx).Create(pMsg).Error; err != nil {
 		return nil, err
 	}
@@ -131,6 +178,13 @@ func (gm *groupManager) SendMessage(ctx context.Context, dbTX persistence.DBTX,
 		if err := gm.transportManager.SendReliable(ctx, dbTX, msgs...); err != nil {
 			return nil, err
 		}
+		distributions := make([]*persistedMessageDistribution, len(msgs))
+		for i, rm := range msgs {
+			distributions[i] = &persistedMessageDistribution{Msg: msgID, Node: rm.Node, ReliableMsg: rm.ID}
+		}
+		if err := dbTX.DB().WithContext(ctx).Create(distributions).Error; err != nil {
+			return nil, err
+		}
 	}
 
 	dbTX.AddPostCommit(func(txCtx context.Context) {
@@ -141,12 +195,68 @@ func (gm *groupManager) SendMessage(ctx context.Context, dbTX persistence.DBTX,
 
 }
 
+// Checks the correlation ID of a message being sent refers to a message we already have in the group
+func (gm *groupManager) checkCorrelationID(ctx context.Context, dbTX persistence.DBTX, domainName string, groupID tktypes.HexBytes, cid uuid.UUID) error {
+	var count int64
+	err := dbTX.DB().
+		WithContext(ctx).
+		Model(&persistedMessage{}).
+		Where(`"domain" = ?`, domainName).
+		Where(`"group" = ?`, groupID).
+		Where(`"id" = ?`, cid).
+		Count(&count).
+		Error
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return i18n.NewError(ctx, msgs.MsgPGroupsCorrelationIDNotFound, cid, groupID)
+	}
+	return nil
+}
+
+func (gm *groupManager) initInboundMessages(ctx context.Context) error {
+	gm.unknownGroupAction = confutil.StringNotEmpty(gm.conf.InboundMessages.UnknownGroupAction, *pldconf.GroupManagerDefaults.InboundMessages.UnknownGroupAction)
+	switch gm.unknownGroupAction {
+	case pldconf.UnknownGroupMessageReject, pldconf.UnknownGroupMessageQuarantine:
+		return nil
+	default:
+		return i18n.NewError(ctx, msgs.MsgPGroupsBadUnknownGroupAction, gm.unknownGroupAction)
+	}
+}
+
+// Messages for a domain or group we do not have are never stored alongside the messages of known groups.
+// Depending on configuration they are either rejected back to the sender, or quarantined and accepted.
+func (gm *groupManager) handleUnknownGroupMessage(ctx context.Context, pm *persistedMessage, reason string, err error, results map[uuid.UUID]error, quarantined []*quarantinedMessage) []*quarantinedMessage {
+	log.L(ctx).Errorf("Received message %s from node %s for unknown %s/%s (action=%s): %s", pm.ID, pm.Node, pm.Domain, pm.Group, gm.unknownGroupAction, err)
+	gm.metrics.recordInboundMessageRejected(reason, gm.unknownGroupAction)
+	if gm.unknownGroupAction != pldconf.UnknownGroupMessageQuarantine {
+		results[pm.ID] = err
+		return quarantined
+	}
+	results[pm.ID] = nil // accepted into quarantine
+	return append(quarantined, &quarantinedMessage{
+		Domain:   pm.Domain,
+		Group:    pm.Group,
+		Node:     pm.Node,
+		Sent:     pm.Sent,
+		Received: pm.Received,
+		ID:       pm.ID,
+		CID:      pm.CID,
+		Topic:    pm.Topic,
+		Data:     pm.Data,
+		Reason:   err.Error(),
+	})
+}
+
 func (gm *groupManager) ReceiveMessages(ctx context.Context, dbTX persistence.DBTX, messages []*pldapi.PrivacyGroupMessage) (results map[uuid.UUID]error, err error) {
 
 	results = make(map[uuid.UUID]error)
 	now := tktypes.TimestampNow()
 	pMsgs := make([]*persistedMessage, 0, len(messages))
 	validatedGroups := make(map[string]*pldapi.PrivacyGroup)
+	knownDomains := make(map[string]bool)
+	var quarantined []*quarantinedMessage
 	for _, msg := range messages {
 		pm := &persistedMessage{
 			Domain:   msg.Domain,
@@ -166,17 +276,31 @@ func (gm *groupManager) ReceiveMessages(ctx context.Context, dbTX persistence.DB
 		}
 		mapKey := pm.Domain + "/" + pm.Group.String()
 		if validatedGroups[mapKey] == nil {
+			known, checked := knownDomains[pm.Domain]
+			if !checked {
+				_, err := gm.domainManager.GetDomainByName(ctx, pm.Domain)
+				known = err == nil
+				knownDomains[pm.Domain] = known
+			}
+			if !known {
+				quarantined = gm.handleUnknownGroupMessage(ctx, pm, rejectReasonUnknownDomain,
+					i18n.NewError(ctx, msgs.MsgPGroupsDomainNotFound, pm.Domain), results, quarantined)
+				continue
+			}
 			group, err := gm.GetGroupByID(ctx, dbTX, pm.Domain, pm.Group)
 			if err != nil {
 				return nil, err
 			}
 			if group == nil {
-				log.L(ctx).Errorf("Unable to process received message as group not initialized %s: %s", pm.ID, err)
-				results[pm.ID] = i18n.NewError(ctx, msgs.MsgPGroupsGroupNotFound, pm.Group)
+				quarantined = gm.handleUnknownGroupMessage(ctx, pm, rejectReasonUnknownGroup,
+					i18n.NewError(ctx, msgs.MsgPGroupsGroupNotFound, pm.Group), results, quarantined)
 				continue
 			}
 			validatedGroups[mapKey] = group
 		}
+		if err := gm.storeMessageData(ctx, pm); err != nil {
+			return nil, err
+		}
 		results[pm.ID] = nil // success
 		pMsgs = append(pMsgs, pm)
 	}
@@ -195,6 +319,16 @@ func (gm *groupManager) ReceiveMessages(ctx context.Context, dbTX persistence.DB
 		})
 	}
 
+	if len(quarantined) > 0 {
+		if err := dbTX.DB().
+			WithContext(ctx).
+			Clauses(clause.OnConflict{DoNothing: true}).
+			Create(quarantined).
+			Error; err != nil {
+			return nil, err
+		}
+	}
+
 	return results, nil
 }
 
@@ -205,6 +339,9 @@ func (gm *groupManager) QueryMessages(ctx context.Context, dbTX persistence.DBTX
 		Filters:     messageFilters,
 		Query:       jq,
 		MapResult: func(dbPM *persistedMessage) (*pldapi.PrivacyGroupMessage, error) {
+			if err := gm.loadMessageData(ctx, dbPM); err != nil {
+				return nil, err
+			}
 			return dbPM.mapToAPI(), nil
 		},
 	}
@@ -224,3 +361,85 @@ func (gm *groupManager) GetMessageByID(ctx context.Context, dbTX persistence.DBT
 	}
 	return dbMsgs[0], nil
 }
+
+func (gm *groupManager) GetGroupMessageStats(ctx context.Context, dbTX persistence.DBTX, domainName string, groupID tktypes.HexBytes) (*pldapi.PrivacyGroupMessageStats, error) {
+	var result struct {
+		Count          int64              `gorm:"column:count"`
+		LatestLocalSeq *uint64            `gorm:"column:latest_local_seq"`
+		LatestSent     *tktypes.Timestamp `gorm:"column:latest_sent"`
+	}
+	err := dbTX.DB().
+		WithContext(ctx).
+		Table("pgroup_msgs").
+		Select(`COUNT(*) AS "count", MAX("local_seq") AS "latest_local_seq", MAX("sent") AS "latest_sent"`).
+		Where(`"domain" = ?`, domainName).
+		Where(`"group" = ?`, groupID).
+		Scan(&result).
+		Error
+	if err != nil {
+		return nil, err
+	}
+	return &pldapi.PrivacyGroupMessageStats{
+		Domain:              domainName,
+		Group:               groupID,
+		Count:               result.Count,
+		LatestLocalSequence: result.LatestLocalSeq,
+		LatestSent:          result.LatestSent,
+	}, nil
+}
+
+// The reliable message distributions of a locally sent message to each remote member node
+func (gm *groupManager) getMessageDistributions(ctx context.Context, dbTX persistence.DBTX, msgID uuid.UUID) ([]*pldapi.ReliableMessage, error) {
+	if _, err := gm.GetMessageByID(ctx, dbTX, msgID, true); err != nil {
+		return nil, err
+	}
+	var distributions []*persistedMessageDistribution
+	err := dbTX.DB().WithContext(ctx).
+		Where("msg = ?", msgID).
+		Limit(maxMessageDistributions).
+		Find(&distributions).
+		Error
+	if err != nil || len(distributions) == 0 {
+		return nil, err
+	}
+	rmIDs := make([]any, len(distributions))
+	for i, d := range distributions {
+		rmIDs[i] = d.ReliableMsg
+	}
+	return gm.transportManager.QueryReliableMessages(ctx, dbTX, query.NewQueryBuilder().
+		In("id", rmIDs).
+		Sort("node").
+		Limit(maxMessageDistributions).
+		Query())
+}
+
+func (gm *groupManager) GetMessageDistributionStatus(ctx context.Context, dbTX persistence.DBTX, msgID uuid.UUID) ([]*pldapi.ReliableMessageRetryStatus, error) {
+	rms, err := gm.getMessageDistributions(ctx, dbTX, msgID)
+	if err != nil {
+		return nil, err
+	}
+	statuses := make([]*pldapi.ReliableMessageRetryStatus, len(rms))
+	for i, rm := range rms {
+		if statuses[i], err = gm.transportManager.GetReliableMessageRetryStatus(ctx, dbTX, rm.ID); err != nil {
+			return nil, err
+		}
+	}
+	return statuses, nil
+}
+
+// Forces an immediate retry of every distribution of the message that is not yet acknowledged
+func (gm *groupManager) RetryMessageDistribution(ctx context.Context, dbTX persistence.DBTX, msgID uuid.UUID) error {
+	rms, err := gm.getMessageDistributions(ctx, dbTX, msgID)
+	if err != nil {
+		return err
+	}
+	for _, rm := range rms {
+		if rm.Ack != nil {
+			continue
+		}
+		if err := gm.transportManager.RetryReliableMessageNow(ctx, dbTX, rm.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -22,11 +22,14 @@ import (
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/google/uuid"
+	"github.com/kaleido-io/paladin/config/pkg/confutil"
 	"github.com/kaleido-io/paladin/config/pkg/pldconf"
 	"github.com/kaleido-io/paladin/core/internal/components"
 	"github.com/kaleido-io/paladin/core/pkg/persistence"
 	"github.com/kaleido-io/paladin/toolkit/pkg/pldapi"
+	"github.com/kaleido-io/paladin/toolkit/pkg/query"
 	"github.com/kaleido-io/paladin/toolkit/pkg/tktypes"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 )
@@ -82,6 +85,89 @@ func TestSendMessageGroupFail(t *testing.T) {
 	require.Regexp(t, "pop", err)
 }
 
+func sendMessageInTX(ctx context.Context, gm *groupManager, msg *pldapi.PrivacyGroupMessageInput) (msgID *uuid.UUID, err error) {
+	err = gm.p.Transaction(ctx, func(ctx context.Context, dbTX persistence.DBTX) (err error) {
+		msgID, err = gm.SendMessage(ctx, dbTX, msg)
+		return err
+	})
+	return msgID, err
+}
+
+func testSendCorrelatedMessages(t *testing.T, conf *pldconf.GroupManagerConfig) (validErr, danglingErr error) {
+	ctx, gm, mc, done := newTestGroupManager(t, true, conf)
+	defer done()
+
+	mc.registryManager.On("GetNodeTransports", mock.Anything, "node2").
+		Return([]*components.RegistryNodeTransportEntry{ /* contents not checked */ }, nil)
+	mc.transportManager.On("SendReliable", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	groupIDs := createTestGroups(t, ctx, mc, gm,
+		&pldapi.PrivacyGroupInput{
+			Domain:  "domain1",
+			Members: []string{"me@node1", "you@node2"},
+		},
+	)
+	require.Len(t, groupIDs, 1)
+
+	firstID, err := sendMessageInTX(ctx, gm, &pldapi.PrivacyGroupMessageInput{
+		Domain: "domain1",
+		Group:  groupIDs[0],
+		Topic:  "topic1",
+		Data:   tktypes.JSONString("some data"),
+	})
+	require.NoError(t, err)
+
+	_, validErr = sendMessageInTX(ctx, gm, &pldapi.PrivacyGroupMessageInput{
+		Domain:        "domain1",
+		Group:         groupIDs[0],
+		CorrelationID: firstID,
+		Topic:         "topic1",
+		Data:          tktypes.JSONString("reply"),
+	})
+	_, danglingErr = sendMessageInTX(ctx, gm, &pldapi.PrivacyGroupMessageInput{
+		Domain:        "domain1",
+		Group:         groupIDs[0],
+		CorrelationID: confutil.P(uuid.New()),
+		Topic:         "topic1",
+		Data:          tktypes.JSONString("reply"),
+	})
+	return validErr, danglingErr
+}
+
+func TestSendMessageCorrelationIDStrict(t *testing.T) {
+	validErr, danglingErr := testSendCorrelatedMessages(t, &pldconf.GroupManagerConfig{
+		ValidateCorrelationIDs: confutil.P(true),
+	})
+	require.NoError(t, validErr)
+	require.Regexp(t, "PD012530", danglingErr)
+}
+
+func TestSendMessageCorrelationIDLenient(t *testing.T) {
+	validErr, danglingErr := testSendCorrelatedMessages(t, &pldconf.GroupManagerConfig{})
+	require.NoError(t, validErr)
+	require.NoError(t, danglingErr)
+}
+
+func TestSendMessageCorrelationIDCheckFail(t *testing.T) {
+	ctx, gm, mc, done := newTestGroupManager(t, false, &pldconf.GroupManagerConfig{
+		ValidateCorrelationIDs: confutil.P(true),
+	}, mockEmptyMessageListeners)
+	defer done()
+
+	groupID := tktypes.RandBytes(32)
+	mockDBPrivacyGroup(mc, tktypes.RandBytes32(), groupID, nil)
+	mc.db.Mock.ExpectQuery("SELECT.*pgroup_msgs").WillReturnError(fmt.Errorf("pop"))
+
+	_, err := gm.SendMessage(ctx, gm.p.NOTX(), &pldapi.PrivacyGroupMessageInput{
+		Domain:        "domain1",
+		Data:          tktypes.JSONString("some data"),
+		Group:         groupID,
+		CorrelationID: confutil.P(uuid.New()),
+		Topic:         "topic1",
+	})
+	require.Regexp(t, "pop", err)
+}
+
 func TestReceiveMessageInvalid(t *testing.T) {
 	ctx, gm, mc, done := newTestGroupManager(t, true, &pldconf.GroupManagerConfig{})
 	defer done()
@@ -304,3 +390,425 @@ func TestGetMessageByIDEmptyNull(t *testing.T) {
 	require.Nil(t, msgByID)
 
 }
+
+func TestGetGroupMessageStats(t *testing.T) {
+	ctx, gm, mc, done := newTestGroupManager(t, true, &pldconf.GroupManagerConfig{})
+	defer done()
+
+	mc.registryManager.On("GetNodeTransports", mock.Anything, "node2").
+		Return([]*components.RegistryNodeTransportEntry{ /* contents not checked */ }, nil)
+	mc.transportManager.On("SendReliable", mock.Anything, mock.Anything, mock.MatchedBy(func(rm *pldapi.ReliableMessage) bool {
+		return rm.MessageType.V() == pldapi.RMTPrivacyGroupMessage
+	})).Return(nil)
+
+	groupIDs := createTestGroups(t, ctx, mc, gm,
+		&pldapi.PrivacyGroupInput{
+			Domain:  "domain1",
+			Members: []string{"me@node1", "you@node2"},
+		},
+		&pldapi.PrivacyGroupInput{
+			Domain:  "domain1",
+			Members: []string{"me@node1", "you@node2"},
+		},
+		&pldapi.PrivacyGroupInput{
+			Domain:  "domain1",
+			Members: []string{"me@node1", "you@node2"},
+		},
+	)
+
+	// 3 messages in the first group, 1 in the second, none in the third
+	var lastGroup1, lastGroup2 *pldapi.PrivacyGroupMessage
+	err := gm.p.Transaction(ctx, func(ctx context.Context, dbTX persistence.DBTX) error {
+		for i, groupIdx := range []int{0, 1, 0, 0} {
+			msgID, err := gm.SendMessage(ctx, dbTX, &pldapi.PrivacyGroupMessageInput{
+				Domain: "domain1",
+				Group:  groupIDs[groupIdx],
+				Topic:  fmt.Sprintf("topic.%d", i),
+				Data:   tktypes.JSONString("some data"),
+			})
+			require.NoError(t, err)
+			msg, err := gm.GetMessageByID(ctx, dbTX, *msgID, true)
+			require.NoError(t, err)
+			if groupIdx == 0 {
+				lastGroup1 = msg
+			} else {
+				lastGroup2 = msg
+			}
+		}
+		return nil
+	})
+	require.NoError(t, err)
+
+	stats, err := gm.GetGroupMessageStats(ctx, gm.p.NOTX(), "domain1", groupIDs[0])
+	require.NoError(t, err)
+	require.Equal(t, int64(3), stats.Count)
+	require.Equal(t, lastGroup1.LocalSequence, *stats.LatestLocalSequence)
+	require.Equal(t, lastGroup1.Sent, *stats.LatestSent)
+	require.Equal(t, groupIDs[0], stats.Group)
+
+	stats, err = gm.GetGroupMessageStats(ctx, gm.p.NOTX(), "domain1", groupIDs[1])
+	require.NoError(t, err)
+	require.Equal(t, int64(1), stats.Count)
+	require.Equal(t, lastGroup2.LocalSequence, *stats.LatestLocalSequence)
+	require.Equal(t, lastGroup2.Sent, *stats.LatestSent)
+
+	stats, err = gm.GetGroupMessageStats(ctx, gm.p.NOTX(), "domain1", groupIDs[2])
+	require.NoError(t, err)
+	require.Zero(t, stats.Count)
+	require.Nil(t, stats.LatestLocalSequence)
+	require.Nil(t, stats.LatestSent)
+
+	// same group ID in a different domain is isolated
+	stats, err = gm.GetGroupMessageStats(ctx, gm.p.NOTX(), "domain2", groupIDs[0])
+	require.NoError(t, err)
+	require.Zero(t, stats.Count)
+}
+
+func TestGetGroupMessageStatsFail(t *testing.T) {
+	ctx, gm, mc, done := newTestGroupManager(t, false, &pldconf.GroupManagerConfig{}, mockEmptyMessageListeners)
+	defer done()
+
+	mc.db.Mock.ExpectQuery("SELECT.*pgroup_msgs").WillReturnError(fmt.Errorf("pop"))
+
+	_, err := gm.GetGroupMessageStats(ctx, gm.p.NOTX(), "domain1", tktypes.RandBytes(32))
+	require.Regexp(t, "pop", err)
+}
+
+func TestMessageDistributionStatusAndRetry(t *testing.T) {
+	ctx, gm, mc, done := newTestGroupManager(t, true, &pldconf.GroupManagerConfig{})
+	defer done()
+
+	mc.registryManager.On("GetNodeTransports", mock.Anything, "node2").
+		Return([]*components.RegistryNodeTransportEntry{ /* contents not checked */ }, nil)
+
+	groupIDs := createTestGroups(t, ctx, mc, gm,
+		&pldapi.PrivacyGroupInput{
+			Domain:  "domain1",
+			Members: []string{"me@node1", "you@node2"},
+		},
+	)
+
+	// The ID assigned by the transport manager is recorded against the message
+	rmNode2 := &pldapi.ReliableMessage{ID: uuid.New(), Node: "node2"}
+	mc.transportManager.On("SendReliable", mock.Anything, mock.Anything, mock.MatchedBy(func(rm *pldapi.ReliableMessage) bool {
+		return rm.MessageType.V() == pldapi.RMTPrivacyGroupMessage
+	})).Run(func(args mock.Arguments) {
+		args[2].(*pldapi.ReliableMessage).ID = rmNode2.ID
+	}).Return(nil)
+
+	msgID, err := sendMessageInTX(ctx, gm, &pldapi.PrivacyGroupMessageInput{
+		Domain: "domain1",
+		Group:  groupIDs[0],
+		Topic:  "my/topic",
+		Data:   tktypes.JSONString("some data"),
+	})
+	require.NoError(t, err)
+
+	// and the distributions are looked up by that ID
+	mc.transportManager.On("QueryReliableMessages", mock.Anything, mock.Anything, mock.MatchedBy(func(jq *query.QueryJSON) bool {
+		return len(jq.In) == 1 && len(jq.In[0].Values) == 1 && jq.In[0].Values[0].StringValue() == rmNode2.ID.String()
+	})).Return([]*pldapi.ReliableMessage{rmNode2}, nil)
+	mc.transportManager.On("GetReliableMessageRetryStatus", mock.Anything, mock.Anything, rmNode2.ID).
+		Return(&pldapi.ReliableMessageRetryStatus{MessageID: rmNode2.ID, Node: "node2", Attempts: 5, RetryFailures: 5}, nil)
+	mc.transportManager.On("RetryReliableMessageNow", mock.Anything, mock.Anything, rmNode2.ID).Return(nil).Once()
+
+	statuses, err := gm.GetMessageDistributionStatus(ctx, gm.p.NOTX(), *msgID)
+	require.NoError(t, err)
+	require.Len(t, statuses, 1)
+	require.Equal(t, "node2", statuses[0].Node)
+	require.Equal(t, 5, statuses[0].RetryFailures)
+
+	// An unacknowledged distribution is retried
+	err = gm.RetryMessageDistribution(ctx, gm.p.NOTX(), *msgID)
+	require.NoError(t, err)
+	mc.transportManager.AssertNumberOfCalls(t, "RetryReliableMessageNow", 1)
+
+	// but not once it is acknowledged
+	rmNode2.Ack = &pldapi.ReliableMessageAckNoMsgID{Time: tktypes.TimestampNow()}
+	err = gm.RetryMessageDistribution(ctx, gm.p.NOTX(), *msgID)
+	require.NoError(t, err)
+	mc.transportManager.AssertNumberOfCalls(t, "RetryReliableMessageNow", 1)
+}
+
+func TestMessageDistributionStatusNotDistributed(t *testing.T) {
+	ctx, gm, mc, done := newTestGroupManager(t, false, &pldconf.GroupManagerConfig{}, mockEmptyMessageListeners)
+	defer done()
+
+	msgID := uuid.New()
+	mc.db.Mock.ExpectQuery("SELECT.*pgroup_msgs").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(msgID))
+	mc.db.Mock.ExpectQuery("SELECT.*pgroup_msg_distributions").WillReturnRows(sqlmock.NewRows([]string{}))
+
+	statuses, err := gm.GetMessageDistributionStatus(ctx, gm.p.NOTX(), msgID)
+	require.NoError(t, err)
+	assert.Empty(t, statuses)
+}
+
+func TestMessageDistributionStatusMessageNotFound(t *testing.T) {
+	ctx, gm, mc, done := newTestGroupManager(t, false, &pldconf.GroupManagerConfig{}, mockEmptyMessageListeners)
+	defer done()
+
+	mc.db.Mock.ExpectQuery("SELECT.*pgroup_msgs").WillReturnRows(sqlmock.NewRows([]string{}))
+	mc.db.Mock.ExpectQuery("SELECT.*pgroup_msgs").WillReturnRows(sqlmock.NewRows([]string{}))
+
+	_, err := gm.GetMessageDistributionStatus(ctx, gm.p.NOTX(), uuid.New())
+	require.Regexp(t, "PD012513", err)
+
+	err = gm.RetryMessageDistribution(ctx, gm.p.NOTX(), uuid.New())
+	require.Regexp(t, "PD012513", err)
+}
+
+func TestMessageDistributionStatusFail(t *testing.T) {
+	ctx, gm, mc, done := newTestGroupManager(t, false, &pldconf.GroupManagerConfig{}, mockEmptyMessageListeners)
+	defer done()
+
+	msgID := uuid.New()
+	mc.db.Mock.ExpectQuery("SELECT.*pgroup_msgs").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(msgID))
+	mc.db.Mock.ExpectQuery("SELECT.*pgroup_msg_distributions").WillReturnRows(sqlmock.NewRows([]string{"msg", "node", "reliable_msg"}).AddRow(msgID, "node2", uuid.New()))
+	mc.transportManager.On("QueryReliableMessages", mock.Anything, mock.Anything, mock.Anything).
+		Return([]*pldapi.ReliableMessage{{ID: uuid.New()}}, nil)
+	mc.transportManager.On("GetReliableMessageRetryStatus", mock.Anything, mock.Anything, mock.Anything).
+		Return(nil, fmt.Errorf("pop"))
+
+	_, err := gm.GetMessageDistributionStatus(ctx, gm.p.NOTX(), msgID)
+	require.Regexp(t, "pop", err)
+}
+
+func TestMessageDistributionLookupFail(t *testing.T) {
+	ctx, gm, mc, done := newTestGroupManager(t, false, &pldconf.GroupManagerConfig{}, mockEmptyMessageListeners)
+	defer done()
+
+	msgID := uuid.New()
+	mc.db.Mock.ExpectQuery("SELECT.*pgroup_msgs").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(msgID))
+	mc.db.Mock.ExpectQuery("SELECT.*pgroup_msg_distributions").WillReturnError(fmt.Errorf("pop"))
+
+	err := gm.RetryMessageDistribution(ctx, gm.p.NOTX(), msgID)
+	require.Regexp(t, "pop", err)
+}
+
+func TestSendReceiveMessagesAsSimulatedNodes(t *testing.T) {
+	ctx, gm, mc, done := newTestGroupManager(t, true, &pldconf.GroupManagerConfig{})
+	defer done()
+
+	for _, node := range []string{"node1", "node2"} {
+		mc.registryManager.On("GetNodeTransports", mock.Anything, node).
+			Return([]*components.RegistryNodeTransportEntry{ /* contents not checked */ }, nil).Maybe()
+	}
+	var sentTo []string
+	mc.transportManager.On("SendReliable", mock.Anything, mock.Anything, mock.MatchedBy(func(rm *pldapi.ReliableMessage) bool {
+		return rm.MessageType.V() == pldapi.RMTPrivacyGroupMessage
+	})).Run(func(args mock.Arguments) {
+		sentTo = append(sentTo, args[2].(*pldapi.ReliableMessage).Node)
+	}).Return(nil)
+
+	groupIDs := createTestGroups(t, ctx, mc, gm,
+		&pldapi.PrivacyGroupInput{
+			Domain:  "domain1",
+			Members: []string{"me@node1", "you@node2"},
+		},
+	)
+
+	sendAs := func(node string) *pldapi.PrivacyGroupMessage {
+		gm.testOnlyLocalNodeName = node
+		msgID, err := sendMessageInTX(ctx, gm, &pldapi.PrivacyGroupMessageInput{
+			Domain: "domain1",
+			Group:  groupIDs[0],
+			Topic:  "my/topic",
+			Data:   tktypes.JSONString("from " + node),
+		})
+		require.NoError(t, err)
+		msg, err := gm.GetMessageByID(ctx, gm.p.NOTX(), *msgID, true)
+		require.NoError(t, err)
+		return msg
+	}
+
+	// Each logical node stamps its own name, and distributes to the other
+	msg1 := sendAs("node1")
+	msg2 := sendAs("node2")
+	assert.Equal(t, "node1", msg1.Node)
+	assert.Equal(t, "node2", msg2.Node)
+	assert.Equal(t, []string{"node2", "node1"}, sentTo)
+
+	// A listener excluding local messages sees only those from the other logical node
+	l := &messageListener{gm: gm, spec: &pldapi.PrivacyGroupMessageListener{
+		Options: pldapi.PrivacyGroupMessageListenerOptions{ExcludeLocal: true},
+	}}
+	for node, expected := range map[string]*pldapi.PrivacyGroupMessage{"node1": msg2, "node2": msg1} {
+		gm.testOnlyLocalNodeName = node
+		var pMsgs []*persistedMessage
+		err := gm.buildListenerDBQuery(l.spec, gm.p.DB().WithContext(ctx)).Find(&pMsgs).Error
+		require.NoError(t, err)
+		require.Len(t, pMsgs, 1)
+		assert.Equal(t, expected.ID, pMsgs[0].ID)
+		assert.True(t, l.checkMatch(pMsgs[0]))
+	}
+
+	// Without the override, the node name comes from the transport manager
+	gm.testOnlyLocalNodeName = ""
+	assert.Equal(t, "node1", gm.localNodeName())
+}
+
+func gatherRejectedInboundMessages(t *testing.T, mc *mockComponents, reason, action string) (count float64) {
+	families, err := mc.metricsManager.Registry().Gather()
+	require.NoError(t, err)
+	for _, mf := range families {
+		for _, metric := range mf.GetMetric() {
+			labels := map[string]string{}
+			for _, l := range metric.GetLabel() {
+				labels[l.GetName()] = l.GetValue()
+			}
+			if mf.GetName() == metricsInboundMessagesRejected && labels[metricsReasonLabel] == reason && labels[metricsActionLabel] == action {
+				count = metric.GetCounter().GetValue()
+			}
+		}
+	}
+	return count
+}
+
+func newTestReceivedMessage(domain string, group tktypes.HexBytes) *pldapi.PrivacyGroupMessage {
+	return &pldapi.PrivacyGroupMessage{
+		Sent:     tktypes.TimestampNow(),
+		Received: tktypes.TimestampNow(),
+		Node:     "node2",
+		ID:       uuid.New(),
+		PrivacyGroupMessageInput: pldapi.PrivacyGroupMessageInput{
+			Domain: domain,
+			Data:   tktypes.JSONString("some data"),
+			Group:  group,
+			Topic:  "topic1",
+		},
+	}
+}
+
+func TestReceiveMessagesUnknownDomainReject(t *testing.T) {
+	ctx, gm, mc, done := newTestGroupManager(t, false, &pldconf.GroupManagerConfig{}, mockEmptyMessageListeners)
+	defer done()
+
+	mc.domainManager.On("GetDomainByName", mock.Anything, "domain2").Return(nil, fmt.Errorf("not found")).Once()
+	mc.db.Mock.ExpectBegin()
+	mc.db.Mock.ExpectCommit()
+
+	msg1 := newTestReceivedMessage("domain2", tktypes.RandBytes(32))
+	msg2 := newTestReceivedMessage("domain2", tktypes.RandBytes(32))
+	err := gm.p.Transaction(ctx, func(ctx context.Context, dbTX persistence.DBTX) error {
+		results, err := gm.ReceiveMessages(ctx, dbTX, []*pldapi.PrivacyGroupMessage{msg1, msg2})
+		require.Regexp(t, "PD012534", results[msg1.ID])
+		require.Regexp(t, "PD012534", results[msg2.ID])
+		return err
+	})
+	require.NoError(t, err)
+	assert.Equal(t, float64(2), gatherRejectedInboundMessages(t, mc, rejectReasonUnknownDomain, pldconf.UnknownGroupMessageReject))
+	assert.NoError(t, mc.db.Mock.ExpectationsWereMet())
+}
+
+func TestReceiveMessagesUnknownGroupQuarantine(t *testing.T) {
+	ctx, gm, mc, done := newTestGroupManager(t, false, &pldconf.GroupManagerConfig{
+		InboundMessages: pldconf.GroupInboundMessagesConfig{
+			UnknownGroupAction: confutil.P(pldconf.UnknownGroupMessageQuarantine),
+		},
+	}, mockEmptyMessageListeners)
+	defer done()
+
+	mc.db.Mock.ExpectBegin()
+	mc.db.Mock.ExpectQuery("SELECT.*privacy_groups").WillReturnRows(sqlmock.NewRows([]string{}))
+	mc.db.Mock.ExpectQuery("INSERT.*pgroup_msgs_quarantine").WillReturnRows(sqlmock.NewRows([]string{"local_seq"}).AddRow(1))
+	mc.db.Mock.ExpectCommit()
+
+	msg := newTestReceivedMessage("domain1", tktypes.RandBytes(32))
+	err := gm.p.Transaction(ctx, func(ctx context.Context, dbTX persistence.DBTX) error {
+		results, err := gm.ReceiveMessages(ctx, dbTX, []*pldapi.PrivacyGroupMessage{msg})
+		require.Contains(t, results, msg.ID)
+		require.Nil(t, results[msg.ID])
+		return err
+	})
+	require.NoError(t, err)
+	assert.Equal(t, float64(1), gatherRejectedInboundMessages(t, mc, rejectReasonUnknownGroup, pldconf.UnknownGroupMessageQuarantine))
+	assert.NoError(t, mc.db.Mock.ExpectationsWereMet())
+}
+
+func TestReceiveMessagesQuarantineFail(t *testing.T) {
+	ctx, gm, mc, done := newTestGroupManager(t, false, &pldconf.GroupManagerConfig{
+		InboundMessages: pldconf.GroupInboundMessagesConfig{
+			UnknownGroupAction: confutil.P(pldconf.UnknownGroupMessageQuarantine),
+		},
+	}, mockEmptyMessageListeners)
+	defer done()
+
+	mc.db.Mock.ExpectBegin()
+	mc.db.Mock.ExpectQuery("SELECT.*privacy_groups").WillReturnRows(sqlmock.NewRows([]string{}))
+	mc.db.Mock.ExpectQuery("INSERT.*pgroup_msgs_quarantine").WillReturnError(fmt.Errorf("pop"))
+	mc.db.Mock.ExpectRollback()
+
+	err := gm.p.Transaction(ctx, func(ctx context.Context, dbTX persistence.DBTX) error {
+		_, err := gm.ReceiveMessages(ctx, dbTX, []*pldapi.PrivacyGroupMessage{
+			newTestReceivedMessage("domain1", tktypes.RandBytes(32)),
+		})
+		return err
+	})
+	require.Regexp(t, "pop", err)
+}
+
+func TestReceiveMessagesBadUnknownGroupAction(t *testing.T) {
+	mc := newMockComponents(t, false)
+	gm := NewGroupManager(context.Background(), &pldconf.GroupManagerConfig{
+		InboundMessages: pldconf.GroupInboundMessagesConfig{
+			UnknownGroupAction: confutil.P("ignore"),
+		},
+	})
+	_, err := gm.PreInit(mc.c)
+	require.NoError(t, err)
+	err = gm.PostInit(mc.c)
+	assert.Regexp(t, "PD012535", err)
+}
+
+func TestReceiveMessagesKnownGroupAcceptedUnknownQuarantined(t *testing.T) {
+	ctx, gm, mc, done := newTestGroupManager(t, true, &pldconf.GroupManagerConfig{
+		InboundMessages: pldconf.GroupInboundMessagesConfig{
+			UnknownGroupAction: confutil.P(pldconf.UnknownGroupMessageQuarantine),
+		},
+	})
+	defer done()
+
+	mc.registryManager.On("GetNodeTransports", mock.Anything, "node2").
+		Return([]*components.RegistryNodeTransportEntry{ /* contents not checked */ }, nil)
+	mc.domainManager.On("GetDomainByName", mock.Anything, "domain2").Return(nil, fmt.Errorf("not found"))
+
+	groupIDs := createTestGroups(t, ctx, mc, gm,
+		&pldapi.PrivacyGroupInput{
+			Domain:  "domain1",
+			Members: []string{"me@node1", "you@node2"},
+		},
+	)
+
+	knownMsg := newTestReceivedMessage("domain1", groupIDs[0])
+	unknownGroupMsg := newTestReceivedMessage("domain1", tktypes.RandBytes(32))
+	unknownDomainMsg := newTestReceivedMessage("domain2", groupIDs[0])
+	err := gm.p.Transaction(ctx, func(ctx context.Context, dbTX persistence.DBTX) error {
+		results, err := gm.ReceiveMessages(ctx, dbTX, []*pldapi.PrivacyGroupMessage{knownMsg, unknownGroupMsg, unknownDomainMsg})
+		require.NoError(t, err)
+		require.Len(t, results, 3)
+		for _, r := range results {
+			require.NoError(t, r)
+		}
+		return nil
+	})
+	require.NoError(t, err)
+
+	// Only the message for the known group is stored as a group message
+	stored, err := gm.QueryMessages(ctx, gm.p.NOTX(), query.NewQueryBuilder().Limit(10).Query())
+	require.NoError(t, err)
+	require.Len(t, stored, 1)
+	assert.Equal(t, knownMsg.ID, stored[0].ID)
+
+	var quarantined []*quarantinedMessage
+	err = gm.p.DB().Order("local_seq").Find(&quarantined).Error
+	require.NoError(t, err)
+	require.Len(t, quarantined, 2)
+	assert.Equal(t, unknownGroupMsg.ID, quarantined[0].ID)
+	assert.Regexp(t, "PD012502", quarantined[0].Reason)
+	assert.Equal(t, unknownDomainMsg.ID, quarantined[1].ID)
+	assert.Regexp(t, "PD012534", quarantined[1].Reason)
+	assert.JSONEq(t, `"some data"`, quarantined[1].Data.String())
+
+	assert.Equal(t, float64(1), gatherRejectedInboundMessages(t, mc, rejectReasonUnknownGroup, pldconf.UnknownGroupMessageQuarantine))
+	assert.Equal(t, float64(1), gatherRejectedInboundMessages(t, mc, rejectReasonUnknownDomain, pldconf.UnknownGroupMessageQuarantine))
+}
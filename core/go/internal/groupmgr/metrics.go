@@ -0,0 +1,54 @@
+/*
+ * Copyright © 2024 Kaleido, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with
+ * the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package groupmgr
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	metricsInboundMessagesRejected = "paladin_groupmgr_inbound_messages_rejected_total"
+	metricsReasonLabel             = "reason"
+	metricsActionLabel             = "action"
+
+	rejectReasonUnknownDomain = "unknown_domain"
+	rejectReasonUnknownGroup  = "unknown_group"
+)
+
+type groupManagerMetrics struct {
+	inboundMessagesRejected *prometheus.CounterVec
+}
+
+func newGroupManagerMetrics() *groupManagerMetrics {
+	m := &groupManagerMetrics{
+		inboundMessagesRejected: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: metricsInboundMessagesRejected,
+			Help: "Number of messages received from other nodes for a domain or privacy group that does not exist locally, by reason and the action taken",
+		}, []string{metricsReasonLabel, metricsActionLabel}),
+	}
+	return m
+}
+
+func (m *groupManagerMetrics) register(registry prometheus.Registerer) {
+	registry.MustRegister(m.inboundMessagesRejected)
+}
+
+func (m *groupManagerMetrics) recordInboundMessageRejected(reason, action string) {
+	if m == nil {
+		return
+	}
+	m.inboundMessagesRejected.WithLabelValues(reason, action).Inc()
+}
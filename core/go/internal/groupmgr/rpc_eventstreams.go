@@ -17,6 +17,8 @@ package groupmgr
 
 import (
 	"context"
+	"encoding/json"
+	"strings"
 	"sync"
 
 	"github.com/kaleido-io/paladin/core/internal/components"
@@ -75,7 +77,8 @@ func (es *rpcEventStreams) HandleStart(ctx context.Context, req *rpcclient.RPCRe
 		return nil, rpcclient.NewRPCErrorResponse(err, req.ID, rpcclient.RPCCodeInvalidRequest)
 	}
 
-	// Only one type right now
+	// Only one type right now, which requires either the name of a listener, or a set of filters
+	// for an ephemeral listener that exists only for the life of this subscription
 	if len(req.Params) < 2 {
 		return nil, rpcclient.NewRPCErrorResponse(i18n.NewError(ctx, msgs.MsgPGroupsListenerNameRequired), req.ID, rpcclient.RPCCodeInvalidRequest)
 	}
@@ -87,7 +90,14 @@ func (es *rpcEventStreams) HandleStart(ctx context.Context, req *rpcclient.RPCRe
 	}
 	es.receiptSubs[ctrl.ID()] = sub
 	var err error
-	sub.pgmrc, err = es.gm.AddMessageReceiver(ctx, req.Params[1].StringValue(), sub)
+	if strings.HasPrefix(strings.TrimSpace(req.Params[1].String()), "{") {
+		var filters pldapi.PrivacyGroupMessageListenerFilters
+		if err = json.Unmarshal(req.Params[1], &filters); err == nil {
+			sub.pgmrc, err = es.gm.addEphemeralMessageReceiver(ctx, ctrl.ID(), &filters, sub)
+		}
+	} else {
+		sub.pgmrc, err = es.gm.AddMessageReceiver(ctx, req.Params[1].StringValue(), sub)
+	}
 	if err != nil {
 		return nil, rpcclient.NewRPCErrorResponse(err, req.ID, rpcclient.RPCCodeInvalidRequest)
 	}
@@ -190,10 +200,11 @@ func (sub *receiptListenerSubscription) ConnectionClosed() {
 
 func (es *rpcEventStreams) cleanupLocked(sub *receiptListenerSubscription) {
 	delete(sub.es.receiptSubs, sub.ctrl.ID())
+	// Release any in-flight delivery first, as closing an ephemeral receiver waits for its listener to stop
+	close(sub.closed)
 	if sub.pgmrc != nil {
 		sub.pgmrc.Close()
 	}
-	close(sub.closed)
 }
 
 func (es *rpcEventStreams) stop() {
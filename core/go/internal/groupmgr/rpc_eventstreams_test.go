@@ -353,6 +353,118 @@ func TestRPCEventListenerE2ENack(t *testing.T) {
 
 }
 
+func TestRPCEventListenerE2EEphemeralFilters(t *testing.T) {
+	ctx, url, gm, mc, done := newTestGroupManagerWithWebSocketRPC(t)
+	defer done()
+
+	mc.registryManager.On("GetNodeTransports", mock.Anything, "node2").
+		Return([]*components.RegistryNodeTransportEntry{ /* contents not checked */ }, nil)
+
+	mc.transportManager.On("SendReliable", mock.Anything, mock.Anything, mock.MatchedBy(func(rm *pldapi.ReliableMessage) bool {
+		return rm.MessageType.V() == pldapi.RMTPrivacyGroupMessage
+	})).Return(nil)
+
+	groupIDs := createTestGroups(t, ctx, mc, gm,
+		&pldapi.PrivacyGroupInput{
+			Domain:  "domain1",
+			Members: []string{"me@node1", "you@node2"},
+		},
+	)
+	groupID := groupIDs[0]
+
+	sendMessage := func(topic string) uuid.UUID {
+		var msgID *uuid.UUID
+		err := gm.p.Transaction(ctx, func(ctx context.Context, dbTX persistence.DBTX) (err error) {
+			msgID, err = gm.SendMessage(ctx, dbTX, &pldapi.PrivacyGroupMessageInput{
+				Domain: "domain1",
+				Group:  groupID,
+				Data:   tktypes.JSONString("some data"),
+				Topic:  topic,
+			})
+			return err
+		})
+		require.NoError(t, err)
+		return *msgID
+	}
+
+	// Sent before we subscribe, so not delivered
+	sendMessage("my/topic")
+
+	wscConf, err := rpcclient.ParseWSConfig(ctx, &pldconf.WSClientConfig{
+		HTTPClientConfig: pldconf.HTTPClientConfig{URL: url},
+	})
+	require.NoError(t, err)
+
+	wsc, err := wsclient.New(ctx, wscConf, nil, nil)
+	require.NoError(t, err)
+	err = wsc.Connect()
+	require.NoError(t, err)
+
+	subReqID, req := rpcTestRequest("pgroup_subscribe", "messages", &pldapi.PrivacyGroupMessageListenerFilters{
+		Domain: "domain1",
+		Group:  groupID,
+		Topic:  "my/.*",
+	})
+	err = wsc.Send(ctx, req)
+	require.NoError(t, err)
+
+	subIDChan := make(chan string)
+	messages := make(chan *pldapi.PrivacyGroupMessage)
+	var subID atomic.Pointer[string]
+
+	go func() {
+		for payload := range wsc.Receive() {
+			var rpcPayload *rpcclient.RPCResponse
+			err := json.Unmarshal(payload, &rpcPayload)
+			require.NoError(t, err)
+
+			if rpcPayload.Error != nil {
+				require.NoError(t, rpcPayload.Error)
+			}
+
+			if !rpcPayload.ID.IsNil() {
+				var rpcID uint64
+				err := json.Unmarshal(rpcPayload.ID.Bytes(), &rpcID)
+				require.NoError(t, err)
+				if rpcID == subReqID {
+					subIDChan <- rpcPayload.Result.StringValue()
+				}
+			}
+
+			if rpcPayload.Method == "pgroup_subscription" {
+				var batchPayload pldapi.JSONRPCSubscriptionNotification[pldapi.PrivacyGroupMessageBatch]
+				err := json.Unmarshal(rpcPayload.Params.Bytes(), &batchPayload)
+				require.NoError(t, err)
+
+				for _, r := range batchPayload.Result.Messages {
+					messages <- r
+				}
+
+				_, req := rpcTestRequest("pgroup_ack", *subID.Load())
+				err = wsc.Send(ctx, req)
+				require.NoError(t, err)
+			}
+		}
+	}()
+
+	subIDStr := <-subIDChan
+	subID.Store(&subIDStr)
+
+	// Only the matching message is delivered
+	sendMessage("other/topic")
+	msgID := sendMessage("my/topic")
+	require.Equal(t, msgID, (<-messages).ID)
+
+	// Disconnecting cleans up the subscription, and the ephemeral listener
+	wsc.Close()
+	require.Eventually(t, func() bool {
+		gm.messageListenerLock.Lock()
+		defer gm.messageListenerLock.Unlock()
+		return len(gm.ephemeralListeners) == 0 && gm.rpcEventStreams.getSubscription(subIDStr) == nil
+	}, 5*time.Second, 10*time.Millisecond)
+
+}
+
 func TestRPCSubscribeNoType(t *testing.T) {
 	ctx, url, _, _, done := newTestGroupManagerWithWebSocketRPC(t)
 	defer done()
@@ -437,6 +549,37 @@ func TestRPCSubscribeBadListener(t *testing.T) {
 
 }
 
+func TestRPCSubscribeBadFilters(t *testing.T) {
+	ctx, url, gm, _, done := newTestGroupManagerWithWebSocketRPC(t)
+	defer done()
+
+	wscConf, err := rpcclient.ParseWSConfig(ctx, &pldconf.WSClientConfig{
+		HTTPClientConfig: pldconf.HTTPClientConfig{URL: url},
+	})
+	require.NoError(t, err)
+
+	wsc, err := wsclient.New(ctx, wscConf, nil, nil)
+	require.NoError(t, err)
+	err = wsc.Connect()
+	require.NoError(t, err)
+	defer wsc.Close()
+
+	_, req := rpcTestRequest("pgroup_subscribe", "messages", &pldapi.PrivacyGroupMessageListenerFilters{
+		Topic: "[",
+	})
+	err = wsc.Send(ctx, req)
+	require.NoError(t, err)
+
+	payload := <-wsc.Receive()
+
+	var rpcPayload *rpcclient.RPCResponse
+	err = json.Unmarshal(payload, &rpcPayload)
+	require.NoError(t, err)
+	require.Regexp(t, "PD012509", rpcPayload.Error.Error())
+	require.Empty(t, gm.ephemeralListeners)
+
+}
+
 func TestUnsubscribeNoSubscriptionID(t *testing.T) {
 	ctx, url, _, _, done := newTestGroupManagerWithWebSocketRPC(t)
 	defer done()
@@ -0,0 +1,206 @@
+/*
+ * Copyright © 2024 Kaleido, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with
+ * the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package groupmgr
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/kaleido-io/paladin/config/pkg/confutil"
+	"github.com/kaleido-io/paladin/config/pkg/pldconf"
+	"github.com/kaleido-io/paladin/core/internal/msgs"
+	"github.com/kaleido-io/paladin/core/pkg/persistence"
+	"github.com/kaleido-io/paladin/toolkit/pkg/i18n"
+	"github.com/kaleido-io/paladin/toolkit/pkg/log"
+	"github.com/kaleido-io/paladin/toolkit/pkg/pldapi"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const searchIndexPrefix = "pgroup_msgs_search"
+
+// Data field names are built into the SQL of the search (and the full-text index), so are restricted to simple identifiers
+var searchDataFieldRegex = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+func (gm *groupManager) initSearch(ctx context.Context) error {
+	gm.searchEnabled = confutil.Bool(gm.conf.Search.Enabled, *pldconf.GroupManagerDefaults.Search.Enabled)
+	gm.searchDefaultLimit = confutil.IntMin(gm.conf.Search.DefaultLimit, 1, *pldconf.GroupManagerDefaults.Search.DefaultLimit)
+	if !gm.searchEnabled {
+		return nil
+	}
+	for _, field := range gm.conf.Search.DataFields {
+		if !searchDataFieldRegex.MatchString(field) {
+			return i18n.NewError(ctx, msgs.MsgPGroupsSearchBadDataField, field)
+		}
+	}
+
+	gm.searchFullText = gm.p.DB().Dialector.Name() == persistence.TypePostgres
+	if !gm.searchFullText {
+		gm.searchDocumentSQL = buildSearchDocumentSQL(gm.conf.Search.DataFields, `json_extract("data", '$.%s')`)
+		log.L(ctx).Infof("Message search enabled using substring matching")
+		return nil
+	}
+
+	// The index expression must exactly match the one used in the query, for the index to be used
+	gm.searchDocumentSQL = `to_tsvector('simple', ` +
+		buildSearchDocumentSQL(gm.conf.Search.DataFields, `("data"::jsonb) ->> '%s'`) + `)`
+	indexName, err := gm.initSearchIndex(ctx)
+	if err != nil {
+		return err
+	}
+	log.L(ctx).Infof("Message search enabled using full-text index %s", indexName)
+	return nil
+}
+
+func searchIndexName(documentSQL string) string {
+	exprHash := sha256.Sum256([]byte(documentSQL))
+	return searchIndexPrefix + "_" + hex.EncodeToString(exprHash[0:8])
+}
+
+type searchIndexInfo struct {
+	Name  string `gorm:"column:name"`
+	Valid bool   `gorm:"column:valid"`
+}
+
+// The full-text index is named by a hash of its expression, so a change to the configured data fields builds a new
+// index, rather than the stale one being kept and searches falling back to a sequential scan. Indexes are built
+// and dropped concurrently, so writes to the messages are not blocked while that happens.
+func (gm *groupManager) initSearchIndex(ctx context.Context) (string, error) {
+	indexName := searchIndexName(gm.searchDocumentSQL)
+
+	db := gm.p.DB().WithContext(ctx)
+	var indexes []*searchIndexInfo
+	err := db.
+		Raw(`SELECT c.relname AS "name", i.indisvalid AS "valid" FROM pg_index i JOIN pg_class c ON c.oid = i.indexrelid WHERE i.indrelid = 'pgroup_msgs'::regclass`).
+		Scan(&indexes).
+		Error
+	if err != nil {
+		return "", err
+	}
+	indexReady := false
+	for _, idx := range indexes {
+		if !strings.HasPrefix(idx.Name, searchIndexPrefix) {
+			continue
+		}
+		if idx.Name == indexName && idx.Valid {
+			indexReady = true
+			continue
+		}
+		// Either built for a different configuration, or left invalid by an interrupted concurrent build
+		log.L(ctx).Infof("Dropping message search index %s (valid=%t)", idx.Name, idx.Valid)
+		if err := db.Exec(`DROP INDEX CONCURRENTLY IF EXISTS "` + idx.Name + `"`).Error; err != nil {
+			return "", err
+		}
+	}
+	if !indexReady {
+		log.L(ctx).Infof("Building message search index %s", indexName)
+		err = db.Exec(`CREATE INDEX CONCURRENTLY IF NOT EXISTS "` + indexName + `" ON pgroup_msgs USING GIN (` + gm.searchDocumentSQL + `)`).Error
+	}
+	return indexName, err
+}
+
+// Concatenates the topic with the searched data fields (or the whole data), separated by spaces
+func buildSearchDocumentSQL(dataFields []string, fieldExprFormat string) string {
+	parts := []string{`"topic"`}
+	if len(dataFields) == 0 {
+		parts = append(parts, `"data"`)
+	}
+	for _, field := range dataFields {
+		parts = append(parts, `COALESCE(`+fmt.Sprintf(fieldExprFormat, field)+`, '')`)
+	}
+	return strings.Join(parts, ` || ' ' || `)
+}
+
+// Returns messages matching the search text, most relevant first, and then most recent first.
+func (gm *groupManager) SearchMessages(ctx context.Context, dbTX persistence.DBTX, search *pldapi.PrivacyGroupMessageSearch) ([]*pldapi.PrivacyGroupMessage, error) {
+	if !gm.searchEnabled {
+		return nil, i18n.NewError(ctx, msgs.MsgPGroupsSearchNotEnabled)
+	}
+	terms := strings.Fields(search.Text)
+	if len(terms) == 0 {
+		return nil, i18n.NewError(ctx, msgs.MsgPGroupsSearchTextEmpty)
+	}
+
+	q := dbTX.DB().WithContext(ctx).Model(&persistedMessage{})
+	if search.Domain != "" {
+		q = q.Where(`"domain" = ?`, search.Domain)
+	}
+	if search.Group != nil {
+		q = q.Where(`"group" = ?`, search.Group)
+	}
+	var rank clause.Expr
+	if gm.searchFullText {
+		q, rank = gm.fullTextSearch(q, search.Text)
+	} else {
+		q, rank = gm.substringSearch(q, terms)
+	}
+	// A single expression is used for the whole ordering, as gorm does not merge expressions with columns
+	q = q.Order(clause.OrderBy{Expression: clause.Expr{
+		SQL:                rank.SQL + ` DESC, "local_seq" DESC`,
+		Vars:               rank.Vars,
+		WithoutParentheses: true,
+	}})
+
+	var dbMsgs []*persistedMessage
+	err := q.
+		Limit(confutil.IntMin(search.Limit, 1, gm.searchDefaultLimit)).
+		Find(&dbMsgs).
+		Error
+	if err != nil {
+		return nil, err
+	}
+	results := make([]*pldapi.PrivacyGroupMessage, len(dbMsgs))
+	for i, dbPM := range dbMsgs {
+		if err := gm.loadMessageData(ctx, dbPM); err != nil {
+			return nil, err
+		}
+		results[i] = dbPM.mapToAPI()
+	}
+	return results, nil
+}
+
+func (gm *groupManager) fullTextSearch(q *gorm.DB, text string) (*gorm.DB, clause.Expr) {
+	q = q.Where(gm.searchDocumentSQL+` @@ plainto_tsquery('simple', ?)`, text)
+	return q, clause.Expr{
+		SQL:  `ts_rank(` + gm.searchDocumentSQL + `, plainto_tsquery('simple', ?))`,
+		Vars: []any{text},
+	}
+}
+
+// Every term must match somewhere in the searched content. Messages where more of the terms match the topic rank higher.
+func (gm *groupManager) substringSearch(q *gorm.DB, terms []string) (*gorm.DB, clause.Expr) {
+	topicMatches := make([]string, len(terms))
+	topicVars := make([]any, len(terms))
+	for i, term := range terms {
+		pattern := likePattern(term)
+		q = q.Where(`LOWER(`+gm.searchDocumentSQL+`) LIKE ? ESCAPE '\'`, pattern)
+		topicMatches[i] = `CASE WHEN LOWER("topic") LIKE ? ESCAPE '\' THEN 1 ELSE 0 END`
+		topicVars[i] = pattern
+	}
+	return q, clause.Expr{
+		SQL:  `(` + strings.Join(topicMatches, ` + `) + `)`,
+		Vars: topicVars,
+	}
+}
+
+func likePattern(term string) string {
+	escaped := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(strings.ToLower(term))
+	return "%" + escaped + "%"
+}
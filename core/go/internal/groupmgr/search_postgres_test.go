@@ -0,0 +1,161 @@
+//go:build testdbpostgres
+// +build testdbpostgres
+
+/*
+ * Copyright © 2024 Kaleido, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with
+ * the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package groupmgr
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/kaleido-io/paladin/config/pkg/confutil"
+	"github.com/kaleido-io/paladin/config/pkg/pldconf"
+	"github.com/kaleido-io/paladin/core/internal/components"
+	"github.com/kaleido-io/paladin/core/pkg/persistence"
+	"github.com/kaleido-io/paladin/toolkit/pkg/pldapi"
+	"github.com/kaleido-io/paladin/toolkit/pkg/tktypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func getSearchIndexes(t *testing.T, ctx context.Context, gm *groupManager) map[string]bool {
+	var indexes []*searchIndexInfo
+	err := gm.p.DB().WithContext(ctx).
+		Raw(`SELECT c.relname AS "name", i.indisvalid AS "valid" FROM pg_index i JOIN pg_class c ON c.oid = i.indexrelid WHERE i.indrelid = 'pgroup_msgs'::regclass`).
+		Scan(&indexes).
+		Error
+	require.NoError(t, err)
+	searchIndexes := make(map[string]bool)
+	for _, idx := range indexes {
+		if strings.HasPrefix(idx.Name, searchIndexPrefix) {
+			searchIndexes[idx.Name] = idx.Valid
+		}
+	}
+	return searchIndexes
+}
+
+// Checks the planner can answer the search from the index, so the expressions in the index and query match
+func requireSearchUsesIndex(t *testing.T, ctx context.Context, gm *groupManager, indexName string) {
+	var plan []string
+	err := gm.p.Transaction(ctx, func(ctx context.Context, dbTX persistence.DBTX) error {
+		if err := dbTX.DB().Exec(`SET LOCAL enable_seqscan = off`).Error; err != nil {
+			return err
+		}
+		return dbTX.DB().
+			Raw(`EXPLAIN SELECT * FROM pgroup_msgs WHERE `+gm.searchDocumentSQL+` @@ plainto_tsquery('simple', ?)`, "invoice").
+			Scan(&plan).
+			Error
+	})
+	require.NoError(t, err)
+	assert.Contains(t, strings.Join(plan, "\n"), indexName)
+}
+
+func TestSearchMessagesFullTextRealDB(t *testing.T) {
+	ctx, gm, mc, done := newTestGroupManager(t, true, &pldconf.GroupManagerConfig{
+		Search: pldconf.GroupMessageSearchConfig{
+			Enabled:    confutil.P(true),
+			DataFields: []string{"body"},
+		},
+	})
+	defer done()
+	require.True(t, gm.searchFullText)
+
+	bodyIndex := searchIndexName(gm.searchDocumentSQL)
+	assert.Equal(t, map[string]bool{bodyIndex: true}, getSearchIndexes(t, ctx, gm))
+	requireSearchUsesIndex(t, ctx, gm, bodyIndex)
+
+	mc.registryManager.On("GetNodeTransports", mock.Anything, "node2").
+		Return([]*components.RegistryNodeTransportEntry{ /* contents not checked */ }, nil)
+	mc.transportManager.On("SendReliable", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	groupIDs := createTestGroups(t, ctx, mc, gm,
+		&pldapi.PrivacyGroupInput{
+			Domain:  "domain1",
+			Members: []string{"me@node1", "you@node2"},
+		},
+		&pldapi.PrivacyGroupInput{
+			Domain:  "domain1",
+			Members: []string{"me@node1", "you@node2"},
+		},
+	)
+
+	msgIDs := make([]uuid.UUID, 0)
+	err := gm.p.Transaction(ctx, func(ctx context.Context, dbTX persistence.DBTX) error {
+		for _, m := range []struct {
+			group int
+			topic string
+			data  any
+		}{
+			{0, "invoice", map[string]any{"body": "Invoice paid in full", "tags": "finance"}},
+			{0, "shipping", map[string]any{"body": "Invoice attached", "tags": "logistics"}},
+			{0, "chat", map[string]any{"body": "nothing to see", "other": "invoice"}},
+			{1, "billing", map[string]any{"body": "New invoice"}},
+		} {
+			msgID, err := gm.SendMessage(ctx, dbTX, &pldapi.PrivacyGroupMessageInput{
+				Domain: "domain1",
+				Group:  groupIDs[m.group],
+				Topic:  m.topic,
+				Data:   tktypes.JSONString(m.data),
+			})
+			require.NoError(t, err)
+			msgIDs = append(msgIDs, *msgID)
+		}
+		return nil
+	})
+	require.NoError(t, err)
+
+	// More matches rank higher, then the most recent first. Fields outside the configured data fields are not searched
+	results, err := gm.SearchMessages(ctx, gm.p.NOTX(), &pldapi.PrivacyGroupMessageSearch{Text: "INVOICE"})
+	require.NoError(t, err)
+	require.Len(t, results, 3)
+	assert.Equal(t, msgIDs[0], results[0].ID)
+	assert.Equal(t, msgIDs[3], results[1].ID)
+	assert.Equal(t, msgIDs[1], results[2].ID)
+	assert.JSONEq(t, `{"body": "Invoice attached", "tags": "logistics"}`, results[2].Data.String())
+
+	// Restricted to a group, and all terms must match
+	results, err = gm.SearchMessages(ctx, gm.p.NOTX(), &pldapi.PrivacyGroupMessageSearch{
+		Domain: "domain1",
+		Group:  groupIDs[0],
+		Text:   "invoice paid",
+	})
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, msgIDs[0], results[0].ID)
+
+	// Not searched until the field is configured
+	results, err = gm.SearchMessages(ctx, gm.p.NOTX(), &pldapi.PrivacyGroupMessageSearch{Text: "logistics"})
+	require.NoError(t, err)
+	assert.Empty(t, results)
+
+	// Restart with another field configured, which replaces the index
+	gm.conf.Search.DataFields = []string{"body", "tags"}
+	err = gm.initSearch(ctx)
+	require.NoError(t, err)
+	tagsIndex := searchIndexName(gm.searchDocumentSQL)
+	assert.NotEqual(t, bodyIndex, tagsIndex)
+	assert.Equal(t, map[string]bool{tagsIndex: true}, getSearchIndexes(t, ctx, gm))
+	requireSearchUsesIndex(t, ctx, gm, tagsIndex)
+
+	results, err = gm.SearchMessages(ctx, gm.p.NOTX(), &pldapi.PrivacyGroupMessageSearch{Text: "logistics"})
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, msgIDs[1], results[0].ID)
+}
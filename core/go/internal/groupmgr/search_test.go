@@ -0,0 +1,276 @@
+/*
+ * Copyright © 2024 Kaleido, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with
+ * the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package groupmgr
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/kaleido-io/paladin/config/pkg/confutil"
+	"github.com/kaleido-io/paladin/config/pkg/pldconf"
+	"github.com/kaleido-io/paladin/core/internal/components"
+	"github.com/kaleido-io/paladin/core/pkg/persistence"
+	"github.com/kaleido-io/paladin/toolkit/pkg/pldapi"
+	"github.com/kaleido-io/paladin/toolkit/pkg/tktypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func mockSearchIndex(mc *mockComponents, conf *pldconf.GroupManagerConfig) {
+	mc.db.Mock.ExpectQuery(`SELECT.*pg_index`).WillReturnRows(sqlmock.NewRows([]string{"name", "valid"}))
+	mc.db.Mock.ExpectExec(`CREATE INDEX CONCURRENTLY IF NOT EXISTS "pgroup_msgs_search_[0-9a-f]{16}"`).WillReturnResult(sqlmock.NewResult(0, 0))
+}
+
+func TestSearchMessagesSubstring(t *testing.T) {
+	ctx, gm, mc, done := newTestGroupManager(t, true, &pldconf.GroupManagerConfig{
+		Search: pldconf.GroupMessageSearchConfig{
+			Enabled:    confutil.P(true),
+			DataFields: []string{"body", "tags"},
+		},
+	})
+	defer done()
+	if gm.searchFullText {
+		t.Skip("substring search is only used on databases without full-text search")
+	}
+
+	mc.registryManager.On("GetNodeTransports", mock.Anything, "node2").
+		Return([]*components.RegistryNodeTransportEntry{ /* contents not checked */ }, nil)
+	mc.transportManager.On("SendReliable", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	groupIDs := createTestGroups(t, ctx, mc, gm,
+		&pldapi.PrivacyGroupInput{
+			Domain:  "domain1",
+			Members: []string{"me@node1", "you@node2"},
+		},
+		&pldapi.PrivacyGroupInput{
+			Domain:  "domain1",
+			Members: []string{"me@node1", "you@node2"},
+		},
+	)
+
+	msgIDs := make([]uuid.UUID, 0)
+	err := gm.p.Transaction(ctx, func(ctx context.Context, dbTX persistence.DBTX) error {
+		for _, m := range []struct {
+			group int
+			topic string
+			data  any
+		}{
+			{0, "invoice.paid", map[string]any{"body": "Payment of 100% received", "tags": "finance"}},
+			{0, "shipping", map[string]any{"body": "Invoice attached to the shipment", "tags": "logistics"}},
+			{0, "chat", map[string]any{"body": "nothing to see", "other": "invoice"}},
+			{1, "invoice.raised", map[string]any{"body": "New invoice"}},
+		} {
+			msgID, err := gm.SendMessage(ctx, dbTX, &pldapi.PrivacyGroupMessageInput{
+				Domain: "domain1",
+				Group:  groupIDs[m.group],
+				Topic:  m.topic,
+				Data:   tktypes.JSONString(m.data),
+			})
+			require.NoError(t, err)
+			msgIDs = append(msgIDs, *msgID)
+		}
+		return nil
+	})
+	require.NoError(t, err)
+
+	// Topic matches rank above data matches, and fields outside the configured data fields are not searched
+	results, err := gm.SearchMessages(ctx, gm.p.NOTX(), &pldapi.PrivacyGroupMessageSearch{Text: "INVOICE"})
+	require.NoError(t, err)
+	require.Len(t, results, 3)
+	assert.Equal(t, msgIDs[3], results[0].ID)
+	assert.Equal(t, msgIDs[0], results[1].ID)
+	assert.Equal(t, msgIDs[1], results[2].ID)
+	assert.JSONEq(t, `{"body": "Invoice attached to the shipment", "tags": "logistics"}`, results[2].Data.String())
+
+	// Restricted to a group, and all terms must match
+	results, err = gm.SearchMessages(ctx, gm.p.NOTX(), &pldapi.PrivacyGroupMessageSearch{
+		Domain: "domain1",
+		Group:  groupIDs[0],
+		Text:   "invoice finance",
+	})
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, msgIDs[0], results[0].ID)
+
+	// LIKE wildcards in the search text are matched literally
+	results, err = gm.SearchMessages(ctx, gm.p.NOTX(), &pldapi.PrivacyGroupMessageSearch{Text: "100%"})
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, msgIDs[0], results[0].ID)
+	results, err = gm.SearchMessages(ctx, gm.p.NOTX(), &pldapi.PrivacyGroupMessageSearch{Text: "%"})
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+
+	// Limit
+	results, err = gm.SearchMessages(ctx, gm.p.NOTX(), &pldapi.PrivacyGroupMessageSearch{Text: "invoice", Limit: confutil.P(1)})
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, msgIDs[3], results[0].ID)
+}
+
+func TestSearchMessagesFullText(t *testing.T) {
+	ctx, gm, mc, done := newTestGroupManager(t, false, &pldconf.GroupManagerConfig{
+		Search: pldconf.GroupMessageSearchConfig{
+			Enabled:    confutil.P(true),
+			DataFields: []string{"body"},
+		},
+	}, mockSearchIndex, mockEmptyMessageListeners)
+	defer done()
+	assert.True(t, gm.searchFullText)
+	assert.Equal(t, `to_tsvector('simple', "topic" || ' ' || COALESCE(("data"::jsonb) ->> 'body', ''))`, gm.searchDocumentSQL)
+
+	msgID := uuid.New()
+	groupID := tktypes.HexBytes(tktypes.RandBytes(32))
+	mc.db.Mock.ExpectQuery(`SELECT \* FROM "pgroup_msgs" WHERE "domain" = \$1 AND "group" = \$2 AND to_tsvector\(.*\) @@ plainto_tsquery\('simple', \$3\) ORDER BY ts_rank\(to_tsvector\(.*\), plainto_tsquery\('simple', \$4\)\) DESC, "local_seq" DESC LIMIT \$5`).
+		WithArgs("domain1", groupID.HexString(), "invoice paid", "invoice paid", 25).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "domain", "group", "topic", "data"}).
+			AddRow(msgID.String(), "domain1", groupID.HexString(), "invoice.paid", `{"body":"paid"}`))
+
+	results, err := gm.SearchMessages(ctx, gm.p.NOTX(), &pldapi.PrivacyGroupMessageSearch{
+		Domain: "domain1",
+		Group:  groupID,
+		Text:   "invoice paid",
+	})
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, msgID, results[0].ID)
+	assert.Equal(t, "invoice.paid", results[0].Topic)
+}
+
+func TestSearchMessagesFullTextIndexFail(t *testing.T) {
+	mc := newMockComponents(t, false)
+	mc.db.Mock.ExpectQuery(`SELECT.*pg_index`).WillReturnRows(sqlmock.NewRows([]string{"name", "valid"}))
+	mc.db.Mock.ExpectExec(`CREATE INDEX CONCURRENTLY IF NOT EXISTS "pgroup_msgs_search_[0-9a-f]{16}" ON pgroup_msgs USING GIN \(to_tsvector\('simple', "topic" \|\| ' ' \|\| "data"\)\)`).
+		WillReturnError(fmt.Errorf("pop"))
+
+	gm := NewGroupManager(context.Background(), &pldconf.GroupManagerConfig{
+		Search: pldconf.GroupMessageSearchConfig{Enabled: confutil.P(true)},
+	})
+	_, err := gm.PreInit(mc.c)
+	require.NoError(t, err)
+	err = gm.PostInit(mc.c)
+	assert.Regexp(t, "pop", err)
+}
+
+func TestSearchIndexNamedByExpression(t *testing.T) {
+	bodyIndex := searchIndexName(`to_tsvector('simple', "topic" || ' ' || COALESCE(("data"::jsonb) ->> 'body', ''))`)
+	assert.Regexp(t, `^pgroup_msgs_search_[0-9a-f]{16}$`, bodyIndex)
+	assert.Equal(t, bodyIndex, searchIndexName(`to_tsvector('simple', "topic" || ' ' || COALESCE(("data"::jsonb) ->> 'body', ''))`))
+	assert.NotEqual(t, bodyIndex, searchIndexName(`to_tsvector('simple', "topic" || ' ' || "data")`))
+}
+
+func TestSearchIndexStaleDropped(t *testing.T) {
+	documentSQL := `to_tsvector('simple', "topic" || ' ' || "data")`
+	indexName := searchIndexName(documentSQL)
+	mc := newMockComponents(t, false)
+	mc.db.Mock.ExpectQuery(`SELECT.*pg_index`).WillReturnRows(sqlmock.NewRows([]string{"name", "valid"}).
+		AddRow("pgroup_msgs_pkey", true).
+		AddRow("pgroup_msgs_search", true). // from a previous version, before the index was named by its expression
+		AddRow("pgroup_msgs_search_0011223344556677", true).
+		AddRow(indexName, false), // left by an interrupted build
+	)
+	mc.db.Mock.ExpectExec(`DROP INDEX CONCURRENTLY IF EXISTS "pgroup_msgs_search"`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mc.db.Mock.ExpectExec(`DROP INDEX CONCURRENTLY IF EXISTS "pgroup_msgs_search_0011223344556677"`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mc.db.Mock.ExpectExec(`DROP INDEX CONCURRENTLY IF EXISTS "` + indexName + `"`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mc.db.Mock.ExpectExec(`CREATE INDEX CONCURRENTLY IF NOT EXISTS "` + indexName + `"`).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	gm := &groupManager{p: mc.p, searchDocumentSQL: documentSQL}
+	_, err := gm.initSearchIndex(context.Background())
+	require.NoError(t, err)
+	require.NoError(t, mc.db.Mock.ExpectationsWereMet())
+}
+
+func TestSearchIndexExisting(t *testing.T) {
+	documentSQL := `to_tsvector('simple', "topic" || ' ' || "data")`
+	indexName := searchIndexName(documentSQL)
+	mc := newMockComponents(t, false)
+	mc.db.Mock.ExpectQuery(`SELECT.*pg_index`).WillReturnRows(sqlmock.NewRows([]string{"name", "valid"}).
+		AddRow(indexName, true),
+	)
+
+	gm := &groupManager{p: mc.p, searchDocumentSQL: documentSQL}
+	name, err := gm.initSearchIndex(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, indexName, name)
+	require.NoError(t, mc.db.Mock.ExpectationsWereMet())
+}
+
+func TestSearchIndexFail(t *testing.T) {
+	gm := &groupManager{searchDocumentSQL: `to_tsvector('simple', "topic" || ' ' || "data")`}
+
+	mc := newMockComponents(t, false)
+	gm.p = mc.p
+	mc.db.Mock.ExpectQuery(`SELECT.*pg_index`).WillReturnError(fmt.Errorf("pop"))
+	_, err := gm.initSearchIndex(context.Background())
+	assert.Regexp(t, "pop", err)
+
+	mc = newMockComponents(t, false)
+	gm.p = mc.p
+	mc.db.Mock.ExpectQuery(`SELECT.*pg_index`).WillReturnRows(sqlmock.NewRows([]string{"name", "valid"}).
+		AddRow("pgroup_msgs_search", true),
+	)
+	mc.db.Mock.ExpectExec(`DROP INDEX CONCURRENTLY`).WillReturnError(fmt.Errorf("pop"))
+	_, err = gm.initSearchIndex(context.Background())
+	assert.Regexp(t, "pop", err)
+}
+
+func TestSearchMessagesBadDataField(t *testing.T) {
+	mc := newMockComponents(t, false)
+	gm := NewGroupManager(context.Background(), &pldconf.GroupManagerConfig{
+		Search: pldconf.GroupMessageSearchConfig{
+			Enabled:    confutil.P(true),
+			DataFields: []string{"body'); DROP TABLE pgroup_msgs; --"},
+		},
+	})
+	_, err := gm.PreInit(mc.c)
+	require.NoError(t, err)
+	err = gm.PostInit(mc.c)
+	assert.Regexp(t, "PD012533", err)
+}
+
+func TestSearchMessagesNotEnabled(t *testing.T) {
+	ctx, gm, _, done := newTestGroupManager(t, false, &pldconf.GroupManagerConfig{}, mockEmptyMessageListeners)
+	defer done()
+
+	_, err := gm.SearchMessages(ctx, gm.p.NOTX(), &pldapi.PrivacyGroupMessageSearch{Text: "anything"})
+	assert.Regexp(t, "PD012531", err)
+}
+
+func TestSearchMessagesEmptyText(t *testing.T) {
+	ctx, gm, _, done := newTestGroupManager(t, false, &pldconf.GroupManagerConfig{
+		Search: pldconf.GroupMessageSearchConfig{Enabled: confutil.P(true)},
+	}, mockSearchIndex, mockEmptyMessageListeners)
+	defer done()
+
+	_, err := gm.SearchMessages(ctx, gm.p.NOTX(), &pldapi.PrivacyGroupMessageSearch{Text: "  "})
+	assert.Regexp(t, "PD012532", err)
+}
+
+func TestSearchMessagesQueryFail(t *testing.T) {
+	ctx, gm, mc, done := newTestGroupManager(t, false, &pldconf.GroupManagerConfig{
+		Search: pldconf.GroupMessageSearchConfig{Enabled: confutil.P(true)},
+	}, mockSearchIndex, mockEmptyMessageListeners)
+	defer done()
+
+	mc.db.Mock.ExpectQuery("SELECT.*pgroup_msgs").WillReturnError(fmt.Errorf("pop"))
+
+	_, err := gm.SearchMessages(ctx, gm.p.NOTX(), &pldapi.PrivacyGroupMessageSearch{Text: "anything"})
+	assert.Regexp(t, "pop", err)
+}
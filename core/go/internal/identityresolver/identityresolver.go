@@ -20,8 +20,10 @@ import (
 	"context"
 	"fmt"
 	"sync"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/kaleido-io/paladin/config/pkg/confutil"
 	"github.com/kaleido-io/paladin/config/pkg/pldconf"
 	"github.com/kaleido-io/paladin/core/internal/components"
 	"github.com/kaleido-io/paladin/core/internal/msgs"
@@ -42,7 +44,19 @@ type identityResolver struct {
 	transportManager      components.TransportManager
 	inflightRequests      map[string]*inflightRequest
 	inflightRequestsMutex *sync.Mutex
-	verifierCache         cache.Cache[string, string]
+	verifierCacheEnabled  bool
+	verifierCacheTTL      time.Duration
+	verifierCache         cache.Cache[string, *cachedVerifier]
+	nodeGenerations       map[string]uint64
+	nodeGenerationsMutex  sync.Mutex
+}
+
+// Each cached resolution records the generation of its node at the point the resolution started,
+// so invalidating a node is just a case of bumping its generation - without needing to find the entries.
+type cachedVerifier struct {
+	verifier   string
+	generation uint64
+	expires    time.Time
 }
 
 type inflightRequest struct {
@@ -55,7 +69,10 @@ func NewIdentityResolver(ctx context.Context, conf *pldconf.IdentityResolverConf
 		bgCtx:                 ctx,
 		inflightRequests:      make(map[string]*inflightRequest),
 		inflightRequestsMutex: &sync.Mutex{},
-		verifierCache:         cache.NewCache[string, string](&conf.VerifierCache, &pldconf.IdentityResolverDefaults.VerifierCache),
+		verifierCacheEnabled:  confutil.Bool(conf.VerifierCacheEnabled, *pldconf.IdentityResolverDefaults.VerifierCacheEnabled),
+		verifierCacheTTL:      confutil.DurationMin(conf.VerifierCacheTTL, 0, *pldconf.IdentityResolverDefaults.VerifierCacheTTL),
+		verifierCache:         cache.NewCache[string, *cachedVerifier](&conf.VerifierCache, &pldconf.IdentityResolverDefaults.VerifierCache),
+		nodeGenerations:       make(map[string]uint64),
 	}
 }
 
@@ -63,6 +80,47 @@ func cacheKey(identifier, node, algorithm, verifierType string) string {
 	return fmt.Sprintf("%s@%s|%s|%s", identifier, node, algorithm, verifierType)
 }
 
+func (ir *identityResolver) nodeGeneration(node string) uint64 {
+	ir.nodeGenerationsMutex.Lock()
+	defer ir.nodeGenerationsMutex.Unlock()
+	return ir.nodeGenerations[node]
+}
+
+// InvalidateNode discards all cached resolutions for identities on the given node,
+// for use when something about that node (such as its transport details) has changed.
+func (ir *identityResolver) InvalidateNode(node string) {
+	ir.nodeGenerationsMutex.Lock()
+	defer ir.nodeGenerationsMutex.Unlock()
+	ir.nodeGenerations[node]++
+}
+
+func (ir *identityResolver) getCachedVerifier(key, node string) (string, bool) {
+	if !ir.verifierCacheEnabled {
+		return "", false
+	}
+	cached, _ := ir.verifierCache.Get(key)
+	if cached == nil {
+		return "", false
+	}
+	if cached.generation != ir.nodeGeneration(node) ||
+		(!cached.expires.IsZero() && time.Now().After(cached.expires)) {
+		ir.verifierCache.Delete(key)
+		return "", false
+	}
+	return cached.verifier, true
+}
+
+func (ir *identityResolver) setCachedVerifier(key string, generation uint64, verifier string) {
+	if !ir.verifierCacheEnabled {
+		return
+	}
+	cached := &cachedVerifier{verifier: verifier, generation: generation}
+	if ir.verifierCacheTTL > 0 {
+		cached.expires = time.Now().Add(ir.verifierCacheTTL)
+	}
+	ir.verifierCache.Set(key, cached)
+}
+
 func (ir *identityResolver) PreInit(c components.PreInitComponents) (*components.ManagerInitResult, error) {
 	return &components.ManagerInitResult{}, nil
 }
@@ -114,12 +172,12 @@ func (ir *identityResolver) ResolveVerifierAsync(ctx context.Context, lookup str
 
 	// Ensure we log and cache if we resolve
 	cacheKey := cacheKey(identifier, node, algorithm, verifierType)
-	cachedVerifier, _ := ir.verifierCache.Get(cacheKey)
-	isCached := cachedVerifier != ""
+	generation := ir.nodeGeneration(node)
+	cachedVerifier, isCached := ir.getCachedVerifier(cacheKey, node)
 	isLocal := node == ir.nodeName
 	cacheAndResolve := func(ctx context.Context, verifier string) {
 		if !isCached {
-			ir.verifierCache.Set(cacheKey, verifier)
+			ir.setCachedVerifier(cacheKey, generation, verifier)
 		}
 		log.L(ctx).Debugf("ResolvedVerifier(lookup='%s',identifier='%s',node='%s',isLocal=%t,algorithm='%s',verifierType='%s',cached=%t): %s",
 			lookup, identifier, node, isLocal, algorithm, verifierType, isCached, verifier,
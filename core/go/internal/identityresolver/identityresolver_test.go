@@ -3,9 +3,17 @@ package identityresolver
 import (
 	"context"
 	"testing"
+	"time"
 
+	"github.com/kaleido-io/paladin/config/pkg/confutil"
 	"github.com/kaleido-io/paladin/config/pkg/pldconf"
+	"github.com/kaleido-io/paladin/core/mocks/componentmocks"
+	"github.com/kaleido-io/paladin/toolkit/pkg/algorithms"
+	"github.com/kaleido-io/paladin/toolkit/pkg/pldapi"
+	"github.com/kaleido-io/paladin/toolkit/pkg/verifiers"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 func TestNewIdentityResolver(t *testing.T) {
@@ -55,3 +63,100 @@ func TestCacheKey(t *testing.T) {
 		})
 	}
 }
+
+func newTestLocalIdentityResolver(t *testing.T, conf *pldconf.IdentityResolverConfig) (*identityResolver, *componentmocks.KeyManager) {
+	ir := NewIdentityResolver(context.Background(), conf).(*identityResolver)
+	ir.nodeName = "node1"
+	keyManager := componentmocks.NewKeyManager(t)
+	ir.keyManager = keyManager
+	return ir, keyManager
+}
+
+func expectLocalKeyResolution(keyManager *componentmocks.KeyManager, identifier, verifier string) *mock.Call {
+	return keyManager.On("ResolveKeyNewDatabaseTX", mock.Anything, identifier, algorithms.ECDSA_SECP256K1, verifiers.ETH_ADDRESS).
+		Return(&pldapi.KeyMappingAndVerifier{Verifier: &pldapi.KeyVerifier{Verifier: verifier}}, nil)
+}
+
+func TestResolveVerifierCacheHitMiss(t *testing.T) {
+	ctx := context.Background()
+	ir, keyManager := newTestLocalIdentityResolver(t, &pldconf.IdentityResolverConfig{})
+	expectLocalKeyResolution(keyManager, "alice", "0xaaaa").Once()
+	expectLocalKeyResolution(keyManager, "bob", "0xbbbb").Once()
+
+	// miss, then hit
+	for i := 0; i < 3; i++ {
+		verifier, err := ir.ResolveVerifier(ctx, "alice@node1", algorithms.ECDSA_SECP256K1, verifiers.ETH_ADDRESS)
+		require.NoError(t, err)
+		assert.Equal(t, "0xaaaa", verifier)
+	}
+
+	// a different identity is a separate entry
+	verifier, err := ir.ResolveVerifier(ctx, "bob", algorithms.ECDSA_SECP256K1, verifiers.ETH_ADDRESS)
+	require.NoError(t, err)
+	assert.Equal(t, "0xbbbb", verifier)
+}
+
+func TestResolveVerifierCacheTTL(t *testing.T) {
+	ctx := context.Background()
+	ir, keyManager := newTestLocalIdentityResolver(t, &pldconf.IdentityResolverConfig{
+		VerifierCacheTTL: confutil.P("1h"),
+	})
+	expectLocalKeyResolution(keyManager, "alice", "0xaaaa").Twice()
+
+	verifier, err := ir.ResolveVerifier(ctx, "alice@node1", algorithms.ECDSA_SECP256K1, verifiers.ETH_ADDRESS)
+	require.NoError(t, err)
+	assert.Equal(t, "0xaaaa", verifier)
+
+	key := cacheKey("alice", "node1", algorithms.ECDSA_SECP256K1, verifiers.ETH_ADDRESS)
+	cached, _ := ir.verifierCache.Get(key)
+	require.NotNil(t, cached)
+	assert.True(t, cached.expires.After(time.Now().Add(59*time.Minute)))
+
+	// expire the entry
+	cached.expires = time.Now().Add(-1 * time.Second)
+
+	verifier, err = ir.ResolveVerifier(ctx, "alice@node1", algorithms.ECDSA_SECP256K1, verifiers.ETH_ADDRESS)
+	require.NoError(t, err)
+	assert.Equal(t, "0xaaaa", verifier)
+}
+
+func TestResolveVerifierCacheInvalidateNode(t *testing.T) {
+	ctx := context.Background()
+	ir, keyManager := newTestLocalIdentityResolver(t, &pldconf.IdentityResolverConfig{})
+	expectLocalKeyResolution(keyManager, "alice", "0xaaaa").Once()
+	expectLocalKeyResolution(keyManager, "alice", "0xcccc").Once()
+
+	verifier, err := ir.ResolveVerifier(ctx, "alice@node1", algorithms.ECDSA_SECP256K1, verifiers.ETH_ADDRESS)
+	require.NoError(t, err)
+	assert.Equal(t, "0xaaaa", verifier)
+
+	// invalidating some other node has no effect
+	ir.InvalidateNode("node2")
+	verifier, err = ir.ResolveVerifier(ctx, "alice@node1", algorithms.ECDSA_SECP256K1, verifiers.ETH_ADDRESS)
+	require.NoError(t, err)
+	assert.Equal(t, "0xaaaa", verifier)
+
+	ir.InvalidateNode("node1")
+	verifier, err = ir.ResolveVerifier(ctx, "alice@node1", algorithms.ECDSA_SECP256K1, verifiers.ETH_ADDRESS)
+	require.NoError(t, err)
+	assert.Equal(t, "0xcccc", verifier)
+
+	// and the new value is cached again
+	verifier, err = ir.ResolveVerifier(ctx, "alice@node1", algorithms.ECDSA_SECP256K1, verifiers.ETH_ADDRESS)
+	require.NoError(t, err)
+	assert.Equal(t, "0xcccc", verifier)
+}
+
+func TestResolveVerifierCacheDisabled(t *testing.T) {
+	ctx := context.Background()
+	ir, keyManager := newTestLocalIdentityResolver(t, &pldconf.IdentityResolverConfig{
+		VerifierCacheEnabled: confutil.P(false),
+	})
+	expectLocalKeyResolution(keyManager, "alice", "0xaaaa").Times(3)
+
+	for i := 0; i < 3; i++ {
+		verifier, err := ir.ResolveVerifier(ctx, "alice@node1", algorithms.ECDSA_SECP256K1, verifiers.ETH_ADDRESS)
+		require.NoError(t, err)
+		assert.Equal(t, "0xaaaa", verifier)
+	}
+}
@@ -106,6 +106,14 @@ func (km *keyManager) Sign(ctx context.Context, mapping *pldapi.KeyMappingAndVer
 	return w.sign(ctx, mapping, payloadType, payload)
 }
 
+func (km *keyManager) SignerHealthCheck(ctx context.Context, mapping *pldapi.KeyMappingAndVerifier) error {
+	w, err := km.getWalletByName(ctx, mapping.Wallet)
+	if err != nil {
+		return err
+	}
+	return w.signingModule.HealthCheck(ctx)
+}
+
 func (km *keyManager) lockAllocationOrGetOwner(kr *keyResolver) *keyResolver {
 	km.allocLock.Lock()
 	defer km.allocLock.Unlock()
@@ -238,6 +246,12 @@ func (km *keyManager) ReverseKeyLookup(ctx context.Context, dbTX persistence.DBT
 	return mapping, nil
 }
 
+func (km *keyManager) ResolveExistingKey(ctx context.Context, dbTX persistence.DBTX, identifier, algorithm, verifierType string) (*pldapi.KeyMappingAndVerifier, error) {
+	// As with the reverse lookup, this is a read-only use of a KRC so it does not need to be bound to the DB TX
+	kr := km.newKeyResolver(dbTX, false /* allowing use with NOTX() */).(*keyResolver)
+	return kr.resolveKey(ctx, identifier, algorithm, verifierType, true /* existing only */)
+}
+
 func (km *keyManager) QueryKeys(ctx context.Context, dbTX *gorm.DB, jq *query.QueryJSON) (keyList []*pldapi.KeyQueryEntry, err error) {
 
 	q := filters.BuildGORM(ctx, jq,
@@ -506,6 +506,32 @@ func TestReverseKeyLookupNotFound(t *testing.T) {
 	assert.Regexp(t, "PD010511", err)
 }
 
+func TestResolveExistingKey(t *testing.T) {
+	ctx, km, mc, done := newTestKeyManager(t, true, &pldconf.KeyManagerConfig{
+		Wallets: []*pldconf.WalletConfig{hdWalletConfig("hdwallet1", "")},
+	})
+	defer done()
+
+	// Nothing is created for an unknown identifier, or an unknown leaf of a known path
+	_, err := km.ResolveExistingKey(ctx, mc.c.Persistence().NOTX(), "existing.key1", algorithms.ECDSA_SECP256K1, verifiers.ETH_ADDRESS)
+	assert.Regexp(t, "PD010512", err)
+
+	created, err := km.ResolveKeyNewDatabaseTX(ctx, "existing.key1", algorithms.ECDSA_SECP256K1, verifiers.ETH_ADDRESS)
+	require.NoError(t, err)
+
+	_, err = km.ResolveExistingKey(ctx, mc.c.Persistence().NOTX(), "existing.key2", algorithms.ECDSA_SECP256K1, verifiers.ETH_ADDRESS)
+	assert.Regexp(t, "PD010512", err)
+	var mappings []*DBKeyMapping
+	err = mc.c.Persistence().DB().Find(&mappings).Error
+	require.NoError(t, err)
+	assert.Len(t, mappings, 1)
+
+	// The existing key resolves to the same verifier
+	resolved, err := km.ResolveExistingKey(ctx, mc.c.Persistence().NOTX(), "existing.key1", algorithms.ECDSA_SECP256K1, verifiers.ETH_ADDRESS)
+	require.NoError(t, err)
+	assert.Equal(t, created.Verifier.Verifier, resolved.Verifier.Verifier)
+}
+
 func TestReverseKeyLookupFailMapping(t *testing.T) {
 	ctx, km, mc, done := newTestKeyManager(t, false, &pldconf.KeyManagerConfig{
 		Wallets: []*pldconf.WalletConfig{hdWalletConfig("hdwallet1", "")},
@@ -129,3 +129,30 @@ func TestSignError(t *testing.T) {
 	}, "any", []byte("payload"))
 	assert.Regexp(t, "pop", err)
 }
+
+func TestSignerHealthCheck(t *testing.T) {
+
+	ctx, km, _, done := newTestKeyManager(t, false, &pldconf.KeyManagerConfig{
+		Wallets: []*pldconf.WalletConfig{hdWalletConfig("hdwallet1", "")},
+	})
+	defer done()
+
+	w, err := km.getWalletByName(ctx, "hdwallet1")
+	require.NoError(t, err)
+	ms := signermocks.NewSigningModule(t)
+	ms.On("HealthCheck", mock.Anything).Return(nil).Once()
+	ms.On("HealthCheck", mock.Anything).Return(fmt.Errorf("pop")).Once()
+	w.signingModule = ms
+
+	mapping := &pldapi.KeyMappingAndVerifier{KeyMappingWithPath: &pldapi.KeyMappingWithPath{KeyMapping: &pldapi.KeyMapping{
+		Wallet: "hdwallet1",
+	}}}
+	require.NoError(t, km.SignerHealthCheck(ctx, mapping))
+	assert.Regexp(t, "pop", km.SignerHealthCheck(ctx, mapping))
+
+	err = km.SignerHealthCheck(ctx, &pldapi.KeyMappingAndVerifier{KeyMappingWithPath: &pldapi.KeyMappingWithPath{KeyMapping: &pldapi.KeyMapping{
+		Wallet: "unknown",
+	}}})
+	assert.Regexp(t, "PD010503", err)
+
+}
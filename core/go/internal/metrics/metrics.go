@@ -0,0 +1,48 @@
+/*
+ * Copyright © 2024 Kaleido, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with
+ * the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Metrics holds the single registry that all components register their collectors with,
+// so they are gathered and served together
+type Metrics interface {
+	Registry() *prometheus.Registry
+	HTTPHandler() http.Handler
+}
+
+type metricsManager struct {
+	registry *prometheus.Registry
+}
+
+func NewMetricsManager() Metrics {
+	return &metricsManager{
+		registry: prometheus.NewRegistry(),
+	}
+}
+
+func (mm *metricsManager) Registry() *prometheus.Registry {
+	return mm.registry
+}
+
+func (mm *metricsManager) HTTPHandler() http.Handler {
+	return promhttp.HandlerFor(mm.registry, promhttp.HandlerOpts{})
+}
@@ -0,0 +1,40 @@
+/*
+ * Copyright © 2024 Kaleido, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with
+ * the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package metrics
+
+import (
+	"io"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetricsServedFromRegistry(t *testing.T) {
+	mm := NewMetricsManager()
+	counter := prometheus.NewCounter(prometheus.CounterOpts{Name: "paladin_test_total", Help: "test"})
+	mm.Registry().MustRegister(counter)
+	counter.Inc()
+
+	res := httptest.NewRecorder()
+	mm.HTTPHandler().ServeHTTP(res, httptest.NewRequest("GET", "/metrics", nil))
+	assert.Equal(t, 200, res.Code)
+	body, err := io.ReadAll(res.Body)
+	require.NoError(t, err)
+	assert.Contains(t, string(body), "paladin_test_total 1")
+}
@@ -18,6 +18,7 @@ package msgs
 
 import (
 	"fmt"
+	"net/http"
 	"strings"
 	"sync"
 
@@ -109,6 +110,8 @@ var (
 	MsgStateFlushInProgress           = pde("PD010131", "A flush is already in progress for this domain context")
 	MsgDomainContextImportInvalidJSON = pde("PD010132", "Attempted to import state locks but the JSON could not be parsed")
 	MsgDomainContextImportBadStates   = pde("PD010133", "Attempted to import state failed")
+	MsgStatePageQueryInvalid          = pde("PD010134", "A paginated state query must specify a limit, and must not specify a sort")
+	MsgStatePageCursorInvalid         = pde("PD010135", "Invalid state page cursor '%s'")
 
 	// Persistence PD0102XX
 	MsgPersistenceInvalidType          = pde("PD010200", "Invalid persistence type: %s")
@@ -202,6 +205,7 @@ var (
 	MsgBlockIndexerTransactionReverted      = pde("PD011309", "Transaction reverted: %s")
 	MsgBlockIndexerConfirmedBlockNotFound   = pde("PD011310", "Block %s (%d) not found on retrieval after detection and requested number of confirmations")
 	MsgBlockIndexerLimitRequired            = pde("PD011311", "limit is required on all queries")
+	MsgBlockIndexerInvalidTrackingMode      = pde("PD011312", "Unsupported block tracking mode: %s")
 
 	// EthClient module PD0115XX
 	MsgEthClientInvalidInput            = pde("PD011500", "Unable to convert to ABI function input (func=%s)")
@@ -287,6 +291,7 @@ var (
 	MsgDomainInvalidPGroupGenesisABI          = pde("PD011664", "Domain generated an invalid privacy group genesis ABI parameter schema")
 	MsgDomainInvalidPGroupTxTypeNotPrivate    = pde("PD011665", "Resulting wrapped function call for privacy group must be a private transaction (type=%s)")
 	MsgDomainInvalidPGroupTxCannotRedirect    = pde("PD011666", "Resulting wrapped function call must target the same smart contract (contract=%s,addr=%s)")
+	MsgDomainPagedNullifierQuery              = pde("PD011667", "Paginated queries are not supported when using nullifiers")
 
 	// Entrypoint PD0117XX
 	MsgEntrypointUnknownRunMode = pde("PD011700", "Unknown run mode '%s'")
@@ -331,36 +336,84 @@ var (
 	MsgPrivateTxMgrFunctionNotProvided           = pde("PD011836", "Function abi not provided in transaction input")
 	MsgPrivateTxMgrAssembleRequestInvalid        = pde("PD011837", "Assemble request is invalid for transaction %s")
 	MsgPrivateTxMgrAssembleTxnNotFound           = pde("PD011838", "Transaction %s not found in local node")
+	MsgPrivateTxMgrMaxReassemblyAttempts         = pde("PD011839", "Transaction %s reverted after reaching the maximum of %d reassembly attempts. Revert reasons: %s")
+	MsgPrivateTxMgrAutoApproveNotDevMode         = pde("PD011840", "Auto-approval of endorsements for domains %v can only be configured when development mode is enabled")
+	MsgPrivateTxMgrAutoApproveNoVerifier         = pde("PD011841", "No resolved verifier for endorsing party %s (algorithm=%s,verifierType=%s)")
+	MsgPrivateTxMgrEndorsementQuorumInvalid      = pde("PD011842", "Endorsement quorum for domain '%s' must be at least 1: %d")
+	MsgPrivateTxMgrEndorsementQuorumTooLarge     = pde("PD011843", "Endorsement quorum %d for domain '%s' exceeds the %d endorsing parties of attestation request '%s'")
+	MsgPrivateTxMgrDelegationReclaimed           = pde("PD011844", "Delegation to node %s was not accepted within %s and has been reclaimed")
+	MsgPrivateTxMgrMaxAssembleAttempts           = pde("PD011845", "Transaction %s reverted after failing to assemble %d times. Last error: %s")
+	MsgPrivateTxMgrPinnedSignerInvalid           = pde("PD011846", "Pinned signer for contract '%s' must be a non-empty identity for a valid contract address")
+	MsgPrivateTxBlockedDelegation                = pde("PD011847", "Waiting for node %s to accept the delegation of the transaction")
+	MsgPrivateTxBlockedVerifiers                 = pde("PD011848", "Waiting for verifiers to be resolved for %s")
+	MsgPrivateTxBlockedAssembly                  = pde("PD011849", "Waiting for the transaction to be assembled")
+	MsgPrivateTxBlockedSignatures                = pde("PD011850", "Waiting for signatures for attestation requests %s")
+	MsgPrivateTxBlockedEndorsements              = pde("PD011851", "Waiting for endorsement from %s")
+	MsgPrivateTxBlockedDependencies              = pde("PD011852", "Waiting for prerequisite transactions %s, which are not yet ready to dispatch")
+	MsgPrivateTxBlockedDispatchThrottle          = pde("PD011853", "Waiting to dispatch, as signer %s has %d dispatched transactions that are not yet confirmed (max=%d)")
+	MsgPrivateTxMgrInvalidUntrustedPolicy        = pde("PD011854", "Invalid untrusted endorsement policy '%s'")
+	MsgPrivateTxMgrUntrustedEndorsement          = pde("PD011855", "Transaction %s reverted after receiving an endorsement for attestation request '%s' from untrusted party '%s' sent by node '%s'")
+	MsgPrivateTxMgrDelegationFenced              = pde("PD011856", "Transaction %s was reclaimed from node %s, so will not be assembled for it")
+	MsgPrivateTxMgrPinnedSignerNotLocal          = pde("PD011857", "Pinned signer '%s' for contract '%s' must be an identity on the local node '%s'")
 
 	// Public Transaction Manager PD0119XX
-	MsgInsufficientBalance             = pde("PD011900", "Balance %s of fueling source address %s is below the required amount %s")
-	MsgBalanceBelowMinimum             = pde("PD011901", "Balance %s of fueling source address %s is below the configured minimum balance %s")
-	MsgInvalidBigIntString             = pde("PD011902", "Value of '%s' is not a valid bigInt string")
-	MsgMaxBelowMin                     = pde("PD011903", "Value of '%s' is not a valid max, it is below the min value")
-	MsgMaxBelowMinThreshold            = pde("PD011904", "Value of '%s' is not a valid max, it is below the min threshold")
-	MsgSubmitFailedWrongHashReturned   = pde("PD011905", "Submission of transaction with calculatedHash '%s' returned hash '%s'")
-	MsgSubmissionResponseMissingTxHash = pde("PD011906", "Missing transaction hash from the submission response for transaction with ID: %s")
-	MsgPublicTxMgrAlreadyInit          = pde("PD011907", "Public transaction manager already initialized")
-	MsgInvalidGasClientConfig          = pde("PD011908", "Invalid gas client config: %s")
-	MsgInvalidGasPriceIncreaseMax      = pde("PD011909", "Invalid max gas price increase price string %s")
-	MsgMissingTransactionID            = pde("PD011910", "Transaction ID must be provided")
-	MsgPublicTransactionNotFound       = pde("PD011911", "Public transaction not found with id %s")
-	MsgGasPriceError                   = pde("PD011917", `The gasPrice '%s' could not be parsed. Must be a numeric string, or an object with 'gasPrice' field, or 'maxFeePerGas'/'maxPriorityFeePerGas' fields (EIP-1559), error: %s`)
-	MsgPersistError                    = pde("PD011918", "Unexpected internal error, cannot persist stage.")
-	MsgInvalidStageOutput              = pde("PD011919", "Stage output object is missing %s: %+v")
-	MsgInvalidGasLimit                 = pde("PD011920", "Invalid gas limit, must be a positive number")
-	MsgStatusUpdateForbidden           = pde("PD011921", "Cannot update status of a completed transaction")
-	MsgTransactionNotFound             = pde("PD011924", "Transaction '%s' not found")
-	MsgTransactionEngineRequestTimeout = pde("PD011926", "The transaction handler did not acknowledge the request after %.2fs")
-	MsgErrorMissingSignerID            = pde("PD011928", "Signer Identifier must be provided")
-	MsgInvalidTransactionType          = pde("PD011929", "Transaction type invalid")
-	MsgMissingConfirmedTransaction     = pde("PD011930", "Transaction %s with nonce smaller than the recorded confirmed nonce does not have an indexed transaction.")
-	MsgPublicTxHistoryInfo             = pde("PD011931", "PubTx[INFO] from=%s nonce=%s subStatus=%s action=%s info=%s")
-	MsgPublicTxHistoryError            = pde("PD011932", "PubTx[ERROR] from=%s nonce=%s subStatus=%s action=%s error=%s")
-	MsgPublicBatchCompleted            = pde("PD011933", "Batch already completed")
-	MsgInvalidAutoFuelSource           = pde("PD011934", "Invalid auto-fueling source '%s'")
-	MsgInvalidStateMissingTXHash       = pde("PD011935", "Invalid state - missing transaction hash from previous sign stage")
-	MsgInvalidTXMissingFromAddr        = pde("PD011936", "From address missing for transaction")
+	MsgInsufficientBalance                  = pde("PD011900", "Balance %s of fueling source address %s is below the required amount %s")
+	MsgBalanceBelowMinimum                  = pde("PD011901", "Balance %s of fueling source address %s is below the configured minimum balance %s")
+	MsgInvalidBigIntString                  = pde("PD011902", "Value of '%s' is not a valid bigInt string")
+	MsgMaxBelowMin                          = pde("PD011903", "Value of '%s' is not a valid max, it is below the min value")
+	MsgMaxBelowMinThreshold                 = pde("PD011904", "Value of '%s' is not a valid max, it is below the min threshold")
+	MsgSubmitFailedWrongHashReturned        = pde("PD011905", "Submission of transaction with calculatedHash '%s' returned hash '%s'")
+	MsgSubmissionResponseMissingTxHash      = pde("PD011906", "Missing transaction hash from the submission response for transaction with ID: %s")
+	MsgPublicTxMgrAlreadyInit               = pde("PD011907", "Public transaction manager already initialized")
+	MsgInvalidGasClientConfig               = pde("PD011908", "Invalid gas client config: %s")
+	MsgInvalidGasPriceIncreaseMax           = pde("PD011909", "Invalid max gas price increase price string %s")
+	MsgMissingTransactionID                 = pde("PD011910", "Transaction ID must be provided")
+	MsgPublicTransactionNotFound            = pde("PD011911", "Public transaction not found with id %s")
+	MsgGasPriceError                        = pde("PD011917", `The gasPrice '%s' could not be parsed. Must be a numeric string, or an object with 'gasPrice' field, or 'maxFeePerGas'/'maxPriorityFeePerGas' fields (EIP-1559), error: %s`)
+	MsgPersistError                         = pde("PD011918", "Unexpected internal error, cannot persist stage.")
+	MsgInvalidStageOutput                   = pde("PD011919", "Stage output object is missing %s: %+v")
+	MsgInvalidGasLimit                      = pde("PD011920", "Invalid gas limit, must be a positive number")
+	MsgStatusUpdateForbidden                = pde("PD011921", "Cannot update status of a completed transaction")
+	MsgTransactionNotFound                  = pde("PD011924", "Transaction '%s' not found")
+	MsgTransactionEngineRequestTimeout      = pde("PD011926", "The transaction handler did not acknowledge the request after %.2fs")
+	MsgErrorMissingSignerID                 = pde("PD011928", "Signer Identifier must be provided")
+	MsgInvalidTransactionType               = pde("PD011929", "Transaction type invalid")
+	MsgMissingConfirmedTransaction          = pde("PD011930", "Transaction %s with nonce smaller than the recorded confirmed nonce does not have an indexed transaction.")
+	MsgPublicTxHistoryInfo                  = pde("PD011931", "PubTx[INFO] from=%s nonce=%s subStatus=%s action=%s info=%s")
+	MsgPublicTxHistoryError                 = pde("PD011932", "PubTx[ERROR] from=%s nonce=%s subStatus=%s action=%s error=%s")
+	MsgPublicBatchCompleted                 = pde("PD011933", "Batch already completed")
+	MsgInvalidAutoFuelSource                = pde("PD011934", "Invalid auto-fueling source '%s'")
+	MsgInvalidStateMissingTXHash            = pde("PD011935", "Invalid state - missing transaction hash from previous sign stage")
+	MsgInvalidTXMissingFromAddr             = pde("PD011936", "From address missing for transaction")
+	MsgInvalidGasPriceSignerOverride        = pde("PD011937", "Invalid gas price override for signer '%s'")
+	MsgStageTriggerRetriesExhausted         = pde("PD011938", "Failed to start stage '%s' after %d attempts")
+	MsgPublicTxRawTxInvalid                 = pde("PD011939", "Invalid pre-signed transaction")
+	MsgPublicTxRawTxFromMismatch            = pde("PD011940", "Pre-signed transaction was signed by '%s' not the supplied from address '%s'")
+	MsgPublicTxRawTxNonceMismatch           = pde("PD011941", "Pre-signed transaction has nonce %s not the supplied nonce %d")
+	MsgPublicTxRawTxSpeedUpSkipped          = pde("PD011942", "Resubmitted pre-signed transaction unchanged after %s, as it cannot be re-signed with a higher gas price. The submitter can replace it with a new transaction for the same nonce")
+	MsgPublicTxGroupEmpty                   = pde("PD011943", "A transaction group must contain at least one transaction")
+	MsgPublicTxGroupMixedSigners            = pde("PD011944", "All transactions in a group must be from the same address. Found '%s' and '%s'")
+	MsgPublicTxGroupNotFound                = pde("PD011945", "Transaction group '%s' not found")
+	MsgPublicTxDryRunBalance                = pde("PD011946", "Balance %s of signing address %s is below the %s required for the gas and value of the transaction")
+	MsgPublicTxDryRunNotWritable            = pde("PD011947", "A dry-run transaction cannot be written for submission")
+	MsgInvalidGasLimitSignerOverride        = pde("PD011948", "Invalid gas limit override for signer '%s'")
+	MsgGasLimitFloorAboveBlockLimit         = pde("PD011949", "Gas limit floor %d is above the block gas limit %d")
+	MsgPublicTxSignerUnhealthyHold          = pde("PD011950", "Signing held until the signer for %s reports healthy: %s")
+	MsgPublicTxSignerHealthyResumed         = pde("PD011951", "Signer for %s reports healthy, resuming signing")
+	MsgPublicTxEngineOverloaded             = pde("PD011952", "Public transaction engine is overloaded with %d pending transactions (max=%d). Retry the submission later", http.StatusServiceUnavailable)
+	MsgPublicTxGasPriceHistoryRange         = pde("PD011953", "Invalid gas price history query: %s")
+	MsgPublicTxPreSignHookFailed            = pde("PD011954", "Pre-sign hook failed for transaction %s:%d")
+	MsgPublicTxPreSignHookProtected         = pde("PD011955", "Pre-sign hook modified protected field '%s' of transaction %s:%d. Only the gas limit can be changed")
+	MsgPublicTxInvalidPausePolicy           = pde("PD011956", "Invalid orchestrator pause policy '%s'")
+	MsgPublicTxInvalidSignerPriority        = pde("PD011957", "Invalid signing address '%s' in orchestrator priorities")
+	MsgPublicTxInvalidKeyLossPolicy         = pde("PD011958", "Invalid signer key loss policy '%s'")
+	MsgPublicTxSignerKeyLost                = pde("PD011959", "The signing key for %s no longer exists: %s")
+	MsgPublicTxNonceOverrideCompleted       = pde("PD011960", "Nonce %d for %s is at or below the completed nonce watermark %d")
+	MsgPublicTxNonceOverrideConflict        = pde("PD011961", "Nonce %d for %s is already assigned to public transaction %d")
+	MsgPublicTxNonceOverrideNotGap          = pde("PD011962", "Nonce %d for %s is not below the next nonce to be assigned automatically (%d)")
+	MsgPublicTxNonceOverrideDuplicate       = pde("PD011963", "Nonce %d for %s is supplied for more than one transaction")
+	MsgPublicTxRawTxNonceGap                = pde("PD011964", "Pre-signed transaction nonce %d for %s is beyond the next nonce for the signing address (%d)")
+	MsgPublicTxResubmitSkippedAtMaxGasPrice = pde("PD011965", "Resubmission skipped after %s, as the gas price cannot be increased beyond the current submission")
 
 	// TransportManager module PD0120XX
 	MsgTransportInvalidMessage                 = pde("PD012000", "Invalid message")
@@ -385,6 +438,9 @@ var (
 	MsgTransportStateSchemaNotAvailableLocally = pde("PD012020", "State schema not available locally: domain=%s,id=%s")
 	MsgTransportMessageNotAvailableLocally     = pde("PD012021", "Message not available locally: id=%s")
 	MsgTransportPrivacyGroupStateStorageFailed = pde("PD012022", "Storage of privacy group state failed: id=%s")
+	MsgTransportReliableMessageNotFound        = pde("PD012023", "Reliable message not found: id=%s")
+	MsgTransportReliableMessageAcknowledged    = pde("PD012024", "Reliable message has already been acknowledged: id=%s")
+	MsgTransportReplyTimeout                   = pde("PD012025", "Timed out after %s waiting for reply from node '%s' to %s message %s")
 
 	// RegistryManager module PD0121XX
 	MsgRegistryNodeEntiresNotFound     = pde("PD012100", "No entries found for node '%s'")
@@ -439,6 +495,12 @@ var (
 	MsgTxMgrListenerNameRequired         = pde("PD012241", "Receipt listener name is required")
 	MsgTxMgrJSONRPCSubscriptionClosed    = pde("PD012242", "JSON/RPC subscription '%s' closed")
 	MsgTxMgrJSONRPCSubscriptionNack      = pde("PD012243", "JSON/RPC subscription '%s' returned nack for receipt batch")
+	MsgTxMgrBadSubscriptionOptions       = pde("PD012244", "Invalid subscription options")
+	MsgTxMgrBadSubscriptionMaxBatchSize  = pde("PD012245", "Subscription maxBatchSize must be at least 1: %d")
+	MsgTxMgrBadAckBatchID                = pde("PD012246", "Invalid batch ID for ack/nack: %s")
+	MsgTxMgrBadSubscriptionBatchTimeout  = pde("PD012247", "Subscription batchTimeout must be a non-negative duration: '%s'")
+	MsgTxMgrBadSubscriptionField         = pde("PD012248", "Subscription field '%s' is not a receipt field")
+	MsgTxMgrNonceNonPublic               = pde("PD012249", "A nonce can only be supplied for a public transaction")
 
 	// FlushWriter module PD0123XX
 	MsgFlushWriterQuiescing      = pde("PD012300", "Writer shutting down")
@@ -473,4 +535,20 @@ var (
 	MsgPGroupsJSONRPCSubscriptionNack       = pde("PD012521", "JSON/RPC subscription '%s' returned nack for message batch")
 	MsgPGroupsGenesisSaltUnset              = pde("PD012522", "Genesis salt must be set")
 	MsgPGroupsReceivedGenesisInvalid        = pde("PD012523", "Received genesis state is invalid")
+	MsgPGroupsEncryptionKeyMissing          = pde("PD012524", "Message encryption for domain '%s' requires a keyIdentifier")
+	MsgPGroupsEncryptionKeyUnavailable      = pde("PD012525", "Message encryption key for domain '%s' is unavailable")
+	MsgPGroupsMessageDecryptFailed          = pde("PD012526", "Failed to decrypt data for message %s")
+	MsgPGroupsBlobStoreInitFailed           = pde("PD012527", "Failed to initialize message blob store at '%s'")
+	MsgPGroupsBlobWriteFailed               = pde("PD012528", "Failed to write data for message %s to the blob store")
+	MsgPGroupsBlobReadFailed                = pde("PD012529", "Failed to read data for message %s from the blob store (ref=%s)")
+	MsgPGroupsCorrelationIDNotFound         = pde("PD012530", "Correlation ID %s does not match a message in group %s")
+	MsgPGroupsSearchNotEnabled              = pde("PD012531", "Message search is not enabled")
+	MsgPGroupsSearchTextEmpty               = pde("PD012532", "Search text must be specified")
+	MsgPGroupsSearchBadDataField            = pde("PD012533", "Invalid message search data field '%s'")
+	MsgPGroupsDomainNotFound                = pde("PD012534", "Domain '%s' not found")
+	MsgPGroupsBadUnknownGroupAction         = pde("PD012535", "Invalid unknownGroupAction '%s' for inbound messages")
+	MsgPGroupsConsumeAckBeyondReceived      = pde("PD012536", "Cannot acknowledge messages up to local sequence %d for consumer '%s', as the latest received message is %d")
+	MsgPGroupsEncryptionAlgorithmInvalid    = pde("PD012537", "Algorithm '%s' is not supported for message encryption keys")
+	MsgPGroupsEncryptionVerifierInvalid     = pde("PD012538", "Verifier type '%s' is not supported for message encryption keys")
+	MsgPGroupsEncryptionPayloadInvalid      = pde("PD012539", "Payload type '%s' is not supported for message encryption key derivation")
 )
@@ -31,9 +31,13 @@ import (
 // assemble a transaction that we are not coordinating, using the provided state locks
 // all errors are assumed to be transient and the request should be retried
 // if the domain as deemed the request as invalid then it will communicate the `revert` directive via the AssembleTransactionResponse_REVERT result without any error
-func (s *Sequencer) assembleForRemoteCoordinator(ctx context.Context, transactionID uuid.UUID, preAssembly *components.TransactionPreAssembly, stateLocksJSON []byte, blockHeight int64) (*components.TransactionPostAssembly, error) {
+func (s *Sequencer) assembleForRemoteCoordinator(ctx context.Context, transactionID uuid.UUID, coordinatorNode string, preAssembly *components.TransactionPreAssembly, stateLocksJSON []byte, blockHeight int64) (*components.TransactionPostAssembly, error) {
 
-	log.L(ctx).Debugf("assembleForRemoteCoordinator: Assembling transaction %s ", transactionID)
+	log.L(ctx).Debugf("assembleForRemoteCoordinator: Assembling transaction %s for %s", transactionID, coordinatorNode)
+
+	if !s.delegationFence.assemble(transactionID.String(), coordinatorNode) {
+		return nil, i18n.NewError(ctx, msgs.MsgPrivateTxMgrDelegationFenced, transactionID, coordinatorNode)
+	}
 
 	log.L(ctx).Debugf("assembleForRemoteCoordinator: resetting domain context with state locks from the coordinator which assumes a block height of %d compared with local blockHeight of %d", blockHeight, s.environment.GetBlockHeight())
 	//If our block height is behind the coordinator, there are some states that would otherwise be available to us but we wont see
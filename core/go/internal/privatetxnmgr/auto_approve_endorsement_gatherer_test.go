@@ -0,0 +1,149 @@
+/*
+ * Copyright © 2024 Kaleido, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with
+ * the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package privatetxnmgr
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kaleido-io/paladin/config/pkg/confutil"
+	"github.com/kaleido-io/paladin/config/pkg/pldconf"
+	"github.com/kaleido-io/paladin/core/mocks/componentmocks"
+	"github.com/kaleido-io/paladin/toolkit/pkg/algorithms"
+	"github.com/kaleido-io/paladin/toolkit/pkg/prototk"
+	"github.com/kaleido-io/paladin/toolkit/pkg/signpayloads"
+	"github.com/kaleido-io/paladin/toolkit/pkg/tktypes"
+	"github.com/kaleido-io/paladin/toolkit/pkg/verifiers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAutoApproveGatherEndorsement(t *testing.T) {
+	ctx := context.Background()
+	dCtx := componentmocks.NewDomainContext(t)
+
+	eg := NewAutoApproveEndorsementGatherer(dCtx)
+	assert.Equal(t, dCtx, eg.DomainContext())
+
+	aliceVerifier := &prototk.ResolvedVerifier{
+		Lookup:       "alice@node1",
+		Algorithm:    algorithms.ECDSA_SECP256K1,
+		VerifierType: verifiers.ETH_ADDRESS,
+		Verifier:     tktypes.RandAddress().String(),
+	}
+	endorsementReq := &prototk.AttestationRequest{
+		Name:            "notary",
+		AttestationType: prototk.AttestationType_ENDORSE,
+		Algorithm:       algorithms.ECDSA_SECP256K1,
+		VerifierType:    verifiers.ETH_ADDRESS,
+		PayloadType:     signpayloads.OPAQUE_TO_RSV,
+		Payload:         []byte("payload"),
+		Parties:         []string{"alice@node1"},
+	}
+	result, revertReason, err := eg.GatherEndorsement(ctx,
+		&prototk.TransactionSpecification{TransactionId: "tx1"},
+		[]*prototk.ResolvedVerifier{
+			{Lookup: "alice@node1", Algorithm: "other", VerifierType: verifiers.ETH_ADDRESS, Verifier: "wrong"},
+			aliceVerifier,
+		},
+		[]*prototk.AttestationResult{}, []*prototk.EndorsableState{}, []*prototk.EndorsableState{}, []*prototk.EndorsableState{}, []*prototk.EndorsableState{},
+		"alice@node1", endorsementReq)
+	require.NoError(t, err)
+	assert.Nil(t, revertReason)
+	assert.Equal(t, &prototk.AttestationResult{
+		Name:            "notary",
+		AttestationType: prototk.AttestationType_ENDORSE,
+		Verifier:        aliceVerifier,
+		PayloadType:     confutil.P(signpayloads.OPAQUE_TO_RSV),
+		Payload:         []byte("payload"),
+	}, result)
+}
+
+func TestAutoApproveGatherEndorsementNoVerifier(t *testing.T) {
+	eg := NewAutoApproveEndorsementGatherer(componentmocks.NewDomainContext(t))
+
+	_, _, err := eg.GatherEndorsement(context.Background(),
+		&prototk.TransactionSpecification{},
+		[]*prototk.ResolvedVerifier{},
+		[]*prototk.AttestationResult{}, []*prototk.EndorsableState{}, []*prototk.EndorsableState{}, []*prototk.EndorsableState{}, []*prototk.EndorsableState{},
+		"alice@node1", &prototk.AttestationRequest{
+			Algorithm:    algorithms.ECDSA_SECP256K1,
+			VerifierType: verifiers.ETH_ADDRESS,
+		})
+	assert.Regexp(t, "PD011841.*alice@node1", err)
+}
+
+func TestAutoApproveRequiresDevelopmentMode(t *testing.T) {
+	ctx := context.Background()
+	for _, devMode := range []*bool{nil, confutil.P(false)} {
+		ptm := NewPrivateTransactionMgr(ctx, &pldconf.PrivateTxManagerConfig{
+			DevelopmentMode:               devMode,
+			AutoApproveEndorsementDomains: []string{"domain1"},
+		})
+		err := ptm.PostInit(componentmocks.NewAllComponents(t))
+		assert.Regexp(t, "PD011840.*domain1", err)
+	}
+}
+
+func TestAutoApproveEndorsementGathererSelectedForDomain(t *testing.T) {
+	ctx := context.Background()
+	mocks := &dependencyMocks{
+		allComponents:       componentmocks.NewAllComponents(t),
+		domain:              componentmocks.NewDomain(t),
+		domainSmartContract: componentmocks.NewDomainSmartContract(t),
+		domainContext:       componentmocks.NewDomainContext(t),
+		domainMgr:           componentmocks.NewDomainManager(t),
+		transportManager:    componentmocks.NewTransportManager(t),
+		stateStore:          componentmocks.NewStateManager(t),
+		keyManager:          componentmocks.NewKeyManager(t),
+		txManager:           componentmocks.NewTXManager(t),
+		publicTxManager:     componentmocks.NewPublicTxManager(t),
+	}
+	mocks.allComponents.On("StateManager").Return(mocks.stateStore).Maybe()
+	mocks.allComponents.On("DomainManager").Return(mocks.domainMgr).Maybe()
+	mocks.allComponents.On("TransportManager").Return(mocks.transportManager).Maybe()
+	mocks.allComponents.On("KeyManager").Return(mocks.keyManager).Maybe()
+	mocks.allComponents.On("TxManager").Return(mocks.txManager).Maybe()
+	mocks.allComponents.On("PublicTxManager").Return(mocks.publicTxManager).Maybe()
+	mocks.allComponents.On("Persistence").Return(nil).Maybe()
+	mocks.transportManager.On("LocalNodeName").Return("node1")
+	mocks.domainSmartContract.On("Domain").Return(mocks.domain)
+
+	autoApproveAddr := tktypes.RandAddress()
+	mocks.mockDomain(autoApproveAddr)
+	otherAddr := tktypes.RandAddress()
+	mocks.mockDomain(otherAddr)
+	mocks.domain.On("Name").Return("domain1").Once()
+	mocks.domain.On("Name").Return("domain2").Once()
+
+	ptm := NewPrivateTransactionMgr(ctx, &pldconf.PrivateTxManagerConfig{
+		DevelopmentMode:               confutil.P(true),
+		AutoApproveEndorsementDomains: []string{"domain1"},
+	})
+	err := ptm.PostInit(mocks.allComponents)
+	require.NoError(t, err)
+
+	eg, err := ptm.(*privateTxManager).getEndorsementGathererForContract(ctx, nil, *autoApproveAddr)
+	require.NoError(t, err)
+	assert.IsType(t, &autoApproveEndorsementGatherer{}, eg)
+
+	eg, err = ptm.(*privateTxManager).getEndorsementGathererForContract(ctx, nil, *otherAddr)
+	require.NoError(t, err)
+	assert.IsType(t, &endorsementGatherer{}, eg)
+
+	mocks.stateStore.AssertCalled(t, "NewDomainContext", mock.Anything, mocks.domain, *autoApproveAddr, mock.Anything)
+}
@@ -46,6 +46,16 @@ import (
 // 1+1 - core option set for Noto
 // 2+1 - core option set for Pente
 // 3+2 - core option set for Zeto
+//
+// Every node involved in a transaction must choose the same coordinator for it, or we get split-brain where
+// multiple nodes coordinate (or none do). So the selection from the endorser set only depends on the transaction:
+//  - parties in the attestation plan that are not fully qualified are resolved against the node of the sender
+//    (which is where the transaction was assembled), never against the node doing the selection
+//  - the candidate nodes are the distinct nodes of the endorsing parties, sorted by name
+//  - for hashed selection, the coordinator is the candidate at index FNV-1a(32bit) of the concatenated
+//    sorted fully qualified endorser identities, modulo the number of candidates
+//  - for round-robin selection, the coordinator is the candidate at index (blockHeight / rangeSize) modulo
+//    the number of candidates
 
 type CoordinatorSelectionMode int
 
@@ -118,10 +128,11 @@ func (s *endorsementSetHashSelection) SelectCoordinatorNode(ctx context.Context,
 		//use a map to dedupe as we go
 		candidateNodesMap := make(map[string]struct{})
 		identities := make([]string, 0, len(transaction.PostAssembly.AttestationPlan))
+		defaultNode := transactionSenderNode(ctx, transaction, s.localNode)
 		for _, attestationPlan := range transaction.PostAssembly.AttestationPlan {
 			if attestationPlan.AttestationType == prototk.AttestationType_ENDORSE {
 				for _, party := range attestationPlan.Parties {
-					identity, node, err := tktypes.PrivateIdentityLocator(party).Validate(ctx, s.localNode, false)
+					identity, node, err := tktypes.PrivateIdentityLocator(party).Validate(ctx, defaultNode, false)
 					if err != nil {
 						log.L(ctx).Errorf("SelectCoordinatorNode: Error resolving node for party %s: %s", party, err)
 						return -1, "", i18n.NewError(ctx, msgs.MsgPrivateTxManagerInternalError, err)
@@ -146,8 +157,8 @@ func (s *endorsementSetHashSelection) SelectCoordinatorNode(ctx context.Context,
 		for _, identity := range identities {
 			h.Write([]byte(identity))
 		}
-		// Use that as an index into the chosen node set
-		s.chosenNode = candidateNodes[int(h.Sum32())%len(candidateNodes)]
+		// Use that as an index into the chosen node set (in unsigned arithmetic, so the result is the same whatever the platform int size)
+		s.chosenNode = candidateNodes[h.Sum32()%uint32(len(candidateNodes))]
 	}
 
 	return blockHeight, s.chosenNode, nil
@@ -172,6 +183,7 @@ func (s *roundRobinCoordinatorSelectorPolicy) SelectCoordinatorNode(ctx context.
 		} else {
 			//use a map to dedupe as we go
 			candidateNodesMap := make(map[string]struct{})
+			defaultNode := transactionSenderNode(ctx, transaction, s.localNode)
 			for _, attestationPlan := range transaction.PostAssembly.AttestationPlan {
 				if attestationPlan.AttestationType == prototk.AttestationType_ENDORSE {
 					for _, party := range attestationPlan.Parties {
@@ -181,7 +193,7 @@ func (s *roundRobinCoordinatorSelectorPolicy) SelectCoordinatorNode(ctx context.
 							return -1, "", i18n.NewError(ctx, msgs.MsgPrivateTxManagerInternalError, err)
 						}
 						if node == "" {
-							node = s.localNode
+							node = defaultNode
 						}
 						candidateNodesMap[node] = struct{}{}
 					}
@@ -209,3 +221,16 @@ func (s *roundRobinCoordinatorSelectorPolicy) SelectCoordinatorNode(ctx context.
 	return blockHeight, coordinatorNode, nil
 
 }
+
+// Unqualified parties in the attestation plan are relative to the node that assembled the transaction, which is
+// the node of the sender. We only fall back to the local node if the sender is not qualified, in which case
+// the transaction has not yet left the node that assembled it.
+func transactionSenderNode(ctx context.Context, transaction *components.PrivateTransaction, localNode string) string {
+	if transaction.PreAssembly != nil && transaction.PreAssembly.TransactionSpecification != nil {
+		node, err := tktypes.PrivateIdentityLocator(transaction.PreAssembly.TransactionSpecification.From).Node(ctx, true)
+		if err == nil && node != "" {
+			return node
+		}
+	}
+	return localNode
+}
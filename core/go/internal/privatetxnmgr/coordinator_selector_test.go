@@ -0,0 +1,97 @@
+/*
+ * Copyright © 2024 Kaleido, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with
+ * the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package privatetxnmgr
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/kaleido-io/paladin/config/pkg/pldconf"
+	"github.com/kaleido-io/paladin/core/internal/components"
+	"github.com/kaleido-io/paladin/toolkit/pkg/prototk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testSequencerEnvironment struct {
+	blockHeight int64
+}
+
+func (e *testSequencerEnvironment) GetBlockHeight() int64 {
+	return e.blockHeight
+}
+
+func newTestEndorsedTransaction(from string, parties ...string) *components.PrivateTransaction {
+	return &components.PrivateTransaction{
+		ID: uuid.New(),
+		PreAssembly: &components.TransactionPreAssembly{
+			TransactionSpecification: &prototk.TransactionSpecification{From: from},
+		},
+		PostAssembly: &components.TransactionPostAssembly{
+			AttestationPlan: []*prototk.AttestationRequest{
+				{AttestationType: prototk.AttestationType_ENDORSE, Parties: parties},
+			},
+		},
+	}
+}
+
+func TestCoordinatorSelectionSameOnAllNodes(t *testing.T) {
+	ctx := context.Background()
+	contractConfig := &prototk.ContractConfig{CoordinatorSelection: prototk.ContractConfig_COORDINATOR_ENDORSER}
+
+	defaultMode := EndorsementCoordinatorSelectionMode
+	defer func() { EndorsementCoordinatorSelectionMode = defaultMode }()
+
+	for _, mode := range []CoordinatorSelectionMode{HashedSelection, BlockHeightRoundRobin} {
+		EndorsementCoordinatorSelectionMode = mode
+
+		for blockHeight := int64(0); blockHeight < 2000; blockHeight += 100 {
+			chosen := make(map[string]bool)
+			for _, localNode := range []string{"node1", "node2", "node3"} {
+				// the unqualified endorser is on the sender's node, so must not be resolved to the local node
+				tx := newTestEndorsedTransaction("alice@node1", "bob", "carol@node2", "dave@node3")
+				selector, err := NewCoordinatorSelector(ctx, localNode, contractConfig, pldconf.PrivateTxManagerSequencerConfig{})
+				require.NoError(t, err)
+				_, node, err := selector.SelectCoordinatorNode(ctx, tx, &testSequencerEnvironment{blockHeight: blockHeight})
+				require.NoError(t, err)
+				chosen[node] = true
+			}
+			assert.Len(t, chosen, 1, "mode=%d blockHeight=%d chose %v", mode, blockHeight, chosen)
+		}
+	}
+}
+
+func TestCoordinatorSelectionHashedIgnoresPartyOrder(t *testing.T) {
+	ctx := context.Background()
+
+	selectNode := func(parties ...string) string {
+		selector := &endorsementSetHashSelection{localNode: "node1"}
+		_, node, err := selector.SelectCoordinatorNode(ctx, newTestEndorsedTransaction("alice@node1", parties...), &testSequencerEnvironment{})
+		require.NoError(t, err)
+		return node
+	}
+	node := selectNode("bob@node2", "carol@node3", "dave@node4", "erin@node5")
+	assert.Equal(t, node, selectNode("erin@node5", "dave@node4", "carol@node3", "bob@node2"))
+	assert.Equal(t, node, selectNode("carol@node3", "erin@node5", "bob@node2", "dave@node4"))
+}
+
+func TestTransactionSenderNode(t *testing.T) {
+	ctx := context.Background()
+	assert.Equal(t, "node2", transactionSenderNode(ctx, newTestEndorsedTransaction("alice@node2"), "node1"))
+	assert.Equal(t, "node1", transactionSenderNode(ctx, newTestEndorsedTransaction("alice"), "node1"))
+	assert.Equal(t, "node1", transactionSenderNode(ctx, &components.PrivateTransaction{}, "node1"))
+}
@@ -0,0 +1,65 @@
+/*
+ * Copyright © 2024 Kaleido, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with
+ * the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package privatetxnmgr
+
+import "sync"
+
+// A delegate can only make progress with a transaction by asking us to assemble it, so that is where we fence a
+// delegate we have reclaimed a transaction from. Reclaiming a transaction (on the sequencer event loop) and assembling
+// it for a remote coordinator (on the transport goroutines) are decided under one lock, so exactly one of them wins:
+//   - a delegate that has asked us to assemble the transaction has accepted it, so we do not reclaim it
+//   - once we have reclaimed the transaction, we refuse to assemble it for that delegate, so it cannot be submitted twice
+type delegationFence struct {
+	mux           sync.Mutex
+	assembledFor  map[string]string // transaction ID -> remote coordinator we have assembled it for
+	reclaimedFrom map[string]string // transaction ID -> delegate we have reclaimed it from
+}
+
+func newDelegationFence() *delegationFence {
+	return &delegationFence{
+		assembledFor:  make(map[string]string),
+		reclaimedFrom: make(map[string]string),
+	}
+}
+
+// Returns false if the delegate has already asked us to assemble the transaction, in which case it must not be reclaimed
+func (df *delegationFence) reclaim(txID, delegateNode string) bool {
+	df.mux.Lock()
+	defer df.mux.Unlock()
+	if df.assembledFor[txID] == delegateNode {
+		return false
+	}
+	df.reclaimedFrom[txID] = delegateNode
+	return true
+}
+
+// Returns false if we have reclaimed the transaction from the coordinator, in which case it must not be assembled for it
+func (df *delegationFence) assemble(txID, coordinatorNode string) bool {
+	df.mux.Lock()
+	defer df.mux.Unlock()
+	if df.reclaimedFrom[txID] == coordinatorNode {
+		return false
+	}
+	df.assembledFor[txID] = coordinatorNode
+	return true
+}
+
+func (df *delegationFence) release(txID string) {
+	df.mux.Lock()
+	defer df.mux.Unlock()
+	delete(df.assembledFor, txID)
+	delete(df.reclaimedFrom, txID)
+}
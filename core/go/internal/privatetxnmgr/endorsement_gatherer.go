@@ -110,6 +110,8 @@ func (e *endorsementGatherer) GatherEndorsement(ctx context.Context, transaction
 		}
 		result.Payload = signaturePayload
 	case prototk.EndorseTransactionResponse_ENDORSER_SUBMIT:
+		// the domain may return data (such as the time of endorsement) for it to check when preparing the transaction
+		result.Payload = endorseRes.Payload
 		result.Constraints = append(result.Constraints, prototk.AttestationResult_ENDORSER_MUST_SUBMIT)
 	}
 
@@ -18,26 +18,53 @@ package privatetxnmgr
 
 import (
 	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
 
+	"github.com/google/uuid"
 	"github.com/kaleido-io/paladin/config/pkg/confutil"
+	"github.com/kaleido-io/paladin/core/internal/components"
 	"github.com/kaleido-io/paladin/core/internal/msgs"
 	"github.com/kaleido-io/paladin/core/internal/privatetxnmgr/ptmgrtypes"
+	"github.com/kaleido-io/paladin/core/pkg/persistence"
 	"github.com/kaleido-io/paladin/toolkit/pkg/i18n"
 	"github.com/kaleido-io/paladin/toolkit/pkg/log"
+	"github.com/kaleido-io/paladin/toolkit/pkg/tktypes"
 )
 
 type Graph interface {
-	AddTransaction(ctx context.Context, transaction ptmgrtypes.TransactionFlow)
+	AddTransaction(ctx context.Context, transaction ptmgrtypes.TransactionFlow) error
 	GetDispatchableTransactions(ctx context.Context) (ptmgrtypes.DispatchableTransactions, error)
 	RemoveTransaction(ctx context.Context, txID string)
 	RemoveTransactions(ctx context.Context, transactionsToRemove []string)
 	IncludesTransaction(txID string) bool
+	Export(ctx context.Context) (*components.PrivateTxDependencyGraph, error)
+}
+
+type persistedSequenceID struct {
+	Transaction     uuid.UUID          `gorm:"column:transaction;primaryKey"`
+	ContractAddress tktypes.EthAddress `gorm:"column:contract_address"`
+	SequenceID      uint64             `gorm:"column:sequence_id"`
 }
 
 type graph struct {
+	persistence     persistence.Persistence
+	contractAddress tktypes.EthAddress
+
+	// the graph is maintained by the sequencer event loop, but can be exported from other goroutines for debugging
+	mux sync.Mutex
+
 	// This is the source of truth for all transaction
 	allTransactions map[string]ptmgrtypes.TransactionFlow
 
+	// Each transaction is given the next sequence ID when it is added, which is the order in which it became ready
+	// for sequencing after being assembled. They are unique and increasing for the contract, and are used to order
+	// the transactions so the dispatch order does not depend on map iteration order.
+	// They are persisted until the transaction leaves the graph, so a graph reloaded after a restart resumes the same order.
+	sequenceIDs map[string]uint64
+
 	// all of the following are ephemeral and derived from allTransactions
 
 	// implement graph of transactions as an adjacency matrix where the values in the matrix is an array of state hashes that connect those transactions
@@ -50,19 +77,94 @@ type graph struct {
 	transactionIndex map[string]int
 }
 
-func NewGraph() Graph {
+func NewGraph(p persistence.Persistence, contractAddress tktypes.EthAddress) Graph {
 	return &graph{
+		persistence:     p,
+		contractAddress: contractAddress,
 		allTransactions: make(map[string]ptmgrtypes.TransactionFlow),
+		sequenceIDs:     make(map[string]uint64),
+	}
+}
+
+func (g *graph) AddTransaction(ctx context.Context, transaction ptmgrtypes.TransactionFlow) error {
+	g.mux.Lock()
+	defer g.mux.Unlock()
+	txID := transaction.ID(ctx)
+	if _, exists := g.sequenceIDs[txID.String()]; !exists {
+		sequenceID, err := g.loadOrAssignSequenceID(ctx, txID)
+		if err != nil {
+			return err
+		}
+		g.sequenceIDs[txID.String()] = sequenceID
 	}
+	log.L(ctx).Debugf("Adding transaction %s to graph with sequence ID %d", txID, g.sequenceIDs[txID.String()])
+	g.allTransactions[txID.String()] = transaction
+	return nil
 }
 
-func (g *graph) AddTransaction(ctx context.Context, transaction ptmgrtypes.TransactionFlow) {
-	log.L(ctx).Debugf("Adding transaction %s to graph", transaction.ID(ctx).String())
-	g.allTransactions[transaction.ID(ctx).String()] = transaction
+// A transaction keeps the sequence ID persisted when it was first added, and otherwise is given the one after
+// the highest persisted for the contract
+func (g *graph) loadOrAssignSequenceID(ctx context.Context, txID uuid.UUID) (sequenceID uint64, err error) {
+	err = g.persistence.Transaction(ctx, func(ctx context.Context, dbTX persistence.DBTX) error {
+		var existing []*persistedSequenceID
+		err := dbTX.DB().WithContext(ctx).
+			Table("private_tx_sequence_ids").
+			Where(`"transaction" = ?`, txID).
+			Limit(1).
+			Find(&existing).
+			Error
+		if err != nil {
+			return err
+		}
+		if len(existing) > 0 {
+			sequenceID = existing[0].SequenceID
+			return nil
+		}
+		var last []*persistedSequenceID
+		err = dbTX.DB().WithContext(ctx).
+			Table("private_tx_sequence_ids").
+			Where("contract_address = ?", g.contractAddress).
+			Order("sequence_id DESC").
+			Limit(1).
+			Find(&last).
+			Error
+		if err != nil {
+			return err
+		}
+		sequenceID = 1
+		if len(last) > 0 {
+			sequenceID = last[0].SequenceID + 1
+		}
+		return dbTX.DB().WithContext(ctx).
+			Table("private_tx_sequence_ids").
+			Create(&persistedSequenceID{
+				Transaction:     txID,
+				ContractAddress: g.contractAddress,
+				SequenceID:      sequenceID,
+			}).
+			Error
+	})
+	return sequenceID, err
+}
 
+func (g *graph) deleteSequenceIDs(ctx context.Context, txIDs []string) {
+	if len(txIDs) == 0 {
+		return
+	}
+	err := g.persistence.DB().WithContext(ctx).
+		Table("private_tx_sequence_ids").
+		Where(`"transaction" IN ?`, txIDs).
+		Delete(&persistedSequenceID{}).
+		Error
+	if err != nil {
+		// the transaction keeps its place in the order if it returns to the graph, which does no harm
+		log.L(ctx).Warnf("Failed to delete sequence IDs for transactions %v: %s", txIDs, err)
+	}
 }
 
 func (g *graph) IncludesTransaction(txID string) bool {
+	g.mux.Lock()
+	defer g.mux.Unlock()
 	return g.allTransactions[txID] != nil
 }
 
@@ -70,13 +172,17 @@ func (g *graph) buildMatrix(ctx context.Context) error {
 	log.L(ctx).Debugf("Building graph with %d transactions", len(g.allTransactions))
 	g.transactionIndex = make(map[string]int)
 	g.transactions = make([]ptmgrtypes.TransactionFlow, len(g.allTransactions))
-	currentIndex := 0
-	for txnId, txn := range g.allTransactions {
+	txnIDs := make([]string, 0, len(g.allTransactions))
+	for txnId := range g.allTransactions {
+		txnIDs = append(txnIDs, txnId)
+	}
+	// in sequence ID order, so independent transactions are dispatched in the order they were assembled
+	sort.Slice(txnIDs, func(i, j int) bool {
+		return g.sequenceIDs[txnIDs[i]] < g.sequenceIDs[txnIDs[j]]
+	})
+	for currentIndex, txnId := range txnIDs {
 		g.transactionIndex[txnId] = currentIndex
-		g.transactions[currentIndex] = txn
-
-		currentIndex++
-
+		g.transactions[currentIndex] = g.allTransactions[txnId]
 	}
 	//for each unique state hash, create an index of its minter and/or spender
 	stateToSpender := make(map[string]*int)
@@ -121,6 +227,8 @@ func (g *graph) buildMatrix(ctx context.Context) error {
 // and then doing a topological sort of each of those subgraphs
 func (g *graph) GetDispatchableTransactions(ctx context.Context) (ptmgrtypes.DispatchableTransactions, error) {
 	log.L(ctx).Debug("Graph.GetDispatchableTransactions")
+	g.mux.Lock()
+	defer g.mux.Unlock()
 
 	// TODO should probably cache this graph and only rebuild it when needed (e.g. on restart)
 	// and incrementally update it when new transactions are added etc...
@@ -133,7 +241,7 @@ func (g *graph) GetDispatchableTransactions(ctx context.Context) (ptmgrtypes.Dis
 
 	//TODO there are many valid topological sorts of any given graph,
 	// should we bias in favour of older transactions?
-	// for now, we do a breath first search which is a close approximation of an bias in favour of older transactions
+	// for now, we do a breath first search in sequence ID order which is a close approximation of an bias in favour of older transactions
 
 	queue := make([]int, 0, len(g.transactionsMatrix))
 	//find all independent transactions - that have no input states in this graph and then do a breadth first search
@@ -165,6 +273,7 @@ func (g *graph) GetDispatchableTransactions(ctx context.Context) (ptmgrtypes.Dis
 	// process the queue until it is empty
 	// for each transaction in the queue, check if it is dispatchable, if it is, add it to the dispatchable list and add its dependent transactions to the queue if the have no other dependencies
 	// queue will become empty when there are no more dispatchable transactions
+	isDispatchable := make([]bool, len(g.transactionsMatrix))
 	for len(queue) > 0 {
 		nextTransaction := queue[0]
 		queue = queue[1:]
@@ -183,6 +292,7 @@ func (g *graph) GetDispatchableTransactions(ctx context.Context) (ptmgrtypes.Dis
 
 		//transaction can be dispatched
 		dispatchable = append(dispatchable, g.transactions[nextTransaction])
+		isDispatchable[nextTransaction] = true
 
 		//get this transaction's dependencies
 		dependencies := g.transactionsMatrix[nextTransaction]
@@ -201,6 +311,8 @@ func (g *graph) GetDispatchableTransactions(ctx context.Context) (ptmgrtypes.Dis
 		}
 	}
 
+	g.setDispatchBlockedReasons(ctx, isDispatchable)
+
 	//TODO for now, we assume that all dispatchable transactions are to be dispatched by the same signing key
 	// in reality, we need to maintain subgraphs per signing key because there is no way to guarantee ordering
 	// across signing keys
@@ -216,13 +328,44 @@ func (g *graph) GetDispatchableTransactions(ctx context.Context) (ptmgrtypes.Dis
 
 	return map[string][]ptmgrtypes.TransactionFlow{}, nil
 }
+
+// Endorsed transactions that are not dispatchable are waiting on the transactions that mint the states they spend,
+// so we record which of those are not yet dispatchable on each of them
+func (g *graph) setDispatchBlockedReasons(ctx context.Context, isDispatchable []bool) {
+	for txnIndex, txn := range g.transactions {
+		if isDispatchable[txnIndex] {
+			txn.SetDispatchBlockedReason(ctx, "")
+			continue
+		}
+		if !txn.IsEndorsed(ctx) {
+			continue
+		}
+		prerequisites := make([]string, 0)
+		for minterIndex, dependants := range g.transactionsMatrix {
+			if len(dependants[txnIndex]) > 0 && !isDispatchable[minterIndex] {
+				prerequisites = append(prerequisites, g.transactions[minterIndex].ID(ctx).String())
+			}
+		}
+		sort.Strings(prerequisites)
+		txn.SetDispatchBlockedReason(ctx, i18n.ExpandWithCode(ctx, i18n.MessageKey(msgs.MsgPrivateTxBlockedDependencies), strings.Join(prerequisites, ",")))
+	}
+}
+
 func (g *graph) RemoveTransaction(ctx context.Context, txID string) {
 	log.L(ctx).Debugf("Graph.RemoveTransaction Removing transaction %s from graph", txID)
+	g.mux.Lock()
+	defer g.mux.Unlock()
 	delete(g.allTransactions, txID)
+	if _, exists := g.sequenceIDs[txID]; exists {
+		delete(g.sequenceIDs, txID)
+		g.deleteSequenceIDs(ctx, []string{txID})
+	}
 }
 
 func (g *graph) RemoveTransactions(ctx context.Context, transactionIDsToRemove []string) {
 	log.L(ctx).Debugf("Graph.RemoveTransactions Removing transactions from graph")
+	g.mux.Lock()
+	defer g.mux.Unlock()
 	// no validation performed here
 	// it is valid to remove transactions that have dependents.  In fact that is normal.
 	//Transactions are removed when they are dispatched and dependencies are dispatched before their dependents
@@ -230,11 +373,84 @@ func (g *graph) RemoveTransactions(ctx context.Context, transactionIDsToRemove [
 	// maybe they got reverted before being endorsed or whatever it is not the concern of the graph to validate this
 	// the graph just gets redrawn based on the dependencies that remain after a transaction is removed
 
+	removed := make([]string, 0, len(transactionIDsToRemove))
 	for _, transactionID := range transactionIDsToRemove {
 		if g.allTransactions[transactionID] == nil {
 			log.L(ctx).Infof("Transaction %s already removed", transactionID)
 		} else {
 			delete(g.allTransactions, transactionID)
+			delete(g.sequenceIDs, transactionID)
+			removed = append(removed, transactionID)
 		}
 	}
+	g.deleteSequenceIDs(ctx, removed)
+}
+
+// Export returns the transactions in the graph, and the dependencies between them, sorted by transaction ID
+func (g *graph) Export(ctx context.Context) (*components.PrivateTxDependencyGraph, error) {
+	g.mux.Lock()
+	defer g.mux.Unlock()
+
+	err := g.buildMatrix(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	export := &components.PrivateTxDependencyGraph{
+		Transactions: make([]*components.PrivateTxGraphNode, 0, len(g.transactions)),
+		Dependencies: []*components.PrivateTxGraphEdge{},
+	}
+	for minterIndex, minter := range g.transactions {
+		status, err := minter.GetTxStatus(ctx)
+		if err != nil {
+			return nil, err
+		}
+		export.Transactions = append(export.Transactions, &components.PrivateTxGraphNode{
+			TxID:        minter.ID(ctx).String(),
+			Status:      status.Status,
+			LatestEvent: status.LatestEvent,
+			Endorsed:    minter.IsEndorsed(ctx),
+		})
+		for spenderIndex, states := range g.transactionsMatrix[minterIndex] {
+			if len(states) > 0 {
+				sortedStates := append([]string{}, states...)
+				sort.Strings(sortedStates)
+				export.Dependencies = append(export.Dependencies, &components.PrivateTxGraphEdge{
+					Dependency: minter.ID(ctx).String(),
+					Dependant:  g.transactions[spenderIndex].ID(ctx).String(),
+					States:     sortedStates,
+				})
+			}
+		}
+	}
+	// the order of the matrix is random, so sort for stable output
+	sort.Slice(export.Transactions, func(i, j int) bool {
+		return export.Transactions[i].TxID < export.Transactions[j].TxID
+	})
+	sort.Slice(export.Dependencies, func(i, j int) bool {
+		if export.Dependencies[i].Dependency != export.Dependencies[j].Dependency {
+			return export.Dependencies[i].Dependency < export.Dependencies[j].Dependency
+		}
+		return export.Dependencies[i].Dependant < export.Dependencies[j].Dependant
+	})
+	return export, nil
+}
+
+// dependencyGraphDOT renders an exported graph in the Graphviz DOT language, with an edge from each
+// transaction to the transactions that spend its states
+func dependencyGraphDOT(dg *components.PrivateTxDependencyGraph) string {
+	var b strings.Builder
+	b.WriteString("digraph dependencies {\n")
+	for _, n := range dg.Transactions {
+		label := fmt.Sprintf("%s\n%s", n.TxID, n.Status)
+		if n.Endorsed {
+			label += " (endorsed)"
+		}
+		fmt.Fprintf(&b, "  %q [label=%q];\n", n.TxID, label)
+	}
+	for _, e := range dg.Dependencies {
+		fmt.Fprintf(&b, "  %q -> %q [label=%q];\n", e.Dependency, e.Dependant, strings.Join(e.States, ","))
+	}
+	b.WriteString("}\n")
+	return b.String()
 }
@@ -18,10 +18,17 @@ package privatetxnmgr
 
 import (
 	"context"
+	"fmt"
+	"sort"
+	"sync"
 	"testing"
 
+	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/google/uuid"
+	"github.com/kaleido-io/paladin/core/internal/components"
 	"github.com/kaleido-io/paladin/core/mocks/privatetxnmgrmocks"
+	"github.com/kaleido-io/paladin/core/pkg/persistence"
+	"github.com/kaleido-io/paladin/core/pkg/persistence/mockpersistence"
 	"github.com/kaleido-io/paladin/toolkit/pkg/tktypes"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -35,9 +42,18 @@ func NewMockTransactionProcessorForTesting(t *testing.T, transactionID uuid.UUID
 	mockTransactionProcessor.On("OutputStateIDs", mock.Anything).Return(outputStateIDs).Maybe()
 	mockTransactionProcessor.On("IsEndorsed", mock.Anything, mock.Anything).Return(endorsed).Maybe()
 	mockTransactionProcessor.On("Signer", mock.Anything).Return(signer).Maybe()
+	mockTransactionProcessor.On("SetDispatchBlockedReason", mock.Anything, mock.Anything).Return().Maybe()
+	mockTransactionProcessor.On("Stop", mock.Anything).Return().Maybe()
 	return mockTransactionProcessor
 }
 
+func newGraphForTesting(t *testing.T, ctx context.Context) Graph {
+	p, persistenceDone, err := persistence.NewUnitTestPersistence(ctx, "privatetxmgr")
+	require.NoError(t, err)
+	t.Cleanup(persistenceDone)
+	return NewGraph(p, *tktypes.RandAddress())
+}
+
 func TestAddTransactions(t *testing.T) {
 	ctx := context.Background()
 	signer := tktypes.RandHex(32)
@@ -54,11 +70,11 @@ func TestAddTransactions(t *testing.T) {
 	TxID3 := uuid.New()
 	mockTransactionProcessor3 := NewMockTransactionProcessorForTesting(t, TxID3, []string{"S0", "S1A"}, []string{"S3"}, false, signer)
 
-	testGraph := NewGraph()
-	testGraph.AddTransaction(ctx, mockTransactionProcessor0)
-	testGraph.AddTransaction(ctx, mockTransactionProcessor1)
-	testGraph.AddTransaction(ctx, mockTransactionProcessor2)
-	testGraph.AddTransaction(ctx, mockTransactionProcessor3)
+	testGraph := newGraphForTesting(t, ctx)
+	require.NoError(t, testGraph.AddTransaction(ctx, mockTransactionProcessor0))
+	require.NoError(t, testGraph.AddTransaction(ctx, mockTransactionProcessor1))
+	require.NoError(t, testGraph.AddTransaction(ctx, mockTransactionProcessor2))
+	require.NoError(t, testGraph.AddTransaction(ctx, mockTransactionProcessor3))
 
 	assert.True(t, testGraph.IncludesTransaction(TxID0.String()))
 	assert.True(t, testGraph.IncludesTransaction(TxID1.String()))
@@ -70,7 +86,7 @@ func TestAddTransactions(t *testing.T) {
 func TestRemoveTransactions(t *testing.T) {
 	ctx := context.Background()
 
-	testGraph := NewGraph()
+	testGraph := newGraphForTesting(t, ctx)
 	signer := tktypes.RandHex(32)
 
 	TxID0 := uuid.New()
@@ -85,10 +101,10 @@ func TestRemoveTransactions(t *testing.T) {
 	TxID3 := uuid.New()
 	mockTransactionProcessor3 := NewMockTransactionProcessorForTesting(t, TxID3, []string{"S0", "S1A"}, []string{"S3"}, false, signer)
 
-	testGraph.AddTransaction(ctx, mockTransactionProcessor0)
-	testGraph.AddTransaction(ctx, mockTransactionProcessor1)
-	testGraph.AddTransaction(ctx, mockTransactionProcessor2)
-	testGraph.AddTransaction(ctx, mockTransactionProcessor3)
+	require.NoError(t, testGraph.AddTransaction(ctx, mockTransactionProcessor0))
+	require.NoError(t, testGraph.AddTransaction(ctx, mockTransactionProcessor1))
+	require.NoError(t, testGraph.AddTransaction(ctx, mockTransactionProcessor2))
+	require.NoError(t, testGraph.AddTransaction(ctx, mockTransactionProcessor3))
 
 	testGraph.RemoveTransactions(ctx, []string{"tx1", "tx2"})
 
@@ -111,7 +127,7 @@ func TestScenario1(t *testing.T) {
 
 	// build the matrix by adding transactions
 	ctx := context.Background()
-	testGraph := NewGraph()
+	testGraph := newGraphForTesting(t, ctx)
 	signer := tktypes.RandHex(32)
 
 	TxID0 := uuid.New()
@@ -130,11 +146,11 @@ func TestScenario1(t *testing.T) {
 	TxID4 := uuid.New()
 	mockTransactionProcessor4 := NewMockTransactionProcessorForTesting(t, TxID4, []string{"S1B", "S2"}, []string{"S4"}, true, signer)
 
-	testGraph.AddTransaction(ctx, mockTransactionProcessor0)
-	testGraph.AddTransaction(ctx, mockTransactionProcessor1)
-	testGraph.AddTransaction(ctx, mockTransactionProcessor2)
-	testGraph.AddTransaction(ctx, mockTransactionProcessor3)
-	testGraph.AddTransaction(ctx, mockTransactionProcessor4)
+	require.NoError(t, testGraph.AddTransaction(ctx, mockTransactionProcessor0))
+	require.NoError(t, testGraph.AddTransaction(ctx, mockTransactionProcessor1))
+	require.NoError(t, testGraph.AddTransaction(ctx, mockTransactionProcessor2))
+	require.NoError(t, testGraph.AddTransaction(ctx, mockTransactionProcessor3))
+	require.NoError(t, testGraph.AddTransaction(ctx, mockTransactionProcessor4))
 
 	dispatchable, err := testGraph.GetDispatchableTransactions(ctx)
 	require.NoError(t, err)
@@ -188,7 +204,7 @@ func TestScenario2(t *testing.T) {
 
 	// build the matrix by adding transactions
 	ctx := context.Background()
-	testGraph := NewGraph()
+	testGraph := newGraphForTesting(t, ctx)
 	signer := tktypes.RandHex(32)
 
 	TxID0 := uuid.New()
@@ -210,12 +226,12 @@ func TestScenario2(t *testing.T) {
 	TxID5 := uuid.New()
 	mockTransactionProcessor5 := NewMockTransactionProcessorForTesting(t, TxID5, []string{"S3"}, []string{"S5"}, true, signer)
 
-	testGraph.AddTransaction(ctx, mockTransactionProcessor0)
-	testGraph.AddTransaction(ctx, mockTransactionProcessor1)
-	testGraph.AddTransaction(ctx, mockTransactionProcessor2)
-	testGraph.AddTransaction(ctx, mockTransactionProcessor3)
-	testGraph.AddTransaction(ctx, mockTransactionProcessor4)
-	testGraph.AddTransaction(ctx, mockTransactionProcessor5)
+	require.NoError(t, testGraph.AddTransaction(ctx, mockTransactionProcessor0))
+	require.NoError(t, testGraph.AddTransaction(ctx, mockTransactionProcessor1))
+	require.NoError(t, testGraph.AddTransaction(ctx, mockTransactionProcessor2))
+	require.NoError(t, testGraph.AddTransaction(ctx, mockTransactionProcessor3))
+	require.NoError(t, testGraph.AddTransaction(ctx, mockTransactionProcessor4))
+	require.NoError(t, testGraph.AddTransaction(ctx, mockTransactionProcessor5))
 
 	dispatchable, err := testGraph.GetDispatchableTransactions(ctx)
 	require.NoError(t, err)
@@ -250,3 +266,261 @@ func TestScenario2(t *testing.T) {
 	assert.True(t, isBefore(TxID3.String(), TxID5.String()))
 
 }
+
+func TestExportDependencyGraph(t *testing.T) {
+	// 0 and 1 are independent, 2 spends states minted by both of them, and 3 spends a state minted by 2
+	ctx := context.Background()
+	testGraph := newGraphForTesting(t, ctx)
+	signer := tktypes.RandHex(32)
+
+	txIDs := []uuid.UUID{
+		uuid.MustParse("00000000-0000-0000-0000-000000000000"),
+		uuid.MustParse("11111111-1111-1111-1111-111111111111"),
+		uuid.MustParse("22222222-2222-2222-2222-222222222222"),
+		uuid.MustParse("33333333-3333-3333-3333-333333333333"),
+	}
+	inputs := [][]string{{}, {}, {"S1A", "S0"}, {"S2"}}
+	outputs := [][]string{{"S0"}, {"S1A", "S1B"}, {"S2"}, {"S3"}}
+	endorsed := []bool{true, true, true, false}
+	statuses := []string{"dispatched", "endorsed", "endorsed", "pending"}
+	// add in reverse order, to show the output is sorted
+	for i := len(txIDs) - 1; i >= 0; i-- {
+		mtp := NewMockTransactionProcessorForTesting(t, txIDs[i], inputs[i], outputs[i], endorsed[i], signer)
+		mtp.On("GetTxStatus", mock.Anything).Return(components.PrivateTxStatus{
+			TxID:        txIDs[i].String(),
+			Status:      statuses[i],
+			LatestEvent: "event" + statuses[i],
+		}, nil)
+		require.NoError(t, testGraph.AddTransaction(ctx, mtp))
+	}
+
+	dg, err := testGraph.Export(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, &components.PrivateTxDependencyGraph{
+		Transactions: []*components.PrivateTxGraphNode{
+			{TxID: txIDs[0].String(), Status: "dispatched", LatestEvent: "eventdispatched", Endorsed: true},
+			{TxID: txIDs[1].String(), Status: "endorsed", LatestEvent: "eventendorsed", Endorsed: true},
+			{TxID: txIDs[2].String(), Status: "endorsed", LatestEvent: "eventendorsed", Endorsed: true},
+			{TxID: txIDs[3].String(), Status: "pending", LatestEvent: "eventpending", Endorsed: false},
+		},
+		Dependencies: []*components.PrivateTxGraphEdge{
+			{Dependency: txIDs[0].String(), Dependant: txIDs[2].String(), States: []string{"S0"}},
+			{Dependency: txIDs[1].String(), Dependant: txIDs[2].String(), States: []string{"S1A"}},
+			{Dependency: txIDs[2].String(), Dependant: txIDs[3].String(), States: []string{"S2"}},
+		},
+	}, dg)
+
+	assert.Equal(t, `digraph dependencies {
+  "00000000-0000-0000-0000-000000000000" [label="00000000-0000-0000-0000-000000000000\ndispatched (endorsed)"];
+  "11111111-1111-1111-1111-111111111111" [label="11111111-1111-1111-1111-111111111111\nendorsed (endorsed)"];
+  "22222222-2222-2222-2222-222222222222" [label="22222222-2222-2222-2222-222222222222\nendorsed (endorsed)"];
+  "33333333-3333-3333-3333-333333333333" [label="33333333-3333-3333-3333-333333333333\npending"];
+  "00000000-0000-0000-0000-000000000000" -> "22222222-2222-2222-2222-222222222222" [label="S0"];
+  "11111111-1111-1111-1111-111111111111" -> "22222222-2222-2222-2222-222222222222" [label="S1A"];
+  "22222222-2222-2222-2222-222222222222" -> "33333333-3333-3333-3333-333333333333" [label="S2"];
+}
+`, dependencyGraphDOT(dg))
+}
+
+func TestExportDependencyGraphContention(t *testing.T) {
+	ctx := context.Background()
+	testGraph := newGraphForTesting(t, ctx)
+	signer := tktypes.RandHex(32)
+
+	require.NoError(t, testGraph.AddTransaction(ctx, NewMockTransactionProcessorForTesting(t, uuid.New(), []string{"S0"}, []string{}, false, signer)))
+	require.NoError(t, testGraph.AddTransaction(ctx, NewMockTransactionProcessorForTesting(t, uuid.New(), []string{"S0"}, []string{}, false, signer)))
+
+	_, err := testGraph.Export(ctx)
+	assert.Regexp(t, "PD011823", err)
+}
+
+func TestGetTxDependencyGraphNoSequencer(t *testing.T) {
+	ctx := context.Background()
+	p := &privateTxManager{sequencers: map[string]*Sequencer{}}
+
+	dg, err := p.GetTxDependencyGraph(ctx, tktypes.RandAddress().String())
+	require.NoError(t, err)
+	assert.Empty(t, dg.Transactions)
+	assert.Empty(t, dg.Dependencies)
+
+	dot, err := p.GetTxDependencyGraphDOT(ctx, tktypes.RandAddress().String())
+	require.NoError(t, err)
+	assert.Equal(t, "digraph dependencies {\n}\n", dot)
+}
+
+// The reason most recently given to the mock transaction flow for not dispatching it
+func lastDispatchBlockedReason(mtp *privatetxnmgrmocks.TransactionFlow) *string {
+	var reason *string
+	for _, call := range mtp.Calls {
+		if call.Method == "SetDispatchBlockedReason" {
+			r := call.Arguments.String(1)
+			reason = &r
+		}
+	}
+	return reason
+}
+
+func TestGraphDispatchBlockedReasons(t *testing.T) {
+	ctx := context.Background()
+	signer := tktypes.RandHex(32)
+	testGraph := newGraphForTesting(t, ctx)
+
+	// tx0 is not endorsed, so tx1 that spends its state is held, as is tx2 that spends a state of tx1.
+	// tx3 is independent and can be dispatched.
+	txIDs := []uuid.UUID{uuid.New(), uuid.New(), uuid.New(), uuid.New()}
+	tx0 := NewMockTransactionProcessorForTesting(t, txIDs[0], []string{}, []string{"S0"}, false, signer)
+	tx1 := NewMockTransactionProcessorForTesting(t, txIDs[1], []string{"S0"}, []string{"S1"}, true, signer)
+	tx2 := NewMockTransactionProcessorForTesting(t, txIDs[2], []string{"S1"}, []string{"S2"}, true, signer)
+	tx3 := NewMockTransactionProcessorForTesting(t, txIDs[3], []string{}, []string{"S3"}, true, signer)
+	for _, tx := range []*privatetxnmgrmocks.TransactionFlow{tx0, tx1, tx2, tx3} {
+		require.NoError(t, testGraph.AddTransaction(ctx, tx))
+	}
+
+	dispatchable, err := testGraph.GetDispatchableTransactions(ctx)
+	require.NoError(t, err)
+	require.Len(t, dispatchable[signer], 1)
+
+	assert.Nil(t, lastDispatchBlockedReason(tx0)) // blocked before it is endorsed, not by the graph
+	require.NotNil(t, lastDispatchBlockedReason(tx1))
+	assert.Regexp(t, "PD011852.*"+txIDs[0].String(), *lastDispatchBlockedReason(tx1))
+	require.NotNil(t, lastDispatchBlockedReason(tx2))
+	assert.Regexp(t, "PD011852.*"+txIDs[1].String(), *lastDispatchBlockedReason(tx2))
+	assert.Equal(t, "", *lastDispatchBlockedReason(tx3))
+}
+
+func dispatchOrder(t *testing.T, ctx context.Context, testGraph Graph, signer string) []uuid.UUID {
+	dispatchableTransactions, err := testGraph.GetDispatchableTransactions(ctx)
+	require.NoError(t, err)
+	txIDs := make([]uuid.UUID, 0, len(dispatchableTransactions[signer]))
+	for _, tx := range dispatchableTransactions[signer] {
+		txIDs = append(txIDs, tx.ID(ctx))
+	}
+	return txIDs
+}
+
+func TestGraphDispatchOrderFollowsSequenceIDs(t *testing.T) {
+	ctx := context.Background()
+	signer := tktypes.RandHex(32)
+
+	// independent transactions, in the order they were assembled
+	txIDs := make([]uuid.UUID, 10)
+	transactions := make([]*privatetxnmgrmocks.TransactionFlow, len(txIDs))
+	for i := range txIDs {
+		txIDs[i] = uuid.New()
+		transactions[i] = NewMockTransactionProcessorForTesting(t, txIDs[i], []string{}, []string{fmt.Sprintf("S%d", i)}, true, signer)
+	}
+
+	p, persistenceDone, err := persistence.NewUnitTestPersistence(ctx, "privatetxmgr")
+	require.NoError(t, err)
+	defer persistenceDone()
+	contractAddress := *tktypes.RandAddress()
+
+	testGraph := NewGraph(p, contractAddress)
+	for _, tx := range transactions {
+		require.NoError(t, testGraph.AddTransaction(ctx, tx))
+	}
+	// adding again does not change the sequence ID
+	require.NoError(t, testGraph.AddTransaction(ctx, transactions[0]))
+
+	// the order is stable, rather than following the order of the map
+	for i := 0; i < 5; i++ {
+		assert.Equal(t, txIDs, dispatchOrder(t, ctx, testGraph, signer))
+	}
+
+	// a graph reloaded from the DB, as after a restart, dispatches them in the same order even when they are added in reverse
+	restartedGraph := NewGraph(p, contractAddress)
+	for i := len(transactions) - 1; i >= 0; i-- {
+		require.NoError(t, restartedGraph.AddTransaction(ctx, transactions[i]))
+	}
+	assert.Equal(t, txIDs, dispatchOrder(t, ctx, restartedGraph, signer))
+
+	// whereas the sequence IDs of another contract are independent
+	otherTxID := uuid.New()
+	otherGraph := NewGraph(p, *tktypes.RandAddress())
+	require.NoError(t, otherGraph.AddTransaction(ctx, NewMockTransactionProcessorForTesting(t, otherTxID, []string{}, []string{"S10"}, true, signer)))
+	assert.Equal(t, uint64(1), otherGraph.(*graph).sequenceIDs[otherTxID.String()])
+
+	// a transaction that leaves the graph, such as to be re-assembled, goes to the back when it returns
+	restartedGraph.RemoveTransaction(ctx, txIDs[0].String())
+	require.NoError(t, restartedGraph.AddTransaction(ctx, transactions[0]))
+	assert.Equal(t, append(append([]uuid.UUID{}, txIDs[1:]...), txIDs[0]), dispatchOrder(t, ctx, restartedGraph, signer))
+
+	// and dispatched transactions are no longer persisted, so the order resumes after those remaining
+	restartedGraph.RemoveTransactions(ctx, []string{txIDs[1].String(), txIDs[2].String()})
+	reloadedGraph := NewGraph(p, contractAddress)
+	require.NoError(t, reloadedGraph.AddTransaction(ctx, transactions[1]))
+	require.NoError(t, reloadedGraph.AddTransaction(ctx, transactions[3]))
+	assert.Equal(t, []uuid.UUID{txIDs[3], txIDs[1]}, dispatchOrder(t, ctx, reloadedGraph, signer))
+}
+
+func TestGraphSequenceIDsConcurrent(t *testing.T) {
+	ctx := context.Background()
+	signer := tktypes.RandHex(32)
+
+	testGraph := newGraphForTesting(t, ctx)
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		tx := NewMockTransactionProcessorForTesting(t, uuid.New(), []string{}, []string{fmt.Sprintf("S%d", i)}, true, signer)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, testGraph.AddTransaction(ctx, tx))
+		}()
+	}
+	wg.Wait()
+
+	// unique and gap-free
+	sequenceIDs := make([]uint64, 0, 50)
+	for _, sequenceID := range testGraph.(*graph).sequenceIDs {
+		sequenceIDs = append(sequenceIDs, sequenceID)
+	}
+	sort.Slice(sequenceIDs, func(i, j int) bool { return sequenceIDs[i] < sequenceIDs[j] })
+	for i, sequenceID := range sequenceIDs {
+		assert.Equal(t, uint64(i+1), sequenceID)
+	}
+	assert.Len(t, sequenceIDs, 50)
+}
+
+func TestGraphSequenceIDPersistenceErrors(t *testing.T) {
+	ctx := context.Background()
+	signer := tktypes.RandHex(32)
+
+	db, err := mockpersistence.NewSQLMockProvider()
+	require.NoError(t, err)
+	testGraph := NewGraph(db.P, *tktypes.RandAddress())
+
+	txID := uuid.New()
+	tx := NewMockTransactionProcessorForTesting(t, txID, []string{}, []string{"S0"}, true, signer)
+
+	// the transaction is not added without a sequence ID
+	db.Mock.ExpectBegin()
+	db.Mock.ExpectQuery("SELECT.*private_tx_sequence_ids").WillReturnError(fmt.Errorf("pop"))
+	db.Mock.ExpectRollback()
+	err = testGraph.AddTransaction(ctx, tx)
+	assert.Regexp(t, "pop", err)
+	assert.False(t, testGraph.IncludesTransaction(txID.String()))
+
+	db.Mock.ExpectBegin()
+	db.Mock.ExpectQuery("SELECT.*private_tx_sequence_ids").WillReturnRows(sqlmock.NewRows([]string{}))
+	db.Mock.ExpectQuery("SELECT.*private_tx_sequence_ids").WillReturnError(fmt.Errorf("pop"))
+	db.Mock.ExpectRollback()
+	err = testGraph.AddTransaction(ctx, tx)
+	assert.Regexp(t, "pop", err)
+	assert.False(t, testGraph.IncludesTransaction(txID.String()))
+
+	db.Mock.ExpectBegin()
+	db.Mock.ExpectQuery("SELECT.*private_tx_sequence_ids").WillReturnRows(sqlmock.NewRows([]string{}))
+	db.Mock.ExpectQuery("SELECT.*private_tx_sequence_ids").WillReturnRows(sqlmock.NewRows([]string{"sequence_id"}).AddRow(41))
+	db.Mock.ExpectExec("INSERT.*private_tx_sequence_ids").WillReturnResult(sqlmock.NewResult(1, 1))
+	db.Mock.ExpectCommit()
+	err = testGraph.AddTransaction(ctx, tx)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(42), testGraph.(*graph).sequenceIDs[txID.String()])
+
+	// failing to delete the sequence ID does not stop the transaction leaving the graph
+	db.Mock.ExpectExec("DELETE.*private_tx_sequence_ids").WillReturnError(fmt.Errorf("pop"))
+	testGraph.RemoveTransaction(ctx, txID.String())
+	assert.False(t, testGraph.IncludesTransaction(txID.String()))
+
+	require.NoError(t, db.Mock.ExpectationsWereMet())
+}
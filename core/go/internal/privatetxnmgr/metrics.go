@@ -0,0 +1,126 @@
+/*
+ * Copyright © 2024 Kaleido, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with
+ * the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package privatetxnmgr
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/kaleido-io/paladin/core/internal/privatetxnmgr/ptmgrtypes"
+	"github.com/kaleido-io/paladin/toolkit/pkg/tktypes"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	metricsSequencerInFlightTransactions = "paladin_privatetxmgr_sequencer_inflight_transactions"
+	metricsSequencerDeferredTransactions = "paladin_privatetxmgr_sequencer_deferred_transactions"
+	metricsSequencerEventsProcessed      = "paladin_privatetxmgr_sequencer_events_processed_total"
+	metricsSequencerEventsUnprocessed    = "paladin_privatetxmgr_sequencer_events_unprocessed_total"
+	metricsSequencerPendingEvents        = "paladin_privatetxmgr_sequencer_pending_events"
+	metricsUntrustedEndorsements         = "paladin_privatetxmgr_untrusted_endorsements_total"
+	metricsContractLabel                 = "contract"
+	metricsEventLabel                    = "event"
+)
+
+type privateTxManagerMetrics struct {
+	inFlightTransactions  *prometheus.GaugeVec
+	deferredTransactions  *prometheus.GaugeVec
+	eventsProcessed       *prometheus.CounterVec
+	eventsUnprocessed     *prometheus.CounterVec
+	pendingEvents         *prometheus.GaugeVec
+	untrustedEndorsements prometheus.Counter
+}
+
+func newPrivateTxManagerMetrics() *privateTxManagerMetrics {
+	m := &privateTxManagerMetrics{
+		inFlightTransactions: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: metricsSequencerInFlightTransactions,
+			Help: "Number of transactions in memory in the sequencer for each contract address",
+		}, []string{metricsContractLabel}),
+		deferredTransactions: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: metricsSequencerDeferredTransactions,
+			Help: "Number of transactions waiting for capacity in the sequencer for each contract address",
+		}, []string{metricsContractLabel}),
+		eventsProcessed: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: metricsSequencerEventsProcessed,
+			Help: "Number of transaction events applied by sequencers, for each type of event",
+		}, []string{metricsEventLabel}),
+		eventsUnprocessed: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: metricsSequencerEventsUnprocessed,
+			Help: "Number of transaction events discarded by sequencers without being applied, for each type of event",
+		}, []string{metricsEventLabel}),
+		pendingEvents: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: metricsSequencerPendingEvents,
+			Help: "Number of transaction events queued for the sequencer for each contract address",
+		}, []string{metricsContractLabel}),
+		untrustedEndorsements: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: metricsUntrustedEndorsements,
+			Help: "Number of endorsements received from a party that was not in the expected endorser set",
+		}),
+	}
+	return m
+}
+
+func (m *privateTxManagerMetrics) register(registry prometheus.Registerer) {
+	registry.MustRegister(m.inFlightTransactions, m.deferredTransactions, m.eventsProcessed, m.eventsUnprocessed, m.pendingEvents, m.untrustedEndorsements)
+}
+
+func (m *privateTxManagerMetrics) recordSequencerTransactions(contractAddr tktypes.EthAddress, inFlight, deferred int) {
+	if m == nil {
+		return
+	}
+	m.inFlightTransactions.WithLabelValues(contractAddr.String()).Set(float64(inFlight))
+	m.deferredTransactions.WithLabelValues(contractAddr.String()).Set(float64(deferred))
+}
+
+// An event that is not applied is usually for a transaction that is no longer in memory, but a steady
+// increase for one type of event indicates events that are being misrouted, or a stuck transaction
+func (m *privateTxManagerMetrics) recordSequencerEvent(event ptmgrtypes.PrivateTransactionEvent, applied bool) {
+	if m == nil {
+		return
+	}
+	eventType := strings.TrimPrefix(fmt.Sprintf("%T", event), "*ptmgrtypes.")
+	if applied {
+		m.eventsProcessed.WithLabelValues(eventType).Inc()
+	} else {
+		m.eventsUnprocessed.WithLabelValues(eventType).Inc()
+	}
+}
+
+func (m *privateTxManagerMetrics) recordSequencerPendingEvents(contractAddr tktypes.EthAddress, pending int) {
+	if m == nil {
+		return
+	}
+	m.pendingEvents.WithLabelValues(contractAddr.String()).Set(float64(pending))
+}
+
+// Any increase is security relevant, as it means a node has responded on behalf of a party we did not ask
+func (m *privateTxManagerMetrics) recordUntrustedEndorsement() {
+	if m == nil {
+		return
+	}
+	m.untrustedEndorsements.Inc()
+}
+
+// Sequencers are created and stopped on demand, so we remove the series to avoid unbounded cardinality
+func (m *privateTxManagerMetrics) removeSequencer(contractAddr tktypes.EthAddress) {
+	if m == nil {
+		return
+	}
+	m.inFlightTransactions.DeleteLabelValues(contractAddr.String())
+	m.deferredTransactions.DeleteLabelValues(contractAddr.String())
+	m.pendingEvents.DeleteLabelValues(contractAddr.String())
+}
@@ -0,0 +1,163 @@
+/*
+ * Copyright © 2024 Kaleido, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with
+ * the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package privatetxnmgr
+
+import (
+	"testing"
+
+	"github.com/kaleido-io/paladin/core/internal/privatetxnmgr/ptmgrtypes"
+	"github.com/kaleido-io/paladin/toolkit/pkg/tktypes"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestPrivateTxManagerMetrics() (*privateTxManagerMetrics, *prometheus.Registry) {
+	registry := prometheus.NewRegistry()
+	m := newPrivateTxManagerMetrics()
+	m.register(registry)
+	return m, registry
+}
+
+// returns contract -> [inFlight, deferred]
+func gatherSequencerMetrics(t *testing.T, g prometheus.Gatherer) map[string][2]float64 {
+	families, err := g.Gather()
+	require.NoError(t, err)
+	results := make(map[string][2]float64)
+	for _, mf := range families {
+		if mf.GetName() != metricsSequencerInFlightTransactions && mf.GetName() != metricsSequencerDeferredTransactions {
+			continue
+		}
+		for _, metric := range mf.GetMetric() {
+			require.Len(t, metric.GetLabel(), 1)
+			assert.Equal(t, metricsContractLabel, metric.GetLabel()[0].GetName())
+			contract := metric.GetLabel()[0].GetValue()
+			v := results[contract]
+			switch mf.GetName() {
+			case metricsSequencerInFlightTransactions:
+				v[0] = metric.GetGauge().GetValue()
+			case metricsSequencerDeferredTransactions:
+				v[1] = metric.GetGauge().GetValue()
+			}
+			results[contract] = v
+		}
+	}
+	return results
+}
+
+func TestSequencerMetricsRecordAndRemove(t *testing.T) {
+	m, registry := newTestPrivateTxManagerMetrics()
+	addr1 := *tktypes.RandAddress()
+	addr2 := *tktypes.RandAddress()
+
+	m.recordSequencerTransactions(addr1, 5, 2)
+	m.recordSequencerTransactions(addr2, 1, 0)
+	results := gatherSequencerMetrics(t, registry)
+	assert.Equal(t, [2]float64{5, 2}, results[addr1.String()])
+	assert.Equal(t, [2]float64{1, 0}, results[addr2.String()])
+
+	m.removeSequencer(addr1)
+	results = gatherSequencerMetrics(t, registry)
+	assert.NotContains(t, results, addr1.String())
+	assert.Contains(t, results, addr2.String())
+
+	// nil safe for sequencers constructed without metrics
+	var nilMetrics *privateTxManagerMetrics
+	nilMetrics.recordSequencerTransactions(addr1, 1, 1)
+	nilMetrics.removeSequencer(addr1)
+}
+
+// returns event type -> [processed, unprocessed]
+func gatherSequencerEventMetrics(t *testing.T, g prometheus.Gatherer) map[string][2]float64 {
+	families, err := g.Gather()
+	require.NoError(t, err)
+	results := make(map[string][2]float64)
+	for _, mf := range families {
+		if mf.GetName() != metricsSequencerEventsProcessed && mf.GetName() != metricsSequencerEventsUnprocessed {
+			continue
+		}
+		for _, metric := range mf.GetMetric() {
+			require.Len(t, metric.GetLabel(), 1)
+			assert.Equal(t, metricsEventLabel, metric.GetLabel()[0].GetName())
+			eventType := metric.GetLabel()[0].GetValue()
+			v := results[eventType]
+			if mf.GetName() == metricsSequencerEventsProcessed {
+				v[0] = metric.GetCounter().GetValue()
+			} else {
+				v[1] = metric.GetCounter().GetValue()
+			}
+			results[eventType] = v
+		}
+	}
+	return results
+}
+
+func gatherSequencerPendingEvents(t *testing.T, g prometheus.Gatherer) map[string]float64 {
+	families, err := g.Gather()
+	require.NoError(t, err)
+	results := make(map[string]float64)
+	for _, mf := range families {
+		if mf.GetName() == metricsSequencerPendingEvents {
+			for _, metric := range mf.GetMetric() {
+				results[metric.GetLabel()[0].GetValue()] = metric.GetGauge().GetValue()
+			}
+		}
+	}
+	return results
+}
+
+func TestSequencerEventMetricsRecordAndRemove(t *testing.T) {
+	m, registry := newTestPrivateTxManagerMetrics()
+	addr := *tktypes.RandAddress()
+
+	m.recordSequencerEvent(&ptmgrtypes.TransactionSubmittedEvent{}, true)
+	m.recordSequencerEvent(&ptmgrtypes.TransactionSubmittedEvent{}, true)
+	m.recordSequencerEvent(&ptmgrtypes.TransactionEndorsedEvent{}, false)
+	results := gatherSequencerEventMetrics(t, registry)
+	assert.Equal(t, [2]float64{2, 0}, results["TransactionSubmittedEvent"])
+	assert.Equal(t, [2]float64{0, 1}, results["TransactionEndorsedEvent"])
+
+	m.recordSequencerPendingEvents(addr, 3)
+	assert.Equal(t, float64(3), gatherSequencerPendingEvents(t, registry)[addr.String()])
+	m.removeSequencer(addr)
+	assert.NotContains(t, gatherSequencerPendingEvents(t, registry), addr.String())
+
+	// nil safe for sequencers constructed without metrics
+	var nilMetrics *privateTxManagerMetrics
+	nilMetrics.recordSequencerEvent(&ptmgrtypes.TransactionSubmittedEvent{}, true)
+	nilMetrics.recordSequencerPendingEvents(addr, 1)
+}
+
+func gatherUntrustedEndorsements(t *testing.T, g prometheus.Gatherer) float64 {
+	families, err := g.Gather()
+	require.NoError(t, err)
+	for _, mf := range families {
+		if mf.GetName() == metricsUntrustedEndorsements {
+			return mf.GetMetric()[0].GetCounter().GetValue()
+		}
+	}
+	return 0
+}
+
+func TestUntrustedEndorsementMetric(t *testing.T) {
+	m, registry := newTestPrivateTxManagerMetrics()
+	m.recordUntrustedEndorsement()
+	m.recordUntrustedEndorsement()
+	assert.Equal(t, float64(2), gatherUntrustedEndorsements(t, registry))
+
+	var nilMetrics *privateTxManagerMetrics
+	nilMetrics.recordUntrustedEndorsement()
+}
@@ -18,6 +18,7 @@ package privatetxnmgr
 import (
 	"context"
 	"encoding/json"
+	"slices"
 	"sync"
 
 	"github.com/google/uuid"
@@ -40,8 +41,10 @@ import (
 	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/types/known/anypb"
 
+	"github.com/kaleido-io/paladin/toolkit/pkg/algorithms"
 	"github.com/kaleido-io/paladin/toolkit/pkg/log"
 	"github.com/kaleido-io/paladin/toolkit/pkg/prototk"
+	"github.com/kaleido-io/paladin/toolkit/pkg/verifiers"
 )
 
 type privateTxManager struct {
@@ -57,10 +60,14 @@ type privateTxManager struct {
 	subscribersLock      sync.Mutex
 	syncPoints           syncpoints.SyncPoints
 	blockHeight          int64
+	metrics              *privateTxManagerMetrics
+	pinnedSigners        map[tktypes.EthAddress]string
+	untrustedPolicy      string
 }
 
 // Init implements Engine.
 func (p *privateTxManager) PreInit(c components.PreInitComponents) (*components.ManagerInitResult, error) {
+	p.metrics.register(c.MetricsManager().Registry())
 	return &components.ManagerInitResult{
 		PreCommitHandler: func(ctx context.Context, dbTX persistence.DBTX, blocks []*pldapi.IndexedBlock, transactions []*blockindexer.IndexedTransactionNotify) error {
 			log.L(ctx).Debug("PrivateTxManager PreCommitHandler")
@@ -75,12 +82,59 @@ func (p *privateTxManager) PreInit(c components.PreInitComponents) (*components.
 }
 
 func (p *privateTxManager) PostInit(c components.AllComponents) error {
+	if len(p.config.AutoApproveEndorsementDomains) > 0 && !confutil.Bool(p.config.DevelopmentMode, false) {
+		return i18n.NewError(p.ctx, msgs.MsgPrivateTxMgrAutoApproveNotDevMode, p.config.AutoApproveEndorsementDomains)
+	}
+	for domainName, quorum := range p.config.EndorsementQuorum {
+		if quorum < 1 {
+			return i18n.NewError(p.ctx, msgs.MsgPrivateTxMgrEndorsementQuorumInvalid, domainName, quorum)
+		}
+	}
+	for contractAddr, signer := range p.config.PinnedSigners {
+		addr, err := tktypes.ParseEthAddress(contractAddr)
+		if err != nil || signer == "" {
+			return i18n.NewError(p.ctx, msgs.MsgPrivateTxMgrPinnedSignerInvalid, contractAddr)
+		}
+		p.pinnedSigners[*addr] = signer
+	}
+	p.untrustedPolicy = confutil.StringNotEmpty(p.config.UntrustedEndorsementPolicy, *pldconf.PrivateTxManagerDefaults.UntrustedEndorsementPolicy)
+	switch p.untrustedPolicy {
+	case pldconf.UntrustedEndorsementPolicyDrop, pldconf.UntrustedEndorsementPolicyRevert:
+	default:
+		return i18n.NewError(p.ctx, msgs.MsgPrivateTxMgrInvalidUntrustedPolicy, p.untrustedPolicy)
+	}
 	p.components = c
 	p.nodeName = p.components.TransportManager().LocalNodeName()
+	if err := p.validatePinnedSigners(p.ctx); err != nil {
+		return err
+	}
 	p.syncPoints = syncpoints.NewSyncPoints(p.ctx, &p.config.Writer, c.Persistence(), c.TxManager(), c.PublicTxManager(), c.TransportManager())
 	return nil
 }
 
+// Pinned signers are checked once at startup, and must be existing keys on this node. We never create a key for a
+// pinned signer, so a mistyped identity cannot silently allocate one. A pinned signer that cannot be resolved is not
+// used, so the sequencer for the contract falls back to its default signer.
+func (p *privateTxManager) validatePinnedSigners(ctx context.Context) error {
+	for contractAddr, signer := range p.pinnedSigners {
+		identity, node, err := tktypes.PrivateIdentityLocator(signer).Validate(ctx, p.nodeName, false)
+		if err != nil {
+			return i18n.WrapError(ctx, err, msgs.MsgPrivateTxMgrPinnedSignerInvalid, contractAddr)
+		}
+		if node != p.nodeName {
+			return i18n.NewError(ctx, msgs.MsgPrivateTxMgrPinnedSignerNotLocal, signer, contractAddr, p.nodeName)
+		}
+		_, err = p.components.KeyManager().ResolveExistingKey(ctx, p.components.Persistence().NOTX(), identity, algorithms.ECDSA_SECP256K1, verifiers.ETH_ADDRESS)
+		if err != nil {
+			log.L(ctx).Warnf("Pinned signer %s for contract %s is unavailable, so will not be used: %s", signer, contractAddr, err)
+			delete(p.pinnedSigners, contractAddr)
+			continue
+		}
+		p.pinnedSigners[contractAddr] = identity
+	}
+	return nil
+}
+
 func (p *privateTxManager) Start() error {
 	p.syncPoints.Start()
 	return nil
@@ -95,6 +149,8 @@ func NewPrivateTransactionMgr(ctx context.Context, config *pldconf.PrivateTxMana
 		sequencers:           make(map[string]*Sequencer),
 		endorsementGatherers: make(map[string]ptmgrtypes.EndorsementGatherer),
 		subscribers:          make([]components.PrivateTxEventSubscriber, 0),
+		metrics:              newPrivateTxManagerMetrics(),
+		pinnedSigners:        make(map[tktypes.EthAddress]string),
 	}
 	p.ctx, p.ctxCancel = context.WithCancel(ctx)
 	return p
@@ -164,6 +220,10 @@ func (p *privateTxManager) getSequencerForContract(ctx context.Context, dbTX per
 				log.L(ctx).Errorf("Failed to create sequencer for contract %s: %s", contractAddr.String(), err)
 				return nil, err
 			}
+			newSequencer.metrics = p.metrics
+			newSequencer.endorsementQuorum = p.config.EndorsementQuorum[domainAPI.Domain().Name()]
+			newSequencer.pinnedSigner = p.pinnedSigners[contractAddr]
+			newSequencer.untrustedPolicy = p.untrustedPolicy
 			p.sequencers[contractAddr.String()] = newSequencer
 
 			sequencerDone, err := p.sequencers[contractAddr.String()].Start(ctx)
@@ -179,6 +239,7 @@ func (p *privateTxManager) getSequencerForContract(ctx context.Context, dbTX per
 				p.sequencersLock.Lock()
 				defer p.sequencersLock.Unlock()
 				delete(p.sequencers, contractAddr.String())
+				p.metrics.removeSequencer(contractAddr)
 			}()
 		}
 	}
@@ -196,7 +257,13 @@ func (p *privateTxManager) getEndorsementGathererForContract(ctx context.Context
 	if p.endorsementGatherers[contractAddr.String()] == nil {
 		// TODO: Consider scope of state in privateTxManager threading model
 		dCtx := p.components.StateManager().NewDomainContext(p.ctx /* background context */, domainSmartContract.Domain(), contractAddr)
-		endorsementGatherer := NewEndorsementGatherer(p.components.Persistence(), domainSmartContract, dCtx, p.components.KeyManager())
+		var endorsementGatherer ptmgrtypes.EndorsementGatherer
+		if slices.Contains(p.config.AutoApproveEndorsementDomains, domainSmartContract.Domain().Name()) {
+			// only possible in development mode, which is checked on startup
+			endorsementGatherer = NewAutoApproveEndorsementGatherer(dCtx)
+		} else {
+			endorsementGatherer = NewEndorsementGatherer(p.components.Persistence(), domainSmartContract, dCtx, p.components.KeyManager())
+		}
 		p.endorsementGatherers[contractAddr.String()] = endorsementGatherer
 	}
 	return p.endorsementGatherers[contractAddr.String()], nil
@@ -273,7 +340,7 @@ func (p *privateTxManager) handleNewTx(ctx context.Context, dbTX persistence.DBT
 	}
 	queued := oc.ProcessNewTransaction(ctx, tx)
 	if queued {
-		log.L(ctx).Debugf("Transaction with ID %s queued in database", tx.ID)
+		log.L(ctx).Debugf("Transaction with ID %s deferred until the sequencer has capacity", tx.ID)
 	}
 	return nil
 }
@@ -310,7 +377,7 @@ func (p *privateTxManager) handleDelegatedTransaction(ctx context.Context, dbTX
 	}
 	queued := sequencer.ProcessInFlightTransaction(ctx, tx, &delegationBlockHeight)
 	if queued {
-		log.L(ctx).Debugf("Delegated Transaction with ID %s queued in database", tx.ID)
+		log.L(ctx).Debugf("Delegated Transaction with ID %s deferred until the sequencer has capacity", tx.ID)
 	}
 	err = sequencer.transportWriter.SendDelegationRequestAcknowledgment(ctx, delegatingNodeName, delegationId, p.nodeName, tx.ID.String())
 	if err != nil {
@@ -499,9 +566,15 @@ func (p *privateTxManager) GetTxStatus(ctx context.Context, domainAddress string
 	defer p.sequencersLock.RUnlock()
 	targetSequencer := p.sequencers[domainAddress]
 	if targetSequencer == nil {
+		// the persisted blocked reason is all we know of a transaction whose sequencer is not loaded
+		blockedReason, err := readBlockedReason(ctx, p.components.Persistence(), txID)
+		if err != nil {
+			return components.PrivateTxStatus{}, err
+		}
 		return components.PrivateTxStatus{
-			TxID:   txID.String(),
-			Status: "unknown",
+			TxID:          txID.String(),
+			Status:        "unknown",
+			BlockedReason: blockedReason,
 		}, nil
 
 	} else {
@@ -510,6 +583,29 @@ func (p *privateTxManager) GetTxStatus(ctx context.Context, domainAddress string
 
 }
 
+func (p *privateTxManager) GetTxDependencyGraph(ctx context.Context, domainAddress string) (*components.PrivateTxDependencyGraph, error) {
+	// like the status, this is the graph we happen to have in memory at the moment, for debugging
+
+	p.sequencersLock.RLock()
+	defer p.sequencersLock.RUnlock()
+	targetSequencer := p.sequencers[domainAddress]
+	if targetSequencer == nil {
+		return &components.PrivateTxDependencyGraph{
+			Transactions: []*components.PrivateTxGraphNode{},
+			Dependencies: []*components.PrivateTxGraphEdge{},
+		}, nil
+	}
+	return targetSequencer.GetTxDependencyGraph(ctx)
+}
+
+func (p *privateTxManager) GetTxDependencyGraphDOT(ctx context.Context, domainAddress string) (string, error) {
+	dg, err := p.GetTxDependencyGraph(ctx, domainAddress)
+	if err != nil {
+		return "", err
+	}
+	return dependencyGraphDOT(dg), nil
+}
+
 func (p *privateTxManager) HandleNewEvent(ctx context.Context, event ptmgrtypes.PrivateTransactionEvent) {
 	p.sequencersLock.RLock()
 	defer p.sequencersLock.RUnlock()
@@ -721,7 +817,7 @@ func (p *privateTxManager) handleDelegationRequestAcknowledgment(ctx context.Con
 
 }
 
-func (p *privateTxManager) handleEndorsementResponse(ctx context.Context, messagePayload []byte) {
+func (p *privateTxManager) handleEndorsementResponse(ctx context.Context, messagePayload []byte, fromNode string) {
 
 	endorsementResponse := &pbEngine.EndorsementResponse{}
 	err := proto.Unmarshal(messagePayload, endorsementResponse)
@@ -753,6 +849,7 @@ func (p *privateTxManager) handleEndorsementResponse(ctx context.Context, messag
 		Party:                  endorsementResponse.Party,
 		AttestationRequestName: endorsementResponse.AttestationRequestName,
 		IdempotencyKey:         endorsementResponse.IdempotencyKey,
+		FromNode:               fromNode,
 	})
 
 }
@@ -827,7 +924,7 @@ func (p *privateTxManager) handleAssembleRequest(ctx context.Context, messagePay
 		return
 	}
 
-	postAssembly, err := sequencer.assembleForRemoteCoordinator(ctx, transactionID, preAssembly, assembleRequest.StateLocks, assembleRequest.BlockHeight)
+	postAssembly, err := sequencer.assembleForRemoteCoordinator(ctx, transactionID, replyTo, preAssembly, assembleRequest.StateLocks, assembleRequest.BlockHeight)
 	if err != nil {
 		log.L(ctx).Errorf("Failed to assemble for coordinator: %s", err)
 		p.sendAssembleError(ctx, replyTo, assembleRequest.AssembleRequestId, assembleRequest.ContractAddress, assembleRequest.TransactionId, err)
@@ -979,15 +1076,42 @@ func (p *privateTxManager) NotifyFailedPublicTx(ctx context.Context, dbTX persis
 // at which point it is important for us to remove transactions from our Domain Context in-memory buffer.
 // This might also unblock significant extra processing for more transactions.
 func (p *privateTxManager) PrivateTransactionConfirmed(ctx context.Context, receipt *components.TxCompletion) {
-	log.L(ctx).Infof("private TX manager notified of transaction confirmation %s deploy=%t",
-		receipt.TransactionID, receipt.PSC == nil)
-	if receipt.PSC != nil {
-		seq, err := p.getSequencerForContract(ctx, p.components.Persistence().NOTX(), receipt.PSC.Address(), receipt.PSC)
+	p.PrivateTransactionsConfirmed(ctx, []*components.TxCompletion{receipt})
+}
+
+// Confirmations arrive in batches from each block, so we group them by contract and pass each sequencer
+// a single event for all of its confirmations. The sequencer applies them together in one pass,
+// rather than re-evaluating its dependency graph for each transaction in turn.
+func (p *privateTxManager) PrivateTransactionsConfirmed(ctx context.Context, receipts []*components.TxCompletion) {
+	contractOrder := make([]tktypes.EthAddress, 0)
+	contractReceipts := make(map[tktypes.EthAddress][]*components.TxCompletion)
+	for _, receipt := range receipts {
+		log.L(ctx).Infof("private TX manager notified of transaction confirmation %s deploy=%t",
+			receipt.TransactionID, receipt.PSC == nil)
+		if receipt.PSC != nil {
+			addr := receipt.PSC.Address()
+			if _, seen := contractReceipts[addr]; !seen {
+				contractOrder = append(contractOrder, addr)
+			}
+			contractReceipts[addr] = append(contractReceipts[addr], receipt)
+		}
+	}
+	for _, addr := range contractOrder {
+		confirmed := contractReceipts[addr]
+		seq, err := p.getSequencerForContract(ctx, p.components.Persistence().NOTX(), addr, confirmed[0].PSC)
 		if err != nil {
-			log.L(ctx).Errorf("failed to obtain sequence to process receipts on contract %s: %s", receipt.PSC.Address(), err)
-			return
+			log.L(ctx).Errorf("failed to obtain sequence to process receipts on contract %s: %s", addr, err)
+			continue
+		}
+		if len(confirmed) == 1 {
+			seq.publisher.PublishTransactionConfirmedEvent(ctx, confirmed[0].TransactionID.String())
+			continue
+		}
+		transactionIDs := make([]string, len(confirmed))
+		for i, receipt := range confirmed {
+			transactionIDs[i] = receipt.TransactionID.String()
 		}
-		seq.publisher.PublishTransactionConfirmedEvent(ctx, receipt.TransactionID.String())
+		seq.publisher.PublishTransactionsConfirmedEvent(ctx, transactionIDs)
 	}
 }
 
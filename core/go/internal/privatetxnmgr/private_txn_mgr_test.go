@@ -41,6 +41,7 @@ import (
 	"google.golang.org/protobuf/types/known/anypb"
 
 	"github.com/kaleido-io/paladin/core/internal/components"
+	"github.com/kaleido-io/paladin/core/internal/metrics"
 	"github.com/kaleido-io/paladin/core/mocks/componentmocks"
 	"github.com/kaleido-io/paladin/core/pkg/blockindexer"
 	"github.com/kaleido-io/paladin/core/pkg/persistence"
@@ -2881,6 +2882,7 @@ func NewPrivateTransactionMgrForPackageTesting(t *testing.T, nodeName string) (p
 	mocks.allComponents.On("TxManager").Return(mocks.txManager).Maybe()
 	mocks.allComponents.On("PublicTxManager").Return(mocks.publicTxManager).Maybe()
 	mocks.allComponents.On("Persistence").Return(mocks.persistence).Maybe()
+	mocks.preInitComponents.On("MetricsManager").Return(metrics.NewMetricsManager()).Maybe()
 	mocks.domainSmartContract.On("Domain").Return(mocks.domain).Maybe()
 	mocks.domainSmartContract.On("LockStates", mock.Anything, mock.Anything, mock.Anything).Return(nil).Maybe()
 	mocks.domainMgr.On("GetDomainByName", mock.Anything, "domain1").Return(mocks.domain, nil).Maybe()
@@ -3096,3 +3098,132 @@ func pollForStatus(ctx context.Context, t *testing.T, expectedStatus string, pri
 		}
 	}
 }
+
+func TestPinnedSignerConfigInvalid(t *testing.T) {
+	ctx := context.Background()
+	ptm := NewPrivateTransactionMgr(ctx, &pldconf.PrivateTxManagerConfig{
+		PinnedSigners: map[string]string{"not an address": "signer1"},
+	})
+	err := ptm.PostInit(componentmocks.NewAllComponents(t))
+	assert.Regexp(t, "PD011846.*not an address", err)
+
+	contractAddr := tktypes.RandAddress()
+	ptm = NewPrivateTransactionMgr(ctx, &pldconf.PrivateTxManagerConfig{
+		PinnedSigners: map[string]string{contractAddr.String(): ""},
+	})
+	err = ptm.PostInit(componentmocks.NewAllComponents(t))
+	assert.Regexp(t, "PD011846", err)
+}
+
+func newPinnedSignerComponentsForTesting(t *testing.T) (*componentmocks.AllComponents, *componentmocks.KeyManager) {
+	mdb, err := mockpersistence.NewSQLMockProvider()
+	require.NoError(t, err)
+	allComponents := componentmocks.NewAllComponents(t)
+	transportManager := componentmocks.NewTransportManager(t)
+	keyManager := componentmocks.NewKeyManager(t)
+	allComponents.On("TransportManager").Return(transportManager)
+	allComponents.On("KeyManager").Return(keyManager).Maybe()
+	allComponents.On("Persistence").Return(mdb.P).Maybe()
+	allComponents.On("TxManager").Return(componentmocks.NewTXManager(t)).Maybe()
+	allComponents.On("PublicTxManager").Return(componentmocks.NewPublicTxManager(t)).Maybe()
+	transportManager.On("LocalNodeName").Return("node1")
+	return allComponents, keyManager
+}
+
+func TestPinnedSignerValidatedAtInit(t *testing.T) {
+	ctx := context.Background()
+	contract1, contract2, contract3 := tktypes.RandAddress(), tktypes.RandAddress(), tktypes.RandAddress()
+	ptm := NewPrivateTransactionMgr(ctx, &pldconf.PrivateTxManagerConfig{
+		PinnedSigners: map[string]string{
+			contract1.String(): "signer1",
+			contract2.String(): "signer2@node1",
+			contract3.String(): "missing.signer",
+		},
+	})
+	allComponents, keyManager := newPinnedSignerComponentsForTesting(t)
+	for _, identity := range []string{"signer1", "signer2"} {
+		keyManager.On("ResolveExistingKey", mock.Anything, mock.Anything, identity, algorithms.ECDSA_SECP256K1, verifiers.ETH_ADDRESS).
+			Return(&pldapi.KeyMappingAndVerifier{}, nil).Once()
+	}
+	keyManager.On("ResolveExistingKey", mock.Anything, mock.Anything, "missing.signer", algorithms.ECDSA_SECP256K1, verifiers.ETH_ADDRESS).
+		Return(nil, errors.New("PD010512: not found")).Once()
+
+	err := ptm.PostInit(allComponents)
+	require.NoError(t, err)
+
+	// Cached without the node qualifier, and only if the key exists
+	assert.Equal(t, map[tktypes.EthAddress]string{
+		*contract1: "signer1",
+		*contract2: "signer2",
+	}, ptm.(*privateTxManager).pinnedSigners)
+}
+
+func TestPinnedSignerRemoteOrInvalid(t *testing.T) {
+	ctx := context.Background()
+	contractAddr := tktypes.RandAddress()
+
+	ptm := NewPrivateTransactionMgr(ctx, &pldconf.PrivateTxManagerConfig{
+		PinnedSigners: map[string]string{contractAddr.String(): "signer1@node2"},
+	})
+	allComponents, _ := newPinnedSignerComponentsForTesting(t)
+	err := ptm.PostInit(allComponents)
+	assert.Regexp(t, "PD011857.*signer1@node2", err)
+
+	ptm = NewPrivateTransactionMgr(ctx, &pldconf.PrivateTxManagerConfig{
+		PinnedSigners: map[string]string{contractAddr.String(): "@@@"},
+	})
+	allComponents, _ = newPinnedSignerComponentsForTesting(t)
+	err = ptm.PostInit(allComponents)
+	assert.Regexp(t, "PD011846", err)
+}
+
+func TestEndorsementQuorumConfigInvalid(t *testing.T) {
+	ctx := context.Background()
+	ptm := NewPrivateTransactionMgr(ctx, &pldconf.PrivateTxManagerConfig{
+		EndorsementQuorum: map[string]int{"domain1": 0},
+	})
+	err := ptm.PostInit(componentmocks.NewAllComponents(t))
+	assert.Regexp(t, "PD011842.*domain1", err)
+}
+
+func TestUntrustedEndorsementPolicyConfigInvalid(t *testing.T) {
+	ctx := context.Background()
+	ptm := NewPrivateTransactionMgr(ctx, &pldconf.PrivateTxManagerConfig{
+		UntrustedEndorsementPolicy: confutil.P("ignore"),
+	})
+	err := ptm.PostInit(componentmocks.NewAllComponents(t))
+	assert.Regexp(t, "PD011854.*ignore", err)
+}
+
+func TestGetTxStatusNoSequencerPersistedBlockedReason(t *testing.T) {
+	ctx := context.Background()
+	privateTxManager, mocks := NewPrivateTransactionMgrForPackageTesting(t, "node1")
+
+	// a transaction whose sequencer is not loaded, such as after a restart, reports what it was last waiting on
+	txID := uuid.New()
+	require.NoError(t, writeBlockedReason(ctx, mocks.persistence, txID, "waiting on the sequencer"))
+	status, err := privateTxManager.GetTxStatus(ctx, tktypes.RandAddress().String(), txID)
+	require.NoError(t, err)
+	assert.Equal(t, "unknown", status.Status)
+	assert.Equal(t, "waiting on the sequencer", status.BlockedReason)
+
+	// and nothing once it is no longer blocked
+	require.NoError(t, writeBlockedReason(ctx, mocks.persistence, txID, ""))
+	status, err = privateTxManager.GetTxStatus(ctx, tktypes.RandAddress().String(), txID)
+	require.NoError(t, err)
+	assert.Empty(t, status.BlockedReason)
+}
+
+func TestGetTxStatusNoSequencerPersistedBlockedReasonFail(t *testing.T) {
+	ctx := context.Background()
+
+	db, err := mockpersistence.NewSQLMockProvider()
+	require.NoError(t, err)
+	allComponents := componentmocks.NewAllComponents(t)
+	allComponents.On("Persistence").Return(db.P)
+	p := &privateTxManager{sequencers: map[string]*Sequencer{}, components: allComponents}
+
+	db.Mock.ExpectQuery("SELECT.*private_tx_blocked_reasons").WillReturnError(fmt.Errorf("pop"))
+	_, err = p.GetTxStatus(ctx, tktypes.RandAddress().String(), uuid.New())
+	assert.Regexp(t, "pop", err)
+}
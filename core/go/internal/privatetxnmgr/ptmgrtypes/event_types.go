@@ -93,6 +93,7 @@ type TransactionEndorsedEvent struct {
 	Party                  string // In case Endorsement is nil, this is need to correlate with the attestation request
 	AttestationRequestName string // In case Endorsement is nil, this is need to correlate with the attestation request
 	IdempotencyKey         string
+	FromNode               string // The node that sent us the endorsement, which must be the node of the party
 }
 
 type TransactionDispatchedEvent struct {
@@ -109,6 +110,13 @@ type TransactionConfirmedEvent struct {
 	PrivateTransactionEventBase
 }
 
+// The confirmations of several transactions on the same contract, from a single batch of blocks.
+// The TransactionID of the base is unset, and each of the TransactionIDs receives a TransactionConfirmedEvent.
+type TransactionsConfirmedEvent struct {
+	PrivateTransactionEventBase
+	TransactionIDs []string
+}
+
 type TransactionRevertedEvent struct {
 	PrivateTransactionEventBase
 }
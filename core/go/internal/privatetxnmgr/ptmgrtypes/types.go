@@ -35,6 +35,14 @@ type EndorsementRequest struct {
 	InputStates   []string
 }
 
+// The outcome of checking an endorsement request for contention with the in-flight transactions.
+// Denied when any of the input states are already claimed by another endorsed transaction.
+type EndorsementApproval struct {
+	TransactionID   string
+	Approved        bool
+	ContendedStates []string
+}
+
 type Transaction struct {
 	ID              string
 	AssemblerNodeID string
@@ -55,7 +63,9 @@ type Publisher interface {
 	PublishTransactionFinalizedEvent(ctx context.Context, transactionId string)
 	PublishTransactionFinalizeError(ctx context.Context, transactionId string, revertReason string, err error)
 	PublishTransactionConfirmedEvent(ctx context.Context, transactionId string)
+	PublishTransactionsConfirmedEvent(ctx context.Context, transactionIds []string)
 	PublishNudgeEvent(ctx context.Context, transactionId string)
+	PublishTransactionDelegationReclaimedEvent(ctx context.Context, transactionId string, delegateNode string)
 }
 
 // Map of signing address to an ordered list of transaction flows that are ready to be dispatched by that signing address
@@ -132,6 +142,10 @@ type TransactionFlow interface {
 	InputStateIDs(ctx context.Context) []string
 	OutputStateIDs(ctx context.Context) []string
 	Signer(ctx context.Context) string
+	// Set by the sequencer to explain why an endorsed transaction has not been dispatched, or empty if it is dispatchable
+	SetDispatchBlockedReason(ctx context.Context, reason string)
+	// Called when the transaction is removed from the sequencer, or the sequencer stops, to cancel any pending retry
+	Stop(ctx context.Context)
 }
 
 type Clock interface {
@@ -107,6 +107,7 @@ func (p *publisher) PublishTransactionEndorsedEvent(ctx context.Context, transac
 		Party:                  party,
 		AttestationRequestName: attestationRequestName,
 		IdempotencyKey:         idempotencyKey,
+		FromNode:               p.privateTxManager.nodeName, // gathered locally
 	}
 	p.privateTxManager.HandleNewEvent(ctx, event)
 }
@@ -172,6 +173,24 @@ func (p *publisher) PublishTransactionConfirmedEvent(ctx context.Context, transa
 	p.privateTxManager.HandleNewEvent(ctx, event)
 }
 
+func (p *publisher) PublishTransactionsConfirmedEvent(ctx context.Context, transactionIds []string) {
+	event := &ptmgrtypes.TransactionsConfirmedEvent{
+		PrivateTransactionEventBase: ptmgrtypes.PrivateTransactionEventBase{
+			ContractAddress: p.contractAddress,
+		},
+		TransactionIDs: transactionIds,
+	}
+	p.privateTxManager.HandleNewEvent(ctx, event)
+}
+
+func (p *publisher) PublishTransactionDelegationReclaimedEvent(ctx context.Context, transactionId string, delegateNode string) {
+	p.privateTxManager.publishToSubscribers(ctx, &components.TransactionDelegationReclaimedEvent{
+		TransactionID:   transactionId,
+		ContractAddress: p.contractAddress,
+		DelegateNode:    delegateNode,
+	})
+}
+
 func (p *publisher) PublishNudgeEvent(ctx context.Context, transactionId string) {
 	event := &ptmgrtypes.TransactionNudgeEvent{
 		PrivateTransactionEventBase: ptmgrtypes.PrivateTransactionEventBase{
@@ -28,7 +28,9 @@ import (
 	"github.com/kaleido-io/paladin/core/internal/msgs"
 	"github.com/kaleido-io/paladin/core/internal/privatetxnmgr/ptmgrtypes"
 	"github.com/kaleido-io/paladin/core/internal/privatetxnmgr/syncpoints"
+	"github.com/kaleido-io/paladin/toolkit/pkg/cache"
 	"github.com/kaleido-io/paladin/toolkit/pkg/i18n"
+	"github.com/kaleido-io/paladin/toolkit/pkg/retry"
 
 	"github.com/kaleido-io/paladin/toolkit/pkg/log"
 	"github.com/kaleido-io/paladin/toolkit/pkg/tktypes"
@@ -76,6 +78,14 @@ func (e *sequencerEnvironment) GetBlockHeight() int64 {
 	return e.blockHeight
 }
 
+// A transaction that could not be brought into memory because the sequencer was at capacity.
+// It is swapped in as soon as an in-flight transaction completes, so a busy contract holds
+// at most maxConcurrentProcess transactions in memory regardless of the arrival rate.
+type deferredTransaction struct {
+	tx       *components.PrivateTransaction
+	inFlight bool
+}
+
 type Sequencer struct {
 	ctx              context.Context
 	privateTxManager components.PrivateTxManager
@@ -89,8 +99,16 @@ type Sequencer struct {
 	maxConcurrentProcess        int
 	incompleteTxProcessMapMutex sync.Mutex
 	incompleteTxSProcessMap     map[string]ptmgrtypes.TransactionFlow // a map of all known transactions that are not completed
-
-	processedTxIDs    map[string]bool // an internal record of completed transactions to handle persistence delays that causes reprocessing
+	deferredTransactions        []*deferredTransaction                // transactions over the maxConcurrentProcess cap for this contract, in arrival order
+	deferredTxIDs               map[string]bool                       // protected by incompleteTxProcessMapMutex
+	earlyAssembledEvents        map[string]*earlyAssembledEvent       // assemblies by other nodes for transactions not yet known here, protected by incompleteTxProcessMapMutex
+	dispatchedTxSigners         map[string]string                     // signer of each dispatched transaction that is not yet complete, protected by incompleteTxProcessMapMutex
+	maxDispatchedPerSigner      int                                   // 0 means no limit
+	metrics                     *privateTxManagerMetrics
+
+	processedTxIDs    map[string]bool                // an internal record of completed transactions to handle persistence delays that causes reprocessing
+	eventDedupCache   cache.Cache[string, time.Time] // when each recently applied event with a stable identity was applied, so redeliveries are discarded
+	eventDedupTimeout time.Duration
 	sequencerLoopDone chan struct{}
 
 	// input channels
@@ -108,6 +126,7 @@ type Sequencer struct {
 
 	contractAddress          tktypes.EthAddress // the contract address managed by the current sequencer
 	defaultSigner            string
+	pinnedSigner             string // configured per contract, and used in preference to the default signer if it was resolved at startup
 	nodeName                 string
 	domainAPI                components.DomainSmartContract
 	coordinatorDomainContext components.DomainContext
@@ -120,6 +139,13 @@ type Sequencer struct {
 	transportWriter          ptmgrtypes.TransportWriter
 	graph                    Graph
 	requestTimeout           time.Duration
+	maxReassemblyAttempts    int
+	assembleRetry            *retry.Retry
+	maxAssembleAttempts      int // 0 means no limit
+	delegationTimeout        time.Duration
+	delegationFence          *delegationFence
+	endorsementQuorum        int    // 0 means every party must endorse
+	untrustedPolicy          string // what happens to a transaction that receives an endorsement from an untrusted party
 	coordinatorSelector      ptmgrtypes.CoordinatorSelector
 	newBlockEvents           chan int64
 	assembleCoordinator      ptmgrtypes.AssembleCoordinator
@@ -155,10 +181,16 @@ func NewSequencer(
 		stateEntryTime:       time.Now(),
 
 		incompleteTxSProcessMap: make(map[string]ptmgrtypes.TransactionFlow),
+		deferredTxIDs:           make(map[string]bool),
+		earlyAssembledEvents:    make(map[string]*earlyAssembledEvent),
+		dispatchedTxSigners:     make(map[string]string),
+		maxDispatchedPerSigner:  confutil.IntMin(sequencerConfig.MaxDispatchedPerSigner, 0, *pldconf.PrivateTxManagerDefaults.Sequencer.MaxDispatchedPerSigner),
 		persistenceRetryTimeout: confutil.DurationMin(sequencerConfig.PersistenceRetryTimeout, 1*time.Millisecond, *pldconf.PrivateTxManagerDefaults.Sequencer.PersistenceRetryTimeout),
 
 		staleTimeout:                 confutil.DurationMin(sequencerConfig.StaleTimeout, 1*time.Millisecond, *pldconf.PrivateTxManagerDefaults.Sequencer.StaleTimeout),
 		processedTxIDs:               make(map[string]bool),
+		eventDedupCache:              cache.NewCache[string, time.Time](&sequencerConfig.EventDedupCache, &pldconf.PrivateTxManagerDefaults.Sequencer.EventDedupCache),
+		eventDedupTimeout:            confutil.DurationMin(sequencerConfig.EventDedupTimeout, 0, *pldconf.PrivateTxManagerDefaults.Sequencer.EventDedupTimeout),
 		orchestrationEvalRequestChan: make(chan bool, 1),
 		stopProcess:                  make(chan bool, 1),
 		pendingTransactionEvents:     make(chan ptmgrtypes.PrivateTransactionEvent, *pldconf.PrivateTxManagerDefaults.Sequencer.MaxPendingEvents),
@@ -170,8 +202,13 @@ func NewSequencer(
 		syncPoints:                   syncPoints,
 		identityResolver:             identityResolver,
 		transportWriter:              transportWriter,
-		graph:                        NewGraph(),
+		graph:                        NewGraph(allComponents.Persistence(), contractAddress),
 		requestTimeout:               requestTimeout,
+		maxReassemblyAttempts:        confutil.IntMin(sequencerConfig.MaxReassemblyAttempts, 0, *pldconf.PrivateTxManagerDefaults.Sequencer.MaxReassemblyAttempts),
+		assembleRetry:                retry.NewRetryLimited(&sequencerConfig.AssembleRetry, &pldconf.PrivateTxManagerDefaults.Sequencer.AssembleRetry),
+		maxAssembleAttempts:          confutil.IntMin(sequencerConfig.AssembleRetry.MaxAttempts, 0, *pldconf.PrivateTxManagerDefaults.Sequencer.AssembleRetry.MaxAttempts),
+		delegationTimeout:            confutil.DurationMin(sequencerConfig.DelegationTimeout, 0, *pldconf.PrivateTxManagerDefaults.Sequencer.DelegationTimeout),
+		delegationFence:              newDelegationFence(),
 		environment: &sequencerEnvironment{
 			blockHeight: blockHeight,
 		},
@@ -240,8 +277,130 @@ func (s *Sequencer) getTransactionProcessor(txID string) ptmgrtypes.TransactionF
 
 func (s *Sequencer) removeTransactionProcessor(txID string) {
 	s.incompleteTxProcessMapMutex.Lock()
-	defer s.incompleteTxProcessMapMutex.Unlock()
+	transactionProcessor := s.incompleteTxSProcessMap[txID]
 	delete(s.incompleteTxSProcessMap, txID)
+	delete(s.earlyAssembledEvents, txID)
+	delete(s.dispatchedTxSigners, txID)
+	s.delegationFence.release(txID)
+	s.swapInDeferredTransactions()
+	s.incompleteTxProcessMapMutex.Unlock()
+	if transactionProcessor != nil {
+		transactionProcessor.Stop(s.ctx)
+	}
+}
+
+// must hold incompleteTxProcessMapMutex
+func (s *Sequencer) deferTransaction(tx *components.PrivateTransaction, inFlight bool) {
+	txID := tx.ID.String()
+	if !s.deferredTxIDs[txID] {
+		log.L(s.ctx).Debugf("Sequencer at capacity (%d), deferring transaction %s", s.maxConcurrentProcess, txID)
+		s.deferredTxIDs[txID] = true
+		s.deferredTransactions = append(s.deferredTransactions, &deferredTransaction{tx: tx, inFlight: inFlight})
+	}
+	s.recordMetrics()
+}
+
+// must hold incompleteTxProcessMapMutex
+func (s *Sequencer) addTransactionProcessor(ctx context.Context, tx *components.PrivateTransaction) {
+	txID := tx.ID.String()
+	delete(s.deferredTxIDs, txID)
+	s.incompleteTxSProcessMap[txID] = NewTransactionFlow(ctx, tx, s.nodeName, s.components, s.domainAPI, s.coordinatorDomainContext, s.publisher, s.endorsementGatherer, s.identityResolver, s.syncPoints, s.transportWriter, s.requestTimeout, s.delegationTimeout, s.delegationFence, s.maxReassemblyAttempts, s.assembleRetry, s.maxAssembleAttempts, s.endorsementQuorum, s.untrustedPolicy, s.metrics, s.coordinatorSelector, s.assembleCoordinator, s.environment)
+	s.recordMetrics()
+	early := s.earlyAssembledEvents[txID]
+	if early == nil {
+		return
+	}
+	delete(s.earlyAssembledEvents, txID)
+	if tx.PostAssembly != nil || s.isStaleEarlyAssembledEvent(early) {
+		// The transaction has arrived with its own assembly, or we have held this one too long for it to be current
+		log.L(ctx).Infof("Discarding stale assembly received before transaction %s was known", txID)
+		return
+	}
+	// The transaction was assembled by another node before we knew about it, so replay that now.
+	// We are usually called on the event loop, or holding the lock, so must not block on our own event channel
+	log.L(ctx).Infof("Replaying assembly received before transaction %s was known", txID)
+	go func() {
+		select {
+		case s.pendingTransactionEvents <- early.event:
+		case <-s.sequencerLoopDone:
+			log.L(ctx).Debugf("Sequencer stopped before assembly of %s was replayed", txID)
+		case <-s.ctx.Done():
+			log.L(ctx).Debugf("Sequencer stopped before assembly of %s was replayed", txID)
+		}
+	}()
+}
+
+// An assembly by another node, received before we knew about the transaction it assembles
+type earlyAssembledEvent struct {
+	event    *ptmgrtypes.TransactionAssembledEvent
+	received time.Time
+}
+
+// An early assembly is only replayed within the request timeout, after which the node that
+// assembled it will have moved on (for example by asking for the transaction to be assembled again)
+func (s *Sequencer) isStaleEarlyAssembledEvent(early *earlyAssembledEvent) bool {
+	return time.Since(early.received) > s.requestTimeout
+}
+
+// Records an assembled event for a transaction that is not yet in memory, so that it is
+// correlated with the transaction once it arrives (for example via delegation from the node that assembled it)
+func (s *Sequencer) trackEarlyAssembledEvent(ctx context.Context, event *ptmgrtypes.TransactionAssembledEvent) {
+	s.incompleteTxProcessMapMutex.Lock()
+	defer s.incompleteTxProcessMapMutex.Unlock()
+	txID := event.TransactionID
+	for otherTxID, early := range s.earlyAssembledEvents {
+		if s.isStaleEarlyAssembledEvent(early) {
+			delete(s.earlyAssembledEvents, otherTxID)
+		}
+	}
+	if s.earlyAssembledEvents[txID] == nil && len(s.earlyAssembledEvents) >= s.maxConcurrentProcess {
+		log.L(ctx).Warnf("Too many assemblies for unknown transactions (%d). Ignoring assembly of %s", len(s.earlyAssembledEvents), txID)
+		return
+	}
+	log.L(ctx).Infof("Tracking assembly of transaction %s that is not yet known to this node", txID)
+	s.earlyAssembledEvents[txID] = &earlyAssembledEvent{event: event, received: time.Now()}
+}
+
+// Forgets any assembly held for a transaction, once it has completed without ever being known here
+func (s *Sequencer) evictEarlyAssembledEvent(txID string) {
+	s.incompleteTxProcessMapMutex.Lock()
+	defer s.incompleteTxProcessMapMutex.Unlock()
+	delete(s.earlyAssembledEvents, txID)
+}
+
+// must hold incompleteTxProcessMapMutex
+func (s *Sequencer) swapInDeferredTransactions() {
+	var events []ptmgrtypes.PrivateTransactionEvent
+	for len(s.deferredTransactions) > 0 && len(s.incompleteTxSProcessMap) < s.maxConcurrentProcess {
+		dt := s.deferredTransactions[0]
+		s.deferredTransactions = s.deferredTransactions[1:]
+		txID := dt.tx.ID.String()
+		if !s.deferredTxIDs[txID] {
+			continue // already brought into memory by a later submission
+		}
+		log.L(s.ctx).Debugf("Swapping in deferred transaction %s", txID)
+		s.addTransactionProcessor(s.ctx, dt.tx)
+		eventBase := ptmgrtypes.PrivateTransactionEventBase{TransactionID: txID}
+		if dt.inFlight {
+			events = append(events, &ptmgrtypes.TransactionSwappedInEvent{PrivateTransactionEventBase: eventBase})
+		} else {
+			events = append(events, &ptmgrtypes.TransactionSubmittedEvent{PrivateTransactionEventBase: eventBase})
+		}
+	}
+	s.recordMetrics()
+	if len(events) > 0 {
+		// We are usually called on the event loop, so must not block on our own event channel
+		go func() {
+			for _, e := range events {
+				s.pendingTransactionEvents <- e
+			}
+		}()
+	}
+}
+
+// must hold incompleteTxProcessMapMutex
+func (s *Sequencer) recordMetrics() {
+	s.metrics.recordSequencerTransactions(s.contractAddress, len(s.incompleteTxSProcessMap), len(s.deferredTxIDs))
 }
 
 func (s *Sequencer) OnNewBlockHeight(ctx context.Context, blockHeight int64) {
@@ -255,11 +414,11 @@ func (s *Sequencer) ProcessNewTransaction(ctx context.Context, tx *components.Pr
 	defer s.incompleteTxProcessMapMutex.Unlock()
 	if s.incompleteTxSProcessMap[tx.ID.String()] == nil {
 		if len(s.incompleteTxSProcessMap) >= s.maxConcurrentProcess {
-			// TODO: decide how this map is managed, it shouldn't track the entire lifecycle
-			// tx processing pool is full, queue the item
+			// tx processing pool is full, defer the item until an in-flight transaction completes
+			s.deferTransaction(tx, false)
 			return true
 		} else {
-			s.incompleteTxSProcessMap[tx.ID.String()] = NewTransactionFlow(ctx, tx, s.nodeName, s.components, s.domainAPI, s.coordinatorDomainContext, s.publisher, s.endorsementGatherer, s.identityResolver, s.syncPoints, s.transportWriter, s.requestTimeout, s.coordinatorSelector, s.assembleCoordinator, s.environment)
+			s.addTransactionProcessor(ctx, tx)
 		}
 		s.pendingTransactionEvents <- &ptmgrtypes.TransactionSubmittedEvent{
 			PrivateTransactionEventBase: ptmgrtypes.PrivateTransactionEventBase{TransactionID: tx.ID.String()},
@@ -290,11 +449,11 @@ func (s *Sequencer) ProcessInFlightTransaction(ctx context.Context, tx *componen
 		return false
 	} else {
 		if len(s.incompleteTxSProcessMap) >= s.maxConcurrentProcess {
-			// TODO: decide how this map is managed, it shouldn't track the entire lifecycle
-			// tx processing pool is full, queue the item
+			// tx processing pool is full, defer the item until an in-flight transaction completes
+			s.deferTransaction(tx, true)
 			return true
 		} else {
-			s.incompleteTxSProcessMap[tx.ID.String()] = NewTransactionFlow(ctx, tx, s.nodeName, s.components, s.domainAPI, s.coordinatorDomainContext, s.publisher, s.endorsementGatherer, s.identityResolver, s.syncPoints, s.transportWriter, s.requestTimeout, s.coordinatorSelector, s.assembleCoordinator, s.environment)
+			s.addTransactionProcessor(ctx, tx)
 		}
 		s.pendingTransactionEvents <- &ptmgrtypes.TransactionSwappedInEvent{
 			PrivateTransactionEventBase: ptmgrtypes.PrivateTransactionEventBase{TransactionID: tx.ID.String()},
@@ -305,6 +464,7 @@ func (s *Sequencer) ProcessInFlightTransaction(ctx context.Context, tx *componen
 
 func (s *Sequencer) HandleEvent(ctx context.Context, event ptmgrtypes.PrivateTransactionEvent) {
 	s.pendingTransactionEvents <- event
+	s.metrics.recordSequencerPendingEvents(s.contractAddress, len(s.pendingTransactionEvents))
 }
 
 func (s *Sequencer) Start(ctx context.Context) (done <-chan struct{}, err error) {
@@ -338,6 +498,10 @@ func (s *Sequencer) TriggerSequencerEvaluation() {
 	}
 }
 
+func (s *Sequencer) GetTxDependencyGraph(ctx context.Context) (*components.PrivateTxDependencyGraph, error) {
+	return s.graph.Export(ctx)
+}
+
 func (s *Sequencer) GetTxStatus(ctx context.Context, txID uuid.UUID) (components.PrivateTxStatus, error) {
 
 	s.incompleteTxProcessMapMutex.Lock()
@@ -363,9 +527,19 @@ func (s *Sequencer) GetTxStatus(ctx context.Context, txID uuid.UUID) (components
 
 	}
 
+	// a transaction that is not in memory, such as after a restart, can still report what it was last waiting on
+	blockedReason := ""
+	if persistedTxn.Receipt == nil {
+		blockedReason, err = readBlockedReason(ctx, s.components.Persistence(), txID)
+		if err != nil {
+			return components.PrivateTxStatus{}, err
+		}
+	}
+
 	return components.PrivateTxStatus{
 		TxID:           txID.String(),
 		Status:         status,
 		FailureMessage: failureMessage,
+		BlockedReason:  blockedReason,
 	}, nil
 }
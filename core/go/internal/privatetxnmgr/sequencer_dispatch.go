@@ -18,6 +18,9 @@ package privatetxnmgr
 import (
 	"context"
 	"fmt"
+	"slices"
+	"sort"
+	"strings"
 
 	"github.com/kaleido-io/paladin/core/internal/components"
 	"github.com/kaleido-io/paladin/core/internal/msgs"
@@ -31,6 +34,108 @@ import (
 	"github.com/kaleido-io/paladin/toolkit/pkg/tktypes"
 )
 
+// The signer for transactions that do not require a specific submitter. A signer pinned to this contract
+// in configuration is used if it was found to be an existing local key at startup, otherwise we fall back
+// to our randomly assigned one.
+func (s *Sequencer) dispatchSigner() string {
+	if s.pinnedSigner == "" {
+		return s.defaultSigner
+	}
+	return s.pinnedSigner
+}
+
+// Limits the transactions dispatched for each signer, so that the number dispatched and not yet complete does not
+// exceed the configured maximum. The transactions for a signer are in dependency order, so dispatching the first
+// of them is always safe - the rest stay in the graph, to be dispatched as earlier transactions are confirmed.
+// A dispatchable transaction can depend on one held for a different signer, so the transitive dependants of all
+// held transactions are held too - otherwise they could be mined before the states they spend are minted.
+func (s *Sequencer) throttleDispatch(ctx context.Context, dispatchableTransactions ptmgrtypes.DispatchableTransactions) ptmgrtypes.DispatchableTransactions {
+	if s.maxDispatchedPerSigner == 0 {
+		return dispatchableTransactions
+	}
+
+	s.incompleteTxProcessMapMutex.Lock()
+	inFlight := make(map[string]int)
+	for _, signer := range s.dispatchedTxSigners {
+		inFlight[signer]++
+	}
+	s.incompleteTxProcessMapMutex.Unlock()
+
+	throttled := make(ptmgrtypes.DispatchableTransactions, len(dispatchableTransactions))
+	heldMinters := make(map[string]string) // output state ID -> ID of the held transaction that mints it
+	holdOutputs := func(held ptmgrtypes.TransactionFlow) {
+		for _, stateID := range held.OutputStateIDs(ctx) {
+			heldMinters[stateID] = held.ID(ctx).String()
+		}
+	}
+	for signingAddress, transactionFlows := range dispatchableTransactions {
+		signer := signingAddress
+		if signer == "" {
+			// transactions without a signer are assigned the dispatch signer when they are prepared
+			signer = s.dispatchSigner()
+		}
+		available := max(s.maxDispatchedPerSigner-inFlight[signer], 0)
+		if len(transactionFlows) > available {
+			log.L(ctx).Debugf("Dispatching %d of %d dispatchable transactions for signer %s with %d dispatched", available, len(transactionFlows), signer, inFlight[signer])
+			blockedReason := i18n.ExpandWithCode(ctx, i18n.MessageKey(msgs.MsgPrivateTxBlockedDispatchThrottle), signer, inFlight[signer], s.maxDispatchedPerSigner)
+			for _, held := range transactionFlows[available:] {
+				held.SetDispatchBlockedReason(ctx, blockedReason)
+				holdOutputs(held)
+			}
+			transactionFlows = transactionFlows[:available]
+		}
+		if available == 0 {
+			continue
+		}
+		throttled[signingAddress] = transactionFlows
+	}
+
+	// Hold the dependants of held transactions, until there are no more to find
+	for heldDependants := len(heldMinters) > 0; heldDependants; {
+		heldDependants = false
+		for signingAddress, transactionFlows := range throttled {
+			dispatchable := make([]ptmgrtypes.TransactionFlow, 0, len(transactionFlows))
+			for _, transactionFlow := range transactionFlows {
+				prerequisites := make([]string, 0)
+				for _, stateID := range transactionFlow.InputStateIDs(ctx) {
+					if minter, held := heldMinters[stateID]; held && !slices.Contains(prerequisites, minter) {
+						prerequisites = append(prerequisites, minter)
+					}
+				}
+				if len(prerequisites) == 0 {
+					dispatchable = append(dispatchable, transactionFlow)
+					continue
+				}
+				log.L(ctx).Debugf("Holding transaction %s that depends on held transactions %s", transactionFlow.ID(ctx), prerequisites)
+				sort.Strings(prerequisites)
+				transactionFlow.SetDispatchBlockedReason(ctx, i18n.ExpandWithCode(ctx, i18n.MessageKey(msgs.MsgPrivateTxBlockedDependencies), strings.Join(prerequisites, ",")))
+				holdOutputs(transactionFlow)
+				heldDependants = true
+			}
+			if len(dispatchable) == 0 {
+				delete(throttled, signingAddress)
+			} else {
+				throttled[signingAddress] = dispatchable
+			}
+		}
+	}
+	return throttled
+}
+
+// Tracks the signer of each dispatched transaction until it completes, when the dispatch limit is enabled
+func (s *Sequencer) recordDispatched(ctx context.Context, dispatchedTransactions ptmgrtypes.DispatchableTransactions) {
+	if s.maxDispatchedPerSigner == 0 {
+		return
+	}
+	s.incompleteTxProcessMapMutex.Lock()
+	defer s.incompleteTxProcessMapMutex.Unlock()
+	for _, transactionFlows := range dispatchedTransactions {
+		for _, transactionFlow := range transactionFlows {
+			s.dispatchedTxSigners[transactionFlow.ID(ctx).String()] = transactionFlow.Signer(ctx)
+		}
+	}
+}
+
 // synchronously prepare and dispatch all given transactions to their associated signing address / or deliver prepared transaction to their custodian
 func (s *Sequencer) DispatchTransactions(ctx context.Context, dispatchableTransactions ptmgrtypes.DispatchableTransactions) error {
 	log.L(ctx).Debug("DispatchTransactions")
@@ -42,6 +147,8 @@ func (s *Sequencer) DispatchTransactions(ctx context.Context, dispatchableTransa
 		PublicDispatches: make([]*syncpoints.PublicDispatch, 0, len(dispatchableTransactions)),
 	}
 
+	dispatchSigner := s.dispatchSigner()
+
 	stateDistributions := make([]*components.StateDistribution, 0)
 	localStateDistributions := make([]*components.StateDistributionWithData, 0)
 	preparedTxnDistributions := make([]*components.PreparedTransactionWithRefs, 0)
@@ -56,9 +163,9 @@ func (s *Sequencer) DispatchTransactions(ctx context.Context, dispatchableTransa
 		for _, transactionFlow := range transactionFlows {
 			// prepare all transactions
 
-			// If we don't have a signing key for the TX at this point, we use our randomly assigned one
+			// If we don't have a signing key for the TX at this point, we use the pinned or randomly assigned one
 			// TODO: Rotation
-			preparedTransaction, err := transactionFlow.PrepareTransaction(ctx, s.defaultSigner)
+			preparedTransaction, err := transactionFlow.PrepareTransaction(ctx, dispatchSigner)
 			if err != nil {
 				log.L(ctx).Errorf("Error preparing transaction: %s", err)
 				//TODO this is a really bad time to be getting an error.  need to think carefully about how to handle this
@@ -0,0 +1,67 @@
+/*
+ * Copyright © 2024 Kaleido, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with
+ * the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package privatetxnmgr
+
+import (
+	"context"
+
+	"github.com/kaleido-io/paladin/core/internal/privatetxnmgr/ptmgrtypes"
+	"github.com/kaleido-io/paladin/toolkit/pkg/log"
+)
+
+// Checks whether the transaction in the request can be endorsed now, without contending for input
+// states with another in-flight transaction that has already been endorsed.
+func (s *Sequencer) ApproveEndorsement(ctx context.Context, request ptmgrtypes.EndorsementRequest) *ptmgrtypes.EndorsementApproval {
+	return s.ApproveEndorsements(ctx, []ptmgrtypes.EndorsementRequest{request})[0]
+}
+
+// Batch variant of ApproveEndorsement, which builds a single snapshot of input state ownership
+// and checks every request against it, rather than re-analyzing the in-flight transactions per request.
+// Requests are not checked against each other, so each result is the same as an individual call.
+func (s *Sequencer) ApproveEndorsements(ctx context.Context, requests []ptmgrtypes.EndorsementRequest) []*ptmgrtypes.EndorsementApproval {
+	owners := s.inputStateOwnership(ctx)
+	approvals := make([]*ptmgrtypes.EndorsementApproval, len(requests))
+	for i, request := range requests {
+		approval := &ptmgrtypes.EndorsementApproval{TransactionID: request.TransactionID}
+		for _, stateID := range request.InputStates {
+			if owner, claimed := owners[stateID]; claimed && owner != request.TransactionID {
+				approval.ContendedStates = append(approval.ContendedStates, stateID)
+			}
+		}
+		approval.Approved = len(approval.ContendedStates) == 0
+		if !approval.Approved {
+			log.L(ctx).Debugf("Endorsement of transaction %s denied due to contended states %v", request.TransactionID, approval.ContendedStates)
+		}
+		approvals[i] = approval
+	}
+	return approvals
+}
+
+// Map of input state ID to the ID of the endorsed in-flight transaction that spends it
+func (s *Sequencer) inputStateOwnership(ctx context.Context) map[string]string {
+	s.incompleteTxProcessMapMutex.Lock()
+	defer s.incompleteTxProcessMapMutex.Unlock()
+	owners := make(map[string]string)
+	for txID, tf := range s.incompleteTxSProcessMap {
+		if !tf.IsEndorsed(ctx) {
+			continue
+		}
+		for _, stateID := range tf.InputStateIDs(ctx) {
+			owners[stateID] = txID
+		}
+	}
+	return owners
+}
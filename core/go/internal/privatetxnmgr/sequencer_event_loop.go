@@ -36,6 +36,20 @@ func (s *Sequencer) evaluationLoop() {
 	log.L(ctx).Infof("Sequencer for contract address %s started evaluation loop based on interval %s", s.contractAddress, s.evalInterval)
 
 	defer close(s.sequencerLoopDone)
+	defer func() {
+		// assemblies held for transactions we never saw are not carried over to the next sequencer,
+		// and the transactions we hold must not go on to nudge a sequencer that has stopped
+		s.incompleteTxProcessMapMutex.Lock()
+		s.earlyAssembledEvents = make(map[string]*earlyAssembledEvent)
+		transactionProcessors := make([]ptmgrtypes.TransactionFlow, 0, len(s.incompleteTxSProcessMap))
+		for _, transactionProcessor := range s.incompleteTxSProcessMap {
+			transactionProcessors = append(transactionProcessors, transactionProcessor)
+		}
+		s.incompleteTxProcessMapMutex.Unlock()
+		for _, transactionProcessor := range transactionProcessors {
+			transactionProcessor.Stop(ctx)
+		}
+	}()
 
 	ticker := time.NewTicker(s.evalInterval)
 	for {
@@ -45,6 +59,7 @@ func (s *Sequencer) evaluationLoop() {
 			//TODO should we use this is as the metronome to periodically trigger any inflight transactions to re-evaluate their state?
 			s.environment.blockHeight = blockHeight
 		case pendingEvent := <-s.pendingTransactionEvents:
+			s.metrics.recordSequencerPendingEvents(s.contractAddress, len(s.pendingTransactionEvents))
 			s.handleTransactionEvent(ctx, pendingEvent)
 		case <-s.orchestrationEvalRequestChan:
 		case <-ticker.C:
@@ -63,12 +78,54 @@ func (s *Sequencer) evaluationLoop() {
 }
 
 func (s *Sequencer) handleTransactionEvent(ctx context.Context, event ptmgrtypes.PrivateTransactionEvent) {
+	if confirmedEvent, isBatch := event.(*ptmgrtypes.TransactionsConfirmedEvent); isBatch {
+		s.handleTransactionsConfirmedEvent(ctx, confirmedEvent)
+		return
+	}
+	applied := s.applyTransactionEvent(ctx, event)
+	s.metrics.recordSequencerEvent(event, applied)
+	if applied {
+		s.dispatchReadyTransactions(ctx)
+	}
+}
+
+// A block can confirm many of our transactions at once. Each confirmation is applied to its transaction exactly
+// as if it had arrived on its own, but we only analyze the graph and dispatch once for the whole batch.
+func (s *Sequencer) handleTransactionsConfirmedEvent(ctx context.Context, event *ptmgrtypes.TransactionsConfirmedEvent) {
+	log.L(ctx).Debugf("Sequencer handling confirmation of %d transactions", len(event.TransactionIDs))
+	applied := false
+	for _, transactionID := range event.TransactionIDs {
+		confirmedEvent := &ptmgrtypes.TransactionConfirmedEvent{
+			PrivateTransactionEventBase: ptmgrtypes.PrivateTransactionEventBase{
+				ContractAddress: event.ContractAddress,
+				TransactionID:   transactionID,
+			},
+		}
+		confirmedApplied := s.applyTransactionEvent(ctx, confirmedEvent)
+		s.metrics.recordSequencerEvent(confirmedEvent, confirmedApplied)
+		if confirmedApplied {
+			applied = true
+		}
+	}
+	if applied {
+		s.dispatchReadyTransactions(ctx)
+	}
+}
+
+// Applies the event to its transaction, and updates the graph for that transaction.
+// Returns false if the event was discarded.
+func (s *Sequencer) applyTransactionEvent(ctx context.Context, event ptmgrtypes.PrivateTransactionEvent) bool {
 	//For any event that is specific to a single transaction,
 	// find (or create) the transaction processor for that transaction
 	// and pass the event to it
 	transactionID := event.GetTransactionID()
 	log.L(ctx).Debugf("Sequencer handling event %T for transaction %s", event, transactionID)
 
+	dedupKey := eventDedupKey(event)
+	if s.isDuplicateEvent(ctx, dedupKey) {
+		return false
+	}
+
 	transactionProcessor := s.getTransactionProcessor(transactionID)
 	if transactionProcessor == nil {
 		//What has happened here is either:
@@ -80,15 +137,26 @@ func (s *Sequencer) handleTransactionEvent(ctx context.Context, event ptmgrtypes
 		// we have completed the initialization from the database
 		// in case of (b) we ignore it because an event for a completed transaction is redundant.
 		// most likely it is a tardy response for something we timed out waiting for and failed or retried successfully
+		// The exception is an assembly, which can be performed by any node and so can legitimately arrive
+		// before the transaction itself. We hold onto that until the transaction arrives.
+		switch event := event.(type) {
+		case *ptmgrtypes.TransactionAssembledEvent:
+			if event.PostAssembly != nil {
+				s.trackEarlyAssembledEvent(ctx, event)
+				return false
+			}
+		case *ptmgrtypes.TransactionConfirmedEvent, *ptmgrtypes.TransactionRevertedEvent, *ptmgrtypes.TransactionFinalizedEvent:
+			s.evictEarlyAssembledEvent(transactionID)
+		}
 		log.L(ctx).Warnf("Received an event for a transaction that is not in flight %s", transactionID)
-		return
+		return false
 	}
 
 	validationError := event.Validate(ctx)
 	if validationError != nil {
 		log.L(ctx).Errorf("Error validating %T event: %s ", event, validationError.Error())
 		//we can't handle this event.  If that leaves a transaction in an incomplete state, then it will eventually resend requests for the data it needs
-		return
+		return false
 	}
 
 	/*
@@ -99,6 +167,9 @@ func (s *Sequencer) handleTransactionEvent(ctx context.Context, event ptmgrtypes
 			- completely apply the the event
 	*/
 	transactionProcessor.ApplyEvent(ctx, event)
+	if dedupKey != "" {
+		s.eventDedupCache.Set(dedupKey, time.Now())
+	}
 
 	/*
 		 	After applying the event to the transaction, we can either a) clean up that transaction ( if we have just learned, from the event that the transaction is complete and needs no further actions)
@@ -121,7 +192,10 @@ func (s *Sequencer) handleTransactionEvent(ctx context.Context, event ptmgrtypes
 	if transactionProcessor.CoordinatingLocally(ctx) && transactionProcessor.ReadyForSequencing(ctx) && !transactionProcessor.Dispatched(ctx) {
 		// we are responsible for coordinating the endorsement flow for this transaction, ensure that it has been added it to the graph
 		// NOTE: AddTransaction is idempotent so we don't need to check whether we have already added it
-		s.graph.AddTransaction(ctx, transactionProcessor)
+		if err := s.graph.AddTransaction(ctx, transactionProcessor); err != nil {
+			// it will be added on the next event for the transaction
+			log.L(ctx).Errorf("Error adding transaction %s to graph: %s", transactionID, err)
+		}
 	} else {
 		// incase the transaction was previously added to the graph but is no longer coordinating locally or is no longer ready for sequencing
 		// then we need to remove it from the graph
@@ -131,7 +205,35 @@ func (s *Sequencer) handleTransactionEvent(ctx context.Context, event ptmgrtypes
 		// that are no longer ready for sequencing and remove them from the graph
 		s.graph.RemoveTransaction(ctx, transactionID)
 	}
+	return true
+}
+
+// The events that can be redelivered to us (endorsements over the transport, and confirmations from the indexer)
+// have a stable identity, so that applying a duplicate is a no-op rather than advancing the transaction twice.
+// Returns an empty key for events that are safe to apply again.
+func eventDedupKey(event ptmgrtypes.PrivateTransactionEvent) string {
+	switch event := event.(type) {
+	case *ptmgrtypes.TransactionEndorsedEvent:
+		return fmt.Sprintf("%s/endorsed/%s/%s/%s", event.TransactionID, event.AttestationRequestName, event.Party, event.IdempotencyKey)
+	case *ptmgrtypes.TransactionConfirmedEvent:
+		return fmt.Sprintf("%s/confirmed", event.TransactionID)
+	}
+	return ""
+}
+
+func (s *Sequencer) isDuplicateEvent(ctx context.Context, dedupKey string) bool {
+	if dedupKey == "" {
+		return false
+	}
+	appliedAt, applied := s.eventDedupCache.Get(dedupKey)
+	if !applied || time.Since(appliedAt) > s.eventDedupTimeout {
+		return false
+	}
+	log.L(ctx).Infof("Discarding duplicate event %s already applied at %s", dedupKey, appliedAt)
+	return true
+}
 
+func (s *Sequencer) dispatchReadyTransactions(ctx context.Context) {
 	//analyze the graph to see if we can dispatch any transactions
 	dispatchableTransactions, err := s.graph.GetDispatchableTransactions(ctx)
 	if err != nil {
@@ -142,6 +244,7 @@ func (s *Sequencer) handleTransactionEvent(ctx context.Context, event ptmgrtypes
 		s.abort(err)
 		return
 	}
+	dispatchableTransactions = s.throttleDispatch(ctx, dispatchableTransactions)
 	if len(dispatchableTransactions) == 0 {
 		log.L(ctx).Debug("No dispatchable transactions")
 		return
@@ -152,6 +255,7 @@ func (s *Sequencer) handleTransactionEvent(ctx context.Context, event ptmgrtypes
 		// assuming this is a transient error with e.g. network or the DB, then we will try again next time round the loop
 		return
 	}
+	s.recordDispatched(ctx, dispatchableTransactions)
 
 	//DispatchTransactions is a persistence point so we can remove the transactions from our graph now that they are dispatched
 	s.graph.RemoveTransactions(ctx, dispatchableTransactions.IDs(ctx))
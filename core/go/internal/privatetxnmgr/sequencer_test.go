@@ -22,13 +22,17 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/kaleido-io/paladin/config/pkg/confutil"
 	"github.com/kaleido-io/paladin/config/pkg/pldconf"
 	"github.com/kaleido-io/paladin/core/internal/components"
+	"github.com/kaleido-io/paladin/core/internal/privatetxnmgr/ptmgrtypes"
 	"github.com/kaleido-io/paladin/core/internal/privatetxnmgr/syncpoints"
 	"github.com/kaleido-io/paladin/core/mocks/componentmocks"
 	"github.com/kaleido-io/paladin/core/mocks/privatetxnmgrmocks"
 
 	"github.com/kaleido-io/paladin/core/pkg/persistence"
+	"github.com/kaleido-io/paladin/core/pkg/persistence/mockpersistence"
+	"github.com/kaleido-io/paladin/toolkit/pkg/cache"
 	"github.com/kaleido-io/paladin/toolkit/pkg/pldapi"
 	"github.com/kaleido-io/paladin/toolkit/pkg/prototk"
 	"github.com/kaleido-io/paladin/toolkit/pkg/tktypes"
@@ -203,3 +207,623 @@ func TestSequencerPollingLoopCancelContext(t *testing.T) {
 
 	cancel()
 }
+
+func TestSequencerDispatchSignerPinned(t *testing.T) {
+
+	ctx, cancel := context.WithCancel(context.Background())
+	testOc, _, ocDone := newSequencerForTesting(t, ctx, nil)
+	defer ocDone()
+	defer cancel()
+
+	require.Equal(t, testOc.defaultSigner, testOc.dispatchSigner())
+
+	testOc.pinnedSigner = "pinned.signer"
+	require.Equal(t, "pinned.signer", testOc.dispatchSigner())
+}
+
+func TestSequencerCapEnforcedPerContract(t *testing.T) {
+	ctx := context.Background()
+	metrics, registry := newTestPrivateTxManagerMetrics()
+
+	// Stop the event loops, so the transactions stay in memory for the duration of the test
+	newCappedSequencer := func() *Sequencer {
+		s, _, done := newSequencerForTesting(t, ctx, nil)
+		s.Stop()
+		done()
+		s.metrics = metrics
+		s.maxConcurrentProcess = 2
+		return s
+	}
+	busySequencer := newCappedSequencer()
+	quietSequencer := newCappedSequencer()
+
+	newTx := func() *components.PrivateTransaction {
+		return &components.PrivateTransaction{ID: uuid.New()}
+	}
+
+	// The busy contract fills its slots, and further work is deferred
+	busyTxs := []*components.PrivateTransaction{newTx(), newTx(), newTx(), newTx()}
+	assert.False(t, busySequencer.ProcessNewTransaction(ctx, busyTxs[0]))
+	assert.False(t, busySequencer.ProcessNewTransaction(ctx, busyTxs[1]))
+	assert.True(t, busySequencer.ProcessNewTransaction(ctx, busyTxs[2]))
+	assert.True(t, busySequencer.ProcessInFlightTransaction(ctx, busyTxs[3], nil))
+	assert.True(t, busySequencer.ProcessNewTransaction(ctx, busyTxs[2])) // not deferred twice
+	assert.Len(t, busySequencer.incompleteTxSProcessMap, 2)
+	assert.Len(t, busySequencer.deferredTransactions, 2)
+
+	// The quiet contract is unaffected
+	assert.False(t, quietSequencer.ProcessNewTransaction(ctx, newTx()))
+	assert.Len(t, quietSequencer.incompleteTxSProcessMap, 1)
+
+	results := gatherSequencerMetrics(t, registry)
+	assert.Equal(t, [2]float64{2, 2}, results[busySequencer.contractAddress.String()])
+	assert.Equal(t, [2]float64{1, 0}, results[quietSequencer.contractAddress.String()])
+
+	// Drain the submissions of the transactions that were not deferred
+	for range 2 {
+		_ = waitForChannel(t, busySequencer.pendingTransactionEvents)
+	}
+
+	// Completing an in-flight transaction swaps in the oldest deferred one
+	busySequencer.removeTransactionProcessor(busyTxs[0].ID.String())
+	assert.Len(t, busySequencer.incompleteTxSProcessMap, 2)
+	assert.NotNil(t, busySequencer.getTransactionProcessor(busyTxs[2].ID.String()))
+	assert.Nil(t, busySequencer.getTransactionProcessor(busyTxs[3].ID.String()))
+	submitted := waitForChannel(t, busySequencer.pendingTransactionEvents)
+	assert.IsType(t, &ptmgrtypes.TransactionSubmittedEvent{}, submitted)
+	assert.Equal(t, busyTxs[2].ID.String(), submitted.GetTransactionID())
+
+	busySequencer.removeTransactionProcessor(busyTxs[1].ID.String())
+	assert.NotNil(t, busySequencer.getTransactionProcessor(busyTxs[3].ID.String()))
+	swappedIn := waitForChannel(t, busySequencer.pendingTransactionEvents)
+	assert.IsType(t, &ptmgrtypes.TransactionSwappedInEvent{}, swappedIn)
+	assert.Equal(t, busyTxs[3].ID.String(), swappedIn.GetTransactionID())
+
+	results = gatherSequencerMetrics(t, registry)
+	assert.Equal(t, [2]float64{2, 0}, results[busySequencer.contractAddress.String()])
+}
+
+func TestSequencerAssembledEventBeforeTransactionKnown(t *testing.T) {
+	ctx := context.Background()
+	s, _, done := newSequencerForTesting(t, ctx, nil)
+	s.Stop()
+	done()
+	s.metrics = newPrivateTxManagerMetrics()
+	// we read the events the loop would have handled, so pretend it is still running
+	s.sequencerLoopDone = make(chan struct{})
+
+	// Another node has assembled a transaction we have never seen
+	txID := uuid.New()
+	assembled := &ptmgrtypes.TransactionAssembledEvent{
+		PrivateTransactionEventBase: ptmgrtypes.PrivateTransactionEventBase{
+			TransactionID:   txID.String(),
+			ContractAddress: s.contractAddress.String(),
+		},
+		PostAssembly: &components.TransactionPostAssembly{
+			AssemblyResult: prototk.AssembleTransactionResponse_OK,
+		},
+		AssembleRequestID: uuid.NewString(),
+	}
+	assert.False(t, s.applyTransactionEvent(ctx, assembled))
+	assert.Nil(t, s.getTransactionProcessor(txID.String()))
+	assert.Same(t, assembled, s.earlyAssembledEvents[txID.String()].event)
+
+	// When the transaction arrives, the assembly is correlated with it
+	assert.False(t, s.ProcessInFlightTransaction(ctx, &components.PrivateTransaction{ID: txID}, nil))
+	assert.NotNil(t, s.getTransactionProcessor(txID.String()))
+	assert.Empty(t, s.earlyAssembledEvents)
+	var events []ptmgrtypes.PrivateTransactionEvent
+	for range 2 {
+		events = append(events, waitForChannel(t, s.pendingTransactionEvents))
+	}
+	assert.Contains(t, events, ptmgrtypes.PrivateTransactionEvent(assembled))
+
+	// Tracking is bounded
+	s.maxConcurrentProcess = 1
+	s.trackEarlyAssembledEvent(ctx, assembled)
+	other := *assembled
+	other.TransactionID = uuid.NewString()
+	s.trackEarlyAssembledEvent(ctx, &other)
+	assert.Len(t, s.earlyAssembledEvents, 1)
+
+	// and cleaned up if the transaction completes without ever being known
+	s.removeTransactionProcessor(txID.String())
+	assert.Empty(t, s.earlyAssembledEvents)
+	s.trackEarlyAssembledEvent(ctx, assembled)
+	assert.False(t, s.applyTransactionEvent(ctx, &ptmgrtypes.TransactionConfirmedEvent{
+		PrivateTransactionEventBase: ptmgrtypes.PrivateTransactionEventBase{TransactionID: txID.String()},
+	}))
+	assert.Empty(t, s.earlyAssembledEvents)
+}
+
+func TestSequencerStaleAssembledEventNotReplayed(t *testing.T) {
+	ctx := context.Background()
+	s, _, done := newSequencerForTesting(t, ctx, nil)
+	s.Stop()
+	done()
+	s.metrics = newPrivateTxManagerMetrics()
+
+	assembledEvent := func(txID uuid.UUID) *ptmgrtypes.TransactionAssembledEvent {
+		return &ptmgrtypes.TransactionAssembledEvent{
+			PrivateTransactionEventBase: ptmgrtypes.PrivateTransactionEventBase{
+				TransactionID:   txID.String(),
+				ContractAddress: s.contractAddress.String(),
+			},
+			PostAssembly: &components.TransactionPostAssembly{
+				AssemblyResult: prototk.AssembleTransactionResponse_OK,
+			},
+			AssembleRequestID: uuid.NewString(),
+		}
+	}
+
+	// An assembly held for longer than the request timeout is discarded, rather than replayed
+	tx1 := uuid.New()
+	s.trackEarlyAssembledEvent(ctx, assembledEvent(tx1))
+	s.earlyAssembledEvents[tx1.String()].received = time.Now().Add(-2 * s.requestTimeout)
+	assert.False(t, s.ProcessInFlightTransaction(ctx, &components.PrivateTransaction{ID: tx1}, nil))
+	assert.Empty(t, s.earlyAssembledEvents)
+
+	// as is one for a transaction that arrives with its own assembly
+	tx2 := uuid.New()
+	s.trackEarlyAssembledEvent(ctx, assembledEvent(tx2))
+	assert.False(t, s.ProcessInFlightTransaction(ctx, &components.PrivateTransaction{ID: tx2, PostAssembly: &components.TransactionPostAssembly{}}, nil))
+	assert.Empty(t, s.earlyAssembledEvents)
+
+	// Only the swap-in events are queued
+	for _, txID := range []uuid.UUID{tx1, tx2} {
+		event := waitForChannel(t, s.pendingTransactionEvents)
+		assert.IsType(t, &ptmgrtypes.TransactionSwappedInEvent{}, event)
+		assert.Equal(t, txID.String(), event.GetTransactionID())
+	}
+	assert.Empty(t, s.pendingTransactionEvents)
+
+	// Stale assemblies are also evicted when another is tracked
+	tx3 := uuid.New()
+	s.trackEarlyAssembledEvent(ctx, assembledEvent(tx3))
+	s.earlyAssembledEvents[tx3.String()].received = time.Now().Add(-2 * s.requestTimeout)
+	tx4 := uuid.New()
+	s.trackEarlyAssembledEvent(ctx, assembledEvent(tx4))
+	assert.Len(t, s.earlyAssembledEvents, 1)
+	assert.NotNil(t, s.earlyAssembledEvents[tx4.String()])
+}
+
+func TestSequencerEarlyAssembledEventsClearedOnStop(t *testing.T) {
+	ctx := context.Background()
+	s, _, done := newSequencerForTesting(t, ctx, nil)
+
+	s.trackEarlyAssembledEvent(ctx, &ptmgrtypes.TransactionAssembledEvent{
+		PrivateTransactionEventBase: ptmgrtypes.PrivateTransactionEventBase{TransactionID: uuid.NewString()},
+		PostAssembly:                &components.TransactionPostAssembly{},
+	})
+	s.Stop()
+	done()
+
+	s.incompleteTxProcessMapMutex.Lock()
+	defer s.incompleteTxProcessMapMutex.Unlock()
+	assert.Empty(t, s.earlyAssembledEvents)
+}
+
+func TestSequencerEventMetrics(t *testing.T) {
+	ctx := context.Background()
+	s, _, done := newSequencerForTesting(t, ctx, nil)
+	s.Stop()
+	done()
+	metrics, registry := newTestPrivateTxManagerMetrics()
+	s.metrics = metrics
+
+	// Events queue up while the sequencer is not processing them
+	for range 3 {
+		s.HandleEvent(ctx, &ptmgrtypes.TransactionEndorsedEvent{
+			PrivateTransactionEventBase: ptmgrtypes.PrivateTransactionEventBase{TransactionID: uuid.NewString()},
+		})
+	}
+	assert.Equal(t, float64(3), gatherSequencerPendingEvents(t, registry)[s.contractAddress.String()])
+
+	// Each is left unprocessed, as none of the transactions are known
+	for range 3 {
+		s.handleTransactionEvent(ctx, waitForChannel(t, s.pendingTransactionEvents))
+	}
+	assert.Equal(t, [2]float64{0, 3}, gatherSequencerEventMetrics(t, registry)["TransactionEndorsedEvent"])
+
+	// Along with each confirmation in a batch that is not in flight
+	s.handleTransactionEvent(ctx, &ptmgrtypes.TransactionsConfirmedEvent{
+		TransactionIDs: []string{uuid.NewString(), uuid.NewString()},
+	})
+	assert.Equal(t, [2]float64{0, 2}, gatherSequencerEventMetrics(t, registry)["TransactionConfirmedEvent"])
+}
+
+func TestSequencerDuplicateEventsAppliedOnce(t *testing.T) {
+	ctx := context.Background()
+	s, _, done := newSequencerForTesting(t, ctx, nil)
+	s.Stop()
+	done()
+
+	txID := uuid.New()
+	tx := NewMockTransactionProcessorForTesting(t, txID, []string{}, []string{"S0"}, false, tktypes.RandHex(32))
+	tx.On("IsComplete", mock.Anything).Return(false)
+	tx.On("Action", mock.Anything).Return()
+	tx.On("CoordinatingLocally", mock.Anything).Return(false)
+	s.incompleteTxSProcessMap[txID.String()] = tx
+
+	endorsed := func(idempotencyKey string) *ptmgrtypes.TransactionEndorsedEvent {
+		return &ptmgrtypes.TransactionEndorsedEvent{
+			PrivateTransactionEventBase: ptmgrtypes.PrivateTransactionEventBase{TransactionID: txID.String()},
+			Party:                       "party1@node1",
+			FromNode:                    "node1",
+			AttestationRequestName:      "endorse",
+			IdempotencyKey:              idempotencyKey,
+		}
+	}
+	confirmed := &ptmgrtypes.TransactionConfirmedEvent{
+		PrivateTransactionEventBase: ptmgrtypes.PrivateTransactionEventBase{TransactionID: txID.String()},
+	}
+	tx.On("ApplyEvent", mock.Anything, mock.Anything).Return()
+
+	// A redelivered endorsement is only applied once
+	assert.True(t, s.applyTransactionEvent(ctx, endorsed("key1")))
+	assert.False(t, s.applyTransactionEvent(ctx, endorsed("key1")))
+	tx.AssertNumberOfCalls(t, "ApplyEvent", 1)
+
+	// but the response to a different request is not a duplicate
+	assert.True(t, s.applyTransactionEvent(ctx, endorsed("key2")))
+	tx.AssertNumberOfCalls(t, "ApplyEvent", 2)
+
+	// Same for a redelivered confirmation
+	assert.True(t, s.applyTransactionEvent(ctx, confirmed))
+	assert.False(t, s.applyTransactionEvent(ctx, confirmed))
+	tx.AssertNumberOfCalls(t, "ApplyEvent", 3)
+
+	// Once the duplicate is older than the timeout, it is applied again
+	s.eventDedupTimeout = 0
+	assert.True(t, s.applyTransactionEvent(ctx, confirmed))
+	tx.AssertNumberOfCalls(t, "ApplyEvent", 4)
+}
+
+func TestSequencerEventDedupCacheBounded(t *testing.T) {
+	ctx := context.Background()
+	s, _, done := newSequencerForTesting(t, ctx, nil)
+	s.Stop()
+	done()
+	s.eventDedupCache = cache.NewCache[string, time.Time](&pldconf.CacheConfig{Capacity: confutil.P(2)}, &pldconf.PrivateTxManagerDefaults.Sequencer.EventDedupCache)
+
+	txIDs := []uuid.UUID{uuid.New(), uuid.New(), uuid.New()}
+	for _, txID := range txIDs {
+		tx := NewMockTransactionProcessorForTesting(t, txID, []string{}, []string{}, false, tktypes.RandHex(32))
+		tx.On("ApplyEvent", mock.Anything, mock.Anything).Return()
+		tx.On("IsComplete", mock.Anything).Return(false)
+		tx.On("Action", mock.Anything).Return()
+		tx.On("CoordinatingLocally", mock.Anything).Return(false)
+		s.incompleteTxSProcessMap[txID.String()] = tx
+		assert.True(t, s.applyTransactionEvent(ctx, &ptmgrtypes.TransactionConfirmedEvent{
+			PrivateTransactionEventBase: ptmgrtypes.PrivateTransactionEventBase{TransactionID: txID.String()},
+		}))
+	}
+
+	// The oldest record was evicted, so only the most recent are still treated as duplicates
+	assert.False(t, s.isDuplicateEvent(ctx, txIDs[0].String()+"/confirmed"))
+	assert.True(t, s.isDuplicateEvent(ctx, txIDs[1].String()+"/confirmed"))
+	assert.True(t, s.isDuplicateEvent(ctx, txIDs[2].String()+"/confirmed"))
+}
+
+type countingGraph struct {
+	Graph
+	evaluations int
+}
+
+func (g *countingGraph) GetDispatchableTransactions(ctx context.Context) (ptmgrtypes.DispatchableTransactions, error) {
+	g.evaluations++
+	return g.Graph.GetDispatchableTransactions(ctx)
+}
+
+func TestSequencerBatchConfirmation(t *testing.T) {
+	ctx := context.Background()
+	s, _, done := newSequencerForTesting(t, ctx, nil)
+	s.Stop()
+	defer done()
+	s.metrics = newPrivateTxManagerMetrics()
+	graph := &countingGraph{Graph: NewGraph(s.components.Persistence(), s.contractAddress)}
+	s.graph = graph
+
+	// A chain of transactions, where the first two are confirmed in the same block
+	signer := tktypes.RandHex(32)
+	txIDs := []uuid.UUID{uuid.New(), uuid.New(), uuid.New()}
+	tx0 := NewMockTransactionProcessorForTesting(t, txIDs[0], []string{}, []string{"S0"}, true, signer)
+	tx1 := NewMockTransactionProcessorForTesting(t, txIDs[1], []string{"S0"}, []string{"S1"}, true, signer)
+	tx2 := NewMockTransactionProcessorForTesting(t, txIDs[2], []string{"S1"}, []string{"S2"}, false, signer)
+	for i, tx := range []*privatetxnmgrmocks.TransactionFlow{tx0, tx1, tx2} {
+		s.incompleteTxSProcessMap[txIDs[i].String()] = tx
+		require.NoError(t, graph.AddTransaction(ctx, tx))
+	}
+
+	// Each confirmation is applied to its own transaction, just as if it had arrived alone
+	for i, tx := range []*privatetxnmgrmocks.TransactionFlow{tx0, tx1} {
+		txID := txIDs[i].String()
+		tx.On("ApplyEvent", mock.Anything, mock.MatchedBy(func(e *ptmgrtypes.TransactionConfirmedEvent) bool {
+			return e.TransactionID == txID && e.ContractAddress == s.contractAddress.String()
+		})).Return().Once()
+		tx.On("IsComplete", mock.Anything).Return(true)
+		tx.On("CoordinatingLocally", mock.Anything).Return(true)
+		tx.On("ReadyForSequencing", mock.Anything).Return(true)
+		tx.On("Dispatched", mock.Anything).Return(true)
+	}
+
+	s.handleTransactionEvent(ctx, &ptmgrtypes.TransactionsConfirmedEvent{
+		PrivateTransactionEventBase: ptmgrtypes.PrivateTransactionEventBase{
+			ContractAddress: s.contractAddress.String(),
+		},
+		TransactionIDs: []string{txIDs[0].String(), txIDs[1].String(), uuid.NewString() /* not in flight */},
+	})
+
+	// Both confirmed transactions are complete, leaving their dependent in flight,
+	// and the graph was only evaluated for dispatch once for the whole batch
+	assert.Nil(t, s.getTransactionProcessor(txIDs[0].String()))
+	assert.Nil(t, s.getTransactionProcessor(txIDs[1].String()))
+	assert.NotNil(t, s.getTransactionProcessor(txIDs[2].String()))
+	assert.False(t, graph.IncludesTransaction(txIDs[0].String()))
+	assert.False(t, graph.IncludesTransaction(txIDs[1].String()))
+	assert.True(t, graph.IncludesTransaction(txIDs[2].String()))
+	assert.Equal(t, 1, graph.evaluations)
+
+	// A batch with nothing in flight does not evaluate the graph at all
+	s.handleTransactionEvent(ctx, &ptmgrtypes.TransactionsConfirmedEvent{
+		PrivateTransactionEventBase: ptmgrtypes.PrivateTransactionEventBase{
+			ContractAddress: s.contractAddress.String(),
+		},
+		TransactionIDs: []string{txIDs[0].String()},
+	})
+	assert.Equal(t, 1, graph.evaluations)
+}
+
+func TestSequencerApproveEndorsements(t *testing.T) {
+	ctx := context.Background()
+	s, _, done := newSequencerForTesting(t, ctx, nil)
+	s.Stop()
+	done()
+
+	signer := tktypes.RandHex(32)
+	txIDs := []uuid.UUID{uuid.New(), uuid.New(), uuid.New()}
+	tx0 := NewMockTransactionProcessorForTesting(t, txIDs[0], []string{"S0", "S1"}, []string{"S5"}, true, signer)
+	tx1 := NewMockTransactionProcessorForTesting(t, txIDs[1], []string{"S2"}, []string{"S6"}, true, signer)
+	tx2 := NewMockTransactionProcessorForTesting(t, txIDs[2], []string{"S3"}, []string{"S7"}, false, signer) // not yet endorsed
+	for i, tx := range []*privatetxnmgrmocks.TransactionFlow{tx0, tx1, tx2} {
+		s.incompleteTxSProcessMap[txIDs[i].String()] = tx
+	}
+
+	requests := []ptmgrtypes.EndorsementRequest{
+		{TransactionID: uuid.NewString(), InputStates: []string{"S0"}},
+		{TransactionID: txIDs[0].String(), InputStates: []string{"S0", "S1"}}, // its own states
+		{TransactionID: uuid.NewString(), InputStates: []string{"S3", "S4"}},  // only claimed by an unendorsed transaction
+		{TransactionID: uuid.NewString(), InputStates: []string{"S1", "S4", "S2"}},
+	}
+
+	approvals := s.ApproveEndorsements(ctx, requests)
+	require.Len(t, approvals, len(requests))
+	assert.False(t, approvals[0].Approved)
+	assert.Equal(t, []string{"S0"}, approvals[0].ContendedStates)
+	assert.True(t, approvals[1].Approved)
+	assert.Empty(t, approvals[1].ContendedStates)
+	assert.True(t, approvals[2].Approved)
+	assert.False(t, approvals[3].Approved)
+	assert.Equal(t, []string{"S1", "S2"}, approvals[3].ContendedStates)
+	for i, approval := range approvals {
+		assert.Equal(t, requests[i].TransactionID, approval.TransactionID)
+	}
+
+	// The in-flight transactions were only analyzed once for the whole batch
+	tx0.AssertNumberOfCalls(t, "IsEndorsed", 1)
+	tx0.AssertNumberOfCalls(t, "InputStateIDs", 1)
+	tx1.AssertNumberOfCalls(t, "InputStateIDs", 1)
+	tx2.AssertNumberOfCalls(t, "IsEndorsed", 1)
+	tx2.AssertNumberOfCalls(t, "InputStateIDs", 0)
+
+	// Each batch result matches checking the request individually, which analyzes every time
+	for i, request := range requests {
+		assert.Equal(t, approvals[i], s.ApproveEndorsement(ctx, request))
+	}
+	tx0.AssertNumberOfCalls(t, "InputStateIDs", 1+len(requests))
+}
+
+func TestSequencerStopsTransactionsOnRemoveAndStop(t *testing.T) {
+	ctx := context.Background()
+	s, _, done := newSequencerForTesting(t, ctx, nil)
+
+	removed := privatetxnmgrmocks.NewTransactionFlow(t)
+	removed.On("Stop", mock.Anything).Return().Once()
+	inFlight := privatetxnmgrmocks.NewTransactionFlow(t)
+	inFlight.On("Stop", mock.Anything).Return().Once()
+	s.incompleteTxProcessMapMutex.Lock()
+	s.incompleteTxSProcessMap["removed"] = removed
+	s.incompleteTxSProcessMap["inFlight"] = inFlight
+	s.incompleteTxProcessMapMutex.Unlock()
+
+	// a completed transaction is stopped when it is removed
+	s.removeTransactionProcessor("removed")
+
+	// the rest are stopped with the sequencer
+	s.Stop()
+	done()
+}
+
+func newDispatchedFlowForTesting(t *testing.T, signer string) *privatetxnmgrmocks.TransactionFlow {
+	return newDependentFlowForTesting(t, signer, nil, nil)
+}
+
+func newDependentFlowForTesting(t *testing.T, signer string, inputStateIDs, outputStateIDs []string) *privatetxnmgrmocks.TransactionFlow {
+	tf := privatetxnmgrmocks.NewTransactionFlow(t)
+	tf.On("ID", mock.Anything).Return(uuid.New()).Maybe()
+	tf.On("Signer", mock.Anything).Return(signer).Maybe()
+	tf.On("InputStateIDs", mock.Anything).Return(inputStateIDs).Maybe()
+	tf.On("OutputStateIDs", mock.Anything).Return(outputStateIDs).Maybe()
+	tf.On("SetDispatchBlockedReason", mock.Anything, mock.Anything).Return().Maybe()
+	return tf
+}
+
+func TestSequencerThrottleDispatchPerSigner(t *testing.T) {
+	ctx := context.Background()
+	s, _, done := newSequencerForTesting(t, ctx, nil)
+	s.Stop()
+	done()
+	s.maxDispatchedPerSigner = 3
+
+	flows := func(signer string, count int) []ptmgrtypes.TransactionFlow {
+		tfs := make([]ptmgrtypes.TransactionFlow, count)
+		for i := range tfs {
+			tfs[i] = newDispatchedFlowForTesting(t, signer)
+		}
+		return tfs
+	}
+
+	// Two transactions already dispatched for signerA, so only one more can be dispatched
+	signerAFlows := flows("signerA", 5)
+	s.recordDispatched(ctx, ptmgrtypes.DispatchableTransactions{"signerA": signerAFlows[:2]})
+	dispatchable := s.throttleDispatch(ctx, ptmgrtypes.DispatchableTransactions{
+		"signerA": signerAFlows[2:],
+		"signerB": flows("signerB", 2),
+	})
+	require.Len(t, dispatchable["signerA"], 1)
+	assert.Same(t, signerAFlows[2], dispatchable["signerA"][0]) // in dependency order
+	assert.Len(t, dispatchable["signerB"], 2)
+
+	// The transactions that were held say why
+	assert.Nil(t, lastDispatchBlockedReason(signerAFlows[2].(*privatetxnmgrmocks.TransactionFlow)))
+	for _, held := range signerAFlows[3:] {
+		assert.Regexp(t, "PD011853.*signerA.*2.*max=3", *lastDispatchBlockedReason(held.(*privatetxnmgrmocks.TransactionFlow)))
+	}
+
+	// Once signerA is at the limit its transactions are held
+	s.recordDispatched(ctx, dispatchable)
+	dispatchable = s.throttleDispatch(ctx, ptmgrtypes.DispatchableTransactions{"signerA": signerAFlows[3:]})
+	assert.Empty(t, dispatchable)
+	assert.Regexp(t, "PD011853.*signerA.*3.*max=3", *lastDispatchBlockedReason(signerAFlows[4].(*privatetxnmgrmocks.TransactionFlow)))
+
+	// Completing a dispatched transaction frees a slot
+	s.removeTransactionProcessor(signerAFlows[0].ID(ctx).String())
+	dispatchable = s.throttleDispatch(ctx, ptmgrtypes.DispatchableTransactions{"signerA": signerAFlows[3:]})
+	require.Len(t, dispatchable["signerA"], 1)
+	assert.Same(t, signerAFlows[3], dispatchable["signerA"][0])
+}
+
+func TestSequencerThrottleDispatchHoldsDependantsAcrossSigners(t *testing.T) {
+	ctx := context.Background()
+	s, _, done := newSequencerForTesting(t, ctx, nil)
+	s.Stop()
+	done()
+	s.maxDispatchedPerSigner = 2
+	s.recordDispatched(ctx, ptmgrtypes.DispatchableTransactions{
+		"signerA": []ptmgrtypes.TransactionFlow{newDispatchedFlowForTesting(t, "signerA")},
+	})
+
+	// signerA can only dispatch tx0, so tx1 is held. tx2 (signerB) spends the output of tx1, and tx3 (signerC)
+	// spends the output of tx2, so both must be held even though their signers have capacity.
+	tx0 := newDependentFlowForTesting(t, "signerA", nil, []string{"S0"})
+	tx1 := newDependentFlowForTesting(t, "signerA", nil, []string{"S1"})
+	tx2 := newDependentFlowForTesting(t, "signerB", []string{"S1"}, []string{"S2"})
+	tx3 := newDependentFlowForTesting(t, "signerC", []string{"S2"}, nil)
+	tx4 := newDependentFlowForTesting(t, "signerC", []string{"S0"}, nil) // depends only on a dispatched transaction
+	dispatchable := s.throttleDispatch(ctx, ptmgrtypes.DispatchableTransactions{
+		"signerA": {tx0, tx1},
+		"signerB": {tx2},
+		"signerC": {tx3, tx4},
+	})
+	assert.Equal(t, ptmgrtypes.DispatchableTransactions{
+		"signerA": {tx0},
+		"signerC": {tx4},
+	}, dispatchable)
+
+	assert.Regexp(t, "PD011853.*signerA", *lastDispatchBlockedReason(tx1))
+	assert.Regexp(t, "PD011852.*"+tx1.ID(ctx).String(), *lastDispatchBlockedReason(tx2))
+	assert.Regexp(t, "PD011852.*"+tx2.ID(ctx).String(), *lastDispatchBlockedReason(tx3))
+	assert.Nil(t, lastDispatchBlockedReason(tx4))
+}
+
+func TestSequencerThrottleDispatchDefaultSigner(t *testing.T) {
+	ctx := context.Background()
+	s, _, done := newSequencerForTesting(t, ctx, nil)
+	s.Stop()
+	done()
+	s.maxDispatchedPerSigner = 1
+
+	// Transactions without a signer are counted against the signer they will be dispatched with
+	s.recordDispatched(ctx, ptmgrtypes.DispatchableTransactions{
+		"": []ptmgrtypes.TransactionFlow{newDispatchedFlowForTesting(t, s.defaultSigner)},
+	})
+	dispatchable := s.throttleDispatch(ctx, ptmgrtypes.DispatchableTransactions{
+		"": []ptmgrtypes.TransactionFlow{newDispatchedFlowForTesting(t, "")},
+	})
+	assert.Empty(t, dispatchable)
+}
+
+func TestSequencerThrottleDispatchDisabled(t *testing.T) {
+	ctx := context.Background()
+	s, _, done := newSequencerForTesting(t, ctx, nil)
+	s.Stop()
+	done()
+	require.Zero(t, s.maxDispatchedPerSigner)
+
+	tfs := []ptmgrtypes.TransactionFlow{privatetxnmgrmocks.NewTransactionFlow(t), privatetxnmgrmocks.NewTransactionFlow(t)}
+	s.recordDispatched(ctx, ptmgrtypes.DispatchableTransactions{"signerA": tfs})
+	assert.Empty(t, s.dispatchedTxSigners)
+	dispatchable := s.throttleDispatch(ctx, ptmgrtypes.DispatchableTransactions{"signerA": tfs})
+	assert.Len(t, dispatchable["signerA"], 2)
+}
+
+func TestSequencerAssembleForRemoteCoordinatorFenced(t *testing.T) {
+	ctx := context.Background()
+	s, _, done := newSequencerForTesting(t, ctx, nil)
+	s.Stop()
+	done()
+
+	txID := uuid.New()
+	require.True(t, s.delegationFence.reclaim(txID.String(), "node2"))
+	_, err := s.assembleForRemoteCoordinator(ctx, txID, "node2", &components.TransactionPreAssembly{}, nil, 0)
+	assert.Regexp(t, "PD011856.*node2", err)
+
+	// the fence is released with the transaction
+	s.removeTransactionProcessor(txID.String())
+	assert.True(t, s.delegationFence.assemble(txID.String(), "node2"))
+}
+
+func TestSequencerGetTxStatusPersistedBlockedReason(t *testing.T) {
+	ctx := context.Background()
+	s, mocks, done := newSequencerForTesting(t, ctx, nil)
+	s.Stop()
+	defer done()
+
+	// a transaction that is not in memory, such as after a restart, reports what it was last waiting on
+	txID := uuid.New()
+	require.NoError(t, writeBlockedReason(ctx, s.components.Persistence(), txID, "waiting on the sequencer"))
+	mocks.txManager.On("GetTransactionByIDFull", mock.Anything, txID).Return(&pldapi.TransactionFull{}, nil).Once()
+	status, err := s.GetTxStatus(ctx, txID)
+	require.NoError(t, err)
+	assert.Equal(t, "unknown", status.Status)
+	assert.Equal(t, "waiting on the sequencer", status.BlockedReason)
+
+	// but not once it has reached a final state
+	mocks.txManager.On("GetTransactionByIDFull", mock.Anything, txID).Return(&pldapi.TransactionFull{
+		Receipt: &pldapi.TransactionReceiptData{Success: true},
+	}, nil).Once()
+	status, err = s.GetTxStatus(ctx, txID)
+	require.NoError(t, err)
+	assert.Equal(t, "confirmed", status.Status)
+	assert.Empty(t, status.BlockedReason)
+}
+
+func TestSequencerGetTxStatusPersistedBlockedReasonFail(t *testing.T) {
+	ctx := context.Background()
+	s, mocks, done := newSequencerForTesting(t, ctx, nil)
+	s.Stop()
+	defer done()
+
+	db, err := mockpersistence.NewSQLMockProvider()
+	require.NoError(t, err)
+	allComponents := componentmocks.NewAllComponents(t)
+	allComponents.On("Persistence").Return(db.P)
+	allComponents.On("TxManager").Return(mocks.txManager)
+	s.components = allComponents
+
+	txID := uuid.New()
+	mocks.txManager.On("GetTransactionByIDFull", mock.Anything, txID).Return(&pldapi.TransactionFull{}, nil)
+	db.Mock.ExpectQuery("SELECT.*private_tx_blocked_reasons").WillReturnError(errors.New("pop"))
+	_, err = s.GetTxStatus(ctx, txID)
+	assert.Regexp(t, "pop", err)
+}
@@ -17,6 +17,7 @@ package privatetxnmgr
 
 import (
 	"context"
+	"strings"
 	"sync"
 	"time"
 
@@ -29,6 +30,8 @@ import (
 
 	"github.com/kaleido-io/paladin/toolkit/pkg/log"
 	"github.com/kaleido-io/paladin/toolkit/pkg/prototk"
+	"github.com/kaleido-io/paladin/toolkit/pkg/retry"
+	"github.com/kaleido-io/paladin/toolkit/pkg/tkmsgs"
 )
 
 func NewTransactionFlow(
@@ -44,6 +47,14 @@ func NewTransactionFlow(
 	syncPoints syncpoints.SyncPoints,
 	transportWriter ptmgrtypes.TransportWriter,
 	requestTimeout time.Duration,
+	delegationTimeout time.Duration,
+	delegationFence *delegationFence,
+	maxReassemblyAttempts int,
+	assembleRetry *retry.Retry,
+	maxAssembleAttempts int,
+	endorsementQuorum int,
+	untrustedPolicy string,
+	metrics *privateTxManagerMetrics,
 	selectCoordinator ptmgrtypes.CoordinatorSelector,
 	assembleCoordinator ptmgrtypes.AssembleCoordinator,
 	environment ptmgrtypes.SequencerEnvironment,
@@ -73,6 +84,14 @@ func NewTransactionFlow(
 		prepared:                    false,
 		clock:                       ptmgrtypes.RealClock(),
 		requestTimeout:              requestTimeout,
+		delegationTimeout:           delegationTimeout,
+		delegationFence:             delegationFence,
+		maxReassemblyAttempts:       maxReassemblyAttempts,
+		assembleRetry:               assembleRetry,
+		maxAssembleAttempts:         maxAssembleAttempts,
+		endorsementQuorum:           endorsementQuorum,
+		untrustedPolicy:             untrustedPolicy,
+		metrics:                     metrics,
 		selectCoordinator:           selectCoordinator,
 		assembleCoordinator:         assembleCoordinator,
 		environment:                 environment,
@@ -109,6 +128,9 @@ type transactionFlow struct {
 	delegateRequestBlockHeight  int64
 	delegated                   bool
 	delegateRequestTimer        *time.Timer
+	delegateNode                string    // the node we are delegating to, while a delegation is pending
+	delegationStartTime         time.Time // when we started delegating to delegateNode, across repeated requests
+	delegationReclaimedFrom     string    // a delegate that never accepted the transaction, so we coordinate it locally instead
 	assemblePending             bool
 	complete                    bool
 	requestedVerifierResolution bool                                      //TODO add precision here so that we can track individual requests and implement retry as per endorsement
@@ -119,6 +141,21 @@ type transactionFlow struct {
 	prepared                    bool
 	clock                       ptmgrtypes.Clock
 	requestTimeout              time.Duration
+	delegationTimeout           time.Duration // 0 means we wait indefinitely for a delegate to accept
+	maxReassemblyAttempts       int           // 0 means no limit
+	reassemblyCount             int           // number of times the transaction has been sent back for re-assembly after a revert
+	reassemblyRevertReasons     []string      // revert reasons accumulated across the re-assembly attempts
+	assembleRetry               *retry.Retry  // backoff between attempts to assemble after a failure
+	maxAssembleAttempts         int           // 0 means no limit
+	assembleFailureCount        int           // consecutive failed attempts to assemble
+	assembleRetryTime           time.Time     // we do not attempt to assemble again until this time
+	assembleRetryTimer          *time.Timer   // nudges the transaction when the backoff has passed
+	endorsementQuorum           int           // parties per endorsement attestation request that must endorse - 0 means all
+	untrustedPolicy             string        // drop or revert, on an endorsement from a party that was not in the expected endorser set
+	metrics                     *privateTxManagerMetrics
+	delegationFence             *delegationFence
+	blockedReason               string // what the transaction is waiting on, re-evaluated each time it is actioned
+	dispatchBlockedReason       string // set by the sequencer, for an endorsed transaction that it has not dispatched
 	selectCoordinator           ptmgrtypes.CoordinatorSelector
 	assembleCoordinator         ptmgrtypes.AssembleCoordinator
 	environment                 ptmgrtypes.SequencerEnvironment
@@ -142,6 +179,16 @@ func (tf *transactionFlow) IsEndorsed(ctx context.Context) bool {
 	return !tf.hasOutstandingEndorsementRequests(ctx)
 }
 
+func (tf *transactionFlow) Stop(ctx context.Context) {
+	tf.statusLock.Lock()
+	defer tf.statusLock.Unlock()
+	if tf.assembleRetryTimer != nil {
+		log.L(ctx).Debugf("Cancelling assemble retry of transaction %s", tf.transaction.ID)
+		tf.assembleRetryTimer.Stop()
+		tf.assembleRetryTimer = nil
+	}
+}
+
 func (tf *transactionFlow) CoordinatingLocally(_ context.Context) bool {
 	return tf.localCoordinator
 }
@@ -149,7 +196,7 @@ func (tf *transactionFlow) CoordinatingLocally(_ context.Context) bool {
 func (tf *transactionFlow) PrepareTransaction(ctx context.Context, defaultSigner string) (*components.PrivateTransaction, error) {
 
 	if tf.transaction.Signer == "" {
-		log.L(ctx).Infof("Using random signing key from sequencer to prepare transaction: %s", defaultSigner)
+		log.L(ctx).Infof("Using default signing key from sequencer to prepare transaction: %s", defaultSigner)
 		tf.transaction.Signer = defaultSigner
 	}
 
@@ -158,11 +205,27 @@ func (tf *transactionFlow) PrepareTransaction(ctx context.Context, defaultSigner
 	if prepError != nil {
 		log.L(ctx).Errorf("Error preparing transaction: %s", prepError)
 		tf.latestError = i18n.ExpandWithCode(ctx, i18n.MessageKey(msgs.MsgPrivateTxManagerPrepareError), prepError.Error())
+		if strings.Contains(prepError.Error(), string(tkmsgs.MsgPluginEndorsementInvalid)) {
+			tf.discardEndorsements(ctx)
+		}
 		return nil, prepError
 	}
 	return tf.transaction, nil
 }
 
+// The domain can reject endorsements at prepare time (for example if they have expired), so after
+// a prepare that fails for that reason we gather them again before the transaction is next eligible for dispatch.
+// The error comes back across the plugin boundary as a string, so is matched on its code.
+func (tf *transactionFlow) discardEndorsements(ctx context.Context) {
+	if tf.transaction.PostAssembly == nil || len(tf.transaction.PostAssembly.Endorsements) == 0 {
+		return
+	}
+	log.L(ctx).Infof("Discarding %d endorsements for transaction %s after failed prepare", len(tf.transaction.PostAssembly.Endorsements), tf.transaction.ID)
+	tf.transaction.PostAssembly.Endorsements = nil
+	tf.pendingEndorsementRequests = make(map[string]map[string]*endorsementRequest)
+	tf.publisher.PublishNudgeEvent(ctx, tf.transaction.ID.String())
+}
+
 func toEndorsableList(states []*components.FullState) []*prototk.EndorsableState {
 	endorsableList := make([]*prototk.EndorsableState, len(states))
 	for i, input := range states {
@@ -57,6 +57,7 @@ func (tf *transactionFlow) logActionError(ctx context.Context, msg string, err e
 func (tf *transactionFlow) Action(ctx context.Context) {
 	tf.statusLock.Lock()
 	defer tf.statusLock.Unlock()
+	defer tf.updateBlockedReason(ctx)
 
 	tf.logActionDebug(ctx, ">>")
 	if tf.complete {
@@ -138,6 +139,10 @@ func (tf *transactionFlow) Action(ctx context.Context) {
 	// so we are responsible for coordinating the endorsement flow
 	// either because it was submitted locally and we decided not to delegate or because it was delegated to us
 
+	if err := tf.checkEndorsementQuorum(ctx); err != nil {
+		tf.revertTransaction(ctx, err.Error())
+		return
+	}
 	tf.requestEndorsements(ctx)
 	if tf.hasOutstandingEndorsementRequests(ctx) {
 		tf.logActionDebug(ctx, "Transaction not ready to dispatch. Waiting for endorsements to be resolved")
@@ -299,7 +304,11 @@ func (tf *transactionFlow) delegateIfRequired(ctx context.Context) (doContinue b
 			return false
 		}
 		tf.logActionDebug(ctx, "Delegation request timed out")
-
+		if tf.delegationTimeout > 0 && !tf.clock.Now().Before(tf.delegationStartTime.Add(tf.delegationTimeout)) {
+			if !tf.reclaimDelegation(ctx) {
+				return false
+			}
+		}
 	}
 
 	if tf.status == "delegated" {
@@ -328,6 +337,12 @@ func (tf *transactionFlow) delegateIfRequired(ctx context.Context) (doContinue b
 		tf.logActionDebug(ctx, "Local coordinator")
 		return true
 	}
+	if coordinatorNode == tf.delegationReclaimedFrom {
+		// the selected coordinator never accepted this transaction when we delegated it before
+		tf.logActionInfof(ctx, "Coordinating locally as %s did not accept the delegation", coordinatorNode)
+		tf.localCoordinator = true
+		return true
+	}
 	tf.localCoordinator = false
 
 	//TODO if already `delegating` check how long we have been waiting for the ack and send again.
@@ -357,6 +372,10 @@ func (tf *transactionFlow) delegateIfRequired(ctx context.Context) (doContinue b
 		tf.logActionError(ctx, "Failed to send delegation request", err)
 	}
 	tf.pendingDelegationRequestID = delegationRequestID
+	if !tf.delegatePending || tf.delegateNode != coordinatorNode {
+		tf.delegationStartTime = tf.clock.Now()
+	}
+	tf.delegateNode = coordinatorNode
 	tf.delegatePending = true
 	tf.delegateRequestBlockHeight = blockHeight
 	tf.delegateRequestTime = tf.clock.Now()
@@ -369,6 +388,36 @@ func (tf *transactionFlow) delegateIfRequired(ctx context.Context) (doContinue b
 
 }
 
+// Gives up on a delegation that the delegate has not accepted within the delegation timeout, so the transaction
+// is not orphaned if the delegate is permanently down. The caller then re-selects the coordinator, and we coordinate
+// locally if the same delegate is selected again, or re-delegate if an alternate is selected.
+// Returns false if the delegate has already asked us to assemble the transaction, so has accepted it even though
+// its acknowledgment has not reached us - in which case we leave the transaction with it.
+func (tf *transactionFlow) reclaimDelegation(ctx context.Context) bool {
+	if tf.delegateRequestTimer != nil {
+		tf.delegateRequestTimer.Stop()
+		tf.delegateRequestTimer = nil
+	}
+	if !tf.delegationFence.reclaim(tf.transaction.ID.String(), tf.delegateNode) {
+		tf.logActionInfof(ctx, "Not reclaiming transaction as %s has requested it be assembled, so has accepted the delegation", tf.delegateNode)
+		tf.status = "delegated"
+		tf.delegated = true
+		tf.delegatePending = false
+		return false
+	}
+	tf.logActionInfof(ctx, "Reclaiming transaction as %s has not accepted the delegation since %s", tf.delegateNode, tf.delegationStartTime)
+	tf.latestError = i18n.ExpandWithCode(ctx, i18n.MessageKey(msgs.MsgPrivateTxMgrDelegationReclaimed), tf.delegateNode, tf.delegationTimeout)
+	tf.delegationReclaimedFrom = tf.delegateNode
+	tf.delegatePending = false
+	tf.pendingDelegationRequestID = ""
+	tf.delegateNode = ""
+	tf.delegationStartTime = time.Time{}
+	tf.localCoordinator = true
+	tf.status = "reclaimed"
+	tf.publisher.PublishTransactionDelegationReclaimedEvent(ctx, tf.transaction.ID.String(), tf.delegationReclaimedFrom)
+	return true
+}
+
 func (tf *transactionFlow) writeAndLockStates(ctx context.Context) {
 	//this needs to be carefully coordinated with the assemble requester thread and the sequencer event loop thread
 	// we are accessing the transactionFlow's PrivateTransaction object which is only safe to do on the sequencer thread
@@ -429,6 +478,11 @@ func (tf *transactionFlow) requestAssemble(ctx context.Context) {
 		return
 	}
 
+	if tf.clock.Now().Before(tf.assembleRetryTime) {
+		tf.logActionDebugf(ctx, "Waiting until %s to retry assemble after %d failures", tf.assembleRetryTime, tf.assembleFailureCount)
+		return
+	}
+
 	var err error
 	var assemblingNode string
 	preAssemblyCopy := *tf.transaction.PreAssembly
@@ -17,12 +17,17 @@ package privatetxnmgr
 
 import (
 	"context"
+	"slices"
+	"strings"
+	"time"
 
+	"github.com/kaleido-io/paladin/config/pkg/pldconf"
 	"github.com/kaleido-io/paladin/core/internal/msgs"
 	"github.com/kaleido-io/paladin/core/internal/privatetxnmgr/ptmgrtypes"
 	"github.com/kaleido-io/paladin/toolkit/pkg/i18n"
 	"github.com/kaleido-io/paladin/toolkit/pkg/log"
 	"github.com/kaleido-io/paladin/toolkit/pkg/prototk"
+	"github.com/kaleido-io/paladin/toolkit/pkg/tktypes"
 )
 
 func (tf *transactionFlow) ApplyEvent(ctx context.Context, event ptmgrtypes.PrivateTransactionEvent) {
@@ -111,6 +116,7 @@ func (tf *transactionFlow) applyTransactionAssembledEvent(ctx context.Context, e
 		return
 	}
 	tf.status = "assembled"
+	tf.assembleFailureCount = 0
 	tf.writeAndLockStates(ctx)
 
 	//allow assembly thread to proceed
@@ -125,6 +131,24 @@ func (tf *transactionFlow) applyTransactionAssembleFailedEvent(ctx context.Conte
 	// set assemblePending to false so that the transaction can be re-assembled
 	tf.assemblePending = false
 	tf.assembleCoordinator.Complete(event.AssembleRequestID)
+
+	// Failures are often transient, such as the states the transaction requires not yet being available on the
+	// assembling node, so we back off and try again rather than reverting straight away
+	tf.assembleFailureCount++
+	if tf.maxAssembleAttempts > 0 && tf.assembleFailureCount >= tf.maxAssembleAttempts {
+		tf.revertTransaction(ctx, i18n.ExpandWithCode(ctx, i18n.MessageKey(msgs.MsgPrivateTxMgrMaxAssembleAttempts),
+			tf.transaction.ID.String(), tf.assembleFailureCount, event.Error))
+		return
+	}
+	retryDelay := tf.assembleRetry.Delay(tf.assembleFailureCount)
+	log.L(ctx).Infof("Assemble of transaction %s failed (failures=%d), retrying after %s", tf.transaction.ID.String(), tf.assembleFailureCount, retryDelay)
+	tf.assembleRetryTime = tf.clock.Now().Add(retryDelay)
+	if tf.assembleRetryTimer != nil {
+		tf.assembleRetryTimer.Stop()
+	}
+	tf.assembleRetryTimer = time.AfterFunc(retryDelay, func() {
+		tf.publisher.PublishNudgeEvent(ctx, tf.transaction.ID.String())
+	})
 }
 
 func (tf *transactionFlow) applyTransactionSignedEvent(ctx context.Context, event *ptmgrtypes.TransactionSignedEvent) {
@@ -154,6 +178,18 @@ func (tf *transactionFlow) applyTransactionEndorsedEvent(ctx context.Context, ev
 		log.L(ctx).Debugf("Pending request idempotencyKey %s does not match endorsement idempotencyKey %s, assuming response from obsolete request", pendingRequest.idempotencyKey, event.IdempotencyKey)
 		return
 	}
+
+	if tf.isUntrustedEndorsement(ctx, event) {
+		// this is security relevant - a node has responded on behalf of a party that it cannot speak for.
+		// The request stays pending, so the genuine endorsement can still be accepted if we are not reverting.
+		log.L(ctx).Errorf("Untrusted endorsement for attestation request %s of transaction %s from party %s sent by node %s (policy=%s)", event.AttestationRequestName, tf.transaction.ID.String(), event.Party, event.FromNode, tf.untrustedPolicy)
+		tf.metrics.recordUntrustedEndorsement()
+		if tf.untrustedPolicy == pldconf.UntrustedEndorsementPolicyRevert {
+			tf.revertTransaction(ctx, i18n.ExpandWithCode(ctx, i18n.MessageKey(msgs.MsgPrivateTxMgrUntrustedEndorsement),
+				tf.transaction.ID.String(), event.AttestationRequestName, event.Party, event.FromNode))
+		}
+		return
+	}
 	//we have (had) a pending request for this endorsement but it is no longer pending because we now have a response
 	delete(pendingRequestsForAttRequestName, event.Party)
 
@@ -166,6 +202,14 @@ func (tf *transactionFlow) applyTransactionEndorsedEvent(ctx context.Context, ev
 		//TODO - there may be other endorsements that are en route, based on the previous assembly.  Need to make sure that
 		// we discard them when they do return.
 		//only apply at this stage, action will be taken later
+		tf.reassemblyRevertReasons = append(tf.reassemblyRevertReasons, *event.RevertReason)
+		if tf.maxReassemblyAttempts > 0 && tf.reassemblyCount >= tf.maxReassemblyAttempts {
+			// the transaction keeps getting reverted, so rather than looping forever we give up and fail it
+			tf.revertTransaction(ctx, i18n.ExpandWithCode(ctx, i18n.MessageKey(msgs.MsgPrivateTxMgrMaxReassemblyAttempts),
+				tf.transaction.ID.String(), tf.maxReassemblyAttempts, strings.Join(tf.reassemblyRevertReasons, "; ")))
+		} else {
+			tf.reassemblyCount++
+		}
 		tf.transaction.PostAssembly = nil
 		// remove all pending endorsement request records because they are no longer valid
 		tf.pendingEndorsementRequests = make(map[string]map[string]*endorsementRequest)
@@ -177,6 +221,44 @@ func (tf *transactionFlow) applyTransactionEndorsedEvent(ctx context.Context, ev
 	}
 }
 
+// An endorsement is untrusted if it was not sent by the node of the party it claims to be from, if the party is
+// not in the attestation plan of the request it answers, or if the verifier in the result is not the party (or
+// does not match the verifier we resolved for that party)
+func (tf *transactionFlow) isUntrustedEndorsement(ctx context.Context, event *ptmgrtypes.TransactionEndorsedEvent) bool {
+	partyNode, err := tktypes.PrivateIdentityLocator(event.Party).Node(ctx, true)
+	if err != nil {
+		return true
+	}
+	if partyNode == "" {
+		partyNode = tf.nodeName
+	}
+	if event.FromNode != partyNode {
+		return true
+	}
+	if tf.transaction.PostAssembly != nil {
+		for _, attRequest := range tf.transaction.PostAssembly.AttestationPlan {
+			if attRequest.Name == event.AttestationRequestName && !slices.Contains(attRequest.Parties, event.Party) {
+				return true
+			}
+		}
+	}
+	if event.RevertReason != nil || event.Endorsement == nil || event.Endorsement.Verifier == nil {
+		return false
+	}
+	endorser := event.Endorsement.Verifier
+	if endorser.Lookup != event.Party {
+		return true
+	}
+	if tf.transaction.PreAssembly != nil {
+		for _, v := range tf.transaction.PreAssembly.Verifiers {
+			if v.Lookup == endorser.Lookup && v.Algorithm == endorser.Algorithm && v.VerifierType == endorser.VerifierType {
+				return v.Verifier != endorser.Verifier
+			}
+		}
+	}
+	return false
+}
+
 func (tf *transactionFlow) applyTransactionDispatchedEvent(ctx context.Context, event *ptmgrtypes.TransactionDispatchedEvent) {
 	log.L(ctx).Debugf("transactionFlow:applyTransactionDispatchedEvent transactionID:%s nonce:%d signingAddress:%s", tf.transaction.ID.String(), event.Nonce, event.SigningAddress)
 	tf.latestEvent = "TransactionDispatchedEvent"
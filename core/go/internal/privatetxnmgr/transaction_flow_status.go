@@ -17,11 +17,19 @@ package privatetxnmgr
 
 import (
 	"context"
+	"slices"
+	"strings"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/kaleido-io/paladin/core/internal/components"
+	"github.com/kaleido-io/paladin/core/internal/msgs"
+	"github.com/kaleido-io/paladin/core/pkg/persistence"
+	"github.com/kaleido-io/paladin/toolkit/pkg/i18n"
 	"github.com/kaleido-io/paladin/toolkit/pkg/log"
 	"github.com/kaleido-io/paladin/toolkit/pkg/prototk"
+	"github.com/kaleido-io/paladin/toolkit/pkg/tktypes"
+	"gorm.io/gorm/clause"
 )
 
 func (tf *transactionFlow) GetTxStatus(ctx context.Context) (components.PrivateTxStatus, error) {
@@ -43,20 +51,146 @@ func (tf *transactionFlow) GetTxStatus(ctx context.Context) (components.PrivateT
 	}
 
 	return components.PrivateTxStatus{
-		TxID:         tf.transaction.ID.String(),
-		Status:       tf.status,
-		LatestEvent:  tf.latestEvent,
-		LatestError:  tf.latestError,
-		Endorsements: endorsementStatus,
-		Transaction:  tf.transaction,
+		TxID:                tf.transaction.ID.String(),
+		Status:              tf.status,
+		LatestEvent:         tf.latestEvent,
+		LatestError:         tf.latestError,
+		Endorsements:        endorsementStatus,
+		EndorsementProgress: tf.endorsementProgress(ctx),
+		BlockedReason:       tf.blockedReason,
+		Transaction:         tf.transaction,
 	}, nil
 }
 
+func (tf *transactionFlow) SetDispatchBlockedReason(ctx context.Context, reason string) {
+	tf.statusLock.Lock()
+	defer tf.statusLock.Unlock()
+	tf.dispatchBlockedReason = reason
+	tf.updateBlockedReason(ctx)
+}
+
+func (tf *transactionFlow) updateBlockedReason(ctx context.Context) {
+	blockedReason := tf.evaluateBlockedReason(ctx)
+	if blockedReason != tf.blockedReason {
+		log.L(ctx).Debugf("Transaction %s blocked reason: %s", tf.transaction.ID, blockedReason)
+		if err := writeBlockedReason(ctx, tf.components.Persistence(), tf.transaction.ID, blockedReason); err != nil {
+			// the reason is only for diagnostics, so we try again when it next changes
+			log.L(ctx).Warnf("Failed to persist blocked reason for transaction %s: %s", tf.transaction.ID, err)
+		}
+	}
+	tf.blockedReason = blockedReason
+}
+
+type persistedBlockedReason struct {
+	Transaction uuid.UUID         `gorm:"column:transaction;primaryKey"`
+	Reason      string            `gorm:"column:reason"`
+	Updated     tktypes.Timestamp `gorm:"column:updated"`
+}
+
+// The blocked reason is persisted so it can be queried for a transaction that is not in memory,
+// and is removed once the transaction is no longer blocked
+func writeBlockedReason(ctx context.Context, p persistence.Persistence, txID uuid.UUID, blockedReason string) error {
+	if blockedReason == "" {
+		return p.DB().
+			WithContext(ctx).
+			Table("private_tx_blocked_reasons").
+			Where(`"transaction" = ?`, txID).
+			Delete(&persistedBlockedReason{}).
+			Error
+	}
+	return p.DB().
+		WithContext(ctx).
+		Table("private_tx_blocked_reasons").
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "transaction"}},
+			DoUpdates: clause.AssignmentColumns([]string{"reason", "updated"}),
+		}).
+		Create(&persistedBlockedReason{
+			Transaction: txID,
+			Reason:      blockedReason,
+			Updated:     tktypes.TimestampNow(),
+		}).
+		Error
+}
+
+func readBlockedReason(ctx context.Context, p persistence.Persistence, txID uuid.UUID) (string, error) {
+	var blockedReasons []*persistedBlockedReason
+	err := p.DB().
+		WithContext(ctx).
+		Table("private_tx_blocked_reasons").
+		Where(`"transaction" = ?`, txID).
+		Limit(1).
+		Find(&blockedReasons).
+		Error
+	if err != nil || len(blockedReasons) == 0 {
+		return "", err
+	}
+	return blockedReasons[0].Reason, nil
+}
+
+// Explains the first thing the transaction is waiting on, in the order the flow progresses through them
+func (tf *transactionFlow) evaluateBlockedReason(ctx context.Context) string {
+	switch {
+	case tf.complete, tf.dispatched, tf.finalizeRequired:
+		return ""
+	case tf.delegatePending:
+		return i18n.ExpandWithCode(ctx, i18n.MessageKey(msgs.MsgPrivateTxBlockedDelegation), tf.delegateNode)
+	case tf.transaction.PostAssembly == nil:
+		if unresolved := tf.unresolvedVerifiers(); len(unresolved) > 0 {
+			return i18n.ExpandWithCode(ctx, i18n.MessageKey(msgs.MsgPrivateTxBlockedVerifiers), strings.Join(unresolved, ","))
+		}
+		return i18n.ExpandWithCode(ctx, i18n.MessageKey(msgs.MsgPrivateTxBlockedAssembly))
+	}
+	if unsigned := tf.outstandingSignatureRequests(); len(unsigned) > 0 {
+		return i18n.ExpandWithCode(ctx, i18n.MessageKey(msgs.MsgPrivateTxBlockedSignatures), strings.Join(unsigned, ","))
+	}
+	if outstanding := tf.endorsementProgress(ctx).Outstanding; len(outstanding) > 0 {
+		return i18n.ExpandWithCode(ctx, i18n.MessageKey(msgs.MsgPrivateTxBlockedEndorsements), strings.Join(outstanding, ","))
+	}
+	return tf.dispatchBlockedReason
+}
+
+func (tf *transactionFlow) endorsementProgress(ctx context.Context) *components.PrivateTxEndorsementProgress {
+	progress := &components.PrivateTxEndorsementProgress{
+		Outstanding: make([]string, 0),
+	}
+	if tf.transaction.PostAssembly == nil {
+		return progress
+	}
+	for _, attRequest := range tf.transaction.PostAssembly.AttestationPlan {
+		if attRequest.AttestationType == prototk.AttestationType_ENDORSE {
+			required := tf.endorsementsRequired(attRequest)
+			progress.Required += required
+			progress.Gathered += min(len(attRequest.Parties)-len(tf.unendorsedParties(ctx, attRequest)), required)
+		}
+	}
+	// a party might be required to endorse against more than one attestation request, but we only need to name them once
+	for _, requirement := range tf.outstandingEndorsementRequests(ctx) {
+		if !slices.Contains(progress.Outstanding, requirement.party) {
+			progress.Outstanding = append(progress.Outstanding, requirement.party)
+		}
+	}
+	return progress
+}
+
 func (tf *transactionFlow) hasOutstandingVerifierRequests(ctx context.Context) bool {
 	log.L(ctx).Debug("transactionFlow:hasOutstandingVerifierRequests")
 
-	// assume they are all resolved until we find one in RequiredVerifiers that is not in Verifiers
-	verifiersResolved := true
+	if len(tf.unresolvedVerifiers()) == 0 {
+		return false
+	} else {
+		log.L(ctx).Infof("Waiting for verifiers to be resolved for transaction %s", tf.transaction.ID.String())
+		return true
+	}
+
+}
+
+// The lookups in RequiredVerifiers that are not yet in Verifiers
+func (tf *transactionFlow) unresolvedVerifiers() []string {
+	unresolved := make([]string, 0)
+	if tf.transaction.PreAssembly == nil {
+		return unresolved
+	}
 	for _, v := range tf.transaction.PreAssembly.RequiredVerifiers {
 		thisVerifierIsResolved := false
 		for _, rv := range tf.transaction.PreAssembly.Verifiers {
@@ -66,21 +200,19 @@ func (tf *transactionFlow) hasOutstandingVerifierRequests(ctx context.Context) b
 			}
 		}
 		if !thisVerifierIsResolved {
-			verifiersResolved = false
+			unresolved = append(unresolved, v.Lookup)
 		}
 	}
-	if verifiersResolved {
-		return false
-	} else {
-		log.L(ctx).Infof("Waiting for verifiers to be resolved for transaction %s", tf.transaction.ID.String())
-		return true
-	}
-
+	return unresolved
 }
 
 func (tf *transactionFlow) hasOutstandingSignatureRequests() bool {
-	outstandingSignatureRequests := false
-out:
+	return len(tf.outstandingSignatureRequests()) > 0
+}
+
+// The names of the signing attestation requests that we do not yet have a signature for
+func (tf *transactionFlow) outstandingSignatureRequests() []string {
+	outstandingSignatureRequests := make([]string, 0)
 	for _, attRequest := range tf.transaction.PostAssembly.AttestationPlan {
 		if attRequest.AttestationType == prototk.AttestationType_SIGN {
 			found := false
@@ -91,9 +223,7 @@ out:
 				}
 			}
 			if !found {
-				outstandingSignatureRequests = true
-				// no point checking any further, we have at least one outstanding signature request
-				break out
+				outstandingSignatureRequests = append(outstandingSignatureRequests, attRequest.Name)
 			}
 		}
 	}
@@ -117,32 +247,66 @@ func (tf *transactionFlow) outstandingEndorsementRequests(ctx context.Context) [
 	}
 	for _, attRequest := range tf.transaction.PostAssembly.AttestationPlan {
 		if attRequest.AttestationType == prototk.AttestationType_ENDORSE {
-			for _, party := range attRequest.Parties {
-				found := false
-				for _, endorsement := range tf.transaction.PostAssembly.Endorsements {
-					found = endorsement.Name == attRequest.Name &&
-						party == endorsement.Verifier.Lookup &&
-						attRequest.VerifierType == endorsement.Verifier.VerifierType
-					log.L(ctx).Infof("endorsement matched=%t: request[name=%s,party=%s,verifierType=%s] endorsement[name=%s,party=%s,verifierType=%s] verifier=%s",
-						found,
-						attRequest.Name, party, attRequest.VerifierType,
-						endorsement.Name, endorsement.Verifier.Lookup, endorsement.Verifier.VerifierType,
-						endorsement.Verifier.Verifier,
-					)
-					if found {
-						break
-					}
-				}
-				if !found {
-					log.L(ctx).Debugf("endorsement request for %s outstanding for transaction %s", party, tf.transaction.ID)
-					outstandingEndorsementRequests = append(outstandingEndorsementRequests, &endorsementRequirement{party: party, attRequest: attRequest})
-				}
+			unendorsed := tf.unendorsedParties(ctx, attRequest)
+			// once the quorum is reached we no longer wait on, or request endorsements from, the remaining parties
+			if len(attRequest.Parties)-len(unendorsed) >= tf.endorsementsRequired(attRequest) {
+				continue
+			}
+			for _, party := range unendorsed {
+				log.L(ctx).Debugf("endorsement request for %s outstanding for transaction %s", party, tf.transaction.ID)
+				outstandingEndorsementRequests = append(outstandingEndorsementRequests, &endorsementRequirement{party: party, attRequest: attRequest})
 			}
 		}
 	}
 	return outstandingEndorsementRequests
 }
 
+func (tf *transactionFlow) unendorsedParties(ctx context.Context, attRequest *prototk.AttestationRequest) []string {
+	unendorsed := make([]string, 0, len(attRequest.Parties))
+	for _, party := range attRequest.Parties {
+		found := false
+		for _, endorsement := range tf.transaction.PostAssembly.Endorsements {
+			found = endorsement.Name == attRequest.Name &&
+				party == endorsement.Verifier.Lookup &&
+				attRequest.VerifierType == endorsement.Verifier.VerifierType
+			log.L(ctx).Infof("endorsement matched=%t: request[name=%s,party=%s,verifierType=%s] endorsement[name=%s,party=%s,verifierType=%s] verifier=%s",
+				found,
+				attRequest.Name, party, attRequest.VerifierType,
+				endorsement.Name, endorsement.Verifier.Lookup, endorsement.Verifier.VerifierType,
+				endorsement.Verifier.Verifier,
+			)
+			if found {
+				break
+			}
+		}
+		if !found {
+			unendorsed = append(unendorsed, party)
+		}
+	}
+	return unendorsed
+}
+
+// Every party in the attestation request must endorse, unless a smaller quorum is configured for the domain
+func (tf *transactionFlow) endorsementsRequired(attRequest *prototk.AttestationRequest) int {
+	if tf.endorsementQuorum > 0 && tf.endorsementQuorum < len(attRequest.Parties) {
+		return tf.endorsementQuorum
+	}
+	return len(attRequest.Parties)
+}
+
+// A quorum larger than the number of parties in an attestation request can never be met
+func (tf *transactionFlow) checkEndorsementQuorum(ctx context.Context) error {
+	if tf.endorsementQuorum == 0 || tf.transaction.PostAssembly == nil {
+		return nil
+	}
+	for _, attRequest := range tf.transaction.PostAssembly.AttestationPlan {
+		if attRequest.AttestationType == prototk.AttestationType_ENDORSE && len(attRequest.Parties) < tf.endorsementQuorum {
+			return i18n.NewError(ctx, msgs.MsgPrivateTxMgrEndorsementQuorumTooLarge, tf.endorsementQuorum, tf.transaction.Domain, len(attRequest.Parties), attRequest.Name)
+		}
+	}
+	return nil
+}
+
 func (tf *transactionFlow) endorsementRequirements(ctx context.Context) []*endorsementRequirement {
 	//utility function to fold all the attestation plan into a single list, filtered by type - Endorse
 	endorsementRequests := make([]*endorsementRequirement, 0)
@@ -17,21 +17,27 @@ package privatetxnmgr
 
 import (
 	"context"
+	"fmt"
 	"testing"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/kaleido-io/paladin/config/pkg/confutil"
+	"github.com/kaleido-io/paladin/config/pkg/pldconf"
 	"github.com/kaleido-io/paladin/core/internal/components"
 	"github.com/kaleido-io/paladin/core/internal/privatetxnmgr/ptmgrtypes"
 	"github.com/kaleido-io/paladin/core/mocks/componentmocks"
 	"github.com/kaleido-io/paladin/core/mocks/privatetxnmgrmocks"
 	"github.com/kaleido-io/paladin/core/mocks/prvtxsyncpointsmocks"
+	"github.com/kaleido-io/paladin/core/pkg/persistence"
+	"github.com/kaleido-io/paladin/core/pkg/persistence/mockpersistence"
 	"github.com/kaleido-io/paladin/toolkit/pkg/algorithms"
 	"github.com/kaleido-io/paladin/toolkit/pkg/prototk"
+	"github.com/kaleido-io/paladin/toolkit/pkg/retry"
 	"github.com/kaleido-io/paladin/toolkit/pkg/signpayloads"
 	"github.com/kaleido-io/paladin/toolkit/pkg/tktypes"
 	"github.com/kaleido-io/paladin/toolkit/pkg/verifiers"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
@@ -53,6 +59,7 @@ type transactionFlowDepencyMocks struct {
 	environment         *privatetxnmgrmocks.SequencerEnvironment
 	coordinatorSelector *privatetxnmgrmocks.CoordinatorSelector
 	localAssembler      *privatetxnmgrmocks.LocalAssembler
+	persistence         persistence.Persistence
 }
 
 func newTransactionFlowForTesting(t *testing.T, ctx context.Context, transaction *components.PrivateTransaction, nodeName string) (*transactionFlow, *transactionFlowDepencyMocks) {
@@ -74,11 +81,16 @@ func newTransactionFlowForTesting(t *testing.T, ctx context.Context, transaction
 		coordinatorSelector: privatetxnmgrmocks.NewCoordinatorSelector(t),
 		localAssembler:      privatetxnmgrmocks.NewLocalAssembler(t),
 	}
+	p, persistenceDone, err := persistence.NewUnitTestPersistence(ctx, "privatetxmgr")
+	require.NoError(t, err)
+	t.Cleanup(persistenceDone)
+	mocks.persistence = p
 	contractAddress := tktypes.RandAddress()
 	mocks.allComponents.On("StateManager").Return(mocks.stateStore).Maybe()
 	mocks.allComponents.On("DomainManager").Return(mocks.domainMgr).Maybe()
 	mocks.allComponents.On("TransportManager").Return(mocks.transportManager).Maybe()
 	mocks.allComponents.On("KeyManager").Return(mocks.keyManager).Maybe()
+	mocks.allComponents.On("Persistence").Return(mocks.persistence).Maybe()
 	mocks.endorsementGatherer.On("DomainContext").Return(mocks.domainContext).Maybe()
 	mocks.domainSmartContract.On("Address").Return(*contractAddress).Maybe()
 	mocks.domainSmartContract.On("ContractConfig").Return(&prototk.ContractConfig{
@@ -91,7 +103,7 @@ func newTransactionFlowForTesting(t *testing.T, ctx context.Context, transaction
 
 	assembleCoordinator := NewAssembleCoordinator(ctx, nodeName, 1, mocks.allComponents, mocks.domainSmartContract, mocks.domainContext, mocks.transportWriter, *contractAddress, mocks.environment, 1*time.Second, mocks.localAssembler)
 
-	tp := NewTransactionFlow(ctx, transaction, nodeName, mocks.allComponents, mocks.domainSmartContract, mocks.domainContext, mocks.publisher, mocks.endorsementGatherer, mocks.identityResolver, mocks.syncPoints, mocks.transportWriter, 1*time.Minute, mocks.coordinatorSelector, assembleCoordinator, mocks.environment)
+	tp := NewTransactionFlow(ctx, transaction, nodeName, mocks.allComponents, mocks.domainSmartContract, mocks.domainContext, mocks.publisher, mocks.endorsementGatherer, mocks.identityResolver, mocks.syncPoints, mocks.transportWriter, 1*time.Minute, 0, newDelegationFence(), 10, retry.NewRetryLimited(&pldconf.PrivateTxManagerDefaults.Sequencer.AssembleRetry), 5, 0, pldconf.UntrustedEndorsementPolicyDrop, nil, mocks.coordinatorSelector, assembleCoordinator, mocks.environment)
 
 	return tp.(*transactionFlow), mocks
 }
@@ -1084,6 +1096,7 @@ func TestTimedOutEndorsementRequest(t *testing.T) {
 			},
 		},
 		Party:                  bobIdentityLocator,
+		FromNode:               "node2",
 		AttestationRequestName: "foo",
 		IdempotencyKey:         idempotencyKeyBob,
 	})
@@ -1203,6 +1216,7 @@ func TestEndorsementResponseAfterRevert(t *testing.T) {
 			ContractAddress: testContractAddress.String(),
 		},
 		Party:                  bobIdentityLocator,
+		FromNode:               "node2",
 		AttestationRequestName: "foo",
 		IdempotencyKey:         bobIdempotencyKey,
 		RevertReason:           confutil.P("bob refused to endorse"),
@@ -1226,6 +1240,7 @@ func TestEndorsementResponseAfterRevert(t *testing.T) {
 			},
 		},
 		Party:                  carolIdentityLocator,
+		FromNode:               "node2",
 		AttestationRequestName: "foo",
 		IdempotencyKey:         carolIdempotencyKey,
 	})
@@ -1349,6 +1364,7 @@ func TestEndorsementResponseAfterReassemble(t *testing.T) {
 			ContractAddress: testContractAddress.String(),
 		},
 		Party:                  bobIdentityLocator,
+		FromNode:               "node2",
 		AttestationRequestName: "foo",
 		RevertReason:           confutil.P("bob refused to endorse"),
 		IdempotencyKey:         bobIdempotencyKey,
@@ -1399,6 +1415,7 @@ func TestEndorsementResponseAfterReassemble(t *testing.T) {
 			Payload:     payloadFromAssemble1,
 		},
 		Party:                  carolIdentityLocator,
+		FromNode:               "node2",
 		AttestationRequestName: "foo",
 		IdempotencyKey:         carolIdempotencyKey,
 	})
@@ -1555,6 +1572,7 @@ func TestDuplicateEndorsementResponse(t *testing.T) {
 			},
 		},
 		Party:                  aliceIdentityLocator,
+		FromNode:               "node1",
 		AttestationRequestName: "foo",
 		IdempotencyKey:         aliceIdempotencyKey,
 	})
@@ -1582,6 +1600,7 @@ func TestDuplicateEndorsementResponse(t *testing.T) {
 			},
 		},
 		Party:                  carolIdentityLocator,
+		FromNode:               "node2",
 		AttestationRequestName: "foo",
 		IdempotencyKey:         carolIdempotencyKey,
 	})
@@ -1600,6 +1619,7 @@ func TestDuplicateEndorsementResponse(t *testing.T) {
 			},
 		},
 		Party:                  carolIdentityLocator,
+		FromNode:               "node2",
 		AttestationRequestName: "foo",
 		IdempotencyKey:         carolIdempotencyKey,
 	})
@@ -1736,13 +1756,14 @@ func TestGetTxStatusPendingEndorsements(t *testing.T) {
 		},
 		IdempotencyKey:         aliceIdempotencyKey,
 		Party:                  aliceIdentityLocator,
+		FromNode:               "node1",
 		AttestationRequestName: "foo",
 		Endorsement: &prototk.AttestationResult{
 			Name: "foo",
 			Verifier: &prototk.ResolvedVerifier{
 				Lookup:       aliceIdentityLocator,
 				Algorithm:    algorithms.ECDSA_SECP256K1,
-				Verifier:     tktypes.RandAddress().String(),
+				Verifier:     aliceVerifier,
 				VerifierType: verifiers.ETH_ADDRESS,
 			},
 		},
@@ -1754,13 +1775,14 @@ func TestGetTxStatusPendingEndorsements(t *testing.T) {
 		},
 		IdempotencyKey:         daveIdempotencyKey,
 		Party:                  daveIdentityLocator,
+		FromNode:               "node4",
 		AttestationRequestName: "bar",
 		Endorsement: &prototk.AttestationResult{
 			Name: "bar",
 			Verifier: &prototk.ResolvedVerifier{
 				Lookup:       daveIdentityLocator,
 				Algorithm:    algorithms.ECDSA_SECP256K1,
-				Verifier:     tktypes.RandAddress().String(),
+				Verifier:     daveVerifier,
 				VerifierType: verifiers.ETH_ADDRESS,
 			},
 		},
@@ -1808,3 +1830,788 @@ type fakeClock struct {
 func (f *fakeClock) Now() time.Time {
 	return time.Now().Add(f.timePassed)
 }
+
+func TestEndorsementRevertMaxReassemblyAttempts(t *testing.T) {
+	// Every endorsement attempt is rejected, so once we have exhausted the configured
+	// number of re-assemblies the transaction is finalized as reverted with all the reasons
+	ctx := context.Background()
+	newTxID := uuid.New()
+	bobIdentityLocator := "bob@node2"
+
+	testTx := &components.PrivateTransaction{
+		ID:     newTxID,
+		Domain: "domain1",
+		PreAssembly: &components.TransactionPreAssembly{
+			TransactionSpecification: &prototk.TransactionSpecification{
+				TransactionId: newTxID.String(),
+			},
+		},
+	}
+
+	tp, mocks := newTransactionFlowForTesting(t, ctx, testTx, "node1")
+	tp.maxReassemblyAttempts = 2
+
+	var finalizeReason string
+	mocks.syncPoints.On("QueueTransactionFinalize", ctx, "domain1", mock.Anything, newTxID, mock.Anything, mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) {
+			finalizeReason = args.Get(4).(string)
+		}).Return().Once()
+
+	rejectEndorsement := func(reason string) {
+		tp.transaction.PostAssembly = &components.TransactionPostAssembly{}
+		tp.pendingEndorsementRequests = map[string]map[string]*endorsementRequest{
+			"foo": {bobIdentityLocator: {idempotencyKey: reason}},
+		}
+		tp.applyTransactionEndorsedEvent(ctx, &ptmgrtypes.TransactionEndorsedEvent{
+			PrivateTransactionEventBase: ptmgrtypes.PrivateTransactionEventBase{
+				TransactionID: newTxID.String(),
+			},
+			Party:                  bobIdentityLocator,
+			FromNode:               "node2",
+			AttestationRequestName: "foo",
+			IdempotencyKey:         reason,
+			RevertReason:           confutil.P(reason),
+		})
+	}
+
+	rejectEndorsement("reason1")
+	assert.Equal(t, 1, tp.reassemblyCount)
+	assert.False(t, tp.finalizeRequired)
+	assert.Nil(t, tp.transaction.PostAssembly)
+
+	rejectEndorsement("reason2")
+	assert.Equal(t, 2, tp.reassemblyCount)
+	assert.False(t, tp.finalizeRequired)
+
+	rejectEndorsement("reason3")
+	assert.Equal(t, 2, tp.reassemblyCount)
+	assert.True(t, tp.finalizeRequired)
+	assert.True(t, tp.finalizePending)
+	assert.Regexp(t, "PD011839.*2.*reason1; reason2; reason3", finalizeReason)
+	assert.Equal(t, finalizeReason, tp.finalizeRevertReason)
+}
+
+func TestUntrustedEndorsementPolicies(t *testing.T) {
+	bobIdentityLocator := "bob@node2"
+	malloryIdentityLocator := "mallory@node3"
+	bobVerifier := tktypes.RandAddress().String()
+
+	setup := func(t *testing.T, policy string) (context.Context, *transactionFlow, *transactionFlowDepencyMocks, *prometheus.Registry) {
+		ctx := context.Background()
+		newTxID := uuid.New()
+		testTx := &components.PrivateTransaction{
+			ID:     newTxID,
+			Domain: "domain1",
+			PreAssembly: &components.TransactionPreAssembly{
+				TransactionSpecification: &prototk.TransactionSpecification{
+					TransactionId: newTxID.String(),
+				},
+				Verifiers: []*prototk.ResolvedVerifier{
+					{
+						Lookup:       bobIdentityLocator,
+						Algorithm:    algorithms.ECDSA_SECP256K1,
+						VerifierType: verifiers.ETH_ADDRESS,
+						Verifier:     bobVerifier,
+					},
+				},
+			},
+			PostAssembly: &components.TransactionPostAssembly{
+				AttestationPlan: []*prototk.AttestationRequest{
+					{
+						Name:            "foo",
+						AttestationType: prototk.AttestationType_ENDORSE,
+						Parties:         []string{bobIdentityLocator},
+					},
+				},
+			},
+		}
+		tp, mocks := newTransactionFlowForTesting(t, ctx, testTx, "node1")
+		tp.untrustedPolicy = policy
+		metrics, registry := newTestPrivateTxManagerMetrics()
+		tp.metrics = metrics
+		tp.pendingEndorsementRequests = map[string]map[string]*endorsementRequest{
+			"foo": {bobIdentityLocator: {idempotencyKey: "key"}},
+		}
+		return ctx, tp, mocks, registry
+	}
+
+	endorsedEvent := func(tp *transactionFlow, party, signer, verifier, fromNode string) *ptmgrtypes.TransactionEndorsedEvent {
+		return &ptmgrtypes.TransactionEndorsedEvent{
+			PrivateTransactionEventBase: ptmgrtypes.PrivateTransactionEventBase{
+				TransactionID: tp.transaction.ID.String(),
+			},
+			Endorsement: &prototk.AttestationResult{
+				Name: "foo",
+				Verifier: &prototk.ResolvedVerifier{
+					Lookup:       signer,
+					Algorithm:    algorithms.ECDSA_SECP256K1,
+					VerifierType: verifiers.ETH_ADDRESS,
+					Verifier:     verifier,
+				},
+			},
+			Party:                  party,
+			AttestationRequestName: "foo",
+			IdempotencyKey:         "key",
+			FromNode:               fromNode,
+		}
+	}
+
+	t.Run("drop", func(t *testing.T) {
+		ctx, tp, _, registry := setup(t, pldconf.UntrustedEndorsementPolicyDrop)
+
+		// a party we did not ask is ignored, as it does not match a pending request
+		tp.applyTransactionEndorsedEvent(ctx, endorsedEvent(tp, malloryIdentityLocator, malloryIdentityLocator, bobVerifier, "node3"))
+		assert.Equal(t, float64(0), gatherUntrustedEndorsements(t, registry))
+
+		// an endorsement for the party sent by a node other than the party's
+		tp.applyTransactionEndorsedEvent(ctx, endorsedEvent(tp, bobIdentityLocator, bobIdentityLocator, bobVerifier, "node3"))
+		// an endorsement signed by a different party to the one it claims to be from
+		tp.applyTransactionEndorsedEvent(ctx, endorsedEvent(tp, bobIdentityLocator, malloryIdentityLocator, bobVerifier, "node2"))
+		// an endorsement signed by a different key to the one we resolved for the party
+		tp.applyTransactionEndorsedEvent(ctx, endorsedEvent(tp, bobIdentityLocator, bobIdentityLocator, tktypes.RandAddress().String(), "node2"))
+		assert.Empty(t, tp.transaction.PostAssembly.Endorsements)
+		assert.Contains(t, tp.pendingEndorsementRequests["foo"], bobIdentityLocator)
+		assert.False(t, tp.finalizeRequired)
+		assert.Equal(t, float64(3), gatherUntrustedEndorsements(t, registry))
+
+		// the transaction continues, and accepts the expected endorser
+		tp.applyTransactionEndorsedEvent(ctx, endorsedEvent(tp, bobIdentityLocator, bobIdentityLocator, bobVerifier, "node2"))
+		assert.Len(t, tp.transaction.PostAssembly.Endorsements, 1)
+		assert.Equal(t, float64(3), gatherUntrustedEndorsements(t, registry))
+	})
+
+	t.Run("revert", func(t *testing.T) {
+		ctx, tp, mocks, registry := setup(t, pldconf.UntrustedEndorsementPolicyRevert)
+
+		var finalizeReason string
+		mocks.syncPoints.On("QueueTransactionFinalize", ctx, "domain1", mock.Anything, tp.transaction.ID, mock.Anything, mock.Anything, mock.Anything).
+			Run(func(args mock.Arguments) {
+				finalizeReason = args.Get(4).(string)
+			}).Return().Once()
+
+		tp.applyTransactionEndorsedEvent(ctx, endorsedEvent(tp, bobIdentityLocator, bobIdentityLocator, bobVerifier, "node3"))
+		assert.Empty(t, tp.transaction.PostAssembly.Endorsements)
+		assert.True(t, tp.finalizeRequired)
+		assert.True(t, tp.finalizePending)
+		assert.Regexp(t, "PD011855.*foo.*bob@node2.*node3", finalizeReason)
+		assert.Equal(t, float64(1), gatherUntrustedEndorsements(t, registry))
+	})
+}
+
+func TestEndorsementRevertUnlimitedReassembly(t *testing.T) {
+	ctx := context.Background()
+	newTxID := uuid.New()
+
+	testTx := &components.PrivateTransaction{
+		ID: newTxID,
+		PreAssembly: &components.TransactionPreAssembly{
+			TransactionSpecification: &prototk.TransactionSpecification{
+				TransactionId: newTxID.String(),
+			},
+		},
+	}
+
+	tp, _ := newTransactionFlowForTesting(t, ctx, testTx, "node1")
+	tp.maxReassemblyAttempts = 0
+
+	for i := 0; i < 20; i++ {
+		tp.transaction.PostAssembly = &components.TransactionPostAssembly{}
+		tp.pendingEndorsementRequests = map[string]map[string]*endorsementRequest{
+			"foo": {"bob@node2": {idempotencyKey: "key"}},
+		}
+		tp.applyTransactionEndorsedEvent(ctx, &ptmgrtypes.TransactionEndorsedEvent{
+			Party:                  "bob@node2",
+			FromNode:               "node2",
+			AttestationRequestName: "foo",
+			IdempotencyKey:         "key",
+			RevertReason:           confutil.P("rejected"),
+		})
+	}
+	assert.Equal(t, 20, tp.reassemblyCount)
+	assert.False(t, tp.finalizeRequired)
+}
+
+func newAssembleRetryTestFlow(t *testing.T, ctx context.Context) (*transactionFlow, *transactionFlowDepencyMocks, *fakeClock) {
+	newTxID := uuid.New()
+	testTx := &components.PrivateTransaction{
+		ID:     newTxID,
+		Domain: "domain1",
+		PreAssembly: &components.TransactionPreAssembly{
+			TransactionSpecification: &prototk.TransactionSpecification{
+				TransactionId: newTxID.String(),
+				From:          "alice@node1",
+			},
+		},
+	}
+	tp, mocks := newTransactionFlowForTesting(t, ctx, testTx, "node1")
+	fakeClock := &fakeClock{timePassed: 0}
+	tp.clock = fakeClock
+	// a long backoff, so the nudge timer does not fire during the test
+	tp.assembleRetry = retry.NewRetryLimited(&pldconf.RetryConfigWithMax{
+		RetryConfig: pldconf.RetryConfig{
+			InitialDelay: confutil.P("1h"),
+		},
+	}, &pldconf.PrivateTxManagerDefaults.Sequencer.AssembleRetry)
+	return tp, mocks, fakeClock
+}
+
+func failAssemble(ctx context.Context, tp *transactionFlow, errMsg string) {
+	tp.ApplyEvent(ctx, &ptmgrtypes.TransactionAssembleFailedEvent{
+		PrivateTransactionEventBase: ptmgrtypes.PrivateTransactionEventBase{
+			TransactionID: tp.transaction.ID.String(),
+		},
+		AssembleRequestID: "request1",
+		Error:             errMsg,
+	})
+	// the assemble coordinator is not running to consume the completion
+	<-tp.assembleCoordinator.(*assembleCoordinator).commit
+	if tp.assembleRetryTimer != nil {
+		tp.assembleRetryTimer.Stop()
+	}
+}
+
+func TestAssembleFailureRetryThenSuccess(t *testing.T) {
+	ctx := context.Background()
+	tp, _, fakeClock := newAssembleRetryTestFlow(t, ctx)
+	tp.maxAssembleAttempts = 3
+
+	failAssemble(ctx, tp, "state not found")
+	assert.Equal(t, 1, tp.assembleFailureCount)
+	assert.False(t, tp.finalizeRequired)
+	assert.NotNil(t, tp.assembleRetryTimer)
+
+	// we do not try again until the backoff has passed
+	tp.requestAssemble(ctx)
+	assert.False(t, tp.assemblePending)
+
+	fakeClock.timePassed = 2 * time.Hour
+	tp.requestAssemble(ctx)
+	assert.True(t, tp.assemblePending)
+
+	tp.ApplyEvent(ctx, &ptmgrtypes.TransactionAssembledEvent{
+		PrivateTransactionEventBase: ptmgrtypes.PrivateTransactionEventBase{
+			TransactionID: tp.transaction.ID.String(),
+		},
+		PostAssembly: &components.TransactionPostAssembly{
+			AssemblyResult: prototk.AssembleTransactionResponse_OK,
+		},
+		AssembleRequestID: "request2",
+	})
+	assert.Equal(t, "assembled", tp.status)
+	assert.Equal(t, 0, tp.assembleFailureCount)
+	assert.False(t, tp.finalizeRequired)
+}
+
+func TestAssembleFailureRetriesExhausted(t *testing.T) {
+	ctx := context.Background()
+	tp, mocks, fakeClock := newAssembleRetryTestFlow(t, ctx)
+	tp.maxAssembleAttempts = 2
+
+	var finalizeReason string
+	mocks.syncPoints.On("QueueTransactionFinalize", ctx, "domain1", mock.Anything, tp.transaction.ID, mock.Anything, mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) {
+			finalizeReason = args.Get(4).(string)
+		}).Return().Once()
+
+	failAssemble(ctx, tp, "state not found")
+	assert.False(t, tp.finalizeRequired)
+
+	fakeClock.timePassed = 2 * time.Hour
+	tp.requestAssemble(ctx)
+	assert.True(t, tp.assemblePending)
+
+	failAssemble(ctx, tp, "state still not found")
+	assert.Equal(t, 2, tp.assembleFailureCount)
+	assert.True(t, tp.finalizeRequired)
+	assert.True(t, tp.finalizePending)
+	assert.Regexp(t, "PD011845.*2.*state still not found", finalizeReason)
+}
+
+func TestAssembleRetryCancelledOnStop(t *testing.T) {
+	ctx := context.Background()
+	tp, _, _ := newAssembleRetryTestFlow(t, ctx)
+
+	failAssemble(ctx, tp, "state not found")
+	assert.NotNil(t, tp.assembleRetryTimer)
+
+	tp.Stop(ctx)
+	assert.Nil(t, tp.assembleRetryTimer)
+	tp.Stop(ctx)
+}
+
+func TestGetTxStatusEndorsementProgress(t *testing.T) {
+	ctx := context.Background()
+	newTxID := uuid.New()
+
+	aliceIdentityLocator := "alice@node1"
+	bobIdentityLocator := "bob@node2"
+	carolIdentityLocator := "carol@node3"
+
+	testTx := &components.PrivateTransaction{
+		ID: newTxID,
+		PreAssembly: &components.TransactionPreAssembly{
+			TransactionSpecification: &prototk.TransactionSpecification{
+				TransactionId: newTxID.String(),
+			},
+		},
+		PostAssembly: &components.TransactionPostAssembly{
+			AttestationPlan: []*prototk.AttestationRequest{
+				{
+					Name:            "foo",
+					AttestationType: prototk.AttestationType_ENDORSE,
+					VerifierType:    verifiers.ETH_ADDRESS,
+					Parties:         []string{aliceIdentityLocator, bobIdentityLocator},
+				},
+				{
+					Name:            "bar",
+					AttestationType: prototk.AttestationType_ENDORSE,
+					VerifierType:    verifiers.ETH_ADDRESS,
+					Parties:         []string{bobIdentityLocator, carolIdentityLocator},
+				},
+				{
+					Name:            "sign",
+					AttestationType: prototk.AttestationType_SIGN,
+					Parties:         []string{aliceIdentityLocator},
+				},
+			},
+		},
+	}
+
+	tp, _ := newTransactionFlowForTesting(t, ctx, testTx, "node1")
+	tp.pendingEndorsementRequests = map[string]map[string]*endorsementRequest{
+		"foo": {
+			aliceIdentityLocator: {idempotencyKey: "foo-alice"},
+			bobIdentityLocator:   {idempotencyKey: "foo-bob"},
+		},
+		"bar": {
+			bobIdentityLocator:   {idempotencyKey: "bar-bob"},
+			carolIdentityLocator: {idempotencyKey: "bar-carol"},
+		},
+	}
+
+	endorse := func(attRequestName, party, idempotencyKey string) {
+		partyNode, err := tktypes.PrivateIdentityLocator(party).Node(ctx, false)
+		require.NoError(t, err)
+		tp.applyTransactionEndorsedEvent(ctx, &ptmgrtypes.TransactionEndorsedEvent{
+			IdempotencyKey:         idempotencyKey,
+			Party:                  party,
+			FromNode:               partyNode,
+			AttestationRequestName: attRequestName,
+			Endorsement: &prototk.AttestationResult{
+				Name: attRequestName,
+				Verifier: &prototk.ResolvedVerifier{
+					Lookup:       party,
+					Algorithm:    algorithms.ECDSA_SECP256K1,
+					Verifier:     tktypes.RandAddress().String(),
+					VerifierType: verifiers.ETH_ADDRESS,
+				},
+			},
+		})
+	}
+	progress := func() *components.PrivateTxEndorsementProgress {
+		status, err := tp.GetTxStatus(ctx)
+		require.NoError(t, err)
+		require.NotNil(t, status.EndorsementProgress)
+		return status.EndorsementProgress
+	}
+
+	assert.Equal(t, &components.PrivateTxEndorsementProgress{
+		Gathered:    0,
+		Required:    4,
+		Outstanding: []string{aliceIdentityLocator, bobIdentityLocator, carolIdentityLocator},
+	}, progress())
+
+	endorse("foo", bobIdentityLocator, "foo-bob")
+	assert.Equal(t, &components.PrivateTxEndorsementProgress{
+		Gathered:    1,
+		Required:    4,
+		Outstanding: []string{aliceIdentityLocator, bobIdentityLocator, carolIdentityLocator},
+	}, progress())
+
+	endorse("foo", aliceIdentityLocator, "foo-alice")
+	endorse("bar", bobIdentityLocator, "bar-bob")
+	assert.Equal(t, &components.PrivateTxEndorsementProgress{
+		Gathered:    3,
+		Required:    4,
+		Outstanding: []string{carolIdentityLocator},
+	}, progress())
+
+	endorse("bar", carolIdentityLocator, "bar-carol")
+	assert.Equal(t, &components.PrivateTxEndorsementProgress{
+		Gathered:    4,
+		Required:    4,
+		Outstanding: []string{},
+	}, progress())
+}
+
+func newQuorumTestTransaction(parties ...string) *components.PrivateTransaction {
+	newTxID := uuid.New()
+	return &components.PrivateTransaction{
+		ID:     newTxID,
+		Domain: "domain1",
+		PreAssembly: &components.TransactionPreAssembly{
+			TransactionSpecification: &prototk.TransactionSpecification{
+				TransactionId: newTxID.String(),
+			},
+		},
+		PostAssembly: &components.TransactionPostAssembly{
+			AttestationPlan: []*prototk.AttestationRequest{
+				{
+					Name:            "notary",
+					AttestationType: prototk.AttestationType_ENDORSE,
+					VerifierType:    verifiers.ETH_ADDRESS,
+					Parties:         parties,
+				},
+			},
+		},
+	}
+}
+
+func TestEndorsementQuorum(t *testing.T) {
+	ctx := context.Background()
+	aliceIdentityLocator := "alice@node1"
+	bobIdentityLocator := "bob@node2"
+	carolIdentityLocator := "carol@node3"
+
+	tp, _ := newTransactionFlowForTesting(t, ctx, newQuorumTestTransaction(aliceIdentityLocator, bobIdentityLocator, carolIdentityLocator), "node1")
+	tp.endorsementQuorum = 2
+	require.NoError(t, tp.checkEndorsementQuorum(ctx))
+
+	// an endorsement has been requested from every party
+	tp.pendingEndorsementRequests = map[string]map[string]*endorsementRequest{"notary": {}}
+	for _, party := range []string{aliceIdentityLocator, bobIdentityLocator, carolIdentityLocator} {
+		tp.pendingEndorsementRequests["notary"][party] = &endorsementRequest{idempotencyKey: "notary-" + party}
+	}
+
+	endorse := func(party string) {
+		partyNode, err := tktypes.PrivateIdentityLocator(party).Node(ctx, false)
+		require.NoError(t, err)
+		tp.applyTransactionEndorsedEvent(ctx, &ptmgrtypes.TransactionEndorsedEvent{
+			PrivateTransactionEventBase: ptmgrtypes.PrivateTransactionEventBase{
+				TransactionID: tp.transaction.ID.String(),
+			},
+			Party:                  party,
+			FromNode:               partyNode,
+			IdempotencyKey:         "notary-" + party,
+			AttestationRequestName: "notary",
+			Endorsement: &prototk.AttestationResult{
+				Name: "notary",
+				Verifier: &prototk.ResolvedVerifier{
+					Lookup:       party,
+					Algorithm:    algorithms.ECDSA_SECP256K1,
+					Verifier:     tktypes.RandAddress().String(),
+					VerifierType: verifiers.ETH_ADDRESS,
+				},
+			},
+		})
+	}
+
+	// quorum not met - we wait on (and request from) everyone we have not heard from
+	endorse(bobIdentityLocator)
+	assert.False(t, tp.IsEndorsed(ctx))
+	assert.Equal(t, &components.PrivateTxEndorsementProgress{
+		Gathered:    1,
+		Required:    2,
+		Outstanding: []string{aliceIdentityLocator, carolIdentityLocator},
+	}, tp.endorsementProgress(ctx))
+
+	// quorum met - the remaining party is no longer required
+	endorse(carolIdentityLocator)
+	assert.True(t, tp.IsEndorsed(ctx))
+	assert.Empty(t, tp.outstandingEndorsementRequests(ctx))
+	assert.Equal(t, &components.PrivateTxEndorsementProgress{
+		Gathered:    2,
+		Required:    2,
+		Outstanding: []string{},
+	}, tp.endorsementProgress(ctx))
+
+	// without a quorum every party is required
+	tp.endorsementQuorum = 0
+	assert.False(t, tp.IsEndorsed(ctx))
+	assert.Equal(t, []string{aliceIdentityLocator}, tp.endorsementProgress(ctx).Outstanding)
+}
+
+func TestEndorsementQuorumExceedsParties(t *testing.T) {
+	ctx := context.Background()
+
+	tp, _ := newTransactionFlowForTesting(t, ctx, newQuorumTestTransaction("alice@node1", "bob@node2"), "node1")
+	tp.endorsementQuorum = 3
+	err := tp.checkEndorsementQuorum(ctx)
+	assert.Regexp(t, "PD011843.*3.*domain1.*2.*notary", err)
+
+	// the parties we do have are all still required
+	assert.Equal(t, 2, tp.endorsementsRequired(tp.transaction.PostAssembly.AttestationPlan[0]))
+}
+
+func newDelegationTestFlow(t *testing.T, ctx context.Context) (*transactionFlow, *transactionFlowDepencyMocks, *fakeClock) {
+	newTxID := uuid.New()
+	testTx := &components.PrivateTransaction{
+		ID:     newTxID,
+		Domain: "domain1",
+		PreAssembly: &components.TransactionPreAssembly{
+			TransactionSpecification: &prototk.TransactionSpecification{
+				TransactionId: newTxID.String(),
+				From:          "alice",
+			},
+		},
+	}
+	tp, mocks := newTransactionFlowForTesting(t, ctx, testTx, "node1")
+	clock := &fakeClock{}
+	tp.clock = clock
+	tp.delegationTimeout = 5 * time.Minute
+	mocks.transportWriter.On("SendDelegationRequest", mock.Anything, mock.Anything, mock.Anything, testTx, int64(0)).Return(nil)
+	return tp, mocks, clock
+}
+
+func TestDelegationNeverAcceptedReclaimedLocally(t *testing.T) {
+	ctx := context.Background()
+	tp, mocks, clock := newDelegationTestFlow(t, ctx)
+	mocks.coordinatorSelector.On("SelectCoordinatorNode", mock.Anything, mock.Anything, mock.Anything).Return(int64(0), "node2", nil)
+
+	assert.False(t, tp.delegateIfRequired(ctx))
+	assert.True(t, tp.delegatePending)
+	assert.Equal(t, "node2", tp.delegateNode)
+	delegationStartTime := tp.delegationStartTime
+
+	// the request times out and is sent again, but we keep waiting for the delegate
+	clock.timePassed = 2 * time.Minute
+	assert.False(t, tp.delegateIfRequired(ctx))
+	assert.True(t, tp.delegatePending)
+	assert.Equal(t, delegationStartTime, tp.delegationStartTime)
+	mocks.transportWriter.AssertNumberOfCalls(t, "SendDelegationRequest", 2)
+
+	// once the delegation timeout passes we reclaim the transaction, and coordinate it ourselves
+	mocks.publisher.On("PublishTransactionDelegationReclaimedEvent", mock.Anything, tp.transaction.ID.String(), "node2").Return().Once()
+	clock.timePassed = 6 * time.Minute
+	assert.True(t, tp.delegateIfRequired(ctx))
+	assert.False(t, tp.delegatePending)
+	assert.True(t, tp.localCoordinator)
+	assert.Equal(t, "node2", tp.delegationReclaimedFrom)
+	assert.Regexp(t, "PD011844.*node2", tp.latestError)
+	mocks.transportWriter.AssertNumberOfCalls(t, "SendDelegationRequest", 2)
+
+	// a late acceptance by the delegate cannot get the transaction assembled, so it cannot be submitted twice
+	assert.False(t, tp.delegationFence.assemble(tp.transaction.ID.String(), "node2"))
+}
+
+func TestDelegationNotReclaimedOnceAssembleRequested(t *testing.T) {
+	ctx := context.Background()
+	tp, mocks, clock := newDelegationTestFlow(t, ctx)
+	mocks.coordinatorSelector.On("SelectCoordinatorNode", mock.Anything, mock.Anything, mock.Anything).Return(int64(0), "node2", nil)
+
+	assert.False(t, tp.delegateIfRequired(ctx))
+	assert.True(t, tp.delegatePending)
+
+	// the delegate asks us to assemble the transaction, but its acknowledgment of the delegation is lost
+	assert.True(t, tp.delegationFence.assemble(tp.transaction.ID.String(), "node2"))
+
+	// so it has accepted the transaction, and we leave it with the delegate rather than reclaiming it
+	clock.timePassed = 6 * time.Minute
+	assert.False(t, tp.delegateIfRequired(ctx))
+	assert.False(t, tp.delegatePending)
+	assert.True(t, tp.delegated)
+	assert.Equal(t, "delegated", tp.status)
+	assert.Empty(t, tp.delegationReclaimedFrom)
+	mocks.publisher.AssertNotCalled(t, "PublishTransactionDelegationReclaimedEvent", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestDelegationNeverAcceptedReDelegated(t *testing.T) {
+	ctx := context.Background()
+	tp, mocks, clock := newDelegationTestFlow(t, ctx)
+	mocks.coordinatorSelector.On("SelectCoordinatorNode", mock.Anything, mock.Anything, mock.Anything).Return(int64(0), "node2", nil).Once()
+	mocks.coordinatorSelector.On("SelectCoordinatorNode", mock.Anything, mock.Anything, mock.Anything).Return(int64(0), "node3", nil).Once()
+
+	assert.False(t, tp.delegateIfRequired(ctx))
+	assert.Equal(t, "node2", tp.delegateNode)
+
+	// an alternate coordinator is selected after the reclaim, so we delegate to that instead
+	mocks.publisher.On("PublishTransactionDelegationReclaimedEvent", mock.Anything, tp.transaction.ID.String(), "node2").Return().Once()
+	clock.timePassed = 6 * time.Minute
+	assert.False(t, tp.delegateIfRequired(ctx))
+	assert.True(t, tp.delegatePending)
+	assert.Equal(t, "node3", tp.delegateNode)
+	assert.Equal(t, "node2", tp.delegationReclaimedFrom)
+	assert.Greater(t, time.Until(tp.delegationStartTime), 5*time.Minute)
+}
+
+func TestDelegationTimeoutDisabled(t *testing.T) {
+	ctx := context.Background()
+	tp, mocks, clock := newDelegationTestFlow(t, ctx)
+	tp.delegationTimeout = 0
+	mocks.coordinatorSelector.On("SelectCoordinatorNode", mock.Anything, mock.Anything, mock.Anything).Return(int64(0), "node2", nil)
+
+	assert.False(t, tp.delegateIfRequired(ctx))
+	clock.timePassed = 24 * time.Hour
+	assert.False(t, tp.delegateIfRequired(ctx))
+	assert.True(t, tp.delegatePending)
+	assert.Empty(t, tp.delegationReclaimedFrom)
+}
+
+func TestPrepareFailDiscardsEndorsements(t *testing.T) {
+	ctx := context.Background()
+	tp, mocks := newTransactionFlowForTesting(t, ctx, newQuorumTestTransaction("alice@node1"), "node1")
+	tp.transaction.PostAssembly.Endorsements = []*prototk.AttestationResult{
+		{
+			Name:     "notary",
+			Verifier: &prototk.ResolvedVerifier{Lookup: "alice@node1", VerifierType: verifiers.ETH_ADDRESS},
+		},
+	}
+	assert.True(t, tp.IsEndorsed(ctx))
+
+	mocks.domainSmartContract.On("PrepareTransaction", mocks.domainContext, mock.Anything, tp.transaction).
+		Return(fmt.Errorf("PD020304: Endorsement is no longer valid: endorsement expired"))
+	mocks.publisher.On("PublishNudgeEvent", mock.Anything, tp.transaction.ID.String()).Return().Once()
+
+	// the endorsements are gathered again before the transaction can be dispatched
+	_, err := tp.PrepareTransaction(ctx, "signer1")
+	assert.Regexp(t, "endorsement expired", err)
+	assert.Regexp(t, "PD011.*endorsement expired", tp.latestError)
+	assert.Empty(t, tp.transaction.PostAssembly.Endorsements)
+	assert.False(t, tp.IsEndorsed(ctx))
+}
+
+func TestPrepareFailKeepsEndorsements(t *testing.T) {
+	ctx := context.Background()
+	tp, mocks := newTransactionFlowForTesting(t, ctx, newQuorumTestTransaction("alice@node1"), "node1")
+	tp.transaction.PostAssembly.Endorsements = []*prototk.AttestationResult{
+		{
+			Name:     "notary",
+			Verifier: &prototk.ResolvedVerifier{Lookup: "alice@node1", VerifierType: verifiers.ETH_ADDRESS},
+		},
+	}
+
+	mocks.domainSmartContract.On("PrepareTransaction", mocks.domainContext, mock.Anything, tp.transaction).Return(fmt.Errorf("pop"))
+
+	// other failures do not invalidate the endorsements
+	_, err := tp.PrepareTransaction(ctx, "signer1")
+	assert.Regexp(t, "pop", err)
+	assert.Len(t, tp.transaction.PostAssembly.Endorsements, 1)
+	assert.True(t, tp.IsEndorsed(ctx))
+}
+
+func TestGetTxStatusBlockedReason(t *testing.T) {
+	ctx := context.Background()
+	newTxID := uuid.New()
+	bobIdentityLocator := "bob@node2"
+
+	testTx := &components.PrivateTransaction{
+		ID: newTxID,
+		PreAssembly: &components.TransactionPreAssembly{
+			TransactionSpecification: &prototk.TransactionSpecification{
+				TransactionId: newTxID.String(),
+			},
+			RequiredVerifiers: []*prototk.ResolveVerifierRequest{
+				{
+					Lookup:       bobIdentityLocator,
+					Algorithm:    algorithms.ECDSA_SECP256K1,
+					VerifierType: verifiers.ETH_ADDRESS,
+				},
+			},
+		},
+	}
+
+	tp, mocks := newTransactionFlowForTesting(t, ctx, testTx, "node1")
+	blockedReason := func() string {
+		tp.updateBlockedReason(ctx)
+		status, err := tp.GetTxStatus(ctx)
+		require.NoError(t, err)
+		// the reason is persisted as it changes, and removed when the transaction is no longer blocked
+		persistedReason, err := readBlockedReason(ctx, mocks.persistence, newTxID)
+		require.NoError(t, err)
+		assert.Equal(t, status.BlockedReason, persistedReason)
+		return status.BlockedReason
+	}
+
+	assert.Regexp(t, "PD011848.*bob@node2", blockedReason())
+
+	bobVerifier := tktypes.RandAddress().String()
+	testTx.PreAssembly.Verifiers = []*prototk.ResolvedVerifier{
+		{
+			Lookup:       bobIdentityLocator,
+			Algorithm:    algorithms.ECDSA_SECP256K1,
+			Verifier:     bobVerifier,
+			VerifierType: verifiers.ETH_ADDRESS,
+		},
+	}
+	assert.Regexp(t, "PD011849", blockedReason())
+
+	tp.delegatePending = true
+	tp.delegateNode = "node2"
+	assert.Regexp(t, "PD011847.*node2", blockedReason())
+	tp.delegatePending = false
+
+	testTx.PostAssembly = &components.TransactionPostAssembly{
+		AttestationPlan: []*prototk.AttestationRequest{
+			{
+				Name:            "sign1",
+				AttestationType: prototk.AttestationType_SIGN,
+				Parties:         []string{"alice@node1"},
+			},
+			{
+				Name:            "notary",
+				AttestationType: prototk.AttestationType_ENDORSE,
+				VerifierType:    verifiers.ETH_ADDRESS,
+				Parties:         []string{bobIdentityLocator},
+			},
+		},
+	}
+	assert.Regexp(t, "PD011850.*sign1", blockedReason())
+
+	testTx.PostAssembly.Signatures = []*prototk.AttestationResult{
+		{
+			Name:            "sign1",
+			AttestationType: prototk.AttestationType_SIGN,
+			Verifier:        &prototk.ResolvedVerifier{Lookup: "alice@node1"},
+		},
+	}
+	assert.Regexp(t, "PD011851.*bob@node2", blockedReason())
+
+	tp.pendingEndorsementRequests = map[string]map[string]*endorsementRequest{
+		"notary": {bobIdentityLocator: {idempotencyKey: "notary-bob"}},
+	}
+	tp.applyTransactionEndorsedEvent(ctx, &ptmgrtypes.TransactionEndorsedEvent{
+		IdempotencyKey:         "notary-bob",
+		Party:                  bobIdentityLocator,
+		FromNode:               "node2",
+		AttestationRequestName: "notary",
+		Endorsement: &prototk.AttestationResult{
+			Name: "notary",
+			Verifier: &prototk.ResolvedVerifier{
+				Lookup:       bobIdentityLocator,
+				Algorithm:    algorithms.ECDSA_SECP256K1,
+				Verifier:     bobVerifier,
+				VerifierType: verifiers.ETH_ADDRESS,
+			},
+		},
+	})
+	assert.Empty(t, blockedReason())
+
+	// The sequencer explains why an endorsed transaction is not dispatched
+	tp.SetDispatchBlockedReason(ctx, "waiting on the sequencer")
+	assert.Equal(t, "waiting on the sequencer", blockedReason())
+
+	tp.dispatched = true
+	assert.Empty(t, blockedReason())
+}
+
+func TestBlockedReasonPersistFail(t *testing.T) {
+	ctx := context.Background()
+	tp, _ := newTransactionFlowForTesting(t, ctx, &components.PrivateTransaction{
+		ID:          uuid.New(),
+		PreAssembly: &components.TransactionPreAssembly{},
+	}, "node1")
+
+	db, err := mockpersistence.NewSQLMockProvider()
+	require.NoError(t, err)
+	allComponents := componentmocks.NewAllComponents(t)
+	allComponents.On("Persistence").Return(db.P)
+	tp.components = allComponents
+	db.Mock.ExpectExec("INSERT.*private_tx_blocked_reasons").WillReturnError(fmt.Errorf("pop"))
+
+	// the reason is still reported from memory
+	tp.updateBlockedReason(ctx)
+	status, err := tp.GetTxStatus(ctx)
+	require.NoError(t, err)
+	assert.Regexp(t, "PD011849", status.BlockedReason)
+	require.NoError(t, db.Mock.ExpectationsWereMet())
+}
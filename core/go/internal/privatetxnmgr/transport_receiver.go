@@ -34,7 +34,7 @@ func (p *privateTxManager) HandlePaladinMsg(ctx context.Context, message *compon
 	case "EndorsementRequest":
 		go p.handleEndorsementRequest(p.ctx, messagePayload, fromNode)
 	case "EndorsementResponse":
-		go p.handleEndorsementResponse(p.ctx, messagePayload)
+		go p.handleEndorsementResponse(p.ctx, messagePayload, fromNode)
 	case "DelegationRequest":
 		go p.handleDelegationRequest(p.ctx, messagePayload, fromNode)
 	case "DelegationRequestAcknowledgment":
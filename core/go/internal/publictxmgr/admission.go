@@ -0,0 +1,69 @@
+/*
+ * Copyright © 2024 Kaleido, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with
+ * the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package publictxmgr
+
+import (
+	"context"
+
+	"github.com/kaleido-io/paladin/core/internal/msgs"
+	"github.com/kaleido-io/paladin/toolkit/pkg/i18n"
+	"github.com/kaleido-io/paladin/toolkit/pkg/log"
+)
+
+// Rejects new submissions while the backlog of pending transactions is at or above the configured maximum,
+// so we shed load at the edge rather than deepening a backlog the engine cannot keep up with.
+// The error is a retryable 503, and submissions are accepted again once the backlog drains.
+func (ble *pubTxManager) checkAdmission(ctx context.Context) error {
+	if ble.maxPendingBacklog <= 0 {
+		return nil
+	}
+	backlog := ble.pendingBacklog.Load()
+	if backlog >= int64(ble.maxPendingBacklog) {
+		log.L(ctx).Warnf("Rejecting submission with %d transactions pending (max=%d)", backlog, ble.maxPendingBacklog)
+		return i18n.NewError(ctx, msgs.MsgPublicTxEngineOverloaded, backlog, ble.maxPendingBacklog)
+	}
+	return nil
+}
+
+// Counts admitted transactions into the backlog as soon as they are committed, rather than waiting
+// for the next engine poll, so a burst of submissions cannot overshoot the maximum
+func (ble *pubTxManager) postCommitAdmitted(count int) func(ctx context.Context) {
+	return func(ctx context.Context) {
+		if ble.maxPendingBacklog > 0 {
+			ble.thMetrics.RecordPendingBacklog(ctx, ble.pendingBacklog.Add(int64(count)))
+		}
+	}
+}
+
+// Called on each engine poll to re-count the pending transactions, which is how completed transactions
+// drain from the backlog. On failure the previous count is kept until the next poll.
+func (ble *pubTxManager) refreshPendingBacklog(ctx context.Context) {
+	if ble.maxPendingBacklog <= 0 {
+		return
+	}
+	var backlog int64
+	err := ble.p.DB().WithContext(ctx).Raw(`SELECT COUNT(*) FROM "public_txns" AS t ` +
+		`LEFT JOIN "public_completions" AS c ON t."pub_txn_id" = c."pub_txn_id" ` +
+		`WHERE c."pub_txn_id" IS NULL AND "suspended" IS FALSE`).
+		Scan(&backlog).
+		Error
+	if err != nil {
+		log.L(ctx).Errorf("Failed to count pending transactions for admission control: %s", err)
+		return
+	}
+	ble.pendingBacklog.Store(backlog)
+	ble.thMetrics.RecordPendingBacklog(ctx, backlog)
+}
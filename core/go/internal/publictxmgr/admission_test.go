@@ -0,0 +1,139 @@
+/*
+ * Copyright © 2024 Kaleido, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with
+ * the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package publictxmgr
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/kaleido-io/paladin/config/pkg/confutil"
+	"github.com/kaleido-io/paladin/config/pkg/pldconf"
+	"github.com/kaleido-io/paladin/core/internal/components"
+	"github.com/kaleido-io/paladin/core/pkg/persistence"
+	"github.com/kaleido-io/paladin/toolkit/pkg/i18n"
+	"github.com/kaleido-io/paladin/toolkit/pkg/pldapi"
+	"github.com/kaleido-io/paladin/toolkit/pkg/tktypes"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testAdmissionSubmission() []*components.PublicTxSubmission {
+	return []*components.PublicTxSubmission{{
+		PublicTxInput: pldapi.PublicTxInput{
+			From: tktypes.RandAddress(),
+			PublicTxOptions: pldapi.PublicTxOptions{
+				Gas: confutil.P(tktypes.HexUint64(100000)),
+			},
+		},
+	}}
+}
+
+func gatherPendingBacklog(t *testing.T, registry prometheus.Gatherer) float64 {
+	families, err := registry.Gather()
+	require.NoError(t, err)
+	for _, mf := range families {
+		if mf.GetName() == metricsPendingBacklog {
+			return mf.GetMetric()[0].GetGauge().GetValue()
+		}
+	}
+	return -1
+}
+
+func TestAdmissionControlRejectsAboveMaxBacklogUntilDrained(t *testing.T) {
+	ctx, ble, m, done := newTestPublicTxManager(t, true, func(mocks *mocksAndTestControl, conf *pldconf.PublicTxManagerConfig) {
+		mocks.disableManagerStart = true
+		conf.Manager.MaxPendingBacklog = confutil.P(2)
+	})
+	defer done()
+
+	submit := func() ([]*pldapi.PublicTx, error) {
+		var ptxs []*pldapi.PublicTx
+		err := ble.p.Transaction(ctx, func(ctx context.Context, dbTX persistence.DBTX) (err error) {
+			ptxs, err = ble.WriteNewTransactions(ctx, dbTX, testAdmissionSubmission())
+			return err
+		})
+		return ptxs, err
+	}
+
+	// accepted up to the max, with the backlog counted as each is committed
+	first, err := submit()
+	require.NoError(t, err)
+	_, err = submit()
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), ble.pendingBacklog.Load())
+	assert.Equal(t, float64(2), gatherPendingBacklog(t, m.metricsManager.Registry()))
+
+	// rejected at the max, with a retryable status
+	_, err = submit()
+	require.Regexp(t, "PD011952", err)
+	assert.Equal(t, http.StatusServiceUnavailable, err.(i18n.PDError).HTTPStatus())
+	err = ble.p.Transaction(ctx, func(ctx context.Context, dbTX persistence.DBTX) (err error) {
+		_, err = ble.WriteNewTransactionGroup(ctx, dbTX, testAdmissionSubmission())
+		return err
+	})
+	require.Regexp(t, "PD011952", err)
+	_, err = ble.WriteNewRawTransaction(ctx, ble.p.NOTX(), &components.PublicRawTxSubmission{})
+	require.Regexp(t, "PD011952", err)
+
+	// still rejected after a re-count, as nothing has completed
+	ble.refreshPendingBacklog(ctx)
+	assert.Equal(t, int64(2), ble.pendingBacklog.Load())
+	_, err = submit()
+	require.Regexp(t, "PD011952", err)
+
+	// once one completes, the backlog drains on the next poll and we accept again
+	err = ble.p.DB().WithContext(ctx).Create(&DBPublicTxnCompletion{
+		PublicTxnID:     *first[0].LocalID,
+		TransactionHash: tktypes.RandBytes32(),
+		Success:         true,
+	}).Error
+	require.NoError(t, err)
+	ble.refreshPendingBacklog(ctx)
+	assert.Equal(t, int64(1), ble.pendingBacklog.Load())
+	assert.Equal(t, float64(1), gatherPendingBacklog(t, m.metricsManager.Registry()))
+	_, err = submit()
+	require.NoError(t, err)
+}
+
+func TestAdmissionControlDisabled(t *testing.T) {
+	ctx, ble, _, done := newTestPublicTxManager(t, false, func(mocks *mocksAndTestControl, conf *pldconf.PublicTxManagerConfig) {
+		mocks.disableManagerStart = true
+	})
+	defer done()
+
+	// no query is made to count the backlog, and everything is admitted
+	ble.pendingBacklog.Store(1000000)
+	ble.refreshPendingBacklog(ctx)
+	require.NoError(t, ble.checkAdmission(ctx))
+}
+
+func TestAdmissionControlRefreshFail(t *testing.T) {
+	ctx, ble, m, done := newTestPublicTxManager(t, false, func(mocks *mocksAndTestControl, conf *pldconf.PublicTxManagerConfig) {
+		mocks.disableManagerStart = true
+		conf.Manager.MaxPendingBacklog = confutil.P(10)
+	})
+	defer done()
+
+	// the previous count is kept on failure
+	ble.pendingBacklog.Store(10)
+	m.db.ExpectQuery("SELECT COUNT.*public_txns").WillReturnError(fmt.Errorf("pop"))
+	ble.refreshPendingBacklog(ctx)
+	assert.Equal(t, int64(10), ble.pendingBacklog.Load())
+	require.Regexp(t, "PD011952", ble.checkAdmission(ctx))
+}
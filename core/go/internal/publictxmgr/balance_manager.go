@@ -19,6 +19,7 @@ import (
 	"context"
 	"math/big"
 	"sync"
+	"time"
 
 	"github.com/kaleido-io/paladin/config/pkg/confutil"
 	"github.com/kaleido-io/paladin/config/pkg/pldconf"
@@ -44,12 +45,13 @@ type BalanceManagerWithInMemoryTracking struct {
 
 	// balance cache is used to store cached balances of any address
 	balanceCache cache.Cache[tktypes.EthAddress, *big.Int]
+	// cached balances older than this are re-fetched, even without a notification of a change
+	balanceCacheTTL time.Duration
 
-	// the unresolved signer to use when submitting transactions
-	source string
-
-	// if set to a valid ethereum address, autofueling is turned on
-	sourceAddress *tktypes.EthAddress
+	// the funding sources for fueling transactions, which are selected using a smooth weighted round-robin.
+	// if any source has a non-zero weight, autofueling is turned on
+	sources    []*fuelingSource
+	sourcesMux sync.Mutex
 
 	// reject autofueling when the source address below this balance
 	minSourceBalance *big.Int
@@ -83,11 +85,22 @@ type BalanceManagerWithInMemoryTracking struct {
 	// a map of signing addresses and a boolean to indicate whether balance manager should fetch
 	// the balance of the signing address from the chain
 	addressBalanceChangedMap    map[tktypes.EthAddress]bool
+	addressBalanceFetchTimes    map[tktypes.EthAddress]time.Time // protected by addressBalanceChangedMapMux
 	addressBalanceChangedMapMux sync.Mutex
 }
 
+type fuelingSource struct {
+	// the unresolved signer to use when submitting transactions
+	source  string
+	address tktypes.EthAddress
+	// a weight of 0 excludes the source from selection, but we still look for its in-flight fueling transactions
+	weight int
+	// the running score of the weighted round-robin
+	current int
+}
+
 func (af *BalanceManagerWithInMemoryTracking) TopUpAccount(ctx context.Context, addAccount *AddressAccount) (mtx *pldapi.PublicTx, err error) {
-	if af.sourceAddress == nil {
+	if !af.IsAutoFuelingEnabled(ctx) {
 		log.L(ctx).Debugf("Skip top up transaction as no fueling source configured")
 		// No-op
 		return nil, nil
@@ -166,7 +179,44 @@ func (af *BalanceManagerWithInMemoryTracking) NotifyAddressBalanceChanged(ctx co
 }
 
 func (af *BalanceManagerWithInMemoryTracking) IsAutoFuelingEnabled(ctx context.Context) bool {
-	return af.sourceAddress != nil
+	for _, s := range af.sources {
+		if s.weight > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func (af *BalanceManagerWithInMemoryTracking) sourceAddresses() []tktypes.EthAddress {
+	addresses := make([]tktypes.EthAddress, len(af.sources))
+	for i, s := range af.sources {
+		addresses[i] = s.address
+	}
+	return addresses
+}
+
+// selectSource picks the funding source for the next fueling transaction, using a smooth weighted round-robin
+// so that over time each source is selected in proportion to its weight, with the selections interleaved
+func (af *BalanceManagerWithInMemoryTracking) selectSource() *fuelingSource {
+	af.sourcesMux.Lock()
+	defer af.sourcesMux.Unlock()
+
+	var selected *fuelingSource
+	totalWeight := 0
+	for _, s := range af.sources {
+		if s.weight <= 0 {
+			continue
+		}
+		totalWeight += s.weight
+		s.current += s.weight
+		if selected == nil || s.current > selected.current {
+			selected = s
+		}
+	}
+	if selected != nil {
+		selected.current -= totalWeight
+	}
+	return selected
 }
 
 func (af *BalanceManagerWithInMemoryTracking) GetAddressBalance(ctx context.Context, address tktypes.EthAddress) (*AddressAccount, error) {
@@ -177,7 +227,9 @@ func (af *BalanceManagerWithInMemoryTracking) GetAddressBalance(ctx context.Cont
 	cachedAddressBalance, _ := af.balanceCache.Get(address)
 	var addressBalance big.Int
 	balanceChangedOnChain := af.addressBalanceChangedMap[address]
-	if balanceChangedOnChain || cachedAddressBalance == nil {
+	fetchTime, fetched := af.addressBalanceFetchTimes[address]
+	balanceExpired := !fetched || af.pubTxMgr.clock.Since(fetchTime) > af.balanceCacheTTL
+	if balanceChangedOnChain || balanceExpired || cachedAddressBalance == nil {
 		log.L(ctx).Debugf("Retrieving balance for address %s from connector", address)
 		// fetch the latest balance from the chain
 		addressBalancePtr, err := af.pubTxMgr.ethClient.GetBalance(ctx, address, "latest")
@@ -187,6 +239,7 @@ func (af *BalanceManagerWithInMemoryTracking) GetAddressBalance(ctx context.Cont
 		}
 		addressBalance = *addressBalancePtr.Int()
 		af.balanceCache.Set(address, addressBalancePtr.Int())
+		af.addressBalanceFetchTimes[address] = af.pubTxMgr.clock.Now()
 		// set the flag to false so that the following requests of this address
 		// uses cache if there is no new balance change
 		af.addressBalanceChangedMap[address] = false
@@ -208,7 +261,7 @@ func (af *BalanceManagerWithInMemoryTracking) GetAddressBalance(ctx context.Cont
 func (af *BalanceManagerWithInMemoryTracking) TransferGasFromAutoFuelingSource(ctx context.Context, destAddress tktypes.EthAddress, value *big.Int) (fuelingTx *pldapi.PublicTx, err error) {
 	// check whether there is a pending fueling transaction already
 	// check whether the current balance manager already tracking the existing in-flight fueling transactions
-	log.L(ctx).Tracef("TransferGasFromAutoFuelingSource entry, source address: %s, destination address: %s, amount: %s", af.sourceAddress, destAddress, value.String())
+	log.L(ctx).Tracef("TransferGasFromAutoFuelingSource entry, destination address: %s, amount: %s", destAddress, value.String())
 
 	af.destinationAddressesFuelingTrackedMux.Lock()
 	perAddressMux, ok := af.destinationAddressesFuelingTracked[destAddress]
@@ -228,7 +281,7 @@ func (af *BalanceManagerWithInMemoryTracking) TransferGasFromAutoFuelingSource(c
 		log.L(ctx).Debugf("TransferGasFromAutoFuelingSource no existing tracking fueling request for  destination address: %s", destAddress)
 		// there is no tracked fueling transaction for this address, do a lookup in the db in case we've restarted or couldn't record the last one submitted
 		// in the middle of tracking
-		fuelingTx, err = af.pubTxMgr.GetPendingFuelingTransaction(ctx, *af.sourceAddress, destAddress)
+		fuelingTx, err = af.pubTxMgr.GetPendingFuelingTransaction(ctx, af.sourceAddresses(), destAddress)
 		if err != nil {
 			log.L(ctx).Errorf("TransferGasFromAutoFuelingSource error occurred when getting pending fueling tx for address: %s, error: %+v", destAddress, err)
 			// we don't risk the chance of having duplicate fueling transactions when we cannot fetching all the in-flight transactions
@@ -259,11 +312,16 @@ func (af *BalanceManagerWithInMemoryTracking) TransferGasFromAutoFuelingSource(c
 	delete(af.trackedFuelingTransactions, destAddress)
 	af.trackedFuelingTransactionsMux.Unlock()
 
-	// 1) Check balance of source address to ensure we have enough to transfer
-	sourceAccount, err := af.GetAddressBalance(ctx, *af.sourceAddress)
+	// 1) Pick the source, and check its balance to ensure we have enough to transfer
+	source := af.selectSource()
+	if source == nil {
+		log.L(ctx).Debugf("TransferGasFromAutoFuelingSource no fueling source with a non-zero weight for destination address: %s", destAddress)
+		return nil, nil
+	}
+	sourceAccount, err := af.GetAddressBalance(ctx, source.address)
 
 	if err != nil {
-		log.L(ctx).Errorf("TransferGasFromAutoFuelingSource failed to get balance of source: %s", af.sourceAddress)
+		log.L(ctx).Errorf("TransferGasFromAutoFuelingSource failed to get balance of source: %s", source.address)
 		return nil, err
 	}
 	log.L(ctx).Tracef("TransferGasFromAutoFuelingSource source balance: (%v)", sourceAccount.Balance.String())
@@ -284,10 +342,10 @@ func (af *BalanceManagerWithInMemoryTracking) TransferGasFromAutoFuelingSource(c
 
 	// 2) Perform transaction to transfer value to the dest address
 
-	log.L(ctx).Debugf("TransferGasFromAutoFuelingSource submitting a fueling tx for  destination address: %s ", destAddress)
-	fuelingTx, err = af.pubTxMgr.SingleTransactionSubmit(ctx, &components.PublicTxSubmission{
+	log.L(ctx).Debugf("TransferGasFromAutoFuelingSource submitting a fueling tx from source %s (%s) for destination address: %s ", source.source, source.address, destAddress)
+	fuelingTx, err = af.pubTxMgr.submitFuelingTransaction(ctx, &components.PublicTxSubmission{
 		PublicTxInput: pldapi.PublicTxInput{
-			From: af.sourceAddress,
+			From: &source.address,
 			To:   &destAddress,
 			PublicTxOptions: pldapi.PublicTxOptions{
 				Value: (*tktypes.HexUint256)(value),
@@ -327,25 +385,36 @@ func NewBalanceManagerWithInMemoryTracking(ctx context.Context, conf *pldconf.Pu
 			return nil, i18n.NewError(ctx, msgs.MsgMaxBelowMinThreshold, "maxDestBalance")
 		}
 	}
-	var autoFuelingSourceAddress *tktypes.EthAddress
-	autoFuelingSource := confutil.StringOrEmpty(conf.BalanceManager.AutoFueling.Source, "")
-	if autoFuelingSource != "" {
+	// The single source is equivalent to an entry in the sources list with the default weight
+	sourceConfs := conf.BalanceManager.AutoFueling.Sources
+	if autoFuelingSource := confutil.StringOrEmpty(conf.BalanceManager.AutoFueling.Source, ""); autoFuelingSource != "" {
+		sourceConfs = append([]*pldconf.AutoFuelingSourceConfig{{Source: &autoFuelingSource}}, sourceConfs...)
+	}
+	sources := make([]*fuelingSource, 0, len(sourceConfs))
+	for _, sourceConf := range sourceConfs {
+		autoFuelingSource := confutil.StringOrEmpty(sourceConf.Source, "")
 		// We must be able to resolve the supplied auto fueling source at startup, so we can check its balance
 		resolved, err := publicTxMgr.keymgr.ResolveKeyNewDatabaseTX(ctx, autoFuelingSource, algorithms.ECDSA_SECP256K1, verifiers.ETH_ADDRESS)
+		var autoFuelingSourceAddress *tktypes.EthAddress
 		if err == nil {
 			autoFuelingSourceAddress, err = tktypes.ParseEthAddress(resolved.Verifier.Verifier)
 		}
 		if err != nil {
 			return nil, i18n.WrapError(ctx, err, msgs.MsgInvalidAutoFuelSource, autoFuelingSource)
 		}
+		sources = append(sources, &fuelingSource{
+			source:  autoFuelingSource,
+			address: *autoFuelingSourceAddress,
+			weight:  confutil.IntMin(sourceConf.Weight, 0, *pldconf.AutoFuelingSourceDefaults.Weight),
+		})
 	}
 	calcMethod := confutil.StringNotEmpty(conf.BalanceManager.AutoFueling.ProactiveCostEstimationMethod, string(pldconf.ProactiveAutoFuelingCalcMethodMax))
 	log.L(ctx).Debugf("Balance manager calcMethod setting: %s", calcMethod)
 	bm := &BalanceManagerWithInMemoryTracking{
-		source:                             autoFuelingSource,
-		sourceAddress:                      autoFuelingSourceAddress,
+		sources:                            sources,
 		pubTxMgr:                           publicTxMgr,
 		balanceCache:                       cache.NewCache[tktypes.EthAddress, *big.Int](&conf.BalanceManager.Cache, &pldconf.PublicTxManagerDefaults.BalanceManager.Cache),
+		balanceCacheTTL:                    confutil.DurationMin(conf.BalanceManager.CacheTTL, 0, *pldconf.PublicTxManagerDefaults.BalanceManager.CacheTTL),
 		minSourceBalance:                   minSourceBalance,
 		proactiveFuelingTransactionTotal:   confutil.IntMin(conf.BalanceManager.AutoFueling.ProactiveFuelingTransactionTotal, 0, *pldconf.PublicTxManagerDefaults.BalanceManager.AutoFueling.ProactiveFuelingTransactionTotal),
 		proactiveFuelingCalcMethod:         pldconf.ProactiveAutoFuelingCalcMethod(calcMethod),
@@ -355,6 +424,7 @@ func NewBalanceManagerWithInMemoryTracking(ctx context.Context, conf *pldconf.Pu
 		destinationAddressesFuelingTracked: make(map[tktypes.EthAddress]*sync.Mutex),
 		trackedFuelingTransactions:         make(map[tktypes.EthAddress]*pldapi.PublicTx),
 		addressBalanceChangedMap:           make(map[tktypes.EthAddress]bool),
+		addressBalanceFetchTimes:           make(map[tktypes.EthAddress]time.Time),
 	}
 	return bm, nil
 }
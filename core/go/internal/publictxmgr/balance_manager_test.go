@@ -17,11 +17,13 @@ package publictxmgr
 
 import (
 	"context"
+	"database/sql/driver"
 	"errors"
 	"fmt"
 	"math/big"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/kaleido-io/paladin/config/pkg/confutil"
@@ -141,6 +143,37 @@ func TestGetAddressBalance(t *testing.T) {
 	assert.Error(t, err)
 }
 
+func TestGetAddressBalanceCacheExpiry(t *testing.T) {
+	ctx, bm, ble, m, done := newTestBalanceManager(t, false, func(m *mocksAndTestControl, conf *pldconf.PublicTxManagerConfig) {
+		conf.BalanceManager.CacheTTL = confutil.P("10s")
+	})
+	defer done()
+
+	fc := newFakeClock()
+	ble.clock = fc
+
+	exampleAddr := *tktypes.RandAddress()
+	m.ethClient.On("GetBalance", mock.Anything, exampleAddr, "latest").Return(tktypes.Uint64ToUint256(400), nil).Once()
+	m.ethClient.On("GetBalance", mock.Anything, exampleAddr, "latest").Return(tktypes.Uint64ToUint256(500), nil).Once()
+
+	addressAccount, err := bm.GetAddressBalance(ctx, exampleAddr)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(400), addressAccount.Balance.Uint64())
+
+	// within the TTL the cached balance is used
+	fc.Advance(5 * time.Second)
+	addressAccount, err = bm.GetAddressBalance(ctx, exampleAddr)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(400), addressAccount.Balance.Uint64())
+
+	// once expired it is fetched again, without any notification of a change
+	fc.Advance(6 * time.Second)
+	addressAccount, err = bm.GetAddressBalance(ctx, exampleAddr)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(500), addressAccount.Balance.Uint64())
+	m.ethClient.AssertNumberOfCalls(t, "GetBalance", 2)
+}
+
 func TestAddressAccountSpend(t *testing.T) {
 	ctx, bm, _, m, done := newTestBalanceManager(t, true)
 	defer done()
@@ -253,7 +286,7 @@ func TestTopUpAddressNoOpScenarios(t *testing.T) {
 	assert.Nil(t, fuelingTx)
 
 	// no source address configured
-	bm.sourceAddress = nil
+	bm.sources = nil
 	fuelingTx, err = bm.TopUpAccount(ctx, &AddressAccount{
 		Spent:                 big.NewInt(10),
 		Balance:               big.NewInt(0),
@@ -274,11 +307,12 @@ func mockAutoFuelTransactionSubmit(m *mocksAndTestControl, bm *BalanceManagerWit
 	// Then insert of the auto-fueling transaction
 	m.db.ExpectBegin()
 	m.db.ExpectQuery("INSERT.*public_txns").WillReturnRows(m.db.NewRows([]string{"pub_txn_id"}).AddRow(12345))
+	m.db.ExpectExec("INSERT.*public_fueling_sources").WillReturnResult(driver.ResultNoRows)
 	m.db.ExpectCommit()
 
 	if uncachedBalance {
 		// Mock the sufficient balance on the auto-fueling source address, and the nonce assignment
-		m.ethClient.On("GetBalance", mock.Anything, *bm.sourceAddress, "latest").Return(tktypes.Uint64ToUint256(400), nil).Once()
+		m.ethClient.On("GetBalance", mock.Anything, bm.sources[0].address, "latest").Return(tktypes.Uint64ToUint256(400), nil).Once()
 	}
 
 	// Gas estimate for the auto-fueling TX
@@ -311,7 +345,7 @@ func TestTopUpWithNoAmountModificationWithMultipleFuelingTxs(t *testing.T) {
 	expectedTopUpAmount := big.NewInt(100)
 	fuelingTx, err := bm.TopUpAccount(ctx, accountToTopUp)
 	require.NoError(t, err)
-	expectFuelingEqual(t, fuelingTx, expectedTopUpAmount.Uint64(), *bm.sourceAddress, testDestAddress)
+	expectFuelingEqual(t, fuelingTx, expectedTopUpAmount.Uint64(), bm.sources[0].address, testDestAddress)
 
 	// Test no new fueling transaction when the current one is pending
 	accountToTopUp2 := &AddressAccount{
@@ -326,23 +360,23 @@ func TestTopUpWithNoAmountModificationWithMultipleFuelingTxs(t *testing.T) {
 	// return not yet completed, so should return the existing pending transaction
 	m.db.ExpectQuery("SELECT.*public_txns").
 		WillReturnRows(sqlmock.NewRows([]string{"from"}).AddRow(
-			*bm.sourceAddress,
+			bm.sources[0].address,
 		))
 
 	newFuelingTx, err := bm.TopUpAccount(ctx, accountToTopUp2)
 	require.NoError(t, err)
-	expectFuelingEqual(t, newFuelingTx, expectedTopUpAmount.Uint64(), *bm.sourceAddress, testDestAddress)
+	expectFuelingEqual(t, newFuelingTx, expectedTopUpAmount.Uint64(), bm.sources[0].address, testDestAddress)
 
 	// current transaction completed, replace with new transaction
 	expectedTopUpAmount2 := big.NewInt(50)
 	m.db.ExpectQuery("SELECT.*public_txns").WillReturnRows(sqlmock.NewRows([]string{"from", `Completed__tx_hash`}).
-		AddRow(*bm.sourceAddress, tktypes.RandBytes32()))
+		AddRow(bm.sources[0].address, tktypes.RandBytes32()))
 
 	mockAutoFuelTransactionSubmit(m, bm, false)
 
 	fuelingTx2, err := bm.TopUpAccount(ctx, accountToTopUp2)
 	require.NoError(t, err)
-	expectFuelingEqual(t, fuelingTx2, expectedTopUpAmount2.Uint64(), *bm.sourceAddress, testDestAddress)
+	expectFuelingEqual(t, fuelingTx2, expectedTopUpAmount2.Uint64(), bm.sources[0].address, testDestAddress)
 
 	// test when couldn't record the result of the submitted transaction
 	// also do a balance look up
@@ -355,11 +389,11 @@ func TestTopUpWithNoAmountModificationWithMultipleFuelingTxs(t *testing.T) {
 		MaxCost:               big.NewInt(50),
 	}
 	expectedTopUpAmount3 := big.NewInt(50)
-	bm.NotifyAddressBalanceChanged(ctx, *bm.sourceAddress)
-	m.ethClient.On("GetBalance", mock.Anything, *bm.sourceAddress, "latest").Return(tktypes.Uint64ToUint256(50), nil).Once()
+	bm.NotifyAddressBalanceChanged(ctx, bm.sources[0].address)
+	m.ethClient.On("GetBalance", mock.Anything, bm.sources[0].address, "latest").Return(tktypes.Uint64ToUint256(50), nil).Once()
 
 	m.db.ExpectQuery("SELECT.*public_txns").WillReturnRows(sqlmock.NewRows([]string{"from", `Completed__tx_hash`}).
-		AddRow(*bm.sourceAddress, tktypes.RandBytes32()))
+		AddRow(bm.sources[0].address, tktypes.RandBytes32()))
 	m.db.ExpectBegin()
 
 	m.ethClient.On("EstimateGasNoResolve", mock.Anything, mock.Anything, mock.Anything).
@@ -374,13 +408,13 @@ func TestTopUpWithNoAmountModificationWithMultipleFuelingTxs(t *testing.T) {
 	// also do a address balance re-lookup
 	m.db.ExpectQuery("SELECT.*public_txns").
 		WillReturnRows(sqlmock.NewRows([]string{"from", "to", "value"}).AddRow(
-			*bm.sourceAddress, testDestAddress, (*tktypes.HexUint256)(expectedTopUpAmount3),
+			bm.sources[0].address, testDestAddress, (*tktypes.HexUint256)(expectedTopUpAmount3),
 		))
 	m.db.ExpectQuery("SELECT.*public_txns").WillReturnRows(sqlmock.NewRows([]string{"from", "to", "value", `Completed__tx_hash`}).
-		AddRow(*bm.sourceAddress, testDestAddress, (*tktypes.HexUint256)(expectedTopUpAmount3), nil /* incomplete */))
+		AddRow(bm.sources[0].address, testDestAddress, (*tktypes.HexUint256)(expectedTopUpAmount3), nil /* incomplete */))
 	fuelingTx3, err := bm.TopUpAccount(ctx, accountToTopUp3)
 	require.NoError(t, err)
-	expectFuelingEqual(t, fuelingTx3, expectedTopUpAmount3.Uint64(), *bm.sourceAddress, testDestAddress)
+	expectFuelingEqual(t, fuelingTx3, expectedTopUpAmount3.Uint64(), bm.sources[0].address, testDestAddress)
 }
 
 func TestTopUpSuccessTopUpMinAheadUseMin(t *testing.T) {
@@ -413,7 +447,7 @@ func TestTopUpSuccessTopUpMinAheadUseMin(t *testing.T) {
 
 	fuelingTx, err := bm.TopUpAccount(ctx, accountToTopUp)
 	require.NoError(t, err)
-	expectFuelingEqual(t, fuelingTx, expectedTopUpAmount.Uint64(), *bm.sourceAddress, testDestAddress)
+	expectFuelingEqual(t, fuelingTx, expectedTopUpAmount.Uint64(), bm.sources[0].address, testDestAddress)
 
 }
 
@@ -447,7 +481,7 @@ func TestTopUpSuccessTopUpMinAheadUseMax(t *testing.T) {
 
 	fuelingTx, err := bm.TopUpAccount(ctx, accountToTopUp)
 	require.NoError(t, err)
-	expectFuelingEqual(t, fuelingTx, expectedTopUpAmount.Uint64(), *bm.sourceAddress, testDestAddress)
+	expectFuelingEqual(t, fuelingTx, expectedTopUpAmount.Uint64(), bm.sources[0].address, testDestAddress)
 
 }
 
@@ -481,7 +515,7 @@ func TestTopUpSuccessTopUpMinAheadUseAvg(t *testing.T) {
 
 	fuelingTx, err := bm.TopUpAccount(ctx, accountToTopUp)
 	require.NoError(t, err)
-	expectFuelingEqual(t, fuelingTx, expectedTopUpAmount.Uint64(), *bm.sourceAddress, testDestAddress)
+	expectFuelingEqual(t, fuelingTx, expectedTopUpAmount.Uint64(), bm.sources[0].address, testDestAddress)
 
 }
 
@@ -512,7 +546,7 @@ func TestTopUpSuccessUseMinDestBalance(t *testing.T) {
 
 	fuelingTx, err := bm.TopUpAccount(ctx, accountToTopUp)
 	require.NoError(t, err)
-	expectFuelingEqual(t, fuelingTx, expectedTopUpAmount.Uint64(), *bm.sourceAddress, testDestAddress)
+	expectFuelingEqual(t, fuelingTx, expectedTopUpAmount.Uint64(), bm.sources[0].address, testDestAddress)
 }
 
 func TestTopUpSuccessUseMaxDestBalance(t *testing.T) {
@@ -542,7 +576,7 @@ func TestTopUpSuccessUseMaxDestBalance(t *testing.T) {
 
 	fuelingTx, err := bm.TopUpAccount(ctx, accountToTopUp)
 	require.NoError(t, err)
-	expectFuelingEqual(t, fuelingTx, expectedTopUpAmount.Uint64(), *bm.sourceAddress, testDestAddress)
+	expectFuelingEqual(t, fuelingTx, expectedTopUpAmount.Uint64(), bm.sources[0].address, testDestAddress)
 }
 
 func TestTopUpNoOpAlreadyAboveMaxDestBalance(t *testing.T) {
@@ -613,7 +647,7 @@ func TestTopUpFailedDueToSourceBalanceBelowMin(t *testing.T) {
 	m.db.ExpectQuery("SELECT.*public_txns.*data IS NULL").WillReturnRows(sqlmock.NewRows([]string{}))
 
 	// Mock the sufficient balance on the auto-fueling source address, and the nonce assignment
-	m.ethClient.On("GetBalance", mock.Anything, *bm.sourceAddress, "latest").Return(tktypes.Uint64ToUint256(400), nil).Once()
+	m.ethClient.On("GetBalance", mock.Anything, bm.sources[0].address, "latest").Return(tktypes.Uint64ToUint256(400), nil).Once()
 
 	// set min source balance to 1000, which is way beyond 400
 	bm.minSourceBalance = big.NewInt(1000)
@@ -621,7 +655,7 @@ func TestTopUpFailedDueToSourceBalanceBelowMin(t *testing.T) {
 	fuelingTx, err := bm.TopUpAccount(ctx, accountToTopUp)
 	assert.Error(t, err)
 	assert.Nil(t, fuelingTx)
-	assert.Regexp(t, fmt.Sprintf("PD011901: Balance 400 of fueling source address %s is below the configured minimum balance 1000", bm.sourceAddress), err.Error())
+	assert.Regexp(t, fmt.Sprintf("PD011901: Balance 400 of fueling source address %s is below the configured minimum balance 1000", bm.sources[0].address), err.Error())
 }
 
 func TestTopUpFailedDueToSourceBalanceBelowRequestedAmount(t *testing.T) {
@@ -644,12 +678,12 @@ func TestTopUpFailedDueToSourceBalanceBelowRequestedAmount(t *testing.T) {
 	m.db.ExpectQuery("SELECT.*public_txns.*data IS NULL").WillReturnRows(sqlmock.NewRows([]string{}))
 
 	// Mock the sufficient balance on the auto-fueling source address, and the nonce assignment
-	m.ethClient.On("GetBalance", mock.Anything, *bm.sourceAddress, "latest").Return(tktypes.Uint64ToUint256(400), nil).Once()
+	m.ethClient.On("GetBalance", mock.Anything, bm.sources[0].address, "latest").Return(tktypes.Uint64ToUint256(400), nil).Once()
 
 	fuelingTx, err := bm.TopUpAccount(ctx, accountToTopUp)
 	assert.Error(t, err)
 	assert.Nil(t, fuelingTx)
-	assert.Regexp(t, fmt.Sprintf("PD011900: Balance 400 of fueling source address %s is below the required amount 1900", bm.sourceAddress), err.Error())
+	assert.Regexp(t, fmt.Sprintf("PD011900: Balance 400 of fueling source address %s is below the required amount 1900", bm.sources[0].address), err.Error())
 }
 
 func TestTopUpFailedDueToSourceBalanceBelowRequestedAmountConcurrencyTest(t *testing.T) {
@@ -666,7 +700,7 @@ func TestTopUpFailedDueToSourceBalanceBelowRequestedAmountConcurrencyTest(t *tes
 	}
 
 	// Mock the sufficient balance on the auto-fueling source address, and the nonce assignment
-	m.ethClient.On("GetBalance", mock.Anything, *bm.sourceAddress, "latest").Return(tktypes.Uint64ToUint256(400), nil).Once() // called once and then cached
+	m.ethClient.On("GetBalance", mock.Anything, bm.sources[0].address, "latest").Return(tktypes.Uint64ToUint256(400), nil).Once() // called once and then cached
 
 	var wg sync.WaitGroup
 	for i := 0; i < testConcurrency; i++ {
@@ -683,7 +717,7 @@ func TestTopUpFailedDueToSourceBalanceBelowRequestedAmountConcurrencyTest(t *tes
 			})
 			assert.Error(t, err)
 			assert.Nil(t, fuelingTx)
-			assert.Regexp(t, fmt.Sprintf("PD011900: Balance 400 of fueling source address %s is below the required amount 1900", bm.sourceAddress), err.Error())
+			assert.Regexp(t, fmt.Sprintf("PD011900: Balance 400 of fueling source address %s is below the required amount 1900", bm.sources[0].address), err.Error())
 		}()
 	}
 	wg.Wait()
@@ -734,10 +768,142 @@ func TestTopUpFailedDueToUnableToGetSourceAddressBalance(t *testing.T) {
 	m.db.ExpectQuery("SELECT.*public_txns.*data IS NULL").WillReturnRows(sqlmock.NewRows([]string{}))
 
 	// Mock the sufficient balance on the auto-fueling source address, and the nonce assignment
-	m.ethClient.On("GetBalance", mock.Anything, *bm.sourceAddress, "latest").Return(tktypes.Uint64ToUint256(0), fmt.Errorf("pop")).Once()
+	m.ethClient.On("GetBalance", mock.Anything, bm.sources[0].address, "latest").Return(tktypes.Uint64ToUint256(0), fmt.Errorf("pop")).Once()
 
 	fuelingTx, err := bm.TopUpAccount(ctx, accountToTopUp)
 	assert.Error(t, err)
 	assert.Nil(t, fuelingTx)
 	assert.Regexp(t, "pop", err.Error())
 }
+
+func TestSelectSourceWeightedRoundRobin(t *testing.T) {
+	bm := &BalanceManagerWithInMemoryTracking{
+		sources: []*fuelingSource{
+			{source: "heavy", address: *tktypes.RandAddress(), weight: 3},
+			{source: "light", address: *tktypes.RandAddress(), weight: 1},
+			{source: "paused", address: *tktypes.RandAddress(), weight: 0},
+		},
+	}
+	assert.True(t, bm.IsAutoFuelingEnabled(context.Background()))
+
+	const selections = 4000
+	counts := make(map[string]int)
+	maxConsecutive, consecutive := 0, 0
+	var last *fuelingSource
+	for i := 0; i < selections; i++ {
+		s := bm.selectSource()
+		require.NotNil(t, s)
+		counts[s.source]++
+		if s == last {
+			consecutive++
+		} else {
+			consecutive = 1
+		}
+		maxConsecutive = max(maxConsecutive, consecutive)
+		last = s
+	}
+	assert.InDelta(t, selections*3/4, counts["heavy"], selections*0.01)
+	assert.InDelta(t, selections*1/4, counts["light"], selections*0.01)
+	assert.Zero(t, counts["paused"])
+	// selections are interleaved, rather than being taken in blocks of the weight
+	assert.LessOrEqual(t, maxConsecutive, 3)
+
+	// with every weight at zero, nothing is selected and fueling is turned off
+	bm.sources[0].weight = 0
+	bm.sources[1].weight = 0
+	assert.Nil(t, bm.selectSource())
+	assert.False(t, bm.IsAutoFuelingEnabled(context.Background()))
+}
+
+func TestNewBalanceManagerMultipleSources(t *testing.T) {
+	ctx, bm, _, _, done := newTestBalanceManager(t, true, func(m *mocksAndTestControl, conf *pldconf.PublicTxManagerConfig) {
+		conf.BalanceManager.AutoFueling.Sources = []*pldconf.AutoFuelingSourceConfig{
+			{Source: confutil.P("autofueler2"), Weight: confutil.P(5)},
+			{Source: confutil.P("autofueler3"), Weight: confutil.P(0)},
+		}
+		mockKeyMgr := m.keyManager.(*componentmocks.KeyManager)
+		for _, source := range []string{"autofueler2", "autofueler3"} {
+			mockKeyMgr.On("ResolveKeyNewDatabaseTX", mock.Anything, source, mock.Anything, mock.Anything).
+				Return(&pldapi.KeyMappingAndVerifier{
+					Verifier: &pldapi.KeyVerifier{Verifier: tktypes.RandAddress().String()},
+				}, nil)
+		}
+	})
+	defer done()
+
+	require.Len(t, bm.sources, 3)
+	assert.Equal(t, "autofueler", bm.sources[0].source)
+	assert.Equal(t, 1, bm.sources[0].weight)
+	assert.Equal(t, "autofueler2", bm.sources[1].source)
+	assert.Equal(t, 5, bm.sources[1].weight)
+	assert.Equal(t, "autofueler3", bm.sources[2].source)
+	assert.Equal(t, 0, bm.sources[2].weight)
+	assert.True(t, bm.IsAutoFuelingEnabled(ctx))
+	// zero weight sources are still checked for in-flight fueling transactions
+	assert.Len(t, bm.sourceAddresses(), 3)
+}
+
+func TestNewBalanceManagerBadWeightedSource(t *testing.T) {
+	ctx, ble, m, done := newTestPublicTxManager(t, false)
+	defer done()
+
+	ble.conf.BalanceManager.AutoFueling.Sources = []*pldconf.AutoFuelingSourceConfig{
+		{Source: confutil.P("bad source"), Weight: confutil.P(1)},
+	}
+	mockKeyMgr := m.keyManager.(*componentmocks.KeyManager)
+	mockKeyMgr.On("ResolveKeyNewDatabaseTX", mock.Anything, "bad source", mock.Anything, mock.Anything).
+		Return(nil, fmt.Errorf("pop"))
+	_, err := NewBalanceManagerWithInMemoryTracking(ctx, ble.conf, ble)
+	assert.Regexp(t, "PD011934.*bad source.*pop", err)
+}
+
+func TestWeightedSourcesFuelingCountsPersisted(t *testing.T) {
+	ctx, ble, m, done := newTestPublicTxManager(t, true, func(m *mocksAndTestControl, conf *pldconf.PublicTxManagerConfig) {
+		m.disableManagerStart = true
+		conf.BalanceManager.AutoFueling.Sources = []*pldconf.AutoFuelingSourceConfig{
+			{Source: confutil.P("fueler1"), Weight: confutil.P(2)},
+			{Source: confutil.P("fueler2"), Weight: confutil.P(1)},
+			{Source: confutil.P("fueler3"), Weight: confutil.P(0)},
+		}
+	})
+	defer done()
+	bm := ble.balanceManager.(*BalanceManagerWithInMemoryTracking)
+	require.Len(t, bm.sources, 3)
+
+	m.ethClient.On("GetBalance", mock.Anything, mock.Anything, "latest").Return(tktypes.Uint64ToUint256(1000000), nil)
+	m.ethClient.On("EstimateGasNoResolve", mock.Anything, mock.Anything, mock.Anything).
+		Return(ethclient.EstimateGasResult{GasLimit: tktypes.HexUint64(10)}, nil)
+
+	// each destination needs a new fueling transaction
+	const fuelingTxCount = 9
+	fromCounts := make(map[tktypes.EthAddress]int)
+	for i := 0; i < fuelingTxCount; i++ {
+		fuelingTx, err := bm.TransferGasFromAutoFuelingSource(ctx, *tktypes.RandAddress(), big.NewInt(100))
+		require.NoError(t, err)
+		fromCounts[fuelingTx.From]++
+	}
+
+	var persisted []*DBPublicFuelingSource
+	err := ble.p.DB().WithContext(ctx).Find(&persisted).Error
+	require.NoError(t, err)
+	persistedCounts := make(map[tktypes.EthAddress]uint64)
+	for _, p := range persisted {
+		persistedCounts[p.Source] = p.FuelingCount
+		assert.NotZero(t, p.Updated)
+	}
+	assert.Equal(t, map[tktypes.EthAddress]uint64{
+		bm.sources[0].address: 6,
+		bm.sources[1].address: 3,
+	}, persistedCounts)
+	assert.Equal(t, 6, fromCounts[bm.sources[0].address])
+	assert.Equal(t, 3, fromCounts[bm.sources[1].address])
+
+	// a pending fueling transaction from any of the sources is found for the destination
+	dest := *tktypes.RandAddress()
+	fuelingTx, err := bm.TransferGasFromAutoFuelingSource(ctx, dest, big.NewInt(100))
+	require.NoError(t, err)
+	bm.trackedFuelingTransactions = make(map[tktypes.EthAddress]*pldapi.PublicTx)
+	pendingTx, err := bm.TransferGasFromAutoFuelingSource(ctx, dest, big.NewInt(100))
+	require.NoError(t, err)
+	assert.Equal(t, fuelingTx.LocalID, pendingTx.LocalID)
+}
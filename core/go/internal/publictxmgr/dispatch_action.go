@@ -37,7 +37,7 @@ func (pte *pubTxManager) persistSuspendedFlag(ctx context.Context, from tktypes.
 		Table("public_txns").
 		Where(`"from" = ?`, from).
 		Where("nonce = ?", nonce).
-		UpdateColumn("suspended", suspended).
+		UpdateColumns(map[string]any{"suspended": suspended, "updated": tktypes.TimestampNow()}).
 		Error
 }
 
@@ -19,6 +19,7 @@ import (
 	"testing"
 
 	"github.com/kaleido-io/paladin/toolkit/pkg/tktypes"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
@@ -37,3 +38,19 @@ func TestDispatchCompletedActionForNonInflightIgnored(t *testing.T) {
 	err := txm.dispatchAction(ctx, *tktypes.RandAddress(), 12345, ActionCompleted)
 	require.NoError(t, err)
 }
+
+func TestDispatchCompletedActionForInflight(t *testing.T) {
+	ctx, o, _, done := newTestOrchestrator(t)
+	defer done()
+
+	// The same completion action is dispatched whether the transaction was mined successfully
+	// or mined but reverted, as either way the nonce has been consumed on-chain
+	mockIT, _ := newInflightTransaction(o, 1)
+	mockIT.testOnlyNoActionMode = true
+	o.inFlightTxs = []*inFlightTransactionStageController{mockIT}
+
+	err := o.dispatchAction(ctx, 1, ActionCompleted)
+	require.NoError(t, err)
+	require.NotNil(t, mockIT.newStatus)
+	assert.Equal(t, InFlightStatusConfirmReceived, *mockIT.newStatus)
+}
@@ -0,0 +1,100 @@
+/*
+ * Copyright © 2024 Kaleido, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with
+ * the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package publictxmgr
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/kaleido-io/paladin/config/pkg/confutil"
+	"github.com/kaleido-io/paladin/core/pkg/ethclient"
+	"github.com/kaleido-io/paladin/core/pkg/persistence"
+	"github.com/kaleido-io/paladin/toolkit/pkg/log"
+	"github.com/kaleido-io/paladin/toolkit/pkg/pldapi"
+	"github.com/kaleido-io/paladin/toolkit/pkg/tktypes"
+)
+
+// Derives the category of a failure from the error returned by the node.
+// Returns an empty category for errors that do not fall into one a client can act on.
+func mapFailureCategory(err error) pldapi.PublicTxFailureCategory {
+	if err == nil {
+		return ""
+	}
+	switch ethclient.MapError(err) {
+	case ethclient.ErrorReasonInsufficientFunds:
+		return pldapi.PublicTxFailureInsufficientFunds
+	case ethclient.ErrorReasonNonceTooLow:
+		return pldapi.PublicTxFailureNonceTooLow
+	case ethclient.ErrorReasonTransactionUnderpriced:
+		return pldapi.PublicTxFailureUnderpriced
+	case ethclient.ErrorReasonTransactionReverted:
+		return pldapi.PublicTxFailureReverted
+	}
+	errString := strings.ToLower(err.Error())
+	if errors.Is(err, context.DeadlineExceeded) ||
+		strings.Contains(errString, "timeout") ||
+		strings.Contains(errString, "timed out") ||
+		strings.Contains(errString, "deadline exceeded") {
+		return pldapi.PublicTxFailureTimeout
+	}
+	return ""
+}
+
+func (pte *pubTxManager) UpdateFailureCategory(ctx context.Context, imtx InMemoryTxStateReadOnly, category pldapi.PublicTxFailureCategory) error {
+	log.L(ctx).Infof("Recording failure category '%s' for transaction %s", category, imtx.GetSignerNonce())
+	return pte.p.DB().
+		WithContext(ctx).
+		Table("public_txns").
+		Where("pub_txn_id = ?", imtx.GetPubTxnID()).
+		UpdateColumns(map[string]any{"failure_category": string(category), "updated": tktypes.TimestampNow()}).
+		Error
+}
+
+// Called in the same DB transaction that records the completions. Earlier failures are no longer relevant once
+// a transaction is mined successfully, and a transaction that is mined but reverted is always categorized as reverted.
+func updateCompletedFailureCategories(ctx context.Context, dbTX persistence.DBTX, completions []*DBPublicTxnCompletion) error {
+	var succeeded, reverted []uint64
+	for _, completion := range completions {
+		if completion.Success {
+			succeeded = append(succeeded, completion.PublicTxnID)
+		} else {
+			reverted = append(reverted, completion.PublicTxnID)
+		}
+	}
+	now := tktypes.TimestampNow()
+	for _, update := range []struct {
+		pubTxnIDs []uint64
+		category  *string
+	}{
+		{pubTxnIDs: succeeded},
+		{pubTxnIDs: reverted, category: confutil.P(string(pldapi.PublicTxFailureReverted))},
+	} {
+		if len(update.pubTxnIDs) == 0 {
+			continue
+		}
+		err := dbTX.DB().
+			WithContext(ctx).
+			Table("public_txns").
+			Where(`"pub_txn_id" IN (?)`, update.pubTxnIDs).
+			UpdateColumns(map[string]any{"failure_category": update.category, "updated": now}).
+			Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
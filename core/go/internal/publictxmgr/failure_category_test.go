@@ -0,0 +1,148 @@
+/*
+ * Copyright © 2024 Kaleido, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with
+ * the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package publictxmgr
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/kaleido-io/paladin/config/pkg/confutil"
+	"github.com/kaleido-io/paladin/config/pkg/pldconf"
+	"github.com/kaleido-io/paladin/core/internal/components"
+	"github.com/kaleido-io/paladin/core/pkg/blockindexer"
+	"github.com/kaleido-io/paladin/core/pkg/persistence"
+	"github.com/kaleido-io/paladin/toolkit/pkg/pldapi"
+	"github.com/kaleido-io/paladin/toolkit/pkg/query"
+	"github.com/kaleido-io/paladin/toolkit/pkg/tktypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMapFailureCategory(t *testing.T) {
+	for errString, category := range map[string]pldapi.PublicTxFailureCategory{
+		"insufficient funds for gas * price + value":                pldapi.PublicTxFailureInsufficientFunds,
+		"err: insufficient funds for transfer (supplied gas 21000)": pldapi.PublicTxFailureInsufficientFunds,
+		"nonce too low: next nonce 12, tx nonce 10":                 pldapi.PublicTxFailureNonceTooLow,
+		"Nonce too low":                                              pldapi.PublicTxFailureNonceTooLow,
+		"transaction underpriced":                                    pldapi.PublicTxFailureUnderpriced,
+		"replacement transaction underpriced":                        pldapi.PublicTxFailureUnderpriced,
+		"execution reverted: ERC20: transfer amount exceeds balance": pldapi.PublicTxFailureReverted,
+		"Execution reverted":                                         pldapi.PublicTxFailureReverted,
+		"net/http: request canceled (Client.Timeout exceeded)":       pldapi.PublicTxFailureTimeout,
+		"dial tcp 10.0.0.1:8545: i/o timeout":                        pldapi.PublicTxFailureTimeout,
+		"request timed out":                                          pldapi.PublicTxFailureTimeout,
+		"known transaction: 0x1234":                                  "",
+		"exceeds block gas limit":                                    "",
+	} {
+		assert.Equal(t, category, mapFailureCategory(fmt.Errorf("%s", errString)), errString)
+	}
+	assert.Equal(t, pldapi.PublicTxFailureTimeout, mapFailureCategory(fmt.Errorf("wrapped: %w", context.DeadlineExceeded)))
+	assert.Equal(t, pldapi.PublicTxFailureCategory(""), mapFailureCategory(nil))
+}
+
+func TestMapPersistedTransactionFailureCategory(t *testing.T) {
+	tx := mapPersistedTransaction(&DBPublicTxn{FailureCategory: confutil.P(string(pldapi.PublicTxFailureUnderpriced))})
+	assert.Equal(t, pldapi.PublicTxFailureUnderpriced, tx.FailureCategory)
+
+	// cleared once the transaction succeeds
+	tx = mapPersistedTransaction(&DBPublicTxn{
+		FailureCategory: confutil.P(string(pldapi.PublicTxFailureUnderpriced)),
+		Completed:       &DBPublicTxnCompletion{Success: true},
+	})
+	assert.Empty(t, tx.FailureCategory)
+
+	// and always reverted once the transaction fails on-chain
+	tx = mapPersistedTransaction(&DBPublicTxn{
+		FailureCategory: confutil.P(string(pldapi.PublicTxFailureUnderpriced)),
+		Completed:       &DBPublicTxnCompletion{Success: false},
+	})
+	assert.Equal(t, pldapi.PublicTxFailureReverted, tx.FailureCategory)
+}
+
+func TestCompletionUpdatesFailureCategory(t *testing.T) {
+	ctx, ble, _, done := newTestPublicTxManager(t, true, func(mocks *mocksAndTestControl, conf *pldconf.PublicTxManagerConfig) {
+		mocks.disableManagerStart = true
+	})
+	defer done()
+
+	submitWithFailure := func(result pldapi.EthTransactionResult) (uint64, *blockindexer.IndexedTransactionNotify) {
+		txID := uuid.New()
+		fakeTxManagerInsert(t, ble.p.DB(), txID, "signer1")
+		var ptxs []*pldapi.PublicTx
+		err := ble.p.Transaction(ctx, func(ctx context.Context, dbTX persistence.DBTX) (err error) {
+			ptxs, err = ble.WriteNewTransactions(ctx, dbTX, []*components.PublicTxSubmission{{
+				Bindings: []*components.PaladinTXReference{
+					{TransactionID: txID, TransactionType: pldapi.TransactionTypePrivate.Enum()},
+				},
+				PublicTxInput: pldapi.PublicTxInput{
+					From: tktypes.RandAddress(),
+					PublicTxOptions: pldapi.PublicTxOptions{
+						Gas: confutil.P(tktypes.HexUint64(100000)),
+					},
+				},
+			}})
+			return err
+		})
+		require.NoError(t, err)
+		pubTxnID := *ptxs[0].LocalID
+
+		// an earlier submission failed
+		err = ble.p.DB().Table("public_txns").Where("pub_txn_id = ?", pubTxnID).
+			UpdateColumn("failure_category", string(pldapi.PublicTxFailureUnderpriced)).Error
+		require.NoError(t, err)
+
+		txHash := tktypes.RandBytes32()
+		err = ble.p.DB().Create(&DBPubTxnSubmission{
+			PublicTxnID:     pubTxnID,
+			Created:         tktypes.TimestampNow(),
+			TransactionHash: txHash,
+		}).Error
+		require.NoError(t, err)
+		return pubTxnID, &blockindexer.IndexedTransactionNotify{
+			IndexedTransaction: pldapi.IndexedTransaction{
+				Hash:        txHash,
+				BlockNumber: 1000,
+				Result:      result.Enum(),
+			},
+		}
+	}
+	succeededID, succeeded := submitWithFailure(pldapi.TXResult_SUCCESS)
+	revertedID, reverted := submitWithFailure(pldapi.TXResult_FAILURE)
+
+	matches, err := ble.MatchUpdateConfirmedTransactions(ctx, ble.p.NOTX(), []*blockindexer.IndexedTransactionNotify{succeeded, reverted})
+	require.NoError(t, err)
+	require.Len(t, matches, 2)
+
+	// the category is updated in the DB, so it can be queried
+	queryCategory := func(category pldapi.PublicTxFailureCategory) []uint64 {
+		txs, err := ble.QueryPublicTxWithBindings(ctx, ble.p.NOTX(), query.NewQueryBuilder().Equal("failureCategory", category).Limit(10).Query())
+		require.NoError(t, err)
+		pubTxnIDs := make([]uint64, len(txs))
+		for i, tx := range txs {
+			pubTxnIDs[i] = *tx.LocalID
+		}
+		return pubTxnIDs
+	}
+	assert.Empty(t, queryCategory(pldapi.PublicTxFailureUnderpriced))
+	assert.Equal(t, []uint64{revertedID}, queryCategory(pldapi.PublicTxFailureReverted))
+
+	var dbTxn DBPublicTxn
+	err = ble.p.DB().Table("public_txns").Where("pub_txn_id = ?", succeededID).Take(&dbTxn).Error
+	require.NoError(t, err)
+	assert.Nil(t, dbTxn.FailureCategory)
+}
@@ -0,0 +1,85 @@
+/*
+ * Copyright © 2024 Kaleido, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with
+ * the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package publictxmgr
+
+import (
+	"context"
+
+	"github.com/kaleido-io/paladin/config/pkg/confutil"
+	"github.com/kaleido-io/paladin/config/pkg/pldconf"
+	"github.com/kaleido-io/paladin/core/internal/msgs"
+	"github.com/kaleido-io/paladin/toolkit/pkg/i18n"
+	"github.com/kaleido-io/paladin/toolkit/pkg/tktypes"
+)
+
+// Node gas estimates are exact for the state at the time of the estimate, so a multiplier and floor give
+// submitted transactions headroom. Overrides allow individual signers to use a different policy.
+type gasLimitPolicy struct {
+	factor float64
+	floor  uint64
+}
+
+func parseGasLimitPolicies(ctx context.Context, conf *pldconf.GasLimitConfig) (defaultPolicy *gasLimitPolicy, overrides map[tktypes.EthAddress]*gasLimitPolicy, blockGasLimit uint64, err error) {
+	defaultPolicy = &gasLimitPolicy{
+		factor: confutil.Float64Min(conf.GasEstimateFactor, 1.0, *pldconf.PublicTxManagerDefaults.GasLimit.GasEstimateFactor),
+	}
+	if conf.Floor != nil {
+		defaultPolicy.floor = *conf.Floor
+	}
+	if conf.BlockGasLimit != nil {
+		blockGasLimit = *conf.BlockGasLimit
+	}
+	if blockGasLimit > 0 && defaultPolicy.floor > blockGasLimit {
+		return nil, nil, 0, i18n.NewError(ctx, msgs.MsgGasLimitFloorAboveBlockLimit, defaultPolicy.floor, blockGasLimit)
+	}
+
+	overrides = make(map[tktypes.EthAddress]*gasLimitPolicy, len(conf.SignerOverrides))
+	for addrStr, oc := range conf.SignerOverrides {
+		addr, err := tktypes.ParseEthAddress(addrStr)
+		if err != nil {
+			return nil, nil, 0, i18n.WrapError(ctx, err, msgs.MsgInvalidGasLimitSignerOverride, addrStr)
+		}
+		// anything not set on the override is inherited from the default
+		p := &gasLimitPolicy{
+			factor: confutil.Float64Min(oc.GasEstimateFactor, 1.0, defaultPolicy.factor),
+			floor:  defaultPolicy.floor,
+		}
+		if oc.Floor != nil {
+			p.floor = *oc.Floor
+		}
+		if blockGasLimit > 0 && p.floor > blockGasLimit {
+			return nil, nil, 0, i18n.NewError(ctx, msgs.MsgGasLimitFloorAboveBlockLimit, p.floor, blockGasLimit)
+		}
+		overrides[*addr] = p
+	}
+	return defaultPolicy, overrides, blockGasLimit, nil
+}
+
+// Applies the multiplier and floor for the signer to a gas estimate, capping the result at the block gas limit
+func (ble *pubTxManager) gasLimitFromEstimate(from tktypes.EthAddress, estimate uint64) uint64 {
+	p := ble.gasLimitPolicies[from]
+	if p == nil {
+		p = ble.gasLimitDefault
+	}
+	gasLimit := uint64(float64(estimate) * p.factor)
+	if gasLimit < p.floor {
+		gasLimit = p.floor
+	}
+	if ble.blockGasLimit > 0 && gasLimit > ble.blockGasLimit {
+		gasLimit = ble.blockGasLimit
+	}
+	return gasLimit
+}
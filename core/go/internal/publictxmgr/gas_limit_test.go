@@ -0,0 +1,141 @@
+/*
+ * Copyright © 2024 Kaleido, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with
+ * the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package publictxmgr
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kaleido-io/paladin/config/pkg/confutil"
+	"github.com/kaleido-io/paladin/config/pkg/pldconf"
+	"github.com/kaleido-io/paladin/core/internal/components"
+	"github.com/kaleido-io/paladin/core/pkg/ethclient"
+	"github.com/kaleido-io/paladin/toolkit/pkg/pldapi"
+	"github.com/kaleido-io/paladin/toolkit/pkg/tktypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGasLimitMultiplierFloorAndBlockLimit(t *testing.T) {
+	defaultAddr := tktypes.RandAddress()
+	heavyAddr := tktypes.RandAddress()
+	floorAddr := tktypes.RandAddress()
+
+	_, ble, _, done := newTestPublicTxManager(t, false, func(mocks *mocksAndTestControl, conf *pldconf.PublicTxManagerConfig) {
+		mocks.disableManagerStart = true
+		conf.GasLimit = pldconf.GasLimitConfig{
+			GasEstimateFactor: confutil.P(1.2),
+			Floor:             confutil.P(uint64(50000)),
+			BlockGasLimit:     confutil.P(uint64(1000000)),
+			SignerOverrides: map[string]pldconf.GasLimitSignerOverrideConfig{
+				heavyAddr.String(): {GasEstimateFactor: confutil.P(2.0)},
+				floorAddr.String(): {Floor: confutil.P(uint64(300000))},
+			},
+		}
+	})
+	defer done()
+
+	// multiplier
+	assert.Equal(t, uint64(120000), ble.gasLimitFromEstimate(*defaultAddr, 100000))
+	// floor
+	assert.Equal(t, uint64(50000), ble.gasLimitFromEstimate(*defaultAddr, 21000))
+	// clamped to the block gas limit
+	assert.Equal(t, uint64(1000000), ble.gasLimitFromEstimate(*defaultAddr, 900000))
+
+	// the override multiplier, inheriting the floor
+	assert.Equal(t, uint64(200000), ble.gasLimitFromEstimate(*heavyAddr, 100000))
+	assert.Equal(t, uint64(50000), ble.gasLimitFromEstimate(*heavyAddr, 21000))
+	assert.Equal(t, uint64(1000000), ble.gasLimitFromEstimate(*heavyAddr, 600000))
+
+	// the override floor, inheriting the multiplier
+	assert.Equal(t, uint64(300000), ble.gasLimitFromEstimate(*floorAddr, 100000))
+	assert.Equal(t, uint64(480000), ble.gasLimitFromEstimate(*floorAddr, 400000))
+}
+
+func TestGasLimitDefaults(t *testing.T) {
+	_, ble, _, done := newTestPublicTxManager(t, false, func(mocks *mocksAndTestControl, conf *pldconf.PublicTxManagerConfig) {
+		mocks.disableManagerStart = true
+	})
+	defer done()
+
+	// only the default multiplier applies, with no floor and no cap
+	assert.Equal(t, uint64(150), ble.gasLimitFromEstimate(*tktypes.RandAddress(), 100))
+	assert.Equal(t, uint64(150000000), ble.gasLimitFromEstimate(*tktypes.RandAddress(), 100000000))
+}
+
+func TestValidateTransactionAppliesGasLimitPolicy(t *testing.T) {
+	ctx, ble, m, done := newTestPublicTxManager(t, false, func(mocks *mocksAndTestControl, conf *pldconf.PublicTxManagerConfig) {
+		mocks.disableManagerStart = true
+		conf.GasLimit = pldconf.GasLimitConfig{
+			GasEstimateFactor: confutil.P(1.2),
+			BlockGasLimit:     confutil.P(uint64(1000000)),
+		}
+	})
+	defer done()
+
+	m.ethClient.On("EstimateGasNoResolve", mock.Anything, mock.Anything, mock.Anything).
+		Return(ethclient.EstimateGasResult{GasLimit: tktypes.HexUint64(900000)}, nil).Once()
+
+	txi := &components.PublicTxSubmission{
+		PublicTxInput: pldapi.PublicTxInput{
+			From: tktypes.RandAddress(),
+		},
+	}
+	err := ble.ValidateTransaction(ctx, ble.p.NOTX(), txi)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1000000), txi.Gas.Uint64())
+}
+
+func TestParseGasLimitPoliciesErrors(t *testing.T) {
+	ctx := context.Background()
+
+	_, _, _, err := parseGasLimitPolicies(ctx, &pldconf.GasLimitConfig{
+		SignerOverrides: map[string]pldconf.GasLimitSignerOverrideConfig{
+			"not an address": {},
+		},
+	})
+	assert.Regexp(t, "PD011948.*not an address", err)
+
+	_, _, _, err = parseGasLimitPolicies(ctx, &pldconf.GasLimitConfig{
+		Floor:         confutil.P(uint64(2000)),
+		BlockGasLimit: confutil.P(uint64(1000)),
+	})
+	assert.Regexp(t, "PD011949.*2,000.*1,000", err)
+
+	_, _, _, err = parseGasLimitPolicies(ctx, &pldconf.GasLimitConfig{
+		BlockGasLimit: confutil.P(uint64(1000)),
+		SignerOverrides: map[string]pldconf.GasLimitSignerOverrideConfig{
+			tktypes.RandAddress().String(): {Floor: confutil.P(uint64(2000))},
+		},
+	})
+	assert.Regexp(t, "PD011949.*2,000.*1,000", err)
+}
+
+func TestPostInitBadGasLimitConfig(t *testing.T) {
+	mocks := baseMocks(t)
+	mocks.allComponents.On("Persistence").Return(mocks.db).Maybe()
+	mocks.allComponents.On("KeyManager").Return(mocks.keyManager).Maybe()
+	pmgr := NewPublicTransactionManager(context.Background(), &pldconf.PublicTxManagerConfig{
+		GasLimit: pldconf.GasLimitConfig{
+			SignerOverrides: map[string]pldconf.GasLimitSignerOverrideConfig{
+				"not an address": {},
+			},
+		},
+	})
+	err := pmgr.PostInit(mocks.allComponents)
+	assert.Regexp(t, "PD011948", err)
+}
@@ -0,0 +1,197 @@
+/*
+ * Copyright © 2024 Kaleido, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with
+ * the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package publictxmgr
+
+import (
+	"context"
+	"math/big"
+	"sort"
+	"time"
+
+	"github.com/kaleido-io/paladin/core/internal/msgs"
+	"github.com/kaleido-io/paladin/core/pkg/persistence"
+	"github.com/kaleido-io/paladin/toolkit/pkg/i18n"
+	"github.com/kaleido-io/paladin/toolkit/pkg/log"
+	"github.com/kaleido-io/paladin/toolkit/pkg/pldapi"
+	"github.com/kaleido-io/paladin/toolkit/pkg/tktypes"
+)
+
+// The number of buckets a single history query can return is bounded, so a tiny bucket size
+// over a long range cannot generate an unbounded response
+const maxGasPriceHistoryBuckets = 10000
+
+// The number of samples read from the database at a time, when aggregating a history query
+const gasPriceHistoryQueryPageSize = 1000
+
+type DBGasPriceSample struct {
+	Sequence             uint64              `gorm:"column:sequence;primaryKey;autoIncrement"`
+	Created              tktypes.Timestamp   `gorm:"column:created"`
+	GasPrice             *tktypes.HexUint256 `gorm:"column:gas_price"`
+	MaxFeePerGas         *tktypes.HexUint256 `gorm:"column:max_fee_per_gas"`
+	MaxPriorityFeePerGas *tktypes.HexUint256 `gorm:"column:max_priority_fee_per_gas"`
+}
+
+func (DBGasPriceSample) TableName() string {
+	return "public_gas_price_history"
+}
+
+// The price a sample contributes to the aggregates - the legacy gas price, or the EIP-1559 max fee
+func (s *DBGasPriceSample) price() *big.Int {
+	if s.GasPrice != nil {
+		return s.GasPrice.Int()
+	}
+	if s.MaxFeePerGas != nil {
+		return s.MaxFeePerGas.Int()
+	}
+	return nil
+}
+
+// The history loop records the gas price that would be suggested for a new submission at each
+// sample interval, and purges samples older than the retention window.
+func (ble *pubTxManager) gasPriceHistoryLoop() {
+	defer close(ble.gasPriceHistoryLoopDone)
+	ctx := log.WithLogField(ble.ctx, "role", "gas-price-history")
+	log.L(ctx).Infof("Gas price history enabled (interval=%s retention=%s)", ble.gasPriceHistoryInterval, ble.gasPriceHistoryRetention)
+
+	ticker := time.NewTicker(ble.gasPriceHistoryInterval)
+	defer ticker.Stop()
+	for {
+		now := time.Now()
+		if err := ble.recordGasPriceSample(ctx, now); err != nil {
+			log.L(ctx).Warnf("Failed to record gas price sample: %s", err)
+		}
+		if purged, err := ble.purgeGasPriceHistory(ctx, now.Add(-ble.gasPriceHistoryRetention)); err != nil {
+			log.L(ctx).Warnf("Failed to purge gas price history: %s", err)
+		} else if purged > 0 {
+			log.L(ctx).Debugf("Purged %d gas price samples", purged)
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			log.L(ctx).Infof("Gas price history loop exiting")
+			return
+		}
+	}
+}
+
+func (ble *pubTxManager) recordGasPriceSample(ctx context.Context, now time.Time) error {
+	gasPricing, err := ble.gasPriceClient.GetGasPriceObject(ctx)
+	if err != nil {
+		return err
+	}
+	return ble.p.DB().
+		WithContext(ctx).
+		Create(&DBGasPriceSample{
+			Created:              tktypes.Timestamp(now.UnixNano()),
+			GasPrice:             gasPricing.GasPrice,
+			MaxFeePerGas:         gasPricing.MaxFeePerGas,
+			MaxPriorityFeePerGas: gasPricing.MaxPriorityFeePerGas,
+		}).
+		Error
+}
+
+func (ble *pubTxManager) purgeGasPriceHistory(ctx context.Context, cutoff time.Time) (int64, error) {
+	res := ble.p.DB().
+		WithContext(ctx).
+		Where(`"created" < ?`, tktypes.Timestamp(cutoff.UnixNano())).
+		Delete(&DBGasPriceSample{})
+	return res.RowsAffected, res.Error
+}
+
+// Returns the min/max/avg of the gas price samples in [start,end), aggregated into consecutive
+// buckets of the given size starting at the start of the range. Buckets with no samples are omitted.
+// A zero bucket size aggregates the whole range into a single bucket.
+func (ble *pubTxManager) QueryGasPriceHistory(ctx context.Context, dbTX persistence.DBTX, start, end tktypes.Timestamp, bucket time.Duration) ([]*pldapi.GasPriceHistoryEntry, error) {
+	if end <= start {
+		return nil, i18n.NewError(ctx, msgs.MsgPublicTxGasPriceHistoryRange, "end must be after start")
+	}
+	if bucket <= 0 {
+		bucket = time.Duration(end - start)
+	}
+	if int64(end-start)/int64(bucket) >= maxGasPriceHistoryBuckets {
+		return nil, i18n.NewError(ctx, msgs.MsgPublicTxGasPriceHistoryRange, "too many buckets for the time range")
+	}
+
+	// Samples are read a page at a time and folded into the aggregates, so the memory used is bounded
+	// by the number of buckets rather than the number of samples in the range
+	type aggregate struct {
+		entry *pldapi.GasPriceHistoryEntry
+		min   *big.Int
+		max   *big.Int
+		sum   *big.Int
+	}
+	aggregates := make(map[tktypes.Timestamp]*aggregate)
+	var lastSequence uint64
+	for {
+		var samples []*DBGasPriceSample
+		err := dbTX.DB().
+			WithContext(ctx).
+			Where(`"created" >= ?`, start).
+			Where(`"created" < ?`, end).
+			Where(`"sequence" > ?`, lastSequence).
+			Order(`"sequence"`).
+			Limit(ble.gasPriceHistoryPageSize).
+			Find(&samples).
+			Error
+		if err != nil {
+			return nil, err
+		}
+		for _, s := range samples {
+			lastSequence = s.Sequence
+			price := s.price()
+			if price == nil {
+				continue
+			}
+			bucketStart := start + tktypes.Timestamp(int64(s.Created-start)/int64(bucket)*int64(bucket))
+			a := aggregates[bucketStart]
+			if a == nil {
+				bucketEnd := bucketStart + tktypes.Timestamp(bucket)
+				if bucketEnd > end {
+					bucketEnd = end
+				}
+				a = &aggregate{
+					entry: &pldapi.GasPriceHistoryEntry{Start: bucketStart, End: bucketEnd},
+					min:   price,
+					max:   price,
+					sum:   new(big.Int),
+				}
+				aggregates[bucketStart] = a
+			}
+			a.entry.Samples++
+			a.sum.Add(a.sum, price)
+			if price.Cmp(a.min) < 0 {
+				a.min = price
+			}
+			if price.Cmp(a.max) > 0 {
+				a.max = price
+			}
+		}
+		if len(samples) < ble.gasPriceHistoryPageSize {
+			break
+		}
+	}
+
+	entries := make([]*pldapi.GasPriceHistoryEntry, 0, len(aggregates))
+	for _, a := range aggregates {
+		a.entry.Min = (*tktypes.HexUint256)(a.min)
+		a.entry.Max = (*tktypes.HexUint256)(a.max)
+		a.entry.Avg = (*tktypes.HexUint256)(new(big.Int).Div(a.sum, big.NewInt(int64(a.entry.Samples))))
+		entries = append(entries, a.entry)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Start < entries[j].Start })
+	return entries, nil
+}
@@ -0,0 +1,188 @@
+/*
+ * Copyright © 2024 Kaleido, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with
+ * the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package publictxmgr
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/kaleido-io/paladin/config/pkg/confutil"
+	"github.com/kaleido-io/paladin/config/pkg/pldconf"
+	"github.com/kaleido-io/paladin/toolkit/pkg/tktypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestGasPriceHistoryManager(t *testing.T) (context.Context, *pubTxManager, func()) {
+	ctx, ble, _, done := newTestPublicTxManager(t, true, func(mocks *mocksAndTestControl, conf *pldconf.PublicTxManagerConfig) {
+		mocks.disableManagerStart = true
+		conf.GasPrice.FixedGasPrice = 1000
+		conf.GasPrice.History.Enabled = confutil.P(true)
+	})
+	return ctx, ble, done
+}
+
+func insertTestGasPriceSample(t *testing.T, ctx context.Context, ble *pubTxManager, created tktypes.Timestamp, gasPrice, maxFeePerGas int64) {
+	sample := &DBGasPriceSample{Created: created}
+	if gasPrice > 0 {
+		sample.GasPrice = (*tktypes.HexUint256)(big.NewInt(gasPrice))
+	}
+	if maxFeePerGas > 0 {
+		sample.MaxFeePerGas = (*tktypes.HexUint256)(big.NewInt(maxFeePerGas))
+	}
+	err := ble.p.DB().WithContext(ctx).Create(sample).Error
+	require.NoError(t, err)
+}
+
+func TestQueryGasPriceHistoryAggregates(t *testing.T) {
+	ctx, ble, done := newTestGasPriceHistoryManager(t)
+	defer done()
+
+	start := tktypes.Timestamp(time.Now().Add(-1 * time.Hour).Truncate(time.Hour).UnixNano())
+	minute := tktypes.Timestamp(time.Minute)
+
+	insertTestGasPriceSample(t, ctx, ble, start-minute, 1, 0) // before the range
+	// first 10m bucket
+	insertTestGasPriceSample(t, ctx, ble, start, 100, 0)
+	insertTestGasPriceSample(t, ctx, ble, start+1*minute, 300, 0)
+	insertTestGasPriceSample(t, ctx, ble, start+2*minute, 0, 201) // EIP-1559 max fee
+	// nothing in the second bucket, then two in the third
+	insertTestGasPriceSample(t, ctx, ble, start+25*minute, 50, 0)
+	insertTestGasPriceSample(t, ctx, ble, start+29*minute, 70, 0)
+	insertTestGasPriceSample(t, ctx, ble, start+30*minute, 999999, 0) // at the end of the range
+
+	entries, err := ble.QueryGasPriceHistory(ctx, ble.p.NOTX(), start, start+30*minute, 10*time.Minute)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+
+	assert.Equal(t, start, entries[0].Start)
+	assert.Equal(t, start+10*minute, entries[0].End)
+	assert.Equal(t, 3, entries[0].Samples)
+	assert.Equal(t, int64(100), entries[0].Min.Int().Int64())
+	assert.Equal(t, int64(300), entries[0].Max.Int().Int64())
+	assert.Equal(t, int64(200), entries[0].Avg.Int().Int64())
+
+	assert.Equal(t, start+20*minute, entries[1].Start)
+	assert.Equal(t, start+30*minute, entries[1].End)
+	assert.Equal(t, 2, entries[1].Samples)
+	assert.Equal(t, int64(50), entries[1].Min.Int().Int64())
+	assert.Equal(t, int64(70), entries[1].Max.Int().Int64())
+	assert.Equal(t, int64(60), entries[1].Avg.Int().Int64())
+
+	// zero bucket size aggregates the whole range
+	entries, err = ble.QueryGasPriceHistory(ctx, ble.p.NOTX(), start, start+30*minute, 0)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, 5, entries[0].Samples)
+	assert.Equal(t, int64(50), entries[0].Min.Int().Int64())
+	assert.Equal(t, int64(300), entries[0].Max.Int().Int64())
+	assert.Equal(t, int64(144), entries[0].Avg.Int().Int64())
+
+	// empty range
+	entries, err = ble.QueryGasPriceHistory(ctx, ble.p.NOTX(), start+40*minute, start+50*minute, time.Minute)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestQueryGasPriceHistoryPaged(t *testing.T) {
+	ctx, ble, done := newTestGasPriceHistoryManager(t)
+	defer done()
+	ble.gasPriceHistoryPageSize = 2
+
+	start := tktypes.Timestamp(time.Now().Add(-1 * time.Hour).Truncate(time.Hour).UnixNano())
+	minute := tktypes.Timestamp(time.Minute)
+
+	// inserted out of time order, so buckets are revisited across pages
+	insertTestGasPriceSample(t, ctx, ble, start+15*minute, 40, 0)
+	insertTestGasPriceSample(t, ctx, ble, start+1*minute, 10, 0)
+	insertTestGasPriceSample(t, ctx, ble, start-minute, 1, 0) // before the range
+	insertTestGasPriceSample(t, ctx, ble, start+12*minute, 20, 0)
+	insertTestGasPriceSample(t, ctx, ble, start+2*minute, 30, 0)
+
+	entries, err := ble.QueryGasPriceHistory(ctx, ble.p.NOTX(), start, start+20*minute, 10*time.Minute)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+
+	assert.Equal(t, start, entries[0].Start)
+	assert.Equal(t, 2, entries[0].Samples)
+	assert.Equal(t, int64(10), entries[0].Min.Int().Int64())
+	assert.Equal(t, int64(30), entries[0].Max.Int().Int64())
+	assert.Equal(t, int64(20), entries[0].Avg.Int().Int64())
+
+	assert.Equal(t, start+10*minute, entries[1].Start)
+	assert.Equal(t, 2, entries[1].Samples)
+	assert.Equal(t, int64(20), entries[1].Min.Int().Int64())
+	assert.Equal(t, int64(40), entries[1].Max.Int().Int64())
+	assert.Equal(t, int64(30), entries[1].Avg.Int().Int64())
+}
+
+func TestQueryGasPriceHistoryBadRange(t *testing.T) {
+	ctx, ble, done := newTestGasPriceHistoryManager(t)
+	defer done()
+
+	now := tktypes.TimestampNow()
+	_, err := ble.QueryGasPriceHistory(ctx, ble.p.NOTX(), now, now, time.Minute)
+	assert.Regexp(t, "PD011953.*end must be after start", err)
+
+	_, err = ble.QueryGasPriceHistory(ctx, ble.p.NOTX(), now, now+tktypes.Timestamp(24*time.Hour), time.Second)
+	assert.Regexp(t, "PD011953.*too many buckets", err)
+}
+
+func TestRecordAndPurgeGasPriceHistory(t *testing.T) {
+	ctx, ble, done := newTestGasPriceHistoryManager(t)
+	defer done()
+
+	old := time.Now().Add(-2 * time.Hour)
+	insertTestGasPriceSample(t, ctx, ble, tktypes.Timestamp(old.UnixNano()), 10, 0)
+	insertTestGasPriceSample(t, ctx, ble, tktypes.Timestamp(old.Add(time.Minute).UnixNano()), 20, 0)
+
+	now := time.Now()
+	err := ble.recordGasPriceSample(ctx, now)
+	require.NoError(t, err)
+
+	purged, err := ble.purgeGasPriceHistory(ctx, now.Add(-1*time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), purged)
+
+	var samples []*DBGasPriceSample
+	err = ble.p.DB().WithContext(ctx).Find(&samples).Error
+	require.NoError(t, err)
+	require.Len(t, samples, 1)
+	assert.Equal(t, tktypes.Timestamp(now.UnixNano()), samples[0].Created)
+	assert.Equal(t, int64(1000), samples[0].GasPrice.Int().Int64())
+}
+
+func TestGasPriceHistoryLoopStartStop(t *testing.T) {
+	ctx, ble, done := newTestGasPriceHistoryManager(t)
+
+	insertTestGasPriceSample(t, ctx, ble, tktypes.Timestamp(time.Now().Add(-2*time.Hour).UnixNano()), 10, 0)
+
+	ble.gasPriceHistoryRetention = 1 * time.Hour
+	ble.gasPriceHistoryLoopDone = make(chan struct{})
+	go ble.gasPriceHistoryLoop()
+
+	assert.Eventually(t, func() bool {
+		var samples []*DBGasPriceSample
+		err := ble.p.DB().WithContext(ctx).Find(&samples).Error
+		require.NoError(t, err)
+		return len(samples) == 1 && samples[0].GasPrice.Int().Int64() == 1000
+	}, 5*time.Second, 10*time.Millisecond)
+
+	done()
+	<-ble.gasPriceHistoryLoopDone
+}
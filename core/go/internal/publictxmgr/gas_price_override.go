@@ -0,0 +1,176 @@
+/*
+ * Copyright © 2024 Kaleido, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with
+ * the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package publictxmgr
+
+import (
+	"context"
+	"math/big"
+	"time"
+
+	"github.com/kaleido-io/paladin/config/pkg/confutil"
+	"github.com/kaleido-io/paladin/config/pkg/pldconf"
+	"github.com/kaleido-io/paladin/core/internal/msgs"
+	"github.com/kaleido-io/paladin/toolkit/pkg/i18n"
+	"github.com/kaleido-io/paladin/toolkit/pkg/pldapi"
+	"github.com/kaleido-io/paladin/toolkit/pkg/tktypes"
+)
+
+// The gas price client is shared across all signing addresses. Overrides allow individual
+// signers to bid higher (latency critical) or lower (background) than the shared suggestion.
+type gasPriceOverride struct {
+	multiplier float64
+	floor      *big.Int
+	ceiling    *big.Int
+
+	// only set for the economy strategy, which escalates as the transaction ages
+	escalateTo   float64
+	escalateFrom time.Duration
+	deadline     time.Duration
+}
+
+func parseGasPriceOverrides(ctx context.Context, conf map[string]pldconf.GasPriceSignerOverrideConfig, strategies *pldconf.GasPriceStrategiesConfig) (map[tktypes.EthAddress]*gasPriceOverride, error) {
+	defaults := &pldconf.PublicTxManagerDefaults.GasPrice.Strategies
+	overrides := make(map[tktypes.EthAddress]*gasPriceOverride, len(conf))
+	for addrStr, oc := range conf {
+		addr, err := tktypes.ParseEthAddress(addrStr)
+		if err != nil {
+			return nil, i18n.WrapError(ctx, err, msgs.MsgInvalidGasPriceSignerOverride, addrStr)
+		}
+		o := &gasPriceOverride{
+			multiplier: confutil.Float64Min(oc.Multiplier, 0, 1.0),
+		}
+		if oc.Strategy != nil {
+			if oc.Multiplier != nil {
+				return nil, i18n.NewError(ctx, msgs.MsgInvalidGasPriceSignerOverride, addrStr)
+			}
+			switch *oc.Strategy {
+			case pldconf.GasPriceStrategyAggressive:
+				o.multiplier = confutil.Float64Min(strategies.Aggressive, 0, *defaults.Aggressive)
+			case pldconf.GasPriceStrategyNormal:
+				o.multiplier = confutil.Float64Min(strategies.Normal, 0, *defaults.Normal)
+			case pldconf.GasPriceStrategyEconomy:
+				o.multiplier = confutil.Float64Min(strategies.Economy, 0, *defaults.Economy)
+				o.escalateTo = confutil.Float64Min(strategies.Aggressive, 0, *defaults.Aggressive)
+				o.deadline = confutil.DurationMin(strategies.EconomyDeadline, 0, *defaults.EconomyDeadline)
+				o.escalateFrom = o.deadline - confutil.DurationMin(strategies.EconomyEscalation, 0, *defaults.EconomyEscalation)
+				if o.escalateFrom < 0 {
+					o.escalateFrom = 0
+				}
+			default:
+				return nil, i18n.NewError(ctx, msgs.MsgInvalidGasPriceSignerOverride, addrStr)
+			}
+		}
+		if oc.Floor != nil {
+			if o.floor = confutil.BigIntOrNil(oc.Floor); o.floor == nil {
+				return nil, i18n.NewError(ctx, msgs.MsgInvalidGasPriceSignerOverride, addrStr)
+			}
+		}
+		if oc.Ceiling != nil {
+			if o.ceiling = confutil.BigIntOrNil(oc.Ceiling); o.ceiling == nil {
+				return nil, i18n.NewError(ctx, msgs.MsgInvalidGasPriceSignerOverride, addrStr)
+			}
+		}
+		if o.floor != nil && o.ceiling != nil && o.floor.Cmp(o.ceiling) > 0 {
+			return nil, i18n.NewError(ctx, msgs.MsgInvalidGasPriceSignerOverride, addrStr)
+		}
+		overrides[*addr] = o
+	}
+	return overrides, nil
+}
+
+// Returns a new gas price object with the override applied - the input is not modified,
+// as it might be shared via the gas price cache.
+func (o *gasPriceOverride) apply(gpo *pldapi.PublicTxGasPricing) *pldapi.PublicTxGasPricing {
+	return o.applyForAge(gpo, 0)
+}
+
+// As apply, for a transaction that was created the given time ago
+func (o *gasPriceOverride) applyForAge(gpo *pldapi.PublicTxGasPricing, age time.Duration) *pldapi.PublicTxGasPricing {
+	if o == nil || gpo == nil {
+		return gpo
+	}
+	multiplier := o.multiplierForAge(age)
+	adjusted := &pldapi.PublicTxGasPricing{
+		GasPrice:             o.adjust(gpo.GasPrice, multiplier, true),
+		MaxFeePerGas:         o.adjust(gpo.MaxFeePerGas, multiplier, true),
+		MaxPriorityFeePerGas: o.adjust(gpo.MaxPriorityFeePerGas, multiplier, false),
+	}
+	// the priority fee can never exceed the max fee
+	if adjusted.MaxFeePerGas != nil && adjusted.MaxPriorityFeePerGas != nil &&
+		adjusted.MaxPriorityFeePerGas.Int().Cmp(adjusted.MaxFeePerGas.Int()) > 0 {
+		adjusted.MaxPriorityFeePerGas = adjusted.MaxFeePerGas
+	}
+	return adjusted
+}
+
+// The ceiling is a hard limit for the signer, so it also applies to prices that do not come from
+// the shared suggestion - fixed pricing on a submission, and the increase on each resubmission.
+// Returns the input unmodified if it is within the ceiling.
+func (o *gasPriceOverride) clampToCeiling(gpo *pldapi.PublicTxGasPricing) *pldapi.PublicTxGasPricing {
+	if o == nil || o.ceiling == nil || gpo == nil {
+		return gpo
+	}
+	overCeiling := func(v *tktypes.HexUint256) bool { return v != nil && v.Int().Cmp(o.ceiling) > 0 }
+	if !overCeiling(gpo.GasPrice) && !overCeiling(gpo.MaxFeePerGas) && !overCeiling(gpo.MaxPriorityFeePerGas) {
+		return gpo
+	}
+	clamped := *gpo
+	for _, v := range []**tktypes.HexUint256{&clamped.GasPrice, &clamped.MaxFeePerGas, &clamped.MaxPriorityFeePerGas} {
+		if overCeiling(*v) {
+			*v = (*tktypes.HexUint256)(new(big.Int).Set(o.ceiling))
+		}
+	}
+	return &clamped
+}
+
+// The multiplier rises linearly from the economy multiplier to the escalation target between
+// the start of the escalation period and the deadline, then stays at the target
+func (o *gasPriceOverride) multiplierForAge(age time.Duration) float64 {
+	if o.escalateTo == 0 || age <= o.escalateFrom {
+		return o.multiplier
+	}
+	if age >= o.deadline {
+		return o.escalateTo
+	}
+	progress := float64(age-o.escalateFrom) / float64(o.deadline-o.escalateFrom)
+	return o.multiplier + (o.escalateTo-o.multiplier)*progress
+}
+
+func (o *gasPriceOverride) adjust(v *tktypes.HexUint256, multiplier float64, clamp bool) *tktypes.HexUint256 {
+	if v == nil {
+		return nil
+	}
+	bi := new(big.Int).Set(v.Int())
+	if multiplier != 1.0 {
+		bi, _ = new(big.Float).Mul(new(big.Float).SetInt(bi), big.NewFloat(multiplier)).Int(nil)
+	}
+	if clamp {
+		if o.floor != nil && bi.Cmp(o.floor) < 0 {
+			bi.Set(o.floor)
+		}
+		if o.ceiling != nil && bi.Cmp(o.ceiling) > 0 {
+			bi.Set(o.ceiling)
+		}
+	}
+	return (*tktypes.HexUint256)(bi)
+}
+
+func (o *gasPriceOverride) effectiveMultiplier() float64 {
+	if o == nil {
+		return 1.0
+	}
+	return o.multiplier
+}
@@ -0,0 +1,336 @@
+/*
+ * Copyright © 2024 Kaleido, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with
+ * the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package publictxmgr
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/firefly-common/pkg/fftypes"
+	"github.com/kaleido-io/paladin/config/pkg/confutil"
+	"github.com/kaleido-io/paladin/config/pkg/pldconf"
+	"github.com/kaleido-io/paladin/core/internal/components"
+	"github.com/kaleido-io/paladin/core/pkg/persistence"
+	"github.com/kaleido-io/paladin/toolkit/pkg/pldapi"
+	"github.com/kaleido-io/paladin/toolkit/pkg/tktypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGasPriceOverridesPerSigner(t *testing.T) {
+	fastAddr := tktypes.RandAddress()
+	slowAddr := tktypes.RandAddress()
+	defaultAddr := tktypes.RandAddress()
+
+	ctx, ble, _, done := newTestPublicTxManager(t, false, func(mocks *mocksAndTestControl, conf *pldconf.PublicTxManagerConfig) {
+		mocks.disableManagerStart = true
+		conf.GasPrice.FixedGasPrice = "1000000000"
+		conf.GasPrice.SignerOverrides = map[string]pldconf.GasPriceSignerOverrideConfig{
+			fastAddr.String(): {Multiplier: confutil.P(1.5)},
+			slowAddr.String(): {Multiplier: confutil.P(0.5), Floor: confutil.P("600000000")},
+		}
+	})
+	defer done()
+
+	fast := NewOrchestrator(ble, *fastAddr, ble.conf)
+	slow := NewOrchestrator(ble, *slowAddr, ble.conf)
+	dflt := NewOrchestrator(ble, *defaultAddr, ble.conf)
+
+	base, err := ble.gasPriceClient.GetGasPriceObject(ctx)
+	require.NoError(t, err)
+
+	assert.Equal(t, "1500000000", fast.gasPriceOverride.apply(base).GasPrice.Int().String())
+	assert.Equal(t, "600000000", slow.gasPriceOverride.apply(base).GasPrice.Int().String())
+	assert.Equal(t, base, dflt.gasPriceOverride.apply(base))
+	// shared base is untouched
+	assert.Equal(t, "1000000000", base.GasPrice.Int().String())
+
+	assert.Equal(t, 1.5, fast.snapshot().GasPriceMultiplier)
+	assert.True(t, fast.snapshot().GasPriceOverridden)
+	assert.Equal(t, 0.5, slow.snapshot().GasPriceMultiplier)
+	assert.Equal(t, 1.0, dflt.snapshot().GasPriceMultiplier)
+	assert.False(t, dflt.snapshot().GasPriceOverridden)
+}
+
+func TestGasPriceOverridesIgnoredOnZeroGasPriceChain(t *testing.T) {
+	addr := tktypes.RandAddress()
+	_, ble, _, done := newTestPublicTxManager(t, false, func(mocks *mocksAndTestControl, conf *pldconf.PublicTxManagerConfig) {
+		mocks.disableManagerStart = true
+		conf.GasPrice.SignerOverrides = map[string]pldconf.GasPriceSignerOverrideConfig{
+			addr.String(): {Floor: confutil.P("100")},
+		}
+	})
+	defer done()
+
+	o := NewOrchestrator(ble, *addr, ble.conf)
+	assert.Nil(t, o.gasPriceOverride)
+}
+
+func TestGasPriceOverrideEIP1559(t *testing.T) {
+	o := &gasPriceOverride{multiplier: 2.0, ceiling: tktypes.Uint64ToUint256(300).Int()}
+	adjusted := o.apply(&pldapi.PublicTxGasPricing{
+		MaxFeePerGas:         tktypes.Uint64ToUint256(200),
+		MaxPriorityFeePerGas: tktypes.Uint64ToUint256(190),
+	})
+	assert.Nil(t, adjusted.GasPrice)
+	assert.Equal(t, uint64(300), adjusted.MaxFeePerGas.Int().Uint64())
+	// priority fee is not clamped by the ceiling, but cannot exceed the max fee
+	assert.Equal(t, uint64(300), adjusted.MaxPriorityFeePerGas.Int().Uint64())
+
+	var nilOverride *gasPriceOverride
+	assert.Nil(t, nilOverride.apply(nil))
+}
+
+func TestParseGasPriceOverridesErrors(t *testing.T) {
+	ctx := context.Background()
+	addr := tktypes.RandAddress().String()
+
+	_, err := parseGasPriceOverrides(ctx, map[string]pldconf.GasPriceSignerOverrideConfig{
+		"not an address": {},
+	}, &pldconf.GasPriceStrategiesConfig{})
+	assert.Regexp(t, "PD011937", err)
+
+	_, err = parseGasPriceOverrides(ctx, map[string]pldconf.GasPriceSignerOverrideConfig{
+		addr: {Floor: confutil.P("wrong")},
+	}, &pldconf.GasPriceStrategiesConfig{})
+	assert.Regexp(t, "PD011937", err)
+
+	_, err = parseGasPriceOverrides(ctx, map[string]pldconf.GasPriceSignerOverrideConfig{
+		addr: {Ceiling: confutil.P("wrong")},
+	}, &pldconf.GasPriceStrategiesConfig{})
+	assert.Regexp(t, "PD011937", err)
+
+	_, err = parseGasPriceOverrides(ctx, map[string]pldconf.GasPriceSignerOverrideConfig{
+		addr: {Floor: confutil.P("200"), Ceiling: confutil.P("100")},
+	}, &pldconf.GasPriceStrategiesConfig{})
+	assert.Regexp(t, "PD011937", err)
+
+	_, err = parseGasPriceOverrides(ctx, map[string]pldconf.GasPriceSignerOverrideConfig{
+		addr: {Strategy: confutil.P("wrong")},
+	}, &pldconf.GasPriceStrategiesConfig{})
+	assert.Regexp(t, "PD011937", err)
+
+	_, err = parseGasPriceOverrides(ctx, map[string]pldconf.GasPriceSignerOverrideConfig{
+		addr: {Strategy: confutil.P(pldconf.GasPriceStrategyAggressive), Multiplier: confutil.P(2.0)},
+	}, &pldconf.GasPriceStrategiesConfig{})
+	assert.Regexp(t, "PD011937", err)
+}
+
+func TestGasPriceStrategies(t *testing.T) {
+	ctx := context.Background()
+	aggressiveAddr := tktypes.RandAddress()
+	normalAddr := tktypes.RandAddress()
+	economyAddr := tktypes.RandAddress()
+	tunedAddr := tktypes.RandAddress()
+
+	overrides, err := parseGasPriceOverrides(ctx, map[string]pldconf.GasPriceSignerOverrideConfig{
+		aggressiveAddr.String(): {Strategy: confutil.P(pldconf.GasPriceStrategyAggressive)},
+		normalAddr.String():     {Strategy: confutil.P(pldconf.GasPriceStrategyNormal)},
+		economyAddr.String():    {Strategy: confutil.P(pldconf.GasPriceStrategyEconomy)},
+		tunedAddr.String():      {Strategy: confutil.P(pldconf.GasPriceStrategyAggressive), Ceiling: confutil.P("1800")},
+	}, &pldconf.GasPriceStrategiesConfig{})
+	require.NoError(t, err)
+
+	base := &pldapi.PublicTxGasPricing{GasPrice: tktypes.Uint64ToUint256(1000)}
+	assert.Equal(t, uint64(1500), overrides[*aggressiveAddr].apply(base).GasPrice.Int().Uint64())
+	assert.Equal(t, uint64(1000), overrides[*normalAddr].apply(base).GasPrice.Int().Uint64())
+	assert.Equal(t, uint64(800), overrides[*economyAddr].apply(base).GasPrice.Int().Uint64())
+	assert.Equal(t, uint64(1500), overrides[*tunedAddr].apply(base).GasPrice.Int().Uint64())
+
+	// Multipliers of the strategies are configurable
+	overrides, err = parseGasPriceOverrides(ctx, map[string]pldconf.GasPriceSignerOverrideConfig{
+		aggressiveAddr.String(): {Strategy: confutil.P(pldconf.GasPriceStrategyAggressive), Ceiling: confutil.P("1800")},
+	}, &pldconf.GasPriceStrategiesConfig{Aggressive: confutil.P(2.0)})
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1800), overrides[*aggressiveAddr].apply(base).GasPrice.Int().Uint64())
+}
+
+func TestGasPriceEconomyEscalatesNearDeadline(t *testing.T) {
+	ctx := context.Background()
+	addr := tktypes.RandAddress()
+
+	overrides, err := parseGasPriceOverrides(ctx, map[string]pldconf.GasPriceSignerOverrideConfig{
+		addr.String(): {Strategy: confutil.P(pldconf.GasPriceStrategyEconomy)},
+	}, &pldconf.GasPriceStrategiesConfig{
+		Aggressive:        confutil.P(2.0),
+		Economy:           confutil.P(0.5),
+		EconomyDeadline:   confutil.P("10m"),
+		EconomyEscalation: confutil.P("4m"),
+	})
+	require.NoError(t, err)
+	o := overrides[*addr]
+
+	base := &pldapi.PublicTxGasPricing{
+		MaxFeePerGas:         tktypes.Uint64ToUint256(1000),
+		MaxPriorityFeePerGas: tktypes.Uint64ToUint256(100),
+	}
+	effective := func(age time.Duration) uint64 {
+		return o.applyForAge(base, age).MaxFeePerGas.Int().Uint64()
+	}
+	assert.Equal(t, uint64(500), effective(0))
+	assert.Equal(t, uint64(500), effective(6*time.Minute))  // escalation starts
+	assert.Equal(t, uint64(1250), effective(8*time.Minute)) // half way to the deadline
+	assert.Equal(t, uint64(2000), effective(10*time.Minute))
+	assert.Equal(t, uint64(2000), effective(1*time.Hour))
+	assert.Equal(t, uint64(200), o.applyForAge(base, 1*time.Hour).MaxPriorityFeePerGas.Int().Uint64())
+
+	// The base multiplier is reported
+	assert.Equal(t, 0.5, o.effectiveMultiplier())
+
+	// An escalation period longer than the deadline starts immediately
+	overrides, err = parseGasPriceOverrides(ctx, map[string]pldconf.GasPriceSignerOverrideConfig{
+		addr.String(): {Strategy: confutil.P(pldconf.GasPriceStrategyEconomy)},
+	}, &pldconf.GasPriceStrategiesConfig{
+		EconomyDeadline:   confutil.P("1m"),
+		EconomyEscalation: confutil.P("1h"),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), int64(overrides[*addr].escalateFrom))
+	assert.Greater(t, overrides[*addr].multiplierForAge(30*time.Second), 0.8)
+}
+
+func TestGasPriceCeilingAppliesToFixedPricingAndIncreases(t *testing.T) {
+	cappedAddr := tktypes.RandAddress()
+
+	ctx, ble, _, done := newTestPublicTxManager(t, false, func(mocks *mocksAndTestControl, conf *pldconf.PublicTxManagerConfig) {
+		mocks.disableManagerStart = true
+		conf.GasPrice.FixedGasPrice = "500"
+		conf.GasPrice.IncreasePercentage = confutil.P(50)
+		conf.GasPrice.SignerOverrides = map[string]pldconf.GasPriceSignerOverrideConfig{
+			cappedAddr.String(): {Ceiling: confutil.P("1000")},
+		}
+	})
+	defer done()
+
+	o := NewOrchestrator(ble, *cappedAddr, ble.conf)
+	it, _ := newInflightTransaction(o, 1)
+
+	// fixed pricing on a submission is capped
+	fixed := &pldapi.PublicTxGasPricing{
+		MaxFeePerGas:         tktypes.Uint64ToUint256(2000),
+		MaxPriorityFeePerGas: tktypes.Uint64ToUint256(100),
+	}
+	capped := o.gasPriceOverride.clampToCeiling(fixed)
+	assert.Equal(t, "1000", capped.MaxFeePerGas.Int().String())
+	assert.Equal(t, "100", capped.MaxPriorityFeePerGas.Int().String())
+	assert.Equal(t, "2000", fixed.MaxFeePerGas.Int().String()) // input untouched
+	assert.Same(t, capped, o.gasPriceOverride.clampToCeiling(capped))
+
+	// the increase on resubmission stops at the ceiling
+	gpo := it.calculateNewGasPrice(ctx,
+		&pldapi.PublicTxGasPricing{GasPrice: tktypes.Uint64ToUint256(800)},
+		&pldapi.PublicTxGasPricing{GasPrice: tktypes.Uint64ToUint256(500)})
+	assert.Equal(t, "1000", gpo.GasPrice.Int().String())
+
+	// as does a first assignment
+	gpo = it.calculateNewGasPrice(ctx, nil, &pldapi.PublicTxGasPricing{GasPrice: tktypes.Uint64ToUint256(5000)})
+	assert.Equal(t, "1000", gpo.GasPrice.Int().String())
+
+	// signers without a ceiling are unaffected
+	var noOverride *gasPriceOverride
+	assert.Same(t, fixed, noOverride.clampToCeiling(fixed))
+}
+
+func TestGasPriceCeilingNotAppliedOnZeroGasPriceChain(t *testing.T) {
+	cappedAddr := tktypes.RandAddress()
+
+	ctx, ble, _, done := newTestPublicTxManager(t, true, func(mocks *mocksAndTestControl, conf *pldconf.PublicTxManagerConfig) {
+		mocks.disableManagerStart = true
+		conf.GasPrice.FixedGasPrice = 0
+		conf.GasPrice.SignerOverrides = map[string]pldconf.GasPriceSignerOverrideConfig{
+			cappedAddr.String(): {Ceiling: confutil.P("1000")},
+		}
+	})
+	defer done()
+	require.True(t, ble.gasPriceClient.HasZeroGasPrice(ctx))
+
+	var ptxs []*pldapi.PublicTx
+	err := ble.p.Transaction(ctx, func(ctx context.Context, dbTX persistence.DBTX) (err error) {
+		ptxs, err = ble.WriteNewTransactions(ctx, dbTX, []*components.PublicTxSubmission{{
+			PublicTxInput: pldapi.PublicTxInput{
+				From: cappedAddr,
+				PublicTxOptions: pldapi.PublicTxOptions{
+					Gas: confutil.P(tktypes.HexUint64(100000)),
+					PublicTxGasPricing: pldapi.PublicTxGasPricing{
+						GasPrice: tktypes.Uint64ToUint256(2000),
+					},
+				},
+			},
+		}})
+		return err
+	})
+	require.NoError(t, err)
+
+	var stored []*DBPublicTxn
+	err = ble.p.DB().Table("public_txns").Where(`"pub_txn_id" = ?`, *ptxs[0].LocalID).Find(&stored).Error
+	require.NoError(t, err)
+	require.Len(t, stored, 1)
+	assert.Equal(t, "2000", recoverGasPriceOptions(stored[0].FixedGasPricing).GasPrice.Int().String())
+}
+
+func TestGasPriceResubmissionSkippedAtCeiling(t *testing.T) {
+	ctx, o, _, done := newTestOrchestrator(t, func(mocks *mocksAndTestControl, conf *pldconf.PublicTxManagerConfig) {
+		conf.GasPrice.IncreasePercentage = confutil.P(50)
+	})
+	defer done()
+	fc := newFakeClock()
+	o.clock = fc
+	o.gasPriceOverride = &gasPriceOverride{multiplier: 1.0, ceiling: big.NewInt(1000)}
+
+	it, mTS := newInflightTransaction(o, 1, func(tx *DBPublicTxn) {
+		tx.Submissions = []*DBPubTxnSubmission{{
+			TransactionHash: tktypes.RandBytes32(),
+			Created:         tktypes.Timestamp(fc.Now().UnixNano()),
+			GasPricing:      tktypes.JSONString(&pldapi.PublicTxGasPricing{GasPrice: tktypes.Uint64ToUint256(1000)}),
+		}}
+	})
+	it.testOnlyNoActionMode = true
+	it.testOnlyNoEventMode = true
+	mTS.statusUpdater = &mockStatusUpdater{
+		updateSubStatus: func(ctx context.Context, imtx InMemoryTxStateReadOnly, subStatus BaseTxSubStatus, action BaseTxAction, info, err *fftypes.JSONAny, actionOccurred *tktypes.Timestamp) error {
+			return nil
+		},
+	}
+	it.stateManager.SetValidatedTransactionHashMatchState(ctx, true)
+
+	// stale, so the gas price is retrieved for a resubmission
+	fc.Advance(it.resubmitInterval + time.Second)
+	_ = it.ProduceLatestInFlightStageContext(ctx, &OrchestratorContext{PreviousNonceCostUnknown: true})
+	rsc := it.stateManager.GetRunningStageContext(ctx)
+	require.NotNil(t, rsc)
+	assert.Equal(t, InFlightTxStageRetrieveGasPrice, rsc.Stage)
+
+	// the increase is clamped back to the price already submitted, so nothing is persisted or re-signed
+	it.stateManager.AddGasPriceOutput(ctx, &pldapi.PublicTxGasPricing{GasPrice: tktypes.Uint64ToUint256(800)}, nil)
+	_ = it.ProduceLatestInFlightStageContext(ctx, &OrchestratorContext{PreviousNonceCostUnknown: true})
+	assert.Nil(t, it.stateManager.GetRunningStageContext(ctx))
+	assert.Nil(t, rsc.StageOutputsToBePersisted.TxUpdates)
+	assert.True(t, it.stateManager.ValidatedTransactionHashMatchState(ctx))
+	assert.NotNil(t, it.resubmitSkippedAt)
+
+	// not retried until another resubmit interval has passed
+	fc.Advance(it.resubmitInterval / 2)
+	_ = it.ProduceLatestInFlightStageContext(ctx, &OrchestratorContext{PreviousNonceCostUnknown: true})
+	assert.Nil(t, it.stateManager.GetRunningStageContext(ctx))
+
+	fc.Advance(it.resubmitInterval)
+	_ = it.ProduceLatestInFlightStageContext(ctx, &OrchestratorContext{PreviousNonceCostUnknown: true})
+	rsc = it.stateManager.GetRunningStageContext(ctx)
+	require.NotNil(t, rsc)
+	assert.Equal(t, InFlightTxStageRetrieveGasPrice, rsc.Stage)
+}
@@ -25,6 +25,7 @@ import (
 	"time"
 
 	"github.com/hyperledger/firefly-common/pkg/fftypes"
+	"github.com/hyperledger/firefly-signer/pkg/ethsigner"
 	"github.com/kaleido-io/paladin/config/pkg/confutil"
 	"github.com/kaleido-io/paladin/core/internal/msgs"
 	"github.com/kaleido-io/paladin/core/pkg/ethclient"
@@ -72,6 +73,17 @@ type inFlightTransactionStageController struct {
 
 	newStatus *InFlightStatus
 
+	// consecutive failures to start a stage, and when we are next allowed to retry
+	stageTriggerFailures   int
+	stageTriggerRetryAfter *time.Time
+
+	// set while signing is held back because the signer reported unhealthy
+	heldForUnhealthySigner bool
+
+	// when a resubmission was last skipped because the gas price could not be increased, so the next
+	// attempt waits for another resubmit interval
+	resubmitSkippedAt *time.Time
+
 	// deleteRequested bool // figure out what's the reliable approach for deletion
 }
 
@@ -201,10 +213,11 @@ func (it *inFlightTransactionStageController) ProduceLatestInFlightStageContext(
 		rsc := it.stateManager.GetRunningStageContext(ctx)
 		log.L(ctx).Debugf("ProduceLatestInFlightStageContext for tx %s, on stage: %s , current stage context lived: %s , stage lived: %s, last stage error: %+v", it.stateManager.GetSignerNonce(), it.stateManager.GetStage(ctx), time.Since(rsc.StageStartTime), time.Since(it.stateManager.GetStageStartTime(ctx)), it.stateManager.GetStageTriggerError(ctx))
 		// once we have a running context, all the metadata should already be loaded
-		if it.stateManager.GetStageTriggerError(ctx) != nil {
-			log.L(ctx).Errorf("Failed to trigger sate due to %+v, cleaning up the context and retry", it.stateManager.GetStageTriggerError(ctx))
-			it.stateManager.ClearRunningStageContext(ctx)
+		if triggerErr := it.stateManager.GetStageTriggerError(ctx); triggerErr != nil {
+			it.handleStageTriggerError(ctx, rsc.Stage, triggerErr, tOut)
 		} else {
+			// the stage was started successfully
+			it.stageTriggerFailures = 0
 			// there is a running stage waiting for inputs
 			// first of checking the inputs to see whether we have new items to process
 			it.stateManager.ProcessStageOutputs(ctx, func(stageOutputs []*StageOutput) (unprocessedStageOutputs []*StageOutput) {
@@ -250,7 +263,18 @@ func (it *inFlightTransactionStageController) ProduceLatestInFlightStageContext(
 									// if failed to get gas price, persist the error
 									rsc.StageOutputsToBePersisted.UpdateSubStatus(BaseTxActionRetrieveGasPrice, nil, fftypes.JSONAnyPtr(`{"error":"`+rsIn.GasPriceOutput.Err.Error()+`"}`))
 								} else {
-									gpo := it.calculateNewGasPrice(ctx, rsc.InMemoryTx.GetGasPriceObject(), rsIn.GasPriceOutput.GasPriceObject)
+									existingGpo := rsc.InMemoryTx.GetGasPriceObject()
+									gpo := it.calculateNewGasPrice(ctx, existingGpo, rsIn.GasPriceOutput.GasPriceObject)
+									if existingGpo != nil && it.stateManager.GetTransactionHash() != nil && gasPricingEqual(gpo, existingGpo) {
+										// the increase is capped (by the ceiling for the signer, or the maximum increase) at the price already submitted,
+										// so a resubmission would not replace the transaction
+										log.L(ctx).Infof("Transaction with ID %s not resubmitted, as the gas price cannot be increased beyond %+v", rsc.InMemoryTx.GetSignerNonce(), gpo)
+										it.addActivityRecord(it.stateManager.GetPubTxnID(), i18n.ExpandWithCode(ctx, i18n.MessageKey(msgs.MsgPublicTxResubmitSkippedAtMaxGasPrice), it.resubmitInterval.String()))
+										skippedAt := it.clock.Now()
+										it.resubmitSkippedAt = &skippedAt
+										it.stateManager.ClearRunningStageContext(ctx)
+										continue
+									}
 									gpoJSON, _ := json.Marshal(gpo)
 									rsc.StageOutputsToBePersisted.TxUpdates = &BaseTXUpdates{GasPricing: gpo}
 									rsc.StageOutputsToBePersisted.UpdateSubStatus(BaseTxActionRetrieveGasPrice, fftypes.JSONAnyPtr(string(gpoJSON)), nil)
@@ -294,6 +318,14 @@ func (it *inFlightTransactionStageController) ProduceLatestInFlightStageContext(
 									// persist the error
 									log.L(ctx).Errorf("Transaction signing failed for transaction with ID: %s, due to error: %+v", rsc.InMemoryTx.GetSignerNonce(), rsIn.SignOutput.Err)
 									rsc.StageOutputsToBePersisted.UpdateSubStatus(BaseTxActionSign, nil, fftypes.JSONAnyPtr(`{"error":"`+rsIn.SignOutput.Err.Error()+`"}`))
+									failureCategory := pldapi.PublicTxFailureSignerUnavailable
+									if isPermanentKeyError(rsIn.SignOutput.Err) {
+										failureCategory = pldapi.PublicTxFailureSignerKeyLost
+										it.handleSignerKeyLost(ctx, rsIn.SignOutput.Err)
+									}
+									rsc.StageOutputsToBePersisted.TxUpdates = &BaseTXUpdates{
+										FailureCategory: confutil.P(failureCategory),
+									}
 								} else {
 									log.L(ctx).Tracef("SignOutput %+v", rsIn.SignOutput)
 									// signed data received
@@ -301,6 +333,12 @@ func (it *inFlightTransactionStageController) ProduceLatestInFlightStageContext(
 										// signed message can be nil when no signer is configured
 										rsc.StageOutputsToBePersisted.UpdateSubStatus(BaseTxActionSign, fftypes.JSONAnyPtr(fmt.Sprintf(`{"hash":"%s"}`, rsIn.SignOutput.TxHash)), nil)
 									}
+									if rsIn.SignOutput.GasLimit != nil {
+										// the pre-sign hook changed the gas limit, which must be kept for future submissions
+										rsc.StageOutputsToBePersisted.TxUpdates = &BaseTXUpdates{
+											GasLimit: rsIn.SignOutput.GasLimit,
+										}
+									}
 								}
 
 								// Very important that we persist the transaction after SIGNING (and before SUBMISSION)
@@ -367,6 +405,9 @@ func (it *inFlightTransactionStageController) ProduceLatestInFlightStageContext(
 									rsc.StageOutputsToBePersisted.TxUpdates = &BaseTXUpdates{
 										ErrorMessage: &errMsg,
 									}
+									if failureCategory := mapFailureCategory(rsIn.SubmitOutput.Err); failureCategory != "" {
+										rsc.StageOutputsToBePersisted.TxUpdates.FailureCategory = &failureCategory
+									}
 									rsc.StageOutputsToBePersisted.UpdateSubStatus(BaseTxActionSubmitTransaction, fftypes.JSONAnyPtr(`{"reason":"`+string(rsIn.SubmitOutput.ErrorReason)+`"}`), fftypes.JSONAnyPtr(`{"error":"`+rsIn.SubmitOutput.Err.Error()+`"}`))
 									if rsc.InMemoryTx.GetTransactionHash() != nil {
 										// did a re-submission, no matter the result, update the last warn time to avoid another retry
@@ -384,9 +425,15 @@ func (it *inFlightTransactionStageController) ProduceLatestInFlightStageContext(
 									} else if rsIn.SubmitOutput.SubmissionOutcome == SubmissionOutcomeNonceTooLow {
 										log.L(ctx).Debugf("Nonce too low for tx %s (hash=%s)", rsc.InMemoryTx.GetSignerNonce(), rsc.InMemoryTx.GetTransactionHash())
 										rsc.StageOutputsToBePersisted.UpdateSubStatus(BaseTxActionSubmitTransaction, fftypes.JSONAnyPtr(`{"txHash":"`+rsIn.SubmitOutput.TxHash.String()+`"}`), nil)
+										rsc.StageOutputsToBePersisted.TxUpdates = &BaseTXUpdates{
+											FailureCategory: confutil.P(pldapi.PublicTxFailureNonceTooLow),
+										}
 									} else if rsIn.SubmitOutput.SubmissionOutcome == SubmissionOutcomeAlreadyKnown {
-										// nothing to add for persistence, go to the tracking stage
+										// the rebroadcast still counts as a submission, so the resubmit interval starts again
 										log.L(ctx).Debugf("Transaction already known for tx %s (hash=%s)", rsc.InMemoryTx.GetSignerNonce(), rsc.InMemoryTx.GetTransactionHash())
+										rsc.StageOutputsToBePersisted.TxUpdates = &BaseTXUpdates{
+											LastSubmit: rsIn.SubmitOutput.SubmissionTime,
+										}
 									}
 									// did the first submit
 									if rsc.InMemoryTx.GetFirstSubmit() == nil {
@@ -490,8 +537,10 @@ func (it *inFlightTransactionStageController) ProduceLatestInFlightStageContext(
 		} else if it.stateManager.GetTransactionHash() == nil {
 			if it.stateManager.CanSubmit(ctx, tOut.Cost) {
 				// no transaction hash, do signing and submission
-				log.L(ctx).Debugf("Transaction with ID %s entering signing stage as no transaction hash recorded.", it.stateManager.GetSignerNonce())
-				it.TriggerNewStageRun(ctx, InFlightTxStageSigning, BaseTxSubStatusReceived, nil)
+				if !it.holdForUnhealthySigner(ctx, tIn) {
+					log.L(ctx).Debugf("Transaction with ID %s entering signing stage as no transaction hash recorded.", it.stateManager.GetSignerNonce())
+					it.TriggerNewStageRun(ctx, InFlightTxStageSigning, BaseTxSubStatusReceived, nil)
+				}
 			} else {
 				log.L(ctx).Debugf("Transaction with ID %s no op, as cannot submit.", it.stateManager.GetSignerNonce())
 			}
@@ -500,15 +549,23 @@ func (it *inFlightTransactionStageController) ProduceLatestInFlightStageContext(
 			// the state we persisted by triggering a submission
 			if !it.stateManager.ValidatedTransactionHashMatchState(ctx) {
 				if it.stateManager.CanSubmit(ctx, tOut.Cost) {
-					log.L(ctx).Debugf("Transaction with ID %s entering signing stage as current state hasn't been validated.", it.stateManager.GetSignerNonce())
-					it.TriggerNewStageRun(ctx, InFlightTxStageSigning, BaseTxSubStatusReceived, nil)
+					if !it.holdForUnhealthySigner(ctx, tIn) {
+						log.L(ctx).Debugf("Transaction with ID %s entering signing stage as current state hasn't been validated.", it.stateManager.GetSignerNonce())
+						it.TriggerNewStageRun(ctx, InFlightTxStageSigning, BaseTxSubStatusReceived, nil)
+					}
 				} else {
 					log.L(ctx).Debugf("Transaction with ID %s no op, as cannot submit, state not validated.", it.stateManager.GetSignerNonce())
 				}
 			} else {
 				// once we validated the transaction hash matched the transaction state
 				lastSubmitTime := it.stateManager.GetLastSubmitTime()
-				if lastSubmitTime != nil && time.Since(lastSubmitTime.Time()) > it.resubmitInterval {
+				if it.resubmitIntervalElapsed(lastSubmitTime) && it.stateManager.GetRawTransaction() != nil {
+					// we cannot speed up a pre-signed transaction with a new gas price, so we re-send the same bytes
+					// and leave it to the submitter to replace the transaction if it is stuck
+					log.L(ctx).Debugf("Transaction with ID %s entering submitting stage with its pre-signed transaction as exceeded resubmit interval of %s.", it.stateManager.GetSignerNonce(), it.resubmitInterval.String())
+					it.addActivityRecord(it.stateManager.GetPubTxnID(), i18n.ExpandWithCode(ctx, i18n.MessageKey(msgs.MsgPublicTxRawTxSpeedUpSkipped), it.resubmitInterval.String()))
+					it.TriggerNewStageRun(ctx, InFlightTxStageSubmitting, BaseTxSubStatusStale, it.stateManager.GetRawTransaction())
+				} else if it.resubmitIntervalElapsed(lastSubmitTime) {
 					// do a resubmission when exceeded the resubmit interval
 					log.L(ctx).Debugf("Transaction with ID %s entering retrieve gas price as exceeded resubmit interval of %s.", it.stateManager.GetSignerNonce(), it.resubmitInterval.String())
 					it.TriggerNewStageRun(ctx, InFlightTxStageRetrieveGasPrice, BaseTxSubStatusStale, nil)
@@ -527,10 +584,94 @@ func (it *inFlightTransactionStageController) ProduceLatestInFlightStageContext(
 	return tOut
 }
 
+// resubmitIntervalElapsed applies the minimum interval between broadcasts of the same transaction, so an unconfirmed
+// transaction is not re-sent to the mempool on every poll cycle. Every submission, including a gas price increase,
+// records a new last submit time, so the interval starts again from each broadcast.
+func (it *inFlightTransactionStageController) resubmitIntervalElapsed(lastSubmitTime *tktypes.Timestamp) bool {
+	if it.resubmitSkippedAt != nil && it.clock.Since(*it.resubmitSkippedAt) <= it.resubmitInterval {
+		return false
+	}
+	return lastSubmitTime != nil && it.clock.Since(lastSubmitTime.Time()) > it.resubmitInterval
+}
+
+func gasPricingEqual(a, b *pldapi.PublicTxGasPricing) bool {
+	equal := func(x, y *tktypes.HexUint256) bool {
+		return (x == nil && y == nil) || (x != nil && y != nil && x.Int().Cmp(y.Int()) == 0)
+	}
+	return equal(a.GasPrice, b.GasPrice) && equal(a.MaxFeePerGas, b.MaxFeePerGas) && equal(a.MaxPriorityFeePerGas, b.MaxPriorityFeePerGas)
+}
+
+// holdForUnhealthySigner keeps the transaction pending, rather than spending the signing retries on failures,
+// while the signer for the address reports unhealthy. The hold and the resume are each recorded once in the
+// activity of the transaction. Pre-signed transactions do not need the signer, so are never held.
+func (it *inFlightTransactionStageController) holdForUnhealthySigner(ctx context.Context, tIn *OrchestratorContext) bool {
+	if tIn.SignerHealthCheck == nil || it.stateManager.GetRawTransaction() != nil {
+		return false
+	}
+	healthErr := tIn.SignerHealthCheck(ctx)
+	if healthErr == nil {
+		if it.heldForUnhealthySigner {
+			it.heldForUnhealthySigner = false
+			log.L(ctx).Infof("Transaction with ID %s resuming signing as the signer is healthy", it.stateManager.GetSignerNonce())
+			it.addActivityRecord(it.stateManager.GetPubTxnID(), i18n.ExpandWithCode(ctx, i18n.MessageKey(msgs.MsgPublicTxSignerHealthyResumed), it.signingAddress))
+		}
+		return false
+	}
+	if !it.heldForUnhealthySigner {
+		it.heldForUnhealthySigner = true
+		log.L(ctx).Warnf("Transaction with ID %s held from signing as the signer is unhealthy: %s", it.stateManager.GetSignerNonce(), healthErr)
+		it.thMetrics.RecordSignerUnhealthyHold(ctx)
+		it.addActivityRecord(it.stateManager.GetPubTxnID(), i18n.ExpandWithCode(ctx, i18n.MessageKey(msgs.MsgPublicTxSignerUnhealthyHold), it.signingAddress, healthErr.Error()))
+	}
+	return true
+}
+
+// handleStageTriggerError clears the running stage context so the stage is started again, backing off between
+// consecutive failures. Once the configured number of attempts is exhausted the failure is reported in the
+// output and the activity records of the transaction, and the transaction is suspended until it is resumed.
+func (it *inFlightTransactionStageController) handleStageTriggerError(ctx context.Context, stage InFlightTxStage, triggerErr error, tOut *TriggerNextStageOutput) {
+	if it.stageTriggerRetryAfter == nil {
+		// first time we've seen this failure
+		it.stageTriggerFailures++
+		retryAfter := time.Now().Add(it.stageTriggerRetry.Delay(it.stageTriggerFailures - 1))
+		it.stageTriggerRetryAfter = &retryAfter
+		if it.stageTriggerFailures >= it.stageTriggerMaxAttempts {
+			tOut.Error = i18n.WrapError(ctx, triggerErr, msgs.MsgStageTriggerRetriesExhausted, stage, it.stageTriggerFailures)
+			log.L(ctx).Errorf("Transaction with ID %s: %s", it.stateManager.GetSignerNonce(), tOut.Error)
+			it.addActivityRecord(it.stateManager.GetPubTxnID(), tOut.Error.Error())
+			it.suspendForStageTriggerFailure(ctx)
+			return
+		}
+	}
+	if time.Now().Before(*it.stageTriggerRetryAfter) {
+		log.L(ctx).Debugf("Transaction with ID %s waiting until %s to retry stage %s", it.stateManager.GetSignerNonce(), it.stageTriggerRetryAfter.Format(time.RFC3339Nano), stage)
+		return
+	}
+	log.L(ctx).Errorf("Failed to trigger stage %s due to %+v, cleaning up the context and retry", stage, triggerErr)
+	it.stageTriggerRetryAfter = nil
+	it.stateManager.ClearRunningStageContext(ctx)
+}
+
+// suspendForStageTriggerFailure persists the suspended flag, so the transaction is not picked up again
+// on the next poll, and then moves the in-flight transaction to suspending so it exits the orchestrator.
+// If we cannot persist the flag we keep retrying the stage, and try again after the next set of attempts.
+func (it *inFlightTransactionStageController) suspendForStageTriggerFailure(ctx context.Context) {
+	it.stageTriggerFailures = 0
+	it.stageTriggerRetryAfter = nil
+	if err := it.persistSuspendedFlag(ctx, it.signingAddress, it.stateManager.GetNonce(), true); err != nil {
+		log.L(ctx).Errorf("Failed to suspend transaction with ID %s after stage trigger failures: %s", it.stateManager.GetSignerNonce(), err)
+	} else {
+		it.addActivityRecord(it.stateManager.GetPubTxnID(), "Suspended after repeated failures to start a stage")
+		suspending := InFlightStatusSuspending
+		it.newStatus = &suspending // we already hold the transaction lock
+	}
+	it.stateManager.ClearRunningStageContext(ctx)
+}
+
 func (it *inFlightTransactionStageController) calculateNewGasPrice(ctx context.Context, existingGpo *pldapi.PublicTxGasPricing, newGpo *pldapi.PublicTxGasPricing) *pldapi.PublicTxGasPricing {
 	if existingGpo == nil {
 		log.L(ctx).Debugf("First time assigning gas price to transaction with ID: %s, gas price object: %+v.", it.stateManager.GetSignerNonce(), newGpo)
-		return newGpo
+		return it.gasPriceOverride.clampToCeiling(newGpo)
 	}
 
 	// The change is not made here to InMemoryTx, but rather pushed to TxUpdates for persisting.
@@ -567,7 +708,8 @@ func (it *inFlightTransactionStageController) calculateNewGasPrice(ctx context.C
 		}
 	}
 
-	return newGpo
+	// the increase must not take the price over the ceiling for the signer
+	return it.gasPriceOverride.clampToCeiling(newGpo)
 }
 
 func calculateGasRequiredForTransaction(ctx context.Context, gpo *pldapi.PublicTxGasPricing, gasLimit uint64) (gasRequired *big.Int, err error) {
@@ -606,6 +748,10 @@ func (it *inFlightTransactionStageController) NotifyStatusUpdate(ctx context.Con
 func (it *inFlightTransactionStageController) TriggerRetrieveGasPrice(ctx context.Context) error {
 	it.executeAsync(func() {
 		gasPrice, err := it.gasPriceClient.GetGasPriceObject(ctx)
+		if err == nil {
+			// economy transactions escalate as they age, so the age is taken from when the transaction was created
+			gasPrice = it.gasPriceOverride.applyForAge(gasPrice, it.clock.Since(it.stateManager.GetCreatedTime().Time()))
+		}
 		it.stateManager.AddGasPriceOutput(ctx, gasPrice, err)
 	}, ctx, it.stateManager.GetStage(ctx), false)
 	return nil
@@ -626,9 +772,22 @@ func (it *inFlightTransactionStageController) TriggerStatusUpdate(ctx context.Co
 }
 func (it *inFlightTransactionStageController) TriggerSignTx(ctx context.Context) error {
 	it.executeAsync(func() {
-		signedMessage, txHash, err := it.signTx(ctx, it.stateManager.GetFrom(), it.stateManager.BuildEthTX())
+		var signedMessage []byte
+		var txHash *tktypes.Bytes32
+		var gasLimit *uint64
+		var err error
+		if rawTx := it.stateManager.GetRawTransaction(); rawTx != nil {
+			// pre-signed by the submitter, so there is nothing for us to sign
+			signedMessage, txHash = rawTx, calculateTransactionHash(rawTx)
+		} else {
+			var signedTx *ethsigner.Transaction
+			signedMessage, txHash, signedTx, err = it.signTx(ctx, it.stateManager.GetFrom(), it.stateManager.BuildEthTX())
+			if err == nil && signedTx.GasLimit.Uint64() != it.stateManager.GetGasLimit() {
+				gasLimit = confutil.P(signedTx.GasLimit.Uint64())
+			}
+		}
 		log.L(ctx).Debugf("Adding signed message to output, hash %s, signedMessage not nil %t, err %+v", txHash, signedMessage != nil, err)
-		it.stateManager.AddSignOutput(ctx, signedMessage, txHash, err)
+		it.stateManager.AddSignOutput(ctx, signedMessage, txHash, gasLimit, err)
 	}, ctx, it.stateManager.GetStage(ctx), false)
 	return nil
 }
@@ -16,10 +16,13 @@
 package publictxmgr
 
 import (
+	"database/sql/driver"
 	"fmt"
 	"testing"
 	"time"
 
+	"github.com/kaleido-io/paladin/config/pkg/confutil"
+	"github.com/kaleido-io/paladin/config/pkg/pldconf"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -52,6 +55,109 @@ func TestProduceLatestInFlightStageContextTriggerStageError(t *testing.T) {
 	assert.Nil(t, inFlightStageManager.stageTriggerError) // check stage trigger error has been reset
 }
 
+func TestProduceLatestInFlightStageContextTriggerStageErrorBackoff(t *testing.T) {
+	ctx, o, _, done := newTestOrchestrator(t, func(mocks *mocksAndTestControl, conf *pldconf.PublicTxManagerConfig) {
+		conf.Orchestrator.StageTriggerRetry.InitialDelay = confutil.P("1h")
+	})
+	defer done()
+	it, _ := newInflightTransaction(o, 1)
+	inFlightStageManager := it.stateManager.(*inFlightTransactionState)
+	orchestratorContext := &OrchestratorContext{PreviousNonceCostUnknown: true}
+
+	_ = it.ProduceLatestInFlightStageContext(ctx, orchestratorContext)
+	rsc := it.stateManager.GetRunningStageContext(ctx)
+	require.NotNil(t, rsc)
+
+	// first failure is retried immediately
+	inFlightStageManager.stageTriggerError = fmt.Errorf("pop1")
+	tOut := it.ProduceLatestInFlightStageContext(ctx, orchestratorContext)
+	assert.NoError(t, tOut.Error)
+	assert.NotEqual(t, rsc, it.stateManager.GetRunningStageContext(ctx))
+	assert.Equal(t, 1, it.stageTriggerFailures)
+	rsc = it.stateManager.GetRunningStageContext(ctx)
+
+	// second failure backs off
+	inFlightStageManager.stageTriggerError = fmt.Errorf("pop2")
+	tOut = it.ProduceLatestInFlightStageContext(ctx, orchestratorContext)
+	assert.NoError(t, tOut.Error)
+	assert.Equal(t, rsc, it.stateManager.GetRunningStageContext(ctx))
+	assert.Equal(t, 2, it.stageTriggerFailures)
+	require.NotNil(t, it.stageTriggerRetryAfter)
+	assert.Greater(t, time.Until(*it.stageTriggerRetryAfter), 59*time.Minute)
+
+	// still waiting, the failure is not counted again
+	_ = it.ProduceLatestInFlightStageContext(ctx, orchestratorContext)
+	assert.Equal(t, rsc, it.stateManager.GetRunningStageContext(ctx))
+	assert.Equal(t, 2, it.stageTriggerFailures)
+
+	// once the backoff has passed the stage is triggered again, and succeeds
+	pastTime := time.Now().Add(-1 * time.Second)
+	it.stageTriggerRetryAfter = &pastTime
+	tOut = it.ProduceLatestInFlightStageContext(ctx, orchestratorContext)
+	assert.NoError(t, tOut.Error)
+	assert.NotEqual(t, rsc, it.stateManager.GetRunningStageContext(ctx))
+	assert.Nil(t, inFlightStageManager.stageTriggerError)
+	assert.Nil(t, it.stageTriggerRetryAfter)
+
+	_ = it.ProduceLatestInFlightStageContext(ctx, orchestratorContext)
+	assert.Zero(t, it.stageTriggerFailures)
+}
+
+func TestProduceLatestInFlightStageContextTriggerStageErrorRetriesExhausted(t *testing.T) {
+	ctx, o, m, done := newTestOrchestrator(t, func(mocks *mocksAndTestControl, conf *pldconf.PublicTxManagerConfig) {
+		conf.Orchestrator.StageTriggerRetry.InitialDelay = confutil.P("0")
+		conf.Orchestrator.StageTriggerRetry.MaxAttempts = confutil.P(2)
+	})
+	defer done()
+	it, _ := newInflightTransaction(o, 1)
+	inFlightStageManager := it.stateManager.(*inFlightTransactionState)
+	orchestratorContext := &OrchestratorContext{PreviousNonceCostUnknown: true}
+
+	_ = it.ProduceLatestInFlightStageContext(ctx, orchestratorContext)
+	inFlightStageManager.stageTriggerError = fmt.Errorf("pop")
+	tOut := it.ProduceLatestInFlightStageContext(ctx, orchestratorContext)
+	assert.NoError(t, tOut.Error)
+
+	m.db.ExpectExec("UPDATE.*public_txns.*suspended").WillReturnResult(driver.ResultNoRows)
+	inFlightStageManager.stageTriggerError = fmt.Errorf("pop")
+	tOut = it.ProduceLatestInFlightStageContext(ctx, orchestratorContext)
+	assert.Regexp(t, "PD011938.*retrieveGasPrice.*pop", tOut.Error)
+	assert.Zero(t, it.stageTriggerFailures)
+	require.NoError(t, m.db.ExpectationsWereMet())
+
+	// the transaction is suspended, rather than the stage being retried
+	require.NotNil(t, it.newStatus)
+	assert.Equal(t, InFlightStatusSuspending, *it.newStatus)
+	assert.Equal(t, InFlightTxStageStatusUpdate, it.stateManager.GetRunningStageContext(ctx).Stage)
+
+	records := o.getActivityRecords(it.stateManager.GetPubTxnID())
+	require.Len(t, records, 2)
+	assert.Regexp(t, "Suspended", records[0].Message)
+	assert.Regexp(t, "PD011938", records[1].Message)
+}
+
+func TestProduceLatestInFlightStageContextTriggerStageErrorSuspendFails(t *testing.T) {
+	ctx, o, m, done := newTestOrchestrator(t, func(mocks *mocksAndTestControl, conf *pldconf.PublicTxManagerConfig) {
+		conf.Orchestrator.StageTriggerRetry.InitialDelay = confutil.P("0")
+		conf.Orchestrator.StageTriggerRetry.MaxAttempts = confutil.P(1)
+	})
+	defer done()
+	it, _ := newInflightTransaction(o, 1)
+	inFlightStageManager := it.stateManager.(*inFlightTransactionState)
+	orchestratorContext := &OrchestratorContext{PreviousNonceCostUnknown: true}
+
+	_ = it.ProduceLatestInFlightStageContext(ctx, orchestratorContext)
+	m.db.ExpectExec("UPDATE.*public_txns.*suspended").WillReturnError(fmt.Errorf("db down"))
+	inFlightStageManager.stageTriggerError = fmt.Errorf("pop")
+	tOut := it.ProduceLatestInFlightStageContext(ctx, orchestratorContext)
+	assert.Regexp(t, "PD011938", tOut.Error)
+
+	// we go back to retrying the stage
+	assert.Nil(t, it.newStatus)
+	_ = it.ProduceLatestInFlightStageContext(ctx, orchestratorContext)
+	assert.Equal(t, InFlightTxStageRetrieveGasPrice, it.stateManager.GetRunningStageContext(ctx).Stage)
+}
+
 func TestProduceLatestInFlightStageContextStatusChange(t *testing.T) {
 	ctx, o, _, done := newTestOrchestrator(t)
 	defer done()
@@ -36,6 +36,14 @@ func (msu *mockStatusUpdater) UpdateSubStatus(ctx context.Context, imtx InMemory
 	return msu.updateSubStatus(ctx, imtx, subStatus, action, info, err, actionOccurred)
 }
 
+func (msu *mockStatusUpdater) UpdateFailureCategory(ctx context.Context, imtx InMemoryTxStateReadOnly, category pldapi.PublicTxFailureCategory) error {
+	return nil
+}
+
+func (msu *mockStatusUpdater) UpdateGasLimit(ctx context.Context, imtx InMemoryTxStateReadOnly, gasLimit uint64) error {
+	return nil
+}
+
 func TestProduceLatestInFlightStageContextRetrieveGas(t *testing.T) {
 	ctx, o, _, done := newTestOrchestrator(t)
 	defer done()
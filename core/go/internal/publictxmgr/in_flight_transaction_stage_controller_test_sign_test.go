@@ -68,7 +68,7 @@ func TestProduceLatestInFlightStageContextSigning(t *testing.T) {
 	inFlightStageMananger.bufferedStageOutputs = make([]*StageOutput, 0)
 	// test panic error that doesn't belong to the current stage gets ignored
 	it.stateManager.AddPanicOutput(ctx, InFlightTxStageRetrieveGasPrice)
-	it.stateManager.AddSignOutput(ctx, signedMsg, &txHash, nil)
+	it.stateManager.AddSignOutput(ctx, signedMsg, &txHash, nil, nil)
 	tOut = it.ProduceLatestInFlightStageContext(ctx, &OrchestratorContext{
 		AvailableToSpend:         nil,
 		PreviousNonceCostUnknown: false,
@@ -82,7 +82,7 @@ func TestProduceLatestInFlightStageContextSigning(t *testing.T) {
 	_ = rsc.StageOutputsToBePersisted.StatusUpdates[0](mTS.statusUpdater)
 	// failed signing
 	inFlightStageMananger.bufferedStageOutputs = make([]*StageOutput, 0)
-	it.stateManager.AddSignOutput(ctx, nil, nil, fmt.Errorf("sign error"))
+	it.stateManager.AddSignOutput(ctx, nil, nil, nil, fmt.Errorf("sign error"))
 	rsc = it.stateManager.GetRunningStageContext(ctx)
 	assert.Equal(t, InFlightTxStageSigning, rsc.Stage)
 	rsc.StageOutputsToBePersisted = nil
@@ -94,6 +94,7 @@ func TestProduceLatestInFlightStageContextSigning(t *testing.T) {
 	assert.Equal(t, "20000", tOut.Cost.String())
 	assert.NotNil(t, rsc.StageOutputsToBePersisted)
 	assert.Equal(t, 1, len(rsc.StageOutputsToBePersisted.StatusUpdates))
+	assert.Equal(t, pldapi.PublicTxFailureSignerUnavailable, *rsc.StageOutputsToBePersisted.TxUpdates.FailureCategory)
 
 	// persisting error waiting for persistence retry timeout
 	assert.False(t, rsc.StageErrored)
@@ -232,3 +233,86 @@ func TestProduceLatestInFlightStageContextTriggerSign(t *testing.T) {
 	assert.Nil(t, inFlightStageMananger.bufferedStageOutputs[0].SignOutput.SignedMessage)
 	assert.Empty(t, inFlightStageMananger.bufferedStageOutputs[0].SignOutput.TxHash)
 }
+
+func TestProduceLatestInFlightStageContextTriggerSignRawTransaction(t *testing.T) {
+	ctx, o, _, done := newTestOrchestrator(t)
+	defer done()
+	rawTx := tktypes.HexBytes("preSignedMessage")
+	it, mTS := newInflightTransaction(o, 1, func(tx *DBPublicTxn) {
+		tx.RawTransaction = rawTx
+	})
+	mTS.ApplyInMemoryUpdates(ctx, &BaseTXUpdates{
+		GasPricing: &pldapi.PublicTxGasPricing{
+			GasPrice: tktypes.Uint64ToUint256(10),
+		},
+	})
+	it.testOnlyNoActionMode = false
+	it.testOnlyNoEventMode = false
+	// no key lookup is required, as the transaction is already signed
+	err := it.TriggerSignTx(ctx)
+	require.NoError(t, err)
+	ticker := time.NewTicker(10 * time.Millisecond)
+	inFlightStageMananger := it.stateManager.(*inFlightTransactionState)
+	for !t.Failed() && len(inFlightStageMananger.bufferedStageOutputs) == 0 {
+		// wait for event
+		<-ticker.C
+	}
+	assert.Len(t, inFlightStageMananger.bufferedStageOutputs, 1)
+	assert.NotNil(t, inFlightStageMananger.bufferedStageOutputs[0].SignOutput)
+	assert.NoError(t, inFlightStageMananger.bufferedStageOutputs[0].SignOutput.Err)
+	assert.Equal(t, []byte(rawTx), inFlightStageMananger.bufferedStageOutputs[0].SignOutput.SignedMessage)
+	assert.Equal(t, calculateTransactionHash(rawTx), inFlightStageMananger.bufferedStageOutputs[0].SignOutput.TxHash)
+}
+
+func TestProduceLatestInFlightStageContextSigningHeldWhileSignerUnhealthy(t *testing.T) {
+	ctx, o, _, done := newTestOrchestrator(t)
+	defer done()
+	it, mTS := newInflightTransaction(o, 1)
+	it.testOnlyNoActionMode = true
+
+	mTS.ApplyInMemoryUpdates(ctx, &BaseTXUpdates{
+		GasPricing: &pldapi.PublicTxGasPricing{
+			GasPrice: tktypes.Uint64ToUint256(10),
+		},
+	})
+
+	var signerErr error = fmt.Errorf("pop")
+	orchestratorContext := &OrchestratorContext{
+		SignerHealthCheck: func(ctx context.Context) error { return signerErr },
+	}
+
+	// held on each poll while unhealthy, but only recorded once
+	for i := 0; i < 2; i++ {
+		tOut := it.ProduceLatestInFlightStageContext(ctx, orchestratorContext)
+		assert.NoError(t, tOut.Error)
+		assert.Nil(t, it.stateManager.GetRunningStageContext(ctx))
+		assert.True(t, it.heldForUnhealthySigner)
+	}
+	activityRecords := o.getActivityRecords(mTS.GetPubTxnID())
+	require.Len(t, activityRecords, 1)
+	assert.Regexp(t, "PD011950.*pop", activityRecords[0].Message)
+
+	// signing starts once healthy again
+	signerErr = nil
+	_ = it.ProduceLatestInFlightStageContext(ctx, orchestratorContext)
+	assert.False(t, it.heldForUnhealthySigner)
+	rsc := it.stateManager.GetRunningStageContext(ctx)
+	require.NotNil(t, rsc)
+	assert.Equal(t, InFlightTxStageSigning, rsc.Stage)
+	activityRecords = o.getActivityRecords(mTS.GetPubTxnID())
+	require.Len(t, activityRecords, 2)
+	assert.Regexp(t, "PD011951", activityRecords[0].Message)
+}
+
+func TestProduceLatestInFlightStageContextSignerHealthIgnoredForRawTransaction(t *testing.T) {
+	ctx, o, _, done := newTestOrchestrator(t)
+	defer done()
+	it, _ := newInflightTransaction(o, 1, func(tx *DBPublicTxn) {
+		tx.RawTransaction = tktypes.HexBytes("preSignedMessage")
+	})
+
+	held := it.holdForUnhealthySigner(ctx, &OrchestratorContext{
+		SignerHealthCheck: func(ctx context.Context) error { return fmt.Errorf("pop") },
+	})
+	assert.False(t, held)
+}
@@ -130,6 +130,7 @@ func TestProduceLatestInFlightStageContextSubmitComplete(t *testing.T) {
 	_ = rsc.StageOutputsToBePersisted.StatusUpdates[0](mTS.statusUpdater)
 	assert.Equal(t, submissionTime, rsc.StageOutputsToBePersisted.TxUpdates.LastSubmit)
 	assert.Equal(t, txHash, rsc.StageOutputsToBePersisted.TxUpdates.TransactionHash)
+	assert.Equal(t, pldapi.PublicTxFailureNonceTooLow, *rsc.StageOutputsToBePersisted.TxUpdates.FailureCategory)
 }
 
 func TestProduceLatestInFlightStageContextCannotSubmit(t *testing.T) {
@@ -294,6 +295,7 @@ func TestProduceLatestInFlightStageContextSubmitErrors(t *testing.T) {
 	assert.Equal(t, 1, len(rsc.StageOutputsToBePersisted.StatusUpdates))
 	_ = rsc.StageOutputsToBePersisted.StatusUpdates[0](mTS.statusUpdater)
 	assert.Equal(t, submissionErr.Error(), *rsc.StageOutputsToBePersisted.TxUpdates.ErrorMessage)
+	assert.Nil(t, rsc.StageOutputsToBePersisted.TxUpdates.FailureCategory) // not an error we can categorize
 	assert.Equal(t, InFlightTxStageSubmitting, rsc.Stage)
 	assert.Nil(t, rsc.StageOutputsToBePersisted.TxUpdates.NewSubmission)
 
@@ -450,3 +452,136 @@ func TestProduceLatestInFlightStageContextTriggerSubmit(t *testing.T) {
 	assert.NotEmpty(t, inFlightStageMananger.bufferedStageOutputs[0].SubmitOutput.SubmissionTime)
 	assert.Equal(t, SubmissionOutcomeFailedRequiresRetry, inFlightStageMananger.bufferedStageOutputs[0].SubmitOutput.SubmissionOutcome)
 }
+
+func TestProduceLatestInFlightStageContextResubmitRawTransaction(t *testing.T) {
+	ctx, o, _, done := newTestOrchestrator(t)
+	defer done()
+	rawTx := tktypes.HexBytes("preSignedMessage")
+	it, mTS := newInflightTransaction(o, 1, func(tx *DBPublicTxn) {
+		tx.RawTransaction = rawTx
+	})
+	it.testOnlyNoActionMode = true
+	it.resubmitInterval = 1 * time.Millisecond
+	mTS.ApplyInMemoryUpdates(ctx, &BaseTXUpdates{
+		GasPricing: &pldapi.PublicTxGasPricing{
+			GasPrice: tktypes.Uint64ToUint256(10),
+		},
+		TransactionHash: calculateTransactionHash(rawTx),
+		LastSubmit:      confutil.P(tktypes.Timestamp(time.Now().Add(-1 * time.Hour).UnixNano())),
+	})
+	it.stateManager.SetValidatedTransactionHashMatchState(ctx, true)
+
+	// the resubmission sends the same pre-signed bytes, rather than getting a new gas price and re-signing
+	tOut := it.ProduceLatestInFlightStageContext(ctx, &OrchestratorContext{
+		AvailableToSpend:         nil,
+		PreviousNonceCostUnknown: false,
+	})
+	assert.NoError(t, tOut.Error)
+	rsc := it.stateManager.GetRunningStageContext(ctx)
+	require.NotNil(t, rsc)
+	assert.Equal(t, InFlightTxStageSubmitting, rsc.Stage)
+	assert.Equal(t, []byte(rawTx), mTS.TransientPreviousStageOutputs.SignedMessage)
+
+	records := o.getActivityRecords(it.stateManager.GetPubTxnID())
+	require.Len(t, records, 1)
+	assert.Regexp(t, "PD011942", records[0].Message)
+}
+
+func TestProduceLatestInFlightStageContextResubmitInterval(t *testing.T) {
+	ctx, o, _, done := newTestOrchestrator(t)
+	defer done()
+	fc := newFakeClock()
+	o.clock = fc
+	it, mTS := newInflightTransaction(o, 1)
+	it.testOnlyNoActionMode = true
+	it.resubmitInterval = 1 * time.Minute
+	mTS.statusUpdater = &mockStatusUpdater{
+		updateSubStatus: func(ctx context.Context, imtx InMemoryTxStateReadOnly, subStatus BaseTxSubStatus, action BaseTxAction, info, err *fftypes.JSONAny, actionOccurred *tktypes.Timestamp) error {
+			return nil
+		},
+	}
+	txHash := confutil.P(tktypes.Bytes32Keccak([]byte("0x000031")))
+	mTS.ApplyInMemoryUpdates(ctx, &BaseTXUpdates{
+		GasPricing: &pldapi.PublicTxGasPricing{
+			GasPrice: tktypes.Uint64ToUint256(10),
+		},
+		TransactionHash: txHash,
+		FirstSubmit:     confutil.P(tktypes.Timestamp(fc.Now().UnixNano())),
+		LastSubmit:      confutil.P(tktypes.Timestamp(fc.Now().UnixNano())),
+	})
+	it.stateManager.SetValidatedTransactionHashMatchState(ctx, true)
+
+	// within the interval the transaction is only tracked
+	fc.Advance(30 * time.Second)
+	tOut := it.ProduceLatestInFlightStageContext(ctx, &OrchestratorContext{})
+	assert.NoError(t, tOut.Error)
+	assert.Nil(t, it.stateManager.GetRunningStageContext(ctx))
+
+	// once the interval has elapsed we go back to the gas price stage to resubmit
+	fc.Advance(31 * time.Second)
+	tOut = it.ProduceLatestInFlightStageContext(ctx, &OrchestratorContext{})
+	assert.NoError(t, tOut.Error)
+	rsc := it.stateManager.GetRunningStageContext(ctx)
+	require.NotNil(t, rsc)
+	assert.Equal(t, InFlightTxStageRetrieveGasPrice, rsc.Stage)
+
+	// rebroadcast of the unchanged transaction is already known by the node, which still restarts the interval
+	it.TriggerNewStageRun(ctx, InFlightTxStageSubmitting, BaseTxSubStatusStale, []byte("signedMessage"))
+	rsc = it.stateManager.GetRunningStageContext(ctx)
+	submissionTime := confutil.P(tktypes.Timestamp(fc.Now().UnixNano()))
+	it.stateManager.AddSubmitOutput(ctx, txHash, submissionTime, SubmissionOutcomeAlreadyKnown, ethclient.ErrorReason(""), nil)
+	tOut = it.ProduceLatestInFlightStageContext(ctx, &OrchestratorContext{})
+	assert.NoError(t, tOut.Error)
+	require.NotNil(t, rsc.StageOutputsToBePersisted)
+	assert.Equal(t, submissionTime, rsc.StageOutputsToBePersisted.TxUpdates.LastSubmit)
+	mTS.ApplyInMemoryUpdates(ctx, rsc.StageOutputsToBePersisted.TxUpdates)
+	it.stateManager.ClearRunningStageContext(ctx)
+
+	fc.Advance(30 * time.Second)
+	tOut = it.ProduceLatestInFlightStageContext(ctx, &OrchestratorContext{})
+	assert.NoError(t, tOut.Error)
+	assert.Nil(t, it.stateManager.GetRunningStageContext(ctx))
+}
+
+func TestProduceLatestInFlightStageContextResubmitGasBumpImmediate(t *testing.T) {
+	ctx, o, _, done := newTestOrchestrator(t)
+	defer done()
+	fc := newFakeClock()
+	o.clock = fc
+	it, mTS := newInflightTransaction(o, 1)
+	it.testOnlyNoActionMode = true
+	it.resubmitInterval = 1 * time.Minute
+	mTS.statusUpdater = &mockStatusUpdater{
+		updateSubStatus: func(ctx context.Context, imtx InMemoryTxStateReadOnly, subStatus BaseTxSubStatus, action BaseTxAction, info, err *fftypes.JSONAny, actionOccurred *tktypes.Timestamp) error {
+			return nil
+		},
+	}
+	mTS.ApplyInMemoryUpdates(ctx, &BaseTXUpdates{
+		GasPricing: &pldapi.PublicTxGasPricing{
+			GasPrice: tktypes.Uint64ToUint256(10),
+		},
+		TransactionHash: confutil.P(tktypes.Bytes32Keccak([]byte("0x000031"))),
+		FirstSubmit:     confutil.P(tktypes.Timestamp(fc.Now().UnixNano())),
+		LastSubmit:      confutil.P(tktypes.Timestamp(fc.Now().UnixNano())),
+	})
+	it.stateManager.SetValidatedTransactionHashMatchState(ctx, true)
+
+	// a new gas price is retrieved and persisted well within the resubmit interval
+	fc.Advance(1 * time.Second)
+	it.TriggerNewStageRun(ctx, InFlightTxStageRetrieveGasPrice, BaseTxSubStatusStale, nil)
+	rsc := it.stateManager.GetRunningStageContext(ctx)
+	it.stateManager.AddGasPriceOutput(ctx, &pldapi.PublicTxGasPricing{GasPrice: tktypes.Uint64ToUint256(20)}, nil)
+	tOut := it.ProduceLatestInFlightStageContext(ctx, &OrchestratorContext{})
+	assert.NoError(t, tOut.Error)
+	require.NotNil(t, rsc.StageOutputsToBePersisted)
+	mTS.ApplyInMemoryUpdates(ctx, rsc.StageOutputsToBePersisted.TxUpdates)
+	it.stateManager.AddPersistenceOutput(ctx, InFlightTxStageRetrieveGasPrice, time.Now(), nil)
+	tOut = it.ProduceLatestInFlightStageContext(ctx, &OrchestratorContext{})
+	assert.NoError(t, tOut.Error)
+
+	// the bumped transaction is signed for rebroadcast straight away, without waiting for the interval
+	assert.Equal(t, "20", mTS.GetGasPriceObject().GasPrice.Int().String())
+	rsc = it.stateManager.GetRunningStageContext(ctx)
+	require.NotNil(t, rsc)
+	assert.Equal(t, InFlightTxStageSigning, rsc.Stage)
+}
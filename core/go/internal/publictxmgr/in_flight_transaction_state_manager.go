@@ -62,6 +62,10 @@ type inFlightTransactionState struct {
 	txLevelStageStartTime time.Time
 	stageTriggerError     error
 
+	// updated by heartbeats from stage actions, so that a long-running stage is not mistaken for a stuck one
+	lastProgressMux  sync.Mutex
+	lastProgressTime time.Time
+
 	bufferedStageOutputsMux sync.Mutex
 	bufferedStageOutputs    []*StageOutput
 
@@ -164,6 +168,19 @@ func (iftxs *inFlightTransactionState) ClearRunningStageContext(ctx context.Cont
 	iftxs.stageTriggerError = nil
 }
 
+func (iftxs *inFlightTransactionState) Heartbeat(ctx context.Context) {
+	iftxs.lastProgressMux.Lock()
+	defer iftxs.lastProgressMux.Unlock()
+	log.L(ctx).Tracef("Transaction with ID %s heartbeat in stage %s", iftxs.InMemoryTxStateManager.GetSignerNonce(), iftxs.stage)
+	iftxs.lastProgressTime = time.Now()
+}
+
+func (iftxs *inFlightTransactionState) GetLastProgressTime() time.Time {
+	iftxs.lastProgressMux.Lock()
+	defer iftxs.lastProgressMux.Unlock()
+	return iftxs.lastProgressTime
+}
+
 func (iftxs *inFlightTransactionState) ProcessStageOutputs(ctx context.Context, processFunction func(stageOutputs []*StageOutput) (unprocessedStageOutputs []*StageOutput)) {
 	iftxs.bufferedStageOutputsMux.Lock()
 	defer iftxs.bufferedStageOutputsMux.Unlock()
@@ -236,7 +253,7 @@ func (iftxs *inFlightTransactionState) AddSubmitOutput(ctx context.Context, txHa
 	log.L(ctx).Debugf("%s AddSubmitOutput took %s to write the result", iftxs.InMemoryTxStateManager.GetSignerNonce(), time.Since(start))
 }
 
-func (iftxs *inFlightTransactionState) AddSignOutput(ctx context.Context, signedMessage []byte, txHash *tktypes.Bytes32, err error) {
+func (iftxs *inFlightTransactionState) AddSignOutput(ctx context.Context, signedMessage []byte, txHash *tktypes.Bytes32, gasLimit *uint64, err error) {
 	start := time.Now()
 	log.L(ctx).Debugf("%s Setting signed message, hash %s, signed message not nil %t, err %+v", iftxs.InMemoryTxStateManager.GetSignerNonce(), txHash, signedMessage != nil, err)
 	iftxs.AddStageOutputs(ctx, &StageOutput{
@@ -244,6 +261,7 @@ func (iftxs *inFlightTransactionState) AddSignOutput(ctx context.Context, signed
 		SignOutput: &SignOutputs{
 			SignedMessage: signedMessage,
 			TxHash:        txHash,
+			GasLimit:      gasLimit,
 			Err:           err,
 		},
 	})
@@ -298,6 +316,13 @@ func (iftxs *inFlightTransactionState) PersistTxState(ctx context.Context) (stag
 
 	if rsc.StageOutputsToBePersisted.TxUpdates != nil {
 
+		// the gas limit must be recorded before the submission, as it is part of the signed transaction
+		if gasLimit := rsc.StageOutputsToBePersisted.TxUpdates.GasLimit; gasLimit != nil {
+			if err := iftxs.statusUpdater.UpdateGasLimit(ctx, rsc.InMemoryTx, *gasLimit); err != nil {
+				return rsc.Stage, time.Now(), err
+			}
+		}
+
 		newSubmission := rsc.StageOutputsToBePersisted.TxUpdates.NewSubmission
 		if newSubmission != nil {
 			// This is the critical point where we must flush to persistence before we go any further - we have a new
@@ -320,6 +345,12 @@ func (iftxs *inFlightTransactionState) PersistTxState(ctx context.Context) (stag
 			iftxs.RecordCompletedTransactionCountMetrics(ctx, string(GenericStatusSuccess))
 		}
 
+		if failureCategory := rsc.StageOutputsToBePersisted.TxUpdates.FailureCategory; failureCategory != nil {
+			if err := iftxs.statusUpdater.UpdateFailureCategory(ctx, rsc.InMemoryTx, *failureCategory); err != nil {
+				return rsc.Stage, time.Now(), err
+			}
+		}
+
 		// update the in memory state
 		iftxs.ApplyInMemoryUpdates(ctx, rsc.StageOutputsToBePersisted.TxUpdates)
 	}
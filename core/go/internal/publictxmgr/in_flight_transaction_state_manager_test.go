@@ -17,6 +17,7 @@ package publictxmgr
 
 import (
 	"context"
+	"database/sql/driver"
 	"fmt"
 	"math/big"
 	"testing"
@@ -32,6 +33,7 @@ import (
 	"github.com/kaleido-io/paladin/toolkit/pkg/tktypes"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 type testInFlightTransactionStateManagerWithMocks struct {
@@ -74,6 +76,7 @@ func TestStateManagerStageManagementBasic(t *testing.T) {
 	assert.Nil(t, stateManager.GetStageTriggerError(ctx))
 	assert.Empty(t, stateManager.GetStage(ctx))
 	assert.NotNil(t, stateManager.GetStageStartTime(ctx))
+	assert.True(t, stateManager.GetLastProgressTime().IsZero())
 	assert.False(t, stateManager.ValidatedTransactionHashMatchState(ctx))
 	stateManager.SetValidatedTransactionHashMatchState(ctx, true)
 	assert.True(t, stateManager.ValidatedTransactionHashMatchState(ctx))
@@ -217,7 +220,7 @@ func TestStateManagerStageOutputManagement(t *testing.T) {
 	go func() {
 		for i := 0; i < expectedNumberOfSignSuccessOutput; i++ {
 			go func() {
-				stateManager.AddSignOutput(ctx, []byte("data"), confutil.P(tktypes.RandBytes32()), nil)
+				stateManager.AddSignOutput(ctx, []byte("data"), confutil.P(tktypes.RandBytes32()), nil, nil)
 				countChanel <- true
 			}()
 		}
@@ -225,7 +228,7 @@ func TestStateManagerStageOutputManagement(t *testing.T) {
 	go func() {
 		for i := 0; i < expectedNumberOfSignErrorOutput; i++ {
 			go func() {
-				stateManager.AddSignOutput(ctx, nil, nil, fmt.Errorf("error"))
+				stateManager.AddSignOutput(ctx, nil, nil, nil, fmt.Errorf("error"))
 				countChanel <- true
 			}()
 		}
@@ -381,3 +384,28 @@ func TestStateManagerTxPersistenceManagementUpdateErrors(t *testing.T) {
 	assert.Regexp(t, "pop", err)
 
 }
+
+func TestStateManagerTxPersistenceFailureCategory(t *testing.T) {
+	ctx := context.Background()
+	testStateManagerWithMocks, m, done := newTestInFlightTransactionStateManager(t)
+	defer done()
+
+	stateManager := testStateManagerWithMocks.stateManager
+	var nilBytes []byte
+	testStateManagerWithMocks.mAT.On("TriggerSubmitTx", mock.Anything, nilBytes).Return(nil).Once()
+	stateManager.StartNewStageContext(ctx, InFlightTxStageSubmitting, BaseTxSubStatusReceived)
+	rsc := stateManager.GetRunningStageContext(ctx)
+	rsc.SetNewPersistenceUpdateOutput()
+	rsc.StageOutputsToBePersisted.TxUpdates = &BaseTXUpdates{
+		FailureCategory: confutil.P(pldapi.PublicTxFailureInsufficientFunds),
+	}
+
+	m.db.ExpectExec("UPDATE.*public_txns.*failure_category").WillReturnError(fmt.Errorf("pop"))
+	_, _, err := stateManager.PersistTxState(ctx)
+	assert.Regexp(t, "pop", err)
+
+	m.db.ExpectExec("UPDATE.*public_txns.*failure_category").WillReturnResult(driver.ResultNoRows)
+	_, _, err = stateManager.PersistTxState(ctx)
+	require.NoError(t, err)
+	require.NoError(t, m.db.ExpectationsWereMet())
+}
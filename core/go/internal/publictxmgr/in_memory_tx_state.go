@@ -119,6 +119,10 @@ func (imtxs *inMemoryTxState) ApplyInMemoryUpdates(ctx context.Context, txUpdate
 	if txUpdates.TransactionHash != nil {
 		mtx.TransactionHash = txUpdates.TransactionHash
 	}
+
+	if txUpdates.GasLimit != nil {
+		mtx.ptx.Gas = *txUpdates.GasLimit
+	}
 }
 
 func (imtxs *inMemoryTxState) GetPubTxnID() uint64 {
@@ -157,6 +161,10 @@ func (imtxs *inMemoryTxState) GetValue() *tktypes.HexUint256 {
 	return imtxs.mtx.ptx.Value
 }
 
+func (imtxs *inMemoryTxState) GetRawTransaction() tktypes.HexBytes {
+	return imtxs.mtx.ptx.RawTransaction
+}
+
 func (imtxs *inMemoryTxState) BuildEthTX() *ethsigner.Transaction {
 	// Builds the ethereum TX using the latest in-memory information that must have been resolved in previous stages
 	ptx := imtxs.mtx.ptx
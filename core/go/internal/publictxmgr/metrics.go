@@ -17,8 +17,23 @@ package publictxmgr
 
 import (
 	"context"
+	"time"
 
 	"github.com/kaleido-io/paladin/toolkit/pkg/log"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	metricsOrchestratorsByState   = "paladin_publictxmgr_orchestrators"
+	metricsOrchestratorFreeSlots  = "paladin_publictxmgr_orchestrator_free_slots"
+	metricsOrchestratorStateLabel = "state"
+	metricsWatchdogRestarts       = "paladin_publictxmgr_orchestrator_watchdog_restarts"
+	metricsPollDuration           = "paladin_publictxmgr_poll_duration_seconds"
+	metricsPollSlotsFilled        = "paladin_publictxmgr_poll_slots_filled"
+	metricsPollFillEfficiency     = "paladin_publictxmgr_poll_fill_efficiency"
+	metricsSignerUnhealthyHolds   = "paladin_publictxmgr_signer_unhealthy_holds"
+	metricsPendingBacklog         = "paladin_publictxmgr_pending_backlog"
+	metricsPolledInFlightSigners  = "paladin_publictxmgr_poll_in_flight_signers"
 )
 
 type PublicTxManagerMetricsManager interface {
@@ -30,6 +45,69 @@ type PublicTxManagerMetricsManager interface {
 }
 
 type publicTxEngineMetrics struct {
+	orchestratorsByState  *prometheus.GaugeVec
+	orchestratorFreeSlots prometheus.Gauge
+	watchdogRestarts      prometheus.Counter
+	pollDuration          prometheus.Histogram
+	pollSlotsFilled       prometheus.Histogram
+	pollFillEfficiency    prometheus.Gauge
+	signerUnhealthyHolds  prometheus.Counter
+	pendingBacklog        prometheus.Gauge
+	polledInFlightSigners prometheus.Counter
+}
+
+func newPublicTxEngineMetrics() *publicTxEngineMetrics {
+	thm := &publicTxEngineMetrics{
+		orchestratorsByState: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: metricsOrchestratorsByState,
+			Help: "Number of in-flight orchestrators in each state",
+		}, []string{metricsOrchestratorStateLabel}),
+		orchestratorFreeSlots: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: metricsOrchestratorFreeSlots,
+			Help: "Number of free orchestrator slots in the in-flight pool",
+		}),
+		watchdogRestarts: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: metricsWatchdogRestarts,
+			Help: "Number of orchestrators restarted by the watchdog after making no progress",
+		}),
+		pollDuration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Name:    metricsPollDuration,
+			Help:    "Duration of each engine poll of the in-flight orchestrator pool",
+			Buckets: prometheus.ExponentialBuckets(0.001, 4, 8),
+		}),
+		pollSlotsFilled: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Name:    metricsPollSlotsFilled,
+			Help:    "Number of free orchestrator slots filled by each engine poll",
+			Buckets: prometheus.ExponentialBuckets(1, 2, 8),
+		}),
+		pollFillEfficiency: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: metricsPollFillEfficiency,
+			Help: "Ratio of free orchestrator slots filled to those available on the last engine poll",
+		}),
+		signerUnhealthyHolds: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: metricsSignerUnhealthyHolds,
+			Help: "Number of times a transaction was held back from signing because its signer reported unhealthy",
+		}),
+		pendingBacklog: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: metricsPendingBacklog,
+			Help: "Number of public transactions pending completion, as used for admission control of new submissions",
+		}),
+		polledInFlightSigners: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: metricsPolledInFlightSigners,
+			Help: "Number of signing addresses returned by an engine poll that already had an in-flight orchestrator",
+		}),
+	}
+	// Every state series exists from the start, so dashboards never see a gap
+	for _, state := range AllOrchestratorStates {
+		thm.orchestratorsByState.WithLabelValues(state).Set(0)
+	}
+	return thm
+}
+
+func (thm *publicTxEngineMetrics) register(registry prometheus.Registerer) {
+	registry.MustRegister(thm.orchestratorsByState, thm.orchestratorFreeSlots, thm.watchdogRestarts,
+		thm.pollDuration, thm.pollSlotsFilled, thm.pollFillEfficiency, thm.signerUnhealthyHolds, thm.pendingBacklog,
+		thm.polledInFlightSigners)
 }
 
 func (thm *publicTxEngineMetrics) InitMetrics(ctx context.Context) {
@@ -49,7 +127,62 @@ func (thm *publicTxEngineMetrics) RecordStageChangeMetrics(ctx context.Context,
 
 func (thm *publicTxEngineMetrics) RecordInFlightOrchestratorPoolMetrics(ctx context.Context, usedCountPerState map[string]int, freeCount int) {
 	log.L(ctx).Tracef("RecordInFlightEnginePoolMetrics")
-	// TODO
+	if thm == nil || thm.orchestratorsByState == nil {
+		return
+	}
+	// Set every known state on each poll (including zero), rather than only those in the map,
+	// so that a state count dropping to zero is reported rather than left at its last value
+	for _, state := range AllOrchestratorStates {
+		thm.orchestratorsByState.WithLabelValues(state).Set(float64(usedCountPerState[state]))
+	}
+	thm.orchestratorFreeSlots.Set(float64(freeCount))
+}
+
+func (thm *publicTxEngineMetrics) RecordOrchestratorWatchdogRestart(ctx context.Context) {
+	log.L(ctx).Tracef("RecordOrchestratorWatchdogRestart")
+	if thm == nil || thm.watchdogRestarts == nil {
+		return
+	}
+	thm.watchdogRestarts.Inc()
+}
+
+func (thm *publicTxEngineMetrics) RecordPendingBacklog(ctx context.Context, depth int64) {
+	log.L(ctx).Tracef("RecordPendingBacklog depth=%d", depth)
+	if thm == nil || thm.pendingBacklog == nil {
+		return
+	}
+	thm.pendingBacklog.Set(float64(depth))
+}
+
+func (thm *publicTxEngineMetrics) RecordSignerUnhealthyHold(ctx context.Context) {
+	log.L(ctx).Tracef("RecordSignerUnhealthyHold")
+	if thm == nil || thm.signerUnhealthyHolds == nil {
+		return
+	}
+	thm.signerUnhealthyHolds.Inc()
+}
+
+func (thm *publicTxEngineMetrics) RecordPolledInFlightSigner(ctx context.Context) {
+	log.L(ctx).Tracef("RecordPolledInFlightSigner")
+	if thm == nil || thm.polledInFlightSigners == nil {
+		return
+	}
+	thm.polledInFlightSigners.Inc()
+}
+
+// A poll with no free slots available is reported as fully efficient, as the pool is already full
+func (thm *publicTxEngineMetrics) RecordPollMetrics(ctx context.Context, duration time.Duration, availableSlots int, filledSlots int) {
+	log.L(ctx).Tracef("RecordPollMetrics")
+	if thm == nil || thm.pollDuration == nil {
+		return
+	}
+	thm.pollDuration.Observe(duration.Seconds())
+	thm.pollSlotsFilled.Observe(float64(filledSlots))
+	efficiency := float64(1)
+	if availableSlots > 0 {
+		efficiency = float64(filledSlots) / float64(availableSlots)
+	}
+	thm.pollFillEfficiency.Set(efficiency)
 }
 
 func (thm *publicTxEngineMetrics) RecordInFlightTxQueueMetrics(ctx context.Context, usedCountPerStage map[string]int, freeCount int) {
@@ -18,6 +18,12 @@ package publictxmgr
 import (
 	"context"
 	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestMetrics(t *testing.T) {
@@ -31,4 +37,125 @@ func TestMetrics(t *testing.T) {
 	btem.RecordInFlightOrchestratorPoolMetrics(ctx, nil, 1)
 	btem.RecordInFlightTxQueueMetrics(ctx, nil, 1)
 	btem.RecordCompletedTransactionCountMetrics(ctx, "test")
+	btem.RecordPollMetrics(ctx, time.Second, 1, 1)
+	btem.RecordSignerUnhealthyHold(ctx)
+	btem.RecordPolledInFlightSigner(ctx)
+}
+
+// Registers with a registry of their own, so tests can check the values
+func newTestPublicTxEngineMetrics() (*publicTxEngineMetrics, *prometheus.Registry) {
+	thm := newPublicTxEngineMetrics()
+	registry := prometheus.NewRegistry()
+	thm.register(registry)
+	return thm, registry
+}
+
+func TestSignerUnhealthyHoldMetrics(t *testing.T) {
+	ctx := context.Background()
+	thm, registry := newTestPublicTxEngineMetrics()
+
+	thm.RecordSignerUnhealthyHold(ctx)
+	thm.RecordSignerUnhealthyHold(ctx)
+	families, err := registry.Gather()
+	require.NoError(t, err)
+	holds := float64(-1)
+	for _, mf := range families {
+		if mf.GetName() == metricsSignerUnhealthyHolds {
+			holds = mf.GetMetric()[0].GetCounter().GetValue()
+		}
+	}
+	assert.Equal(t, float64(2), holds)
+}
+
+func gatherPollMetrics(t *testing.T, registry prometheus.Gatherer) (durations *dto.Histogram, slotsFilled *dto.Histogram, efficiency float64) {
+	families, err := registry.Gather()
+	require.NoError(t, err)
+	for _, mf := range families {
+		switch mf.GetName() {
+		case metricsPollDuration:
+			durations = mf.GetMetric()[0].GetHistogram()
+		case metricsPollSlotsFilled:
+			slotsFilled = mf.GetMetric()[0].GetHistogram()
+		case metricsPollFillEfficiency:
+			efficiency = mf.GetMetric()[0].GetGauge().GetValue()
+		}
+	}
+	require.NotNil(t, durations)
+	require.NotNil(t, slotsFilled)
+	return durations, slotsFilled, efficiency
+}
+
+func TestPollMetrics(t *testing.T) {
+	ctx := context.Background()
+	thm, registry := newTestPublicTxEngineMetrics()
+
+	thm.RecordPollMetrics(ctx, 10*time.Millisecond, 4, 1)
+	durations, slotsFilled, efficiency := gatherPollMetrics(t, registry)
+	assert.Equal(t, uint64(1), durations.GetSampleCount())
+	assert.InDelta(t, 0.01, durations.GetSampleSum(), 0.0001)
+	assert.Equal(t, float64(1), slotsFilled.GetSampleSum())
+	assert.Equal(t, 0.25, efficiency)
+
+	// a full pool has nothing to fill
+	thm.RecordPollMetrics(ctx, 10*time.Millisecond, 0, 0)
+	durations, slotsFilled, efficiency = gatherPollMetrics(t, registry)
+	assert.Equal(t, uint64(2), durations.GetSampleCount())
+	assert.Equal(t, uint64(2), slotsFilled.GetSampleCount())
+	assert.Equal(t, float64(1), efficiency)
+}
+
+func gatherOrchestratorPoolMetrics(t *testing.T, registry prometheus.Gatherer) (map[string]float64, float64) {
+	families, err := registry.Gather()
+	require.NoError(t, err)
+	byState := make(map[string]float64)
+	freeSlots := float64(-1)
+	for _, mf := range families {
+		switch mf.GetName() {
+		case metricsOrchestratorsByState:
+			require.Equal(t, dto.MetricType_GAUGE, mf.GetType())
+			for _, m := range mf.GetMetric() {
+				require.Len(t, m.GetLabel(), 1)
+				assert.Equal(t, metricsOrchestratorStateLabel, m.GetLabel()[0].GetName())
+				byState[m.GetLabel()[0].GetValue()] = m.GetGauge().GetValue()
+			}
+		case metricsOrchestratorFreeSlots:
+			freeSlots = mf.GetMetric()[0].GetGauge().GetValue()
+		}
+	}
+	return byState, freeSlots
+}
+
+func TestOrchestratorPoolMetricsAllStatesEmitted(t *testing.T) {
+	ctx := context.Background()
+	thm, registry := newTestPublicTxEngineMetrics()
+
+	// all states are present before the first poll
+	byState, freeSlots := gatherOrchestratorPoolMetrics(t, registry)
+	assert.Len(t, byState, len(AllOrchestratorStates))
+	assert.Zero(t, freeSlots)
+
+	thm.RecordInFlightOrchestratorPoolMetrics(ctx, map[string]int{
+		string(OrchestratorStateRunning): 3,
+		string(OrchestratorStateIdle):    1,
+	}, 6)
+	byState, freeSlots = gatherOrchestratorPoolMetrics(t, registry)
+	assert.Len(t, byState, len(AllOrchestratorStates))
+	assert.Equal(t, float64(3), byState[string(OrchestratorStateRunning)])
+	assert.Equal(t, float64(1), byState[string(OrchestratorStateIdle)])
+	assert.Equal(t, float64(6), freeSlots)
+
+	// a state dropping out of the counts is reported as zero, not left at its previous value
+	thm.RecordInFlightOrchestratorPoolMetrics(ctx, map[string]int{
+		string(OrchestratorStateIdle): 2,
+	}, 8)
+	byState, freeSlots = gatherOrchestratorPoolMetrics(t, registry)
+	assert.Len(t, byState, len(AllOrchestratorStates))
+	for _, state := range AllOrchestratorStates {
+		if state == string(OrchestratorStateIdle) {
+			assert.Equal(t, float64(2), byState[state])
+		} else {
+			assert.Zero(t, byState[state], state)
+		}
+	}
+	assert.Equal(t, float64(8), freeSlots)
 }
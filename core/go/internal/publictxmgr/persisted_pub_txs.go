@@ -28,12 +28,17 @@ type DBPublicTxn struct {
 	From            tktypes.EthAddress     `gorm:"column:from"`
 	Nonce           *uint64                `gorm:"column:nonce"`
 	Created         tktypes.Timestamp      `gorm:"column:created;autoCreateTime:nano"`
+	Updated         tktypes.Timestamp      `gorm:"column:updated;autoUpdateTime:nano"` // see touchTransactions for updates outside of GORM
 	To              *tktypes.EthAddress    `gorm:"column:to"`
 	Gas             uint64                 `gorm:"column:gas"`
 	FixedGasPricing tktypes.RawJSON        `gorm:"column:fixed_gas_pricing"`
 	Value           *tktypes.HexUint256    `gorm:"column:value"`
 	Data            tktypes.HexBytes       `gorm:"column:data"`
+	RawTransaction  tktypes.HexBytes       `gorm:"column:raw_tx"`                               // set when submitted pre-signed, so it is re-sent as-is and never re-signed
+	GroupID         *uuid.UUID             `gorm:"column:group_id"`                             // set when submitted as part of a group, which is assigned contiguous nonces
+	Label           *string                `gorm:"column:label"`                                // optional free-text label for searching
 	Suspended       bool                   `gorm:"column:suspended"`                            // excluded from processing because it's suspended by user
+	FailureCategory *string                `gorm:"column:failure_category"`                     // the category of the most recent failure, if any
 	Completed       *DBPublicTxnCompletion `gorm:"foreignKey:pub_txn_id;references:pub_txn_id"` // excluded from processing because it's done
 	Submissions     []*DBPubTxnSubmission  `gorm:"-"`                                           // we do the aggregation, not GORM
 	// Binding is used only on queries by transaction (GORM doesn't seem to allow us to define a separate struct for this)
@@ -70,6 +75,7 @@ type DBPublicTxnCompletion struct {
 	PublicTxnID     uint64            `gorm:"column:pub_txn_id;primaryKey"`
 	Created         tktypes.Timestamp `gorm:"column:created;autoCreateTime:nano"`
 	TransactionHash tktypes.Bytes32   `gorm:"column:tx_hash"`
+	BlockNumber     *int64            `gorm:"column:block_number"` // the inclusion block, used to calculate the confirmation depth
 	Success         bool              `gorm:"column:success"`
 	RevertData      tktypes.HexBytes  `gorm:"column:revert_data"` // block indexer does not keep this for all TXs
 }
@@ -91,3 +97,26 @@ type bindingsMatchingSubmission struct {
 type txFromOnly struct {
 	From tktypes.EthAddress
 }
+
+// summary of the highest completed nonce for a signing address, that survives the purge of the
+// public_txns rows by the retention policy
+type DBPublicTxnWatermark struct {
+	From    tktypes.EthAddress `gorm:"column:from;primaryKey"`
+	Nonce   uint64             `gorm:"column:nonce"`
+	Updated tktypes.Timestamp  `gorm:"column:updated"`
+}
+
+func (DBPublicTxnWatermark) TableName() string {
+	return "public_txn_watermarks"
+}
+
+// cumulative count of the auto-fueling transactions submitted from a funding source
+type DBPublicFuelingSource struct {
+	Source       tktypes.EthAddress `gorm:"column:source;primaryKey"`
+	FuelingCount uint64             `gorm:"column:fueling_count"`
+	Updated      tktypes.Timestamp  `gorm:"column:updated"`
+}
+
+func (DBPublicFuelingSource) TableName() string {
+	return "public_fueling_sources"
+}
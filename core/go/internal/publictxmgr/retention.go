@@ -0,0 +1,202 @@
+/*
+ * Copyright © 2024 Kaleido, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with
+ * the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package publictxmgr
+
+import (
+	"context"
+	"time"
+
+	"github.com/kaleido-io/paladin/core/pkg/persistence"
+	"github.com/kaleido-io/paladin/toolkit/pkg/log"
+	"github.com/kaleido-io/paladin/toolkit/pkg/tktypes"
+	"gorm.io/gorm/clause"
+)
+
+// The retention loop periodically purges completed (succeeded or failed) public transactions
+// that completed longer ago than the configured max age.
+// The highest completed nonce for each signing address is kept in a small summary table,
+// so that the nonce watermark is still available after the rows themselves are gone.
+func (ble *pubTxManager) retentionLoop() {
+	defer close(ble.retentionLoopDone)
+	ctx := log.WithLogField(ble.ctx, "role", "retention-loop")
+	log.L(ctx).Infof("Retention enabled for completed transactions older than %s (interval=%s)", ble.retentionMaxAge, ble.retentionInterval)
+
+	ticker := time.NewTicker(ble.retentionInterval)
+	defer ticker.Stop()
+	for {
+		purged, err := ble.purgeCompletedTransactions(ctx, time.Now().Add(-ble.retentionMaxAge))
+		if err != nil {
+			log.L(ctx).Warnf("Retention purge failed after purging %d transactions: %s", purged, err)
+		} else if purged > 0 {
+			log.L(ctx).Infof("Retention purge removed %d completed transactions", purged)
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			log.L(ctx).Infof("Retention loop exiting")
+			return
+		}
+	}
+}
+
+// purges in batches until there is nothing left older than the cutoff, or the context is cancelled
+func (ble *pubTxManager) purgeCompletedTransactions(ctx context.Context, cutoff time.Time) (total int, err error) {
+	for {
+		if err := ctx.Err(); err != nil {
+			return total, err
+		}
+		var purged int
+		err = ble.p.Transaction(ctx, func(ctx context.Context, dbTX persistence.DBTX) (err error) {
+			purged, err = ble.purgeCompletedBatch(ctx, dbTX, tktypes.Timestamp(cutoff.UnixNano()))
+			return err
+		})
+		if err != nil {
+			return total, err
+		}
+		total += purged
+		if purged < ble.retentionBatchSize {
+			return total, nil
+		}
+	}
+}
+
+func (ble *pubTxManager) purgeCompletedBatch(ctx context.Context, dbTX persistence.DBTX, cutoff tktypes.Timestamp) (int, error) {
+	var ptxs []*DBPublicTxn
+	err := dbTX.DB().
+		WithContext(ctx).
+		Table("public_txns").
+		Joins("Completed").
+		Where(`"Completed"."created" < ?`, cutoff).
+		Order(`"public_txns"."pub_txn_id"`).
+		Limit(ble.retentionBatchSize).
+		Find(&ptxs).
+		Error
+	if err != nil || len(ptxs) == 0 {
+		return 0, err
+	}
+
+	pubTxnIDs := make([]uint64, len(ptxs))
+	highestNonces := make(map[tktypes.EthAddress]uint64)
+	for i, ptx := range ptxs {
+		pubTxnIDs[i] = ptx.PublicTxnID
+		if ptx.Nonce != nil {
+			if highest, ok := highestNonces[ptx.From]; !ok || *ptx.Nonce > highest {
+				highestNonces[ptx.From] = *ptx.Nonce
+			}
+		}
+	}
+
+	if len(highestNonces) > 0 {
+		// Merge with any existing watermarks, as completion order does not strictly follow nonce order
+		froms := make([]tktypes.EthAddress, 0, len(highestNonces))
+		for from := range highestNonces {
+			froms = append(froms, from)
+		}
+		var existing []*DBPublicTxnWatermark
+		err = dbTX.DB().
+			WithContext(ctx).
+			Table("public_txn_watermarks").
+			Where(`"from" IN (?)`, froms).
+			Find(&existing).
+			Error
+		if err != nil {
+			return 0, err
+		}
+		for _, wm := range existing {
+			if wm.Nonce > highestNonces[wm.From] {
+				highestNonces[wm.From] = wm.Nonce
+			}
+		}
+		now := tktypes.TimestampNow()
+		watermarks := make([]*DBPublicTxnWatermark, 0, len(highestNonces))
+		for from, nonce := range highestNonces {
+			watermarks = append(watermarks, &DBPublicTxnWatermark{From: from, Nonce: nonce, Updated: now})
+		}
+		err = dbTX.DB().
+			WithContext(ctx).
+			Table("public_txn_watermarks").
+			Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "from"}},
+				DoUpdates: clause.AssignmentColumns([]string{"nonce", "updated"}),
+			}).
+			Create(watermarks).
+			Error
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	// We delete from the child tables explicitly, rather than relying on cascade behavior of the DB
+	for _, table := range []string{"public_submissions", "public_completions", "public_txn_bindings", "public_txns"} {
+		err = dbTX.DB().
+			WithContext(ctx).
+			Exec(`DELETE FROM "`+table+`" WHERE "pub_txn_id" IN (?)`, pubTxnIDs).
+			Error
+		if err != nil {
+			return 0, err
+		}
+	}
+	log.L(ctx).Debugf("Retention purged %d transactions across %d signing addresses", len(ptxs), len(highestNonces))
+	return len(ptxs), nil
+}
+
+// Returns the highest nonce that has completed for the signing address, including transactions
+// that have been purged by the retention policy. Nil if no transaction has completed.
+func (ble *pubTxManager) getCompletedNonceWatermark(ctx context.Context, dbTX persistence.DBTX, from tktypes.EthAddress) (*uint64, error) {
+	var ptxs []*DBPublicTxn
+	err := dbTX.DB().
+		WithContext(ctx).
+		Table("public_txns").
+		Joins("Completed").
+		Where(`"public_txns"."from" = ?`, from).
+		Where(`"Completed"."tx_hash" IS NOT NULL`).
+		Where(`"public_txns"."nonce" IS NOT NULL`).
+		Order(`"public_txns"."nonce" DESC`).
+		Limit(1).
+		Find(&ptxs).
+		Error
+	if err != nil {
+		return nil, err
+	}
+	var watermark *uint64
+	if len(ptxs) > 0 {
+		watermark = ptxs[0].Nonce
+	}
+	purged, err := ble.getPurgedNonceWatermark(ctx, dbTX, from)
+	if err != nil {
+		return nil, err
+	}
+	if purged != nil && (watermark == nil || *purged > *watermark) {
+		watermark = purged
+	}
+	return watermark, nil
+}
+
+func (ble *pubTxManager) getPurgedNonceWatermark(ctx context.Context, dbTX persistence.DBTX, from tktypes.EthAddress) (*uint64, error) {
+	var watermarks []*DBPublicTxnWatermark
+	err := dbTX.DB().
+		WithContext(ctx).
+		Table("public_txn_watermarks").
+		Where(`"from" = ?`, from).
+		Limit(1).
+		Find(&watermarks).
+		Error
+	if err != nil || len(watermarks) == 0 {
+		return nil, err
+	}
+	return &watermarks[0].Nonce, nil
+}
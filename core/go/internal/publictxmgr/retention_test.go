@@ -0,0 +1,305 @@
+/*
+ * Copyright © 2024 Kaleido, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with
+ * the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package publictxmgr
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kaleido-io/paladin/config/pkg/confutil"
+	"github.com/kaleido-io/paladin/config/pkg/pldconf"
+	"github.com/kaleido-io/paladin/toolkit/pkg/pldapi"
+	"github.com/kaleido-io/paladin/toolkit/pkg/tktypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestRetentionManager(t *testing.T) (context.Context, *pubTxManager, func()) {
+	ctx, ble, _, done := newTestPublicTxManager(t, true, func(mocks *mocksAndTestControl, conf *pldconf.PublicTxManagerConfig) {
+		mocks.disableManagerStart = true
+		conf.Manager.Retention.BatchSize = confutil.P(2)
+	})
+	return ctx, ble, done
+}
+
+func insertTestPublicTxn(t *testing.T, ctx context.Context, ble *pubTxManager, from tktypes.EthAddress, nonce uint64, completedAt *time.Time) uint64 {
+	ptx := &DBPublicTxn{
+		From:  from,
+		Nonce: &nonce,
+		Gas:   21000,
+	}
+	err := ble.p.DB().WithContext(ctx).Table("public_txns").Omit("Completed", "Binding").Create(ptx).Error
+	require.NoError(t, err)
+	err = ble.p.DB().WithContext(ctx).Table("public_txn_bindings").Create(&DBPublicTxnBinding{
+		PublicTxnID:     ptx.PublicTxnID,
+		Transaction:     uuid.New(),
+		TransactionType: pldapi.TransactionTypePublic.Enum(),
+	}).Error
+	require.NoError(t, err)
+	if completedAt != nil {
+		err = ble.p.DB().WithContext(ctx).Table("public_completions").Create(&DBPublicTxnCompletion{
+			PublicTxnID:     ptx.PublicTxnID,
+			Created:         tktypes.Timestamp(completedAt.UnixNano()),
+			TransactionHash: tktypes.Bytes32(tktypes.RandBytes(32)),
+			Success:         true,
+		}).Error
+		require.NoError(t, err)
+	}
+	return ptx.PublicTxnID
+}
+
+func countPublicTxns(t *testing.T, ctx context.Context, ble *pubTxManager, from tktypes.EthAddress) int64 {
+	var count int64
+	err := ble.p.DB().WithContext(ctx).Table("public_txns").Where(`"from" = ?`, from).Count(&count).Error
+	require.NoError(t, err)
+	return count
+}
+
+func TestPurgeCompletedTransactions(t *testing.T) {
+	ctx, ble, done := newTestRetentionManager(t)
+	defer done()
+
+	old := time.Now().Add(-2 * time.Hour)
+	recent := time.Now()
+	addr1 := tktypes.RandAddress()
+	addr2 := tktypes.RandAddress()
+
+	// addr1: three old completed (more than one batch), one recent completed, one pending
+	for i := uint64(0); i < 3; i++ {
+		insertTestPublicTxn(t, ctx, ble, *addr1, i, &old)
+	}
+	insertTestPublicTxn(t, ctx, ble, *addr1, 3, &recent)
+	insertTestPublicTxn(t, ctx, ble, *addr1, 4, nil)
+	// addr2: completion order does not follow nonce order
+	insertTestPublicTxn(t, ctx, ble, *addr2, 1, &old)
+	insertTestPublicTxn(t, ctx, ble, *addr2, 0, &old)
+
+	purged, err := ble.purgeCompletedTransactions(ctx, time.Now().Add(-1*time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, 5, purged)
+
+	assert.Equal(t, int64(2), countPublicTxns(t, ctx, ble, *addr1))
+	assert.Equal(t, int64(0), countPublicTxns(t, ctx, ble, *addr2))
+
+	var orphans int64
+	err = ble.p.DB().WithContext(ctx).Table("public_txn_bindings").
+		Where(`"pub_txn_id" NOT IN (SELECT "pub_txn_id" FROM "public_txns")`).
+		Count(&orphans).Error
+	require.NoError(t, err)
+	assert.Zero(t, orphans)
+
+	// addr1 still has the recent completion as its highest
+	watermark, err := ble.getCompletedNonceWatermark(ctx, ble.p.NOTX(), *addr1)
+	require.NoError(t, err)
+	require.NotNil(t, watermark)
+	assert.Equal(t, uint64(3), *watermark)
+
+	// addr2 relies entirely on the purged watermark
+	watermark, err = ble.getCompletedNonceWatermark(ctx, ble.p.NOTX(), *addr2)
+	require.NoError(t, err)
+	require.NotNil(t, watermark)
+	assert.Equal(t, uint64(1), *watermark)
+
+	// unknown address has no watermark
+	watermark, err = ble.getCompletedNonceWatermark(ctx, ble.p.NOTX(), *tktypes.RandAddress())
+	require.NoError(t, err)
+	assert.Nil(t, watermark)
+
+	// running again is a no-op
+	purged, err = ble.purgeCompletedTransactions(ctx, time.Now().Add(-1*time.Hour))
+	require.NoError(t, err)
+	assert.Zero(t, purged)
+}
+
+func TestPurgeCompletedTransactionsWatermarkNeverDecreases(t *testing.T) {
+	ctx, ble, done := newTestRetentionManager(t)
+	defer done()
+
+	old := time.Now().Add(-2 * time.Hour)
+	addr := tktypes.RandAddress()
+
+	insertTestPublicTxn(t, ctx, ble, *addr, 10, &old)
+	_, err := ble.purgeCompletedTransactions(ctx, time.Now().Add(-1*time.Hour))
+	require.NoError(t, err)
+
+	insertTestPublicTxn(t, ctx, ble, *addr, 5, &old)
+	_, err = ble.purgeCompletedTransactions(ctx, time.Now().Add(-1*time.Hour))
+	require.NoError(t, err)
+
+	watermark, err := ble.getPurgedNonceWatermark(ctx, ble.p.NOTX(), *addr)
+	require.NoError(t, err)
+	require.NotNil(t, watermark)
+	assert.Equal(t, uint64(10), *watermark)
+}
+
+func TestPurgeCompletedTransactionsCancelled(t *testing.T) {
+	_, ble, done := newTestRetentionManager(t)
+	defer done()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := ble.purgeCompletedTransactions(ctx, time.Now())
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestPurgeCompletedTransactionsDBError(t *testing.T) {
+	ctx, ble, m, done := newTestPublicTxManager(t, false, func(mocks *mocksAndTestControl, conf *pldconf.PublicTxManagerConfig) {
+		mocks.disableManagerStart = true
+	})
+	defer done()
+
+	m.db.ExpectBegin()
+	m.db.ExpectQuery("SELECT.*public_txns").WillReturnError(fmt.Errorf("pop"))
+	m.db.ExpectRollback()
+
+	_, err := ble.purgeCompletedTransactions(ctx, time.Now())
+	assert.Regexp(t, "pop", err)
+}
+
+func TestRetentionLoopStartStop(t *testing.T) {
+	ctx, ble, done := newTestRetentionManager(t)
+
+	old := time.Now().Add(-2 * time.Hour)
+	addr := tktypes.RandAddress()
+	insertTestPublicTxn(t, ctx, ble, *addr, 0, &old)
+
+	ble.retentionMaxAge = 1 * time.Hour
+	ble.retentionLoopDone = make(chan struct{})
+	go ble.retentionLoop()
+
+	assert.Eventually(t, func() bool {
+		return countPublicTxns(t, ctx, ble, *addr) == 0
+	}, 5*time.Second, 10*time.Millisecond)
+
+	done()
+	<-ble.retentionLoopDone
+}
+
+func TestInitNextNonceFromPurgedWatermark(t *testing.T) {
+	ctx, ble, done := newTestRetentionManager(t)
+	defer done()
+
+	old := time.Now().Add(-2 * time.Hour)
+	addr := tktypes.RandAddress()
+	insertTestPublicTxn(t, ctx, ble, *addr, 41, &old)
+	_, err := ble.purgeCompletedTransactions(ctx, time.Now().Add(-1*time.Hour))
+	require.NoError(t, err)
+	require.Equal(t, int64(0), countPublicTxns(t, ctx, ble, *addr))
+
+	o := NewOrchestrator(ble, *addr, ble.conf)
+	err = o.initNextNonceFromDB(ctx)
+	require.NoError(t, err)
+	require.NotNil(t, o.nextNonce)
+	assert.Equal(t, uint64(42), *o.nextNonce)
+}
+
+func TestReconcileColdStartSeedsWatermarkFromChain(t *testing.T) {
+	ctx, ble, m, done := newTestPublicTxManager(t, true, func(mocks *mocksAndTestControl, conf *pldconf.PublicTxManagerConfig) {
+		mocks.disableManagerStart = true
+		conf.Orchestrator.ColdStartReconcile = confutil.P(true)
+	})
+	defer done()
+
+	addr := tktypes.RandAddress()
+	// Transactions recorded as pending, but the first three have already been mined
+	for i := uint64(0); i < 5; i++ {
+		insertTestPublicTxn(t, ctx, ble, *addr, i, nil)
+	}
+	m.ethClient.On("GetTransactionCount", mock.Anything, *addr).
+		Return(confutil.P(tktypes.HexUint64(3)), nil).Once()
+
+	o := NewOrchestrator(ble, *addr, ble.conf)
+	require.True(t, o.coldStartReconcile)
+	err := o.reconcileColdStart(ctx)
+	require.NoError(t, err)
+	require.NotNil(t, o.coldStartNonceFloor)
+	assert.Equal(t, uint64(2), *o.coldStartNonceFloor)
+	require.NotNil(t, o.completedNonceWatermark)
+	assert.Equal(t, uint64(2), *o.completedNonceWatermark)
+
+	// Nothing is recorded in the DB, as that is reserved for the nonces of purged transactions
+	watermark, err := ble.getCompletedNonceWatermark(ctx, ble.p.NOTX(), *addr)
+	require.NoError(t, err)
+	assert.Nil(t, watermark)
+
+	// So a restarted orchestrator goes back to the chain
+	m.ethClient.On("GetTransactionCount", mock.Anything, *addr).
+		Return(confutil.P(tktypes.HexUint64(4)), nil).Once()
+	o = NewOrchestrator(ble, *addr, ble.conf)
+	err = o.reconcileColdStart(ctx)
+	require.NoError(t, err)
+	require.NotNil(t, o.completedNonceWatermark)
+	assert.Equal(t, uint64(3), *o.completedNonceWatermark)
+}
+
+func TestReconcileColdStartNothingMined(t *testing.T) {
+	ctx, ble, m, done := newTestPublicTxManager(t, true, func(mocks *mocksAndTestControl, conf *pldconf.PublicTxManagerConfig) {
+		mocks.disableManagerStart = true
+	})
+	defer done()
+
+	addr := tktypes.RandAddress()
+	m.ethClient.On("GetTransactionCount", mock.Anything, *addr).
+		Return(confutil.P(tktypes.HexUint64(0)), nil).Once()
+
+	o := NewOrchestrator(ble, *addr, ble.conf)
+	require.False(t, o.coldStartReconcile)
+	err := o.reconcileColdStart(ctx)
+	require.NoError(t, err)
+	assert.Nil(t, o.coldStartNonceFloor)
+	assert.Nil(t, o.completedNonceWatermark)
+
+	watermark, err := ble.getCompletedNonceWatermark(ctx, ble.p.NOTX(), *addr)
+	require.NoError(t, err)
+	assert.Nil(t, watermark)
+}
+
+func TestReconcileColdStartUsesRecordedCompletion(t *testing.T) {
+	ctx, ble, _, done := newTestPublicTxManager(t, true, func(mocks *mocksAndTestControl, conf *pldconf.PublicTxManagerConfig) {
+		mocks.disableManagerStart = true
+	})
+	defer done()
+
+	addr := tktypes.RandAddress()
+	now := time.Now()
+	insertTestPublicTxn(t, ctx, ble, *addr, 7, &now)
+
+	// No call to the chain is made
+	o := NewOrchestrator(ble, *addr, ble.conf)
+	err := o.reconcileColdStart(ctx)
+	require.NoError(t, err)
+	require.NotNil(t, o.completedNonceWatermark)
+	assert.Equal(t, uint64(7), *o.completedNonceWatermark)
+}
+
+func TestReconcileColdStartChainError(t *testing.T) {
+	ctx, ble, m, done := newTestPublicTxManager(t, true, func(mocks *mocksAndTestControl, conf *pldconf.PublicTxManagerConfig) {
+		mocks.disableManagerStart = true
+	})
+	defer done()
+
+	addr := tktypes.RandAddress()
+	m.ethClient.On("GetTransactionCount", mock.Anything, *addr).Return(nil, fmt.Errorf("pop"))
+
+	o := NewOrchestrator(ble, *addr, ble.conf)
+	err := o.reconcileColdStart(ctx)
+	assert.Regexp(t, "pop", err)
+	assert.Nil(t, o.completedNonceWatermark)
+}
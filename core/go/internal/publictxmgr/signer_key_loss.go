@@ -0,0 +1,161 @@
+/*
+ * Copyright © 2024 Kaleido, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with
+ * the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package publictxmgr
+
+import (
+	"context"
+	"errors"
+	"slices"
+
+	"github.com/kaleido-io/paladin/config/pkg/confutil"
+	"github.com/kaleido-io/paladin/config/pkg/pldconf"
+	"github.com/kaleido-io/paladin/core/internal/components"
+	"github.com/kaleido-io/paladin/core/internal/msgs"
+	"github.com/kaleido-io/paladin/core/pkg/persistence"
+	"github.com/kaleido-io/paladin/toolkit/pkg/i18n"
+	"github.com/kaleido-io/paladin/toolkit/pkg/log"
+	"github.com/kaleido-io/paladin/toolkit/pkg/pldapi"
+	"github.com/kaleido-io/paladin/toolkit/pkg/tkmsgs"
+	"github.com/kaleido-io/paladin/toolkit/pkg/tktypes"
+)
+
+// Errors that mean the key for a signing address no longer exists, as opposed to a signer that is
+// temporarily unavailable. Matched on the message key of any error in the chain, so the error can
+// be wrapped on the way back from the key manager.
+var permanentKeyErrors = []i18n.ErrorMessageKey{
+	msgs.MsgKeyManagerVerifierLookupNotFound, // the key mapping for the address has been removed
+	tkmsgs.MsgSigningModuleKeyNotExist,       // the key store no longer has the key
+	tkmsgs.MsgSigningKeyCannotBeResolved,     // the key store no longer has the key
+}
+
+func isPermanentKeyError(err error) bool {
+	var pdErr i18n.PDError
+	for errors.As(err, &pdErr) {
+		if slices.Contains(permanentKeyErrors, pdErr.MessageKey()) {
+			return true
+		}
+		err = errors.Unwrap(pdErr)
+	}
+	return false
+}
+
+func parseSignerKeyLossPolicy(ctx context.Context, conf *pldconf.PublicTxManagerManagerConfig) (string, error) {
+	policy := confutil.StringNotEmpty(conf.SignerKeyLossPolicy, *pldconf.PublicTxManagerDefaults.Manager.SignerKeyLossPolicy)
+	switch policy {
+	case pldconf.SignerKeyLossPolicyPause, pldconf.SignerKeyLossPolicyFail:
+		return policy, nil
+	default:
+		return "", i18n.NewError(ctx, msgs.MsgPublicTxInvalidKeyLossPolicy, policy)
+	}
+}
+
+// Applies the configured policy to a signing address whose key no longer exists:
+//   - pause: no orchestrator is started for the address again until the node is restarted, leaving
+//     its transactions pending for an operator to restore the key
+//   - fail: the pending transactions of the address that have never been submitted are suspended, and
+//     the Paladin transactions they are bound to are finalized with a failure receipt giving the reason.
+//     Transactions that have been submitted might still be mined, so are left to be confirmed
+func (ble *pubTxManager) applySignerKeyLossPolicy(ctx context.Context, from tktypes.EthAddress, keyErr error) error {
+	reason := i18n.ExpandWithCode(ctx, i18n.MessageKey(msgs.MsgPublicTxSignerKeyLost), from, keyErr.Error())
+	if ble.signerKeyLossPolicy == pldconf.SignerKeyLossPolicyFail {
+		log.L(ctx).Errorf("%s - failing all pending transactions", reason)
+		return ble.failPendingTransactionsForSigner(ctx, from, reason)
+	}
+	log.L(ctx).Errorf("%s - pausing the signing address until the node is restarted", reason)
+	ble.signingAddressesKeyLostMux.Lock()
+	defer ble.signingAddressesKeyLostMux.Unlock()
+	ble.signingAddressesKeyLost[from] = reason
+	return nil
+}
+
+func (ble *pubTxManager) isSignerKeyLostPaused(from tktypes.EthAddress) bool {
+	ble.signingAddressesKeyLostMux.Lock()
+	defer ble.signingAddressesKeyLostMux.Unlock()
+	_, paused := ble.signingAddressesKeyLost[from]
+	return paused
+}
+
+func (ble *pubTxManager) failPendingTransactionsForSigner(ctx context.Context, from tktypes.EthAddress, reason string) error {
+	return ble.p.Transaction(ctx, func(ctx context.Context, dbTX persistence.DBTX) error {
+		var pending []*DBPublicTxn
+		err := dbTX.DB().
+			WithContext(ctx).
+			Table("public_txns").
+			Joins("Completed").
+			Where(`"Completed"."tx_hash" IS NULL`).
+			Where(`"public_txns"."from" = ?`, from).
+			Where(`"public_txns"."suspended" IS FALSE`).
+			Where(`NOT EXISTS (SELECT 1 FROM "public_submissions" WHERE "public_submissions"."pub_txn_id" = "public_txns"."pub_txn_id")`).
+			Find(&pending).
+			Error
+		if err != nil || len(pending) == 0 {
+			return err
+		}
+		pubTxnIDs := make([]uint64, len(pending))
+		for i, ptx := range pending {
+			pubTxnIDs[i] = ptx.PublicTxnID
+		}
+		log.L(ctx).Warnf("Failing %d unsubmitted transactions for signing address %s", len(pending), from)
+		err = dbTX.DB().
+			WithContext(ctx).
+			Table("public_txns").
+			Where(`"pub_txn_id" IN (?)`, pubTxnIDs).
+			UpdateColumns(map[string]any{
+				"suspended":        true,
+				"failure_category": string(pldapi.PublicTxFailureSignerKeyLost),
+				"updated":          tktypes.TimestampNow(),
+			}).
+			Error
+		if err != nil {
+			return err
+		}
+
+		var bindings []*DBPublicTxnBinding
+		err = dbTX.DB().
+			WithContext(ctx).
+			Table("public_txn_bindings").
+			Where(`"pub_txn_id" IN (?)`, pubTxnIDs).
+			Find(&bindings).
+			Error
+		if err != nil || len(bindings) == 0 {
+			return err
+		}
+		receipts := make([]*components.ReceiptInput, len(bindings))
+		for i, binding := range bindings {
+			receipts[i] = &components.ReceiptInput{
+				ReceiptType:    components.RT_FailedWithMessage,
+				TransactionID:  binding.Transaction,
+				FailureMessage: reason,
+			}
+		}
+		return ble.rootTxMgr.FinalizeTransactions(ctx, dbTX, receipts)
+	})
+}
+
+// Called on the orchestrator loop when signing fails because the key no longer exists. The policy is
+// only applied once, after which the orchestrator stops. If applying it fails, it is tried again the
+// next time the failure is seen.
+func (oc *orchestrator) handleSignerKeyLost(ctx context.Context, keyErr error) {
+	if oc.signerKeyLost {
+		return
+	}
+	if err := oc.applySignerKeyLossPolicy(ctx, oc.signingAddress, keyErr); err != nil {
+		log.L(ctx).Errorf("Failed to apply the key loss policy for signing address %s: %s", oc.signingAddress, err)
+		return
+	}
+	oc.signerKeyLost = true
+	oc.Stop()
+}
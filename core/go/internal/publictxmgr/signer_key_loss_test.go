@@ -0,0 +1,228 @@
+/*
+ * Copyright © 2024 Kaleido, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with
+ * the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package publictxmgr
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/hyperledger/firefly-common/pkg/fftypes"
+	"github.com/kaleido-io/paladin/config/pkg/confutil"
+	"github.com/kaleido-io/paladin/config/pkg/pldconf"
+	"github.com/kaleido-io/paladin/core/internal/components"
+	"github.com/kaleido-io/paladin/core/internal/msgs"
+	"github.com/kaleido-io/paladin/core/pkg/persistence"
+	"github.com/kaleido-io/paladin/toolkit/pkg/i18n"
+	"github.com/kaleido-io/paladin/toolkit/pkg/pldapi"
+	"github.com/kaleido-io/paladin/toolkit/pkg/tkmsgs"
+	"github.com/kaleido-io/paladin/toolkit/pkg/tktypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsPermanentKeyError(t *testing.T) {
+	ctx := context.Background()
+	assert.True(t, isPermanentKeyError(i18n.NewError(ctx, msgs.MsgKeyManagerVerifierLookupNotFound)))
+	assert.True(t, isPermanentKeyError(i18n.NewError(ctx, tkmsgs.MsgSigningModuleKeyNotExist, "key1")))
+	assert.True(t, isPermanentKeyError(i18n.WrapError(ctx, i18n.NewError(ctx, tkmsgs.MsgSigningKeyCannotBeResolved), msgs.MsgPublicTxPreSignHookFailed, "0x00", 1)))
+	assert.True(t, isPermanentKeyError(fmt.Errorf("wrapped: %w", i18n.NewError(ctx, tkmsgs.MsgSigningModuleKeyNotExist, "key1"))))
+	// the code appearing in the text of another error is not enough
+	assert.False(t, isPermanentKeyError(fmt.Errorf("plugin error: %s", i18n.NewError(ctx, tkmsgs.MsgSigningKeyCannotBeResolved))))
+	assert.False(t, isPermanentKeyError(i18n.NewError(ctx, msgs.MsgPublicTxSignerKeyLost, "0x00", tkmsgs.MsgSigningModuleKeyNotExist)))
+	assert.False(t, isPermanentKeyError(i18n.NewError(ctx, tkmsgs.MsgSigningModuleFSError)))
+	assert.False(t, isPermanentKeyError(fmt.Errorf("pop")))
+	assert.False(t, isPermanentKeyError(nil))
+}
+
+func TestParseSignerKeyLossPolicy(t *testing.T) {
+	ctx := context.Background()
+
+	policy, err := parseSignerKeyLossPolicy(ctx, &pldconf.PublicTxManagerManagerConfig{})
+	require.NoError(t, err)
+	assert.Equal(t, pldconf.SignerKeyLossPolicyPause, policy)
+
+	policy, err = parseSignerKeyLossPolicy(ctx, &pldconf.PublicTxManagerManagerConfig{
+		SignerKeyLossPolicy: confutil.P(pldconf.SignerKeyLossPolicyFail),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, pldconf.SignerKeyLossPolicyFail, policy)
+
+	_, err = parseSignerKeyLossPolicy(ctx, &pldconf.PublicTxManagerManagerConfig{
+		SignerKeyLossPolicy: confutil.P("wrong"),
+	})
+	assert.Regexp(t, "PD011958.*wrong", err)
+}
+
+func TestSignerKeyLossPolicyPause(t *testing.T) {
+	ctx, o, _, done := newTestOrchestrator(t)
+	defer done()
+	it, mTS := newInflightTransaction(o, 1)
+	it.testOnlyNoActionMode = true
+	mTS.statusUpdater = &mockStatusUpdater{
+		updateSubStatus: func(ctx context.Context, imtx InMemoryTxStateReadOnly, subStatus BaseTxSubStatus, action BaseTxAction, info, err *fftypes.JSONAny, actionOccurred *tktypes.Timestamp) error {
+			return nil
+		},
+	}
+	mTS.ApplyInMemoryUpdates(ctx, &BaseTXUpdates{
+		GasPricing: &pldapi.PublicTxGasPricing{
+			GasPrice: tktypes.Uint64ToUint256(10),
+		},
+	})
+
+	// trigger signing
+	_ = it.ProduceLatestInFlightStageContext(ctx, &OrchestratorContext{})
+	rsc := it.stateManager.GetRunningStageContext(ctx)
+	require.NotNil(t, rsc)
+	assert.Equal(t, InFlightTxStageSigning, rsc.Stage)
+
+	// the key store reports the key is gone
+	it.stateManager.(*inFlightTransactionState).bufferedStageOutputs = make([]*StageOutput, 0)
+	it.stateManager.AddSignOutput(ctx, nil, nil, nil, i18n.NewError(ctx, tkmsgs.MsgSigningModuleKeyNotExist, "key1"))
+	_ = it.ProduceLatestInFlightStageContext(ctx, &OrchestratorContext{})
+	require.NotNil(t, rsc.StageOutputsToBePersisted)
+	assert.Equal(t, pldapi.PublicTxFailureSignerKeyLost, *rsc.StageOutputsToBePersisted.TxUpdates.FailureCategory)
+
+	// the orchestrator stops, and the signing address stays paused
+	assert.True(t, o.signerKeyLost)
+	assert.Len(t, o.stopProcess, 1)
+	ble := o.pubTxManager
+	assert.True(t, ble.isSignerKeyLostPaused(o.signingAddress))
+	assert.Regexp(t, "PD011959.*PD020806", ble.signingAddressesKeyLost[o.signingAddress])
+	ble.pollAddress(ctx, o.signingAddress)
+	assert.Nil(t, ble.getOrchestratorForAddress(o.signingAddress))
+
+	// the policy is only applied once
+	o.handleSignerKeyLost(ctx, i18n.NewError(ctx, tkmsgs.MsgSigningModuleKeyNotExist, "key1"))
+	assert.Len(t, o.stopProcess, 1)
+}
+
+func TestSignerKeyLossPolicyFail(t *testing.T) {
+	ctx, ble, m, done := newTestPublicTxManager(t, true, func(mocks *mocksAndTestControl, conf *pldconf.PublicTxManagerConfig) {
+		mocks.disableManagerStart = true
+		conf.Manager.SignerKeyLossPolicy = confutil.P(pldconf.SignerKeyLossPolicyFail)
+	})
+	defer done()
+
+	from := tktypes.RandAddress()
+	txIDs := make([]uuid.UUID, 3)
+	txs := make([]*components.PublicTxSubmission, 3)
+	for i := range txs {
+		txIDs[i] = uuid.New()
+		fakeTxManagerInsert(t, ble.p.DB(), txIDs[i], "signer1")
+		txs[i] = &components.PublicTxSubmission{
+			Bindings: []*components.PaladinTXReference{
+				{TransactionID: txIDs[i], TransactionType: pldapi.TransactionTypePublic.Enum()},
+			},
+			PublicTxInput: pldapi.PublicTxInput{
+				From: from,
+				PublicTxOptions: pldapi.PublicTxOptions{
+					Gas: confutil.P(tktypes.HexUint64(100000)),
+				},
+			},
+		}
+	}
+	err := ble.p.Transaction(ctx, func(ctx context.Context, dbTX persistence.DBTX) (err error) {
+		_, err = ble.WriteNewTransactions(ctx, dbTX, txs)
+		return err
+	})
+	require.NoError(t, err)
+
+	// The last one has been submitted, so might still be mined
+	var submitted DBPublicTxn
+	err = ble.p.DB().Table("public_txns").Order("pub_txn_id DESC").First(&submitted).Error
+	require.NoError(t, err)
+	err = ble.p.DB().Create(&DBPubTxnSubmission{
+		PublicTxnID:     submitted.PublicTxnID,
+		Created:         tktypes.TimestampNow(),
+		TransactionHash: tktypes.RandBytes32(),
+	}).Error
+	require.NoError(t, err)
+
+	// A transaction from another signing address is not affected
+	otherTxID := uuid.New()
+	fakeTxManagerInsert(t, ble.p.DB(), otherTxID, "signer2")
+	err = ble.p.Transaction(ctx, func(ctx context.Context, dbTX persistence.DBTX) (err error) {
+		_, err = ble.WriteNewTransactions(ctx, dbTX, []*components.PublicTxSubmission{
+			{
+				Bindings: []*components.PaladinTXReference{
+					{TransactionID: otherTxID, TransactionType: pldapi.TransactionTypePublic.Enum()},
+				},
+				PublicTxInput: pldapi.PublicTxInput{
+					From: tktypes.RandAddress(),
+					PublicTxOptions: pldapi.PublicTxOptions{
+						Gas: confutil.P(tktypes.HexUint64(100000)),
+					},
+				},
+			},
+		})
+		return err
+	})
+	require.NoError(t, err)
+
+	m.txManager.On("FinalizeTransactions", mock.Anything, mock.Anything, mock.MatchedBy(func(receipts []*components.ReceiptInput) bool {
+		if len(receipts) != 2 {
+			return false
+		}
+		for i, r := range receipts {
+			if r.TransactionID != txIDs[i] || r.ReceiptType != components.RT_FailedWithMessage {
+				return false
+			}
+			assert.Regexp(t, "PD011959.*"+from.String()+".*PD020806", r.FailureMessage)
+		}
+		return true
+	})).Return(nil).Once()
+
+	o := NewOrchestrator(ble, *from, ble.conf)
+	o.handleSignerKeyLost(ctx, i18n.NewError(ctx, tkmsgs.MsgSigningModuleKeyNotExist, "key1"))
+	assert.True(t, o.signerKeyLost)
+	assert.Len(t, o.stopProcess, 1)
+	assert.False(t, ble.isSignerKeyLostPaused(*from))
+
+	var ptxs []*DBPublicTxn
+	err = ble.p.DB().Table("public_txns").Order("pub_txn_id").Find(&ptxs).Error
+	require.NoError(t, err)
+	require.Len(t, ptxs, 4)
+	for _, ptx := range ptxs[0:2] {
+		assert.True(t, ptx.Suspended)
+		assert.Equal(t, string(pldapi.PublicTxFailureSignerKeyLost), *ptx.FailureCategory)
+	}
+	for _, ptx := range ptxs[2:4] {
+		assert.False(t, ptx.Suspended)
+		assert.Nil(t, ptx.FailureCategory)
+	}
+}
+
+func TestSignerKeyLossPolicyFailError(t *testing.T) {
+	ctx, ble, m, done := newTestPublicTxManager(t, false, func(mocks *mocksAndTestControl, conf *pldconf.PublicTxManagerConfig) {
+		mocks.disableManagerStart = true
+		conf.Manager.SignerKeyLossPolicy = confutil.P(pldconf.SignerKeyLossPolicyFail)
+	})
+	defer done()
+
+	m.db.ExpectBegin()
+	m.db.ExpectQuery("SELECT.*public_txns").WillReturnError(fmt.Errorf("pop"))
+	m.db.ExpectRollback()
+
+	// the policy is tried again next time, if it could not be applied
+	o := NewOrchestrator(ble, *tktypes.RandAddress(), ble.conf)
+	o.handleSignerKeyLost(ctx, i18n.NewError(ctx, tkmsgs.MsgSigningModuleKeyNotExist, "key1"))
+	assert.False(t, o.signerKeyLost)
+	assert.Empty(t, o.stopProcess)
+	require.NoError(t, m.db.ExpectationsWereMet())
+}
@@ -17,6 +17,7 @@ package publictxmgr
 
 import (
 	"context"
+	"slices"
 
 	"github.com/kaleido-io/paladin/config/pkg/pldconf"
 	"github.com/kaleido-io/paladin/core/internal/flushwriter"
@@ -47,6 +48,15 @@ func (sw *submissionWriter) runBatch(ctx context.Context, tx persistence.DBTX, v
 		}).
 		Create(values).
 		Error
+	if err == nil {
+		pubTxnIDs := make([]uint64, 0, len(values))
+		for _, v := range values {
+			if !slices.Contains(pubTxnIDs, v.PublicTxnID) {
+				pubTxnIDs = append(pubTxnIDs, v.PublicTxnID)
+			}
+		}
+		err = touchTransactions(ctx, tx, pubTxnIDs)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -19,11 +19,14 @@ import (
 	"context"
 	"encoding/json"
 	"math/big"
+	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/hyperledger/firefly-common/pkg/fftypes"
+	"github.com/hyperledger/firefly-signer/pkg/abi"
 	"github.com/hyperledger/firefly-signer/pkg/ethsigner"
 	"github.com/hyperledger/firefly-signer/pkg/ethtypes"
 	"github.com/kaleido-io/paladin/config/pkg/confutil"
@@ -74,6 +77,7 @@ type pubTxManager struct {
 
 	conf             *pldconf.PublicTxManagerConfig
 	thMetrics        *publicTxEngineMetrics
+	clock            Clock
 	p                persistence.Persistence
 	bIndexer         blockindexer.BlockIndexer
 	ethClient        ethclient.EthClient
@@ -87,24 +91,52 @@ type pubTxManager struct {
 	// a map of signing addresses and transaction engines
 	inFlightOrchestrators       map[tktypes.EthAddress]*orchestrator
 	signingAddressesPausedUntil map[tktypes.EthAddress]time.Time
+	orchestratorLastStarted     map[tktypes.EthAddress]time.Time // only modified on the engine loop routine
 	inFlightOrchestratorMux     sync.Mutex
 	inFlightOrchestratorStale   chan bool
+	orchestratorNudges          chan tktypes.EthAddress
+	signingAddressesKeyLost     map[tktypes.EthAddress]string // paused by the pause key loss policy, with the reason
+	signingAddressesKeyLostMux  sync.Mutex
 
-	// inbound concurrency control TBD
+	// serializes nonce assignment for each signing address, across all orchestrators
+	signingAddressLocks    map[tktypes.EthAddress]*signingAddressLock
+	signingAddressLocksMux sync.Mutex
+
+	// inbound admission control - disabled when maxPendingBacklog is zero
+	maxPendingBacklog int
+	pendingBacklog    atomic.Int64
 
 	// engine config
 	maxInflight              int
 	orchestratorIdleTimeout  time.Duration
 	orchestratorStaleTimeout time.Duration
+	orchestratorWatchdog     time.Duration // disabled when zero
 	orchestratorSwapTimeout  time.Duration
+	orchestratorPausePolicy  string
+	signingAddressPriority   map[tktypes.EthAddress]int
+	signerKeyLossPolicy      string
 	retry                    *retry.Retry
 	enginePollingInterval    time.Duration
 	nonceCacheTimeout        time.Duration
+	confirmationDepth        uint64
 	engineLoopDone           chan struct{}
 
 	activityRecordCache     cache.Cache[uint64, *txActivityRecords]
 	maxActivityRecordsPerTx int
 
+	// retention config - disabled when retentionMaxAge is zero
+	retentionMaxAge    time.Duration
+	retentionInterval  time.Duration
+	retentionBatchSize int
+	retentionLoopDone  chan struct{}
+
+	// gas price history config
+	gasPriceHistoryEnabled   bool
+	gasPriceHistoryInterval  time.Duration
+	gasPriceHistoryRetention time.Duration
+	gasPriceHistoryPageSize  int
+	gasPriceHistoryLoopDone  chan struct{}
+
 	// balance manager
 	balanceManager BalanceManager
 
@@ -112,10 +144,24 @@ type pubTxManager struct {
 	gasPriceIncreaseMax     *big.Int
 	gasPriceIncreasePercent int
 
-	// gas limit config
-	gasEstimateFactor float64
+	// gas limit config, with per-signer overrides of the multiplier and floor
+	gasLimitDefault  *gasLimitPolicy
+	gasLimitPolicies map[tktypes.EthAddress]*gasLimitPolicy
+	blockGasLimit    uint64
+
+	// per-signer gas price overrides
+	gasPriceOverrides map[tktypes.EthAddress]*gasPriceOverride
+
+	// optional hook to adjust transactions before they are signed
+	preSignHook components.PublicTxPreSignHook
 }
 
+const orchestratorNudgeQueueLength = 50
+
+// When filling free orchestrator slots we poll this many times as many candidate signing addresses
+// as there are free slots, so we can prefer the ones that have not had an orchestrator recently
+const orchestratorPollCandidateFactor = 4
+
 type txActivityRecords struct {
 	lock    sync.Mutex
 	records []pldapi.TransactionActivityRecord
@@ -126,7 +172,6 @@ func NewPublicTransactionManager(ctx context.Context, conf *pldconf.PublicTxMana
 
 	gasPriceClient := NewGasPriceClient(ctx, conf)
 	gasPriceIncreaseMax := confutil.BigIntOrNil(conf.GasPrice.IncreaseMax)
-	gasEstimateFactor := confutil.Float64Min(conf.GasLimit.GasEstimateFactor, 1.0, *pldconf.PublicTxManagerDefaults.GasLimit.GasEstimateFactor)
 
 	log.L(ctx).Debugf("Enterprise transaction handler created")
 
@@ -136,25 +181,42 @@ func NewPublicTransactionManager(ctx context.Context, conf *pldconf.PublicTxMana
 		ctx:                         ptmCtx,
 		ctxCancel:                   ptmCtxCancel,
 		conf:                        conf,
+		thMetrics:                   newPublicTxEngineMetrics(),
+		clock:                       realClock{},
 		gasPriceClient:              gasPriceClient,
 		inFlightOrchestratorStale:   make(chan bool, 1),
+		orchestratorNudges:          make(chan tktypes.EthAddress, orchestratorNudgeQueueLength),
+		inFlightOrchestrators:       make(map[tktypes.EthAddress]*orchestrator),
 		signingAddressesPausedUntil: make(map[tktypes.EthAddress]time.Time),
+		orchestratorLastStarted:     make(map[tktypes.EthAddress]time.Time),
+		signingAddressesKeyLost:     make(map[tktypes.EthAddress]string),
+		signingAddressLocks:         make(map[tktypes.EthAddress]*signingAddressLock),
 		maxInflight:                 confutil.IntMin(conf.Manager.MaxInFlightOrchestrators, 1, *pldconf.PublicTxManagerDefaults.Manager.MaxInFlightOrchestrators),
 		orchestratorSwapTimeout:     confutil.DurationMin(conf.Manager.OrchestratorSwapTimeout, 0, *pldconf.PublicTxManagerDefaults.Manager.OrchestratorSwapTimeout),
 		orchestratorStaleTimeout:    confutil.DurationMin(conf.Manager.OrchestratorStaleTimeout, 0, *pldconf.PublicTxManagerDefaults.Manager.OrchestratorStaleTimeout),
 		orchestratorIdleTimeout:     confutil.DurationMin(conf.Manager.OrchestratorIdleTimeout, 0, *pldconf.PublicTxManagerDefaults.Manager.OrchestratorIdleTimeout),
+		orchestratorWatchdog:        confutil.DurationMin(conf.Manager.OrchestratorWatchdog, 0, "0"),
 		enginePollingInterval:       confutil.DurationMin(conf.Manager.Interval, 50*time.Millisecond, *pldconf.PublicTxManagerDefaults.Manager.Interval),
 		nonceCacheTimeout:           confutil.DurationMin(conf.Manager.NonceCacheTimeout, 0, *pldconf.PublicTxManagerDefaults.Manager.NonceCacheTimeout),
+		confirmationDepth:           uint64(confutil.IntMin(conf.Manager.ConfirmationDepth, 0, *pldconf.PublicTxManagerDefaults.Manager.ConfirmationDepth)),
+		maxPendingBacklog:           confutil.IntMin(conf.Manager.MaxPendingBacklog, 0, 0),
 		retry:                       retry.NewRetryIndefinite(&conf.Manager.Retry),
 		gasPriceIncreaseMax:         gasPriceIncreaseMax,
 		gasPriceIncreasePercent:     confutil.Int(conf.GasPrice.IncreasePercentage, *pldconf.PublicTxManagerDefaults.GasPrice.IncreasePercentage),
 		activityRecordCache:         cache.NewCache[uint64, *txActivityRecords](&conf.Manager.ActivityRecords.CacheConfig, &pldconf.PublicTxManagerDefaults.Manager.ActivityRecords.CacheConfig),
 		maxActivityRecordsPerTx:     confutil.Int(conf.Manager.ActivityRecords.RecordsPerTransaction, *pldconf.PublicTxManagerDefaults.Manager.ActivityRecords.RecordsPerTransaction),
-		gasEstimateFactor:           gasEstimateFactor,
+		retentionMaxAge:             confutil.DurationMin(conf.Manager.Retention.MaxAge, 0, "0"),
+		retentionInterval:           confutil.DurationMin(conf.Manager.Retention.Interval, 1*time.Second, *pldconf.PublicTxManagerDefaults.Manager.Retention.Interval),
+		retentionBatchSize:          confutil.IntMin(conf.Manager.Retention.BatchSize, 1, *pldconf.PublicTxManagerDefaults.Manager.Retention.BatchSize),
+		gasPriceHistoryEnabled:      confutil.Bool(conf.GasPrice.History.Enabled, *pldconf.PublicTxManagerDefaults.GasPrice.History.Enabled),
+		gasPriceHistoryInterval:     confutil.DurationMin(conf.GasPrice.History.SampleInterval, 1*time.Second, *pldconf.PublicTxManagerDefaults.GasPrice.History.SampleInterval),
+		gasPriceHistoryRetention:    confutil.DurationMin(conf.GasPrice.History.Retention, 1*time.Minute, *pldconf.PublicTxManagerDefaults.GasPrice.History.Retention),
+		gasPriceHistoryPageSize:     gasPriceHistoryQueryPageSize,
 	}
 }
 
 func (ble *pubTxManager) PreInit(pic components.PreInitComponents) (result *components.ManagerInitResult, err error) {
+	ble.thMetrics.register(pic.MetricsManager().Registry())
 	return &components.ManagerInitResult{}, nil
 }
 
@@ -169,6 +231,27 @@ func (ble *pubTxManager) PostInit(pic components.AllComponents) error {
 	ble.rootTxMgr = pic.TxManager()
 	ble.submissionWriter = newSubmissionWriter(ble.ctx, ble.p, ble.conf)
 
+	gasPriceOverrides, err := parseGasPriceOverrides(ctx, ble.conf.GasPrice.SignerOverrides, &ble.conf.GasPrice.Strategies)
+	if err != nil {
+		return err
+	}
+	ble.gasPriceOverrides = gasPriceOverrides
+
+	ble.orchestratorPausePolicy, ble.signingAddressPriority, err = parseOrchestratorPausePolicy(ctx, &ble.conf.Manager)
+	if err != nil {
+		return err
+	}
+
+	ble.signerKeyLossPolicy, err = parseSignerKeyLossPolicy(ctx, &ble.conf.Manager)
+	if err != nil {
+		return err
+	}
+
+	ble.gasLimitDefault, ble.gasLimitPolicies, ble.blockGasLimit, err = parseGasLimitPolicies(ctx, &ble.conf.GasLimit)
+	if err != nil {
+		return err
+	}
+
 	balanceManager, err := NewBalanceManagerWithInMemoryTracking(ctx, ble.conf, ble)
 	if err != nil {
 		log.L(ctx).Errorf("Failed to create balance manager for public transaction manager due to %+v", err)
@@ -192,6 +275,14 @@ func (ble *pubTxManager) Start() error {
 		log.L(ctx).Debugf("Kicking off  enterprise handler engine loop")
 		go ble.engineLoop()
 	}
+	if ble.retentionMaxAge > 0 && ble.retentionLoopDone == nil {
+		ble.retentionLoopDone = make(chan struct{})
+		go ble.retentionLoop()
+	}
+	if ble.gasPriceHistoryEnabled && ble.gasPriceHistoryLoopDone == nil {
+		ble.gasPriceHistoryLoopDone = make(chan struct{})
+		go ble.gasPriceHistoryLoop()
+	}
 	ble.MarkInFlightOrchestratorsStale()
 	ble.submissionWriter.Start()
 	log.L(ctx).Infof("Started public transaction manager")
@@ -206,6 +297,12 @@ func (ble *pubTxManager) Stop() {
 	if ble.engineLoopDone != nil {
 		<-ble.engineLoopDone
 	}
+	if ble.retentionLoopDone != nil {
+		<-ble.retentionLoopDone
+	}
+	if ble.gasPriceHistoryLoopDone != nil {
+		<-ble.gasPriceHistoryLoopDone
+	}
 }
 
 func buildEthTX(
@@ -234,6 +331,9 @@ func buildEthTX(
 }
 
 func (ble *pubTxManager) SingleTransactionSubmit(ctx context.Context, txi *components.PublicTxSubmission) (tx *pldapi.PublicTx, err error) {
+	if txi.DryRun {
+		return ble.dryRunTransaction(ctx, txi)
+	}
 	var txs []*pldapi.PublicTx
 	err = ble.p.Transaction(ctx, func(ctx context.Context, dbTX persistence.DBTX) error {
 		err := ble.ValidateTransaction(ctx, dbTX, txi)
@@ -248,6 +348,105 @@ func (ble *pubTxManager) SingleTransactionSubmit(ctx context.Context, txi *compo
 	return tx, err
 }
 
+// submits an auto-fueling transaction, and increments the cumulative count for the funding source in the same DB transaction
+func (ble *pubTxManager) submitFuelingTransaction(ctx context.Context, txi *components.PublicTxSubmission) (tx *pldapi.PublicTx, err error) {
+	var txs []*pldapi.PublicTx
+	err = ble.p.Transaction(ctx, func(ctx context.Context, dbTX persistence.DBTX) error {
+		err := ble.ValidateTransaction(ctx, dbTX, txi)
+		if err == nil {
+			// fueling is not subject to admission control, as it is needed to drain the backlog
+			txs, err = ble.writeNewSubmissions(ctx, dbTX, []*components.PublicTxSubmission{txi}, nil)
+		}
+		if err == nil {
+			err = dbTX.DB().
+				WithContext(ctx).
+				Table("public_fueling_sources").
+				Clauses(clause.OnConflict{
+					Columns: []clause.Column{{Name: "source"}},
+					DoUpdates: clause.Assignments(map[string]any{
+						"fueling_count": gorm.Expr(`"public_fueling_sources"."fueling_count" + 1`),
+						"updated":       gorm.Expr("excluded.updated"),
+					}),
+				}).
+				Create(&DBPublicFuelingSource{Source: *txi.From, FuelingCount: 1, Updated: tktypes.TimestampNow()}).
+				Error
+		}
+		return err
+	})
+	if err == nil {
+		tx = txs[0]
+	}
+	return tx, err
+}
+
+// Runs the checks a submission goes through before it is broadcast - gas estimation, nonce calculation, balance
+// sufficiency, and signing to calculate the hash - without writing the transaction to the DB or starting an
+// orchestrator for the signing address. The nonce is the one that would be assigned if the transaction were
+// submitted now, and is not reserved.
+func (ble *pubTxManager) dryRunTransaction(ctx context.Context, txi *components.PublicTxSubmission) (*pldapi.PublicTx, error) {
+	if err := ble.ValidateTransaction(ctx, ble.p.NOTX(), txi); err != nil {
+		return nil, err
+	}
+
+	nonce, err := ble.GetNextNonce(ctx, *txi.From)
+	if err != nil {
+		return nil, err
+	}
+
+	gasPricing, err := ble.gasPriceClient.GetGasPriceObject(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !ble.gasPriceClient.HasZeroGasPrice(ctx) {
+		gasPricing = ble.gasPriceOverrides[*txi.From].apply(gasPricing)
+	}
+
+	required, _ := calculateGasRequiredForTransaction(ctx, gasPricing, txi.Gas.Uint64())
+	if required == nil {
+		required = big.NewInt(0)
+	}
+	if txi.Value != nil {
+		required = required.Add(required, txi.Value.Int())
+	}
+	if required.Sign() > 0 {
+		account, err := ble.balanceManager.GetAddressBalance(ctx, *txi.From)
+		if err != nil {
+			return nil, err
+		}
+		if account.Balance.Cmp(required) < 0 {
+			return nil, i18n.NewError(ctx, msgs.MsgPublicTxDryRunBalance, account.Balance.String(), txi.From, required.String())
+		}
+	}
+
+	options := pldapi.PublicTxOptions{
+		Gas:                txi.Gas,
+		Value:              txi.Value,
+		PublicTxGasPricing: *gasPricing,
+	}
+	ethTx, err := ble.applyPreSignHook(ctx, *txi.From, buildEthTX(*txi.From, &nonce, txi.To, txi.Data, &options))
+	if err != nil {
+		return nil, err
+	}
+	_, txHash, err := ble.signEthTX(ctx, ble.ethClient, *txi.From, ethTx)
+	if err != nil {
+		return nil, err
+	}
+	log.L(ctx).Debugf("Dry-run of transaction from=%s nonce=%d gas=%s hash=%s", txi.From, nonce, txi.Gas, txHash)
+
+	now := tktypes.TimestampNow()
+	return &pldapi.PublicTx{
+		To:              txi.To,
+		Data:            txi.Data,
+		From:            *txi.From,
+		Nonce:           (*tktypes.HexUint64)(&nonce),
+		Created:         now,
+		Updated:         now,
+		TransactionHash: txHash,
+		Label:           txi.Label,
+		PublicTxOptions: options,
+	}, nil
+}
+
 func (ble *pubTxManager) ValidateTransaction(ctx context.Context, dbTX persistence.DBTX, txi *components.PublicTxSubmission) error {
 	log.L(ctx).Tracef("PrepareSubmission transaction: %+v", txi)
 
@@ -281,9 +480,9 @@ func (ble *pubTxManager) ValidateTransaction(ctx context.Context, dbTX persisten
 			}
 			return err
 		}
-		factoredGasLimit := tktypes.HexUint64((float64)(gasEstimateResult.GasLimit) * ble.gasEstimateFactor)
+		factoredGasLimit := tktypes.HexUint64(ble.gasLimitFromEstimate(*txi.From, gasEstimateResult.GasLimit.Uint64()))
 		txi.Gas = &factoredGasLimit
-		log.L(ctx).Tracef("HandleNewTx <%s> using the estimated gas limit %s multiplied by the gas estimate factor %.f (=%s) for transaction: %+v", txType, gasEstimateResult.GasLimit, ble.gasEstimateFactor, factoredGasLimit, txi)
+		log.L(ctx).Tracef("HandleNewTx <%s> using the estimated gas limit %s with the multiplier, floor and block limit applied (=%s) for transaction: %+v", txType, gasEstimateResult.GasLimit, factoredGasLimit, txi)
 	} else {
 		log.L(ctx).Tracef("HandleNewTx <%s> using the provided gas limit %s for transaction: %+v", txType, txi.Gas, txi)
 	}
@@ -294,20 +493,239 @@ func (ble *pubTxManager) ValidateTransaction(ctx context.Context, dbTX persisten
 }
 
 func (ble *pubTxManager) WriteNewTransactions(ctx context.Context, dbTX persistence.DBTX, transactions []*components.PublicTxSubmission) (pubTxns []*pldapi.PublicTx, err error) {
+	if err := ble.checkAdmission(ctx); err != nil {
+		return nil, err
+	}
+	return ble.writeNewSubmissions(ctx, dbTX, transactions, nil)
+}
+
+func (ble *pubTxManager) WriteNewTransactionGroup(ctx context.Context, dbTX persistence.DBTX, transactions []*components.PublicTxSubmission) (*components.PublicTxGroup, error) {
+	if len(transactions) == 0 {
+		return nil, i18n.NewError(ctx, msgs.MsgPublicTxGroupEmpty)
+	}
+	for _, txi := range transactions[1:] {
+		if *txi.From != *transactions[0].From {
+			return nil, i18n.NewError(ctx, msgs.MsgPublicTxGroupMixedSigners, transactions[0].From, txi.From)
+		}
+	}
+	if err := ble.checkAdmission(ctx); err != nil {
+		return nil, err
+	}
+	groupID := uuid.New()
+	pubTxns, err := ble.writeNewSubmissions(ctx, dbTX, transactions, &groupID)
+	if err != nil {
+		return nil, err
+	}
+	return &components.PublicTxGroup{
+		ID:           groupID,
+		Status:       components.PublicTxGroupStatusPending,
+		Transactions: pubTxns,
+	}, nil
+}
+
+func (ble *pubTxManager) GetTransactionGroup(ctx context.Context, dbTX persistence.DBTX, groupID uuid.UUID) (*components.PublicTxGroup, error) {
+	q := dbTX.DB().Table("public_txns").
+		WithContext(ctx).
+		Joins("Completed").
+		Where(`"public_txns"."group_id" = ?`, groupID).
+		Order(`"public_txns"."pub_txn_id"`)
+	ptxs, err := ble.runTransactionQuery(ctx, dbTX, false, nil, q)
+	if err != nil {
+		return nil, err
+	}
+	if len(ptxs) == 0 {
+		return nil, i18n.NewError(ctx, msgs.MsgPublicTxGroupNotFound, groupID)
+	}
+	group := &components.PublicTxGroup{
+		ID:           groupID,
+		Status:       components.PublicTxGroupStatusSucceeded,
+		Transactions: make([]*pldapi.PublicTx, len(ptxs)),
+	}
+	for i, ptx := range ptxs {
+		group.Transactions[i] = mapPersistedTransaction(ptx)
+		switch {
+		case ptx.Completed != nil && !ptx.Completed.Success:
+			// one failure fails the whole group
+			group.Status = components.PublicTxGroupStatusFailed
+		case ptx.Completed == nil && group.Status == components.PublicTxGroupStatusSucceeded:
+			group.Status = components.PublicTxGroupStatusPending
+		}
+	}
+	return group, nil
+}
+
+func (ble *pubTxManager) writeNewSubmissions(ctx context.Context, dbTX persistence.DBTX, transactions []*components.PublicTxSubmission, groupID *uuid.UUID) (pubTxns []*pldapi.PublicTx, err error) {
 	persistedTransactions := make([]*DBPublicTxn, len(transactions))
+	overrides := make(map[tktypes.EthAddress]map[uint64]bool)
+	zeroGasPrice := ble.gasPriceClient.HasZeroGasPrice(ctx)
 	for i, txi := range transactions {
+		if txi.DryRun {
+			return nil, i18n.NewError(ctx, msgs.MsgPublicTxDryRunNotWritable)
+		}
+		fixedGasPricing := &txi.PublicTxGasPricing
+		if !zeroGasPrice {
+			fixedGasPricing = ble.gasPriceOverrides[*txi.From].clampToCeiling(fixedGasPricing)
+		}
 		persistedTransactions[i] = &DBPublicTxn{
 			From:            *txi.From, // safe because validated in ValidateTransaction
 			To:              txi.To,
 			Gas:             txi.Gas.Uint64(),
 			Value:           txi.Value,
 			Data:            txi.Data,
-			FixedGasPricing: tktypes.JSONString(txi.PublicTxGasPricing),
+			FixedGasPricing: tktypes.JSONString(fixedGasPricing),
+			GroupID:         groupID,
+		}
+		if txi.Label != "" {
+			persistedTransactions[i].Label = &txi.Label
+		}
+		if txi.Nonce != nil {
+			nonce := txi.Nonce.Uint64()
+			if overrides[*txi.From][nonce] {
+				return nil, i18n.NewError(ctx, msgs.MsgPublicTxNonceOverrideDuplicate, nonce, txi.From)
+			}
+			if err := ble.validateNonceOverride(ctx, dbTX, *txi.From, nonce); err != nil {
+				return nil, err
+			}
+			if overrides[*txi.From] == nil {
+				overrides[*txi.From] = make(map[uint64]bool)
+			}
+			overrides[*txi.From][nonce] = true
+			persistedTransactions[i].Nonce = &nonce
 		}
 	}
+	bindings := make([][]*components.PaladinTXReference, len(transactions))
+	for i, txi := range transactions {
+		bindings[i] = txi.Bindings
+	}
+	return ble.writeNewTransactions(ctx, dbTX, persistedTransactions, bindings)
+}
+
+// A nonce supplied on submission is for recovery, such as filling a gap left by a transaction that was
+// lost or removed. So it must sit between the completed nonce watermark and the next nonce that will be
+// assigned automatically, and must not already be used by another transaction for the signing address.
+func (ble *pubTxManager) validateNonceOverride(ctx context.Context, dbTX persistence.DBTX, from tktypes.EthAddress, nonce uint64) error {
+	if err := ble.checkNonceAvailable(ctx, dbTX, from, nonce); err != nil {
+		return err
+	}
+	nextNonce, err := ble.getNextNonceFromDB(ctx, dbTX, from)
+	if err != nil {
+		return err
+	}
+	if nextNonce == nil {
+		// nothing has been assigned yet, so there cannot be a gap to fill
+		return i18n.NewError(ctx, msgs.MsgPublicTxNonceOverrideNotGap, nonce, &from, 0)
+	}
+	if nonce >= *nextNonce {
+		return i18n.NewError(ctx, msgs.MsgPublicTxNonceOverrideNotGap, nonce, &from, *nextNonce)
+	}
+	return nil
+}
+
+// The nonce of a pre-signed transaction is chosen outside of Paladin, so as well as filling a gap it can be
+// the next nonce for the signing address - but not beyond that, as it would never be mined.
+func (ble *pubTxManager) validateRawTxNonce(ctx context.Context, dbTX persistence.DBTX, from tktypes.EthAddress, nonce uint64) error {
+	if err := ble.checkNonceAvailable(ctx, dbTX, from, nonce); err != nil {
+		return err
+	}
+	nextNonce, err := ble.getNextNonceFromDB(ctx, dbTX, from)
+	if err != nil {
+		return err
+	}
+	if nextNonce == nil || nonce > *nextNonce {
+		// we might not have assigned the nonces the chain already knows about
+		txCount, err := ble.ethClient.GetTransactionCount(ctx, from)
+		if err != nil {
+			return err
+		}
+		if nextNonce == nil || txCount.Uint64() > *nextNonce {
+			nextNonce = confutil.P(txCount.Uint64())
+		}
+	}
+	if nonce > *nextNonce {
+		return i18n.NewError(ctx, msgs.MsgPublicTxRawTxNonceGap, nonce, &from, *nextNonce)
+	}
+	return nil
+}
+
+// Checks a nonce is above the completed nonce watermark, and not already used by another transaction for the signing address
+func (ble *pubTxManager) checkNonceAvailable(ctx context.Context, dbTX persistence.DBTX, from tktypes.EthAddress, nonce uint64) error {
+	watermark, err := ble.getCompletedNonceWatermark(ctx, dbTX, from)
+	if err != nil {
+		return err
+	}
+	if watermark != nil && nonce <= *watermark {
+		return i18n.NewError(ctx, msgs.MsgPublicTxNonceOverrideCompleted, nonce, &from, *watermark)
+	}
+	var existing []*DBPublicTxn
+	err = dbTX.DB().
+		WithContext(ctx).
+		Table("public_txns").
+		Where(`"from" = ?`, from).
+		Where("nonce = ?", nonce).
+		Limit(1).
+		Find(&existing).
+		Error
+	if err != nil {
+		return err
+	}
+	if len(existing) > 0 {
+		return i18n.NewError(ctx, msgs.MsgPublicTxNonceOverrideConflict, nonce, &from, existing[0].PublicTxnID)
+	}
+	return nil
+}
+
+func (ble *pubTxManager) WriteNewRawTransaction(ctx context.Context, dbTX persistence.DBTX, txi *components.PublicRawTxSubmission) (*pldapi.PublicTx, error) {
+	if err := ble.checkAdmission(ctx); err != nil {
+		return nil, err
+	}
+	signer, tx, err := ethsigner.RecoverRawTransaction(ctx, ethtypes.HexBytes0xPrefix(txi.RawTransaction), ble.ethClient.ChainID())
+	if err != nil {
+		return nil, i18n.WrapError(ctx, err, msgs.MsgPublicTxRawTxInvalid)
+	}
+	if tktypes.EthAddress(*signer) != txi.From {
+		return nil, i18n.NewError(ctx, msgs.MsgPublicTxRawTxFromMismatch, signer, &txi.From)
+	}
+	if tx.Nonce == nil || tx.Nonce.Uint64() != txi.Nonce {
+		return nil, i18n.NewError(ctx, msgs.MsgPublicTxRawTxNonceMismatch, tx.Nonce, txi.Nonce)
+	}
+	if err := ble.validateRawTxNonce(ctx, dbTX, txi.From, txi.Nonce); err != nil {
+		return nil, err
+	}
+	// The gas pricing is fixed at the values that were signed, which means the gas price
+	// retrieval stage is never run for this transaction
+	gasPricing := pldapi.PublicTxGasPricing{
+		GasPrice:             (*tktypes.HexUint256)(tx.GasPrice),
+		MaxFeePerGas:         (*tktypes.HexUint256)(tx.MaxFeePerGas),
+		MaxPriorityFeePerGas: (*tktypes.HexUint256)(tx.MaxPriorityFeePerGas),
+	}
+	nonce := txi.Nonce
+	ptx := &DBPublicTxn{
+		From:            txi.From,
+		Nonce:           &nonce,
+		To:              (*tktypes.EthAddress)(tx.To),
+		Gas:             tx.GasLimit.Uint64(),
+		Value:           (*tktypes.HexUint256)(tx.Value),
+		Data:            tktypes.HexBytes(tx.Data),
+		FixedGasPricing: tktypes.JSONString(gasPricing),
+		RawTransaction:  txi.RawTransaction,
+	}
+	pubTxns, err := ble.writeNewTransactions(ctx, dbTX, []*DBPublicTxn{ptx}, [][]*components.PaladinTXReference{txi.Bindings})
+	if err != nil {
+		return nil, err
+	}
+	return pubTxns[0], nil
+}
+
+func (ble *pubTxManager) writeNewTransactions(ctx context.Context, dbTX persistence.DBTX, persistedTransactions []*DBPublicTxn, bindings [][]*components.PaladinTXReference) (pubTxns []*pldapi.PublicTx, err error) {
 	// All the nonce processing to this point should have ensured we do not have a conflict on nonces.
 	// It is the caller's responsibility to ensure we do not have a conflict on transaction+resubmit_idx.
 	if len(persistedTransactions) > 0 {
+		// a new transaction has not been updated since it was created, which sorting by either relies on
+		now := tktypes.TimestampNow()
+		for _, ptx := range persistedTransactions {
+			ptx.Created = now
+			ptx.Updated = now
+		}
 		err = dbTX.DB().
 			WithContext(ctx).
 			Table("public_txns").
@@ -316,10 +734,10 @@ func (ble *pubTxManager) WriteNewTransactions(ctx context.Context, dbTX persiste
 			Error
 	}
 	if err == nil {
-		publicTxBindings := make([]*DBPublicTxnBinding, 0, len(transactions))
-		for i, txi := range transactions {
+		publicTxBindings := make([]*DBPublicTxnBinding, 0, len(persistedTransactions))
+		for i, txBindings := range bindings {
 			pubTxnID := persistedTransactions[i].PublicTxnID
-			for _, bnd := range txi.Bindings {
+			for _, bnd := range txBindings {
 				publicTxBindings = append(publicTxBindings, &DBPublicTxnBinding{
 					Transaction:     bnd.TransactionID,
 					TransactionType: bnd.TransactionType,
@@ -343,6 +761,7 @@ func (ble *pubTxManager) WriteNewTransactions(ctx context.Context, dbTX persiste
 			toNotify[ptx.From] = true
 		}
 		dbTX.AddPostCommit(ble.postCommitNewTransactions(toNotify))
+		dbTX.AddPostCommit(ble.postCommitAdmitted(len(persistedTransactions)))
 	}
 
 	return pubTxns, err
@@ -351,20 +770,16 @@ func (ble *pubTxManager) WriteNewTransactions(ctx context.Context, dbTX persiste
 func (ble *pubTxManager) postCommitNewTransactions(toNotify map[tktypes.EthAddress]bool) func(ctx context.Context) {
 	return func(ctx context.Context) {
 		// Mark any active orchestrators stale
-		inactive := false
 		for addr := range toNotify {
 			oc := ble.getOrchestratorForAddress(addr)
 			if oc != nil {
 				log.L(ctx).Debugf("Notified orchestrator %s to re-poll due to new transactions", &addr)
 				oc.MarkInFlightTxStale()
 			} else {
-				inactive = true
+				// And if the orchestrator is un-loaded, then nudge the main loop to load just that one
+				ble.NudgeOrchestratorForAddress(addr)
 			}
 		}
-		// And if there was an orchestrator un-loaded, then mark the main poll loop stale
-		if inactive {
-			ble.MarkInFlightOrchestratorsStale()
-		}
 	}
 }
 
@@ -444,9 +859,9 @@ func (ble *pubTxManager) CheckTransactionCompleted(ctx context.Context, pubTxnID
 	err := ble.p.DB().
 		WithContext(ctx).
 		Table("public_txns").
-		Where(`"pub_txn_id" = ?`, pubTxnID).
+		Where(`"public_txns"."pub_txn_id" = ?`, pubTxnID).
 		Joins("Completed").
-		Select(`"Completed"."tx_hash"`).
+		Select(`"Completed"."tx_hash"`, `"Completed"."block_number"`).
 		Limit(1).
 		Find(&ptxs).
 		Error
@@ -454,20 +869,93 @@ func (ble *pubTxManager) CheckTransactionCompleted(ctx context.Context, pubTxnID
 		return false, err
 	}
 	if len(ptxs) > 0 && ptxs[0].Completed != nil {
+		// Completions recorded before the block number was tracked are treated as fully confirmed
+		if blockNumber := ptxs[0].Completed.BlockNumber; ble.confirmationDepth > 0 && blockNumber != nil {
+			height, err := ble.bIndexer.GetBlockListenerHeight(ctx)
+			if err != nil {
+				return false, err
+			}
+			if height < uint64(*blockNumber)+ble.confirmationDepth {
+				log.L(ctx).Debugf("CheckTransactionCompleted waiting for confirmation depth %d for pubTxnID=%d (block=%d,height=%d)", ble.confirmationDepth, pubTxnID, *blockNumber, height)
+				return false, nil
+			}
+		}
 		log.L(ctx).Debugf("CheckTransactionCompleted returned true for %s:%d (pubTxnID=%d)", ptxs[0].From, ptxs[0].Nonce, pubTxnID)
 		return true, nil
 	}
 	return false, nil
 }
 
+// Returns the nonce that will be assigned to the next transaction written for the signing address, taking
+// into account the transactions that are waiting to be assigned a nonce.
+// When there is no orchestrator running with a current nonce for the address, this is calculated the same way
+// as an orchestrator does, from the pending nonce on the chain and the highest nonce we have assigned.
+func (ble *pubTxManager) GetNextNonce(ctx context.Context, from tktypes.EthAddress) (uint64, error) {
+	var nextNonce uint64
+	var cachedNextNonce *uint64
+	if oc := ble.getOrchestratorForAddress(from); oc != nil {
+		cachedNextNonce = oc.getCachedNextNonce()
+	}
+	if cachedNextNonce != nil {
+		nextNonce = *cachedNextNonce
+	} else {
+		txCount, err := ble.ethClient.GetTransactionCount(ctx, from)
+		if err != nil {
+			return 0, err
+		}
+		nextNonce = txCount.Uint64()
+		dbNextNonce, err := ble.getNextNonceFromDB(ctx, ble.p.NOTX(), from)
+		if err != nil {
+			return 0, err
+		}
+		if dbNextNonce != nil && *dbNextNonce > nextNonce {
+			nextNonce = *dbNextNonce
+		}
+	}
+
+	var unassigned int64
+	err := ble.p.DB().
+		WithContext(ctx).
+		Model(&DBPublicTxn{}).
+		Joins("Completed").
+		Where(`"Completed"."tx_hash" IS NULL`).
+		Where("suspended IS FALSE").
+		Where(`"public_txns"."from" = ?`, from).
+		Where(`"public_txns"."nonce" IS NULL`).
+		Count(&unassigned).
+		Error
+	if err != nil {
+		return 0, err
+	}
+	log.L(ctx).Debugf("Next nonce for %s is %d (cached=%t,unassigned=%d)", from, nextNonce+uint64(unassigned), cachedNextNonce != nil, unassigned)
+	return nextNonce + uint64(unassigned), nil
+}
+
+// Diagnostics of each orchestrator currently in flight, sorted by signing address
+func (ble *pubTxManager) GetEngineStatus(ctx context.Context) *pldapi.PublicTxEngineStatus {
+	ble.inFlightOrchestratorMux.Lock()
+	defer ble.inFlightOrchestratorMux.Unlock()
+	status := &pldapi.PublicTxEngineStatus{
+		MaxInFlightOrchestrators: ble.maxInflight,
+		Orchestrators:            make([]*pldapi.PublicTxOrchestratorStatus, 0, len(ble.inFlightOrchestrators)),
+	}
+	for _, oc := range ble.inFlightOrchestrators {
+		status.Orchestrators = append(status.Orchestrators, oc.snapshot())
+	}
+	sort.Slice(status.Orchestrators, func(i, j int) bool {
+		return status.Orchestrators[i].SigningAddress.String() < status.Orchestrators[j].SigningAddress.String()
+	})
+	return status
+}
+
 // the return does NOT include submissions (only the top level TX data)
-func (ble *pubTxManager) GetPendingFuelingTransaction(ctx context.Context, sourceAddress tktypes.EthAddress, destinationAddress tktypes.EthAddress) (*pldapi.PublicTx, error) {
+func (ble *pubTxManager) GetPendingFuelingTransaction(ctx context.Context, sourceAddresses []tktypes.EthAddress, destinationAddress tktypes.EthAddress) (*pldapi.PublicTx, error) {
 	var ptxs []*DBPublicTxn
 	err := ble.p.DB().
 		WithContext(ctx).
 		Table("public_txns").
-		Where("from = ?", sourceAddress).
-		Where("to = ?", destinationAddress).
+		Where(`"public_txns"."from" IN (?)`, sourceAddresses).
+		Where(`"public_txns"."to" = ?`, destinationAddress).
 		Joins("Completed").
 		Where(`"Completed"."tx_hash" IS NULL`).
 		Joins("Binding").
@@ -519,11 +1007,22 @@ func (ble *pubTxManager) runTransactionQuery(ctx context.Context, dbTX persisten
 	return ptxs, nil
 }
 
+// Sets the updated time on transactions, when a change is written to a related table (or by raw SQL)
+func touchTransactions(ctx context.Context, dbTX persistence.DBTX, pubTxnIDs []uint64) error {
+	return dbTX.DB().
+		WithContext(ctx).
+		Table("public_txns").
+		Where(`"pub_txn_id" IN (?)`, pubTxnIDs).
+		UpdateColumn("updated", tktypes.TimestampNow()).
+		Error
+}
+
 func mapPersistedTransaction(ptx *DBPublicTxn) *pldapi.PublicTx {
 	tx := &pldapi.PublicTx{
 		LocalID: &ptx.PublicTxnID,
 		From:    ptx.From,
 		Created: ptx.Created,
+		Updated: ptx.Updated,
 		To:      ptx.To,
 		Nonce:   (*tktypes.HexUint64)(ptx.Nonce),
 		Data:    ptx.Data,
@@ -533,6 +1032,12 @@ func mapPersistedTransaction(ptx *DBPublicTxn) *pldapi.PublicTx {
 			PublicTxGasPricing: recoverGasPriceOptions(ptx.FixedGasPricing),
 		},
 	}
+	if ptx.Label != nil {
+		tx.Label = *ptx.Label
+	}
+	if ptx.FailureCategory != nil {
+		tx.FailureCategory = pldapi.PublicTxFailureCategory(*ptx.FailureCategory)
+	}
 	// We use a separate table in the DB for the completion data, but
 	// we allow a single query and return interface for users.
 	if ptx.Completed != nil {
@@ -541,6 +1046,15 @@ func mapPersistedTransaction(ptx *DBPublicTxn) *pldapi.PublicTx {
 		tx.TransactionHash = &completed.TransactionHash
 		tx.Success = &completed.Success
 		tx.RevertData = completed.RevertData
+		// earlier failures are no longer relevant once the transaction is confirmed
+		tx.FailureCategory = ""
+		if !completed.Success {
+			// A transaction that was mined but reverted has still consumed its nonce, so it is complete, but failed
+			tx.FailureCategory = pldapi.PublicTxFailureReverted
+			if len(completed.RevertData) > 0 {
+				tx.RevertReason, _ = abi.ABI{}.ErrorString(completed.RevertData)
+			}
+		}
 	}
 	// Note: Submissions (sent to the mempool of the chain, but not yet complete) are separate.
 	// See mapPersistedSubmissionData()
@@ -710,6 +1224,7 @@ func (pte *pubTxManager) MatchUpdateConfirmedTransactions(ctx context.Context, d
 				completions = append(completions, &DBPublicTxnCompletion{
 					PublicTxnID:     match.PublicTxnID,
 					TransactionHash: txi.Hash,
+					BlockNumber:     &txi.BlockNumber,
 					Success:         txi.Result.V() == pldapi.TXResult_SUCCESS,
 					RevertData:      txi.RevertReason,
 				})
@@ -728,14 +1243,89 @@ func (pte *pubTxManager) MatchUpdateConfirmedTransactions(ctx context.Context, d
 			}).
 			Create(completions).
 			Error
+		if err == nil {
+			// also updates the time the transactions were last updated
+			err = updateCompletedFailureCategories(ctx, dbTX, completions)
+		}
 		if err != nil {
 			return nil, err
 		}
 	}
 
+	failedTxnIDs := make([]uint64, 0)
+	for _, completion := range completions {
+		if !completion.Success {
+			failedTxnIDs = append(failedTxnIDs, completion.PublicTxnID)
+		}
+	}
+	if len(failedTxnIDs) > 0 {
+		if err := pte.suspendRemainderOfGroups(ctx, dbTX, failedTxnIDs); err != nil {
+			return nil, err
+		}
+	}
+
 	return results, nil
 }
 
+// When a transaction in a group fails, none of the transactions after it in the group should be submitted.
+// They are suspended in the same DB transaction that records the failure, and any in-flight orchestrator
+// is told after the commit.
+func (pte *pubTxManager) suspendRemainderOfGroups(ctx context.Context, dbTX persistence.DBTX, failedTxnIDs []uint64) error {
+	var failed []*DBPublicTxn
+	err := dbTX.DB().
+		WithContext(ctx).
+		Table("public_txns").
+		Where(`"pub_txn_id" IN (?)`, failedTxnIDs).
+		Where(`"group_id" IS NOT NULL`).
+		Find(&failed).
+		Error
+	if err != nil {
+		return err
+	}
+	var toSuspend []*DBPublicTxn
+	for _, ptx := range failed {
+		var remainder []*DBPublicTxn
+		err := dbTX.DB().
+			WithContext(ctx).
+			Table("public_txns").
+			Joins("Completed").
+			Where(`"Completed"."tx_hash" IS NULL`).
+			Where(`"public_txns"."group_id" = ?`, ptx.GroupID).
+			Where(`"public_txns"."pub_txn_id" > ?`, ptx.PublicTxnID).
+			Find(&remainder).
+			Error
+		if err != nil {
+			return err
+		}
+		log.L(ctx).Warnf("Transaction %s:%v failed (pubTxnID=%d), suspending %d remaining transactions in group %s", ptx.From, ptx.Nonce, ptx.PublicTxnID, len(remainder), ptx.GroupID)
+		toSuspend = append(toSuspend, remainder...)
+	}
+	if len(toSuspend) == 0 {
+		return nil
+	}
+	pubTxnIDs := make([]uint64, len(toSuspend))
+	for i, ptx := range toSuspend {
+		pubTxnIDs[i] = ptx.PublicTxnID
+	}
+	err = dbTX.DB().
+		WithContext(ctx).
+		Table("public_txns").
+		Where(`"pub_txn_id" IN (?)`, pubTxnIDs).
+		UpdateColumns(map[string]any{"suspended": true, "updated": tktypes.TimestampNow()}).
+		Error
+	if err != nil {
+		return err
+	}
+	dbTX.AddPostCommit(func(ctx context.Context) {
+		for _, ptx := range toSuspend {
+			if ptx.Nonce != nil {
+				_ = pte.dispatchAction(ctx, ptx.From, *ptx.Nonce, ActionSuspend)
+			}
+		}
+	})
+	return nil
+}
+
 // We've got to be super careful not to block this thread, so we treat this just like a suspend/resume
 // on each of these transactions
 func (pte *pubTxManager) NotifyConfirmPersisted(ctx context.Context, confirms []*components.PublicTxMatch) {
@@ -17,8 +17,13 @@ package publictxmgr
 
 import (
 	"context"
+	"sort"
 	"time"
 
+	"github.com/kaleido-io/paladin/config/pkg/confutil"
+	"github.com/kaleido-io/paladin/config/pkg/pldconf"
+	"github.com/kaleido-io/paladin/core/internal/msgs"
+	"github.com/kaleido-io/paladin/toolkit/pkg/i18n"
 	"github.com/kaleido-io/paladin/toolkit/pkg/log"
 	"github.com/kaleido-io/paladin/toolkit/pkg/tktypes"
 )
@@ -40,7 +45,7 @@ import (
 // 3. auto fueling management - the autofueling transactions
 //    - creating auto-fueling transactions when asked by transaction orchestrators
 // 4. provides shared functionalities for optimization
-//    - handles gas price information which is not signer specific
+//    - handles gas price information which is not signer specific (per-signer overrides are applied by each orchestrator)
 
 func (ble *pubTxManager) engineLoop() {
 	defer close(ble.engineLoopDone)
@@ -53,6 +58,10 @@ func (ble *pubTxManager) engineLoop() {
 		select {
 		case <-ticker.C:
 		case <-ble.inFlightOrchestratorStale:
+		case addr := <-ble.orchestratorNudges:
+			// targeted nudge for a single address - no need for a full poll
+			ble.pollAddress(ctx, addr)
+			continue
 		case <-ctx.Done():
 			ticker.Stop()
 			log.L(ctx).Infof("Engine poller exiting")
@@ -92,9 +101,18 @@ func (ble *pubTxManager) flushStaleOrchestratorsGetCount(ctx context.Context) (i
 
 	// Run through copying across from the old InFlight list to the new one, those that aren't ready to be deleted
 	for signingAddress, oc := range oldInFlight {
-		log.L(ctx).Debugf("Engine checking orchestrator for %s: state: %s, state duration: %s, number of transactions: %d", oc.signingAddress, oc.state, time.Since(oc.stateEntryTime), len(oc.inFlightTxs))
-		if oc.state == OrchestratorStateIdle && time.Since(oc.stateEntryTime) > ble.orchestratorIdleTimeout ||
-			oc.state == OrchestratorStateStale && time.Since(oc.stateEntryTime) > ble.orchestratorStaleTimeout {
+		log.L(ctx).Debugf("Engine checking orchestrator for %s: state: %s, state duration: %s, number of transactions: %d", oc.signingAddress, oc.state, ble.clock.Since(oc.stateEntryTime), len(oc.inFlightTxs))
+		if ble.orchestratorWatchdog > 0 && oc.isStuck(ble.orchestratorWatchdog) {
+			// We cannot wait for a stuck orchestrator to report it has stopped, so we drop it from the in-flight list
+			// straight away so a new one is started for the signing address. If the old one ever gets unstuck,
+			// it will see the stop before processing again.
+			log.L(ctx).Warnf("Engine watchdog restarting orchestrator for signing address %s, which has made no progress for %s in state %s", signingAddress, ble.orchestratorWatchdog, oc.state)
+			oc.Stop()
+			ble.thMetrics.RecordOrchestratorWatchdogRestart(ctx)
+			continue
+		}
+		if oc.state == OrchestratorStateIdle && ble.clock.Since(oc.stateEntryTime) > ble.orchestratorIdleTimeout ||
+			oc.state == OrchestratorStateStale && ble.clock.Since(oc.stateEntryTime) > ble.orchestratorStaleTimeout {
 			// tell transaction orchestrator to stop, there is a chance we later found new transaction for this address, but we got to make a call at some point
 			// so it's here. The transaction orchestrator won't be removed immediately as the state update is async
 			oc.Stop()
@@ -114,24 +132,36 @@ func (ble *pubTxManager) flushStaleOrchestratorsGetCount(ctx context.Context) (i
 }
 
 func (ble *pubTxManager) poll(ctx context.Context) (polled int, total int) {
-	pollStart := time.Now()
+	pollStart := ble.clock.Now()
+
+	ble.refreshPendingBacklog(ctx)
 
 	// Perform locked processing to determine if there are spaces to fill
 	inFlightSigningAddresses, stateCounts, totalBeforePoll := ble.flushStaleOrchestratorsGetCount(ctx)
 
 	// check and poll new signers from the persistence if there are more transaction orchestrators slots
 	spaces := ble.maxInflight - totalBeforePoll
-	if spaces > 0 {
+	if spaces < 0 {
+		// the maximum has been reduced below the number in flight, so some need to be retired
+		ble.retireExcessOrchestrators(ctx, -spaces)
+	} else if spaces > 0 {
 
 		// Run through the paused orchestrators for fairness control
 		// Note not controlled by mutex, as only modified on this routine.
 		for signingAddress, pausedUntil := range ble.signingAddressesPausedUntil {
-			if time.Now().Before(pausedUntil) {
+			if ble.clock.Now().Before(pausedUntil) {
 				log.L(ctx).Debugf("Engine excluded orchestrator for signing address %s from polling as it's paused util %s", signingAddress, pausedUntil.String())
 				stateCounts[string(OrchestratorStatePaused)] = stateCounts[string(OrchestratorStatePaused)] + 1
 				inFlightSigningAddresses = append(inFlightSigningAddresses, signingAddress)
 			}
 		}
+		// Signing addresses paused because their key no longer exists are excluded until restart
+		ble.signingAddressesKeyLostMux.Lock()
+		for signingAddress := range ble.signingAddressesKeyLost {
+			stateCounts[string(OrchestratorStatePaused)] = stateCounts[string(OrchestratorStatePaused)] + 1
+			inFlightSigningAddresses = append(inFlightSigningAddresses, signingAddress)
+		}
+		ble.signingAddressesKeyLostMux.Unlock()
 
 		var additionalNonInFlightSigners []*txFromOnly
 		// We retry the get from persistence indefinitely (until the context cancels)
@@ -143,18 +173,19 @@ func (ble *pubTxManager) poll(ctx context.Context) (polled int, total int) {
 
 			const dbQueryNothingInFlight = dbQueryBase + ` LIMIT ?`
 			if len(inFlightSigningAddresses) == 0 {
-				return true, ble.p.DB().Raw(dbQueryNothingInFlight, spaces).Scan(&additionalNonInFlightSigners).Error
+				return true, ble.p.DB().Raw(dbQueryNothingInFlight, spaces*orchestratorPollCandidateFactor).Scan(&additionalNonInFlightSigners).Error
 			}
 
 			const dbQueryInFlight = dbQueryBase + ` AND t."from" NOT IN (?) LIMIT ?`
-			return true, ble.p.DB().Raw(dbQueryInFlight, inFlightSigningAddresses, spaces).Scan(&additionalNonInFlightSigners).Error
+			return true, ble.p.DB().Raw(dbQueryInFlight, inFlightSigningAddresses, spaces*orchestratorPollCandidateFactor).Scan(&additionalNonInFlightSigners).Error
 		})
 		if err != nil {
 			log.L(ctx).Infof("Engine polling context cancelled while retrying")
 			return -1, totalBeforePoll
 		}
 
-		log.L(ctx).Debugf("Engine polled %d items to fill in %d empty slots.", len(additionalNonInFlightSigners), spaces)
+		log.L(ctx).Debugf("Engine polled %d candidates to fill in %d empty slots.", len(additionalNonInFlightSigners), spaces)
+		additionalNonInFlightSigners = ble.selectLeastRecentlyStarted(additionalNonInFlightSigners, inFlightSigningAddresses, spaces)
 
 		// (Re)obtain the lock to add the additional ones
 		ble.inFlightOrchestratorMux.Lock()
@@ -166,7 +197,13 @@ func (ble *pubTxManager) poll(ctx context.Context) (polled int, total int) {
 				ble.inFlightOrchestrators[r.From] = oc
 				stateCounts[string(oc.state)] = stateCounts[string(oc.state)] + 1
 				_, _ = oc.Start(ble.ctx)
+				ble.orchestratorLastStarted[r.From] = ble.clock.Now()
 				log.L(ctx).Infof("Engine added orchestrator for signing address %s", r.From)
+			} else {
+				// The lock is not held while we query the DB, so an orchestrator can have been added for
+				// this signing address since we built the exclusion list. We keep the existing one.
+				log.L(ctx).Warnf("Engine fetched extra transactions from signing address %s, which already has an orchestrator", r.From)
+				ble.thMetrics.RecordPolledInFlightSigner(ctx)
 			}
 		}
 		total = len(ble.inFlightOrchestrators)
@@ -183,20 +220,193 @@ func (ble *pubTxManager) poll(ctx context.Context) (polled int, total int) {
 
 		// TODO: don't stop more than required number of slots
 
-		// Run through the existing running orchestrators and stop the ones that exceeded the max process timeout
-		for signingAddress, oc := range ble.inFlightOrchestrators {
-			if time.Since(oc.orchestratorBirthTime) > ble.orchestratorSwapTimeout {
-				log.L(ctx).Infof("Engine pause, attempt to stop orchestrator for signing address %s", signingAddress)
-				oc.Stop()
-				ble.signingAddressesPausedUntil[signingAddress] = time.Now().Add(ble.orchestratorSwapTimeout)
-			}
+		// Run through the existing running orchestrators and stop the ones selected by the pause policy
+		for _, oc := range ble.selectOrchestratorsToPause() {
+			log.L(ctx).Infof("Engine pause (policy=%s), attempt to stop orchestrator for signing address %s", ble.orchestratorPausePolicy, oc.signingAddress)
+			oc.Stop()
+			ble.signingAddressesPausedUntil[oc.signingAddress] = ble.clock.Now().Add(ble.orchestratorSwapTimeout)
 		}
 	}
 	ble.thMetrics.RecordInFlightOrchestratorPoolMetrics(ctx, stateCounts, ble.maxInflight-len(ble.inFlightOrchestrators))
-	log.L(ctx).Debugf("Engine poll loop took %s", time.Since(pollStart))
+	pollDuration := ble.clock.Since(pollStart)
+	ble.thMetrics.RecordPollMetrics(ctx, pollDuration, max(spaces, 0), polled)
+	log.L(ctx).Debugf("Engine poll loop took %s (filled %d of %d free slots)", pollDuration, polled, max(spaces, 0))
 	return polled, total
 }
 
+func parseOrchestratorPausePolicy(ctx context.Context, conf *pldconf.PublicTxManagerManagerConfig) (string, map[tktypes.EthAddress]int, error) {
+	policy := confutil.StringNotEmpty(conf.OrchestratorPausePolicy, *pldconf.PublicTxManagerDefaults.Manager.OrchestratorPausePolicy)
+	switch policy {
+	case pldconf.OrchestratorPausePolicyOldest,
+		pldconf.OrchestratorPausePolicyLeastProgressed,
+		pldconf.OrchestratorPausePolicyLowestPriority:
+	default:
+		return "", nil, i18n.NewError(ctx, msgs.MsgPublicTxInvalidPausePolicy, policy)
+	}
+	priorities := make(map[tktypes.EthAddress]int, len(conf.SigningAddressPriority))
+	for addrStr, priority := range conf.SigningAddressPriority {
+		addr, err := tktypes.ParseEthAddress(addrStr)
+		if err != nil {
+			return "", nil, i18n.WrapError(ctx, err, msgs.MsgPublicTxInvalidSignerPriority, addrStr)
+		}
+		priorities[*addr] = priority
+	}
+	return policy, priorities, nil
+}
+
+// Selects the orchestrators to pause when all the slots are full, according to the configured policy:
+//   - oldest: those that have held their slot for longer than the swap timeout
+//   - leastProgressed: those that have made no progress for longer than the swap timeout
+//   - lowestPriority: of those that have held their slot for longer than the swap timeout, the ones with the
+//     lowest priority, so higher priority signing addresses keep their slot while there are others to pause
+//
+// Must be called holding the inFlightOrchestratorMux.
+func (ble *pubTxManager) selectOrchestratorsToPause() []*orchestrator {
+	var selected []*orchestrator
+	switch ble.orchestratorPausePolicy {
+	case pldconf.OrchestratorPausePolicyLeastProgressed:
+		for _, oc := range ble.inFlightOrchestrators {
+			if ble.clock.Since(time.Unix(0, oc.lastProgressNanos.Load())) > ble.orchestratorSwapTimeout {
+				selected = append(selected, oc)
+			}
+		}
+	case pldconf.OrchestratorPausePolicyLowestPriority:
+		lowest := 0
+		for _, oc := range ble.inFlightOrchestrators {
+			if ble.clock.Since(oc.orchestratorBirthTime) > ble.orchestratorSwapTimeout {
+				priority := ble.signingAddressPriority[oc.signingAddress]
+				if len(selected) == 0 || priority < lowest {
+					lowest = priority
+					selected = selected[:0]
+				}
+				if priority == lowest {
+					selected = append(selected, oc)
+				}
+			}
+		}
+	default:
+		for _, oc := range ble.inFlightOrchestrators {
+			if ble.clock.Since(oc.orchestratorBirthTime) > ble.orchestratorSwapTimeout {
+				selected = append(selected, oc)
+			}
+		}
+	}
+	return selected
+}
+
+// Stops orchestrators until we are back within the maximum in flight. We prefer the idlest, and then those that have
+// run the longest (as they have had the most time with a slot), falling back to the signing address so the choice is
+// deterministic. Orchestrators part way through a submission are left to finish it, and are reconsidered on the next poll.
+// Only called on the engine loop routine.
+func (ble *pubTxManager) retireExcessOrchestrators(ctx context.Context, excess int) {
+	ble.inFlightOrchestratorMux.Lock()
+	defer ble.inFlightOrchestratorMux.Unlock()
+
+	type retirementCandidate struct {
+		oc            *orchestrator
+		inFlightCount int
+	}
+	candidates := make([]*retirementCandidate, 0, len(ble.inFlightOrchestrators))
+	for _, oc := range ble.inFlightOrchestrators {
+		if oc.stopPending() {
+			// already on its way out, such as from the idle timeout
+			excess--
+			continue
+		}
+		inFlightCount, submitting := oc.retirementLoad(ctx)
+		if submitting {
+			log.L(ctx).Debugf("Engine deferring retirement of orchestrator for signing address %s until its submission completes", oc.signingAddress)
+			continue
+		}
+		candidates = append(candidates, &retirementCandidate{oc: oc, inFlightCount: inFlightCount})
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		ci, cj := candidates[i], candidates[j]
+		if ci.inFlightCount != cj.inFlightCount {
+			return ci.inFlightCount < cj.inFlightCount
+		}
+		if !ci.oc.orchestratorBirthTime.Equal(cj.oc.orchestratorBirthTime) {
+			return ci.oc.orchestratorBirthTime.Before(cj.oc.orchestratorBirthTime)
+		}
+		return ci.oc.signingAddress.String() < cj.oc.signingAddress.String()
+	})
+	for i := 0; i < excess && i < len(candidates); i++ {
+		log.L(ctx).Infof("Engine retiring orchestrator for signing address %s (in-flight=%d), to reduce to the maximum of %d", candidates[i].oc.signingAddress, candidates[i].inFlightCount, ble.maxInflight)
+		candidates[i].oc.Stop()
+	}
+}
+
+// Called on the engine loop routine to load or wake the orchestrator for a single signing address,
+// when we know that address has new work. Does not perform fairness control - that is left to the full poll.
+func (ble *pubTxManager) pollAddress(ctx context.Context, signingAddress tktypes.EthAddress) {
+	// Note signingAddressesPausedUntil is not controlled by mutex, as only modified on this routine.
+	if pausedUntil, paused := ble.signingAddressesPausedUntil[signingAddress]; paused && ble.clock.Now().Before(pausedUntil) {
+		log.L(ctx).Debugf("Engine ignored nudge for paused orchestrator for signing address %s", signingAddress)
+		return
+	}
+	if ble.isSignerKeyLostPaused(signingAddress) {
+		log.L(ctx).Debugf("Engine ignored nudge for signing address %s, which is paused as its key no longer exists", signingAddress)
+		return
+	}
+
+	ble.inFlightOrchestratorMux.Lock()
+	defer ble.inFlightOrchestratorMux.Unlock()
+
+	if oc, exists := ble.inFlightOrchestrators[signingAddress]; exists && oc.publishedState() != OrchestratorStateStopped {
+		oc.MarkInFlightTxStale()
+		return
+	}
+	if len(ble.inFlightOrchestrators) >= ble.maxInflight {
+		// No free slot - the next full poll will decide which orchestrators to run
+		log.L(ctx).Debugf("Engine has no free slot for nudged signing address %s", signingAddress)
+		return
+	}
+	oc := NewOrchestrator(ble, signingAddress, ble.conf)
+	ble.inFlightOrchestrators[signingAddress] = oc
+	_, _ = oc.Start(ble.ctx)
+	ble.orchestratorLastStarted[signingAddress] = ble.clock.Now()
+	log.L(ctx).Infof("Engine added orchestrator for nudged signing address %s", signingAddress)
+}
+
+// For fairness we prefer breadth when filling free slots, so a signing address with a large backlog that is
+// swapped out does not simply get the next free slot. Signing addresses that have never had an orchestrator come
+// first, followed by the least recently started. Only called on the engine loop routine.
+func (ble *pubTxManager) selectLeastRecentlyStarted(candidates []*txFromOnly, inFlightSigningAddresses []tktypes.EthAddress, spaces int) []*txFromOnly {
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return ble.orchestratorLastStarted[candidates[i].From].Before(ble.orchestratorLastStarted[candidates[j].From])
+	})
+
+	// We only need to remember the addresses that are in-flight, paused (both included in
+	// inFlightSigningAddresses at this point) or still waiting for a slot
+	retain := make(map[tktypes.EthAddress]bool, len(inFlightSigningAddresses)+len(candidates))
+	for _, addr := range inFlightSigningAddresses {
+		retain[addr] = true
+	}
+	for _, c := range candidates {
+		retain[c.From] = true
+	}
+	for addr := range ble.orchestratorLastStarted {
+		if !retain[addr] {
+			delete(ble.orchestratorLastStarted, addr)
+		}
+	}
+
+	if len(candidates) > spaces {
+		candidates = candidates[0:spaces]
+	}
+	return candidates
+}
+
+// Nudge the orchestrator for a single signing address, creating it if there is a free slot.
+// If the nudge queue is full, we fall back to a full poll.
+func (ble *pubTxManager) NudgeOrchestratorForAddress(signingAddress tktypes.EthAddress) {
+	select {
+	case ble.orchestratorNudges <- signingAddress:
+	default:
+		ble.MarkInFlightOrchestratorsStale()
+	}
+}
+
 func (ble *pubTxManager) MarkInFlightOrchestratorsStale() {
 	// try to send an item in `InFlightStale` channel, which has a buffer of 1
 	// to trigger a polling event to update the in flight transaction orchestrators
@@ -16,6 +16,9 @@
 package publictxmgr
 
 import (
+	"context"
+	"database/sql/driver"
+	"sync"
 	"testing"
 	"time"
 
@@ -25,8 +28,34 @@ import (
 
 	"github.com/kaleido-io/paladin/toolkit/pkg/tktypes"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
+type fakeClock struct {
+	lock sync.Mutex
+	now  time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Now()}
+}
+
+func (fc *fakeClock) Now() time.Time {
+	fc.lock.Lock()
+	defer fc.lock.Unlock()
+	return fc.now
+}
+
+func (fc *fakeClock) Since(t time.Time) time.Duration {
+	return fc.Now().Sub(t)
+}
+
+func (fc *fakeClock) Advance(d time.Duration) {
+	fc.lock.Lock()
+	defer fc.lock.Unlock()
+	fc.now = fc.now.Add(d)
+}
+
 func TestNewEnginePollingCancelledContext(t *testing.T) {
 	ctx, ble, _, done := newTestPublicTxManager(t, false)
 	done()
@@ -91,3 +120,494 @@ func TestNewEnginePollingExcludePausedOrchestrator(t *testing.T) {
 	ble.poll(ctx)
 
 }
+
+func TestNewEnginePollingPrefersSignersNotRecentlyStarted(t *testing.T) {
+	hotSigner := *tktypes.RandAddress()
+	warmSigner := *tktypes.RandAddress()
+	coldSigner1 := *tktypes.RandAddress()
+	coldSigner2 := *tktypes.RandAddress()
+	goneSigner := *tktypes.RandAddress()
+
+	ctx, ble, m, done := newTestPublicTxManager(t, false, func(mocks *mocksAndTestControl, conf *pldconf.PublicTxManagerConfig) {
+		mocks.disableManagerStart = true
+		conf.Manager.MaxInFlightOrchestrators = confutil.P(2)
+	})
+	defer done()
+
+	// The hot signer has a huge backlog, so is always the first returned from the DB.
+	// It (and the warm signer) have both had orchestrators recently, but the cold signers have not.
+	ble.inFlightOrchestrators = map[tktypes.EthAddress]*orchestrator{}
+	ble.orchestratorLastStarted = map[tktypes.EthAddress]time.Time{
+		hotSigner:  time.Now().Add(-1 * time.Second),
+		warmSigner: time.Now().Add(-1 * time.Minute),
+		goneSigner: time.Now().Add(-1 * time.Hour),
+	}
+	m.db.ExpectQuery("SELECT.*public_txn").
+		WithArgs(2 * orchestratorPollCandidateFactor).
+		WillReturnRows(sqlmock.NewRows([]string{"from"}).
+			AddRow(hotSigner).
+			AddRow(warmSigner).
+			AddRow(coldSigner1).
+			AddRow(coldSigner2))
+
+	polled, total := ble.poll(ctx)
+	assert.Equal(t, 2, polled)
+	assert.Equal(t, 2, total)
+	assert.NotNil(t, ble.getOrchestratorForAddress(coldSigner1))
+	assert.NotNil(t, ble.getOrchestratorForAddress(coldSigner2))
+	assert.Nil(t, ble.getOrchestratorForAddress(hotSigner))
+	assert.Nil(t, ble.getOrchestratorForAddress(warmSigner))
+
+	// we remember when we started the new orchestrators, and forget signers with nothing pending
+	assert.Contains(t, ble.orchestratorLastStarted, coldSigner1)
+	assert.Contains(t, ble.orchestratorLastStarted, coldSigner2)
+	assert.Contains(t, ble.orchestratorLastStarted, hotSigner)
+	assert.NotContains(t, ble.orchestratorLastStarted, goneSigner)
+
+	// of the signers with recent orchestrators, the least recent wins
+	selected := ble.selectLeastRecentlyStarted([]*txFromOnly{{From: hotSigner}, {From: warmSigner}}, nil, 1)
+	assert.Equal(t, []*txFromOnly{{From: warmSigner}}, selected)
+}
+
+func TestNewEnginePollingRecordsPollMetrics(t *testing.T) {
+	ctx, ble, m, done := newTestPublicTxManager(t, false, func(mocks *mocksAndTestControl, conf *pldconf.PublicTxManagerConfig) {
+		mocks.disableManagerStart = true
+		conf.Manager.MaxInFlightOrchestrators = confutil.P(4)
+	})
+	defer done()
+
+	// Only one signer has work, so we fill one of four slots
+	ble.inFlightOrchestrators = map[tktypes.EthAddress]*orchestrator{}
+	m.db.ExpectQuery("SELECT.*public_txn").WillReturnRows(sqlmock.NewRows([]string{"from"}).AddRow(tktypes.RandAddress()))
+
+	polled, _ := ble.poll(ctx)
+	assert.Equal(t, 1, polled)
+
+	durations, slotsFilled, efficiency := gatherPollMetrics(t, m.metricsManager.Registry())
+	assert.Equal(t, uint64(1), durations.GetSampleCount())
+	assert.Equal(t, uint64(1), slotsFilled.GetSampleCount())
+	assert.Equal(t, float64(1), slotsFilled.GetSampleSum())
+	assert.Equal(t, 0.25, efficiency)
+}
+
+// Lets a test run code while the poll query is executing, when the engine does not hold the in-flight lock
+type duringQueryArg struct {
+	fn func()
+}
+
+func (a *duringQueryArg) Match(_ driver.Value) bool {
+	if a.fn != nil {
+		a.fn()
+		a.fn = nil
+	}
+	return true
+}
+
+func TestNewEnginePollingSignerAddedDuringQuery(t *testing.T) {
+	racingSigner := *tktypes.RandAddress()
+	otherSigner := *tktypes.RandAddress()
+
+	ctx, ble, m, done := newTestPublicTxManager(t, false, func(mocks *mocksAndTestControl, conf *pldconf.PublicTxManagerConfig) {
+		mocks.disableManagerStart = true
+		conf.Manager.MaxInFlightOrchestrators = confutil.P(4)
+	})
+	defer done()
+
+	// An orchestrator is added for the signer after the exclusion list is built, but before the query returns
+	ble.inFlightOrchestrators = map[tktypes.EthAddress]*orchestrator{}
+	racingOrchestrator := &orchestrator{
+		signingAddress:   racingSigner,
+		pubTxManager:     ble,
+		state:            OrchestratorStateRunning,
+		InFlightTxsStale: make(chan bool, 1),
+		stopProcess:      make(chan bool, 1),
+	}
+	m.db.ExpectQuery("SELECT.*public_txn").
+		WithArgs(&duringQueryArg{fn: func() {
+			ble.inFlightOrchestratorMux.Lock()
+			defer ble.inFlightOrchestratorMux.Unlock()
+			ble.inFlightOrchestrators[racingSigner] = racingOrchestrator
+		}}).
+		WillReturnRows(sqlmock.NewRows([]string{"from"}).
+			AddRow(racingSigner).
+			AddRow(otherSigner))
+
+	_, total := ble.poll(ctx)
+	assert.Equal(t, 2, total)
+	assert.Same(t, racingOrchestrator, ble.getOrchestratorForAddress(racingSigner))
+	assert.NotNil(t, ble.getOrchestratorForAddress(otherSigner))
+
+	families, err := m.metricsManager.Registry().Gather()
+	require.NoError(t, err)
+	extras := float64(-1)
+	for _, mf := range families {
+		if mf.GetName() == metricsPolledInFlightSigners {
+			extras = mf.GetMetric()[0].GetCounter().GetValue()
+		}
+	}
+	assert.Equal(t, float64(1), extras)
+}
+
+func TestNudgeOrchestratorForAddressOnlyWakesTarget(t *testing.T) {
+	testSigningAddr1 := *tktypes.RandAddress()
+	testSigningAddr2 := *tktypes.RandAddress()
+
+	ctx, ble, _, done := newTestPublicTxManager(t, false, func(mocks *mocksAndTestControl, conf *pldconf.PublicTxManagerConfig) {
+		mocks.disableManagerStart = true
+		conf.Manager.MaxInFlightOrchestrators = confutil.P(2)
+	})
+	defer done()
+
+	existingOrchestrator := &orchestrator{
+		signingAddress:   testSigningAddr1,
+		pubTxManager:     ble,
+		state:            OrchestratorStateRunning,
+		InFlightTxsStale: make(chan bool, 1),
+		stopProcess:      make(chan bool, 1),
+	}
+	ble.inFlightOrchestrators = map[tktypes.EthAddress]*orchestrator{
+		testSigningAddr1: existingOrchestrator,
+	}
+
+	// nudge is queued for the engine loop, without triggering a full poll
+	ble.NudgeOrchestratorForAddress(testSigningAddr2)
+	assert.Equal(t, testSigningAddr2, <-ble.orchestratorNudges)
+	assert.Empty(t, ble.inFlightOrchestratorStale)
+
+	// processing the nudge creates only the orchestrator for address 2
+	ble.pollAddress(ctx, testSigningAddr2)
+	assert.Len(t, ble.inFlightOrchestrators, 2)
+	assert.NotNil(t, ble.getOrchestratorForAddress(testSigningAddr2))
+	assert.Empty(t, existingOrchestrator.InFlightTxsStale)
+
+	// nudging address 1 wakes the existing orchestrator
+	ble.pollAddress(ctx, testSigningAddr1)
+	assert.Len(t, ble.inFlightOrchestrators, 2)
+	assert.Len(t, existingOrchestrator.InFlightTxsStale, 1)
+}
+
+func TestNudgeOrchestratorForAddressNoSlotOrPaused(t *testing.T) {
+	testSigningAddr1 := *tktypes.RandAddress()
+	testSigningAddr2 := *tktypes.RandAddress()
+	testSigningAddr3 := *tktypes.RandAddress()
+
+	ctx, ble, _, done := newTestPublicTxManager(t, false, func(mocks *mocksAndTestControl, conf *pldconf.PublicTxManagerConfig) {
+		mocks.disableManagerStart = true
+		conf.Manager.MaxInFlightOrchestrators = confutil.P(1)
+	})
+	defer done()
+
+	ble.inFlightOrchestrators = map[tktypes.EthAddress]*orchestrator{
+		testSigningAddr1: {signingAddress: testSigningAddr1, state: OrchestratorStateRunning, InFlightTxsStale: make(chan bool, 1)},
+	}
+	ble.signingAddressesPausedUntil = map[tktypes.EthAddress]time.Time{testSigningAddr3: time.Now().Add(1 * time.Hour)}
+
+	ble.pollAddress(ctx, testSigningAddr2)
+	assert.Nil(t, ble.getOrchestratorForAddress(testSigningAddr2))
+
+	delete(ble.inFlightOrchestrators, testSigningAddr1)
+	ble.pollAddress(ctx, testSigningAddr3)
+	assert.Nil(t, ble.getOrchestratorForAddress(testSigningAddr3))
+}
+
+func TestNudgeOrchestratorForAddressQueueFullFallsBackToPoll(t *testing.T) {
+	_, ble, _, done := newTestPublicTxManager(t, false, func(mocks *mocksAndTestControl, conf *pldconf.PublicTxManagerConfig) {
+		mocks.disableManagerStart = true
+	})
+	defer done()
+
+	for i := 0; i < orchestratorNudgeQueueLength; i++ {
+		ble.NudgeOrchestratorForAddress(*tktypes.RandAddress())
+	}
+	assert.Empty(t, ble.inFlightOrchestratorStale)
+	ble.NudgeOrchestratorForAddress(*tktypes.RandAddress())
+	assert.Len(t, ble.inFlightOrchestratorStale, 1)
+}
+
+func TestEngineWatchdogRestartsStuckOrchestrator(t *testing.T) {
+	ctx, ble, m, done := newTestPublicTxManager(t, false, func(mocks *mocksAndTestControl, conf *pldconf.PublicTxManagerConfig) {
+		mocks.disableManagerStart = true
+		conf.Manager.OrchestratorWatchdog = confutil.P("1m")
+		conf.Manager.OrchestratorStaleTimeout = confutil.P("1h")
+	})
+	defer done()
+	fc := newFakeClock()
+	ble.clock = fc
+
+	newFakeOrchestrator := func(state OrchestratorState, sinceProgress time.Duration) *orchestrator {
+		oc := &orchestrator{
+			signingAddress:   *tktypes.RandAddress(),
+			pubTxManager:     ble,
+			InFlightTxsStale: make(chan bool, 1),
+			stopProcess:      make(chan bool, 1),
+		}
+		oc.setState(state)
+		oc.lastProgressNanos.Store(fc.Now().Add(-sinceProgress).UnixNano())
+		return oc
+	}
+	// well either side of the 1m watchdog
+	stuck := newFakeOrchestrator(OrchestratorStateRunning, 10*time.Minute)
+	healthy := newFakeOrchestrator(OrchestratorStateRunning, 10*time.Second)
+	stale := newFakeOrchestrator(OrchestratorStateStale, 10*time.Minute)
+	ble.inFlightOrchestrators = map[tktypes.EthAddress]*orchestrator{
+		stuck.signingAddress:   stuck,
+		healthy.signingAddress: healthy,
+		stale.signingAddress:   stale,
+	}
+
+	inFlight, _, total := ble.flushStaleOrchestratorsGetCount(ctx)
+	assert.Equal(t, 2, total)
+	assert.NotContains(t, inFlight, stuck.signingAddress)
+	assert.Nil(t, ble.getOrchestratorForAddress(stuck.signingAddress))
+	assert.Len(t, stuck.stopProcess, 1)
+
+	// left to the normal lifecycle handling
+	assert.Same(t, healthy, ble.getOrchestratorForAddress(healthy.signingAddress))
+	assert.Empty(t, healthy.stopProcess)
+	assert.Same(t, stale, ble.getOrchestratorForAddress(stale.signingAddress))
+	assert.Empty(t, stale.stopProcess)
+
+	families, err := m.metricsManager.Registry().Gather()
+	require.NoError(t, err)
+	restarts := float64(-1)
+	for _, mf := range families {
+		if mf.GetName() == metricsWatchdogRestarts {
+			restarts = mf.GetMetric()[0].GetCounter().GetValue()
+		}
+	}
+	assert.Equal(t, float64(1), restarts)
+}
+
+func TestEngineWatchdogDisabledByDefault(t *testing.T) {
+	ctx, ble, _, done := newTestPublicTxManager(t, false, func(mocks *mocksAndTestControl, conf *pldconf.PublicTxManagerConfig) {
+		mocks.disableManagerStart = true
+	})
+	defer done()
+
+	stuck := &orchestrator{
+		signingAddress:   *tktypes.RandAddress(),
+		pubTxManager:     ble,
+		state:            OrchestratorStateRunning,
+		InFlightTxsStale: make(chan bool, 1),
+		stopProcess:      make(chan bool, 1),
+	}
+	ble.inFlightOrchestrators = map[tktypes.EthAddress]*orchestrator{stuck.signingAddress: stuck}
+
+	_, _, total := ble.flushStaleOrchestratorsGetCount(ctx)
+	assert.Equal(t, 1, total)
+	assert.Empty(t, stuck.stopProcess)
+}
+
+func TestEngineIdleAndStaleTimeoutsWithFakeClock(t *testing.T) {
+	ctx, ble, _, done := newTestPublicTxManager(t, false, func(mocks *mocksAndTestControl, conf *pldconf.PublicTxManagerConfig) {
+		mocks.disableManagerStart = true
+		conf.Manager.OrchestratorIdleTimeout = confutil.P("1m")
+		conf.Manager.OrchestratorStaleTimeout = confutil.P("5m")
+	})
+	defer done()
+
+	fc := newFakeClock()
+	ble.clock = fc
+
+	newFakeOrchestrator := func(state OrchestratorState) *orchestrator {
+		return &orchestrator{
+			signingAddress:   *tktypes.RandAddress(),
+			pubTxManager:     ble,
+			state:            state,
+			stateEntryTime:   fc.Now(),
+			InFlightTxsStale: make(chan bool, 1),
+			stopProcess:      make(chan bool, 1),
+		}
+	}
+	idle := newFakeOrchestrator(OrchestratorStateIdle)
+	stale := newFakeOrchestrator(OrchestratorStateStale)
+	ble.inFlightOrchestrators = map[tktypes.EthAddress]*orchestrator{
+		idle.signingAddress:  idle,
+		stale.signingAddress: stale,
+	}
+
+	// neither has reached its timeout
+	_, _, total := ble.flushStaleOrchestratorsGetCount(ctx)
+	assert.Equal(t, 2, total)
+	assert.Empty(t, idle.stopProcess)
+	assert.Empty(t, stale.stopProcess)
+
+	// only the idle timeout has passed
+	fc.Advance(2 * time.Minute)
+	_, _, _ = ble.flushStaleOrchestratorsGetCount(ctx)
+	assert.Len(t, idle.stopProcess, 1)
+	assert.Empty(t, stale.stopProcess)
+
+	// now the stale timeout has passed too
+	fc.Advance(5 * time.Minute)
+	_, _, _ = ble.flushStaleOrchestratorsGetCount(ctx)
+	assert.Len(t, stale.stopProcess, 1)
+}
+
+func TestEnginePauseExpiryWithFakeClock(t *testing.T) {
+	pausedAddr := *tktypes.RandAddress()
+
+	ctx, ble, _, done := newTestPublicTxManager(t, false, func(mocks *mocksAndTestControl, conf *pldconf.PublicTxManagerConfig) {
+		mocks.disableManagerStart = true
+	})
+	defer done()
+
+	fc := newFakeClock()
+	ble.clock = fc
+	ble.inFlightOrchestrators = map[tktypes.EthAddress]*orchestrator{}
+	ble.signingAddressesPausedUntil = map[tktypes.EthAddress]time.Time{pausedAddr: fc.Now().Add(1 * time.Minute)}
+
+	ble.pollAddress(ctx, pausedAddr)
+	assert.Nil(t, ble.getOrchestratorForAddress(pausedAddr))
+
+	fc.Advance(2 * time.Minute)
+	ble.pollAddress(ctx, pausedAddr)
+	oc := ble.getOrchestratorForAddress(pausedAddr)
+	require.NotNil(t, oc)
+	assert.Equal(t, fc.Now(), oc.orchestratorBirthTime)
+	assert.Equal(t, fc.Now(), ble.orchestratorLastStarted[pausedAddr])
+}
+
+func TestEnginePollingRetiresExcessOrchestratorsWhenMaxReduced(t *testing.T) {
+	ctx, ble, _, done := newTestPublicTxManager(t, false, func(mocks *mocksAndTestControl, conf *pldconf.PublicTxManagerConfig) {
+		mocks.disableManagerStart = true
+		conf.Manager.MaxInFlightOrchestrators = confutil.P(5)
+		conf.Manager.OrchestratorSwapTimeout = confutil.P("24h") // so a full pool does not pause any
+	})
+	defer done()
+	ble.inFlightOrchestrators = map[tktypes.EthAddress]*orchestrator{}
+
+	now := time.Now()
+	newFakeOrchestrator := func(birth time.Time, inFlight int) *orchestrator {
+		oc := NewOrchestrator(ble, *tktypes.RandAddress(), ble.conf)
+		oc.state = OrchestratorStateRunning
+		oc.orchestratorBirthTime = birth
+		for i := range inFlight {
+			it, _ := newInflightTransaction(oc, uint64(i))
+			oc.inFlightTxs = append(oc.inFlightTxs, it)
+		}
+		ble.inFlightOrchestrators[oc.signingAddress] = oc
+		return oc
+	}
+	idle := newFakeOrchestrator(now, 0)
+	quietOld := newFakeOrchestrator(now.Add(-1*time.Hour), 1)
+	quietNew := newFakeOrchestrator(now, 1)
+	busy := newFakeOrchestrator(now.Add(-2*time.Hour), 3)
+	submitting := newFakeOrchestrator(now.Add(-3*time.Hour), 0)
+	it, _ := newInflightTransaction(submitting, 0)
+	it.stateManager.(*inFlightTransactionState).stage = InFlightTxStageSubmitting
+	submitting.inFlightTxs = []*inFlightTransactionStageController{it}
+
+	// Nothing is retired while within the maximum
+	ble.poll(ctx)
+	for _, oc := range []*orchestrator{idle, quietOld, quietNew, busy, submitting} {
+		assert.False(t, oc.stopPending())
+	}
+
+	// Shrink the maximum - the idle orchestrator goes first, and then the one that has had its slot the longest,
+	// skipping the one that is part way through a submission
+	ble.maxInflight = 3
+	ble.poll(ctx)
+	assert.True(t, idle.stopPending())
+	assert.True(t, quietOld.stopPending())
+	assert.False(t, quietNew.stopPending())
+	assert.False(t, busy.stopPending())
+	assert.False(t, submitting.stopPending())
+
+	// Polling again while those stops are pending does not retire any more
+	ble.poll(ctx)
+	assert.False(t, quietNew.stopPending())
+	assert.False(t, busy.stopPending())
+
+	// Once they have stopped and been removed, shrinking again waits for the submission to complete
+	idle.state = OrchestratorStateStopped
+	quietOld.state = OrchestratorStateStopped
+	ble.maxInflight = 1
+	ble.poll(ctx)
+	assert.Equal(t, 3, ble.getOrchestratorCount())
+	assert.True(t, quietNew.stopPending())
+	assert.True(t, busy.stopPending())
+	assert.False(t, submitting.stopPending())
+}
+
+func TestEnginePollingPausePolicies(t *testing.T) {
+	for _, tc := range []struct {
+		policy   string
+		expected []string
+	}{
+		{policy: pldconf.OrchestratorPausePolicyOldest, expected: []string{"oldest", "stuck"}},
+		{policy: pldconf.OrchestratorPausePolicyLeastProgressed, expected: []string{"stuck"}},
+		{policy: pldconf.OrchestratorPausePolicyLowestPriority, expected: []string{"oldest"}},
+	} {
+		t.Run(tc.policy, func(t *testing.T) {
+			oldestAddr := tktypes.RandAddress()
+			stuckAddr := tktypes.RandAddress()
+			newestAddr := tktypes.RandAddress()
+			ctx, ble, _, done := newTestPublicTxManager(t, false, func(mocks *mocksAndTestControl, conf *pldconf.PublicTxManagerConfig) {
+				mocks.disableManagerStart = true
+				conf.Manager.MaxInFlightOrchestrators = confutil.P(3)
+				conf.Manager.OrchestratorSwapTimeout = confutil.P("10m")
+				conf.Manager.OrchestratorPausePolicy = confutil.P(tc.policy)
+				conf.Manager.SigningAddressPriority = map[string]int{
+					stuckAddr.String():  5,
+					newestAddr.String(): -1,
+				}
+			})
+			defer done()
+			ble.inFlightOrchestrators = map[tktypes.EthAddress]*orchestrator{}
+			fc := newFakeClock()
+			ble.clock = fc
+
+			// The same contention in each case - every slot is full, with:
+			// - the oldest orchestrator still making progress, at the default priority
+			// - a younger one that has made no progress since it started, at a high priority
+			// - the newest, which has not yet had its turn, at a low priority
+			newFakeOrchestrator := func(addr *tktypes.EthAddress, birth, lastProgress time.Duration) *orchestrator {
+				oc := NewOrchestrator(ble, *addr, ble.conf)
+				oc.state = OrchestratorStateRunning
+				oc.orchestratorBirthTime = fc.Now().Add(-birth)
+				oc.lastProgressNanos.Store(fc.Now().Add(-lastProgress).UnixNano())
+				ble.inFlightOrchestrators[oc.signingAddress] = oc
+				return oc
+			}
+			orchestrators := map[string]*orchestrator{
+				"oldest": newFakeOrchestrator(oldestAddr, 20*time.Minute, 1*time.Minute),
+				"stuck":  newFakeOrchestrator(stuckAddr, 15*time.Minute, 15*time.Minute),
+				"newest": newFakeOrchestrator(newestAddr, 1*time.Minute, 1*time.Minute),
+			}
+
+			ble.poll(ctx)
+
+			paused := []string{}
+			for name, oc := range orchestrators {
+				if oc.stopPending() {
+					paused = append(paused, name)
+					assert.Equal(t, fc.Now().Add(10*time.Minute), ble.signingAddressesPausedUntil[oc.signingAddress])
+				} else {
+					assert.NotContains(t, ble.signingAddressesPausedUntil, oc.signingAddress)
+				}
+			}
+			assert.ElementsMatch(t, tc.expected, paused)
+		})
+	}
+}
+
+func TestParseOrchestratorPausePolicy(t *testing.T) {
+	ctx := context.Background()
+
+	policy, priorities, err := parseOrchestratorPausePolicy(ctx, &pldconf.PublicTxManagerManagerConfig{})
+	require.NoError(t, err)
+	assert.Equal(t, pldconf.OrchestratorPausePolicyOldest, policy)
+	assert.Empty(t, priorities)
+
+	_, _, err = parseOrchestratorPausePolicy(ctx, &pldconf.PublicTxManagerManagerConfig{
+		OrchestratorPausePolicy: confutil.P("newest"),
+	})
+	assert.Regexp(t, "PD011956", err)
+
+	_, _, err = parseOrchestratorPausePolicy(ctx, &pldconf.PublicTxManagerManagerConfig{
+		OrchestratorPausePolicy: confutil.P(pldconf.OrchestratorPausePolicyLowestPriority),
+		SigningAddressPriority:  map[string]int{"wrong": 1},
+	})
+	assert.Regexp(t, "PD011957", err)
+}
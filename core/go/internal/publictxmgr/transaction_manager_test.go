@@ -20,17 +20,21 @@ import (
 	"crypto/rand"
 	"fmt"
 	"math/big"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/google/uuid"
+	"github.com/hyperledger/firefly-signer/pkg/abi"
 	"github.com/hyperledger/firefly-signer/pkg/ethsigner"
 	"github.com/hyperledger/firefly-signer/pkg/ethtypes"
+	"github.com/hyperledger/firefly-signer/pkg/secp256k1"
 	"github.com/kaleido-io/paladin/config/pkg/confutil"
 	"github.com/kaleido-io/paladin/config/pkg/pldconf"
 	"github.com/kaleido-io/paladin/core/internal/components"
 	"github.com/kaleido-io/paladin/core/internal/keymanager"
+	"github.com/kaleido-io/paladin/core/internal/metrics"
 	"github.com/kaleido-io/paladin/core/mocks/componentmocks"
 	"github.com/kaleido-io/paladin/core/mocks/ethclientmocks"
 	"github.com/kaleido-io/paladin/core/pkg/blockindexer"
@@ -58,6 +62,7 @@ type mocksAndTestControl struct {
 	ethClient           *ethclientmocks.EthClient
 	blockIndexer        *componentmocks.BlockIndexer
 	txManager           *componentmocks.TXManager
+	metricsManager      metrics.Metrics
 }
 
 // const testDestAddress = "0x6cee73cf4d5b0ac66ce2d1c0617bec4bedd09f39"
@@ -71,7 +76,9 @@ func baseMocks(t *testing.T) *mocksAndTestControl {
 		ethClient:        ethclientmocks.NewEthClient(t),
 		blockIndexer:     componentmocks.NewBlockIndexer(t),
 		txManager:        componentmocks.NewTXManager(t),
+		metricsManager:   metrics.NewMetricsManager(),
 	}
+	mocks.allComponents.On("MetricsManager").Return(mocks.metricsManager).Maybe()
 	mocks.allComponents.On("EthClientFactory").Return(mocks.ethClientFactory).Maybe()
 	mocks.ethClientFactory.On("SharedWS").Return(mocks.ethClient).Maybe()
 	mocks.ethClientFactory.On("HTTPClient").Return(mocks.ethClient).Maybe()
@@ -419,6 +426,708 @@ func fakeTxManagerInsert(t *testing.T, db *gorm.DB, txID uuid.UUID, fromStr stri
 	require.NoError(t, err)
 }
 
+func signTestRawTransaction(t *testing.T, kp *secp256k1.KeyPair, nonce uint64, chainID int64) tktypes.HexBytes {
+	rawTx, err := (&ethsigner.Transaction{
+		Nonce:                ethtypes.NewHexIntegerU64(nonce),
+		GasLimit:             ethtypes.NewHexIntegerU64(100000),
+		MaxFeePerGas:         ethtypes.NewHexIntegerU64(0),
+		MaxPriorityFeePerGas: ethtypes.NewHexIntegerU64(0),
+		To:                   tktypes.RandAddress().Address0xHex(),
+		Data:                 ethtypes.MustNewHexBytes0xPrefix("0xfeedbeef"),
+	}).SignEIP1559(kp, chainID)
+	require.NoError(t, err)
+	return rawTx
+}
+
+func TestRawTransactionLifecycleRealDB(t *testing.T) {
+	ctx, ble, m, done := newTestPublicTxManager(t, true, func(mocks *mocksAndTestControl, conf *pldconf.PublicTxManagerConfig) {
+		conf.Manager.Interval = confutil.P("50ms")
+		conf.Orchestrator.Interval = confutil.P("50ms")
+		conf.Manager.OrchestratorIdleTimeout = confutil.P("1ms")
+	})
+	defer done()
+
+	chainID, _ := rand.Int(rand.Reader, big.NewInt(100000000000000))
+	m.ethClient.On("ChainID").Return(chainID.Int64())
+
+	// The signing key is held outside of Paladin
+	kp, err := secp256k1.GenerateSecp256k1KeyPair()
+	require.NoError(t, err)
+	from := tktypes.EthAddress(kp.Address)
+	nonce := uint64(42)
+	rawTx := signTestRawTransaction(t, kp, nonce, chainID.Int64())
+	txHash := calculateTransactionHash(rawTx)
+
+	// The nonce is the next one for the signing address on the chain
+	m.ethClient.On("GetTransactionCount", mock.Anything, from).Return(confutil.P(tktypes.HexUint64(nonce)), nil)
+
+	// Paladin must send exactly the bytes it was given
+	submitted := make(chan struct{})
+	m.ethClient.On("SendRawTransaction", mock.Anything, rawTx).
+		Return(txHash, nil).
+		Run(func(args mock.Arguments) { close(submitted) }).
+		Once()
+
+	txID := uuid.New()
+	fakeTxManagerInsert(t, ble.p.DB(), txID, "signer1")
+	var ptx *pldapi.PublicTx
+	err = ble.p.Transaction(ctx, func(ctx context.Context, dbTX persistence.DBTX) (err error) {
+		ptx, err = ble.WriteNewRawTransaction(ctx, dbTX, &components.PublicRawTxSubmission{
+			Bindings: []*components.PaladinTXReference{
+				{TransactionID: txID, TransactionType: pldapi.TransactionTypePrivate.Enum()},
+			},
+			From:           from,
+			Nonce:          nonce,
+			RawTransaction: rawTx,
+		})
+		return err
+	})
+	require.NoError(t, err)
+	assert.Equal(t, from, ptx.From)
+	assert.Equal(t, nonce, ptx.Nonce.Uint64())
+	assert.Equal(t, uint64(100000), ptx.Gas.Uint64())
+	assert.Equal(t, "0xfeedbeef", ptx.Data.String())
+
+	ticker := time.NewTicker(10 * time.Millisecond)
+	defer ticker.Stop()
+	select {
+	case <-submitted:
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "pre-signed transaction was not submitted")
+	}
+
+	// Wait for the submission to be recorded against the transaction
+	var ptxQuery *pldapi.PublicTxWithBinding
+	for ptxQuery == nil || len(ptxQuery.Submissions) == 0 {
+		<-ticker.C
+		ptxQuery, err = ble.GetPublicTransactionForHash(ctx, ble.p.NOTX(), *txHash)
+		require.NoError(t, err)
+		if t.Failed() {
+			return
+		}
+	}
+	assert.Equal(t, txID, ptxQuery.Transaction)
+
+	// Confirm it on chain, and check the orchestrator completes the transaction
+	matches, err := ble.MatchUpdateConfirmedTransactions(ctx, ble.p.NOTX(), []*blockindexer.IndexedTransactionNotify{
+		{
+			IndexedTransaction: pldapi.IndexedTransaction{
+				Hash:             *txHash,
+				BlockNumber:      11223344,
+				TransactionIndex: 10,
+				From:             &from,
+				To:               ptx.To,
+				Nonce:            nonce,
+				Result:           pldapi.TXResult_SUCCESS.Enum(),
+			},
+		},
+	})
+	require.NoError(t, err)
+	require.Len(t, matches, 1)
+	assert.Equal(t, txID, matches[0].TransactionID)
+	ble.NotifyConfirmPersisted(ctx, matches)
+
+	for ble.getOrchestratorCount() > 0 {
+		<-ticker.C
+		if t.Failed() {
+			return
+		}
+	}
+}
+
+func TestWriteNewRawTransactionInvalid(t *testing.T) {
+	ctx, ble, m, done := newTestPublicTxManager(t, false, func(mocks *mocksAndTestControl, conf *pldconf.PublicTxManagerConfig) {
+		mocks.disableManagerStart = true
+	})
+	defer done()
+
+	m.ethClient.On("ChainID").Return(int64(12345))
+	kp, err := secp256k1.GenerateSecp256k1KeyPair()
+	require.NoError(t, err)
+	rawTx := signTestRawTransaction(t, kp, 10, 12345)
+
+	_, err = ble.WriteNewRawTransaction(ctx, ble.p.NOTX(), &components.PublicRawTxSubmission{
+		From:           tktypes.EthAddress(kp.Address),
+		Nonce:          10,
+		RawTransaction: tktypes.HexBytes("not a transaction"),
+	})
+	assert.Regexp(t, "PD011939", err)
+
+	_, err = ble.WriteNewRawTransaction(ctx, ble.p.NOTX(), &components.PublicRawTxSubmission{
+		From:           *tktypes.RandAddress(),
+		Nonce:          10,
+		RawTransaction: rawTx,
+	})
+	assert.Regexp(t, "PD011940", err)
+
+	_, err = ble.WriteNewRawTransaction(ctx, ble.p.NOTX(), &components.PublicRawTxSubmission{
+		From:           tktypes.EthAddress(kp.Address),
+		Nonce:          11,
+		RawTransaction: rawTx,
+	})
+	assert.Regexp(t, "PD011941", err)
+}
+
+func TestWriteNewRawTransactionNonceValidation(t *testing.T) {
+	ctx, ble, m, done := newTestPublicTxManager(t, true, func(mocks *mocksAndTestControl, conf *pldconf.PublicTxManagerConfig) {
+		mocks.disableManagerStart = true
+	})
+	defer done()
+
+	m.ethClient.On("ChainID").Return(int64(12345))
+	kp, err := secp256k1.GenerateSecp256k1KeyPair()
+	require.NoError(t, err)
+	from := tktypes.EthAddress(kp.Address)
+
+	// nonce 5 is complete, 7 and 9 are pending - leaving a gap at 8, and 10 as the next nonce
+	completedAt := time.Now()
+	insertTestPublicTxn(t, ctx, ble, from, 5, &completedAt)
+	pendingID := insertTestPublicTxn(t, ctx, ble, from, 7, nil)
+	insertTestPublicTxn(t, ctx, ble, from, 9, nil)
+
+	writeRaw := func(nonce uint64) error {
+		return ble.p.Transaction(ctx, func(ctx context.Context, dbTX persistence.DBTX) error {
+			_, err := ble.WriteNewRawTransaction(ctx, dbTX, &components.PublicRawTxSubmission{
+				From:           from,
+				Nonce:          nonce,
+				RawTransaction: signTestRawTransaction(t, kp, nonce, 12345),
+			})
+			return err
+		})
+	}
+
+	// filling the gap, and taking the next nonce, are both fine
+	require.NoError(t, writeRaw(8))
+	require.NoError(t, writeRaw(10))
+
+	// at or below the completed nonce watermark
+	assert.Regexp(t, "PD011960", writeRaw(5))
+	assert.Regexp(t, "PD011960", writeRaw(2))
+
+	// already assigned
+	assert.Regexp(t, fmt.Sprintf("PD011961.*%d", pendingID), writeRaw(7))
+
+	// beyond the next nonce, even once we check the chain
+	m.ethClient.On("GetTransactionCount", mock.Anything, from).Return(confutil.P(tktypes.HexUint64(8)), nil).Once()
+	assert.Regexp(t, "PD011964.*11", writeRaw(12))
+
+	// unless the chain is ahead of us
+	m.ethClient.On("GetTransactionCount", mock.Anything, from).Return(confutil.P(tktypes.HexUint64(12)), nil).Once()
+	require.NoError(t, writeRaw(12))
+
+	m.ethClient.On("GetTransactionCount", mock.Anything, from).Return(nil, fmt.Errorf("pop")).Once()
+	assert.Regexp(t, "pop", writeRaw(20))
+}
+
+func TestWriteNewTransactionsNonceOverride(t *testing.T) {
+	ctx, ble, _, done := newTestPublicTxManager(t, true, func(mocks *mocksAndTestControl, conf *pldconf.PublicTxManagerConfig) {
+		mocks.disableManagerStart = true
+	})
+	defer done()
+
+	// nonce 5 is complete, 7 and 9 are pending - leaving gaps at 6 and 8
+	from := *tktypes.RandAddress()
+	completedAt := time.Now()
+	insertTestPublicTxn(t, ctx, ble, from, 5, &completedAt)
+	pendingID := insertTestPublicTxn(t, ctx, ble, from, 7, nil)
+	insertTestPublicTxn(t, ctx, ble, from, 9, nil)
+
+	writeWithNonces := func(from tktypes.EthAddress, nonces ...uint64) (ptxs []*pldapi.PublicTx, err error) {
+		txs := make([]*components.PublicTxSubmission, len(nonces))
+		for i, nonce := range nonces {
+			txs[i] = &components.PublicTxSubmission{
+				PublicTxInput: pldapi.PublicTxInput{
+					From:  &from,
+					Nonce: confutil.P(tktypes.HexUint64(nonce)),
+					PublicTxOptions: pldapi.PublicTxOptions{
+						Gas: confutil.P(tktypes.HexUint64(100000)),
+					},
+				},
+			}
+		}
+		err = ble.p.Transaction(ctx, func(ctx context.Context, dbTX persistence.DBTX) (err error) {
+			ptxs, err = ble.WriteNewTransactions(ctx, dbTX, txs)
+			return err
+		})
+		return ptxs, err
+	}
+
+	// fill the gap at 8
+	ptxs, err := writeWithNonces(from, 8)
+	require.NoError(t, err)
+	require.Len(t, ptxs, 1)
+	assert.Equal(t, tktypes.HexUint64(8), *ptxs[0].Nonce)
+	var stored []*DBPublicTxn
+	err = ble.p.DB().Table("public_txns").Where(`"from" = ?`, from).Where("nonce = ?", 8).Find(&stored).Error
+	require.NoError(t, err)
+	assert.Len(t, stored, 1)
+
+	// conflicts with an existing transaction, including the one we just wrote
+	_, err = writeWithNonces(from, 7)
+	assert.Regexp(t, fmt.Sprintf("PD011961.*%d", pendingID), err)
+	_, err = writeWithNonces(from, 8)
+	assert.Regexp(t, "PD011961", err)
+
+	// at or below the completed watermark
+	_, err = writeWithNonces(from, 5)
+	assert.Regexp(t, "PD011960", err)
+	_, err = writeWithNonces(from, 3)
+	assert.Regexp(t, "PD011960", err)
+
+	// not a gap - automatic assignment owns these nonces
+	_, err = writeWithNonces(from, 10)
+	assert.Regexp(t, "PD011962", err)
+	_, err = writeWithNonces(*tktypes.RandAddress(), 0)
+	assert.Regexp(t, "PD011962", err)
+
+	// the same nonce twice in one batch
+	_, err = writeWithNonces(from, 6, 6)
+	assert.Regexp(t, "PD011963", err)
+}
+
+func TestCheckTransactionCompletedConfirmationDepth(t *testing.T) {
+	ctx, ble, m, done := newTestPublicTxManager(t, true, func(mocks *mocksAndTestControl, conf *pldconf.PublicTxManagerConfig) {
+		mocks.disableManagerStart = true
+		conf.Manager.ConfirmationDepth = confutil.P(5)
+	})
+	defer done()
+
+	txID := uuid.New()
+	fakeTxManagerInsert(t, ble.p.DB(), txID, "signer1")
+	var ptxs []*pldapi.PublicTx
+	err := ble.p.Transaction(ctx, func(ctx context.Context, dbTX persistence.DBTX) (err error) {
+		ptxs, err = ble.WriteNewTransactions(ctx, dbTX, []*components.PublicTxSubmission{{
+			Bindings: []*components.PaladinTXReference{
+				{TransactionID: txID, TransactionType: pldapi.TransactionTypePrivate.Enum()},
+			},
+			PublicTxInput: pldapi.PublicTxInput{
+				From: tktypes.RandAddress(),
+				PublicTxOptions: pldapi.PublicTxOptions{
+					Gas: confutil.P(tktypes.HexUint64(100000)),
+				},
+			},
+		}})
+		return err
+	})
+	require.NoError(t, err)
+	pubTxnID := *ptxs[0].LocalID
+
+	txHash := tktypes.RandBytes32()
+	err = ble.p.DB().Create(&DBPubTxnSubmission{
+		PublicTxnID:     pubTxnID,
+		Created:         tktypes.TimestampNow(),
+		TransactionHash: txHash,
+	}).Error
+	require.NoError(t, err)
+
+	// not yet mined
+	completed, err := ble.CheckTransactionCompleted(ctx, pubTxnID)
+	require.NoError(t, err)
+	assert.False(t, completed)
+
+	matches, err := ble.MatchUpdateConfirmedTransactions(ctx, ble.p.NOTX(), []*blockindexer.IndexedTransactionNotify{
+		{
+			IndexedTransaction: pldapi.IndexedTransaction{
+				Hash:        txHash,
+				BlockNumber: 1000,
+				Result:      pldapi.TXResult_SUCCESS.Enum(),
+			},
+		},
+	})
+	require.NoError(t, err)
+	require.Len(t, matches, 1)
+
+	// shallow confirmation
+	m.blockIndexer.On("GetBlockListenerHeight", mock.Anything).Return(uint64(1004), nil).Once()
+	completed, err = ble.CheckTransactionCompleted(ctx, pubTxnID)
+	require.NoError(t, err)
+	assert.False(t, completed)
+
+	// deep confirmation
+	m.blockIndexer.On("GetBlockListenerHeight", mock.Anything).Return(uint64(1005), nil).Once()
+	completed, err = ble.CheckTransactionCompleted(ctx, pubTxnID)
+	require.NoError(t, err)
+	assert.True(t, completed)
+
+	m.blockIndexer.On("GetBlockListenerHeight", mock.Anything).Return(uint64(0), fmt.Errorf("pop")).Once()
+	_, err = ble.CheckTransactionCompleted(ctx, pubTxnID)
+	assert.Regexp(t, "pop", err)
+
+	// no depth required
+	ble.confirmationDepth = 0
+	completed, err = ble.CheckTransactionCompleted(ctx, pubTxnID)
+	require.NoError(t, err)
+	assert.True(t, completed)
+}
+
+func TestTransactionGroupLifecycleRealKeyMgrAndDB(t *testing.T) {
+	ctx, ble, m, done := newTestPublicTxManager(t, true, func(mocks *mocksAndTestControl, conf *pldconf.PublicTxManagerConfig) {
+		conf.Manager.Interval = confutil.P("50ms")
+		conf.Orchestrator.Interval = confutil.P("50ms")
+		conf.Manager.OrchestratorIdleTimeout = confutil.P("1ms")
+		// the whole group is loaded, even though it is bigger than this
+		conf.Orchestrator.MaxInFlight = confutil.P(1)
+	})
+	defer done()
+
+	chainID, _ := rand.Int(rand.Reader, big.NewInt(100000000000000))
+	m.ethClient.On("ChainID").Return(chainID.Int64())
+	keyMapping, err := m.keyManager.ResolveKeyNewDatabaseTX(ctx, "signer1", algorithms.ECDSA_SECP256K1, verifiers.ETH_ADDRESS)
+	require.NoError(t, err)
+	resolvedKey := tktypes.MustEthAddress(keyMapping.Verifier.Verifier)
+
+	const groupSize = 3
+	txs := make([]*components.PublicTxSubmission, groupSize)
+	for i := range txs {
+		txID := uuid.New()
+		fakeTxManagerInsert(t, ble.p.DB(), txID, "signer1")
+		txs[i] = &components.PublicTxSubmission{
+			Bindings: []*components.PaladinTXReference{
+				{TransactionID: txID, TransactionType: pldapi.TransactionTypePrivate.Enum()},
+			},
+			PublicTxInput: pldapi.PublicTxInput{
+				From: resolvedKey,
+				Data: []byte(fmt.Sprintf("group %d", i)),
+				PublicTxOptions: pldapi.PublicTxOptions{
+					Gas: confutil.P(tktypes.HexUint64(100000)),
+				},
+			},
+		}
+	}
+
+	baseNonce := uint64(11223000)
+	m.ethClient.On("GetTransactionCount", mock.Anything, mock.Anything).
+		Return(confutil.P(tktypes.HexUint64(baseNonce)), nil).Once()
+	calculatedConfirmations := make(chan *blockindexer.IndexedTransactionNotify, groupSize)
+	dataByNonce := make(map[uint64]string)
+	srtx := m.ethClient.On("SendRawTransaction", mock.Anything, mock.Anything)
+	srtx.Run(func(args mock.Arguments) {
+		signedMessage := args[1].(tktypes.HexBytes)
+		_, ethTx, err := ethsigner.RecoverRawTransaction(ctx, ethtypes.HexBytes0xPrefix(signedMessage), m.ethClient.ChainID())
+		require.NoError(t, err)
+		txHash := calculateTransactionHash(signedMessage)
+		dataByNonce[ethTx.Nonce.Uint64()] = string(ethTx.Data)
+		calculatedConfirmations <- &blockindexer.IndexedTransactionNotify{
+			IndexedTransaction: pldapi.IndexedTransaction{
+				Hash:        *txHash,
+				BlockNumber: 11223344,
+				From:        resolvedKey,
+				Nonce:       ethTx.Nonce.Uint64(),
+				Result:      pldapi.TXResult_SUCCESS.Enum(),
+			},
+		}
+		srtx.Return(txHash, nil)
+	})
+
+	var group *components.PublicTxGroup
+	err = ble.p.Transaction(ctx, func(ctx context.Context, dbTX persistence.DBTX) (err error) {
+		group, err = ble.WriteNewTransactionGroup(ctx, dbTX, txs)
+		return err
+	})
+	require.NoError(t, err)
+	assert.Equal(t, components.PublicTxGroupStatusPending, group.Status)
+	require.Len(t, group.Transactions, groupSize)
+
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+	gatheredConfirmations := []*blockindexer.IndexedTransactionNotify{}
+	for len(gatheredConfirmations) < groupSize {
+		select {
+		case confirmation := <-calculatedConfirmations:
+			gatheredConfirmations = append(gatheredConfirmations, confirmation)
+		case <-ticker.C:
+			if t.Failed() {
+				return
+			}
+		}
+	}
+
+	// The nonces are contiguous, in the order of the group
+	for i := range txs {
+		assert.Equal(t, fmt.Sprintf("group %d", i), dataByNonce[baseNonce+uint64(i)])
+	}
+
+	group, err = ble.GetTransactionGroup(ctx, ble.p.NOTX(), group.ID)
+	require.NoError(t, err)
+	assert.Equal(t, components.PublicTxGroupStatusPending, group.Status)
+
+	matches, err := ble.MatchUpdateConfirmedTransactions(ctx, ble.p.NOTX(), gatheredConfirmations)
+	require.NoError(t, err)
+	require.Len(t, matches, groupSize)
+	ble.NotifyConfirmPersisted(ctx, matches)
+
+	group, err = ble.GetTransactionGroup(ctx, ble.p.NOTX(), group.ID)
+	require.NoError(t, err)
+	assert.Equal(t, components.PublicTxGroupStatusSucceeded, group.Status)
+
+	for ble.getOrchestratorCount() > 0 {
+		<-ticker.C
+		if t.Failed() {
+			return
+		}
+	}
+}
+
+func TestTransactionGroupMidGroupFailure(t *testing.T) {
+	ctx, ble, _, done := newTestPublicTxManager(t, true, func(mocks *mocksAndTestControl, conf *pldconf.PublicTxManagerConfig) {
+		mocks.disableManagerStart = true
+	})
+	defer done()
+
+	from := tktypes.RandAddress()
+	txs := make([]*components.PublicTxSubmission, 3)
+	for i := range txs {
+		txID := uuid.New()
+		fakeTxManagerInsert(t, ble.p.DB(), txID, "signer1")
+		txs[i] = &components.PublicTxSubmission{
+			Bindings: []*components.PaladinTXReference{
+				{TransactionID: txID, TransactionType: pldapi.TransactionTypePrivate.Enum()},
+			},
+			PublicTxInput: pldapi.PublicTxInput{
+				From: from,
+				PublicTxOptions: pldapi.PublicTxOptions{
+					Gas: confutil.P(tktypes.HexUint64(100000)),
+				},
+			},
+		}
+	}
+
+	var group *components.PublicTxGroup
+	err := ble.p.Transaction(ctx, func(ctx context.Context, dbTX persistence.DBTX) (err error) {
+		group, err = ble.WriteNewTransactionGroup(ctx, dbTX, txs)
+		return err
+	})
+	require.NoError(t, err)
+
+	// The second transaction is mined, but reverts
+	txHash := tktypes.RandBytes32()
+	err = ble.p.DB().Create(&DBPubTxnSubmission{
+		PublicTxnID:     *group.Transactions[1].LocalID,
+		Created:         tktypes.TimestampNow(),
+		TransactionHash: txHash,
+	}).Error
+	require.NoError(t, err)
+	err = ble.p.Transaction(ctx, func(ctx context.Context, dbTX persistence.DBTX) error {
+		_, err := ble.MatchUpdateConfirmedTransactions(ctx, dbTX, []*blockindexer.IndexedTransactionNotify{
+			{
+				IndexedTransaction: pldapi.IndexedTransaction{
+					Hash:        txHash,
+					BlockNumber: 1000,
+					Result:      pldapi.TXResult_FAILURE.Enum(),
+				},
+			},
+		})
+		return err
+	})
+	require.NoError(t, err)
+
+	group, err = ble.GetTransactionGroup(ctx, ble.p.NOTX(), group.ID)
+	require.NoError(t, err)
+	assert.Equal(t, components.PublicTxGroupStatusFailed, group.Status)
+
+	// Only the transaction after the failure is suspended
+	var ptxs []*DBPublicTxn
+	err = ble.p.DB().Table("public_txns").Where("group_id = ?", group.ID).Order("pub_txn_id").Find(&ptxs).Error
+	require.NoError(t, err)
+	require.Len(t, ptxs, 3)
+	assert.False(t, ptxs[0].Suspended)
+	assert.False(t, ptxs[1].Suspended)
+	assert.True(t, ptxs[2].Suspended)
+}
+
+func TestTransactionGroupErrors(t *testing.T) {
+	ctx, ble, _, done := newTestPublicTxManager(t, true, func(mocks *mocksAndTestControl, conf *pldconf.PublicTxManagerConfig) {
+		mocks.disableManagerStart = true
+	})
+	defer done()
+
+	_, err := ble.WriteNewTransactionGroup(ctx, ble.p.NOTX(), []*components.PublicTxSubmission{})
+	assert.Regexp(t, "PD011943", err)
+
+	_, err = ble.WriteNewTransactionGroup(ctx, ble.p.NOTX(), []*components.PublicTxSubmission{
+		{PublicTxInput: pldapi.PublicTxInput{From: tktypes.RandAddress()}},
+		{PublicTxInput: pldapi.PublicTxInput{From: tktypes.RandAddress()}},
+	})
+	assert.Regexp(t, "PD011944", err)
+
+	_, err = ble.GetTransactionGroup(ctx, ble.p.NOTX(), uuid.New())
+	assert.Regexp(t, "PD011945", err)
+}
+
+func writeTestTransactions(t *testing.T, ctx context.Context, ble *pubTxManager, from *tktypes.EthAddress, count int) []*pldapi.PublicTx {
+	txs := make([]*components.PublicTxSubmission, count)
+	for i := range txs {
+		txs[i] = &components.PublicTxSubmission{
+			PublicTxInput: pldapi.PublicTxInput{
+				From: from,
+				PublicTxOptions: pldapi.PublicTxOptions{
+					Gas: confutil.P(tktypes.HexUint64(100000)),
+				},
+			},
+		}
+	}
+	var ptxs []*pldapi.PublicTx
+	err := ble.p.Transaction(ctx, func(ctx context.Context, dbTX persistence.DBTX) (err error) {
+		ptxs, err = ble.WriteNewTransactions(ctx, dbTX, txs)
+		return err
+	})
+	require.NoError(t, err)
+	return ptxs
+}
+
+func TestGetNextNonceCold(t *testing.T) {
+	ctx, ble, m, done := newTestPublicTxManager(t, true, func(mocks *mocksAndTestControl, conf *pldconf.PublicTxManagerConfig) {
+		mocks.disableManagerStart = true
+	})
+	defer done()
+
+	from := tktypes.RandAddress()
+	m.ethClient.On("GetTransactionCount", mock.Anything, *from).
+		Return(confutil.P(tktypes.HexUint64(10)), nil)
+
+	// nothing recorded, so we use the pending nonce from the chain
+	nextNonce, err := ble.GetNextNonce(ctx, *from)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(10), nextNonce)
+
+	// transactions waiting for a nonce are assigned first
+	ptxs := writeTestTransactions(t, ctx, ble, from, 2)
+	nextNonce, err = ble.GetNextNonce(ctx, *from)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(12), nextNonce)
+
+	// we have assigned nonces ahead of the chain
+	err = ble.p.DB().Table("public_txns").Where("pub_txn_id = ?", *ptxs[0].LocalID).UpdateColumn("nonce", 20).Error
+	require.NoError(t, err)
+	nextNonce, err = ble.GetNextNonce(ctx, *from)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(22), nextNonce)
+}
+
+func TestGetNextNonceColdChainError(t *testing.T) {
+	ctx, ble, m, done := newTestPublicTxManager(t, true, func(mocks *mocksAndTestControl, conf *pldconf.PublicTxManagerConfig) {
+		mocks.disableManagerStart = true
+	})
+	defer done()
+
+	m.ethClient.On("GetTransactionCount", mock.Anything, mock.Anything).Return(nil, fmt.Errorf("pop"))
+	_, err := ble.GetNextNonce(ctx, *tktypes.RandAddress())
+	assert.Regexp(t, "pop", err)
+}
+
+func TestGetNextNonceWarm(t *testing.T) {
+	ctx, ble, _, done := newTestPublicTxManager(t, true, func(mocks *mocksAndTestControl, conf *pldconf.PublicTxManagerConfig) {
+		mocks.disableManagerStart = true
+	})
+	defer done()
+
+	from := tktypes.RandAddress()
+	oc := NewOrchestrator(ble, *from, ble.conf)
+	oc.nextNonce = confutil.P(uint64(42))
+	oc.lastNonceAlloc = time.Now()
+	ble.inFlightOrchestrators = map[tktypes.EthAddress]*orchestrator{*from: oc}
+
+	// the orchestrator's next nonce is used, without a call to the chain
+	nextNonce, err := ble.GetNextNonce(ctx, *from)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(42), nextNonce)
+
+	writeTestTransactions(t, ctx, ble, from, 3)
+	nextNonce, err = ble.GetNextNonce(ctx, *from)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(45), nextNonce)
+}
+
+func TestAllocateNoncesSerializedAcrossOrchestrators(t *testing.T) {
+	ctx, ble, m, done := newTestPublicTxManager(t, true, func(mocks *mocksAndTestControl, conf *pldconf.PublicTxManagerConfig) {
+		mocks.disableManagerStart = true
+	})
+	defer done()
+
+	from := tktypes.RandAddress()
+	m.ethClient.On("GetTransactionCount", mock.Anything, *from).
+		Return(confutil.P(tktypes.HexUint64(0)), nil)
+
+	// Multiple orchestrators for the same address, each with no cached nonce, all racing to assign
+	// nonces to their own batches of transactions
+	const orchestrators = 5
+	const batches = 4
+	const batchSize = 5
+	ptxs := writeTestTransactions(t, ctx, ble, from, orchestrators*batches*batchSize)
+	var wg sync.WaitGroup
+	errs := make(chan error, orchestrators*batches)
+	for i := 0; i < orchestrators; i++ {
+		oc := NewOrchestrator(ble, *from, ble.conf)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for b := 0; b < batches; b++ {
+				start := (i*batches + b) * batchSize
+				txns := make([]*DBPublicTxn, batchSize)
+				for j := range txns {
+					txns[j] = &DBPublicTxn{PublicTxnID: *ptxs[start+j].LocalID, From: *from}
+				}
+				errs <- oc.allocateNonces(ctx, txns)
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		require.NoError(t, err)
+	}
+
+	var nonces []uint64
+	err := ble.p.DB().Table("public_txns").
+		Where(`"from" = ?`, from).
+		Order("nonce").
+		Pluck("nonce", &nonces).
+		Error
+	require.NoError(t, err)
+	require.Len(t, nonces, orchestrators*batches*batchSize)
+	for i, nonce := range nonces {
+		assert.Equal(t, uint64(i), nonce)
+	}
+}
+
+func TestQueryPublicTxSortByUpdated(t *testing.T) {
+	ctx, ble, _, done := newTestPublicTxManager(t, true, func(mocks *mocksAndTestControl, conf *pldconf.PublicTxManagerConfig) {
+		mocks.disableManagerStart = true
+	})
+	defer done()
+
+	from := tktypes.RandAddress()
+	ptxs := writeTestTransactions(t, ctx, ble, from, 3)
+	for _, ptx := range ptxs {
+		assert.Equal(t, ptx.Created, ptx.Updated)
+	}
+
+	// the first transaction we wrote is the most recently updated, once it has a new submission
+	err := ble.p.Transaction(ctx, func(ctx context.Context, dbTX persistence.DBTX) error {
+		_, err := ble.submissionWriter.runBatch(ctx, dbTX, []*DBPubTxnSubmission{{
+			PublicTxnID:     *ptxs[0].LocalID,
+			Created:         tktypes.TimestampNow(),
+			TransactionHash: tktypes.RandBytes32(),
+		}})
+		return err
+	})
+	require.NoError(t, err)
+
+	queryTxs, err := ble.QueryPublicTxWithBindings(ctx, ble.p.NOTX(),
+		query.NewQueryBuilder().Equal("from", from).Sort("-updated").Query())
+	require.NoError(t, err)
+	require.Len(t, queryTxs, 3)
+	assert.Equal(t, *ptxs[0].LocalID, *queryTxs[0].LocalID)
+	assert.Greater(t, queryTxs[0].Updated, queryTxs[0].Created)
+
+	// the timestamps can also be filtered on
+	queryTxs, err = ble.QueryPublicTxWithBindings(ctx, ble.p.NOTX(),
+		query.NewQueryBuilder().Equal("from", from).GreaterThan("updated", ptxs[2].Created).Sort("-created").Query())
+	require.NoError(t, err)
+	require.Len(t, queryTxs, 1)
+	assert.Equal(t, *ptxs[0].LocalID, *queryTxs[0].LocalID)
+}
+
 func TestSubmitFailures(t *testing.T) {
 	ctx, ble, m, done := newTestPublicTxManager(t, false)
 	defer done()
@@ -605,3 +1314,217 @@ func TestGasEstimateFactor(t *testing.T) {
 	require.NoError(t, ble.ValidateTransaction(ctx, ble.p.NOTX(), tx))
 	assert.Equal(t, tktypes.MustParseHexUint64("0xc5f0"), *tx.Gas)
 }
+
+func TestQueryPublicTxByLabel(t *testing.T) {
+	ctx, ble, _, done := newTestPublicTxManager(t, true, func(mocks *mocksAndTestControl, conf *pldconf.PublicTxManagerConfig) {
+		mocks.disableManagerStart = true
+	})
+	defer done()
+
+	from := tktypes.RandAddress()
+	labels := []string{"rebalance", "rebalance-eu", "payout", ""}
+	txs := make([]*components.PublicTxSubmission, len(labels))
+	for i, label := range labels {
+		txs[i] = &components.PublicTxSubmission{
+			PublicTxInput: pldapi.PublicTxInput{
+				From:  from,
+				Label: label,
+				PublicTxOptions: pldapi.PublicTxOptions{
+					Gas: confutil.P(tktypes.HexUint64(100000)),
+				},
+			},
+		}
+	}
+	err := ble.p.Transaction(ctx, func(ctx context.Context, dbTX persistence.DBTX) (err error) {
+		_, err = ble.WriteNewTransactions(ctx, dbTX, txs)
+		return err
+	})
+	require.NoError(t, err)
+
+	exact, err := ble.QueryPublicTxWithBindings(ctx, ble.p.NOTX(),
+		query.NewQueryBuilder().Equal("label", "rebalance").Query())
+	require.NoError(t, err)
+	require.Len(t, exact, 1)
+	assert.Equal(t, "rebalance", exact[0].Label)
+
+	prefix, err := ble.QueryPublicTxWithBindings(ctx, ble.p.NOTX(),
+		query.NewQueryBuilder().Like("label", "rebal%").Sort("localId").Query())
+	require.NoError(t, err)
+	require.Len(t, prefix, 2)
+	assert.Equal(t, "rebalance", prefix[0].Label)
+	assert.Equal(t, "rebalance-eu", prefix[1].Label)
+
+	substring, err := ble.QueryPublicTxWithBindings(ctx, ble.p.NOTX(),
+		query.NewQueryBuilder().Like("label", "%-eu%").Query())
+	require.NoError(t, err)
+	require.Len(t, substring, 1)
+	assert.Equal(t, "rebalance-eu", substring[0].Label)
+
+	unlabelled, err := ble.QueryPublicTxWithBindings(ctx, ble.p.NOTX(),
+		query.NewQueryBuilder().Null("label").Query())
+	require.NoError(t, err)
+	require.Len(t, unlabelled, 1)
+	assert.Empty(t, unlabelled[0].Label)
+}
+
+func TestSingleTransactionSubmitDryRun(t *testing.T) {
+	ctx, ble, m, done := newTestPublicTxManager(t, true, func(mocks *mocksAndTestControl, conf *pldconf.PublicTxManagerConfig) {
+		mocks.disableManagerStart = true
+		conf.GasPrice.FixedGasPrice = nil
+	})
+	defer done()
+
+	keyMapping, err := m.keyManager.ResolveKeyNewDatabaseTX(ctx, "signer1", algorithms.ECDSA_SECP256K1, verifiers.ETH_ADDRESS)
+	require.NoError(t, err)
+	resolvedKey := *tktypes.MustEthAddress(keyMapping.Verifier.Verifier)
+
+	m.ethClient.On("ChainID").Return(int64(12345))
+	m.ethClient.On("GasPrice", mock.Anything).Return(tktypes.MustParseHexUint256("1000"), nil)
+	m.ethClient.On("EstimateGasNoResolve", mock.Anything, mock.Anything, mock.Anything).
+		Return(ethclient.EstimateGasResult{GasLimit: tktypes.HexUint64(100)}, nil)
+	m.ethClient.On("GetTransactionCount", mock.Anything, resolvedKey).Return(confutil.P(tktypes.HexUint64(42)), nil)
+	m.ethClient.On("GetBalance", mock.Anything, resolvedKey, "latest").Return(tktypes.Uint64ToUint256(1000000), nil)
+
+	dryRun := func() *pldapi.PublicTx {
+		tx, err := ble.SingleTransactionSubmit(ctx, &components.PublicTxSubmission{
+			DryRun: true,
+			PublicTxInput: pldapi.PublicTxInput{
+				From: &resolvedKey,
+				To:   tktypes.RandAddress(),
+				PublicTxOptions: pldapi.PublicTxOptions{
+					Value: tktypes.Uint64ToUint256(1000),
+				},
+			},
+		})
+		require.NoError(t, err)
+		return tx
+	}
+
+	tx := dryRun()
+	assert.Nil(t, tx.LocalID)
+	assert.Equal(t, resolvedKey, tx.From)
+	assert.Equal(t, uint64(42), tx.Nonce.Uint64())
+	assert.Equal(t, uint64(150), tx.Gas.Uint64()) // with the default estimate factor
+	assert.Equal(t, "1000", tx.GasPrice.Int().String())
+	assert.NotNil(t, tx.TransactionHash)
+
+	// Nothing is written, so a second dry-run gets the same nonce
+	assert.Equal(t, uint64(42), dryRun().Nonce.Uint64())
+	var count int64
+	err = ble.p.DB().Model(&DBPublicTxn{}).Where(`"from" = ?`, resolvedKey).Count(&count).Error
+	require.NoError(t, err)
+	assert.Zero(t, count)
+
+	// ... and there is nothing for the engine to start an orchestrator for
+	polled, _ := ble.poll(ctx)
+	assert.Zero(t, polled)
+	assert.Zero(t, ble.getOrchestratorCount())
+	assert.Nil(t, ble.getOrchestratorForAddress(resolvedKey))
+}
+
+func TestSingleTransactionSubmitDryRunInsufficientBalance(t *testing.T) {
+	ctx, ble, m, done := newTestPublicTxManager(t, true, func(mocks *mocksAndTestControl, conf *pldconf.PublicTxManagerConfig) {
+		mocks.disableManagerStart = true
+		conf.GasPrice.FixedGasPrice = nil
+	})
+	defer done()
+
+	keyMapping, err := m.keyManager.ResolveKeyNewDatabaseTX(ctx, "signer1", algorithms.ECDSA_SECP256K1, verifiers.ETH_ADDRESS)
+	require.NoError(t, err)
+	resolvedKey := *tktypes.MustEthAddress(keyMapping.Verifier.Verifier)
+
+	m.ethClient.On("GasPrice", mock.Anything).Return(tktypes.MustParseHexUint256("1000"), nil)
+	m.ethClient.On("GetTransactionCount", mock.Anything, resolvedKey).Return(confutil.P(tktypes.HexUint64(42)), nil)
+	m.ethClient.On("GetBalance", mock.Anything, resolvedKey, "latest").Return(tktypes.Uint64ToUint256(1000), nil)
+
+	_, err = ble.SingleTransactionSubmit(ctx, &components.PublicTxSubmission{
+		DryRun: true,
+		PublicTxInput: pldapi.PublicTxInput{
+			From: &resolvedKey,
+			To:   tktypes.RandAddress(),
+			PublicTxOptions: pldapi.PublicTxOptions{
+				Gas:   confutil.P(tktypes.HexUint64(100)),
+				Value: tktypes.Uint64ToUint256(1),
+			},
+		},
+	})
+	assert.Regexp(t, "PD011946.*1000.*100001", err)
+
+	var count int64
+	err = ble.p.DB().Model(&DBPublicTxn{}).Where(`"from" = ?`, resolvedKey).Count(&count).Error
+	require.NoError(t, err)
+	assert.Zero(t, count)
+}
+
+func TestSingleTransactionSubmitDryRunGasEstimateFail(t *testing.T) {
+	ctx, ble, m, done := newTestPublicTxManager(t, false, func(mocks *mocksAndTestControl, conf *pldconf.PublicTxManagerConfig) {
+		mocks.disableManagerStart = true
+	})
+	defer done()
+
+	m.ethClient.On("EstimateGasNoResolve", mock.Anything, mock.Anything, mock.Anything).
+		Return(ethclient.EstimateGasResult{}, fmt.Errorf("pop"))
+
+	_, err := ble.SingleTransactionSubmit(ctx, &components.PublicTxSubmission{
+		DryRun: true,
+		PublicTxInput: pldapi.PublicTxInput{
+			From: tktypes.RandAddress(),
+		},
+	})
+	assert.Regexp(t, "pop", err)
+}
+
+func TestWriteNewTransactionsRejectsDryRun(t *testing.T) {
+	ctx, ble, _, done := newTestPublicTxManager(t, false, func(mocks *mocksAndTestControl, conf *pldconf.PublicTxManagerConfig) {
+		mocks.disableManagerStart = true
+	})
+	defer done()
+
+	_, err := ble.WriteNewTransactions(ctx, ble.p.NOTX(), []*components.PublicTxSubmission{
+		{
+			DryRun: true,
+			PublicTxInput: pldapi.PublicTxInput{
+				From: tktypes.RandAddress(),
+				PublicTxOptions: pldapi.PublicTxOptions{
+					Gas: confutil.P(tktypes.HexUint64(100)),
+				},
+			},
+		},
+	})
+	assert.Regexp(t, "PD011947", err)
+}
+
+func TestMapPersistedTransactionMinedOutcome(t *testing.T) {
+	txHash := tktypes.RandBytes32()
+
+	// mined successfully
+	tx := mapPersistedTransaction(&DBPublicTxn{
+		Completed: &DBPublicTxnCompletion{TransactionHash: txHash, Success: true},
+	})
+	assert.True(t, *tx.Success)
+	assert.Equal(t, txHash, *tx.TransactionHash)
+	assert.Empty(t, tx.FailureCategory)
+	assert.Empty(t, tx.RevertReason)
+
+	// mined, but reverted with a standard error
+	revertData, err := (&abi.Entry{
+		Type: abi.Error, Name: "Error", Inputs: abi.ParameterArray{&abi.Parameter{Name: "reason", Type: "string"}},
+	}).EncodeCallDataValues([]any{"not enough tokens"})
+	require.NoError(t, err)
+	tx = mapPersistedTransaction(&DBPublicTxn{
+		Completed: &DBPublicTxnCompletion{TransactionHash: txHash, Success: false, RevertData: revertData},
+	})
+	assert.False(t, *tx.Success)
+	assert.Equal(t, txHash, *tx.TransactionHash)
+	assert.Equal(t, pldapi.PublicTxFailureReverted, tx.FailureCategory)
+	assert.Equal(t, tktypes.HexBytes(revertData), tx.RevertData)
+	assert.Equal(t, `Error("not enough tokens")`, tx.RevertReason)
+
+	// mined, but reverted with data we cannot decode without the ABI
+	tx = mapPersistedTransaction(&DBPublicTxn{
+		Completed: &DBPublicTxnCompletion{TransactionHash: txHash, Success: false, RevertData: tktypes.HexBytes("0xfeedbeef")},
+	})
+	assert.False(t, *tx.Success)
+	assert.Equal(t, pldapi.PublicTxFailureReverted, tx.FailureCategory)
+	assert.Empty(t, tx.RevertReason)
+}
@@ -18,18 +18,24 @@ package publictxmgr
 import (
 	"context"
 	"math/big"
+	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/kaleido-io/paladin/config/pkg/confutil"
 	"github.com/kaleido-io/paladin/config/pkg/pldconf"
 	"github.com/kaleido-io/paladin/core/pkg/blockindexer"
 	"github.com/kaleido-io/paladin/core/pkg/persistence"
 
 	"github.com/kaleido-io/paladin/core/pkg/ethclient"
+	"github.com/kaleido-io/paladin/toolkit/pkg/algorithms"
 	"github.com/kaleido-io/paladin/toolkit/pkg/log"
+	"github.com/kaleido-io/paladin/toolkit/pkg/pldapi"
 	"github.com/kaleido-io/paladin/toolkit/pkg/retry"
 	"github.com/kaleido-io/paladin/toolkit/pkg/tktypes"
+	"github.com/kaleido-io/paladin/toolkit/pkg/verifiers"
 )
 
 const (
@@ -114,15 +120,25 @@ type orchestrator struct {
 
 	transactionSubmissionRetry *retry.Retry
 
+	// backoff between failed attempts to start a stage, and how many attempts before we report the failure
+	stageTriggerRetry       *retry.Retry
+	stageTriggerMaxAttempts int
+
 	// each transaction orchestrator has its own go routine
 	orchestratorBirthTime       time.Time          // when transaction orchestrator is created
 	orchestratorPollingInterval time.Duration      // between how long the transaction orchestrator will do a poll and trigger none-event driven transaction process actions
 	signingAddress              tktypes.EthAddress // the signing address of the transaction managed by the current transaction orchestrator
 
 	// balance check settings
+	balanceCheck                       bool // when disabled, transactions are signed and submitted without checking they can be afforded
 	hasZeroGasPrice                    bool
 	unavailableBalanceHandlingStrategy OrchestratorBalanceCheckUnavailableBalanceHandlingStrategy
 
+	// cold start reconciliation with the confirmed nonce on the chain
+	coldStartReconcile      bool
+	coldStartNonceFloor     *uint64 // set by the cold start reconciliation - transactions at or below this nonce are known to be mined, so are not loaded to submit
+	completedNonceWatermark *uint64 // the highest nonce we know to be mined, reported for diagnostics
+
 	// in flight txs array
 	maxInFlightTxs       int
 	inFlightTxs          []*inFlightTransactionStageController // a queue of all the in flight transactions
@@ -140,9 +156,19 @@ type orchestrator struct {
 
 	staleTimeout    time.Duration
 	lastQueueUpdate time.Time
+	// lastProgressTime() as unix nanos, published on each poll for the engine watchdog to read without
+	// taking inFlightTxsMux - as the reason it is checking is that we might be stuck while holding the lock
+	lastProgressNanos atomic.Int64
+	// the state, published by setState for the engine loop (and its watchdog) to read without taking inFlightTxsMux
+	watchdogState atomic.Value
 
 	lastNonceAlloc time.Time
 	nextNonce      *uint64
+
+	gasPriceOverride *gasPriceOverride // nil unless configured for this signing address
+
+	signerUnhealthy bool // the result of the last signer health check
+	signerKeyLost   bool // the key loss policy has been applied, as the key for the address no longer exists
 }
 
 const veryShortMinimum = 50 * time.Millisecond
@@ -156,12 +182,12 @@ func NewOrchestrator(
 
 	newOrchestrator := &orchestrator{
 		pubTxManager:                ble,
-		orchestratorBirthTime:       time.Now(),
+		orchestratorBirthTime:       ble.clock.Now(),
 		orchestratorPollingInterval: confutil.DurationMin(conf.Orchestrator.Interval, veryShortMinimum, *pldconf.PublicTxManagerDefaults.Orchestrator.Interval),
 		maxInFlightTxs:              confutil.IntMin(conf.Orchestrator.MaxInFlight, 1, *pldconf.PublicTxManagerDefaults.Orchestrator.MaxInFlight),
 		signingAddress:              signingAddress,
 		state:                       OrchestratorStateNew,
-		stateEntryTime:              time.Now(),
+		stateEntryTime:              ble.clock.Now(),
 		unavailableBalanceHandlingStrategy: OrchestratorBalanceCheckUnavailableBalanceHandlingStrategy(
 			confutil.StringNotEmpty(conf.Orchestrator.UnavailableBalanceHandler, string(OrchestratorBalanceCheckUnavailableBalanceHandlingStrategyWait))),
 
@@ -172,13 +198,22 @@ func NewOrchestrator(
 
 		// submission retry
 		transactionSubmissionRetry: retry.NewRetryLimited(&conf.Orchestrator.SubmissionRetry),
+		stageTriggerRetry:          retry.NewRetryLimited(&conf.Orchestrator.StageTriggerRetry, &pldconf.PublicTxManagerDefaults.Orchestrator.StageTriggerRetry),
+		stageTriggerMaxAttempts:    confutil.IntMin(conf.Orchestrator.StageTriggerRetry.MaxAttempts, 1, *pldconf.PublicTxManagerDefaults.Orchestrator.StageTriggerRetry.MaxAttempts),
 		staleTimeout:               confutil.DurationMin(conf.Orchestrator.StaleTimeout, 0, *pldconf.PublicTxManagerDefaults.Orchestrator.StaleTimeout),
+		balanceCheck:               confutil.Bool(conf.Orchestrator.BalanceCheck, *pldconf.PublicTxManagerDefaults.Orchestrator.BalanceCheck),
+		coldStartReconcile:         confutil.Bool(conf.Orchestrator.ColdStartReconcile, *pldconf.PublicTxManagerDefaults.Orchestrator.ColdStartReconcile),
 		hasZeroGasPrice:            ble.gasPriceClient.HasZeroGasPrice(ctx),
 		InFlightTxsStale:           make(chan bool, 1),
 		stopProcess:                make(chan bool, 1),
 		ethClient:                  ble.ethClient,
 		bIndexer:                   ble.bIndexer,
 	}
+	newOrchestrator.lastProgressNanos.Store(newOrchestrator.orchestratorBirthTime.UnixNano())
+	newOrchestrator.watchdogState.Store(newOrchestrator.state)
+	if !newOrchestrator.hasZeroGasPrice {
+		newOrchestrator.gasPriceOverride = ble.gasPriceOverrides[signingAddress]
+	}
 
 	log.L(ctx).Debugf("NewOrchestrator for signing address %s created: %+v", newOrchestrator.signingAddress, newOrchestrator)
 
@@ -196,6 +231,13 @@ func (oc *orchestrator) orchestratorLoop() {
 		return
 	}
 
+	if oc.coldStartReconcile {
+		if err := oc.reconcileColdStartRetry(ctx); err != nil {
+			log.L(ctx).Warnf("Context cancelled while reconciling completed nonce for %s: %s", oc.signingAddress, err)
+			return
+		}
+	}
+
 	ticker := time.NewTicker(oc.orchestratorPollingInterval)
 	defer ticker.Stop()
 	for {
@@ -207,11 +249,15 @@ func (oc *orchestrator) orchestratorLoop() {
 			log.L(ctx).Infof("Orchestrator loop exit due to canceled context, it processed %d transaction during its lifetime.", oc.totalCompleted)
 			return
 		case <-oc.stopProcess:
-			log.L(ctx).Infof("Orchestrator loop process stopped, it processed %d transaction during its lifetime.", oc.totalCompleted)
-			oc.state = OrchestratorStateStopped
-			oc.stateEntryTime = time.Now()
-			oc.MarkInFlightOrchestratorsStale() // trigger engine loop for removal
+			oc.processStopped(ctx)
+			return
+		}
+		// A stop requested while we were busy (such as by the watchdog, if we were stuck) takes priority
+		select {
+		case <-oc.stopProcess:
+			oc.processStopped(ctx)
 			return
+		default:
 		}
 		polled, total := oc.pollAndProcess(ctx)
 		log.L(ctx).Debugf("Orchestrator loop polled %d txs, there are %d txs in total", polled, total)
@@ -219,6 +265,91 @@ func (oc *orchestrator) orchestratorLoop() {
 
 }
 
+func (oc *orchestrator) processStopped(ctx context.Context) {
+	log.L(ctx).Infof("Orchestrator loop process stopped, it processed %d transaction during its lifetime.", oc.totalCompleted)
+	oc.setState(OrchestratorStateStopped)
+	oc.MarkInFlightOrchestratorsStale() // trigger engine loop for removal
+}
+
+func (oc *orchestrator) setState(state OrchestratorState) {
+	oc.state = state
+	oc.stateEntryTime = oc.clock.Now()
+	oc.watchdogState.Store(state)
+}
+
+// The state last set on the orchestrator routine, safe to read from other routines
+func (oc *orchestrator) publishedState() OrchestratorState {
+	state, _ := oc.watchdogState.Load().(OrchestratorState)
+	return state
+}
+
+// An orchestrator that is not idle or stale, but has not reported progress for longer than the
+// watchdog duration, is assumed to be stuck. Running orchestrators that are polling but not making
+// progress become stale first, so the watchdog should be set longer than the stale timeout.
+func (oc *orchestrator) isStuck(watchdog time.Duration) bool {
+	switch oc.publishedState() {
+	case OrchestratorStateIdle, OrchestratorStateStale, OrchestratorStateStopped:
+		return false
+	}
+	return oc.clock.Since(time.Unix(0, oc.lastProgressNanos.Load())) > watchdog
+}
+
+// A point-in-time view of the orchestrator for diagnostics, taken under the in-flight lock so the nonces
+// reported are consistent with each other
+func (oc *orchestrator) snapshot() *pldapi.PublicTxOrchestratorStatus {
+	oc.inFlightTxsMux.Lock()
+	defer oc.inFlightTxsMux.Unlock()
+	status := &pldapi.PublicTxOrchestratorStatus{
+		SigningAddress:     oc.signingAddress,
+		State:              string(oc.state),
+		StateEntryTime:     tktypes.Timestamp(oc.stateEntryTime.UnixNano()),
+		InFlightCount:      len(oc.inFlightTxs),
+		TotalCompleted:     oc.totalCompleted,
+		GasPriceMultiplier: oc.gasPriceOverride.effectiveMultiplier(),
+		GasPriceOverridden: oc.gasPriceOverride != nil,
+	}
+	if oc.completedNonceWatermark != nil {
+		status.CompletedNonceWatermark = confutil.P(tktypes.HexUint64(*oc.completedNonceWatermark))
+	}
+	nonces := make([]uint64, len(oc.inFlightTxs))
+	for i, it := range oc.inFlightTxs {
+		nonces[i] = it.stateManager.GetNonce()
+	}
+	if len(nonces) == 0 {
+		return status
+	}
+	sort.Slice(nonces, func(i, j int) bool { return nonces[i] < nonces[j] })
+	status.LowestInFlightNonce = confutil.P(tktypes.HexUint64(nonces[0]))
+	status.HighestInFlightNonce = confutil.P(tktypes.HexUint64(nonces[len(nonces)-1]))
+	if oc.completedNonceWatermark != nil && nonces[0] > *oc.completedNonceWatermark+1 {
+		status.NonceGap = true
+	}
+	for i := 1; i < len(nonces); i++ {
+		if nonces[i] > nonces[i-1]+1 {
+			status.NonceGap = true
+		}
+	}
+	return status
+}
+
+// Reports how busy the orchestrator is, so the engine can choose which to retire when it has more orchestrators
+// than it is allowed. An orchestrator that is part way through submitting a transaction should not be retired.
+func (oc *orchestrator) retirementLoad(ctx context.Context) (inFlightCount int, submitting bool) {
+	oc.inFlightTxsMux.Lock()
+	defer oc.inFlightTxsMux.Unlock()
+	for _, it := range oc.inFlightTxs {
+		if it.stateManager.GetStage(ctx) == InFlightTxStageSubmitting {
+			submitting = true
+		}
+	}
+	return len(oc.inFlightTxs), submitting
+}
+
+// A stop that has been requested, but not yet processed by the orchestrator loop
+func (oc *orchestrator) stopPending() bool {
+	return len(oc.stopProcess) > 0
+}
+
 // Used in unit tests
 func (oc *orchestrator) getFirstInFlight() (ift *inFlightTransactionStageController) {
 	oc.inFlightTxsMux.Lock()
@@ -236,22 +367,122 @@ func (oc *orchestrator) initNextNonceFromDBRetry(ctx context.Context) error {
 }
 
 func (oc *orchestrator) initNextNonceFromDB(ctx context.Context) error {
+	nextNonce, err := oc.getNextNonceFromDB(ctx, oc.p.NOTX(), oc.signingAddress)
+	if err != nil || nextNonce == nil {
+		return err
+	}
+	oc.nextNonce = nextNonce
+	log.L(ctx).Infof("Next nonce initialized from DB fro %s: %d", oc.signingAddress, *nextNonce)
+	return nil
+}
+
+func (oc *orchestrator) reconcileColdStartRetry(ctx context.Context) error {
+	return oc.retry.Do(ctx, func(attempt int) (retryable bool, err error) {
+		return true, oc.reconcileColdStart(ctx)
+	})
+}
+
+// Our records might have been created before we were able to track the completion of the transactions
+// (such as on a fresh node), in which case the chain can have mined transactions we still see as pending.
+// So if no completion has been recorded for the address, we seed the completed nonce watermark from the
+// confirmed nonce on the chain. Either way, transactions at or below the watermark are not loaded to be
+// submitted, and are left for the block indexer to complete.
+// A watermark seeded from the chain is only held in memory, and is read from the chain again on the next
+// cold start. The public_txn_watermarks table only records the nonces of transactions we have purged.
+func (oc *orchestrator) reconcileColdStart(ctx context.Context) error {
+	watermark, err := oc.getCompletedNonceWatermark(ctx, oc.p.NOTX(), oc.signingAddress)
+	if err != nil {
+		return err
+	}
+	if watermark == nil {
+		txCount, err := oc.ethClient.GetTransactionCount(ctx, oc.signingAddress)
+		if err != nil {
+			return err
+		}
+		if txCount.Uint64() == 0 {
+			log.L(ctx).Infof("No transactions confirmed on chain for %s", oc.signingAddress)
+			return nil
+		}
+		minedNonce := txCount.Uint64() - 1
+		log.L(ctx).Infof("Completed nonce watermark for %s seeded from chain: %d", oc.signingAddress, minedNonce)
+		watermark = &minedNonce
+	}
+	oc.inFlightTxsMux.Lock()
+	defer oc.inFlightTxsMux.Unlock()
+	oc.coldStartNonceFloor = watermark
+	oc.advanceCompletedNonceWatermark(*watermark)
+	return nil
+}
+
+// Only reported for diagnostics, so it does not change which transactions are loaded.
+// Must be called holding inFlightTxsMux.
+func (oc *orchestrator) advanceCompletedNonceWatermark(minedNonce uint64) {
+	if oc.completedNonceWatermark == nil || minedNonce > *oc.completedNonceWatermark {
+		oc.completedNonceWatermark = &minedNonce
+	}
+}
+
+// Returns the next nonce after the highest we have assigned for the signing address, including transactions
+// that have been purged by the retention policy. Nil if we have never assigned a nonce.
+func (ble *pubTxManager) getNextNonceFromDB(ctx context.Context, dbTX persistence.DBTX, from tktypes.EthAddress) (*uint64, error) {
 	var txns []*DBPublicTxn
-	err := oc.p.DB().
+	err := dbTX.DB().
 		WithContext(ctx).
-		Where(`"from" = ?`, oc.signingAddress).
+		Where(`"from" = ?`, from).
 		Where("nonce IS NOT NULL").
 		Order("nonce DESC").
 		Limit(1).
 		Find(&txns).
 		Error
-	if err != nil || len(txns) == 0 {
-		return err
+	if err != nil {
+		return nil, err
+	}
+	if len(txns) == 0 {
+		// All the transactions might have been purged by the retention policy
+		purgedWatermark, err := ble.getPurgedNonceWatermark(ctx, dbTX, from)
+		if err != nil || purgedWatermark == nil {
+			return nil, err
+		}
+		nextNonce := *purgedWatermark + 1
+		return &nextNonce, nil
 	}
 	nextNonce := *txns[0].Nonce + 1
-	oc.nextNonce = &nextNonce
-	log.L(ctx).Infof("Next nonce initialized from DB fro %s: %d", oc.signingAddress, nextNonce)
-	return nil
+	return &nextNonce, nil
+}
+
+// Returns the next nonce that will be allocated, as long as it is still within the cache timeout
+// (after which the next allocation re-checks the chain)
+func (oc *orchestrator) getCachedNextNonce() *uint64 {
+	oc.inFlightTxsMux.Lock()
+	defer oc.inFlightTxsMux.Unlock()
+	if oc.nextNonce == nil || oc.clock.Since(oc.lastNonceAlloc) > oc.nonceCacheTimeout {
+		return nil
+	}
+	nextNonce := *oc.nextNonce
+	return &nextNonce
+}
+
+// Normally there is only one orchestrator assigning nonces for a signing address at a time, but that is not
+// guaranteed (for example while an orchestrator is being swapped out). So nonce assignment for each address is
+// serialized under a lock held by the manager, which also records the next nonce after the highest assigned.
+// This keeps a single nonce source per signing address, even if two orchestrators overlap.
+type signingAddressLock struct {
+	sync.Mutex
+	nextNonce *uint64
+}
+
+// Returns with the lock held for the signing address - the caller must unlock it
+func (ble *pubTxManager) lockSigningAddress(signingAddress tktypes.EthAddress) *signingAddressLock {
+	ble.signingAddressLocksMux.Lock()
+	sal := ble.signingAddressLocks[signingAddress]
+	if sal == nil {
+		sal = &signingAddressLock{}
+		ble.signingAddressLocks[signingAddress] = sal
+	}
+	ble.signingAddressLocksMux.Unlock()
+
+	sal.Lock()
+	return sal
 }
 
 func (oc *orchestrator) allocateNonces(ctx context.Context, txns []*DBPublicTxn) error {
@@ -263,13 +494,17 @@ func (oc *orchestrator) allocateNonces(ctx context.Context, txns []*DBPublicTxn)
 			toAlloc = append(toAlloc, tx)
 		}
 	}
+	oc.skipPreAssignedNonces(ctx, txns)
 	if len(toAlloc) == 0 {
 		// Nothing to do
 		return nil
 	}
 
+	sal := oc.lockSigningAddress(oc.signingAddress)
+	defer sal.Unlock()
+
 	// We need to ensure we have the next nonce to allocate
-	if oc.nextNonce == nil || time.Since(oc.lastNonceAlloc) > oc.nonceCacheTimeout {
+	if oc.nextNonce == nil || oc.clock.Since(oc.lastNonceAlloc) > oc.nonceCacheTimeout {
 		log.L(ctx).Debugf("no cached nonce, or nonce expired for %s (cached=%v)", oc.signingAddress, oc.lastNonceAlloc)
 		txCount, err := oc.ethClient.GetTransactionCount(ctx, oc.signingAddress)
 		if err != nil {
@@ -284,6 +519,13 @@ func (oc *orchestrator) allocateNonces(ctx context.Context, txns []*DBPublicTxn)
 			log.L(ctx).Infof("Next nonce for %s set to %d (from eth_getTransactionCount)", oc.signingAddress, *oc.nextNonce)
 		}
 	}
+	// Another orchestrator might have assigned nonces for this address since we cached ours
+	if sal.nextNonce != nil && *sal.nextNonce > *oc.nextNonce {
+		log.L(ctx).Infof("Next nonce for %s moved from %d to %d, after assignment by another orchestrator", oc.signingAddress, *oc.nextNonce, *sal.nextNonce)
+		nextNonce := *sal.nextNonce
+		oc.nextNonce = &nextNonce
+	}
+	oc.skipPreAssignedNonces(ctx, txns)
 
 	// Set up the list of nonces we'll allocated, but until it's in the DB we do NOT update the oc.nextNonce beyond the first in the list
 	newNextNonce := *oc.nextNonce
@@ -306,8 +548,9 @@ func (oc *orchestrator) allocateNonces(ctx context.Context, txns []*DBPublicTxn)
 			values = append(values, newNonces[i])
 			log.L(ctx).Debugf("assigning %s:%d (pubTxnId=%d)", oc.signingAddress, newNonces[i], tx.PublicTxnID)
 		}
-		sqlQuery += ` ) UPDATE "public_txns" SET "nonce" = nu."nonce" FROM ( SELECT "pub_txn_id", "nonce" FROM nonce_updates ) AS nu ` +
+		sqlQuery += ` ) UPDATE "public_txns" SET "nonce" = nu."nonce", "updated" = ? FROM ( SELECT "pub_txn_id", "nonce" FROM nonce_updates ) AS nu ` +
 			`WHERE "public_txns"."pub_txn_id" = nu."pub_txn_id";`
+		values = append(values, tktypes.TimestampNow())
 		return dbTX.DB().WithContext(ctx).Exec(sqlQuery, values...).Error
 	})
 	if err != nil {
@@ -319,14 +562,83 @@ func (oc *orchestrator) allocateNonces(ctx context.Context, txns []*DBPublicTxn)
 		nonce := newNonces[i]
 		tx.Nonce = &nonce
 	}
-	oc.lastNonceAlloc = time.Now()
+	oc.lastNonceAlloc = oc.clock.Now()
 	oc.nextNonce = &newNextNonce
+	salNextNonce := newNextNonce
+	sal.nextNonce = &salNextNonce
 
 	return nil
 }
 
+// Pre-signed transactions arrive with a nonce chosen by the submitter, so we move our next nonce
+// past any of those to avoid assigning the same nonce to another transaction
+func (oc *orchestrator) skipPreAssignedNonces(ctx context.Context, txns []*DBPublicTxn) {
+	if oc.nextNonce == nil {
+		return
+	}
+	for _, tx := range txns {
+		if tx.RawTransaction != nil && tx.Nonce != nil && *tx.Nonce >= *oc.nextNonce {
+			nextNonce := *tx.Nonce + 1
+			log.L(ctx).Infof("Next nonce for %s moved to %d past pre-signed transaction (pubTxnId=%d)", oc.signingAddress, nextNonce, tx.PublicTxnID)
+			oc.nextNonce = &nextNonce
+		}
+	}
+}
+
+// The transactions in a group must be assigned contiguous nonces. So when we poll a group member that does
+// not have a nonce yet, we load all the remaining members of that group (even if this takes us over our
+// in-flight limit) and order them together, at the position of the first member we polled.
+func (oc *orchestrator) loadTransactionGroups(ctx context.Context, polled []*DBPublicTxn) ([]*DBPublicTxn, error) {
+	groupIDs := make([]uuid.UUID, 0)
+	polledIDs := make([]uint64, len(polled))
+	members := make(map[uuid.UUID][]*DBPublicTxn)
+	for i, ptx := range polled {
+		polledIDs[i] = ptx.PublicTxnID
+		if ptx.GroupID != nil && ptx.Nonce == nil {
+			if _, found := members[*ptx.GroupID]; !found {
+				groupIDs = append(groupIDs, *ptx.GroupID)
+			}
+			members[*ptx.GroupID] = append(members[*ptx.GroupID], ptx)
+		}
+	}
+	if len(groupIDs) == 0 {
+		return polled, nil
+	}
+
+	q := oc.p.DB().
+		WithContext(ctx).
+		Table("public_txns").
+		Joins("Completed").
+		Where(`"Completed"."tx_hash" IS NULL`).
+		Where("suspended IS FALSE").
+		Where("nonce IS NULL").
+		Where(`"public_txns"."group_id" IN (?)`, groupIDs).
+		Where(`"public_txns"."pub_txn_id" NOT IN (?)`, polledIDs).
+		Order(`"public_txns"."pub_txn_id"`)
+	remainder, err := oc.runTransactionQuery(ctx, oc.p.NOTX(), false, nil, q)
+	if err != nil {
+		return nil, err
+	}
+	for _, ptx := range remainder {
+		members[*ptx.GroupID] = append(members[*ptx.GroupID], ptx)
+	}
+
+	ordered := make([]*DBPublicTxn, 0, len(polled)+len(remainder))
+	for _, ptx := range polled {
+		if ptx.GroupID == nil || ptx.Nonce != nil {
+			ordered = append(ordered, ptx)
+		} else if group := members[*ptx.GroupID]; group != nil {
+			sort.Slice(group, func(i, j int) bool { return group[i].PublicTxnID < group[j].PublicTxnID })
+			log.L(ctx).Debugf("Loaded %d transactions of group %s together", len(group), ptx.GroupID)
+			ordered = append(ordered, group...)
+			delete(members, *ptx.GroupID)
+		}
+	}
+	return ordered, nil
+}
+
 func (oc *orchestrator) pollAndProcess(ctx context.Context) (polled int, total int) {
-	pollStart := time.Now()
+	pollStart := oc.clock.Now()
 	oc.inFlightTxsMux.Lock()
 	defer oc.inFlightTxsMux.Unlock()
 	queueUpdated := false
@@ -341,18 +653,24 @@ func (oc *orchestrator) pollAndProcess(ctx context.Context) (polled int, total i
 	}
 
 	var highestInFlightNonce *uint64
+	inFlightNonces := make([]uint64, 0, len(oldInFlight))
 	// Run through copying across from the old InFlight list to the new one, those that aren't ready to be deleted
 	for _, p := range oldInFlight {
+		inFlightNonces = append(inFlightNonces, p.stateManager.GetNonce())
 		if highestInFlightNonce == nil || p.stateManager.GetNonce() > *highestInFlightNonce {
 			newHighest := p.stateManager.GetNonce()
 			highestInFlightNonce = &newHighest
 		}
 		if p.stateManager.CanBeRemoved(ctx) {
 			oc.totalCompleted = oc.totalCompleted + 1
+			if p.stateManager.GetInFlightStatus() == InFlightStatusConfirmReceived {
+				// a mined nonce means every nonce below it has been mined too
+				oc.advanceCompletedNonceWatermark(p.stateManager.GetNonce())
+			}
 			queueUpdated = true
-			log.L(ctx).Debugf("Orchestrator poll and process, marking %s as complete after: %s", p.stateManager.GetSignerNonce(), time.Since(p.stateManager.GetCreatedTime().Time()))
+			log.L(ctx).Debugf("Orchestrator poll and process, marking %s as complete after: %s", p.stateManager.GetSignerNonce(), oc.clock.Since(p.stateManager.GetCreatedTime().Time()))
 		} else {
-			log.L(ctx).Debugf("Orchestrator poll and process, continuing tx %s after: %s", p.stateManager.GetSignerNonce(), time.Since(p.stateManager.GetCreatedTime().Time()))
+			log.L(ctx).Debugf("Orchestrator poll and process, continuing tx %s after: %s", p.stateManager.GetSignerNonce(), oc.clock.Since(p.stateManager.GetCreatedTime().Time()))
 			oc.inFlightTxs = append(oc.inFlightTxs, p)
 			txStage := p.stateManager.GetStage(ctx)
 			if string(txStage) == "" {
@@ -385,7 +703,12 @@ func (oc *orchestrator) pollAndProcess(ctx context.Context) (polled int, total i
 				// We don't want to see any of the ones we already have in flight.
 				// The only way something leaves our in-flight list, is if we get a notification from the block indexer
 				// that it committed a DB transaction that removed it from our list.
-				q = q.Where("(nonce IS NULL OR nonce > ?)", highestInFlightNonce)
+				// We exclude the in-flight nonces individually, rather than everything below the highest, so that a
+				// transaction submitted with an explicit nonce to fill a gap is picked up.
+				q = q.Where("(nonce IS NULL OR nonce NOT IN ?)", inFlightNonces)
+			}
+			if oc.coldStartNonceFloor != nil {
+				q = q.Where("(nonce IS NULL OR nonce > ?)", *oc.coldStartNonceFloor)
 			}
 			// Note we do not use an explicit DB transaction to coordinate the read of the
 			// transactions table with the read of the submissions table,
@@ -393,6 +716,9 @@ func (oc *orchestrator) pollAndProcess(ctx context.Context) (polled int, total i
 			// inflight transactions we have in memory that would not be overwritten
 			// by this query.
 			additional, err = oc.runTransactionQuery(ctx, oc.p.NOTX(), false /* just the individual transactions - no duplication for bindings */, nil, q)
+			if err == nil {
+				additional, err = oc.loadTransactionGroups(ctx, additional)
+			}
 			return true, err
 		})
 		if err != nil {
@@ -430,40 +756,52 @@ func (oc *orchestrator) pollAndProcess(ctx context.Context) (polled int, total i
 		}
 		oc.thMetrics.RecordInFlightTxQueueMetrics(ctx, stageCounts, oc.maxInFlightTxs-len(oc.inFlightTxs))
 	}
-	log.L(ctx).Debugf("Orchestrator polling from DB took %s", time.Since(pollStart))
+	log.L(ctx).Debugf("Orchestrator polling from DB took %s", oc.clock.Since(pollStart))
 	// now check and process each transaction
 
 	if total > 0 {
 		waitingForBalance, _ := oc.ProcessInFlightTransactions(ctx, oc.inFlightTxs)
 		if queueUpdated {
-			oc.lastQueueUpdate = time.Now()
+			oc.lastQueueUpdate = oc.clock.Now()
 		}
-		if time.Since(oc.lastQueueUpdate) > oc.staleTimeout && oc.state != OrchestratorStateStale {
-			oc.state = OrchestratorStateStale
-			oc.stateEntryTime = time.Now()
+		lastProgress := oc.lastProgressTime()
+		oc.lastProgressNanos.Store(lastProgress.UnixNano())
+		if oc.clock.Since(lastProgress) > oc.staleTimeout && oc.state != OrchestratorStateStale {
+			oc.setState(OrchestratorStateStale)
 		} else if waitingForBalance && oc.state != OrchestratorStateWaiting {
-			oc.state = OrchestratorStateWaiting
-			oc.stateEntryTime = time.Now()
+			oc.setState(OrchestratorStateWaiting)
 		} else if oc.state != OrchestratorStateRunning {
-			oc.state = OrchestratorStateRunning
-			oc.stateEntryTime = time.Now()
+			oc.setState(OrchestratorStateRunning)
 		}
 	} else if oc.state != OrchestratorStateIdle {
-		oc.state = OrchestratorStateIdle
-		oc.stateEntryTime = time.Now()
+		oc.setState(OrchestratorStateIdle)
 	}
-	log.L(ctx).Debugf("Orchestrator process loop took %s", time.Since(pollStart))
+	log.L(ctx).Debugf("Orchestrator process loop took %s", oc.clock.Since(pollStart))
 
 	return polled, total
 }
 
+// The later of the last change to the in-flight queue, and the last heartbeat from any in-flight
+// transaction. A transaction in a long-running stage that is still making progress keeps the orchestrator
+// from being considered stale.
+func (oc *orchestrator) lastProgressTime() time.Time {
+	lastProgress := oc.lastQueueUpdate
+	for _, it := range oc.inFlightTxs {
+		if txProgress := it.stateManager.GetLastProgressTime(); txProgress.After(lastProgress) {
+			lastProgress = txProgress
+		}
+	}
+	return lastProgress
+}
+
 // this function should only have one running instance at any given time
 func (oc *orchestrator) ProcessInFlightTransactions(ctx context.Context, its []*inFlightTransactionStageController) (waitingForBalance bool, err error) {
-	processStart := time.Now()
+	processStart := oc.clock.Now()
 	waitingForBalance = false
 	var addressAccount *AddressAccount
-	skipBalanceCheck := oc.hasZeroGasPrice
-	now := time.Now()
+	// the first transaction that cannot be afforded is held before signing, until the balance catches up
+	skipBalanceCheck := oc.hasZeroGasPrice || !oc.balanceCheck
+	now := oc.clock.Now()
 	log.L(ctx).Debugf("%s ProcessInFlightTransaction entry for signing address %s", now.String(), oc.signingAddress)
 
 	if !skipBalanceCheck {
@@ -487,6 +825,7 @@ func (oc *orchestrator) ProcessInFlightTransactions(ctx context.Context, its []*
 	}
 
 	previousNonceCostUnknown := false
+	signerHealthCheck := oc.newSignerHealthCheck()
 	for i, it := range its {
 		log.L(ctx).Debugf("%s ProcessInFlightTransaction for signing address %s processing transaction with ID: %s, index: %d", now.String(), oc.signingAddress, it.stateManager.GetSignerNonce(), i)
 		var availableToSpend *big.Int
@@ -496,6 +835,7 @@ func (oc *orchestrator) ProcessInFlightTransactions(ctx context.Context, its []*
 		triggerNextStageOutput := it.ProduceLatestInFlightStageContext(ctx, &OrchestratorContext{
 			AvailableToSpend:         availableToSpend,
 			PreviousNonceCostUnknown: previousNonceCostUnknown,
+			SignerHealthCheck:        signerHealthCheck,
 		})
 		if !skipBalanceCheck {
 			if triggerNextStageOutput.Cost != nil {
@@ -518,10 +858,44 @@ func (oc *orchestrator) ProcessInFlightTransactions(ctx context.Context, its []*
 	}
 
 	log.L(ctx).Debugf("%s ProcessInFlightTransaction exit for signing address: %s", now.String(), oc.signingAddress)
-	log.L(ctx).Debugf("Orchestrator process loop took %s", time.Since(processStart))
+	log.L(ctx).Debugf("Orchestrator process loop took %s", oc.clock.Since(processStart))
 	return waitingForBalance, nil
 }
 
+// Returns a check of the health of the signer for this address, that is performed at most once per pass
+// over the in-flight transactions, and only if one of them is ready to be signed.
+// While the signer is unhealthy the check is repeated on each poll, and when it recovers we poll again
+// straight away so the transactions held back from signing move on promptly.
+func (oc *orchestrator) newSignerHealthCheck() func(ctx context.Context) error {
+	checked := false
+	var healthErr error
+	return func(ctx context.Context) error {
+		if !checked {
+			checked = true
+			healthErr = oc.checkSignerHealth(ctx)
+			if healthErr != nil && !oc.signerUnhealthy {
+				log.L(ctx).Warnf("Signer for %s is unhealthy: %s", oc.signingAddress, healthErr)
+			} else if healthErr == nil && oc.signerUnhealthy {
+				log.L(ctx).Infof("Signer for %s is healthy again", oc.signingAddress)
+				oc.MarkInFlightTxStale()
+			}
+			oc.signerUnhealthy = healthErr != nil
+			if isPermanentKeyError(healthErr) {
+				oc.handleSignerKeyLost(ctx, healthErr)
+			}
+		}
+		return healthErr
+	}
+}
+
+func (oc *orchestrator) checkSignerHealth(ctx context.Context) error {
+	resolvedKey, err := oc.keymgr.ReverseKeyLookup(ctx, oc.p.NOTX(), algorithms.ECDSA_SECP256K1, verifiers.ETH_ADDRESS, oc.signingAddress.String())
+	if err != nil {
+		return err
+	}
+	return oc.keymgr.SignerHealthCheck(ctx, resolvedKey)
+}
+
 func (oc *orchestrator) Start(ctx context.Context) (done <-chan struct{}, err error) {
 	oc.orchestratorLoopDone = make(chan struct{})
 	go oc.orchestratorLoop()
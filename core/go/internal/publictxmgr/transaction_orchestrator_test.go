@@ -17,6 +17,7 @@ package publictxmgr
 
 import (
 	"context"
+	"database/sql/driver"
 	"fmt"
 	"testing"
 	"time"
@@ -27,8 +28,10 @@ import (
 
 	"github.com/kaleido-io/paladin/core/mocks/componentmocks"
 	"github.com/kaleido-io/paladin/core/pkg/ethclient"
+	"github.com/kaleido-io/paladin/toolkit/pkg/algorithms"
 	"github.com/kaleido-io/paladin/toolkit/pkg/pldapi"
 	"github.com/kaleido-io/paladin/toolkit/pkg/tktypes"
+	"github.com/kaleido-io/paladin/toolkit/pkg/verifiers"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
@@ -124,6 +127,48 @@ func TestNewOrchestratorPollingContextCancelledWhileRetrying(t *testing.T) {
 
 }
 
+func TestNewOrchestratorPollingSkipsColdStartNonceFloor(t *testing.T) {
+
+	ctx, o, m, done := newTestOrchestrator(t, func(mocks *mocksAndTestControl, conf *pldconf.PublicTxManagerConfig) {
+		conf.Orchestrator.MaxInFlight = confutil.P(10)
+	})
+	defer done()
+
+	o.coldStartNonceFloor = confutil.P(uint64(2))
+	m.db.ExpectQuery(`SELECT.*public_txn.*nonce IS NULL OR nonce >`).
+		WillReturnRows(sqlmock.NewRows([]string{}))
+
+	polled, _ := o.pollAndProcess(ctx)
+	assert.Equal(t, 0, polled)
+	require.NoError(t, m.db.ExpectationsWereMet())
+
+}
+
+func TestNewOrchestratorPollingExcludesOnlyInFlightNonces(t *testing.T) {
+
+	ctx, o, m, done := newTestOrchestrator(t, func(mocks *mocksAndTestControl, conf *pldconf.PublicTxManagerConfig) {
+		conf.Orchestrator.MaxInFlight = confutil.P(10)
+	})
+	defer done()
+
+	// nonce 2 is a gap below the highest in-flight nonce, which a nonce override can fill
+	mockIT1, _ := newInflightTransaction(o, 1)
+	mockIT1.testOnlyNoActionMode = true
+	mockIT3, _ := newInflightTransaction(o, 3)
+	mockIT3.testOnlyNoActionMode = true
+	o.hasZeroGasPrice = true
+	o.inFlightTxs = []*inFlightTransactionStageController{mockIT1, mockIT3}
+	o.state = OrchestratorStateRunning
+
+	m.db.ExpectQuery(`SELECT.*public_txn.*nonce IS NULL OR nonce NOT IN \(.*,.*\)`).
+		WillReturnRows(sqlmock.NewRows([]string{}))
+
+	_, total := o.pollAndProcess(ctx)
+	assert.Equal(t, 2, total)
+	require.NoError(t, m.db.ExpectationsWereMet())
+
+}
+
 func TestNewOrchestratorPollingRemoveCompleted(t *testing.T) {
 
 	ctx, o, m, done := newTestOrchestrator(t, func(mocks *mocksAndTestControl, conf *pldconf.PublicTxManagerConfig) {
@@ -139,10 +184,11 @@ func TestNewOrchestratorPollingRemoveCompleted(t *testing.T) {
 	o.inFlightTxs = []*inFlightTransactionStageController{mockIT}
 	o.state = OrchestratorStateRunning
 
-	for i := 0; i < 2; i++ {
-		// return empty rows - once for max nonce calculation, and then again for the actual query
-		m.db.ExpectQuery("SELECT.*public_txn").WillReturnRows(sqlmock.NewRows([]string{}))
-	}
+	// return empty rows - for max nonce calculation (falling back to the purged nonce watermark),
+	// and then again for the actual query
+	m.db.ExpectQuery("SELECT.*public_txns").WillReturnRows(sqlmock.NewRows([]string{}))
+	m.db.ExpectQuery("SELECT.*public_txn_watermarks").WillReturnRows(sqlmock.NewRows([]string{}))
+	m.db.ExpectQuery("SELECT.*public_txns").WillReturnRows(sqlmock.NewRows([]string{}))
 
 	ocDone, _ := o.Start(ctx)
 
@@ -199,14 +245,16 @@ func TestOrchestratorTriggerTopUp(t *testing.T) {
 	o.inFlightTxs = []*inFlightTransactionStageController{mockIT}
 	o.state = OrchestratorStateRunning
 
-	for i := 0; i < 2; i++ {
-		// return empty rows - once for max nonce calculation, and then again for the actual query
-		m.db.ExpectQuery("SELECT.*public_txn").WillReturnRows(sqlmock.NewRows([]string{}))
-	}
+	// return empty rows - for max nonce calculation (falling back to the purged nonce watermark),
+	// and then again for the actual query
+	m.db.ExpectQuery("SELECT.*public_txns").WillReturnRows(sqlmock.NewRows([]string{}))
+	m.db.ExpectQuery("SELECT.*public_txn_watermarks").WillReturnRows(sqlmock.NewRows([]string{}))
+	m.db.ExpectQuery("SELECT.*public_txns").WillReturnRows(sqlmock.NewRows([]string{}))
 
 	// Then insert of the auto-fueling transaction
 	m.db.ExpectBegin()
 	m.db.ExpectQuery("INSERT.*public_txns").WillReturnRows(m.db.NewRows([]string{"pub_txn_id"}).AddRow(12345))
+	m.db.ExpectExec("INSERT.*public_fueling_sources").WillReturnResult(driver.ResultNoRows)
 	m.db.ExpectCommit()
 
 	// Mock the insufficient balance on the account that's submitting
@@ -236,3 +284,268 @@ func TestOrchestratorTriggerTopUp(t *testing.T) {
 	o.Stop()
 	<-oDone
 }
+
+func newPricedInflightTransaction(o *orchestrator, nonce uint64) *inFlightTransactionStageController {
+	it, txState := newInflightTransaction(o, nonce)
+	it.testOnlyNoActionMode = true
+	// gas of 2000 at a price of 10 gives a max cost of 20000
+	txState.InMemoryTxStateManager.(*inMemoryTxState).mtx.GasPricing = &pldapi.PublicTxGasPricing{
+		GasPrice: tktypes.Int64ToInt256(10),
+	}
+	return it
+}
+
+func mockHealthySigner(m *mocksAndTestControl, o *orchestrator) {
+	mockKeyManager := m.keyManager.(*componentmocks.KeyManager)
+	mapping := &pldapi.KeyMappingAndVerifier{KeyMappingWithPath: &pldapi.KeyMappingWithPath{KeyMapping: &pldapi.KeyMapping{Identifier: "key1"}}}
+	mockKeyManager.On("ReverseKeyLookup", mock.Anything, mock.Anything, algorithms.ECDSA_SECP256K1, verifiers.ETH_ADDRESS, o.signingAddress.String()).
+		Return(mapping, nil)
+	mockKeyManager.On("SignerHealthCheck", mock.Anything, mapping).Return(nil)
+}
+
+func TestOrchestratorBalanceCheckHoldsUnderfundedTx(t *testing.T) {
+	ctx, o, m, done := newTestOrchestrator(t)
+	defer done()
+	o.hasZeroGasPrice = false
+
+	it := newPricedInflightTransaction(o, 1)
+	m.ethClient.On("GetBalance", mock.Anything, o.signingAddress, "latest").Return(tktypes.Uint64ToUint256(19999), nil).Once()
+
+	waitingForBalance, err := o.ProcessInFlightTransactions(ctx, []*inFlightTransactionStageController{it})
+	require.NoError(t, err)
+	assert.True(t, waitingForBalance)
+	// held before signing
+	assert.Nil(t, it.stateManager.GetRunningStageContext(ctx))
+}
+
+func TestOrchestratorBalanceCheckSignsFundedTx(t *testing.T) {
+	ctx, o, m, done := newTestOrchestrator(t)
+	defer done()
+	o.hasZeroGasPrice = false
+
+	it := newPricedInflightTransaction(o, 1)
+	m.ethClient.On("GetBalance", mock.Anything, o.signingAddress, "latest").Return(tktypes.Uint64ToUint256(20000), nil).Once()
+	mockHealthySigner(m, o)
+
+	waitingForBalance, err := o.ProcessInFlightTransactions(ctx, []*inFlightTransactionStageController{it})
+	require.NoError(t, err)
+	assert.False(t, waitingForBalance)
+	require.NotNil(t, it.stateManager.GetRunningStageContext(ctx))
+	assert.Equal(t, InFlightTxStageSigning, it.stateManager.GetRunningStageContext(ctx).Stage)
+}
+
+func TestOrchestratorBalanceCheckDisabled(t *testing.T) {
+	ctx, o, m, done := newTestOrchestrator(t, func(mocks *mocksAndTestControl, conf *pldconf.PublicTxManagerConfig) {
+		conf.Orchestrator.BalanceCheck = confutil.P(false)
+	})
+	defer done()
+	o.hasZeroGasPrice = false
+
+	// no balance is retrieved, and the transaction goes straight to signing
+	it := newPricedInflightTransaction(o, 1)
+	mockHealthySigner(m, o)
+
+	waitingForBalance, err := o.ProcessInFlightTransactions(ctx, []*inFlightTransactionStageController{it})
+	require.NoError(t, err)
+	assert.False(t, waitingForBalance)
+	require.NotNil(t, it.stateManager.GetRunningStageContext(ctx))
+	assert.Equal(t, InFlightTxStageSigning, it.stateManager.GetRunningStageContext(ctx).Stage)
+	m.ethClient.AssertNotCalled(t, "GetBalance", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestOrchestratorNotStaleWhileInFlightTxHeartbeats(t *testing.T) {
+
+	ctx, o, _, done := newTestOrchestrator(t, func(mocks *mocksAndTestControl, conf *pldconf.PublicTxManagerConfig) {
+		conf.Orchestrator.MaxInFlight = confutil.P(1) // just one inflight - which we inject in, so no DB poll
+		conf.Orchestrator.StaleTimeout = confutil.P("1m")
+	})
+	defer done()
+
+	mockIT, txState := newInflightTransaction(o, 1)
+	mockIT.testOnlyNoActionMode = true
+	o.hasZeroGasPrice = true
+	o.inFlightTxs = []*inFlightTransactionStageController{mockIT}
+	o.state = OrchestratorStateRunning
+
+	// the queue has not changed for longer than the stale timeout, but the transaction is making progress
+	o.lastQueueUpdate = time.Now().Add(-1 * time.Hour)
+	txState.Heartbeat(ctx)
+	_, total := o.pollAndProcess(ctx)
+	assert.Equal(t, 1, total)
+	assert.Equal(t, OrchestratorStateRunning, o.state)
+
+	// once the heartbeats stop, the stale timeout applies
+	txState.lastProgressTime = time.Now().Add(-1 * time.Hour)
+	_, _ = o.pollAndProcess(ctx)
+	assert.Equal(t, OrchestratorStateStale, o.state)
+}
+
+func TestOrchestratorStaleWithFakeClock(t *testing.T) {
+
+	ctx, o, _, done := newTestOrchestrator(t, func(mocks *mocksAndTestControl, conf *pldconf.PublicTxManagerConfig) {
+		conf.Orchestrator.MaxInFlight = confutil.P(1) // just one inflight - which we inject in, so no DB poll
+		conf.Orchestrator.StaleTimeout = confutil.P("1m")
+	})
+	defer done()
+
+	fc := newFakeClock()
+	o.clock = fc
+
+	mockIT, txState := newInflightTransaction(o, 1)
+	mockIT.testOnlyNoActionMode = true
+	o.hasZeroGasPrice = true
+	o.inFlightTxs = []*inFlightTransactionStageController{mockIT}
+	o.state = OrchestratorStateRunning
+	o.lastQueueUpdate = fc.Now()
+	txState.lastProgressTime = fc.Now()
+
+	_, _ = o.pollAndProcess(ctx)
+	assert.Equal(t, OrchestratorStateRunning, o.state)
+
+	fc.Advance(2 * time.Minute)
+	_, _ = o.pollAndProcess(ctx)
+	assert.Equal(t, OrchestratorStateStale, o.state)
+	assert.Equal(t, fc.Now(), o.stateEntryTime)
+}
+
+func TestSkipPreAssignedNonces(t *testing.T) {
+	ctx, o, _, done := newTestOrchestrator(t)
+	defer done()
+
+	// nothing to do until we know our next nonce
+	o.skipPreAssignedNonces(ctx, []*DBPublicTxn{{Nonce: confutil.P(uint64(10)), RawTransaction: []byte("tx")}})
+	assert.Nil(t, o.nextNonce)
+
+	o.nextNonce = confutil.P(uint64(5))
+	o.skipPreAssignedNonces(ctx, []*DBPublicTxn{
+		{Nonce: confutil.P(uint64(3)), RawTransaction: []byte("tx1")}, // behind us
+		{Nonce: confutil.P(uint64(20))},                               // one of ours
+		{Nonce: confutil.P(uint64(7)), RawTransaction: []byte("tx2")}, // pre-signed ahead of us
+		{},
+	})
+	assert.Equal(t, uint64(8), *o.nextNonce)
+}
+
+func TestOrchestratorSignerHealthCheck(t *testing.T) {
+	ctx, o, m, done := newTestOrchestrator(t)
+	defer done()
+
+	mockKeyManager := m.keyManager.(*componentmocks.KeyManager)
+	mapping := &pldapi.KeyMappingAndVerifier{KeyMappingWithPath: &pldapi.KeyMappingWithPath{KeyMapping: &pldapi.KeyMapping{Identifier: "key1"}}}
+	mockKeyManager.On("ReverseKeyLookup", mock.Anything, mock.Anything, algorithms.ECDSA_SECP256K1, verifiers.ETH_ADDRESS, o.signingAddress.String()).
+		Return(nil, fmt.Errorf("lookup failed")).Once()
+	mockKeyManager.On("ReverseKeyLookup", mock.Anything, mock.Anything, algorithms.ECDSA_SECP256K1, verifiers.ETH_ADDRESS, o.signingAddress.String()).
+		Return(mapping, nil)
+	mockKeyManager.On("SignerHealthCheck", mock.Anything, mapping).Return(fmt.Errorf("unhealthy")).Once()
+	mockKeyManager.On("SignerHealthCheck", mock.Anything, mapping).Return(nil).Once()
+
+	// a failed lookup is unhealthy, and the check is only made once per pass
+	check := o.newSignerHealthCheck()
+	assert.Regexp(t, "lookup failed", check(ctx))
+	assert.Regexp(t, "lookup failed", check(ctx))
+	assert.True(t, o.signerUnhealthy)
+
+	check = o.newSignerHealthCheck()
+	assert.Regexp(t, "unhealthy", check(ctx))
+	assert.True(t, o.signerUnhealthy)
+	assert.Empty(t, o.InFlightTxsStale)
+
+	// recovery triggers another poll straight away
+	check = o.newSignerHealthCheck()
+	assert.NoError(t, check(ctx))
+	assert.False(t, o.signerUnhealthy)
+	assert.Len(t, o.InFlightTxsStale, 1)
+}
+
+func TestOrchestratorSnapshotNonceGaps(t *testing.T) {
+	_, o, _, done := newTestOrchestrator(t)
+	defer done()
+
+	seedInFlight := func(nonces ...uint64) {
+		o.inFlightTxs = make([]*inFlightTransactionStageController, len(nonces))
+		for i, nonce := range nonces {
+			o.inFlightTxs[i], _ = newInflightTransaction(o, nonce)
+		}
+	}
+
+	// nothing in flight
+	s := o.snapshot()
+	assert.Equal(t, o.signingAddress, s.SigningAddress)
+	assert.Nil(t, s.LowestInFlightNonce)
+	assert.Nil(t, s.HighestInFlightNonce)
+	assert.Nil(t, s.CompletedNonceWatermark)
+	assert.False(t, s.NonceGap)
+
+	// contiguous from the watermark
+	o.completedNonceWatermark = confutil.P(uint64(2))
+	seedInFlight(3, 4, 5)
+	s = o.snapshot()
+	assert.Equal(t, 3, s.InFlightCount)
+	assert.Equal(t, tktypes.HexUint64(3), *s.LowestInFlightNonce)
+	assert.Equal(t, tktypes.HexUint64(5), *s.HighestInFlightNonce)
+	assert.Equal(t, tktypes.HexUint64(2), *s.CompletedNonceWatermark)
+	assert.False(t, s.NonceGap)
+
+	// gap within the in-flight nonces, regardless of the order they are held in
+	seedInFlight(5, 3, 6)
+	s = o.snapshot()
+	assert.Equal(t, tktypes.HexUint64(3), *s.LowestInFlightNonce)
+	assert.Equal(t, tktypes.HexUint64(6), *s.HighestInFlightNonce)
+	assert.True(t, s.NonceGap)
+
+	// gap between the watermark and the lowest in-flight nonce
+	seedInFlight(4, 5)
+	s = o.snapshot()
+	assert.True(t, s.NonceGap)
+
+	// without a watermark only gaps within the in-flight nonces can be detected
+	o.completedNonceWatermark = nil
+	s = o.snapshot()
+	assert.Nil(t, s.CompletedNonceWatermark)
+	assert.False(t, s.NonceGap)
+}
+
+func TestOrchestratorAdvanceCompletedNonceWatermark(t *testing.T) {
+	_, o, _, done := newTestOrchestrator(t)
+	defer done()
+
+	o.advanceCompletedNonceWatermark(3)
+	assert.Equal(t, uint64(3), *o.completedNonceWatermark)
+	o.advanceCompletedNonceWatermark(5)
+	assert.Equal(t, uint64(5), *o.completedNonceWatermark)
+	// confirmations can be processed out of nonce order
+	o.advanceCompletedNonceWatermark(4)
+	assert.Equal(t, uint64(5), *o.completedNonceWatermark)
+}
+
+func TestGetEngineStatus(t *testing.T) {
+	ctx, o, _, done := newTestOrchestrator(t)
+	defer done()
+	ble := o.pubTxManager
+
+	o2 := NewOrchestrator(ble, *tktypes.RandAddress(), ble.conf)
+	o2.completedNonceWatermark = confutil.P(uint64(0))
+	it, _ := newInflightTransaction(o2, 2)
+	o2.inFlightTxs = []*inFlightTransactionStageController{it}
+
+	ble.inFlightOrchestratorMux.Lock()
+	ble.inFlightOrchestrators = map[tktypes.EthAddress]*orchestrator{
+		o.signingAddress:  o,
+		o2.signingAddress: o2,
+	}
+	ble.inFlightOrchestratorMux.Unlock()
+
+	status := ble.GetEngineStatus(ctx)
+	assert.Equal(t, ble.maxInflight, status.MaxInFlightOrchestrators)
+	require.Len(t, status.Orchestrators, 2)
+	assert.Less(t, status.Orchestrators[0].SigningAddress.String(), status.Orchestrators[1].SigningAddress.String())
+	for _, s := range status.Orchestrators {
+		if s.SigningAddress == o2.signingAddress {
+			assert.Equal(t, tktypes.HexUint64(2), *s.LowestInFlightNonce)
+			assert.True(t, s.NonceGap)
+		} else {
+			assert.Zero(t, s.InFlightCount)
+			assert.False(t, s.NonceGap)
+		}
+	}
+}
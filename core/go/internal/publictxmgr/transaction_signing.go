@@ -16,12 +16,19 @@
 package publictxmgr
 
 import (
+	"bytes"
 	"context"
+	"math/big"
 	"time"
 
 	"github.com/hyperledger/firefly-signer/pkg/ethsigner"
+	"github.com/hyperledger/firefly-signer/pkg/ethtypes"
 	"github.com/hyperledger/firefly-signer/pkg/secp256k1"
+	"github.com/kaleido-io/paladin/core/internal/components"
+	"github.com/kaleido-io/paladin/core/internal/msgs"
+	"github.com/kaleido-io/paladin/core/pkg/ethclient"
 	"github.com/kaleido-io/paladin/toolkit/pkg/algorithms"
+	"github.com/kaleido-io/paladin/toolkit/pkg/i18n"
 	"github.com/kaleido-io/paladin/toolkit/pkg/log"
 	"github.com/kaleido-io/paladin/toolkit/pkg/signpayloads"
 	"github.com/kaleido-io/paladin/toolkit/pkg/tktypes"
@@ -29,24 +36,119 @@ import (
 	"golang.org/x/crypto/sha3"
 )
 
-func (it *inFlightTransactionStageController) signTx(ctx context.Context, from tktypes.EthAddress, ethTx *ethsigner.Transaction) ([]byte, *tktypes.Bytes32, error) {
+// Returns the transaction that was signed, which might have had its gas limit changed by the pre-sign hook
+func (it *inFlightTransactionStageController) signTx(ctx context.Context, from tktypes.EthAddress, ethTx *ethsigner.Transaction) ([]byte, *tktypes.Bytes32, *ethsigner.Transaction, error) {
 	log.L(ctx).Debugf("signTx entry")
 	signStart := time.Now()
 
+	signTx, err := it.applyPreSignHook(ctx, from, ethTx)
+	var signedMessage []byte
+	var calculatedHash *tktypes.Bytes32
+	if err == nil {
+		signedMessage, calculatedHash, err = it.signEthTX(ctx, it.ethClient, from, signTx)
+	}
+	if err != nil {
+		it.thMetrics.RecordOperationMetrics(ctx, string(InFlightTxOperationSign), string(GenericStatusFail), time.Since(signStart).Seconds())
+		return nil, nil, nil, err
+	}
+	it.thMetrics.RecordOperationMetrics(ctx, string(InFlightTxOperationSign), string(GenericStatusSuccess), time.Since(signStart).Seconds())
+	return signedMessage, calculatedHash, signTx, err
+}
+
+func (ble *pubTxManager) SetPreSignHook(hook components.PublicTxPreSignHook) {
+	ble.preSignHook = hook
+}
+
+// The hook is given a copy of the transaction, which is only used if the protected fields are unchanged.
+// Only the gas limit can be changed - the access list would be the other candidate, but it is not part of
+// the transactions we sign. A change to the gas limit is persisted by the caller, so it is kept on resubmission.
+func (ble *pubTxManager) applyPreSignHook(ctx context.Context, from tktypes.EthAddress, ethTx *ethsigner.Transaction) (*ethsigner.Transaction, error) {
+	if ble.preSignHook == nil {
+		return ethTx, nil
+	}
+	hookTx := &ethsigner.Transaction{
+		From:                 bytes.Clone(ethTx.From),
+		Nonce:                cloneHexInteger(ethTx.Nonce),
+		GasPrice:             cloneHexInteger(ethTx.GasPrice),
+		MaxPriorityFeePerGas: cloneHexInteger(ethTx.MaxPriorityFeePerGas),
+		MaxFeePerGas:         cloneHexInteger(ethTx.MaxFeePerGas),
+		GasLimit:             cloneHexInteger(ethTx.GasLimit),
+		Value:                cloneHexInteger(ethTx.Value),
+		Data:                 bytes.Clone(ethTx.Data),
+	}
+	if ethTx.To != nil {
+		to := *ethTx.To
+		hookTx.To = &to
+	}
+	if err := ble.preSignHook(ctx, from, hookTx); err != nil {
+		return nil, i18n.WrapError(ctx, err, msgs.MsgPublicTxPreSignHookFailed, from, ethTx.Nonce.Uint64())
+	}
+
+	var protectedField string
+	switch {
+	case !bytes.Equal(hookTx.From, ethTx.From):
+		protectedField = "from"
+	case !hexIntegerEqual(hookTx.Nonce, ethTx.Nonce):
+		protectedField = "nonce"
+	case !hexIntegerEqual(hookTx.GasPrice, ethTx.GasPrice):
+		protectedField = "gasPrice"
+	case !hexIntegerEqual(hookTx.MaxPriorityFeePerGas, ethTx.MaxPriorityFeePerGas):
+		protectedField = "maxPriorityFeePerGas"
+	case !hexIntegerEqual(hookTx.MaxFeePerGas, ethTx.MaxFeePerGas):
+		protectedField = "maxFeePerGas"
+	case !hexIntegerEqual(hookTx.Value, ethTx.Value):
+		protectedField = "value"
+	case (hookTx.To == nil) != (ethTx.To == nil) || (hookTx.To != nil && *hookTx.To != *ethTx.To):
+		protectedField = "to"
+	case !bytes.Equal(hookTx.Data, ethTx.Data):
+		protectedField = "data"
+	case hookTx.GasLimit == nil:
+		protectedField = "gas"
+	}
+	if protectedField != "" {
+		return nil, i18n.NewError(ctx, msgs.MsgPublicTxPreSignHookProtected, protectedField, from, ethTx.Nonce.Uint64())
+	}
+	return hookTx, nil
+}
+
+func (pte *pubTxManager) UpdateGasLimit(ctx context.Context, imtx InMemoryTxStateReadOnly, gasLimit uint64) error {
+	log.L(ctx).Infof("Recording gas limit %d set by the pre-sign hook for transaction %s", gasLimit, imtx.GetSignerNonce())
+	return pte.p.DB().
+		WithContext(ctx).
+		Table("public_txns").
+		Where("pub_txn_id = ?", imtx.GetPubTxnID()).
+		UpdateColumns(map[string]any{"gas": gasLimit, "updated": tktypes.TimestampNow()}).
+		Error
+}
+
+func cloneHexInteger(v *ethtypes.HexInteger) *ethtypes.HexInteger {
+	if v == nil {
+		return nil
+	}
+	return (*ethtypes.HexInteger)(new(big.Int).Set(v.BigInt()))
+}
+
+func hexIntegerEqual(a, b *ethtypes.HexInteger) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.BigInt().Cmp(b.BigInt()) == 0
+}
+
+func (ble *pubTxManager) signEthTX(ctx context.Context, ethClient ethclient.EthClient, from tktypes.EthAddress, ethTx *ethsigner.Transaction) ([]byte, *tktypes.Bytes32, error) {
 	// Reverse resolve the key - to get to this point it will be in the key management system
-	resolvedKey, err := it.keymgr.ReverseKeyLookup(ctx, it.pubTxManager.p.NOTX(), algorithms.ECDSA_SECP256K1, verifiers.ETH_ADDRESS, from.String())
+	resolvedKey, err := ble.keymgr.ReverseKeyLookup(ctx, ble.p.NOTX(), algorithms.ECDSA_SECP256K1, verifiers.ETH_ADDRESS, from.String())
 	if err != nil {
 		log.L(ctx).Errorf("signing failed to resolve key %s for signing: %s", from.String(), err)
-		it.thMetrics.RecordOperationMetrics(ctx, string(InFlightTxOperationSign), string(GenericStatusFail), time.Since(signStart).Seconds())
 		return nil, nil, err
 	}
 	// Sign
-	sigPayload := ethTx.SignaturePayloadEIP1559(it.ethClient.ChainID())
+	sigPayload := ethTx.SignaturePayloadEIP1559(ethClient.ChainID())
 	sigPayloadHash := sha3.NewLegacyKeccak256()
 	_, err = sigPayloadHash.Write(sigPayload.Bytes())
 	var signatureRSV []byte
 	if err == nil {
-		signatureRSV, err = it.keymgr.Sign(ctx, resolvedKey, signpayloads.OPAQUE_TO_RSV, tktypes.HexBytes(sigPayloadHash.Sum(nil)))
+		signatureRSV, err = ble.keymgr.Sign(ctx, resolvedKey, signpayloads.OPAQUE_TO_RSV, tktypes.HexBytes(sigPayloadHash.Sum(nil)))
 	}
 	var sig *secp256k1.SignatureData
 	if err == nil {
@@ -58,11 +160,9 @@ func (it *inFlightTransactionStageController) signTx(ctx context.Context, from t
 	}
 	if err != nil {
 		log.L(ctx).Errorf("signing failed with keyHandle %s (addr=%s): %s", resolvedKey.KeyHandle, resolvedKey.Verifier.Verifier, err)
-		it.thMetrics.RecordOperationMetrics(ctx, string(InFlightTxOperationSign), string(GenericStatusFail), time.Since(signStart).Seconds())
 		return nil, nil, err
 	}
 	calculatedHash := calculateTransactionHash(signedMessage)
 	log.L(ctx).Debugf("Calculated Hash %s of transaction %s:%d", calculatedHash, ethTx.From, ethTx.Nonce.Uint64())
-	it.thMetrics.RecordOperationMetrics(ctx, string(InFlightTxOperationSign), string(GenericStatusSuccess), time.Since(signStart).Seconds())
-	return signedMessage, calculatedHash, err
+	return signedMessage, calculatedHash, nil
 }
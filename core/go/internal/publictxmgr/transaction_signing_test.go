@@ -16,11 +16,15 @@
 package publictxmgr
 
 import (
+	"context"
+	"database/sql/driver"
 	"fmt"
 	"testing"
 
 	"github.com/hyperledger/firefly-signer/pkg/ethsigner"
 	"github.com/hyperledger/firefly-signer/pkg/ethtypes"
+	"github.com/hyperledger/firefly-signer/pkg/secp256k1"
+	"github.com/kaleido-io/paladin/config/pkg/confutil"
 	"github.com/kaleido-io/paladin/core/mocks/componentmocks"
 	"github.com/kaleido-io/paladin/toolkit/pkg/algorithms"
 	"github.com/kaleido-io/paladin/toolkit/pkg/pldapi"
@@ -29,6 +33,7 @@ import (
 	"github.com/kaleido-io/paladin/toolkit/pkg/verifiers"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 func TestInFlightTxSignFail(t *testing.T) {
@@ -60,8 +65,149 @@ func TestInFlightTxSignFail(t *testing.T) {
 		Nonce: ethtypes.NewHexInteger64(12345),
 	}
 
-	_, txHash, err := it.signTx(ctx, fromAddr, ethTx)
+	_, txHash, _, err := it.signTx(ctx, fromAddr, ethTx)
 	assert.Regexp(t, "sign failed", err)
 	assert.Nil(t, txHash)
 
 }
+
+func newTestPreSignTx() *ethsigner.Transaction {
+	return &ethsigner.Transaction{
+		From:                 []byte(`"0x4f3b3d1b4c1e9c7d8f2a4a3b6c5d7e8f9a0b1c2d"`),
+		Nonce:                ethtypes.NewHexInteger64(10),
+		MaxPriorityFeePerGas: ethtypes.NewHexInteger64(100),
+		MaxFeePerGas:         ethtypes.NewHexInteger64(1000),
+		GasLimit:             ethtypes.NewHexInteger64(21000),
+		To:                   ethtypes.MustNewAddress("0x1f9090aaE28b8a3dCeaDf281B0F12828e676c326"),
+		Value:                ethtypes.NewHexInteger64(0),
+		Data:                 ethtypes.MustNewHexBytes0xPrefix("0xfeedbeef"),
+	}
+}
+
+func TestPreSignHookAllowedMutations(t *testing.T) {
+	ctx, o, _, done := newTestOrchestrator(t)
+	defer done()
+
+	fromAddr := *tktypes.RandAddress()
+	o.SetPreSignHook(func(ctx context.Context, from tktypes.EthAddress, ethTx *ethsigner.Transaction) error {
+		assert.Equal(t, fromAddr, from)
+		ethTx.GasLimit.BigInt().SetInt64(50000) // in-place changes to the copy are safe
+		return nil
+	})
+
+	ethTx := newTestPreSignTx()
+	signTx, err := o.applyPreSignHook(ctx, fromAddr, ethTx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(50000), signTx.GasLimit.Int64())
+	assert.Equal(t, ethTx.Nonce.Int64(), signTx.Nonce.Int64())
+	assert.Equal(t, ethTx.To, signTx.To)
+
+	// The original is not modified
+	assert.Equal(t, int64(21000), ethTx.GasLimit.Int64())
+}
+
+func TestPreSignHookNotSet(t *testing.T) {
+	ctx, o, _, done := newTestOrchestrator(t)
+	defer done()
+
+	ethTx := newTestPreSignTx()
+	signTx, err := o.applyPreSignHook(ctx, *tktypes.RandAddress(), ethTx)
+	require.NoError(t, err)
+	assert.Same(t, ethTx, signTx)
+}
+
+func TestPreSignHookProtectedFields(t *testing.T) {
+	ctx, o, _, done := newTestOrchestrator(t)
+	defer done()
+
+	mutations := map[string]func(ethTx *ethsigner.Transaction){
+		"from": func(ethTx *ethsigner.Transaction) {
+			ethTx.From = []byte(`"0x0000000000000000000000000000000000000001"`)
+		},
+		"nonce":                func(ethTx *ethsigner.Transaction) { ethTx.Nonce.BigInt().SetInt64(11) },
+		"gasPrice":             func(ethTx *ethsigner.Transaction) { ethTx.GasPrice = ethtypes.NewHexInteger64(1) },
+		"maxPriorityFeePerGas": func(ethTx *ethsigner.Transaction) { ethTx.MaxPriorityFeePerGas = nil },
+		"maxFeePerGas":         func(ethTx *ethsigner.Transaction) { ethTx.MaxFeePerGas.BigInt().SetInt64(1) },
+		"value":                func(ethTx *ethsigner.Transaction) { ethTx.Value = ethtypes.NewHexInteger64(1) },
+		"to":                   func(ethTx *ethsigner.Transaction) { ethTx.To = nil },
+		"data":                 func(ethTx *ethsigner.Transaction) { ethTx.Data = append(ethTx.Data, 0x01) },
+		"gas":                  func(ethTx *ethsigner.Transaction) { ethTx.GasLimit = nil },
+	}
+	for field, mutate := range mutations {
+		o.SetPreSignHook(func(ctx context.Context, from tktypes.EthAddress, ethTx *ethsigner.Transaction) error {
+			mutate(ethTx)
+			return nil
+		})
+		_, err := o.applyPreSignHook(ctx, *tktypes.RandAddress(), newTestPreSignTx())
+		assert.Regexp(t, "PD011955.*'"+field+"'", err)
+	}
+}
+
+func TestPreSignHookFailsSigning(t *testing.T) {
+	ctx, o, _, done := newTestOrchestrator(t)
+	defer done()
+	it, _ := newInflightTransaction(o, 1)
+
+	o.SetPreSignHook(func(ctx context.Context, from tktypes.EthAddress, ethTx *ethsigner.Transaction) error {
+		return fmt.Errorf("pop")
+	})
+
+	_, txHash, _, err := it.signTx(ctx, *tktypes.RandAddress(), newTestPreSignTx())
+	assert.Regexp(t, "PD011954.*pop", err)
+	assert.Nil(t, txHash)
+}
+
+func TestPreSignHookGasLimitSignedAndPersisted(t *testing.T) {
+	ctx, o, m, done := newTestOrchestrator(t)
+	defer done()
+	it, mTS := newInflightTransaction(o, 1, func(tx *DBPublicTxn) {
+		tx.Gas = 21000
+	})
+	mTS.ApplyInMemoryUpdates(ctx, &BaseTXUpdates{
+		GasPricing: &pldapi.PublicTxGasPricing{GasPrice: tktypes.Uint64ToUint256(10)},
+	})
+
+	kp, err := secp256k1.GenerateSecp256k1KeyPair()
+	require.NoError(t, err)
+	fromAddr := tktypes.EthAddress(kp.Address)
+	keyMapping := &pldapi.KeyMappingAndVerifier{
+		KeyMappingWithPath: &pldapi.KeyMappingWithPath{KeyMapping: &pldapi.KeyMapping{Identifier: "any.key"}},
+		Verifier:           &pldapi.KeyVerifier{Verifier: fromAddr.String()},
+	}
+	m.ethClient.On("ChainID").Return(int64(1122334455))
+	mockKeyManager := m.keyManager.(*componentmocks.KeyManager)
+	mockKeyManager.On("ReverseKeyLookup", mock.Anything, mock.Anything, algorithms.ECDSA_SECP256K1, verifiers.ETH_ADDRESS, fromAddr.String()).
+		Return(keyMapping, nil)
+	mockKeyManager.On("Sign", mock.Anything, keyMapping, signpayloads.OPAQUE_TO_RSV, mock.Anything).
+		Return(func(ctx context.Context, mapping *pldapi.KeyMappingAndVerifier, payloadType string, payload []byte) ([]byte, error) {
+			sig, err := kp.SignDirect(payload)
+			if err != nil {
+				return nil, err
+			}
+			return sig.CompactRSV(), nil
+		})
+
+	o.SetPreSignHook(func(ctx context.Context, from tktypes.EthAddress, ethTx *ethsigner.Transaction) error {
+		ethTx.GasLimit = ethtypes.NewHexInteger64(50000)
+		return nil
+	})
+
+	// the transaction that is signed has the gas limit from the hook
+	_, txHash, signedTx, err := it.signTx(ctx, fromAddr, it.stateManager.BuildEthTX())
+	require.NoError(t, err)
+	assert.NotNil(t, txHash)
+	assert.Equal(t, int64(50000), signedTx.GasLimit.Int64())
+
+	// and it is persisted, so a resubmission uses the same gas limit
+	mTS.runningStageContext = NewRunningStageContext(ctx, InFlightTxStageSigning, BaseTxSubStatusReceived, mTS.InMemoryTxStateManager)
+	rsc := it.stateManager.GetRunningStageContext(ctx)
+	rsc.StageOutputsToBePersisted = &RunningStageContextPersistenceOutput{
+		TxUpdates: &BaseTXUpdates{GasLimit: confutil.P(uint64(50000))},
+	}
+	m.db.ExpectExec("UPDATE.*public_txns.*gas").WillReturnResult(driver.ResultNoRows)
+	_, _, err = it.stateManager.PersistTxState(ctx)
+	require.NoError(t, err)
+	require.NoError(t, m.db.ExpectationsWereMet())
+	assert.Equal(t, uint64(50000), it.stateManager.GetGasLimit())
+	assert.Equal(t, int64(50000), it.stateManager.BuildEthTX().GasLimit.Int64())
+}
@@ -48,12 +48,13 @@ func (it *inFlightTransactionStageController) submitTX(ctx context.Context, mtx
 	}
 	log.L(ctx).Debugf("Sending raw transaction %s (lastSubmit=%s), Hash=%s", mtx.GetSignerNonce(), mtx.GetLastSubmitTime(), txHash)
 
-	submissionTime := confutil.P(tktypes.TimestampNow())
+	submissionTime := confutil.P(tktypes.Timestamp(it.clock.Now().UnixNano()))
 	var submissionErrorReason ethclient.ErrorReason // TODO: fix reason parsing
 	var submissionOutcome SubmissionOutcome
 	var submissionError error
 
 	retryError := it.transactionSubmissionRetry.Do(ctx, func(attempt int) ( /*retry*/ bool, error) {
+		it.stateManager.Heartbeat(ctx)
 		txHash, submissionError = it.ethClient.SendRawTransaction(ctx, tktypes.HexBytes(signedMessage))
 		if submissionError == nil {
 			submissionOutcome = SubmissionOutcomeFailedRequiresRetry
@@ -31,6 +31,23 @@ import (
 	"github.com/hyperledger/firefly-common/pkg/fftypes"
 )
 
+// Wrapper of the time functions used by the engine and orchestrators, primarily so that time-dependent
+// behavior (stale/idle timeouts, pause expiry, swap-out) can be tested deterministically with a fake clock
+type Clock interface {
+	Now() time.Time
+	Since(t time.Time) time.Duration
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) Since(t time.Time) time.Duration {
+	return time.Since(t)
+}
+
 // TXUpdates specifies a set of updates that are possible on the base structure.
 //
 // Any non-nil fields will be set.
@@ -44,14 +61,15 @@ import (
 // There are separate setter functions for fields that depending on the persistence
 // mechanism might be in separate tables - including History, Receipt, and Confirmations
 type BaseTXUpdates struct {
-	InFlightStatus *InFlightStatus
-	SubStatus      *BaseTxSubStatus
-	GasPricing     *pldapi.PublicTxGasPricing
-	// GasLimit          *tktypes.HexUint64 // note this is required for some methods (eth_estimateGas)
+	InFlightStatus    *InFlightStatus
+	SubStatus         *BaseTxSubStatus
+	GasPricing        *pldapi.PublicTxGasPricing
+	GasLimit          *uint64 // only changed by the pre-sign hook
 	TransactionHash   *tktypes.Bytes32
 	FirstSubmit       *tktypes.Timestamp
 	LastSubmit        *tktypes.Timestamp
 	ErrorMessage      *string
+	FailureCategory   *pldapi.PublicTxFailureCategory
 	NewSubmission     *DBPubTxnSubmission
 	FlushedSubmission *DBPubTxnSubmission
 }
@@ -262,6 +280,7 @@ type InMemoryTxStateReadOnly interface {
 	GetFrom() tktypes.EthAddress
 	GetTo() *tktypes.EthAddress
 	GetValue() *tktypes.HexUint256
+	GetRawTransaction() tktypes.HexBytes // set only for transactions that were submitted pre-signed
 	BuildEthTX() *ethsigner.Transaction
 	GetGasPriceObject() *pldapi.PublicTxGasPricing
 	GetFirstSubmit() *tktypes.Timestamp
@@ -305,6 +324,7 @@ type SubmitOutputs struct {
 type SignOutputs struct {
 	SignedMessage []byte
 	TxHash        *tktypes.Bytes32
+	GasLimit      *uint64 // set if the pre-sign hook changed the gas limit
 	Err           error
 }
 
@@ -364,6 +384,8 @@ func (ctx *RunningStageContext) SetNewPersistenceUpdateOutput() {
 
 type StatusUpdater interface {
 	UpdateSubStatus(ctx context.Context, imtx InMemoryTxStateReadOnly, subStatus BaseTxSubStatus, action BaseTxAction, info *fftypes.JSONAny, err *fftypes.JSONAny, actionOccurred *tktypes.Timestamp) error
+	UpdateFailureCategory(ctx context.Context, imtx InMemoryTxStateReadOnly, category pldapi.PublicTxFailureCategory) error
+	UpdateGasLimit(ctx context.Context, imtx InMemoryTxStateReadOnly, gasLimit uint64) error
 }
 
 type RunningStageContextPersistenceOutput struct {
@@ -385,6 +407,7 @@ type OrchestratorContext struct {
 	// input from transaction engine
 	AvailableToSpend         *big.Int
 	PreviousNonceCostUnknown bool
+	SignerHealthCheck        func(ctx context.Context) error // nil if the signer health is not checked
 }
 
 // output of some stages doesn't get written into the database
@@ -411,13 +434,15 @@ type InFlightTransactionStateManager interface {
 	GetStageStartTime(ctx context.Context) time.Time
 	SetValidatedTransactionHashMatchState(ctx context.Context, validatedTransactionHashMatchState bool)
 	ValidatedTransactionHashMatchState(ctx context.Context) bool
+	Heartbeat(ctx context.Context) // called by stage actions that are making progress, but have not produced an output yet
+	GetLastProgressTime() time.Time
 
 	// stage outputs management
 	AddStageOutputs(ctx context.Context, stageOutput *StageOutput)
 	ProcessStageOutputs(ctx context.Context, processFunction func(stageOutputs []*StageOutput) (unprocessedStageOutputs []*StageOutput))
 	AddPersistenceOutput(ctx context.Context, stage InFlightTxStage, persistenceTime time.Time, err error)
 	AddSubmitOutput(ctx context.Context, txHash *tktypes.Bytes32, submissionTime *tktypes.Timestamp, submissionOutcome SubmissionOutcome, errorReason ethclient.ErrorReason, err error)
-	AddSignOutput(ctx context.Context, signedMessage []byte, txHash *tktypes.Bytes32, err error)
+	AddSignOutput(ctx context.Context, signedMessage []byte, txHash *tktypes.Bytes32, gasLimit *uint64, err error)
 	AddGasPriceOutput(ctx context.Context, gasPriceObject *pldapi.PublicTxGasPricing, err error)
 	AddPanicOutput(ctx context.Context, stage InFlightTxStage)
 
@@ -20,6 +20,9 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"slices"
+	"strconv"
+	"strings"
 	"sync"
 
 	"github.com/google/uuid"
@@ -307,6 +310,76 @@ func (dc *domainContext) FindAvailableStates(dbTX persistence.DBTX, schemaID tkt
 	return schema, states, err
 }
 
+func (dc *domainContext) FindAvailableStatesPage(dbTX persistence.DBTX, schemaID tktypes.Bytes32, q *query.QueryJSON, cursor string) (components.Schema, []*pldapi.State, string, error) {
+	pageQuery, err := buildPageQuery(dc, q, cursor)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	schema, states, err := dc.FindAvailableStates(dbTX, schemaID, pageQuery)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	// A full page means there might be more states. We do not know for sure, so the caller might get
+	// an empty final page - but this avoids the cost of querying one more state than requested.
+	var next string
+	if len(states) > 0 && len(states) >= *q.Limit {
+		last := states[len(states)-1]
+		next = fmt.Sprintf("%d:%s", last.Created, last.ID.HexString())
+	}
+	return schema, states, next, nil
+}
+
+// The page is ordered by creation time, with the ID breaking ties, so the cursor is the last state returned.
+// As the filter for the cursor is an OR condition, we need to AND it with the supplied statements (which
+// might themselves contain an OR) - so we build two copies of the supplied statements, each with
+// one side of the cursor condition added.
+func buildPageQuery(ctx context.Context, q *query.QueryJSON, cursor string) (*query.QueryJSON, error) {
+	if q.Limit == nil || *q.Limit <= 0 || len(q.Sort) > 0 {
+		return nil, i18n.NewError(ctx, msgs.MsgStatePageQueryInvalid)
+	}
+	pageQuery := &query.QueryJSON{
+		Statements: q.Statements,
+		Limit:      q.Limit,
+		Sort:       []string{".created", ".id"},
+	}
+	if cursor == "" {
+		return pageQuery, nil
+	}
+	createdStr, idStr, ok := strings.Cut(cursor, ":")
+	created, err := strconv.ParseInt(createdStr, 10, 64)
+	if err == nil {
+		_, err = tktypes.ParseHexBytes(ctx, idStr)
+	}
+	if !ok || err != nil {
+		return nil, i18n.NewError(ctx, msgs.MsgStatePageCursorInvalid, cursor)
+	}
+	createdAfter := copyStatements(&q.Statements)
+	createdAfter.GreaterThan = append(createdAfter.GreaterThan, &query.OpSingleVal{
+		Op:    query.Op{Field: ".created"},
+		Value: tktypes.JSONString(created),
+	})
+	sameCreatedIDAfter := copyStatements(&q.Statements)
+	sameCreatedIDAfter.Equal = append(sameCreatedIDAfter.Equal, &query.OpSingleVal{
+		Op:    query.Op{Field: ".created"},
+		Value: tktypes.JSONString(created),
+	})
+	sameCreatedIDAfter.GreaterThan = append(sameCreatedIDAfter.GreaterThan, &query.OpSingleVal{
+		Op:    query.Op{Field: ".id"},
+		Value: tktypes.JSONString(idStr),
+	})
+	pageQuery.Statements = query.Statements{
+		Or: []*query.Statements{createdAfter, sameCreatedIDAfter},
+	}
+	return pageQuery, nil
+}
+
+func copyStatements(s *query.Statements) *query.Statements {
+	c := *s
+	c.Equal = slices.Clone(s.Equal)
+	c.GreaterThan = slices.Clone(s.GreaterThan)
+	return &c
+}
+
 func (dc *domainContext) FindAvailableNullifiers(dbTX persistence.DBTX, schemaID tktypes.Bytes32, query *query.QueryJSON) (components.Schema, []*pldapi.State, error) {
 
 	// Build a list of unflushed and spending nullifiers
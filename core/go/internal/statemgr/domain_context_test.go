@@ -1348,3 +1348,124 @@ func TestGetStatesByIDFail(t *testing.T) {
 	_, _, err := dc.GetStatesByID(dc.ss.p.NOTX(), tktypes.Bytes32(tktypes.RandBytes(32)), []string{tktypes.RandHex(32)})
 	assert.Regexp(t, "pop", err)
 }
+
+func TestFindAvailableStatesPage(t *testing.T) {
+
+	ctx, ss, _, done := newDBTestStateManager(t)
+	defer done()
+
+	schemas, err := ss.EnsureABISchemas(ctx, ss.p.NOTX(), "domain1", []*abi.Parameter{testABIParam(t, fakeCoinABI)})
+	require.NoError(t, err)
+	schemaID := schemas[0].ID()
+
+	_, dc := newTestDomainContext(t, ctx, ss, "domain1", false)
+	defer dc.Close()
+
+	owner1 := tktypes.RandAddress()
+	owner2 := tktypes.RandAddress()
+	txID := uuid.New()
+	upsertCoins := func(count int) []*pldapi.State {
+		upserts := make([]*components.StateUpsert, 0, count*2)
+		for i := 0; i < count; i++ {
+			for _, owner := range []*tktypes.EthAddress{owner1, owner2} {
+				upserts = append(upserts, &components.StateUpsert{
+					Schema:    schemaID,
+					Data:      tktypes.RawJSON(fmt.Sprintf(`{"amount": %d, "owner": "%s", "salt": "%s"}`, i%10, owner, tktypes.RandHex(32))),
+					CreatedBy: &txID,
+				})
+			}
+		}
+		states, err := dc.UpsertStates(ss.p.NOTX(), upserts...)
+		require.NoError(t, err)
+		return states
+	}
+
+	// A large set of states, with half flushed to the DB and half still in memory
+	states := upsertCoins(50)
+	syncFlushContext(t, dc)
+	states = append(states, upsertCoins(50)...)
+
+	expectedOwner1 := map[string]bool{}
+	expectedAll := map[string]bool{}
+	for _, s := range states {
+		var coin struct {
+			Owner  tktypes.EthAddress  `json:"owner"`
+			Amount *tktypes.HexUint256 `json:"amount"`
+		}
+		err := json.Unmarshal(s.Data, &coin)
+		require.NoError(t, err)
+		if coin.Amount.Int().Int64() >= 5 {
+			expectedAll[s.ID.String()] = true
+			if coin.Owner == *owner1 {
+				expectedOwner1[s.ID.String()] = true
+			}
+		}
+	}
+	require.Len(t, expectedOwner1, 50)
+	require.Len(t, expectedAll, 100)
+
+	pageAll := func(qb query.QueryBuilder, limit int) (found map[string]bool, pages int) {
+		found = map[string]bool{}
+		cursor := ""
+		for {
+			_, page, next, err := dc.FindAvailableStatesPage(ss.p.NOTX(), schemaID,
+				qb.GreaterThanOrEqual("amount", 5).Limit(limit).Query(), cursor)
+			require.NoError(t, err)
+			assert.LessOrEqual(t, len(page), limit)
+			for i, s := range page {
+				assert.False(t, found[s.ID.String()], "duplicate state %s", s.ID)
+				found[s.ID.String()] = true
+				if i > 0 {
+					assert.GreaterOrEqual(t, s.Created, page[i-1].Created)
+				}
+			}
+			pages++
+			if next == "" {
+				return found, pages
+			}
+			cursor = next
+		}
+	}
+
+	// Page through the states of one owner, with a value filter
+	found, pages := pageAll(query.NewQueryBuilder().Equal("owner", owner1.String()), 7)
+	assert.Equal(t, expectedOwner1, found)
+	assert.Equal(t, 8, pages)
+
+	// Queries containing an OR are combined correctly with the cursor
+	found, pages = pageAll(query.NewQueryBuilder().Or(
+		query.NewQueryBuilder().Equal("owner", owner1.String()),
+		query.NewQueryBuilder().Equal("owner", owner2.String()),
+	), 30)
+	assert.Equal(t, expectedAll, found)
+	assert.Equal(t, 4, pages)
+
+	// An exact multiple of the limit results in an empty final page
+	found, pages = pageAll(query.NewQueryBuilder().Equal("owner", owner1.String()), 25)
+	assert.Equal(t, expectedOwner1, found)
+	assert.Equal(t, 3, pages)
+}
+
+func TestFindAvailableStatesPageBadQuery(t *testing.T) {
+
+	ctx, ss, _, done := newDBTestStateManager(t)
+	defer done()
+
+	_, dc := newTestDomainContext(t, ctx, ss, "domain1", false)
+	defer dc.Close()
+
+	schemaID := tktypes.RandBytes32()
+	_, _, _, err := dc.FindAvailableStatesPage(ss.p.NOTX(), schemaID, query.NewQueryBuilder().Query(), "")
+	assert.Regexp(t, "PD010134", err)
+
+	_, _, _, err = dc.FindAvailableStatesPage(ss.p.NOTX(), schemaID, query.NewQueryBuilder().Limit(1).Sort("amount").Query(), "")
+	assert.Regexp(t, "PD010134", err)
+
+	for _, cursor := range []string{"wrong", "1:wrong", "wrong:00"} {
+		_, _, _, err = dc.FindAvailableStatesPage(ss.p.NOTX(), schemaID, query.NewQueryBuilder().Limit(1).Query(), cursor)
+		assert.Regexp(t, "PD010135", err)
+	}
+
+	_, _, _, err = dc.FindAvailableStatesPage(ss.p.NOTX(), schemaID, query.NewQueryBuilder().Limit(1).Query(), "")
+	assert.Regexp(t, "PD010106", err)
+}
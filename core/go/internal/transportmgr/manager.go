@@ -69,6 +69,9 @@ type transportManager struct {
 
 	reliableMsgWriter flushwriter.Writer[*reliableMsgOp, *noResult]
 
+	replyWaitersLock sync.Mutex
+	replyWaiters     map[uuid.UUID]*replyWaiter
+
 	sendShortRetry        *retry.Retry
 	reliableScanRetry     *retry.Retry
 	peerInactivityTimeout time.Duration
@@ -80,12 +83,19 @@ type transportManager struct {
 	reliableMessagePageSize int
 }
 
+// A SendAndWait caller, waiting for a reply from the node it sent the request to
+type replyWaiter struct {
+	node      string
+	replyChan chan *components.ReceivedMessage
+}
+
 var reliableMessageFilters = filters.FieldMap{
 	"sequence":    filters.Int64Field("sequence"),
 	"id":          filters.UUIDField("id"),
 	"created":     filters.TimestampField("created"),
 	"node":        filters.StringField("node"),
 	"messageType": filters.StringField("msg_type"),
+	"metadata":    filters.StringField("metadata"),
 }
 
 var reliableMessageAckFilters = filters.FieldMap{
@@ -101,6 +111,7 @@ func NewTransportManager(bgCtx context.Context, conf *pldconf.TransportManagerCo
 		transportsByID:          make(map[uuid.UUID]*transport),
 		transportsByName:        make(map[string]*transport),
 		peers:                   make(map[string]*peer),
+		replyWaiters:            make(map[uuid.UUID]*replyWaiter),
 		senderBufferLen:         confutil.IntMin(conf.SendQueueLen, 0, *pldconf.TransportManagerDefaults.SendQueueLen),
 		reliableMessageResend:   confutil.DurationMin(conf.ReliableMessageResend, 100*time.Millisecond, *pldconf.TransportManagerDefaults.ReliableMessageResend),
 		sendShortRetry:          retry.NewRetryLimited(&conf.SendRetry, &pldconf.TransportManagerDefaults.SendRetry),
@@ -280,6 +291,65 @@ func (tm *transportManager) Send(ctx context.Context, send *components.FireAndFo
 	return tm.queueFireAndForget(ctx, send.Node, msg)
 }
 
+// See docs in components package
+func (tm *transportManager) SendAndWait(ctx context.Context, send *components.FireAndForgetMessageSend, timeout time.Duration) (*components.ReceivedMessage, error) {
+
+	if send.MessageID == nil {
+		msgID := uuid.New()
+		send.MessageID = &msgID
+	}
+
+	// Register before sending, as the reply could beat us back
+	replyChan := make(chan *components.ReceivedMessage, 1)
+	tm.replyWaitersLock.Lock()
+	tm.replyWaiters[*send.MessageID] = &replyWaiter{node: send.Node, replyChan: replyChan}
+	tm.replyWaitersLock.Unlock()
+	defer func() {
+		tm.replyWaitersLock.Lock()
+		delete(tm.replyWaiters, *send.MessageID)
+		tm.replyWaitersLock.Unlock()
+	}()
+
+	if err := tm.Send(ctx, send); err != nil {
+		return nil, err
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case reply := <-replyChan:
+		log.L(ctx).Debugf("received %s reply %s from %s to %s message %s", reply.MessageType, reply.MessageID, reply.FromNode, send.MessageType, send.MessageID)
+		return reply, nil
+	case <-timer.C:
+		return nil, i18n.NewError(ctx, msgs.MsgTransportReplyTimeout, timeout, send.Node, send.MessageType, send.MessageID)
+	case <-ctx.Done():
+		return nil, i18n.NewError(ctx, msgs.MsgContextCanceled)
+	}
+}
+
+// If the message is a reply that someone is waiting on in SendAndWait, hand it to them
+func (tm *transportManager) deliverReply(ctx context.Context, msg *components.ReceivedMessage) bool {
+	if msg.CorrelationID == nil {
+		return false
+	}
+	tm.replyWaitersLock.Lock()
+	waiter, waiting := tm.replyWaiters[*msg.CorrelationID]
+	if waiting && waiter.node != msg.FromNode {
+		tm.replyWaitersLock.Unlock()
+		// Only the node the request was sent to can reply to it. We leave the waiter in place
+		// so the genuine reply can still be delivered, and drop this message.
+		log.L(ctx).Warnf("discarding message %s from %s claiming to be a reply to %s sent to %s", msg.MessageID, msg.FromNode, msg.CorrelationID, waiter.node)
+		return true
+	}
+	delete(tm.replyWaiters, *msg.CorrelationID)
+	tm.replyWaitersLock.Unlock()
+	if waiting {
+		log.L(ctx).Debugf("delivering message %s as reply to %s", msg.MessageID, msg.CorrelationID)
+		waiter.replyChan <- msg // buffered, and we remove from the map so only one reply is delivered
+	}
+	return waiting
+}
+
 func (tm *transportManager) queueFireAndForget(ctx context.Context, nodeName string, msg *prototk.PaladinMsg) error {
 	// Use or establish a p connection for the send
 	p, err := tm.getPeer(ctx, nodeName, true)
@@ -374,6 +444,52 @@ func (tm *transportManager) getReliableMessageByID(ctx context.Context, dbTX per
 	return rms[0], nil
 }
 
+// Returns the in-memory retry state of the peer sending loop for an outstanding reliable message.
+// Attempt counts are not persisted, so they reset on restart (or if the peer is reaped).
+func (tm *transportManager) GetReliableMessageRetryStatus(ctx context.Context, dbTX persistence.DBTX, id uuid.UUID) (*pldapi.ReliableMessageRetryStatus, error) {
+	rm, err := tm.getReliableMessageByID(ctx, dbTX, id)
+	if err != nil {
+		return nil, err
+	}
+	if rm == nil {
+		return nil, i18n.NewError(ctx, msgs.MsgTransportReliableMessageNotFound, id)
+	}
+	rs := &pldapi.ReliableMessageRetryStatus{
+		MessageID: rm.ID,
+		Node:      rm.Node,
+	}
+	if rm.Ack != nil {
+		rs.Acknowledged = true
+		rs.AckError = rm.Ack.Error
+		return rs, nil
+	}
+	if p := tm.getActivePeer(rm.Node); p != nil {
+		p.reliableRetryStatus(rs)
+	}
+	return rs, nil
+}
+
+// Requests the peer sending loop re-sends the message immediately, regardless of when it was last
+// sent, and discards any backoff built up from previous failures.
+func (tm *transportManager) RetryReliableMessageNow(ctx context.Context, dbTX persistence.DBTX, id uuid.UUID) error {
+	rm, err := tm.getReliableMessageByID(ctx, dbTX, id)
+	if err != nil {
+		return err
+	}
+	if rm == nil {
+		return i18n.NewError(ctx, msgs.MsgTransportReliableMessageNotFound, id)
+	}
+	if rm.Ack != nil {
+		return i18n.NewError(ctx, msgs.MsgTransportReliableMessageAcknowledged, id)
+	}
+	p, err := tm.getPeer(ctx, rm.Node, true)
+	if err != nil {
+		return err
+	}
+	p.forceReliableRetry(rm.ID)
+	return nil
+}
+
 func (tm *transportManager) QueryReliableMessages(ctx context.Context, dbTX persistence.DBTX, jq *query.QueryJSON) ([]*pldapi.ReliableMessage, error) {
 	qw := &filters.QueryWrapper[pldapi.ReliableMessage, pldapi.ReliableMessage]{
 		P:           tm.persistence,
@@ -32,6 +32,7 @@ import (
 	"github.com/kaleido-io/paladin/toolkit/pkg/prototk"
 	"github.com/kaleido-io/paladin/toolkit/pkg/tktypes"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 )
 
@@ -78,6 +79,7 @@ func newMockComponents(t *testing.T, realDB bool) *mockComponents {
 	mc.c.On("TxManager").Return(mc.txManager).Maybe()
 	mc.c.On("PrivateTxManager").Return(mc.privateTxManager).Maybe()
 	mc.c.On("IdentityResolver").Return(mc.identityResolver).Maybe()
+	mc.identityResolver.On("InvalidateNode", mock.Anything).Return().Maybe()
 	mc.c.On("GroupManager").Return(mc.groupManager).Maybe()
 	return mc
 }
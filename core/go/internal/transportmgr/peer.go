@@ -24,6 +24,7 @@ import (
 	"sync/atomic"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/kaleido-io/paladin/core/internal/msgs"
 	"github.com/kaleido-io/paladin/core/pkg/persistence"
 	"github.com/kaleido-io/paladin/toolkit/pkg/i18n"
@@ -54,6 +55,18 @@ type peer struct {
 
 	senderStarted atomic.Bool
 	senderDone    chan struct{}
+
+	// Reliable message retry state - protected by statsLock, as it is read for status queries
+	reliableAttempts map[uuid.UUID]*reliableMsgAttempts
+	reliableForced   map[uuid.UUID]bool
+	scanFailures     int
+	nextScanRetry    *tktypes.Timestamp
+	forceRetry       chan struct{}
+}
+
+type reliableMsgAttempts struct {
+	attempts    int
+	lastAttempt tktypes.Timestamp
 }
 
 type nameSortedPeers []*peer
@@ -127,6 +140,10 @@ func (tm *transportManager) reapPeer(p *peer) {
 	log.L(p.ctx).Infof("peer %s deactivating", p.Name)
 	p.close()
 
+	// The transport details for the node are re-read from the registry if we reconnect, so we
+	// also discard anything we resolved against the node while this connection was active
+	tm.identityResolver.InvalidateNode(p.Name)
+
 	if p.senderStarted.Load() {
 		// Holding the lock while activating/deactivating ensures we never dual-activate in the transport
 		if _, err := p.transport.api.DeactivatePeer(p.ctx, &prototk.DeactivatePeerRequest{
@@ -184,6 +201,7 @@ func (tm *transportManager) connectPeer(ctx context.Context, nodeName string, se
 			persistedMsgsAvailable: make(chan struct{}, 1),
 			sendQueue:              make(chan *prototk.PaladinMsg, tm.senderBufferLen),
 			senderDone:             make(chan struct{}),
+			forceRetry:             make(chan struct{}, 1),
 		}
 		p.ctx, p.cancelCtx = context.WithCancel(
 			log.WithLogField(tm.bgCtx /* go-routine need bg context*/, "peer", nodeName))
@@ -290,7 +308,7 @@ func (p *peer) updateReceivedStats(msg *prototk.PaladinMsg) {
 
 func (p *peer) reliableMessageScan(checkNew bool) error {
 
-	fullScan := p.lastDrainHWM == nil || time.Since(p.lastFullScan) >= p.tm.reliableMessageResend
+	fullScan := p.lastDrainHWM == nil || time.Since(p.lastFullScan) >= p.tm.reliableMessageResend || p.hasForcedRetries()
 	if !fullScan && !checkNew {
 		return nil // Nothing to do
 	}
@@ -298,6 +316,10 @@ func (p *peer) reliableMessageScan(checkNew bool) error {
 	pageSize := p.tm.reliableMessagePageSize
 	var total = 0
 	var lastPageEnd *uint64
+	var outstanding map[uuid.UUID]bool
+	if fullScan {
+		outstanding = make(map[uuid.UUID]bool)
+	}
 	for {
 		query := p.tm.persistence.DB().
 			WithContext(p.ctx).
@@ -325,6 +347,12 @@ func (p *peer) reliableMessageScan(checkNew bool) error {
 			return err
 		}
 
+		for _, rm := range page {
+			if outstanding != nil {
+				outstanding[rm.ID] = true
+			}
+		}
+
 		if len(page) > 0 {
 			p.persistentMsgsDrained = false // we know there's some messages
 			total += len(page)
@@ -352,6 +380,9 @@ func (p *peer) reliableMessageScan(checkNew bool) error {
 		p.persistentMsgsDrained = (total == 0)
 
 		p.lastFullScan = time.Now()
+
+		// Discard retry state for anything that has been acknowledged
+		p.pruneReliableAttempts(outstanding)
 	}
 
 	return nil
@@ -362,6 +393,7 @@ func (p *peer) processReliableMsgPage(dbTX persistence.DBTX, page []*pldapi.Reli
 	type paladinMsgWithSeq struct {
 		*prototk.PaladinMsg
 		seq uint64
+		id  uuid.UUID
 	}
 
 	// Build the messages
@@ -371,7 +403,8 @@ func (p *peer) processReliableMsgPage(dbTX persistence.DBTX, page []*pldapi.Reli
 
 		// Check it's either after our HWM, or eligible for re-send
 		afterHWM := p.lastDrainHWM == nil || *p.lastDrainHWM < rm.Sequence
-		if !afterHWM && time.Since(rm.Created.Time()) < p.tm.reliableMessageResend {
+		forced := p.takeForcedRetry(rm.ID)
+		if !afterHWM && !forced && time.Since(rm.Created.Time()) < p.tm.reliableMessageResend {
 			log.L(p.ctx).Infof("Unacknowledged message %s not yet eligible for re-send", rm.ID)
 			continue
 		}
@@ -405,6 +438,7 @@ func (p *peer) processReliableMsgPage(dbTX persistence.DBTX, page []*pldapi.Reli
 		case msg != nil:
 			msgsToSend = append(msgsToSend, paladinMsgWithSeq{
 				seq:        rm.Sequence,
+				id:         rm.ID,
 				PaladinMsg: msg,
 			})
 		}
@@ -426,6 +460,7 @@ func (p *peer) processReliableMsgPage(dbTX persistence.DBTX, page []*pldapi.Reli
 	// We fail the whole page on error, so we don't thrash (the outer infinite retry
 	// gives a much longer maximum back-off).
 	for _, msg := range msgsToSend {
+		p.recordReliableAttempt(msg.id)
 		if err := p.send(msg.PaladinMsg, &msg.seq); err != nil {
 			return err
 		}
@@ -435,6 +470,127 @@ func (p *peer) processReliableMsgPage(dbTX persistence.DBTX, page []*pldapi.Reli
 
 }
 
+// Retries the scan indefinitely with backoff (until the context is closed).
+// The backoff can be reset by an operator forcing a retry - for example when a peer
+// node that was down for an extended period comes back.
+func (p *peer) reliableMessageScanWithRetry(checkNew bool) error {
+	failures := 0
+	for {
+		err := p.reliableMessageScan(checkNew)
+		if err == nil {
+			p.setScanRetryState(0, 0)
+			return nil
+		}
+		failures++
+		delay := p.tm.reliableScanRetry.Delay(failures)
+		log.L(p.ctx).Errorf("%s (attempt=%d)", err, failures)
+		p.setScanRetryState(failures, delay)
+		select {
+		case <-time.After(delay):
+		case <-p.forceRetry:
+			log.L(p.ctx).Infof("forced retry of reliable messages to peer %s after %d failures", p.Name, failures)
+			failures = 0
+		case <-p.ctx.Done():
+			return i18n.NewError(p.ctx, msgs.MsgContextCanceled)
+		}
+	}
+}
+
+func (p *peer) setScanRetryState(failures int, delay time.Duration) {
+	p.statsLock.Lock()
+	defer p.statsLock.Unlock()
+	p.scanFailures = failures
+	if failures == 0 {
+		p.nextScanRetry = nil
+	} else {
+		next := tktypes.Timestamp(time.Now().Add(delay).UnixNano())
+		p.nextScanRetry = &next
+	}
+}
+
+func (p *peer) recordReliableAttempt(id uuid.UUID) {
+	p.statsLock.Lock()
+	defer p.statsLock.Unlock()
+	if p.reliableAttempts == nil {
+		p.reliableAttempts = make(map[uuid.UUID]*reliableMsgAttempts)
+	}
+	a := p.reliableAttempts[id]
+	if a == nil {
+		a = &reliableMsgAttempts{}
+		p.reliableAttempts[id] = a
+	}
+	a.attempts++
+	a.lastAttempt = tktypes.TimestampNow()
+}
+
+func (p *peer) pruneReliableAttempts(outstanding map[uuid.UUID]bool) {
+	p.statsLock.Lock()
+	defer p.statsLock.Unlock()
+	for id := range p.reliableAttempts {
+		if !outstanding[id] {
+			delete(p.reliableAttempts, id)
+		}
+	}
+	for id := range p.reliableForced {
+		if !outstanding[id] {
+			delete(p.reliableForced, id)
+		}
+	}
+}
+
+func (p *peer) hasForcedRetries() bool {
+	p.statsLock.Lock()
+	defer p.statsLock.Unlock()
+	return len(p.reliableForced) > 0
+}
+
+func (p *peer) takeForcedRetry(id uuid.UUID) bool {
+	p.statsLock.Lock()
+	defer p.statsLock.Unlock()
+	forced := p.reliableForced[id]
+	delete(p.reliableForced, id)
+	return forced
+}
+
+// Requests an immediate re-send of the message, resetting any backoff in the send loop
+func (p *peer) forceReliableRetry(id uuid.UUID) {
+	p.statsLock.Lock()
+	if p.reliableForced == nil {
+		p.reliableForced = make(map[uuid.UUID]bool)
+	}
+	p.reliableForced[id] = true
+	p.statsLock.Unlock()
+
+	select {
+	case p.forceRetry <- struct{}{}:
+	default:
+	}
+	p.notifyPersistedMsgAvailable()
+}
+
+func (p *peer) reliableRetryStatus(rs *pldapi.ReliableMessageRetryStatus) {
+	p.statsLock.Lock()
+	defer p.statsLock.Unlock()
+	rs.PeerActive = p.senderStarted.Load()
+	rs.RetryFailures = p.scanFailures
+	if a := p.reliableAttempts[rs.MessageID]; a != nil {
+		rs.Attempts = a.attempts
+		lastAttempt := a.lastAttempt
+		rs.LastAttempt = &lastAttempt
+	}
+	switch {
+	case p.reliableForced[rs.MessageID]:
+		now := tktypes.TimestampNow()
+		rs.NextAttempt = &now
+	case p.nextScanRetry != nil:
+		next := *p.nextScanRetry
+		rs.NextAttempt = &next
+	case rs.LastAttempt != nil:
+		next := tktypes.Timestamp(rs.LastAttempt.Time().Add(p.tm.reliableMessageResend).UnixNano())
+		rs.NextAttempt = &next
+	}
+}
+
 func (p *peer) sender() {
 	defer close(p.senderDone)
 
@@ -444,9 +600,7 @@ func (p *peer) sender() {
 	for {
 
 		// We send/resend any reliable messages queued up first
-		err := p.tm.reliableScanRetry.Do(p.ctx, func(attempt int) (retryable bool, err error) {
-			return true, p.reliableMessageScan(checkNew)
-		})
+		err := p.reliableMessageScanWithRetry(checkNew)
 		if err != nil {
 			return // context closed
 		}
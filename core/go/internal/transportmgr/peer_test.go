@@ -24,6 +24,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/google/uuid"
 	"github.com/kaleido-io/paladin/config/pkg/confutil"
 	"github.com/kaleido-io/paladin/config/pkg/pldconf"
@@ -659,3 +660,124 @@ func TestProcessReliableMsgPagePrivacyGroupMessage(t *testing.T) {
 	origMsg.Node = receivedMsg.Node         // expect to be changed on incoming message
 	require.Equal(t, origMsg, receivedMsg)
 }
+
+func TestReliableMessageRetryStatusAndForceRetryRealDB(t *testing.T) {
+
+	ctx, tm, tp, done := newTestTransport(t, true,
+		mockGoodTransport,
+		mockGetStateOk,
+	)
+	defer done()
+
+	tm.sendShortRetry = retry.NewRetryLimited(&pldconf.RetryConfigWithMax{
+		MaxAttempts: confutil.P(1),
+	})
+	// Long backoff, so the only way to get a second attempt in the test is to force it
+	tm.reliableScanRetry = retry.NewRetryIndefinite(&pldconf.RetryConfig{
+		InitialDelay: confutil.P("1h"),
+		MaxDelay:     confutil.P("1h"),
+	})
+	tm.reliableMessageResend = 1 * time.Hour
+
+	mockActivateDeactivateOk(tp)
+
+	sentMessages := make(chan *prototk.PaladinMsg, 2)
+	sendCount := 0
+	tp.Functions.SendMessage = func(ctx context.Context, req *prototk.SendMessageRequest) (*prototk.SendMessageResponse, error) {
+		sendCount++
+		sentMessages <- req.Message
+		if sendCount == 1 {
+			return nil, fmt.Errorf("peer down")
+		}
+		return nil, nil
+	}
+
+	err := tm.persistence.Transaction(ctx, func(ctx context.Context, dbTX persistence.DBTX) error {
+		return tm.SendReliable(ctx, dbTX, &pldapi.ReliableMessage{
+			MessageType: pldapi.RMTState.Enum(),
+			Node:        "node2",
+			Metadata: tktypes.JSONString(&components.StateDistribution{
+				Domain:          "domain1",
+				ContractAddress: tktypes.RandAddress().String(),
+				SchemaID:        tktypes.RandHex(32),
+				StateID:         tktypes.RandHex(32),
+			}),
+		})
+	})
+	require.NoError(t, err)
+
+	// First attempt fails, and we back off
+	msgID := uuid.MustParse((<-sentMessages).MessageId)
+	var rs *pldapi.ReliableMessageRetryStatus
+	require.Eventually(t, func() bool {
+		rs, err = tm.GetReliableMessageRetryStatus(ctx, tm.persistence.NOTX(), msgID)
+		require.NoError(t, err)
+		return rs.RetryFailures == 1
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, "node2", rs.Node)
+	assert.True(t, rs.PeerActive)
+	assert.False(t, rs.Acknowledged)
+	assert.Equal(t, 1, rs.Attempts)
+	require.NotNil(t, rs.LastAttempt)
+	require.NotNil(t, rs.NextAttempt)
+	assert.Greater(t, rs.NextAttempt.Time(), time.Now().Add(30*time.Minute))
+
+	// Force the retry, which bypasses the backoff
+	err = tm.RetryReliableMessageNow(ctx, tm.persistence.NOTX(), msgID)
+	require.NoError(t, err)
+	assert.Equal(t, msgID.String(), (<-sentMessages).MessageId)
+	require.Eventually(t, func() bool {
+		rs, err = tm.GetReliableMessageRetryStatus(ctx, tm.persistence.NOTX(), msgID)
+		require.NoError(t, err)
+		return rs.RetryFailures == 0 && rs.Attempts == 2
+	}, 5*time.Second, 10*time.Millisecond)
+	require.NotNil(t, rs.NextAttempt)
+	assert.Greater(t, rs.NextAttempt.Time(), time.Now().Add(30*time.Minute))
+
+	// Once acknowledged, a retry cannot be forced
+	err = tm.persistence.Transaction(ctx, func(ctx context.Context, dbTX persistence.DBTX) error {
+		return tm.writeAcks(ctx, dbTX, &pldapi.ReliableMessageAck{MessageID: msgID, Time: tktypes.TimestampNow()})
+	})
+	require.NoError(t, err)
+	rs, err = tm.GetReliableMessageRetryStatus(ctx, tm.persistence.NOTX(), msgID)
+	require.NoError(t, err)
+	assert.True(t, rs.Acknowledged)
+	err = tm.RetryReliableMessageNow(ctx, tm.persistence.NOTX(), msgID)
+	assert.Regexp(t, "PD012024", err)
+
+	tm.peers["node2"].close()
+}
+
+func TestReliableMessageRetryStatusNotFound(t *testing.T) {
+
+	ctx, tm, _, done := newTestTransport(t, false, func(mc *mockComponents, conf *pldconf.TransportManagerConfig) {
+		mc.db.Mock.ExpectQuery("SELECT.*reliable_msgs").WillReturnRows(sqlmock.NewRows([]string{}))
+		mc.db.Mock.ExpectQuery("SELECT.*reliable_msgs").WillReturnRows(sqlmock.NewRows([]string{}))
+	})
+	defer done()
+
+	_, err := tm.GetReliableMessageRetryStatus(ctx, tm.persistence.NOTX(), uuid.New())
+	assert.Regexp(t, "PD012023", err)
+
+	err = tm.RetryReliableMessageNow(ctx, tm.persistence.NOTX(), uuid.New())
+	assert.Regexp(t, "PD012023", err)
+
+}
+
+func TestForceRetryClearedWhenNotOutstanding(t *testing.T) {
+
+	p := &peer{forceRetry: make(chan struct{}, 1), persistedMsgsAvailable: make(chan struct{}, 1)}
+	id1, id2 := uuid.New(), uuid.New()
+	p.recordReliableAttempt(id1)
+	p.recordReliableAttempt(id2)
+	p.forceReliableRetry(id1)
+	p.forceReliableRetry(id2)
+	assert.True(t, p.hasForcedRetries())
+
+	p.pruneReliableAttempts(map[uuid.UUID]bool{id2: true})
+	assert.Nil(t, p.reliableAttempts[id1])
+	assert.Equal(t, 1, p.reliableAttempts[id2].attempts)
+	assert.True(t, p.takeForcedRetry(id2))
+	assert.False(t, p.hasForcedRetries())
+
+}
@@ -182,6 +182,10 @@ func (t *transport) ReceiveMessage(ctx context.Context, req *prototk.ReceiveMess
 		log.L(ctx).Tracef("transport %s message received: %s", t.name, protoToJSON(msg))
 	}
 
+	if t.tm.deliverReply(ctx, rMsg) {
+		return &prototk.ReceiveMessageResponse{}, nil
+	}
+
 	if err := t.deliverMessage(ctx, p, msg.Component, rMsg); err != nil {
 		return nil, err
 	}
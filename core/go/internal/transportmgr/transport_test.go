@@ -20,6 +20,7 @@ import (
 	"fmt"
 	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/google/uuid"
@@ -551,3 +552,123 @@ func TestSendReliableFail(t *testing.T) {
 	require.Regexp(t, "pop", err)
 
 }
+
+func TestSendAndWaitReply(t *testing.T) {
+	ctx, tm, tp, done := newTestTransport(t, false,
+		mockEmptyReliableMsgs,
+		mockGoodTransport)
+	defer done()
+
+	mockActivateDeactivateOk(tp)
+	tp.Functions.SendMessage = func(ctx context.Context, req *prototk.SendMessageRequest) (*prototk.SendMessageResponse, error) {
+		// The remote node replies, correlated to the request. This is routed straight back to
+		// the waiting caller rather than the transaction engine (which has no mock expectations)
+		go func() {
+			_, err := tp.t.ReceiveMessage(ctx, &prototk.ReceiveMessageRequest{
+				FromNode: "node2",
+				Message: &prototk.PaladinMsg{
+					MessageId:     uuid.NewString(),
+					CorrelationId: &req.Message.MessageId,
+					Component:     prototk.PaladinMsg_TRANSACTION_ENGINE,
+					MessageType:   "myReplyType",
+					Payload:       []byte("the reply"),
+				},
+			})
+			assert.NoError(t, err)
+		}()
+		return nil, nil
+	}
+
+	message := testMessage()
+	message.Component = prototk.PaladinMsg_TRANSACTION_ENGINE
+	reply, err := tm.SendAndWait(ctx, message, 10*time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, "node2", reply.FromNode)
+	assert.Equal(t, *message.MessageID, *reply.CorrelationID)
+	assert.Equal(t, "myReplyType", reply.MessageType)
+	assert.Equal(t, []byte("the reply"), reply.Payload)
+
+	// the waiter is cleaned up
+	assert.Empty(t, tm.replyWaiters)
+}
+
+func TestSendAndWaitIgnoresReplyFromOtherNode(t *testing.T) {
+	ctx, tm, tp, done := newTestTransport(t, false,
+		mockEmptyReliableMsgs,
+		mockGoodTransport)
+	defer done()
+
+	mockActivateDeactivateOk(tp)
+	tp.Functions.SendMessage = func(ctx context.Context, req *prototk.SendMessageRequest) (*prototk.SendMessageResponse, error) {
+		go func() {
+			replyFrom := func(node, payload string) {
+				_, err := tp.t.ReceiveMessage(ctx, &prototk.ReceiveMessageRequest{
+					FromNode: node,
+					Message: &prototk.PaladinMsg{
+						MessageId:     uuid.NewString(),
+						CorrelationId: &req.Message.MessageId,
+						Component:     prototk.PaladinMsg_TRANSACTION_ENGINE,
+						MessageType:   "myReplyType",
+						Payload:       []byte(payload),
+					},
+				})
+				assert.NoError(t, err)
+			}
+			// A different node cannot answer on behalf of the one we sent to
+			replyFrom("node3", "the spoofed reply")
+			replyFrom("node2", "the reply")
+		}()
+		return nil, nil
+	}
+
+	message := testMessage()
+	message.Component = prototk.PaladinMsg_TRANSACTION_ENGINE
+	reply, err := tm.SendAndWait(ctx, message, 10*time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, "node2", reply.FromNode)
+	assert.Equal(t, []byte("the reply"), reply.Payload)
+	assert.Empty(t, tm.replyWaiters)
+}
+
+func TestSendAndWaitTimeout(t *testing.T) {
+	ctx, tm, tp, done := newTestTransport(t, false,
+		mockEmptyReliableMsgs,
+		mockGoodTransport)
+	defer done()
+
+	mockActivateDeactivateOk(tp)
+	tp.Functions.SendMessage = func(ctx context.Context, req *prototk.SendMessageRequest) (*prototk.SendMessageResponse, error) {
+		return nil, nil
+	}
+
+	message := testMessage()
+	_, err := tm.SendAndWait(ctx, message, 10*time.Millisecond)
+	assert.Regexp(t, "PD012025.*node2.*myMessageType", err)
+	assert.Empty(t, tm.replyWaiters)
+}
+
+func TestSendAndWaitContextCancelled(t *testing.T) {
+	_, tm, tp, done := newTestTransport(t, false,
+		mockEmptyReliableMsgs,
+		mockGoodTransport)
+	defer done()
+
+	mockActivateDeactivateOk(tp)
+	ctx, cancelCtx := context.WithCancel(context.Background())
+	tp.Functions.SendMessage = func(_ context.Context, req *prototk.SendMessageRequest) (*prototk.SendMessageResponse, error) {
+		cancelCtx()
+		return nil, nil
+	}
+
+	_, err := tm.SendAndWait(ctx, testMessage(), 10*time.Second)
+	assert.Regexp(t, "PD010301", err)
+}
+
+func TestSendAndWaitSendFail(t *testing.T) {
+	ctx, tm, _, done := newTestTransport(t, false)
+	defer done()
+
+	_, err := tm.SendAndWait(ctx, &components.FireAndForgetMessageSend{Node: "node2"}, 10*time.Second)
+	assert.Regexp(t, "PD012000", err)
+	assert.Empty(t, tm.replyWaiters)
+}
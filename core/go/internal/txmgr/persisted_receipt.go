@@ -18,6 +18,7 @@ package txmgr
 import (
 	"context"
 	"encoding/json"
+	"strings"
 
 	"github.com/google/uuid"
 	"github.com/hyperledger/firefly-signer/pkg/abi"
@@ -338,6 +339,41 @@ func (tm *txManager) QueryTransactionReceipts(ctx context.Context, jq *query.Que
 	return qw.Run(ctx, nil)
 }
 
+// QueryReceipts pages through historical receipts, returning the full receipt for each.
+// The "sequence" of each receipt is unique, so it is always added as the final sort
+// field to give a stable ordering when paging on other fields (such as "indexed").
+func (tm *txManager) QueryReceipts(ctx context.Context, jq *query.QueryJSON) ([]*pldapi.TransactionReceiptFull, error) {
+	if err := filters.CheckLimitSet(ctx, jq); err != nil {
+		return nil, err
+	}
+	jq = withSequenceTieBreak(jq)
+	receipts, err := tm.QueryTransactionReceipts(ctx, jq)
+	if err != nil {
+		return nil, err
+	}
+	fullReceipts := make([]*pldapi.TransactionReceiptFull, len(receipts))
+	for i, r := range receipts {
+		if fullReceipts[i], err = tm.buildFullReceipt(ctx, r, false); err != nil {
+			return nil, err
+		}
+	}
+	return fullReceipts, nil
+}
+
+func withSequenceTieBreak(jq *query.QueryJSON) *query.QueryJSON {
+	if len(jq.Sort) == 0 {
+		return jq
+	}
+	for _, s := range jq.Sort {
+		if strings.TrimPrefix(strings.SplitN(strings.TrimSpace(s), " ", 2)[0], "-") == "sequence" {
+			return jq
+		}
+	}
+	jqCopy := *jq
+	jqCopy.Sort = append(append([]string{}, jq.Sort...), "sequence")
+	return &jqCopy
+}
+
 func (tm *txManager) GetTransactionReceiptByID(ctx context.Context, id uuid.UUID) (*pldapi.TransactionReceipt, error) {
 	prs, err := tm.QueryTransactionReceipts(ctx, query.NewQueryBuilder().Limit(1).Equal("id", id).Query())
 	if len(prs) == 0 || err != nil {
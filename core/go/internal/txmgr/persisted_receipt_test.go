@@ -23,11 +23,13 @@ import (
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/google/uuid"
 	"github.com/hyperledger/firefly-signer/pkg/abi"
+	"github.com/kaleido-io/paladin/config/pkg/confutil"
 	"github.com/kaleido-io/paladin/config/pkg/pldconf"
 	"github.com/kaleido-io/paladin/core/internal/components"
 	"github.com/kaleido-io/paladin/core/mocks/componentmocks"
 	"github.com/kaleido-io/paladin/core/pkg/persistence"
 	"github.com/kaleido-io/paladin/toolkit/pkg/pldapi"
+	"github.com/kaleido-io/paladin/toolkit/pkg/query"
 	"github.com/kaleido-io/paladin/toolkit/pkg/tktypes"
 
 	"github.com/stretchr/testify/assert"
@@ -442,3 +444,94 @@ func TestDecodeEvent(t *testing.T) {
 	assert.Regexp(t, "PD020015", err)
 
 }
+
+func TestQueryReceiptsFilters(t *testing.T) {
+
+	ctx, txm, done := newTestTransactionManager(t, true, func(conf *pldconf.TxManagerConfig, mc *mockComponents) {
+		mc.stateMgr.On("GetTransactionStates", mock.Anything, mock.Anything, mock.Anything).Return(
+			&pldapi.TransactionStates{None: true}, nil,
+		)
+	})
+	defer done()
+
+	contract1 := tktypes.RandAddress()
+	baseTime := tktypes.TimestampFromUnix(1700000000)
+	receipts := []*transactionReceipt{
+		{TransactionID: uuid.New(), Indexed: baseTime, Domain: "domain1", Success: true},
+		{TransactionID: uuid.New(), Indexed: baseTime + 1000, Domain: "domain1", Success: false, FailureMessage: confutil.P("pop")},
+		{TransactionID: uuid.New(), Indexed: baseTime + 2000, Domain: "", Success: true, ContractAddress: contract1},
+		{TransactionID: uuid.New(), Indexed: baseTime + 2000, Domain: "domain2", Success: true},
+		{TransactionID: uuid.New(), Indexed: baseTime + 3000, Domain: "", Success: false, FailureMessage: confutil.P("bang")},
+	}
+	err := txm.p.DB().Table("transaction_receipts").Create(receipts).Error
+	require.NoError(t, err)
+
+	idsOf := func(results []*pldapi.TransactionReceiptFull) []uuid.UUID {
+		ids := make([]uuid.UUID, len(results))
+		for i, r := range results {
+			ids[i] = r.ID
+		}
+		return ids
+	}
+
+	// Domain
+	res, err := txm.QueryReceipts(ctx, query.NewQueryBuilder().Limit(10).Equal("domain", "domain1").Sort("sequence").Query())
+	require.NoError(t, err)
+	assert.Equal(t, []uuid.UUID{receipts[0].TransactionID, receipts[1].TransactionID}, idsOf(res))
+	assert.Equal(t, &pldapi.TransactionStates{None: true}, res[0].States)
+
+	// Success/failure
+	res, err = txm.QueryReceipts(ctx, query.NewQueryBuilder().Limit(10).Equal("success", false).Sort("sequence").Query())
+	require.NoError(t, err)
+	assert.Equal(t, []uuid.UUID{receipts[1].TransactionID, receipts[4].TransactionID}, idsOf(res))
+	assert.Equal(t, "bang", res[1].FailureMessage)
+
+	// Contract address
+	res, err = txm.QueryReceipts(ctx, query.NewQueryBuilder().Limit(10).Equal("contractAddress", contract1).Query())
+	require.NoError(t, err)
+	assert.Equal(t, []uuid.UUID{receipts[2].TransactionID}, idsOf(res))
+	assert.Nil(t, res[0].States) // no state lookup for public receipts
+
+	// Time range - with the same timestamp for two entries, sequence is used as a tie-break
+	res, err = txm.QueryReceipts(ctx, query.NewQueryBuilder().Limit(10).
+		GreaterThanOrEqual("indexed", baseTime+1000).
+		LessThan("indexed", baseTime+3000).
+		Sort("indexed").
+		Query())
+	require.NoError(t, err)
+	assert.Equal(t, []uuid.UUID{receipts[1].TransactionID, receipts[2].TransactionID, receipts[3].TransactionID}, idsOf(res))
+
+	// Combined
+	res, err = txm.QueryReceipts(ctx, query.NewQueryBuilder().Limit(10).
+		Equal("success", true).
+		In("domain", []any{"domain1", "domain2"}).
+		GreaterThan("indexed", baseTime).
+		Query())
+	require.NoError(t, err)
+	assert.Equal(t, []uuid.UUID{receipts[3].TransactionID}, idsOf(res))
+
+	// Paging with a limit uses the stable order
+	res, err = txm.QueryReceipts(ctx, query.NewQueryBuilder().Limit(2).Sort("-indexed").Query())
+	require.NoError(t, err)
+	assert.Equal(t, []uuid.UUID{receipts[4].TransactionID, receipts[2].TransactionID}, idsOf(res))
+
+}
+
+func TestQueryReceiptsErrors(t *testing.T) {
+
+	ctx, txm, done := newTestTransactionManager(t, false,
+		mockEmptyReceiptListeners,
+		func(conf *pldconf.TxManagerConfig, mc *mockComponents) {
+			mc.db.ExpectQuery("SELECT.*transaction_receipts").WillReturnRows(
+				sqlmock.NewRows([]string{"transaction", "domain"}).AddRow(uuid.New(), "domain1"))
+			mc.stateMgr.On("GetTransactionStates", mock.Anything, mock.Anything, mock.Anything).Return(nil, fmt.Errorf("pop"))
+		})
+	defer done()
+
+	_, err := txm.QueryReceipts(ctx, query.NewQueryBuilder().Query())
+	assert.Regexp(t, "PD010721", err)
+
+	_, err = txm.QueryReceipts(ctx, query.NewQueryBuilder().Limit(1).Query())
+	assert.Regexp(t, "pop", err)
+
+}
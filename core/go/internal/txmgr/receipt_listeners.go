@@ -102,11 +102,18 @@ type receiptListener struct {
 
 	nextBatchID  uint64
 	newReceivers chan bool
+	resumed      chan bool // a resuming receiver wants any batch awaiting redelivery without waiting for the retry
 	receiverLock sync.Mutex
 	receivers    []*registeredReceiptReceiver
 	done         chan struct{}
 }
 
+// Implemented by receivers that want receipts arriving in quick succession coalesced into one batch,
+// such as a JSON/RPC subscription that set a batchTimeout
+type receiptBatchCoalescer interface {
+	receiptBatchTimeout() time.Duration
+}
+
 type registeredReceiptReceiver struct {
 	id uuid.UUID
 	l  *receiptListener
@@ -183,6 +190,20 @@ func (tm *txManager) AddReceiptReceiver(ctx context.Context, name string, r comp
 	return l.addReceiver(r), nil
 }
 
+// Redelivers any batch of the listener that is waiting to be retried immediately, such as the
+// batch that was unacknowledged when the connection of a subscription dropped
+func (tm *txManager) resumeReceiptListener(name string) {
+	tm.receiptListenerLock.Lock()
+	defer tm.receiptListenerLock.Unlock()
+
+	if l := tm.receiptListeners[name]; l != nil {
+		select {
+		case l.resumed <- true:
+		default:
+		}
+	}
+}
+
 func (tm *txManager) GetReceiptListener(ctx context.Context, name string) *pldapi.TransactionReceiptListener {
 
 	tm.receiptListenerLock.Lock()
@@ -237,6 +258,15 @@ func (tm *txManager) setReceiptListenerStatus(ctx context.Context, name string,
 }
 
 func (tm *txManager) DeleteReceiptListener(ctx context.Context, name string) error {
+	err := tm.deleteReceiptListener(ctx, name)
+	if err == nil {
+		// Outside of the receipt listener lock, as the subscription lock must be taken first
+		tm.rpcEventStreams.listenerDeleted(name)
+	}
+	return err
+}
+
+func (tm *txManager) deleteReceiptListener(ctx context.Context, name string) error {
 	tm.receiptListenerLock.Lock()
 	defer tm.receiptListenerLock.Unlock()
 
@@ -468,6 +498,7 @@ func (tm *txManager) loadListener(ctx context.Context, pl *persistedReceiptListe
 		spec:         spec,
 		newReceivers: make(chan bool, 1),
 		newReceipts:  make(chan bool, 1),
+		resumed:      make(chan bool, 1),
 	}
 
 	tm.receiptListenerLock.Lock()
@@ -737,7 +768,12 @@ func (l *receiptListener) processPage(page []*transactionReceipt) (*receiptDeliv
 	// If our batch contains some work, we need to wait for someone to process that work
 	// (note we're not holding any resource open at this point - no DB TX or anything).
 	if len(batch.Receipts) > 0 {
-		err := l.tm.receiptsRetry.Do(l.ctx, func(attempt int) (retryable bool, err error) {
+		// Only a resume after this batch has been attempted should cut short its retry
+		select {
+		case <-l.resumed:
+		default:
+		}
+		err := l.tm.receiptsRetry.DoWakeable(l.ctx, l.resumed, func(attempt int) (retryable bool, err error) {
 			return true, l.deliverBatch(&batch)
 		})
 		if err != nil {
@@ -854,6 +890,34 @@ func (l *receiptListener) processStaleGap(gap *persistedReceiptGap) error {
 
 }
 
+// The coalescing window of the receiver the next batch will be delivered to
+func (l *receiptListener) nextBatchTimeout() time.Duration {
+	l.receiverLock.Lock()
+	defer l.receiverLock.Unlock()
+	if len(l.receivers) == 0 {
+		return 0
+	}
+	if c, ok := l.receivers[int(l.nextBatchID)%len(l.receivers)].ReceiptReceiver.(receiptBatchCoalescer); ok {
+		return c.receiptBatchTimeout()
+	}
+	return 0
+}
+
+func (l *receiptListener) waitBatchTimeout() bool {
+	batchTimeout := l.nextBatchTimeout()
+	if batchTimeout <= 0 {
+		return true
+	}
+	timer := time.NewTimer(batchTimeout)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-l.ctx.Done():
+		return false
+	}
+}
+
 func (l *receiptListener) runListener() {
 	defer close(l.done)
 
@@ -918,6 +982,11 @@ func (l *receiptListener) runListener() {
 		for !newReceipts && !newStates {
 			select {
 			case <-l.newReceipts:
+				// Give any receipts that follow shortly after a chance to join the same batch
+				if !l.waitBatchTimeout() {
+					log.L(l.ctx).Warnf("listener stopping (coalescing new receipts)") // cancelled context
+					return
+				}
 				newReceipts = true
 			case <-stateGapCheckTicker.C:
 				// Only do the DB check if we've had the tap that new states have been received
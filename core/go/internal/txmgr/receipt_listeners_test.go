@@ -188,6 +188,72 @@ func TestE2EReceiptListenerDeliveryLateAttach(t *testing.T) {
 
 }
 
+type batchRecordingReceiver struct {
+	batchTimeout time.Duration
+	batches      chan []*pldapi.TransactionReceiptFull
+}
+
+func (brr *batchRecordingReceiver) receiptBatchTimeout() time.Duration {
+	return brr.batchTimeout
+}
+
+func (brr *batchRecordingReceiver) DeliverReceiptBatch(ctx context.Context, batchID uint64, receipts []*pldapi.TransactionReceiptFull) error {
+	brr.batches <- receipts
+	return nil
+}
+
+func TestE2EReceiptListenerCoalescesWithinBatchTimeout(t *testing.T) {
+	ctx, txm, done := newTestTransactionManager(t, true)
+	defer done()
+
+	err := txm.CreateReceiptListener(ctx, &pldapi.TransactionReceiptListener{
+		Name: "listener1",
+	})
+	require.NoError(t, err)
+
+	receiver := &batchRecordingReceiver{
+		batchTimeout: 1 * time.Second,
+		batches:      make(chan []*pldapi.TransactionReceiptFull, 10),
+	}
+	closeReceiver, err := txm.AddReceiptReceiver(ctx, "listener1", receiver)
+	require.NoError(t, err)
+	defer closeReceiver.Close()
+
+	// Commit each receipt in its own DB transaction, so each one notifies the listener separately
+	finalize := func() uuid.UUID {
+		txID := uuid.New()
+		err := txm.p.Transaction(ctx, func(ctx context.Context, dbTX persistence.DBTX) error {
+			return txm.FinalizeTransactions(ctx, dbTX, []*components.ReceiptInput{{
+				ReceiptType:   components.RT_Success,
+				TransactionID: txID,
+				OnChain:       randOnChain(tktypes.RandAddress()),
+			}})
+		})
+		require.NoError(t, err)
+		return txID
+	}
+
+	// Wait for a first receipt to be delivered, so the listener is idle waiting for new receipts
+	finalize()
+	require.Len(t, <-receiver.batches, 1)
+
+	// The next receipt is held back for the window, rather than delivered on its own
+	txIDs := []uuid.UUID{finalize()}
+	select {
+	case batch := <-receiver.batches:
+		require.Fail(t, "receipt delivered before the end of the window", "%d receipts", len(batch))
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	// so the receipts that follow within the window are delivered in the same batch
+	txIDs = append(txIDs, finalize(), finalize())
+	batch := <-receiver.batches
+	require.Len(t, batch, 3)
+	for i, r := range batch {
+		assert.Equal(t, txIDs[i], r.ID)
+	}
+}
+
 func randOnChain(addr *tktypes.EthAddress) tktypes.OnChainLocation {
 	return tktypes.OnChainLocation{
 		Type:             tktypes.OnChainTransaction,
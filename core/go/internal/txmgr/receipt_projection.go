@@ -0,0 +1,116 @@
+/*
+ * Copyright © 2024 Kaleido, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with
+ * the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package txmgr
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"strings"
+
+	"github.com/kaleido-io/paladin/core/internal/msgs"
+	"github.com/kaleido-io/paladin/toolkit/pkg/i18n"
+	"github.com/kaleido-io/paladin/toolkit/pkg/pldapi"
+)
+
+// A subset of the fields of each receipt, requested by a subscriber to reduce the size of the batches
+type receiptProjection [][]string
+
+// A batch in the same shape as pldapi.TransactionReceiptBatch, with projected receipts
+type projectedReceiptBatch struct {
+	BatchID  uint64           `json:"batchId,omitempty"`
+	Receipts []map[string]any `json:"receipts,omitempty"`
+}
+
+var receiptFullType = reflect.TypeOf(pldapi.TransactionReceiptFull{})
+
+func newReceiptProjection(ctx context.Context, fields []string) (receiptProjection, error) {
+	projection := make(receiptProjection, len(fields))
+	for i, field := range fields {
+		path := strings.Split(field, ".")
+		if !isReceiptField(receiptFullType, path) {
+			return nil, i18n.NewError(ctx, msgs.MsgTxMgrBadSubscriptionField, field)
+		}
+		projection[i] = path
+	}
+	return projection, nil
+}
+
+// Checks the path resolves through the JSON names of the fields of the struct, descending into nested structs
+func isReceiptField(t reflect.Type, path []string) bool {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return false
+	}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		switch {
+		case name == "-" || !f.IsExported():
+		case f.Anonymous && name == "":
+			// embedded structs are flattened into the parent JSON object
+			if isReceiptField(f.Type, path) {
+				return true
+			}
+		case name == path[0] || (name == "" && f.Name == path[0]):
+			return len(path) == 1 || isReceiptField(f.Type, path[1:])
+		}
+	}
+	return false
+}
+
+func (rp receiptProjection) apply(receipts []*pldapi.TransactionReceiptFull) ([]map[string]any, error) {
+	projected := make([]map[string]any, len(receipts))
+	for i, r := range receipts {
+		var full map[string]any
+		b, err := json.Marshal(r)
+		if err == nil {
+			err = json.Unmarshal(b, &full)
+		}
+		if err != nil {
+			return nil, err
+		}
+		projected[i] = make(map[string]any)
+		for _, path := range rp {
+			projectPath(full, projected[i], path)
+		}
+	}
+	return projected, nil
+}
+
+// Copies the value at the path, if set, creating the parent objects as required
+func projectPath(from, to map[string]any, path []string) {
+	v, ok := from[path[0]]
+	if !ok {
+		return
+	}
+	if len(path) == 1 {
+		to[path[0]] = v
+		return
+	}
+	fromChild, ok := v.(map[string]any)
+	if !ok {
+		return
+	}
+	toChild, ok := to[path[0]].(map[string]any)
+	if !ok {
+		toChild = make(map[string]any)
+		to[path[0]] = toChild
+	}
+	projectPath(fromChild, toChild, path[1:])
+}
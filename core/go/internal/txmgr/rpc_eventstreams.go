@@ -17,8 +17,13 @@ package txmgr
 
 import (
 	"context"
+	"encoding/json"
+	"sort"
 	"sync"
+	"time"
 
+	"github.com/kaleido-io/paladin/config/pkg/confutil"
+	"github.com/kaleido-io/paladin/config/pkg/pldconf"
 	"github.com/kaleido-io/paladin/core/internal/components"
 	"github.com/kaleido-io/paladin/core/internal/msgs"
 	"github.com/kaleido-io/paladin/toolkit/pkg/i18n"
@@ -30,15 +35,19 @@ import (
 )
 
 type rpcEventStreams struct {
-	tm          *txManager
-	subLock     sync.Mutex
-	receiptSubs map[string]*receiptListenerSubscription
+	tm                   *txManager
+	subLock              sync.Mutex
+	receiptSubs          map[string]*receiptListenerSubscription
+	gracefulCloseTimeout time.Duration
+	maxNacks             int
 }
 
 func newRPCEventStreams(tm *txManager) *rpcEventStreams {
 	es := &rpcEventStreams{
-		tm:          tm,
-		receiptSubs: make(map[string]*receiptListenerSubscription),
+		tm:                   tm,
+		receiptSubs:          make(map[string]*receiptListenerSubscription),
+		gracefulCloseTimeout: confutil.DurationMin(tm.conf.ReceiptListeners.GracefulCloseTimeout, 0, *pldconf.TxManagerDefaults.ReceiptListeners.GracefulCloseTimeout),
+		maxNacks:             confutil.IntMin(tm.conf.ReceiptListeners.MaxSubscriptionNacks, 0, *pldconf.TxManagerDefaults.ReceiptListeners.MaxSubscriptionNacks),
 	}
 	return es
 }
@@ -52,15 +61,29 @@ func (es *rpcEventStreams) LifecycleMethods() []string {
 }
 
 type rpcAckNack struct {
-	ack bool
+	ack     bool
+	batchID *uint64 // optional - when set the ack/nack is ignored unless it matches the in-flight batch
 }
 
 type receiptListenerSubscription struct {
 	es        *rpcEventStreams
+	listener  string
+	created   time.Time
 	rrc       components.ReceiptReceiverCloser
 	ctrl      rpcserver.RPCAsyncControl
 	acksNacks chan *rpcAckNack
 	closed    chan struct{}
+
+	maxBatchSize int               // zero unless the subscriber asked for smaller batches than the listener reads
+	batchTimeout time.Duration     // zero unless the subscriber asked for receipts to be coalesced
+	projection   receiptProjection // nil unless the subscriber asked for only some receipt fields
+	lastBatchID  uint64            // our own batch numbering, used when we split batches
+	nacks        int               // consecutive
+
+	inFlightLock    sync.Mutex
+	inFlight        chan struct{} // non-nil while a batch is awaiting an ack/nack
+	inFlightBatchID uint64
+	inFlightSince   time.Time
 }
 
 func (es *rpcEventStreams) HandleStart(ctx context.Context, req *rpcclient.RPCRequest, ctrl rpcserver.RPCAsyncControl) (rpcserver.RPCAsyncInstance, *rpcclient.RPCResponse) {
@@ -79,18 +102,62 @@ func (es *rpcEventStreams) HandleStart(ctx context.Context, req *rpcclient.RPCRe
 	if len(req.Params) < 2 {
 		return nil, rpcclient.NewRPCErrorResponse(i18n.NewError(ctx, msgs.MsgTxMgrListenerNameRequired), req.ID, rpcclient.RPCCodeInvalidRequest)
 	}
+	var options pldapi.TransactionReceiptSubscriptionOptions
+	if len(req.Params) >= 3 {
+		if err := json.Unmarshal(req.Params[2].Bytes(), &options); err != nil {
+			return nil, rpcclient.NewRPCErrorResponse(i18n.WrapError(ctx, err, msgs.MsgTxMgrBadSubscriptionOptions), req.ID, rpcclient.RPCCodeInvalidRequest)
+		}
+	}
 	sub := &receiptListenerSubscription{
 		es:        es,
+		listener:  req.Params[1].StringValue(),
+		created:   time.Now(),
 		ctrl:      ctrl,
 		acksNacks: make(chan *rpcAckNack, 1),
 		closed:    make(chan struct{}),
 	}
+	if options.MaxBatchSize != nil {
+		if *options.MaxBatchSize < 1 {
+			return nil, rpcclient.NewRPCErrorResponse(i18n.NewError(ctx, msgs.MsgTxMgrBadSubscriptionMaxBatchSize, *options.MaxBatchSize), req.ID, rpcclient.RPCCodeInvalidRequest)
+		}
+		// The listener never builds a batch bigger than a read page, so that is the ceiling
+		if *options.MaxBatchSize < es.tm.receiptsReadPageSize {
+			sub.maxBatchSize = *options.MaxBatchSize
+		}
+	}
+	if options.BatchTimeout != nil {
+		batchTimeout, err := time.ParseDuration(*options.BatchTimeout)
+		if err != nil || batchTimeout < 0 {
+			return nil, rpcclient.NewRPCErrorResponse(i18n.NewError(ctx, msgs.MsgTxMgrBadSubscriptionBatchTimeout, *options.BatchTimeout), req.ID, rpcclient.RPCCodeInvalidRequest)
+		}
+		sub.batchTimeout = batchTimeout
+	}
+	if len(options.Fields) > 0 {
+		var err error
+		if sub.projection, err = newReceiptProjection(ctx, options.Fields); err != nil {
+			return nil, rpcclient.NewRPCErrorResponse(err, req.ID, rpcclient.RPCCodeInvalidRequest)
+		}
+	}
+	if existing := es.receiptSubs[ctrl.ID()]; existing != nil {
+		// Should not happen, but if the ID is reused we must not leak the receiver of the old subscription
+		log.L(ctx).Warnf("Subscription ID %s already in use - closing the existing subscription", ctrl.ID())
+		es.cleanupLocked(existing)
+	}
+	if options.Resume {
+		// A resuming subscription takes over the listener, so it cannot race another live connection
+		// (such as one that dropped, but that we have not yet noticed) for the batches. Closing those
+		// fails the batch they have in-flight, which is then redelivered to this subscription.
+		es.supersedeLocked(ctx, sub.listener)
+	}
 	es.receiptSubs[ctrl.ID()] = sub
 	var err error
-	sub.rrc, err = es.tm.AddReceiptReceiver(ctx, req.Params[1].StringValue(), sub)
+	sub.rrc, err = es.tm.AddReceiptReceiver(ctx, sub.listener, sub)
 	if err != nil {
 		return nil, rpcclient.NewRPCErrorResponse(err, req.ID, rpcclient.RPCCodeInvalidRequest)
 	}
+	if options.Resume {
+		es.tm.resumeReceiptListener(sub.listener)
+	}
 
 	return sub, &rpcclient.RPCResponse{
 		JSONRpc: "2.0",
@@ -116,6 +183,38 @@ func (es *rpcEventStreams) getSubscription(subID string) *receiptListenerSubscri
 	return es.receiptSubs[subID]
 }
 
+// Snapshot of the active subscriptions, so operators can see which listeners they are bound to
+// and spot any that are not cleaned up when their connection goes away
+func (es *rpcEventStreams) getSubscriptionStatus() []*pldapi.TransactionReceiptSubscriptionStatus {
+	es.subLock.Lock()
+	defer es.subLock.Unlock()
+
+	now := time.Now()
+	statuses := make([]*pldapi.TransactionReceiptSubscriptionStatus, 0, len(es.receiptSubs))
+	for subID, sub := range es.receiptSubs {
+		status := &pldapi.TransactionReceiptSubscriptionStatus{
+			ID:       subID,
+			Listener: sub.listener,
+			Created:  tktypes.Timestamp(sub.created.UnixNano()),
+			Age:      now.Sub(sub.created).String(),
+		}
+		sub.inFlightLock.Lock()
+		if sub.inFlight != nil {
+			status.InFlightBatchID = confutil.P(sub.inFlightBatchID)
+			status.AckLag = now.Sub(sub.inFlightSince).String()
+		}
+		sub.inFlightLock.Unlock()
+		statuses = append(statuses, status)
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		if statuses[i].Created != statuses[j].Created {
+			return statuses[i].Created < statuses[j].Created
+		}
+		return statuses[i].ID < statuses[j].ID
+	})
+	return statuses
+}
+
 func (es *rpcEventStreams) HandleLifecycle(ctx context.Context, req *rpcclient.RPCRequest) *rpcclient.RPCResponse {
 
 	if len(req.Params) < 1 {
@@ -125,16 +224,28 @@ func (es *rpcEventStreams) HandleLifecycle(ctx context.Context, req *rpcclient.R
 	sub := es.getSubscription(subID)
 	switch req.Method {
 	case "ptx_ack", "ptx_nack":
-		if sub != nil {
+		ackNack := &rpcAckNack{ack: (req.Method == "ptx_ack")}
+		if len(req.Params) >= 2 {
+			var batchID tktypes.HexUint64
+			if err := json.Unmarshal(req.Params[1].Bytes(), &batchID); err != nil {
+				return rpcclient.NewRPCErrorResponse(i18n.WrapError(ctx, err, msgs.MsgTxMgrBadAckBatchID, req.Params[1]), req.ID, rpcclient.RPCCodeInvalidRequest)
+			}
+			ackNack.batchID = (*uint64)(&batchID)
+		}
+		// A stale ack/nack is dropped here, so it cannot take the place of the one for the in-flight batch
+		if sub != nil && sub.isInFlightBatch(ctx, ackNack) {
 			select {
-			case sub.acksNacks <- &rpcAckNack{ack: (req.Method == "ptx_ack")}:
-				log.L(ctx).Infof("ack/nack received for subID %s ack=%t", subID, req.Method == "ptx_ack")
+			case sub.acksNacks <- ackNack:
+				log.L(ctx).Infof("ack/nack received for subID %s ack=%t", subID, ackNack.ack)
 			default:
 			}
 		}
 		return nil // no reply to acks/nacks - we just send more messages
 	case "ptx_unsubscribe":
 		if sub != nil {
+			// A clean unsubscribe gives any batch already delivered to the client a chance to be
+			// acknowledged, before we close (which fails the in-flight batch for redelivery).
+			sub.waitInFlight(ctx, es.gracefulCloseTimeout)
 			sub.ctrl.Closed()
 			es.cleanupSubscription(subID)
 		}
@@ -149,9 +260,85 @@ func (es *rpcEventStreams) HandleLifecycle(ctx context.Context, req *rpcclient.R
 
 }
 
+func (sub *receiptListenerSubscription) receiptBatchTimeout() time.Duration {
+	return sub.batchTimeout
+}
+
+func (sub *receiptListenerSubscription) setInFlight(inFlight chan struct{}, batchID uint64) {
+	sub.inFlightLock.Lock()
+	defer sub.inFlightLock.Unlock()
+	sub.inFlight = inFlight
+	sub.inFlightBatchID = batchID
+	sub.inFlightSince = time.Now()
+}
+
+// Acks/nacks without a batch ID are always assumed to be for the in-flight batch
+func (sub *receiptListenerSubscription) isInFlightBatch(ctx context.Context, ackNack *rpcAckNack) bool {
+	if ackNack.batchID == nil {
+		return true
+	}
+	sub.inFlightLock.Lock()
+	defer sub.inFlightLock.Unlock()
+	if sub.inFlight == nil || *ackNack.batchID != sub.inFlightBatchID {
+		log.L(ctx).Warnf("Ignoring ack/nack for batch %d on subscription %s (in-flight=%t batch=%d)", *ackNack.batchID, sub.ctrl.ID(), sub.inFlight != nil, sub.inFlightBatchID)
+		return false
+	}
+	return true
+}
+
+func (sub *receiptListenerSubscription) waitInFlight(ctx context.Context, timeout time.Duration) {
+	sub.inFlightLock.Lock()
+	inFlight := sub.inFlight
+	sub.inFlightLock.Unlock()
+	if inFlight == nil {
+		return
+	}
+
+	log.L(ctx).Infof("Waiting up to %s for in-flight batch on subscription %s before closing", timeout, sub.ctrl.ID())
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case <-inFlight:
+	case <-timer.C:
+		log.L(ctx).Warnf("Timed out waiting for in-flight batch on subscription %s to be acknowledged", sub.ctrl.ID())
+	case <-ctx.Done():
+	}
+}
+
 func (sub *receiptListenerSubscription) DeliverReceiptBatch(ctx context.Context, batchID uint64, receipts []*pldapi.TransactionReceiptFull) error {
+	if sub.maxBatchSize == 0 {
+		return sub.deliverBatch(ctx, batchID, receipts)
+	}
+
+	// We split the listener's batch into parts no bigger than the subscriber asked for, delivered in order.
+	// Each part needs its own batch ID, so we number the batches on this subscription ourselves (contiguously).
+	// The listener batch only completes once every part is acked, so a nack (or close) of any part
+	// results in redelivery of the whole listener batch - including parts that were already acked.
+	start := 0
+	for {
+		end := min(start+sub.maxBatchSize, len(receipts))
+		sub.lastBatchID++
+		log.L(ctx).Debugf("Receipt batch %d part [%d:%d] sent as batch %d on subscription %s", batchID, start, end, sub.lastBatchID, sub.ctrl.ID())
+		if err := sub.deliverBatch(ctx, sub.lastBatchID, receipts[start:end]); err != nil {
+			return err
+		}
+		if end >= len(receipts) {
+			return nil
+		}
+		start = end
+	}
+}
+
+func (sub *receiptListenerSubscription) deliverBatch(ctx context.Context, batchID uint64, receipts []*pldapi.TransactionReceiptFull) error {
 	log.L(ctx).Infof("Delivering receipt batch %d to subscription %s over JSON/RPC", batchID, sub.ctrl.ID())
 
+	inFlight := make(chan struct{})
+	sub.setInFlight(inFlight, batchID)
+	defer func() {
+		sub.setInFlight(nil, 0)
+		close(inFlight)
+	}()
+
 	// Note we attempt strong consistency with etH_subscribe semantics here, as described in https://geth.ethereum.org/docs/interacting-with-geth/rpc/pubsub
 	// However, we have layered acks on top - so we're not 100%.
 	// We also end up with quite a bit of nesting doing this:
@@ -164,28 +351,62 @@ func (sub *receiptListenerSubscription) DeliverReceiptBatch(ctx context.Context,
 	//       }
 	//     }
 	// }
-	sub.ctrl.Send("ptx_subscription", &pldapi.JSONRPCSubscriptionNotification[pldapi.TransactionReceiptBatch]{
-		Subscription: sub.ctrl.ID(),
-		Result: pldapi.TransactionReceiptBatch{
-			BatchID:  batchID,
-			Receipts: receipts,
-		},
-	})
-	select {
-	case ackNack := <-sub.acksNacks:
-		if !ackNack.ack {
-			log.L(ctx).Warnf("Batch %d negatively acknowledged by subscription %s over JSON/RPC", batchID, sub.ctrl.ID())
-			return i18n.NewError(ctx, msgs.MsgTxMgrJSONRPCSubscriptionNack, sub.ctrl.ID())
+	if sub.projection != nil {
+		projected, err := sub.projection.apply(receipts)
+		if err != nil {
+			return err
+		}
+		sub.ctrl.Send("ptx_subscription", &pldapi.JSONRPCSubscriptionNotification[projectedReceiptBatch]{
+			Subscription: sub.ctrl.ID(),
+			Result: projectedReceiptBatch{
+				BatchID:  batchID,
+				Receipts: projected,
+			},
+		})
+	} else {
+		sub.ctrl.Send("ptx_subscription", &pldapi.JSONRPCSubscriptionNotification[pldapi.TransactionReceiptBatch]{
+			Subscription: sub.ctrl.ID(),
+			Result: pldapi.TransactionReceiptBatch{
+				BatchID:  batchID,
+				Receipts: receipts,
+			},
+		})
+	}
+	for {
+		select {
+		case ackNack := <-sub.acksNacks:
+			// An ack/nack for an earlier batch could have been queued before this batch was in-flight
+			if ackNack.batchID != nil && *ackNack.batchID != batchID {
+				log.L(ctx).Warnf("Ignoring ack/nack for batch %d while awaiting batch %d on subscription %s", *ackNack.batchID, batchID, sub.ctrl.ID())
+				continue
+			}
+			if !ackNack.ack {
+				log.L(ctx).Warnf("Batch %d negatively acknowledged by subscription %s over JSON/RPC", batchID, sub.ctrl.ID())
+				sub.nacks++
+				if sub.es.maxNacks > 0 && sub.nacks >= sub.es.maxNacks {
+					log.L(ctx).Warnf("Closing subscription %s after %d consecutive nacks", sub.ctrl.ID(), sub.nacks)
+					sub.es.closeSubscription(sub, pldapi.PTXSubscriptionCloseReasonNackedOut)
+				}
+				return i18n.NewError(ctx, msgs.MsgTxMgrJSONRPCSubscriptionNack, sub.ctrl.ID())
+			}
+			sub.nacks = 0
+			log.L(ctx).Infof("Batch %d acknowledged by subscription %s over JSON/RPC", batchID, sub.ctrl.ID())
+			return nil
+		case <-sub.closed:
+			return i18n.NewError(ctx, msgs.MsgTxMgrJSONRPCSubscriptionClosed, sub.ctrl.ID())
 		}
-		log.L(ctx).Infof("Batch %d acknowledged by subscription %s over JSON/RPC", batchID, sub.ctrl.ID())
-		return nil
-	case <-sub.closed:
-		return i18n.NewError(ctx, msgs.MsgTxMgrJSONRPCSubscriptionClosed, sub.ctrl.ID())
 	}
 }
 
 func (sub *receiptListenerSubscription) ConnectionClosed() {
-	sub.es.cleanupSubscription(sub.ctrl.ID())
+	es := sub.es
+	es.subLock.Lock()
+	defer es.subLock.Unlock()
+
+	// Only if we have not already been replaced under the same ID
+	if es.receiptSubs[sub.ctrl.ID()] == sub {
+		es.cleanupLocked(sub)
+	}
 }
 
 func (es *rpcEventStreams) cleanupLocked(sub *receiptListenerSubscription) {
@@ -196,11 +417,59 @@ func (es *rpcEventStreams) cleanupLocked(sub *receiptListenerSubscription) {
 	close(sub.closed)
 }
 
+// Best-effort notification to the client of why we are closing the subscription,
+// so it can decide whether to resubscribe
+func (sub *receiptListenerSubscription) sendClosed(reason pldapi.PTXSubscriptionCloseReason) {
+	sub.ctrl.Send("ptx_subscriptionClosed", &pldapi.JSONRPCSubscriptionNotification[pldapi.PTXSubscriptionClosed]{
+		Subscription: sub.ctrl.ID(),
+		Result: pldapi.PTXSubscriptionClosed{
+			Reason: reason,
+		},
+	})
+}
+
+func (es *rpcEventStreams) closeSubscription(sub *receiptListenerSubscription, reason pldapi.PTXSubscriptionCloseReason) {
+	es.subLock.Lock()
+	defer es.subLock.Unlock()
+
+	if es.receiptSubs[sub.ctrl.ID()] == sub {
+		sub.sendClosed(reason)
+		sub.ctrl.Closed()
+		es.cleanupLocked(sub)
+	}
+}
+
+func (es *rpcEventStreams) supersedeLocked(ctx context.Context, listener string) {
+	for _, sub := range es.receiptSubs {
+		if sub.listener == listener {
+			log.L(ctx).Infof("Subscription %s to listener %s superseded by a resuming subscription", sub.ctrl.ID(), listener)
+			sub.sendClosed(pldapi.PTXSubscriptionCloseReasonSuperseded)
+			sub.ctrl.Closed()
+			es.cleanupLocked(sub)
+		}
+	}
+}
+
+// Called after a receipt listener is deleted, as its subscriptions will never receive any more receipts
+func (es *rpcEventStreams) listenerDeleted(name string) {
+	es.subLock.Lock()
+	defer es.subLock.Unlock()
+
+	for _, sub := range es.receiptSubs {
+		if sub.listener == name {
+			sub.sendClosed(pldapi.PTXSubscriptionCloseReasonListenerDeleted)
+			sub.ctrl.Closed()
+			es.cleanupLocked(sub)
+		}
+	}
+}
+
 func (es *rpcEventStreams) stop() {
 	es.subLock.Lock()
 	defer es.subLock.Unlock()
 
 	for _, sub := range es.receiptSubs {
+		sub.sendClosed(pldapi.PTXSubscriptionCloseReasonServerShutdown)
 		es.cleanupLocked(sub)
 	}
 
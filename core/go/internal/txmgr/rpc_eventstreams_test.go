@@ -25,10 +25,12 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/hyperledger/firefly-common/pkg/wsclient"
+	"github.com/kaleido-io/paladin/config/pkg/confutil"
 	"github.com/kaleido-io/paladin/config/pkg/pldconf"
 	"github.com/kaleido-io/paladin/core/internal/components"
 	"github.com/kaleido-io/paladin/core/pkg/persistence"
 	"github.com/kaleido-io/paladin/toolkit/pkg/pldapi"
+	"github.com/kaleido-io/paladin/toolkit/pkg/retry"
 	"github.com/kaleido-io/paladin/toolkit/pkg/rpcclient"
 	"github.com/kaleido-io/paladin/toolkit/pkg/tktypes"
 	"github.com/stretchr/testify/require"
@@ -451,3 +453,727 @@ func TestHandleLifecycleNoBlockNack(t *testing.T) {
 	require.Empty(t, es.receiptSubs)
 
 }
+
+func newTestInFlightSubscription(t *testing.T, es *rpcEventStreams) (*receiptListenerSubscription, chan error) {
+	sub := &receiptListenerSubscription{
+		es:        es,
+		ctrl:      &mockRPCAsyncControl{},
+		acksNacks: make(chan *rpcAckNack, 1),
+		closed:    make(chan struct{}),
+	}
+	es.receiptSubs["sub1"] = sub
+
+	delivered := make(chan error, 1)
+	go func() {
+		delivered <- sub.DeliverReceiptBatch(context.Background(), 12345, []*pldapi.TransactionReceiptFull{})
+	}()
+	require.Eventually(t, func() bool {
+		sub.inFlightLock.Lock()
+		defer sub.inFlightLock.Unlock()
+		return sub.inFlight != nil
+	}, 5*time.Second, 1*time.Millisecond)
+	return sub, delivered
+}
+
+func ackNackRequest(method string, batchID string) *rpcclient.RPCRequest {
+	return &rpcclient.RPCRequest{
+		JSONRpc: "2.0",
+		ID:      tktypes.RawJSON("12345"),
+		Method:  method,
+		Params:  []tktypes.RawJSON{tktypes.RawJSON(`"sub1"`), tktypes.RawJSON(batchID)},
+	}
+}
+
+func TestAckMatchingBatchID(t *testing.T) {
+	ctx, _, txm, done := newTestTransactionManagerWithWebSocketRPC(t)
+	defer done()
+
+	es := txm.rpcEventStreams
+	_, delivered := newTestInFlightSubscription(t, es)
+
+	res := es.HandleLifecycle(ctx, ackNackRequest("ptx_ack", `"12345"`))
+	require.Nil(t, res)
+	require.NoError(t, <-delivered)
+}
+
+func TestAckNackStaleBatchIDIgnored(t *testing.T) {
+	ctx, _, txm, done := newTestTransactionManagerWithWebSocketRPC(t)
+	defer done()
+
+	es := txm.rpcEventStreams
+	sub, delivered := newTestInFlightSubscription(t, es)
+
+	// acks/nacks for an earlier batch are dropped, and do not take the place of the real ack
+	require.Nil(t, es.HandleLifecycle(ctx, ackNackRequest("ptx_nack", `12344`)))
+	require.Nil(t, es.HandleLifecycle(ctx, ackNackRequest("ptx_ack", `12344`)))
+	require.Empty(t, sub.acksNacks)
+
+	// a stale nack already queued when the batch was delivered is also ignored
+	sub.acksNacks <- &rpcAckNack{ack: false, batchID: confutil.P(uint64(12344))}
+	require.Eventually(t, func() bool { return len(sub.acksNacks) == 0 }, 5*time.Second, 1*time.Millisecond)
+	select {
+	case err := <-delivered:
+		require.Fail(t, "batch completed by stale nack", err)
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	require.Nil(t, es.HandleLifecycle(ctx, ackNackRequest("ptx_ack", `12345`)))
+	require.NoError(t, <-delivered)
+
+	// nothing in-flight now, so even the previous batch ID is ignored
+	require.Nil(t, es.HandleLifecycle(ctx, ackNackRequest("ptx_ack", `12345`)))
+	require.Empty(t, sub.acksNacks)
+}
+
+func TestAckBadBatchID(t *testing.T) {
+	ctx, _, txm, done := newTestTransactionManagerWithWebSocketRPC(t)
+	defer done()
+
+	res := txm.rpcEventStreams.HandleLifecycle(ctx, ackNackRequest("ptx_ack", `"wrong"`))
+	require.Regexp(t, "PD012246", res.Error.Error())
+}
+
+func unsubscribeRequest() *rpcclient.RPCRequest {
+	return &rpcclient.RPCRequest{
+		JSONRpc: "2.0",
+		ID:      tktypes.RawJSON("12345"),
+		Method:  "ptx_unsubscribe",
+		Params:  []tktypes.RawJSON{tktypes.RawJSON(`"sub1"`)},
+	}
+}
+
+func TestUnsubscribeGracefulWaitsForInFlightAck(t *testing.T) {
+	ctx, _, txm, done := newTestTransactionManagerWithWebSocketRPC(t)
+	defer done()
+
+	es := txm.rpcEventStreams
+	sub, delivered := newTestInFlightSubscription(t, es)
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		sub.acksNacks <- &rpcAckNack{ack: true}
+	}()
+	res := es.HandleLifecycle(ctx, unsubscribeRequest())
+	require.Equal(t, `true`, res.Result.String())
+
+	require.NoError(t, <-delivered)
+	require.Empty(t, es.receiptSubs)
+}
+
+func TestUnsubscribeGracefulTimeout(t *testing.T) {
+	ctx, _, txm, done := newTestTransactionManagerWithWebSocketRPC(t)
+	defer done()
+
+	es := txm.rpcEventStreams
+	es.gracefulCloseTimeout = 1 * time.Millisecond
+	_, delivered := newTestInFlightSubscription(t, es)
+
+	res := es.HandleLifecycle(ctx, unsubscribeRequest())
+	require.Equal(t, `true`, res.Result.String())
+
+	require.Regexp(t, "PD012242", <-delivered)
+	require.Empty(t, es.receiptSubs)
+}
+
+func TestConnectionClosedForcesImmediateClose(t *testing.T) {
+	_, _, txm, done := newTestTransactionManagerWithWebSocketRPC(t)
+	defer done()
+
+	es := txm.rpcEventStreams
+	es.gracefulCloseTimeout = 1 * time.Hour // would hang the test if used
+	sub, delivered := newTestInFlightSubscription(t, es)
+
+	sub.ConnectionClosed()
+
+	require.Regexp(t, "PD012242", <-delivered)
+	require.Empty(t, es.receiptSubs)
+}
+
+type recordingRPCAsyncControl struct {
+	id           string
+	sent         chan *pldapi.TransactionReceiptBatch
+	projected    chan *projectedReceiptBatch
+	closeReasons []pldapi.PTXSubscriptionCloseReason
+	closed       bool
+}
+
+func (ac *recordingRPCAsyncControl) ID() string {
+	if ac.id == "" {
+		return "sub1"
+	}
+	return ac.id
+}
+
+func (ac *recordingRPCAsyncControl) Closed() { ac.closed = true }
+
+func (ac *recordingRPCAsyncControl) Send(method string, params any) {
+	switch method {
+	case "ptx_subscriptionClosed":
+		ac.closeReasons = append(ac.closeReasons, params.(*pldapi.JSONRPCSubscriptionNotification[pldapi.PTXSubscriptionClosed]).Result.Reason)
+	default:
+		switch n := params.(type) {
+		case *pldapi.JSONRPCSubscriptionNotification[projectedReceiptBatch]:
+			ac.projected <- &n.Result
+		default:
+			ac.sent <- &params.(*pldapi.JSONRPCSubscriptionNotification[pldapi.TransactionReceiptBatch]).Result
+		}
+	}
+}
+
+func testReceipts(count int) []*pldapi.TransactionReceiptFull {
+	receipts := make([]*pldapi.TransactionReceiptFull, count)
+	for i := range receipts {
+		receipts[i] = &pldapi.TransactionReceiptFull{
+			TransactionReceipt: &pldapi.TransactionReceipt{ID: uuid.New()},
+		}
+	}
+	return receipts
+}
+
+func TestSubscribeMaxBatchSizeOptions(t *testing.T) {
+	ctx, _, txm, done := newTestTransactionManagerWithWebSocketRPC(t)
+	defer done()
+
+	err := txm.CreateReceiptListener(ctx, &pldapi.TransactionReceiptListener{
+		Name: "listener1",
+	})
+	require.NoError(t, err)
+	txm.receiptsReadPageSize = 10
+
+	es := txm.rpcEventStreams
+	subscribe := func(options string) (*receiptListenerSubscription, *rpcclient.RPCResponse) {
+		inst, res := es.HandleStart(ctx, &rpcclient.RPCRequest{
+			JSONRpc: "2.0",
+			ID:      tktypes.RawJSON("12345"),
+			Method:  "ptx_subscribe",
+			Params:  []tktypes.RawJSON{tktypes.RawJSON(`"receipts"`), tktypes.RawJSON(`"listener1"`), tktypes.RawJSON(options)},
+		}, &mockRPCAsyncControl{})
+		if inst == nil {
+			return nil, res
+		}
+		sub := inst.(*receiptListenerSubscription)
+		defer es.cleanupSubscription(sub.ctrl.ID())
+		return sub, res
+	}
+
+	sub, _ := subscribe(`{"maxBatchSize": 3}`)
+	require.Equal(t, 3, sub.maxBatchSize)
+
+	// larger than the listener will ever deliver, so no splitting is needed
+	sub, _ = subscribe(`{"maxBatchSize": 50}`)
+	require.Zero(t, sub.maxBatchSize)
+
+	sub, _ = subscribe(`{}`)
+	require.Zero(t, sub.maxBatchSize)
+
+	_, res := subscribe(`{"maxBatchSize": 0}`)
+	require.Regexp(t, "PD012245", res.Error.Error())
+
+	_, res = subscribe(`"wrong"`)
+	require.Regexp(t, "PD012244", res.Error.Error())
+}
+
+func TestSubscribeBatchTimeoutOptions(t *testing.T) {
+	ctx, _, txm, done := newTestTransactionManagerWithWebSocketRPC(t)
+	defer done()
+
+	err := txm.CreateReceiptListener(ctx, &pldapi.TransactionReceiptListener{
+		Name: "listener1",
+	})
+	require.NoError(t, err)
+
+	es := txm.rpcEventStreams
+	subscribe := func(options string) (*receiptListenerSubscription, *rpcclient.RPCResponse) {
+		inst, res := es.HandleStart(ctx, &rpcclient.RPCRequest{
+			JSONRpc: "2.0",
+			ID:      tktypes.RawJSON("12345"),
+			Method:  "ptx_subscribe",
+			Params:  []tktypes.RawJSON{tktypes.RawJSON(`"receipts"`), tktypes.RawJSON(`"listener1"`), tktypes.RawJSON(options)},
+		}, &mockRPCAsyncControl{})
+		if inst == nil {
+			return nil, res
+		}
+		sub := inst.(*receiptListenerSubscription)
+		defer es.cleanupSubscription(sub.ctrl.ID())
+		return sub, res
+	}
+
+	sub, _ := subscribe(`{"batchTimeout": "250ms"}`)
+	require.Equal(t, 250*time.Millisecond, sub.receiptBatchTimeout())
+
+	sub, _ = subscribe(`{}`)
+	require.Zero(t, sub.receiptBatchTimeout())
+
+	_, res := subscribe(`{"batchTimeout": "forever"}`)
+	require.Regexp(t, "PD012247.*forever", res.Error.Error())
+
+	_, res = subscribe(`{"batchTimeout": "-1s"}`)
+	require.Regexp(t, "PD012247", res.Error.Error())
+}
+
+func TestDeliverReceiptBatchSplitsToMaxBatchSize(t *testing.T) {
+	_, _, txm, done := newTestTransactionManagerWithWebSocketRPC(t)
+	defer done()
+
+	ctrl := &recordingRPCAsyncControl{sent: make(chan *pldapi.TransactionReceiptBatch)}
+	sub := &receiptListenerSubscription{
+		es:           txm.rpcEventStreams,
+		ctrl:         ctrl,
+		acksNacks:    make(chan *rpcAckNack, 1),
+		closed:       make(chan struct{}),
+		maxBatchSize: 2,
+	}
+
+	var received []*pldapi.TransactionReceiptBatch
+	go func() {
+		for batch := range ctrl.sent {
+			received = append(received, batch)
+			sub.acksNacks <- &rpcAckNack{ack: true}
+		}
+	}()
+
+	receipts := testReceipts(5)
+	require.NoError(t, sub.DeliverReceiptBatch(context.Background(), 1000, receipts))
+	moreReceipts := testReceipts(2)
+	require.NoError(t, sub.DeliverReceiptBatch(context.Background(), 1001, moreReceipts))
+	close(ctrl.sent)
+
+	require.Len(t, received, 4)
+	for i, batch := range received {
+		require.Equal(t, uint64(i+1), batch.BatchID)
+		require.LessOrEqual(t, len(batch.Receipts), 2)
+	}
+	var flattened []*pldapi.TransactionReceiptFull
+	for _, batch := range received {
+		flattened = append(flattened, batch.Receipts...)
+	}
+	require.Equal(t, append(receipts, moreReceipts...), flattened)
+}
+
+func TestDeliverReceiptBatchSplitNackStopsDelivery(t *testing.T) {
+	_, _, txm, done := newTestTransactionManagerWithWebSocketRPC(t)
+	defer done()
+
+	ctrl := &recordingRPCAsyncControl{sent: make(chan *pldapi.TransactionReceiptBatch, 3)}
+	sub := &receiptListenerSubscription{
+		es:           txm.rpcEventStreams,
+		ctrl:         ctrl,
+		acksNacks:    make(chan *rpcAckNack, 1),
+		closed:       make(chan struct{}),
+		maxBatchSize: 1,
+	}
+
+	go func() {
+		<-ctrl.sent
+		sub.acksNacks <- &rpcAckNack{ack: true}
+		<-ctrl.sent
+		sub.acksNacks <- &rpcAckNack{ack: false}
+	}()
+
+	err := sub.DeliverReceiptBatch(context.Background(), 1000, testReceipts(3))
+	require.Regexp(t, "PD012243", err)
+	require.Empty(t, ctrl.sent)
+	require.Equal(t, uint64(2), sub.lastBatchID)
+}
+
+func TestSubscribeFieldsOptions(t *testing.T) {
+	ctx, _, txm, done := newTestTransactionManagerWithWebSocketRPC(t)
+	defer done()
+
+	err := txm.CreateReceiptListener(ctx, &pldapi.TransactionReceiptListener{
+		Name: "listener1",
+	})
+	require.NoError(t, err)
+
+	es := txm.rpcEventStreams
+	subscribe := func(options string) (*receiptListenerSubscription, *rpcclient.RPCResponse) {
+		inst, res := es.HandleStart(ctx, &rpcclient.RPCRequest{
+			JSONRpc: "2.0",
+			ID:      tktypes.RawJSON("12345"),
+			Method:  "ptx_subscribe",
+			Params:  []tktypes.RawJSON{tktypes.RawJSON(`"receipts"`), tktypes.RawJSON(`"listener1"`), tktypes.RawJSON(options)},
+		}, &mockRPCAsyncControl{})
+		if inst == nil {
+			return nil, res
+		}
+		sub := inst.(*receiptListenerSubscription)
+		defer es.cleanupSubscription(sub.ctrl.ID())
+		return sub, res
+	}
+
+	// fields of embedded structs are at the top level, and nested fields are selected with dots
+	sub, _ := subscribe(`{"fields": ["id", "success", "transactionHash", "states.confirmed"]}`)
+	require.Equal(t, receiptProjection{{"id"}, {"success"}, {"transactionHash"}, {"states", "confirmed"}}, sub.projection)
+
+	sub, _ = subscribe(`{}`)
+	require.Nil(t, sub.projection)
+
+	_, res := subscribe(`{"fields": ["id", "wrong"]}`)
+	require.Regexp(t, "PD012248.*wrong", res.Error.Error())
+
+	_, res = subscribe(`{"fields": ["transactionHash.wrong"]}`)
+	require.Regexp(t, "PD012248.*transactionHash.wrong", res.Error.Error())
+
+	_, res = subscribe(`{"fields": ["TransactionReceipt"]}`)
+	require.Regexp(t, "PD012248", res.Error.Error())
+}
+
+func TestDeliverReceiptBatchProjected(t *testing.T) {
+	ctx, _, txm, done := newTestTransactionManagerWithWebSocketRPC(t)
+	defer done()
+
+	ctrl := &recordingRPCAsyncControl{projected: make(chan *projectedReceiptBatch, 1)}
+	projection, err := newReceiptProjection(ctx, []string{"id", "transactionHash", "states.confirmed", "domain"})
+	require.NoError(t, err)
+	sub := &receiptListenerSubscription{
+		es:         txm.rpcEventStreams,
+		ctrl:       ctrl,
+		acksNacks:  make(chan *rpcAckNack, 1),
+		closed:     make(chan struct{}),
+		projection: projection,
+	}
+
+	txID := uuid.New()
+	txHash := tktypes.RandBytes32()
+	stateID := tktypes.HexBytes(tktypes.RandBytes(32))
+	receipts := []*pldapi.TransactionReceiptFull{{
+		TransactionReceipt: &pldapi.TransactionReceipt{
+			ID: txID,
+			TransactionReceiptData: pldapi.TransactionReceiptData{
+				Sequence:                      12345,
+				Success:                       true,
+				TransactionReceiptDataOnchain: &pldapi.TransactionReceiptDataOnchain{TransactionHash: &txHash, BlockNumber: 10},
+			},
+		},
+		States: &pldapi.TransactionStates{
+			Confirmed: []*pldapi.StateBase{{ID: stateID}},
+			Spent:     []*pldapi.StateBase{{ID: tktypes.RandBytes(32)}},
+		},
+		DomainReceipt: tktypes.RawJSON(`{"big":"receipt"}`),
+	}}
+
+	sub.acksNacks <- &rpcAckNack{ack: true}
+	require.NoError(t, sub.DeliverReceiptBatch(ctx, 1000, receipts))
+
+	batch := <-ctrl.projected
+	require.Equal(t, uint64(1000), batch.BatchID)
+	require.Len(t, batch.Receipts, 1)
+	// domain is not set on this receipt, so is omitted
+	projected := batch.Receipts[0]
+	require.Len(t, projected, 3)
+	require.Equal(t, txID.String(), projected["id"])
+	require.Equal(t, txHash.String(), projected["transactionHash"])
+	states := projected["states"].(map[string]any)
+	require.Len(t, states, 1)
+	confirmed := states["confirmed"].([]any)
+	require.Len(t, confirmed, 1)
+	require.Equal(t, stateID.String(), confirmed[0].(map[string]any)["id"])
+}
+
+func TestSubscribeDuplicateIDClosesExisting(t *testing.T) {
+	ctx, _, txm, done := newTestTransactionManagerWithWebSocketRPC(t)
+	defer done()
+
+	err := txm.CreateReceiptListener(ctx, &pldapi.TransactionReceiptListener{
+		Name: "listener1",
+	})
+	require.NoError(t, err)
+
+	es := txm.rpcEventStreams
+	subscribe := func() *receiptListenerSubscription {
+		inst, res := es.HandleStart(ctx, &rpcclient.RPCRequest{
+			JSONRpc: "2.0",
+			ID:      tktypes.RawJSON("12345"),
+			Method:  "ptx_subscribe",
+			Params:  []tktypes.RawJSON{tktypes.RawJSON(`"receipts"`), tktypes.RawJSON(`"listener1"`)},
+		}, &mockRPCAsyncControl{}) // always returns the same ID
+		require.Nil(t, res.Error)
+		return inst.(*receiptListenerSubscription)
+	}
+	receiverCount := func() int {
+		l := txm.receiptListeners["listener1"]
+		l.receiverLock.Lock()
+		defer l.receiverLock.Unlock()
+		return len(l.receivers)
+	}
+
+	sub1 := subscribe()
+	require.Equal(t, 1, receiverCount())
+
+	sub2 := subscribe()
+	require.Equal(t, 1, receiverCount())
+	select {
+	case <-sub1.closed:
+	default:
+		require.Fail(t, "existing subscription not closed")
+	}
+	require.Same(t, sub2, es.getSubscription("sub1"))
+
+	// A late close of the replaced subscription must not affect the new one
+	sub1.ConnectionClosed()
+	require.Same(t, sub2, es.getSubscription("sub1"))
+	require.Equal(t, 1, receiverCount())
+
+	sub2.ConnectionClosed()
+	require.Empty(t, es.receiptSubs)
+	require.Zero(t, receiverCount())
+}
+
+func TestSubscriptionClosedServerShutdown(t *testing.T) {
+	ctx, _, txm, done := newTestTransactionManagerWithWebSocketRPC(t)
+	defer done()
+
+	err := txm.CreateReceiptListener(ctx, &pldapi.TransactionReceiptListener{
+		Name: "listener1",
+	})
+	require.NoError(t, err)
+
+	es := txm.rpcEventStreams
+	ctrl := &recordingRPCAsyncControl{}
+	_, res := es.HandleStart(ctx, &rpcclient.RPCRequest{
+		JSONRpc: "2.0",
+		ID:      tktypes.RawJSON("12345"),
+		Method:  "ptx_subscribe",
+		Params:  []tktypes.RawJSON{tktypes.RawJSON(`"receipts"`), tktypes.RawJSON(`"listener1"`)},
+	}, ctrl)
+	require.Nil(t, res.Error)
+
+	es.stop()
+	require.Equal(t, []pldapi.PTXSubscriptionCloseReason{pldapi.PTXSubscriptionCloseReasonServerShutdown}, ctrl.closeReasons)
+	require.Empty(t, es.receiptSubs)
+}
+
+func TestSubscriptionClosedListenerDeleted(t *testing.T) {
+	ctx, _, txm, done := newTestTransactionManagerWithWebSocketRPC(t)
+	defer done()
+
+	es := txm.rpcEventStreams
+	subscribe := func(listener string) *recordingRPCAsyncControl {
+		err := txm.CreateReceiptListener(ctx, &pldapi.TransactionReceiptListener{
+			Name: listener,
+		})
+		require.NoError(t, err)
+		ctrl := &recordingRPCAsyncControl{id: listener + "_sub"}
+		_, res := es.HandleStart(ctx, &rpcclient.RPCRequest{
+			JSONRpc: "2.0",
+			ID:      tktypes.RawJSON("12345"),
+			Method:  "ptx_subscribe",
+			Params:  []tktypes.RawJSON{tktypes.RawJSON(`"receipts"`), tktypes.JSONString(listener)},
+		}, ctrl)
+		require.Nil(t, res.Error)
+		return ctrl
+	}
+	ctrl1 := subscribe("listener1")
+	ctrl2 := subscribe("listener2")
+
+	err := txm.DeleteReceiptListener(ctx, "listener1")
+	require.NoError(t, err)
+
+	require.Equal(t, []pldapi.PTXSubscriptionCloseReason{pldapi.PTXSubscriptionCloseReasonListenerDeleted}, ctrl1.closeReasons)
+	require.True(t, ctrl1.closed)
+	require.Nil(t, es.getSubscription("listener1_sub"))
+
+	require.Empty(t, ctrl2.closeReasons)
+	require.False(t, ctrl2.closed)
+	require.NotNil(t, es.getSubscription("listener2_sub"))
+}
+
+func TestSubscriptionClosedNackedOut(t *testing.T) {
+	_, _, txm, done := newTestTransactionManagerWithWebSocketRPC(t)
+	defer done()
+
+	es := txm.rpcEventStreams
+	es.maxNacks = 2
+	ctrl := &recordingRPCAsyncControl{sent: make(chan *pldapi.TransactionReceiptBatch, 1)}
+	sub := &receiptListenerSubscription{
+		es:        es,
+		ctrl:      ctrl,
+		acksNacks: make(chan *rpcAckNack, 1),
+		closed:    make(chan struct{}),
+	}
+	es.receiptSubs["sub1"] = sub
+
+	deliver := func(ack bool) error {
+		sub.acksNacks <- &rpcAckNack{ack: ack}
+		err := sub.DeliverReceiptBatch(context.Background(), 12345, testReceipts(1))
+		<-ctrl.sent
+		return err
+	}
+
+	// an ack resets the count
+	require.Regexp(t, "PD012243", deliver(false))
+	require.NoError(t, deliver(true))
+	require.Regexp(t, "PD012243", deliver(false))
+	require.Empty(t, ctrl.closeReasons)
+	require.NotNil(t, es.getSubscription("sub1"))
+
+	require.Regexp(t, "PD012243", deliver(false))
+	require.Equal(t, []pldapi.PTXSubscriptionCloseReason{pldapi.PTXSubscriptionCloseReasonNackedOut}, ctrl.closeReasons)
+	require.True(t, ctrl.closed)
+	require.Nil(t, es.getSubscription("sub1"))
+}
+
+func TestGetSubscriptionStatus(t *testing.T) {
+	ctx, _, txm, done := newTestTransactionManagerWithWebSocketRPC(t)
+	defer done()
+
+	es := txm.rpcEventStreams
+	subscribe := func(listener string) *receiptListenerSubscription {
+		err := txm.CreateReceiptListener(ctx, &pldapi.TransactionReceiptListener{
+			Name: listener,
+		})
+		require.NoError(t, err)
+		inst, res := es.HandleStart(ctx, &rpcclient.RPCRequest{
+			JSONRpc: "2.0",
+			ID:      tktypes.RawJSON("12345"),
+			Method:  "ptx_subscribe",
+			Params:  []tktypes.RawJSON{tktypes.RawJSON(`"receipts"`), tktypes.JSONString(listener)},
+		}, &recordingRPCAsyncControl{id: listener + "_sub"})
+		require.Nil(t, res.Error)
+		return inst.(*receiptListenerSubscription)
+	}
+	require.Empty(t, es.getSubscriptionStatus())
+
+	sub1 := subscribe("listener1")
+	sub2 := subscribe("listener2")
+	sub2.setInFlight(make(chan struct{}), 12345)
+
+	statuses := es.getSubscriptionStatus()
+	require.Len(t, statuses, 2)
+	require.Equal(t, "listener1_sub", statuses[0].ID)
+	require.Equal(t, "listener1", statuses[0].Listener)
+	require.Equal(t, tktypes.Timestamp(sub1.created.UnixNano()), statuses[0].Created)
+	require.NotEmpty(t, statuses[0].Age)
+	require.Nil(t, statuses[0].InFlightBatchID)
+	require.Empty(t, statuses[0].AckLag)
+	require.Equal(t, "listener2_sub", statuses[1].ID)
+	require.Equal(t, "listener2", statuses[1].Listener)
+	require.Equal(t, uint64(12345), *statuses[1].InFlightBatchID)
+	require.NotEmpty(t, statuses[1].AckLag)
+
+	// once the connection is gone the subscription is no longer reported
+	sub1.ConnectionClosed()
+	statuses = es.getSubscriptionStatus()
+	require.Len(t, statuses, 1)
+	require.Equal(t, "listener2_sub", statuses[0].ID)
+}
+
+func TestSubscribeResumeSupersedesExisting(t *testing.T) {
+	ctx, _, txm, done := newTestTransactionManagerWithWebSocketRPC(t)
+	defer done()
+
+	err := txm.CreateReceiptListener(ctx, &pldapi.TransactionReceiptListener{
+		Name: "listener1",
+	})
+	require.NoError(t, err)
+
+	es := txm.rpcEventStreams
+	subscribe := func(id string, options string) *recordingRPCAsyncControl {
+		ctrl := &recordingRPCAsyncControl{id: id}
+		_, res := es.HandleStart(ctx, &rpcclient.RPCRequest{
+			JSONRpc: "2.0",
+			ID:      tktypes.RawJSON("12345"),
+			Method:  "ptx_subscribe",
+			Params:  []tktypes.RawJSON{tktypes.RawJSON(`"receipts"`), tktypes.RawJSON(`"listener1"`), tktypes.RawJSON(options)},
+		}, ctrl)
+		require.Nil(t, res.Error)
+		return ctrl
+	}
+	receiverCount := func() int {
+		l := txm.receiptListeners["listener1"]
+		l.receiverLock.Lock()
+		defer l.receiverLock.Unlock()
+		return len(l.receivers)
+	}
+
+	// Without resume, subscriptions share the listener
+	ctrl1 := subscribe("sub1", `{}`)
+	ctrl2 := subscribe("sub2", `{}`)
+	require.Equal(t, 2, receiverCount())
+
+	// A resuming subscription takes it over
+	ctrl3 := subscribe("sub3", `{"resume":true}`)
+	require.Equal(t, 1, receiverCount())
+	for _, ctrl := range []*recordingRPCAsyncControl{ctrl1, ctrl2} {
+		require.Equal(t, []pldapi.PTXSubscriptionCloseReason{pldapi.PTXSubscriptionCloseReasonSuperseded}, ctrl.closeReasons)
+		require.True(t, ctrl.closed)
+	}
+	require.Nil(t, es.getSubscription("sub1"))
+	require.Nil(t, es.getSubscription("sub2"))
+	require.NotNil(t, es.getSubscription("sub3"))
+
+	// As does the next one to resume
+	subscribe("sub4", `{"resume":true}`)
+	require.Equal(t, 1, receiverCount())
+	require.Equal(t, []pldapi.PTXSubscriptionCloseReason{pldapi.PTXSubscriptionCloseReasonSuperseded}, ctrl3.closeReasons)
+	require.NotNil(t, es.getSubscription("sub4"))
+}
+
+func TestSubscribeResumeRedeliversUnackedBatch(t *testing.T) {
+	ctx, _, txm, done := newTestTransactionManagerWithWebSocketRPC(t)
+	defer done()
+
+	// Redelivery after a failure would not happen within the test, unless resumed
+	txm.receiptsRetry = retry.NewRetryIndefinite(&pldconf.RetryConfig{
+		InitialDelay: confutil.P("1h"),
+		MaxDelay:     confutil.P("1h"),
+	})
+
+	err := txm.CreateReceiptListener(ctx, &pldapi.TransactionReceiptListener{
+		Name: "listener1",
+	})
+	require.NoError(t, err)
+
+	es := txm.rpcEventStreams
+	subscribe := func(id string, options string) (*receiptListenerSubscription, *recordingRPCAsyncControl) {
+		ctrl := &recordingRPCAsyncControl{id: id, sent: make(chan *pldapi.TransactionReceiptBatch, 1)}
+		inst, res := es.HandleStart(ctx, &rpcclient.RPCRequest{
+			JSONRpc: "2.0",
+			ID:      tktypes.RawJSON("12345"),
+			Method:  "ptx_subscribe",
+			Params:  []tktypes.RawJSON{tktypes.RawJSON(`"receipts"`), tktypes.RawJSON(`"listener1"`), tktypes.RawJSON(options)},
+		}, ctrl)
+		require.Nil(t, res.Error)
+		return inst.(*receiptListenerSubscription), ctrl
+	}
+
+	sub1, ctrl1 := subscribe("sub1", `{}`)
+
+	txID := uuid.New()
+	err = txm.p.Transaction(ctx, func(ctx context.Context, dbTX persistence.DBTX) error {
+		return txm.FinalizeTransactions(ctx, dbTX, []*components.ReceiptInput{
+			{
+				ReceiptType:   components.RT_Success,
+				TransactionID: txID,
+				OnChain:       randOnChain(tktypes.RandAddress()),
+			},
+		})
+	})
+	require.NoError(t, err)
+
+	// The batch is delivered, but the connection drops before it is acked
+	batch := <-ctrl1.sent
+	require.Len(t, batch.Receipts, 1)
+	require.Equal(t, txID, batch.Receipts[0].ID)
+	sub1.ConnectionClosed()
+
+	// The reconnecting client resumes, and immediately gets the same batch
+	sub2, ctrl2 := subscribe("sub2", `{"resume":true}`)
+	redelivered := <-ctrl2.sent
+	require.Equal(t, batch.BatchID, redelivered.BatchID)
+	require.Len(t, redelivered.Receipts, 1)
+	require.Equal(t, txID, redelivered.Receipts[0].ID)
+
+	res := es.HandleLifecycle(ctx, &rpcclient.RPCRequest{
+		JSONRpc: "2.0",
+		ID:      tktypes.RawJSON("12345"),
+		Method:  "ptx_ack",
+		Params:  []tktypes.RawJSON{tktypes.RawJSON(`"sub2"`)},
+	})
+	require.Nil(t, res)
+	require.Eventually(t, func() bool {
+		sub2.inFlightLock.Lock()
+		defer sub2.inFlightLock.Unlock()
+		return sub2.inFlight == nil
+	}, 5*time.Second, 1*time.Millisecond)
+}
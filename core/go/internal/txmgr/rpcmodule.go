@@ -49,6 +49,8 @@ func (tm *txManager) buildRPCModule() {
 		Add("ptx_queryPublicTransactions", tm.rpcQueryPublicTransactions()).
 		Add("ptx_queryPendingPublicTransactions", tm.rpcQueryPendingPublicTransactions()).
 		Add("ptx_getPublicTransactionByNonce", tm.rpcGetPublicTransactionByNonce()).
+		Add("ptx_queryGasPriceHistory", tm.rpcQueryGasPriceHistory()).
+		Add("ptx_engineStatus", tm.rpcEngineStatus()).
 		Add("ptx_getPublicTransactionByHash", tm.rpcGetPublicTransactionByHash()).
 		Add("ptx_getPreparedTransaction", tm.rpcGetPreparedTransaction()).
 		Add("ptx_queryPreparedTransactions", tm.rpcQueryPreparedTransactions()).
@@ -65,10 +67,13 @@ func (tm *txManager) buildRPCModule() {
 		Add("ptx_startReceiptListener", tm.rpcStartReceiptListener()).
 		Add("ptx_stopReceiptListener", tm.rpcStopReceiptListener()).
 		Add("ptx_deleteReceiptListener", tm.rpcDeleteReceiptListener()).
+		Add("ptx_getReceiptSubscriptions", tm.rpcGetReceiptSubscriptions()).
 		AddAsync(tm.rpcEventStreams)
 
 	tm.debugRpcModule = rpcserver.NewRPCModule("debug").
-		Add("debug_getTransactionStatus", tm.rpcDebugTransactionStatus())
+		Add("debug_getTransactionStatus", tm.rpcDebugTransactionStatus()).
+		Add("debug_getDependencyGraph", tm.rpcDebugDependencyGraph()).
+		Add("debug_getDependencyGraphDOT", tm.rpcDebugDependencyGraphDOT())
 }
 
 func (tm *txManager) rpcSendTransaction() rpcserver.RPCHandler {
@@ -254,6 +259,22 @@ func (tm *txManager) rpcGetPublicTransactionByNonce() rpcserver.RPCHandler {
 	})
 }
 
+func (tm *txManager) rpcQueryGasPriceHistory() rpcserver.RPCHandler {
+	return rpcserver.RPCMethod3(func(ctx context.Context,
+		start tktypes.Timestamp,
+		end tktypes.Timestamp,
+		bucket string,
+	) ([]*pldapi.GasPriceHistoryEntry, error) {
+		return tm.QueryGasPriceHistory(ctx, start, end, bucket)
+	})
+}
+
+func (tm *txManager) rpcEngineStatus() rpcserver.RPCHandler {
+	return rpcserver.RPCMethod0(func(ctx context.Context) (*pldapi.PublicTxEngineStatus, error) {
+		return tm.publicTxMgr.GetEngineStatus(ctx), nil
+	})
+}
+
 func (tm *txManager) rpcGetPublicTransactionByHash() rpcserver.RPCHandler {
 	return rpcserver.RPCMethod1(func(ctx context.Context,
 		hash tktypes.Bytes32,
@@ -305,6 +326,22 @@ func (tm *txManager) rpcDebugTransactionStatus() rpcserver.RPCHandler {
 	})
 }
 
+func (tm *txManager) rpcDebugDependencyGraph() rpcserver.RPCHandler {
+	return rpcserver.RPCMethod1(func(ctx context.Context,
+		contractAddress string,
+	) (*components.PrivateTxDependencyGraph, error) {
+		return tm.privateTxMgr.GetTxDependencyGraph(ctx, contractAddress)
+	})
+}
+
+func (tm *txManager) rpcDebugDependencyGraphDOT() rpcserver.RPCHandler {
+	return rpcserver.RPCMethod1(func(ctx context.Context,
+		contractAddress string,
+	) (string, error) {
+		return tm.privateTxMgr.GetTxDependencyGraphDOT(ctx, contractAddress)
+	})
+}
+
 func (tm *txManager) rpcDecodeError() rpcserver.RPCHandler {
 	return rpcserver.RPCMethod2(func(ctx context.Context,
 		revertError tktypes.HexBytes,
@@ -381,3 +418,9 @@ func (tm *txManager) rpcDeleteReceiptListener() rpcserver.RPCHandler {
 		return true, tm.DeleteReceiptListener(ctx, name)
 	})
 }
+
+func (tm *txManager) rpcGetReceiptSubscriptions() rpcserver.RPCHandler {
+	return rpcserver.RPCMethod0(func(ctx context.Context) ([]*pldapi.TransactionReceiptSubscriptionStatus, error) {
+		return tm.rpcEventStreams.getSubscriptionStatus(), nil
+	})
+}
@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"math/big"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/hyperledger/firefly-signer/pkg/abi"
@@ -569,6 +570,42 @@ func TestDebugTransactionStatus(t *testing.T) {
 
 }
 
+func TestDebugDependencyGraph(t *testing.T) {
+
+	contractAddress := tktypes.RandAddress()
+	graph := &components.PrivateTxDependencyGraph{
+		Transactions: []*components.PrivateTxGraphNode{
+			{TxID: "tx0", Status: "pending", Endorsed: true},
+			{TxID: "tx1", Status: "pending"},
+		},
+		Dependencies: []*components.PrivateTxGraphEdge{
+			{Dependency: "tx0", Dependant: "tx1", States: []string{"s0"}},
+		},
+	}
+
+	ctx, url, _, done := newTestTransactionManagerWithRPC(t,
+		func(tmc *pldconf.TxManagerConfig, mc *mockComponents) {
+			mc.privateTxMgr.On("GetTxDependencyGraph", mock.Anything, contractAddress.String()).Return(graph, nil)
+			mc.privateTxMgr.On("GetTxDependencyGraphDOT", mock.Anything, contractAddress.String()).Return("digraph dependencies {}", nil)
+		},
+	)
+	defer done()
+
+	rpcClient, err := rpcclient.NewHTTPClient(ctx, &pldconf.HTTPClientConfig{URL: url})
+	require.NoError(t, err)
+
+	var result *components.PrivateTxDependencyGraph
+	err = rpcClient.CallRPC(ctx, &result, "debug_getDependencyGraph", contractAddress.String())
+	require.NoError(t, err)
+	assert.Equal(t, graph, result)
+
+	var dot string
+	err = rpcClient.CallRPC(ctx, &dot, "debug_getDependencyGraphDOT", contractAddress.String())
+	require.NoError(t, err)
+	assert.Equal(t, "digraph dependencies {}", dot)
+
+}
+
 func TestQueryPreparedTransactionsNotFound(t *testing.T) {
 
 	ctx, url, _, done := newTestTransactionManagerWithRPC(t)
@@ -744,3 +781,56 @@ func TestRCPReceiptListenersCRUDRealDB(t *testing.T) {
 	require.NotNil(t, txm.receiptListeners["listener1"].done)
 
 }
+
+func TestQueryGasPriceHistoryRPC(t *testing.T) {
+	start := tktypes.TimestampNow()
+	end := start + tktypes.Timestamp(time.Hour)
+	ctx, url, _, done := newTestTransactionManagerWithRPC(t, func(tmc *pldconf.TxManagerConfig, mc *mockComponents) {
+		mc.publicTxMgr.On("QueryGasPriceHistory", mock.Anything, mock.Anything, start, end, 10*time.Minute).Return([]*pldapi.GasPriceHistoryEntry{
+			{Start: start, End: start + tktypes.Timestamp(10*time.Minute), Samples: 1},
+		}, nil)
+	})
+	defer done()
+
+	rpcClient, err := rpcclient.NewHTTPClient(ctx, &pldconf.HTTPClientConfig{URL: url})
+	require.NoError(t, err)
+
+	var entries []*pldapi.GasPriceHistoryEntry
+	err = rpcClient.CallRPC(ctx, &entries, "ptx_queryGasPriceHistory", start, end, "10m")
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, 1, entries[0].Samples)
+
+	err = rpcClient.CallRPC(ctx, &entries, "ptx_queryGasPriceHistory", start, end, "wrong")
+	assert.Regexp(t, "PD011953", err)
+}
+
+func TestEngineStatusRPC(t *testing.T) {
+	signer := tktypes.RandAddress()
+	ctx, url, _, done := newTestTransactionManagerWithRPC(t, func(tmc *pldconf.TxManagerConfig, mc *mockComponents) {
+		mc.publicTxMgr.On("GetEngineStatus", mock.Anything).Return(&pldapi.PublicTxEngineStatus{
+			MaxInFlightOrchestrators: 10,
+			Orchestrators: []*pldapi.PublicTxOrchestratorStatus{
+				{
+					SigningAddress:       *signer,
+					LowestInFlightNonce:  confutil.P(tktypes.HexUint64(3)),
+					HighestInFlightNonce: confutil.P(tktypes.HexUint64(5)),
+					NonceGap:             true,
+				},
+			},
+		})
+	})
+	defer done()
+
+	rpcClient, err := rpcclient.NewHTTPClient(ctx, &pldconf.HTTPClientConfig{URL: url})
+	require.NoError(t, err)
+
+	var status *pldapi.PublicTxEngineStatus
+	err = rpcClient.CallRPC(ctx, &status, "ptx_engineStatus")
+	require.NoError(t, err)
+	assert.Equal(t, 10, status.MaxInFlightOrchestrators)
+	require.Len(t, status.Orchestrators, 1)
+	assert.Equal(t, *signer, status.Orchestrators[0].SigningAddress)
+	assert.Equal(t, tktypes.HexUint64(3), *status.Orchestrators[0].LowestInFlightNonce)
+	assert.True(t, status.Orchestrators[0].NonceGap)
+}
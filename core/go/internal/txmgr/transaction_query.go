@@ -17,6 +17,7 @@ package txmgr
 
 import (
 	"context"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/kaleido-io/paladin/core/internal/components"
@@ -294,3 +295,15 @@ func (tm *txManager) GetPublicTransactionByNonce(ctx context.Context, from tktyp
 func (tm *txManager) GetPublicTransactionByHash(ctx context.Context, hash tktypes.Bytes32) (*pldapi.PublicTxWithBinding, error) {
 	return tm.publicTxMgr.GetPublicTransactionForHash(ctx, tm.p.NOTX(), hash)
 }
+
+// The bucket is a duration string such as "1h" - empty to aggregate the whole range into one entry
+func (tm *txManager) QueryGasPriceHistory(ctx context.Context, start, end tktypes.Timestamp, bucket string) ([]*pldapi.GasPriceHistoryEntry, error) {
+	var bucketDuration time.Duration
+	if bucket != "" {
+		var err error
+		if bucketDuration, err = time.ParseDuration(bucket); err != nil {
+			return nil, i18n.WrapError(ctx, err, msgs.MsgPublicTxGasPriceHistoryRange, bucket)
+		}
+	}
+	return tm.publicTxMgr.QueryGasPriceHistory(ctx, tm.p.NOTX(), start, end, bucketDuration)
+}
@@ -417,6 +417,7 @@ func (tm *txManager) processNewTransactions(ctx context.Context, dbTX persistenc
 				PublicTxInput: pldapi.PublicTxInput{
 					To:              tx.To,
 					Data:            txi.PublicTxData,
+					Nonce:           tx.Nonce,
 					PublicTxOptions: tx.PublicTxOptions,
 				},
 			})
@@ -524,6 +525,9 @@ func (tm *txManager) resolveNewTransaction(ctx context.Context, dbTX persistence
 
 	switch tx.Type.V() {
 	case pldapi.TransactionTypePrivate:
+		if tx.Nonce != nil {
+			return nil, i18n.NewError(ctx, msgs.MsgTxMgrNonceNonPublic)
+		}
 		if err := tm.resolvePrivateDomain(ctx, dbTX, tx); err != nil {
 			return nil, err
 		}
@@ -502,6 +502,23 @@ func TestParseInputsPrivateDeployNoDomain(t *testing.T) {
 	assert.Regexp(t, "PD012232", err)
 }
 
+func TestSendTransactionPrivateNonceOverride(t *testing.T) {
+	ctx, txm, done := newTestTransactionManager(t, false,
+		mockEmptyReceiptListeners,
+		mockBeginRollback)
+	defer done()
+
+	_, err := txm.sendTransactionNewDBTX(ctx, &pldapi.TransactionInput{
+		TransactionBase: pldapi.TransactionBase{
+			Domain: "domain1",
+			Type:   pldapi.TransactionTypePrivate.Enum(),
+			To:     tktypes.MustEthAddress(tktypes.RandHex(20)),
+		},
+		Nonce: confutil.P(tktypes.HexUint64(10)),
+	})
+	assert.Regexp(t, "PD012249", err)
+}
+
 func TestParseInputsBadFromRemoteNode(t *testing.T) {
 	ctx, txm, done := newTestTransactionManager(t, false,
 		mockEmptyReceiptListeners,
@@ -49,6 +49,7 @@ type blockListener struct {
 	highestBlockMux            sync.RWMutex
 	wsMux                      sync.Mutex
 	blockPollingInterval       time.Duration
+	subscribeNewHeads          bool
 	unstableHeadLength         int
 	canonicalChain             *list.List
 	retry                      *retry.Retry
@@ -61,12 +62,17 @@ func newBlockListener(ctx context.Context, conf *pldconf.BlockIndexerConfig, wsC
 		return nil, err
 	}
 	chainHeadCacheLen := confutil.IntMin(conf.ChainHeadCacheLen, 1, *pldconf.BlockIndexerDefaults.ChainHeadCacheLen)
+	trackingMode := confutil.StringNotEmpty(conf.BlockTrackingMode, *pldconf.BlockIndexerDefaults.BlockTrackingMode)
+	if trackingMode != pldconf.BlockTrackingModeSubscription && trackingMode != pldconf.BlockTrackingModePolling {
+		return nil, i18n.NewError(ctx, msgs.MsgBlockIndexerInvalidTrackingMode, trackingMode)
+	}
 	bl = &blockListener{
 		ctx:                        log.WithLogField(ctx, "role", "blocklistener"),
 		initialBlockHeightObtained: make(chan struct{}),
 		newHeadsTap:                make(chan struct{}, 1),
 		highestBlock:               0,
 		blockPollingInterval:       confutil.DurationMin(conf.BlockPollingInterval, 1*time.Millisecond, *pldconf.BlockIndexerDefaults.BlockPollingInterval),
+		subscribeNewHeads:          trackingMode == pldconf.BlockTrackingModeSubscription,
 		canonicalChain:             list.New(),
 		unstableHeadLength:         chainHeadCacheLen,
 		retry:                      retry.NewRetryIndefinite(&conf.Retry),
@@ -128,15 +134,7 @@ func (bl *blockListener) establishBlockHeightWithRetry() error {
 			// if we retry subscribe, we don't want to retry connect
 			wsConnected = true
 		}
-		if bl.newHeadsSub == nil {
-			// Once subscribed the backend will keep us subscribed over reconnect
-			sub, rpcErr := bl.wsConn.Subscribe(bl.ctx, rpcclient.EthSubscribeConfig(), "newHeads")
-			if rpcErr != nil {
-				return true, rpcErr
-			}
-			bl.newHeadsSub = sub
-			go bl.newHeadsSubListener()
-		}
+		bl.ensureNewHeadsSubscription()
 
 		// Now get the block height
 		var hexBlockHeight ethtypes.HexUint64
@@ -154,6 +152,21 @@ func (bl *blockListener) establishBlockHeightWithRetry() error {
 	})
 }
 
+// Called with the wsMux held. If we cannot subscribe we fall back to polling, and try again on the next poll
+func (bl *blockListener) ensureNewHeadsSubscription() {
+	if !bl.subscribeNewHeads || bl.newHeadsSub != nil || bl.wsConnClosed {
+		return
+	}
+	// Once subscribed the backend will keep us subscribed over reconnect
+	sub, rpcErr := bl.wsConn.Subscribe(bl.ctx, rpcclient.EthSubscribeConfig(), "newHeads")
+	if rpcErr != nil {
+		log.L(bl.ctx).Warnf("Unable to subscribe to newHeads, polling every %s until the subscription is established: %s", bl.blockPollingInterval, rpcErr)
+		return
+	}
+	bl.newHeadsSub = sub
+	go bl.newHeadsSubListener()
+}
+
 func isNotFound(err error) bool {
 	if err != nil {
 		lowerCaseErr := strings.ToLower(err.Error())
@@ -224,6 +237,10 @@ func (bl *blockListener) listenLoop(listenerInitiated *chan struct{}) {
 				return false // context cancelled, exit loop
 			}
 
+			bl.wsMux.Lock()
+			bl.ensureNewHeadsSubscription()
+			bl.wsMux.Unlock()
+
 			if filter == "" {
 				err := bl.wsConn.CallRPC(bl.ctx, &filter, "eth_newBlockFilter")
 				if err != nil {
@@ -293,6 +293,96 @@ func TestBlockListenerWSShoulderTap(t *testing.T) {
 	<-svrDone
 }
 
+// Mocks the node returning a single new block, containing a single transaction, through the block filter
+func mockBlockWithTransaction(mRPC *rpcclientmocks.WSClient) (blockHash, txHash ethtypes.HexBytes0xPrefix) {
+	blockHash = ethtypes.MustNewHexBytes0xPrefix(tktypes.RandHex(32))
+	txHash = ethtypes.MustNewHexBytes0xPrefix(tktypes.RandHex(32))
+	mRPC.On("CallRPC", mock.Anything, mock.Anything, "eth_blockNumber").Return(nil).Run(func(args mock.Arguments) {
+		*args[1].(*ethtypes.HexUint64) = ethtypes.HexUint64(1000)
+	}).Once()
+	mRPC.On("CallRPC", mock.Anything, mock.Anything, "eth_newBlockFilter").Return(nil).Run(func(args mock.Arguments) {
+		*args[1].(*string) = testBlockFilterID1
+	})
+	mRPC.On("CallRPC", mock.Anything, mock.Anything, "eth_getFilterChanges", testBlockFilterID1).Return(nil).Run(func(args mock.Arguments) {
+		*args[1].(*[]ethtypes.HexBytes0xPrefix) = []ethtypes.HexBytes0xPrefix{blockHash}
+	}).Once()
+	mRPC.On("CallRPC", mock.Anything, mock.Anything, "eth_getFilterChanges", testBlockFilterID1).Return(nil)
+	mRPC.On("CallRPC", mock.Anything, mock.Anything, "eth_getBlockByHash", blockHash.String(), true).Return(nil).Run(func(args mock.Arguments) {
+		*args[1].(**BlockInfoJSONRPC) = &BlockInfoJSONRPC{
+			Number:       ethtypes.HexUint64(1001),
+			Hash:         blockHash,
+			ParentHash:   ethtypes.MustNewHexBytes0xPrefix(tktypes.RandHex(32)),
+			Transactions: []*PartialTransactionInfo{{Hash: txHash}},
+		}
+	})
+	return blockHash, txHash
+}
+
+func TestBlockListenerPollingMode(t *testing.T) {
+	ctx, cancelCtx := context.WithCancel(context.Background())
+	bl, mRPC := newTestBlockListenerConf(t, ctx, &pldconf.BlockIndexerConfig{
+		BlockPollingInterval: confutil.P("1ms"),
+		BlockTrackingMode:    confutil.P(pldconf.BlockTrackingModePolling),
+	})
+	blockHash, txHash := mockBlockWithTransaction(mRPC)
+
+	bl.start()
+
+	block := <-bl.channel()
+	assert.Equal(t, blockHash, block.Hash)
+	assert.Equal(t, txHash, block.Transactions[0].Hash)
+
+	cancelCtx()
+	bl.waitClosed()
+
+	mRPC.AssertNotCalled(t, "Subscribe", mock.Anything, mock.Anything, "newHeads")
+	assert.Nil(t, bl.newHeadsSub)
+}
+
+func TestBlockListenerSubscriptionModeFallsBackToPolling(t *testing.T) {
+	ctx, cancelCtx := context.WithCancel(context.Background())
+
+	mRPC := rpcclientmocks.NewWSClient(t)
+	mSub := rpcclientmocks.NewSubscription(t)
+	mSub.On("Notifications").Return(make(chan rpcclient.RPCSubscriptionNotification)).Maybe()
+	mRPC.On("Connect", mock.Anything).Return(nil)
+	// The subscription is unavailable at first, then established on a later poll
+	mRPC.On("Subscribe", mock.Anything, mock.Anything, "newHeads").Return(
+		nil, rpcclient.WrapRPCError(rpcclient.RPCCodeInternalError, fmt.Errorf("pop")),
+	).Once()
+	mRPC.On("Subscribe", mock.Anything, mock.Anything, "newHeads").Return(mSub, nil).Once()
+	mRPC.On("UnsubscribeAll", mock.Anything).Return(nil).Maybe()
+	mRPC.On("Close", mock.Anything).Return(nil).Maybe()
+	blockHash, txHash := mockBlockWithTransaction(mRPC)
+
+	bl, err := newBlockListener(ctx, &pldconf.BlockIndexerConfig{
+		BlockPollingInterval: confutil.P("1ms"),
+	}, &pldconf.WSClientConfig{
+		HTTPClientConfig: pldconf.HTTPClientConfig{URL: "ws://localhost:0"}})
+	require.NoError(t, err)
+	bl.wsConn = mRPC
+
+	bl.start()
+
+	block := <-bl.channel()
+	assert.Equal(t, blockHash, block.Hash)
+	assert.Equal(t, txHash, block.Transactions[0].Hash)
+
+	cancelCtx()
+	bl.waitClosed()
+
+	mRPC.AssertNumberOfCalls(t, "Subscribe", 2)
+	assert.Equal(t, mSub, bl.newHeadsSub)
+}
+
+func TestBlockListenerBadTrackingMode(t *testing.T) {
+	_, err := newBlockListener(context.Background(), &pldconf.BlockIndexerConfig{
+		BlockTrackingMode: confutil.P("wrong"),
+	}, &pldconf.WSClientConfig{
+		HTTPClientConfig: pldconf.HTTPClientConfig{URL: "ws://localhost:0"}})
+	assert.Regexp(t, "PD011312.*wrong", err)
+}
+
 func TestBlockListenerOKDuplicates(t *testing.T) {
 
 	_, bl, mRPC, done := newTestBlockListener(t)
@@ -27,6 +27,7 @@ import (
 	"github.com/kaleido-io/paladin/toolkit/pkg/algorithms"
 	"github.com/kaleido-io/paladin/toolkit/pkg/log"
 	"github.com/kaleido-io/paladin/toolkit/pkg/pldapi"
+	"github.com/kaleido-io/paladin/toolkit/pkg/query"
 	"github.com/kaleido-io/paladin/toolkit/pkg/solutils"
 	"github.com/kaleido-io/paladin/toolkit/pkg/tktypes"
 	"github.com/kaleido-io/paladin/toolkit/pkg/verifiers"
@@ -233,6 +234,83 @@ func (s *notoTestSuite) TestNoto() {
 	assert.Equal(t, recipient2Key.Verifier.Verifier, coins[1].Data.Owner.String())
 }
 
+func (s *notoTestSuite) TestNotoCoinSelection() {
+	ctx := context.Background()
+	t := s.T()
+	log.L(ctx).Infof("TestNotoCoinSelection")
+
+	// More coins than fit in one page of the available state query, in an order that differs by amount
+	minted := []int64{6, 1, 12, 2, 7, 3, 11, 4, 8, 5, 10, 9}
+
+	for _, tc := range []struct {
+		coinSelection types.CoinSelection
+		remaining     []int64
+	}{
+		{"", []int64{1, 3, 4, 5, 7, 8, 9, 10, 11}},                                   // 6+1+12+2, change of 1
+		{types.CoinSelectionLargestFirst, []int64{1, 2, 3, 3, 4, 5, 6, 7, 8, 9, 10}}, // 12+11, change of 3
+		{types.CoinSelectionSmallestFirst, []int64{1, 7, 8, 9, 10, 11, 12}},          // 1+2+3+4+5+6, change of 1
+	} {
+		log.L(ctx).Infof("Coin selection '%s'", tc.coinSelection)
+
+		waitForNoto, notoTestbed := newNotoDomain(t, &types.DomainConfig{
+			FactoryAddress: s.factoryAddress,
+			CoinSelection:  tc.coinSelection.Enum(),
+		})
+		done, _, tb, rpc := newTestbed(t, s.hdWalletSeed, map[string]*testbed.TestbedDomain{
+			s.domainName: notoTestbed,
+		})
+
+		notoDomain := <-waitForNoto
+
+		notaryKey, err := tb.ResolveKey(ctx, notaryName, algorithms.ECDSA_SECP256K1, verifiers.ETH_ADDRESS)
+		require.NoError(t, err)
+
+		noto := helpers.DeployNoto(ctx, t, rpc, s.domainName, notary, nil)
+
+		var invokeResult testbed.TransactionResult
+		for _, amount := range minted {
+			rpcerr := rpc.CallRPC(ctx, &invokeResult, "testbed_invoke", &pldapi.TransactionInput{
+				TransactionBase: pldapi.TransactionBase{
+					From:     notaryName,
+					To:       noto.Address,
+					Function: "mint",
+					Data: toJSON(t, &types.MintParams{
+						To:     notaryName,
+						Amount: tktypes.Int64ToInt256(amount),
+					}),
+				},
+				ABI: types.NotoABI,
+			}, true)
+			require.NoError(t, rpcerr)
+		}
+
+		log.L(ctx).Infof("Transfer 20 from notary to recipient1")
+		rpcerr := rpc.CallRPC(ctx, &invokeResult, "testbed_invoke", &pldapi.TransactionInput{
+			TransactionBase: pldapi.TransactionBase{
+				From:     notaryName,
+				To:       noto.Address,
+				Function: "transfer",
+				Data: toJSON(t, &types.TransferParams{
+					To:     recipient1Name,
+					Amount: tktypes.Int64ToInt256(20),
+				}),
+			},
+			ABI: types.NotoABI,
+		}, true)
+		require.NoError(t, rpcerr)
+
+		coins := findAvailableCoins[types.NotoCoinState](t, ctx, rpc, notoDomain.Name(), notoDomain.CoinSchemaID(), noto.Address,
+			query.NewQueryBuilder().Limit(100).Equal("owner", notaryKey.Verifier.Verifier).Sort("amount").Query())
+		remaining := make([]int64, len(coins))
+		for i, coin := range coins {
+			remaining[i] = coin.Data.Amount.Int().Int64()
+		}
+		assert.Equal(t, tc.remaining, remaining, "coin selection '%s'", tc.coinSelection)
+
+		done()
+	}
+}
+
 func (s *notoTestSuite) TestNotoApprove() {
 	ctx := context.Background()
 	t := s.T()
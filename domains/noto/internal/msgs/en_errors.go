@@ -69,4 +69,9 @@ var (
 	MsgMissingStateData            = pde("PD200029", "Missing state data for one or more states: %s")
 	MsgLockNotAllowed              = pde("PD200030", "Lock is not enabled")
 	MsgUnlockOnlyCreator           = pde("PD200031", "Only the lock creator can perform unlock: expected=%s actual=%s")
+	MsgInvalidDecimals             = pde("PD200032", "Invalid decimals %d: must be between 0 and %d")
+	MsgInvalidEndorsementValidity  = pde("PD200033", "Invalid endorsement validity '%s': %s")
+	MsgNotaryEndorsementExpired    = pde("PD200034", "Notary endorsement expired: endorsed at %s, validity %s")
+	MsgInvalidNotaryEndorsement    = pde("PD200035", "Invalid notary endorsement payload: %s")
+	MsgInvalidCoinSelection        = pde("PD200036", "Invalid coin selection '%s': %s")
 )
@@ -134,9 +134,7 @@ func (h *approveHandler) Endorse(ctx context.Context, tx *types.ParsedTransactio
 	if err := h.noto.validateSignature(ctx, "sender", req.Signatures, transferHash); err != nil {
 		return nil, err
 	}
-	return &prototk.EndorseTransactionResponse{
-		EndorsementResult: prototk.EndorseTransactionResponse_ENDORSER_SUBMIT,
-	}, nil
+	return h.noto.endorserSubmit(), nil
 }
 
 func (h *approveHandler) baseLedgerInvoke(ctx context.Context, tx *types.ParsedTransaction, req *prototk.PrepareTransactionRequest) (*TransactionWrapper, error) {
@@ -212,9 +210,8 @@ func (h *approveHandler) hookInvoke(ctx context.Context, tx *types.ParsedTransac
 }
 
 func (h *approveHandler) Prepare(ctx context.Context, tx *types.ParsedTransaction, req *prototk.PrepareTransactionRequest) (*prototk.PrepareTransactionResponse, error) {
-	endorsement := domain.FindAttestation("notary", req.AttestationResult)
-	if endorsement == nil || endorsement.Verifier.Lookup != tx.DomainConfig.NotaryLookup {
-		return nil, i18n.NewError(ctx, msgs.MsgAttestationNotFound, "notary")
+	if err := h.noto.checkNotaryEndorsement(ctx, tx, req.AttestationResult); err != nil {
+		return nil, err
 	}
 
 	baseTransaction, err := h.baseLedgerInvoke(ctx, tx, req)
@@ -170,9 +170,7 @@ func (h *burnHandler) Endorse(ctx context.Context, tx *types.ParsedTransaction,
 	if err := h.noto.validateSignature(ctx, "sender", req.Signatures, encodedTransfer); err != nil {
 		return nil, err
 	}
-	return &prototk.EndorseTransactionResponse{
-		EndorsementResult: prototk.EndorseTransactionResponse_ENDORSER_SUBMIT,
-	}, nil
+	return h.noto.endorserSubmit(), nil
 }
 
 func (h *burnHandler) baseLedgerInvoke(ctx context.Context, req *prototk.PrepareTransactionRequest) (*TransactionWrapper, error) {
@@ -245,9 +243,8 @@ func (h *burnHandler) hookInvoke(ctx context.Context, tx *types.ParsedTransactio
 }
 
 func (h *burnHandler) Prepare(ctx context.Context, tx *types.ParsedTransaction, req *prototk.PrepareTransactionRequest) (*prototk.PrepareTransactionResponse, error) {
-	endorsement := domain.FindAttestation("notary", req.AttestationResult)
-	if endorsement == nil || endorsement.Verifier.Lookup != tx.DomainConfig.NotaryLookup {
-		return nil, i18n.NewError(ctx, msgs.MsgAttestationNotFound, "notary")
+	if err := h.noto.checkNotaryEndorsement(ctx, tx, req.AttestationResult); err != nil {
+		return nil, err
 	}
 
 	baseTransaction, err := h.baseLedgerInvoke(ctx, req)
@@ -248,3 +248,202 @@ func TestBurn(t *testing.T) {
 		}
 	}`, senderKey.Address, senderKey.Address, contractAddress, tktypes.HexBytes(encodedCall)), prepareRes.Transaction.ParamsJson)
 }
+
+func TestBurnPartialWithChange(t *testing.T) {
+	n := &Noto{
+		Callbacks:  mockCallbacks,
+		coinSchema: &prototk.StateSchema{Id: "coin"},
+		dataSchema: &prototk.StateSchema{Id: "data"},
+	}
+	ctx := context.Background()
+	fn := types.NotoABI.Functions()["burn"]
+
+	notaryAddress := "0x1000000000000000000000000000000000000000"
+	senderKey, err := secp256k1.GenerateSecp256k1KeyPair()
+	require.NoError(t, err)
+
+	inputCoin := &types.NotoCoinState{
+		ID: tktypes.RandBytes32(),
+		Data: types.NotoCoin{
+			Owner:  (*tktypes.EthAddress)(&senderKey.Address),
+			Amount: tktypes.Int64ToInt256(100),
+		},
+	}
+	mockCallbacks.MockFindAvailableStates = func() (*prototk.FindAvailableStatesResponse, error) {
+		return &prototk.FindAvailableStatesResponse{
+			States: []*prototk.StoredState{
+				{
+					Id:       inputCoin.ID.String(),
+					SchemaId: "coin",
+					DataJson: mustParseJSON(inputCoin.Data),
+				},
+			},
+		}, nil
+	}
+
+	contractAddress := "0xf6a75f065db3cef95de7aa786eee1d0cb1aeafc3"
+	tx := &prototk.TransactionSpecification{
+		TransactionId: "0x015e1881f2ba769c22d05c841f06949ec6e1bd573f5e1e0328885494212f077d",
+		From:          "sender@node1",
+		ContractInfo: &prototk.ContractInfo{
+			ContractAddress: contractAddress,
+			ContractConfigJson: mustParseJSON(&types.NotoParsedConfig{
+				NotaryLookup: "notary@node1",
+				NotaryMode:   types.NotaryModeBasic.Enum(),
+				Options: types.NotoOptions{
+					Basic: &types.NotoBasicOptions{
+						AllowBurn: &pTrue,
+					},
+				},
+			}),
+		},
+		FunctionAbiJson:   mustParseJSON(fn),
+		FunctionSignature: fn.SolString(),
+		FunctionParamsJson: `{
+			"amount": 60,
+			"data": "0x1234"
+		}`,
+	}
+
+	_, err = n.InitTransaction(ctx, &prototk.InitTransactionRequest{
+		Transaction: tx,
+	})
+	require.NoError(t, err)
+
+	verifiers := []*prototk.ResolvedVerifier{
+		{
+			Lookup:       "notary@node1",
+			Algorithm:    algorithms.ECDSA_SECP256K1,
+			VerifierType: verifiers.ETH_ADDRESS,
+			Verifier:     notaryAddress,
+		},
+		{
+			Lookup:       "sender@node1",
+			Algorithm:    algorithms.ECDSA_SECP256K1,
+			VerifierType: verifiers.ETH_ADDRESS,
+			Verifier:     senderKey.Address.String(),
+		},
+	}
+
+	assembleRes, err := n.AssembleTransaction(ctx, &prototk.AssembleTransactionRequest{
+		Transaction:       tx,
+		ResolvedVerifiers: verifiers,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, prototk.AssembleTransactionResponse_OK, assembleRes.AssemblyResult)
+	require.Len(t, assembleRes.AssembledTransaction.InputStates, 1)
+	require.Len(t, assembleRes.AssembledTransaction.OutputStates, 1)
+	assert.Equal(t, inputCoin.ID.String(), assembleRes.AssembledTransaction.InputStates[0].Id)
+
+	// The remainder is returned to the sender as change
+	changeCoin, err := n.unmarshalCoin(assembleRes.AssembledTransaction.OutputStates[0].StateDataJson)
+	require.NoError(t, err)
+	assert.Equal(t, senderKey.Address.String(), changeCoin.Owner.String())
+	assert.Equal(t, "40", changeCoin.Amount.Int().String())
+	assert.Equal(t, []string{"notary@node1", "sender@node1"}, assembleRes.AssembledTransaction.OutputStates[0].DistributionList)
+
+	encodedTransfer, err := n.encodeTransferUnmasked(ctx, ethtypes.MustNewAddress(contractAddress), []*types.NotoCoin{&inputCoin.Data}, []*types.NotoCoin{changeCoin})
+	require.NoError(t, err)
+	signature, err := senderKey.SignDirect(encodedTransfer)
+	require.NoError(t, err)
+	signatureBytes := tktypes.HexBytes(signature.CompactRSV())
+
+	inputStates := []*prototk.EndorsableState{
+		{
+			SchemaId:      "coin",
+			Id:            inputCoin.ID.String(),
+			StateDataJson: mustParseJSON(inputCoin.Data),
+		},
+	}
+	outputStates := []*prototk.EndorsableState{
+		{
+			SchemaId:      "coin",
+			Id:            "0x26b394af655bdc794a6d7cd7f8004eec20bffb374e4ddd24cdaefe554878d945",
+			StateDataJson: assembleRes.AssembledTransaction.OutputStates[0].StateDataJson,
+		},
+	}
+
+	endorseRes, err := n.EndorseTransaction(ctx, &prototk.EndorseTransactionRequest{
+		Transaction:       tx,
+		ResolvedVerifiers: verifiers,
+		Inputs:            inputStates,
+		Outputs:           outputStates,
+		EndorsementRequest: &prototk.AttestationRequest{
+			Name: "notary",
+		},
+		Signatures: []*prototk.AttestationResult{
+			{
+				Name:     "sender",
+				Verifier: &prototk.ResolvedVerifier{Verifier: senderKey.Address.String()},
+				Payload:  signatureBytes,
+			},
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, prototk.EndorseTransactionResponse_ENDORSER_SUBMIT, endorseRes.EndorsementResult)
+
+	// The notary will not endorse if the change does not leave the burned amount matching the request
+	tx.FunctionParamsJson = `{"amount": 50}`
+	_, err = n.EndorseTransaction(ctx, &prototk.EndorseTransactionRequest{
+		Transaction:       tx,
+		ResolvedVerifiers: verifiers,
+		Inputs:            inputStates,
+		Outputs:           outputStates,
+		EndorsementRequest: &prototk.AttestationRequest{
+			Name: "notary",
+		},
+		Signatures: []*prototk.AttestationResult{
+			{
+				Name:     "sender",
+				Verifier: &prototk.ResolvedVerifier{Verifier: senderKey.Address.String()},
+				Payload:  signatureBytes,
+			},
+		},
+	})
+	assert.ErrorContains(t, err, "PD200013")
+}
+
+func TestBurnNotAllowed(t *testing.T) {
+	n := &Noto{
+		Callbacks:  mockCallbacks,
+		coinSchema: &prototk.StateSchema{Id: "coin"},
+		dataSchema: &prototk.StateSchema{Id: "data"},
+	}
+	ctx := context.Background()
+	fn := types.NotoABI.Functions()["burn"]
+
+	pFalse := false
+	tx := &prototk.TransactionSpecification{
+		TransactionId: "0x015e1881f2ba769c22d05c841f06949ec6e1bd573f5e1e0328885494212f077d",
+		From:          "sender@node1",
+		ContractInfo: &prototk.ContractInfo{
+			ContractAddress: "0xf6a75f065db3cef95de7aa786eee1d0cb1aeafc3",
+			ContractConfigJson: mustParseJSON(&types.NotoParsedConfig{
+				NotaryLookup: "notary@node1",
+				NotaryMode:   types.NotaryModeBasic.Enum(),
+				Options: types.NotoOptions{
+					Basic: &types.NotoBasicOptions{
+						AllowBurn: &pFalse,
+					},
+				},
+			}),
+		},
+		FunctionAbiJson:    mustParseJSON(fn),
+		FunctionSignature:  fn.SolString(),
+		FunctionParamsJson: `{"amount": 100}`,
+	}
+
+	_, err := n.InitTransaction(ctx, &prototk.InitTransactionRequest{
+		Transaction: tx,
+	})
+	assert.ErrorContains(t, err, "PD200025")
+
+	// The notary also refuses to endorse, even if the sender skipped the check during init
+	_, err = n.EndorseTransaction(ctx, &prototk.EndorseTransactionRequest{
+		Transaction: tx,
+		EndorsementRequest: &prototk.AttestationRequest{
+			Name: "notary",
+		},
+	})
+	assert.ErrorContains(t, err, "PD200025")
+}
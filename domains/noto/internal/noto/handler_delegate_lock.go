@@ -162,9 +162,7 @@ func (h *delegateLockHandler) Endorse(ctx context.Context, tx *types.ParsedTrans
 	if err := h.noto.validateSignature(ctx, "sender", req.Signatures, encodedApproval); err != nil {
 		return nil, err
 	}
-	return &prototk.EndorseTransactionResponse{
-		EndorsementResult: prototk.EndorseTransactionResponse_ENDORSER_SUBMIT,
-	}, nil
+	return h.noto.endorserSubmit(), nil
 }
 
 func (h *delegateLockHandler) baseLedgerInvoke(ctx context.Context, tx *types.ParsedTransaction, req *prototk.PrepareTransactionRequest) (*TransactionWrapper, error) {
@@ -241,6 +239,10 @@ func (h *delegateLockHandler) hookInvoke(ctx context.Context, tx *types.ParsedTr
 }
 
 func (h *delegateLockHandler) Prepare(ctx context.Context, tx *types.ParsedTransaction, req *prototk.PrepareTransactionRequest) (*prototk.PrepareTransactionResponse, error) {
+	if err := h.noto.checkNotaryEndorsement(ctx, tx, req.AttestationResult); err != nil {
+		return nil, err
+	}
+
 	baseTransaction, err := h.baseLedgerInvoke(ctx, tx, req)
 	if err != nil {
 		return nil, err
@@ -193,9 +193,7 @@ func (h *lockHandler) Endorse(ctx context.Context, tx *types.ParsedTransaction,
 	if err := h.noto.validateSignature(ctx, "sender", req.Signatures, encodedLock); err != nil {
 		return nil, err
 	}
-	return &prototk.EndorseTransactionResponse{
-		EndorsementResult: prototk.EndorseTransactionResponse_ENDORSER_SUBMIT,
-	}, nil
+	return h.noto.endorserSubmit(), nil
 }
 
 func (h *lockHandler) baseLedgerInvoke(ctx context.Context, req *prototk.PrepareTransactionRequest) (*TransactionWrapper, error) {
@@ -289,9 +287,8 @@ func (h *lockHandler) Prepare(ctx context.Context, tx *types.ParsedTransaction,
 		return nil, err
 	}
 
-	endorsement := domain.FindAttestation("notary", req.AttestationResult)
-	if endorsement == nil || endorsement.Verifier.Lookup != tx.DomainConfig.NotaryLookup {
-		return nil, i18n.NewError(ctx, msgs.MsgAttestationNotFound, "notary")
+	if err := h.noto.checkNotaryEndorsement(ctx, tx, req.AttestationResult); err != nil {
+		return nil, err
 	}
 
 	baseTransaction, err := h.baseLedgerInvoke(ctx, req)
@@ -153,9 +153,7 @@ func (h *mintHandler) Endorse(ctx context.Context, tx *types.ParsedTransaction,
 	if err := h.noto.validateSignature(ctx, "sender", req.Signatures, encodedTransfer); err != nil {
 		return nil, err
 	}
-	return &prototk.EndorseTransactionResponse{
-		EndorsementResult: prototk.EndorseTransactionResponse_ENDORSER_SUBMIT,
-	}, nil
+	return h.noto.endorserSubmit(), nil
 }
 
 func (h *mintHandler) baseLedgerInvoke(ctx context.Context, req *prototk.PrepareTransactionRequest) (*TransactionWrapper, error) {
@@ -231,9 +229,8 @@ func (h *mintHandler) hookInvoke(ctx context.Context, tx *types.ParsedTransactio
 }
 
 func (h *mintHandler) Prepare(ctx context.Context, tx *types.ParsedTransaction, req *prototk.PrepareTransactionRequest) (*prototk.PrepareTransactionResponse, error) {
-	endorsement := domain.FindAttestation("notary", req.AttestationResult)
-	if endorsement == nil || endorsement.Verifier.Lookup != tx.DomainConfig.NotaryLookup {
-		return nil, i18n.NewError(ctx, msgs.MsgAttestationNotFound, "notary")
+	if err := h.noto.checkNotaryEndorsement(ctx, tx, req.AttestationResult); err != nil {
+		return nil, err
 	}
 
 	baseTransaction, err := h.baseLedgerInvoke(ctx, req)
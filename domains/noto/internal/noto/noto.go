@@ -21,6 +21,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"math/big"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/hyperledger/firefly-signer/pkg/abi"
@@ -29,11 +30,13 @@ import (
 	"github.com/kaleido-io/paladin/domains/noto/internal/msgs"
 	"github.com/kaleido-io/paladin/domains/noto/pkg/types"
 	"github.com/kaleido-io/paladin/toolkit/pkg/algorithms"
+	"github.com/kaleido-io/paladin/toolkit/pkg/domain"
 	"github.com/kaleido-io/paladin/toolkit/pkg/i18n"
 	"github.com/kaleido-io/paladin/toolkit/pkg/pldapi"
 	"github.com/kaleido-io/paladin/toolkit/pkg/plugintk"
 	"github.com/kaleido-io/paladin/toolkit/pkg/prototk"
 	"github.com/kaleido-io/paladin/toolkit/pkg/solutils"
+	"github.com/kaleido-io/paladin/toolkit/pkg/tkmsgs"
 	"github.com/kaleido-io/paladin/toolkit/pkg/tktypes"
 	"github.com/kaleido-io/paladin/toolkit/pkg/verifiers"
 )
@@ -90,13 +93,15 @@ var schemasJSON = mustParseSchemas(allSchemas)
 type Noto struct {
 	Callbacks plugintk.DomainCallbacks
 
-	name             string
-	config           types.DomainConfig
-	chainID          int64
-	coinSchema       *prototk.StateSchema
-	lockedCoinSchema *prototk.StateSchema
-	dataSchema       *prototk.StateSchema
-	lockInfoSchema   *prototk.StateSchema
+	name                string
+	config              types.DomainConfig
+	chainID             int64
+	endorsementValidity time.Duration
+	coinSelection       types.CoinSelection
+	coinSchema          *prototk.StateSchema
+	lockedCoinSchema    *prototk.StateSchema
+	dataSchema          *prototk.StateSchema
+	lockInfoSchema      *prototk.StateSchema
 }
 
 type NotoDeployParams struct {
@@ -277,6 +282,16 @@ func (n *Noto) ConfigureDomain(ctx context.Context, req *prototk.ConfigureDomain
 		return nil, err
 	}
 
+	if n.config.EndorsementValidity != "" {
+		n.endorsementValidity, err = time.ParseDuration(n.config.EndorsementValidity)
+		if err != nil || n.endorsementValidity < 0 {
+			return nil, i18n.NewError(ctx, msgs.MsgInvalidEndorsementValidity, n.config.EndorsementValidity, err)
+		}
+	}
+	if n.coinSelection, err = n.config.CoinSelection.Validate(); err != nil {
+		return nil, i18n.NewError(ctx, msgs.MsgInvalidCoinSelection, n.config.CoinSelection, err)
+	}
+
 	n.name = req.Name
 	n.chainID = req.ChainId
 
@@ -360,6 +375,9 @@ func (n *Noto) PrepareDeploy(ctx context.Context, req *prototk.PrepareDeployRequ
 
 	deployData := &types.NotoConfigData_V0{
 		NotaryLookup: notaryQualified.String(),
+		Name:         params.Name,
+		Symbol:       params.Symbol,
+		Decimals:     params.Decimals,
 	}
 	switch params.NotaryMode {
 	case types.NotaryModeBasic:
@@ -422,6 +440,9 @@ func (n *Noto) InitContract(ctx context.Context, req *prototk.InitContractReques
 	var notoContractConfigJSON []byte
 
 	domainConfig, decodedData, err := n.decodeConfig(ctx, req.ContractConfig)
+	if err == nil {
+		err = validateDecimals(ctx, decodedData.Decimals)
+	}
 	if err != nil {
 		// This on-chain contract has invalid configuration - not an error in our process
 		return &prototk.InitContractResponse{Valid: false}, nil
@@ -438,6 +459,12 @@ func (n *Noto) InitContract(ctx context.Context, req *prototk.InitContractReques
 		Variant:      domainConfig.Variant,
 		NotaryLookup: decodedData.NotaryLookup,
 		IsNotary:     notaryNodeName == localNodeName.Name,
+		Name:         decodedData.Name,
+		Symbol:       decodedData.Symbol,
+		Decimals:     types.DefaultDecimals,
+	}
+	if decodedData.Decimals != nil {
+		parsedConfig.Decimals = *decodedData.Decimals
 	}
 	if decodedData.NotaryMode == types.NotaryModeIntHooks {
 		parsedConfig.NotaryMode = types.NotaryModeHooks.Enum()
@@ -529,9 +556,19 @@ func (n *Noto) validateDeploy(tx *prototk.DeployTransactionSpecification) (*type
 	if err == nil && params.Notary == "" {
 		err = i18n.NewError(context.Background(), msgs.MsgParameterRequired, "notary")
 	}
+	if err == nil {
+		err = validateDecimals(context.Background(), params.Decimals)
+	}
 	return &params, err
 }
 
+func validateDecimals(ctx context.Context, decimals *int) error {
+	if decimals != nil && (*decimals < 0 || *decimals > types.MaxDecimals) {
+		return i18n.NewError(ctx, msgs.MsgInvalidDecimals, *decimals, types.MaxDecimals)
+	}
+	return nil
+}
+
 func (n *Noto) validateTransaction(ctx context.Context, tx *prototk.TransactionSpecification) (*types.ParsedTransaction, types.DomainHandler, error) {
 	var functionABI abi.Entry
 	err := json.Unmarshal([]byte(tx.FunctionAbiJson), &functionABI)
@@ -598,6 +635,39 @@ func (n *Noto) ethAddressVerifiers(lookups ...string) []*prototk.ResolveVerifier
 	return request
 }
 
+// The notary endorses by submitting the transaction itself. When a validity window is configured,
+// the time of endorsement is returned in the payload so that it can be checked at prepare time.
+func (n *Noto) endorserSubmit() *prototk.EndorseTransactionResponse {
+	res := &prototk.EndorseTransactionResponse{
+		EndorsementResult: prototk.EndorseTransactionResponse_ENDORSER_SUBMIT,
+	}
+	if n.endorsementValidity > 0 {
+		res.Payload, _ = json.Marshal(&types.NotaryEndorsementPayload{EndorsedAt: tktypes.TimestampNow()})
+	}
+	return res
+}
+
+// Checks the notary endorsement is present, and has not expired. An expired endorsement fails
+// the prepare, which causes the endorsements for the transaction to be gathered again.
+func (n *Noto) checkNotaryEndorsement(ctx context.Context, tx *types.ParsedTransaction, attestations []*prototk.AttestationResult) error {
+	endorsement := domain.FindAttestation("notary", attestations)
+	if endorsement == nil || endorsement.Verifier.Lookup != tx.DomainConfig.NotaryLookup {
+		return i18n.NewError(ctx, msgs.MsgAttestationNotFound, "notary")
+	}
+	if n.endorsementValidity <= 0 {
+		return nil
+	}
+	// These errors are wrapped in a code the private transaction manager recognizes, to gather the endorsement again
+	var payload types.NotaryEndorsementPayload
+	if err := json.Unmarshal(endorsement.Payload, &payload); err != nil {
+		return i18n.WrapError(ctx, i18n.NewError(ctx, msgs.MsgInvalidNotaryEndorsement, err), tkmsgs.MsgPluginEndorsementInvalid)
+	}
+	if time.Since(payload.EndorsedAt.Time()) > n.endorsementValidity {
+		return i18n.WrapError(ctx, i18n.NewError(ctx, msgs.MsgNotaryEndorsementExpired, payload.EndorsedAt, n.endorsementValidity), tkmsgs.MsgPluginEndorsementInvalid)
+	}
+	return nil
+}
+
 func (n *Noto) recoverSignature(ctx context.Context, payload ethtypes.HexBytes0xPrefix, signature []byte) (*ethtypes.Address0xHex, error) {
 	sig, err := secp256k1.DecodeCompactRSV(ctx, signature)
 	if err != nil {
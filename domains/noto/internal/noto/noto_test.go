@@ -20,6 +20,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/hyperledger/firefly-signer/pkg/abi"
 	"github.com/kaleido-io/paladin/domains/noto/pkg/types"
@@ -289,6 +290,99 @@ func TestNotoDomainDeployHooksConfig(t *testing.T) {
 	assert.True(t, initContractRes.Valid)
 }
 
+func TestNotoDomainDeployDisplayMetadata(t *testing.T) {
+	n := &Noto{Callbacks: mockCallbacks}
+	ctx := context.Background()
+
+	deployTransaction := &prototk.DeployTransactionSpecification{
+		TransactionId: "tx1",
+		ConstructorParamsJson: `{
+			"notary": "notary@node1",
+			"notaryMode": "basic",
+			"name": "Test Token",
+			"symbol": "TST",
+			"decimals": 6
+		}`,
+	}
+
+	_, err := n.InitDeploy(ctx, &prototk.InitDeployRequest{
+		Transaction: deployTransaction,
+	})
+	require.NoError(t, err)
+
+	prepareDeployRes, err := n.PrepareDeploy(ctx, &prototk.PrepareDeployRequest{
+		Transaction: deployTransaction,
+		ResolvedVerifiers: []*prototk.ResolvedVerifier{
+			{
+				Lookup:       "notary@node1",
+				Algorithm:    algorithms.ECDSA_SECP256K1,
+				VerifierType: verifiers.ETH_ADDRESS,
+				Verifier:     "0x6e2430d15301a7ee28ceaaee0dff9781f8f82f71",
+			},
+		},
+	})
+	require.NoError(t, err)
+	var deployParams map[string]any
+	err = json.Unmarshal([]byte(prepareDeployRes.Transaction.ParamsJson), &deployParams)
+	require.NoError(t, err)
+	deployData := tktypes.MustParseHexBytes(deployParams["data"].(string))
+	assert.JSONEq(t, `{
+		"notaryLookup": "notary@node1",
+		"notaryMode": "0x0",
+		"privateAddress": null,
+		"privateGroup": null,
+		"restrictMint": true,
+		"allowBurn": true,
+		"allowLock": true,
+		"name": "Test Token",
+		"symbol": "TST",
+		"decimals": 6
+	}`, string(deployData))
+
+	var decodedData types.NotoConfigData_V0
+	err = json.Unmarshal(deployData, &decodedData)
+	require.NoError(t, err)
+	initContractRes, err := n.InitContract(ctx, &prototk.InitContractRequest{
+		ContractAddress: "0xf6a75f065db3cef95de7aa786eee1d0cb1aeafc3",
+		ContractConfig:  encodedConfig(&decodedData),
+	})
+	require.NoError(t, err)
+	require.True(t, initContractRes.Valid)
+	var parsedConfig types.NotoParsedConfig
+	err = json.Unmarshal([]byte(initContractRes.ContractConfig.ContractConfigJson), &parsedConfig)
+	require.NoError(t, err)
+	assert.Equal(t, "Test Token", parsedConfig.Name)
+	assert.Equal(t, "TST", parsedConfig.Symbol)
+	assert.Equal(t, 6, parsedConfig.Decimals)
+}
+
+func TestInitContractDisplayMetadataDefaults(t *testing.T) {
+	n := &Noto{Callbacks: mockCallbacks}
+	initContractRes, err := n.InitContract(context.Background(), &prototk.InitContractRequest{
+		ContractAddress: "0xf6a75f065db3cef95de7aa786eee1d0cb1aeafc3",
+		ContractConfig:  encodedConfig(&types.NotoConfigData_V0{NotaryLookup: "notary@node1"}),
+	})
+	require.NoError(t, err)
+	require.True(t, initContractRes.Valid)
+	var parsedConfig types.NotoParsedConfig
+	err = json.Unmarshal([]byte(initContractRes.ContractConfig.ContractConfigJson), &parsedConfig)
+	require.NoError(t, err)
+	assert.Empty(t, parsedConfig.Name)
+	assert.Empty(t, parsedConfig.Symbol)
+	assert.Equal(t, types.DefaultDecimals, parsedConfig.Decimals)
+}
+
+func TestInitContractBadDecimals(t *testing.T) {
+	n := &Noto{Callbacks: mockCallbacks}
+	decimals := 37
+	res, err := n.InitContract(context.Background(), &prototk.InitContractRequest{
+		ContractAddress: "0xf6a75f065db3cef95de7aa786eee1d0cb1aeafc3",
+		ContractConfig:  encodedConfig(&types.NotoConfigData_V0{NotaryLookup: "notary@node1", Decimals: &decimals}),
+	})
+	require.NoError(t, err)
+	assert.False(t, res.Valid)
+}
+
 func TestConfigureDomainBadConfig(t *testing.T) {
 	n := &Noto{Callbacks: mockCallbacks}
 	_, err := n.ConfigureDomain(context.Background(), &prototk.ConfigureDomainRequest{
@@ -297,6 +391,81 @@ func TestConfigureDomainBadConfig(t *testing.T) {
 	assert.ErrorContains(t, err, "invalid character")
 }
 
+func TestConfigureDomainBadEndorsementValidity(t *testing.T) {
+	n := &Noto{Callbacks: mockCallbacks}
+	_, err := n.ConfigureDomain(context.Background(), &prototk.ConfigureDomainRequest{
+		ConfigJson: `{"endorsementValidity": "wrong"}`,
+	})
+	assert.Regexp(t, "PD200033", err)
+}
+
+func TestConfigureDomainBadCoinSelection(t *testing.T) {
+	n := &Noto{Callbacks: mockCallbacks}
+	_, err := n.ConfigureDomain(context.Background(), &prototk.ConfigureDomainRequest{
+		ConfigJson: `{"coinSelection": "random"}`,
+	})
+	assert.Regexp(t, "PD200036", err)
+}
+
+func TestNotaryEndorsementValidity(t *testing.T) {
+	ctx := context.Background()
+	n := &Noto{Callbacks: mockCallbacks}
+	_, err := n.ConfigureDomain(ctx, &prototk.ConfigureDomainRequest{
+		ConfigJson: `{"endorsementValidity": "1m"}`,
+	})
+	require.NoError(t, err)
+
+	tx := &types.ParsedTransaction{
+		DomainConfig: &types.NotoParsedConfig{NotaryLookup: "notary@node1"},
+	}
+	notaryEndorsement := func(payload []byte) []*prototk.AttestationResult {
+		return []*prototk.AttestationResult{{
+			Name:     "notary",
+			Verifier: &prototk.ResolvedVerifier{Lookup: "notary@node1"},
+			Payload:  payload,
+		}}
+	}
+
+	// A fresh endorsement is used as-is
+	endorseRes := n.endorserSubmit()
+	assert.Equal(t, prototk.EndorseTransactionResponse_ENDORSER_SUBMIT, endorseRes.EndorsementResult)
+	err = n.checkNotaryEndorsement(ctx, tx, notaryEndorsement(endorseRes.Payload))
+	require.NoError(t, err)
+
+	// A stale endorsement must be gathered again
+	stalePayload, err := json.Marshal(&types.NotaryEndorsementPayload{
+		EndorsedAt: tktypes.Timestamp(time.Now().Add(-2 * time.Minute).UnixNano()),
+	})
+	require.NoError(t, err)
+	err = n.checkNotaryEndorsement(ctx, tx, notaryEndorsement(stalePayload))
+	assert.Regexp(t, "PD200034", err)
+
+	err = n.checkNotaryEndorsement(ctx, tx, notaryEndorsement(nil))
+	assert.Regexp(t, "PD200035", err)
+
+	err = n.checkNotaryEndorsement(ctx, tx, nil)
+	assert.Regexp(t, "PD200015", err)
+}
+
+func TestNotaryEndorsementNoExpiry(t *testing.T) {
+	ctx := context.Background()
+	n := &Noto{Callbacks: mockCallbacks}
+	_, err := n.ConfigureDomain(ctx, &prototk.ConfigureDomainRequest{
+		ConfigJson: `{}`,
+	})
+	require.NoError(t, err)
+
+	endorseRes := n.endorserSubmit()
+	assert.Nil(t, endorseRes.Payload)
+	err = n.checkNotaryEndorsement(ctx, &types.ParsedTransaction{
+		DomainConfig: &types.NotoParsedConfig{NotaryLookup: "notary@node1"},
+	}, []*prototk.AttestationResult{{
+		Name:     "notary",
+		Verifier: &prototk.ResolvedVerifier{Lookup: "notary@node1"},
+	}})
+	require.NoError(t, err)
+}
+
 func TestInitDeployBadParams(t *testing.T) {
 	n := &Noto{Callbacks: mockCallbacks}
 	_, err := n.InitDeploy(context.Background(), &prototk.InitDeployRequest{
@@ -320,6 +489,33 @@ func TestInitDeployBadMode(t *testing.T) {
 	assert.ErrorContains(t, err, "PD200007")
 }
 
+func TestInitDeployBadDecimals(t *testing.T) {
+	n := &Noto{Callbacks: mockCallbacks}
+	for _, decimals := range []string{"-1", "37"} {
+		_, err := n.InitDeploy(context.Background(), &prototk.InitDeployRequest{
+			Transaction: &prototk.DeployTransactionSpecification{
+				ConstructorParamsJson: `{
+					"notary": "notary@node1",
+					"notaryMode": "basic",
+					"decimals": ` + decimals + `
+				}`,
+			},
+		})
+		assert.ErrorContains(t, err, "PD200032")
+	}
+
+	_, err := n.InitDeploy(context.Background(), &prototk.InitDeployRequest{
+		Transaction: &prototk.DeployTransactionSpecification{
+			ConstructorParamsJson: `{
+				"notary": "notary@node1",
+				"notaryMode": "basic",
+				"decimals": 36
+			}`,
+		},
+	})
+	assert.NoError(t, err)
+}
+
 func TestInitDeployMissingNotary(t *testing.T) {
 	n := &Noto{Callbacks: mockCallbacks}
 	_, err := n.InitDeploy(context.Background(), &prototk.InitDeployRequest{
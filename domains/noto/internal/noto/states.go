@@ -209,7 +209,12 @@ type preparedLockedOutputs struct {
 }
 
 func (n *Noto) prepareInputs(ctx context.Context, stateQueryContext string, owner *tktypes.EthAddress, amount *tktypes.HexUint256) (inputs *preparedInputs, revert bool, err error) {
-	var lastStateTimestamp int64
+	switch n.coinSelection {
+	case types.CoinSelectionLargestFirst, types.CoinSelectionSmallestFirst:
+		return n.prepareInputsByAmount(ctx, stateQueryContext, owner, amount)
+	}
+
+	var pageCursor string
 	total := big.NewInt(0)
 	stateRefs := []*prototk.StateRef{}
 	coins := []*types.NotoCoin{}
@@ -217,23 +222,14 @@ func (n *Noto) prepareInputs(ctx context.Context, stateQueryContext string, owne
 		// TODO: make this configurable
 		queryBuilder := query.NewQueryBuilder().
 			Limit(10).
-			Sort(".created").
 			Equal("owner", owner.String())
 
-		if lastStateTimestamp > 0 {
-			queryBuilder.GreaterThan(".created", lastStateTimestamp)
-		}
-
-		log.L(ctx).Debugf("State query: %s", queryBuilder.Query())
-		states, err := n.findAvailableStates(ctx, stateQueryContext, n.coinSchema.Id, queryBuilder.Query().String())
+		log.L(ctx).Debugf("State query: %s (cursor=%s)", queryBuilder.Query(), pageCursor)
+		states, nextPageCursor, err := n.findAvailableStates(ctx, stateQueryContext, n.coinSchema.Id, queryBuilder.Query().String(), pageCursor)
 		if err != nil {
 			return nil, false, err
 		}
-		if len(states) == 0 {
-			return nil, true, i18n.NewError(ctx, msgs.MsgInsufficientFunds, total.Text(10))
-		}
 		for _, state := range states {
-			lastStateTimestamp = state.CreatedAt
 			coin, err := n.unmarshalCoin(state.DataJson)
 			if err != nil {
 				return nil, false, i18n.NewError(ctx, msgs.MsgInvalidStateData, state.Id, err)
@@ -253,11 +249,81 @@ func (n *Noto) prepareInputs(ctx context.Context, stateQueryContext string, owne
 				}, false, nil
 			}
 		}
+		if nextPageCursor == "" {
+			return nil, true, i18n.NewError(ctx, msgs.MsgInsufficientFunds, total.Text(10))
+		}
+		pageCursor = nextPageCursor
 	}
 }
 
+// The state store pages available states in the order they were created, so cannot page in order of amount.
+// Instead all the available coins of the owner are read, and ordered in memory before selecting from them.
+func (n *Noto) prepareInputsByAmount(ctx context.Context, stateQueryContext string, owner *tktypes.EthAddress, amount *tktypes.HexUint256) (inputs *preparedInputs, revert bool, err error) {
+	var pageCursor string
+	candidateStates := []*prototk.StoredState{}
+	candidateCoins := []*types.NotoCoin{}
+	for {
+		queryBuilder := query.NewQueryBuilder().
+			Limit(10).
+			Equal("owner", owner.String())
+
+		log.L(ctx).Debugf("State query: %s (cursor=%s)", queryBuilder.Query(), pageCursor)
+		states, nextPageCursor, err := n.findAvailableStates(ctx, stateQueryContext, n.coinSchema.Id, queryBuilder.Query().String(), pageCursor)
+		if err != nil {
+			return nil, false, err
+		}
+		for _, state := range states {
+			coin, err := n.unmarshalCoin(state.DataJson)
+			if err != nil {
+				return nil, false, i18n.NewError(ctx, msgs.MsgInvalidStateData, state.Id, err)
+			}
+			candidateStates = append(candidateStates, state)
+			candidateCoins = append(candidateCoins, coin)
+		}
+		if nextPageCursor == "" {
+			break
+		}
+		pageCursor = nextPageCursor
+	}
+
+	// A stable sort, so coins of equal amount are still spent oldest first
+	order := make([]int, len(candidateCoins))
+	for i := range order {
+		order[i] = i
+	}
+	slices.SortStableFunc(order, func(a, b int) int {
+		cmp := candidateCoins[a].Amount.Int().Cmp(candidateCoins[b].Amount.Int())
+		if n.coinSelection == types.CoinSelectionLargestFirst {
+			return -cmp
+		}
+		return cmp
+	})
+
+	total := big.NewInt(0)
+	stateRefs := []*prototk.StateRef{}
+	coins := []*types.NotoCoin{}
+	for _, i := range order {
+		state, coin := candidateStates[i], candidateCoins[i]
+		total = total.Add(total, coin.Amount.Int())
+		stateRefs = append(stateRefs, &prototk.StateRef{
+			SchemaId: state.SchemaId,
+			Id:       state.Id,
+		})
+		coins = append(coins, coin)
+		log.L(ctx).Debugf("Selecting coin %s value=%s total=%s required=%s)", state.Id, coin.Amount.Int().Text(10), total.Text(10), amount.Int().Text(10))
+		if total.Cmp(amount.Int()) >= 0 {
+			return &preparedInputs{
+				coins:  coins,
+				states: stateRefs,
+				total:  total,
+			}, false, nil
+		}
+	}
+	return nil, true, i18n.NewError(ctx, msgs.MsgInsufficientFunds, total.Text(10))
+}
+
 func (n *Noto) prepareLockedInputs(ctx context.Context, stateQueryContext string, lockID tktypes.Bytes32, owner *tktypes.EthAddress, amount *big.Int) (inputs *preparedLockedInputs, revert bool, err error) {
-	var lastStateTimestamp int64
+	var pageCursor string
 	total := big.NewInt(0)
 	stateRefs := []*prototk.StateRef{}
 	coins := []*types.NotoLockedCoin{}
@@ -265,25 +331,15 @@ func (n *Noto) prepareLockedInputs(ctx context.Context, stateQueryContext string
 	for {
 		queryBuilder := query.NewQueryBuilder().
 			Limit(10).
-			Sort(".created").
 			Equal("lockId", lockID).
 			Equal("owner", owner.String())
 
-		if lastStateTimestamp > 0 {
-			queryBuilder.GreaterThan(".created", lastStateTimestamp)
-		}
-
-		log.L(ctx).Debugf("State query: %s", queryBuilder.Query())
-		states, err := n.findAvailableStates(ctx, stateQueryContext, n.lockedCoinSchema.Id, queryBuilder.Query().String())
-
+		log.L(ctx).Debugf("State query: %s (cursor=%s)", queryBuilder.Query(), pageCursor)
+		states, nextPageCursor, err := n.findAvailableStates(ctx, stateQueryContext, n.lockedCoinSchema.Id, queryBuilder.Query().String(), pageCursor)
 		if err != nil {
 			return nil, false, err
 		}
-		if len(states) == 0 {
-			return nil, true, i18n.NewError(ctx, msgs.MsgInsufficientFunds, total.Text(10))
-		}
 		for _, state := range states {
-			lastStateTimestamp = state.CreatedAt
 			coin, err := n.unmarshalLockedCoin(state.DataJson)
 			if err != nil {
 				return nil, false, i18n.NewError(ctx, msgs.MsgInvalidStateData, state.Id, err)
@@ -303,6 +359,10 @@ func (n *Noto) prepareLockedInputs(ctx context.Context, stateQueryContext string
 				}, false, nil
 			}
 		}
+		if nextPageCursor == "" {
+			return nil, true, i18n.NewError(ctx, msgs.MsgInsufficientFunds, total.Text(10))
+		}
+		pageCursor = nextPageCursor
 	}
 }
 
@@ -386,17 +446,19 @@ func (n *Noto) getStates(ctx context.Context, stateQueryContext, schemaId string
 	return res.States, nil
 }
 
-func (n *Noto) findAvailableStates(ctx context.Context, stateQueryContext, schemaId, query string) ([]*prototk.StoredState, error) {
+// Returns a page of available states in creation order, with the cursor for the next page (empty if there are no more)
+func (n *Noto) findAvailableStates(ctx context.Context, stateQueryContext, schemaId, query, pageCursor string) ([]*prototk.StoredState, string, error) {
 	req := &prototk.FindAvailableStatesRequest{
 		StateQueryContext: stateQueryContext,
 		SchemaId:          schemaId,
 		QueryJson:         query,
+		PageCursor:        &pageCursor,
 	}
 	res, err := n.Callbacks.FindAvailableStates(ctx, req)
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
-	return res.States, nil
+	return res.States, res.GetNextPageCursor(), nil
 }
 
 func (n *Noto) eip712Domain(contract *ethtypes.Address0xHex) map[string]any {
@@ -0,0 +1,161 @@
+/*
+ * Copyright © 2024 Kaleido, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with
+ * the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package noto
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"testing"
+
+	"github.com/kaleido-io/paladin/domains/noto/pkg/types"
+	"github.com/kaleido-io/paladin/toolkit/pkg/domain"
+	"github.com/kaleido-io/paladin/toolkit/pkg/prototk"
+	"github.com/kaleido-io/paladin/toolkit/pkg/query"
+	"github.com/kaleido-io/paladin/toolkit/pkg/tktypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrepareInputsPaged(t *testing.T) {
+	owner := tktypes.RandAddress()
+	pages := []*prototk.FindAvailableStatesResponse{}
+	for i, next := range []string{"cursor1", "cursor2", ""} {
+		page := &prototk.FindAvailableStatesResponse{NextPageCursor: &next}
+		for j := 0; j < 10; j++ {
+			page.States = append(page.States, &prototk.StoredState{
+				Id:       tktypes.RandBytes32().String(),
+				SchemaId: "coin",
+				DataJson: mustParseJSON(&types.NotoCoin{
+					Salt:   tktypes.RandBytes32(),
+					Owner:  owner,
+					Amount: tktypes.Int64ToInt256(int64(i + 1)),
+				}),
+			})
+		}
+		pages = append(pages, page)
+	}
+	calls := 0
+	n := &Noto{
+		Callbacks: &domain.MockDomainCallbacks{
+			MockFindAvailableStates: func() (*prototk.FindAvailableStatesResponse, error) {
+				page := pages[calls]
+				calls++
+				return page, nil
+			},
+		},
+		coinSchema: &prototk.StateSchema{Id: "coin"},
+	}
+	ctx := context.Background()
+
+	// Needs the whole first page (10) and some of the second (2 each)
+	inputs, revert, err := n.prepareInputs(ctx, "query1", owner, tktypes.Int64ToInt256(15))
+	require.NoError(t, err)
+	assert.False(t, revert)
+	assert.Equal(t, 2, calls)
+	assert.Len(t, inputs.coins, 13)
+	assert.Equal(t, int64(16), inputs.total.Int64())
+
+	// Exhausting all the pages is insufficient funds
+	calls = 0
+	_, revert, err = n.prepareInputs(ctx, "query1", owner, tktypes.Int64ToInt256(61))
+	assert.Regexp(t, "PD200005.*60", err)
+	assert.True(t, revert)
+	assert.Equal(t, 3, calls)
+}
+
+// Serves the coins in the order they were created, a page at a time, as the state store would
+type createdOrderCoinCallbacks struct {
+	domain.MockDomainCallbacks
+	t     *testing.T
+	coins []*types.NotoCoin
+}
+
+func (cb *createdOrderCoinCallbacks) FindAvailableStates(ctx context.Context, req *prototk.FindAvailableStatesRequest) (*prototk.FindAvailableStatesResponse, error) {
+	var q query.QueryJSON
+	err := json.Unmarshal([]byte(req.QueryJson), &q)
+	require.NoError(cb.t, err)
+	require.Empty(cb.t, q.Sort) // the state store rejects a sort on a paged query
+	require.NotNil(cb.t, q.Limit)
+	start := 0
+	if req.GetPageCursor() != "" {
+		start, err = strconv.Atoi(req.GetPageCursor())
+		require.NoError(cb.t, err)
+	}
+	end := min(start+*q.Limit, len(cb.coins))
+	res := &prototk.FindAvailableStatesResponse{}
+	for _, coin := range cb.coins[start:end] {
+		res.States = append(res.States, &prototk.StoredState{
+			Id:       tktypes.RandBytes32().String(),
+			SchemaId: "coin",
+			DataJson: mustParseJSON(coin),
+		})
+	}
+	if end < len(cb.coins) {
+		nextPageCursor := strconv.Itoa(end)
+		res.NextPageCursor = &nextPageCursor
+	}
+	return res, nil
+}
+
+func TestPrepareInputsCoinSelection(t *testing.T) {
+	ctx := context.Background()
+	owner := tktypes.RandAddress()
+	callbacks := &createdOrderCoinCallbacks{t: t}
+	// More than one page of coins, with the largest and smallest on the last page
+	for _, amount := range []int64{5, 4, 3, 6, 7, 4, 5, 6, 3, 4, 5, 1, 10, 2} {
+		callbacks.coins = append(callbacks.coins, &types.NotoCoin{
+			Salt:   tktypes.RandBytes32(),
+			Owner:  owner,
+			Amount: tktypes.Int64ToInt256(amount),
+		})
+	}
+	selected := func(coinSelection string, amount int64) []int64 {
+		n := &Noto{Callbacks: callbacks}
+		_, err := n.ConfigureDomain(ctx, &prototk.ConfigureDomainRequest{
+			ConfigJson: fmt.Sprintf(`{"coinSelection": "%s"}`, coinSelection),
+		})
+		require.NoError(t, err)
+		n.coinSchema = &prototk.StateSchema{Id: "coin"}
+		inputs, revert, err := n.prepareInputs(ctx, "query1", owner, tktypes.Int64ToInt256(amount))
+		require.NoError(t, err)
+		assert.False(t, revert)
+		amounts := make([]int64, len(inputs.coins))
+		for i, coin := range inputs.coins {
+			amounts[i] = coin.Amount.Int().Int64()
+		}
+		return amounts
+	}
+
+	// Oldest first (the default) spends the coins in the order they were created
+	assert.Equal(t, []int64{5, 4}, selected("", 6))
+	assert.Equal(t, []int64{5, 4, 3}, selected("oldest_first", 12))
+
+	// Largest first spends the fewest coins, with equal amounts oldest first
+	assert.Equal(t, []int64{10}, selected("largest_first", 6))
+	assert.Equal(t, []int64{10, 7}, selected("largest_first", 12))
+
+	// Smallest first uses up the small coins before the large ones
+	assert.Equal(t, []int64{1, 2, 3}, selected("smallest_first", 6))
+	assert.Equal(t, []int64{1, 2, 3, 3, 4}, selected("smallest_first", 12))
+
+	// Selection across all the coins is still insufficient funds when they do not add up
+	n := &Noto{Callbacks: callbacks, coinSelection: types.CoinSelectionLargestFirst, coinSchema: &prototk.StateSchema{Id: "coin"}}
+	_, revert, err := n.prepareInputs(ctx, "query1", owner, tktypes.Int64ToInt256(66))
+	assert.Regexp(t, "PD200005.*65", err)
+	assert.True(t, revert)
+}
@@ -34,6 +34,9 @@ type ConstructorParams struct {
 	NotaryMode     NotaryMode  `json:"notaryMode"`               // Notary mode (basic or hooks)
 	Implementation string      `json:"implementation,omitempty"` // Use a specific implementation of Noto that was registered to the factory (blank to use default)
 	Options        NotoOptions `json:"options"`                  // Configure options for the chosen notary mode
+	Name           string      `json:"name,omitempty"`           // Display name of the token
+	Symbol         string      `json:"symbol,omitempty"`         // Display symbol of the token
+	Decimals       *int        `json:"decimals,omitempty"`       // Number of decimals used when displaying amounts (0-36, default 0)
 }
 
 type NotaryMode string
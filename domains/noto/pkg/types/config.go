@@ -16,6 +16,8 @@
 package types
 
 import (
+	"context"
+
 	"github.com/hyperledger/firefly-signer/pkg/abi"
 	"github.com/kaleido-io/paladin/toolkit/pkg/domain"
 	"github.com/kaleido-io/paladin/toolkit/pkg/tktypes"
@@ -23,6 +25,40 @@ import (
 
 type DomainConfig struct {
 	FactoryAddress string `json:"factoryAddress"`
+	// How long a notary endorsement remains valid for submission (a Go duration such as "30s").
+	// A stale endorsement is rejected at prepare time, so that it is gathered again. Empty means no expiry.
+	EndorsementValidity string `json:"endorsementValidity,omitempty"`
+	// The order in which a holder's coins are spent, to make up the amount of a transfer, burn or lock
+	CoinSelection tktypes.Enum[CoinSelection] `json:"coinSelection,omitempty"`
+}
+
+type CoinSelection string
+
+const (
+	CoinSelectionOldestFirst   CoinSelection = "oldest_first"   // the order the coins were created
+	CoinSelectionLargestFirst  CoinSelection = "largest_first"  // fewest inputs for each transaction
+	CoinSelectionSmallestFirst CoinSelection = "smallest_first" // consolidates small coins over time, rather than leaving them as dust
+)
+
+func (cs CoinSelection) Enum() tktypes.Enum[CoinSelection] {
+	return tktypes.Enum[CoinSelection](cs)
+}
+
+func (cs CoinSelection) Options() []string {
+	return []string{
+		string(CoinSelectionOldestFirst),
+		string(CoinSelectionLargestFirst),
+		string(CoinSelectionSmallestFirst),
+	}
+}
+
+func (cs CoinSelection) Default() string {
+	return string(CoinSelectionOldestFirst)
+}
+
+// Returned as the payload of the notary's endorsement when an endorsement validity window is configured
+type NotaryEndorsementPayload struct {
+	EndorsedAt tktypes.Timestamp `json:"endorsedAt"`
 }
 
 var NotoConfigID_V0 = tktypes.MustParseHexBytes("0x00010000")
@@ -43,8 +79,16 @@ type NotoConfigData_V0 struct {
 	RestrictMint   bool                `json:"restrictMint"`
 	AllowBurn      bool                `json:"allowBurn"`
 	AllowLock      bool                `json:"allowLock"`
+	Name           string              `json:"name,omitempty"`
+	Symbol         string              `json:"symbol,omitempty"`
+	Decimals       *int                `json:"decimals,omitempty"` // nil for tokens deployed before display metadata was introduced
 }
 
+const (
+	DefaultDecimals = 0
+	MaxDecimals     = 36
+)
+
 // This is the structure we parse the config into in InitConfig and gets passed back to us on every call
 type NotoParsedConfig struct {
 	NotaryLookup string                   `json:"notaryLookup"`
@@ -52,6 +96,33 @@ type NotoParsedConfig struct {
 	Variant      tktypes.HexUint64        `json:"variant"`
 	IsNotary     bool                     `json:"isNotary"`
 	Options      NotoOptions              `json:"options"`
+	Name         string                   `json:"name,omitempty"`
+	Symbol       string                   `json:"symbol,omitempty"`
+	Decimals     int                      `json:"decimals"`
+}
+
+// IsPrivateGroupMember checks whether an identity is a member of the Pente privacy group the token is scoped to.
+// Both the lookup and the configured members are fully qualified against the local node before comparison,
+// consistent with how identities are resolved elsewhere in the domain. Returns false if the token is not
+// scoped to a privacy group.
+func (c *NotoParsedConfig) IsPrivateGroupMember(ctx context.Context, localNodeName, lookup string) (bool, error) {
+	if c.Options.Hooks == nil || c.Options.Hooks.PrivateGroup == nil {
+		return false, nil
+	}
+	qualifiedLookup, err := tktypes.PrivateIdentityLocator(lookup).FullyQualified(ctx, localNodeName)
+	if err != nil {
+		return false, err
+	}
+	for _, member := range c.Options.Hooks.PrivateGroup.Members {
+		qualifiedMember, err := tktypes.PrivateIdentityLocator(member).FullyQualified(ctx, localNodeName)
+		if err != nil {
+			return false, err
+		}
+		if qualifiedMember == qualifiedLookup {
+			return true, nil
+		}
+	}
+	return false, nil
 }
 
 type NotoOptions struct {
@@ -0,0 +1,92 @@
+/*
+ * Copyright © 2024 Kaleido, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with
+ * the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package types
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kaleido-io/paladin/toolkit/pkg/tktypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newPrivateGroupConfig(members ...string) *NotoParsedConfig {
+	return &NotoParsedConfig{
+		NotaryMode: NotaryModeHooks.Enum(),
+		Options: NotoOptions{
+			Hooks: &NotoHooksOptions{
+				PrivateGroup: &PentePrivateGroup{
+					Salt:    tktypes.RandBytes32(),
+					Members: members,
+				},
+			},
+		},
+	}
+}
+
+func TestIsPrivateGroupMember(t *testing.T) {
+	ctx := context.Background()
+	config := newPrivateGroupConfig("alice@node1", "bob", "carol@node2")
+
+	for _, lookup := range []string{"alice@node1", "alice", "bob@node1", "bob", "carol@node2"} {
+		isMember, err := config.IsPrivateGroupMember(ctx, "node1", lookup)
+		require.NoError(t, err)
+		assert.True(t, isMember, lookup)
+	}
+
+	// Identities are case sensitive, as they are for all other lookups in the domain
+	for _, lookup := range []string{"dave@node1", "carol", "carol@node1", "alice@node2", "Alice@node1", "bob@Node1"} {
+		isMember, err := config.IsPrivateGroupMember(ctx, "node1", lookup)
+		require.NoError(t, err)
+		assert.False(t, isMember, lookup)
+	}
+
+	// Unqualified members resolve against the local node
+	isMember, err := config.IsPrivateGroupMember(ctx, "node2", "bob")
+	require.NoError(t, err)
+	assert.True(t, isMember)
+	isMember, err = config.IsPrivateGroupMember(ctx, "node2", "bob@node1")
+	require.NoError(t, err)
+	assert.False(t, isMember)
+}
+
+func TestIsPrivateGroupMemberNoGroup(t *testing.T) {
+	ctx := context.Background()
+
+	isMember, err := (&NotoParsedConfig{NotaryMode: NotaryModeBasic.Enum()}).IsPrivateGroupMember(ctx, "node1", "alice@node1")
+	require.NoError(t, err)
+	assert.False(t, isMember)
+
+	isMember, err = (&NotoParsedConfig{
+		NotaryMode: NotaryModeHooks.Enum(),
+		Options: NotoOptions{
+			Hooks: &NotoHooksOptions{DevUsePublicHooks: true},
+		},
+	}).IsPrivateGroupMember(ctx, "node1", "alice@node1")
+	require.NoError(t, err)
+	assert.False(t, isMember)
+}
+
+func TestIsPrivateGroupMemberBadLookup(t *testing.T) {
+	ctx := context.Background()
+
+	_, err := newPrivateGroupConfig("alice@node1").IsPrivateGroupMember(ctx, "node1", "alice@node1@bad")
+	assert.ErrorContains(t, err, "PD020006")
+
+	_, err = newPrivateGroupConfig("alice@node1@bad").IsPrivateGroupMember(ctx, "node1", "alice@node1")
+	assert.ErrorContains(t, err, "PD020006")
+}
@@ -53,6 +53,11 @@ func (ffe *pdError) MessageKey() ErrorMessageKey {
 	return ffe.msgKey
 }
 
+// Allows errors.Is and errors.As to find an error that has been wrapped by WrapError
+func (ffe *pdError) Unwrap() error {
+	return ffe.error
+}
+
 func (ffe *pdError) HTTPStatus() int {
 	return ffe.status
 }
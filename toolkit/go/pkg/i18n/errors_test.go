@@ -18,6 +18,7 @@ package i18n
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
 	"testing"
@@ -50,6 +51,16 @@ func TestWrapError(t *testing.T) {
 	assert.NotEmpty(t, stackString)
 }
 
+func TestWrapErrorUnwrap(t *testing.T) {
+	inner := NewError(context.Background(), TestError3, "field", "value")
+	err := WrapError(context.Background(), inner, TestError1)
+	assert.ErrorIs(t, err, inner)
+
+	var pdErr PDError
+	assert.True(t, errors.As(errors.Unwrap(err), &pdErr))
+	assert.Equal(t, TestError3, pdErr.MessageKey())
+}
+
 func TestSafeStackFail(t *testing.T) {
 	stackString := (&pdError{}).StackTrace()
 	assert.Empty(t, stackString)
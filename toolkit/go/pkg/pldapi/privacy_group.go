@@ -52,6 +52,14 @@ type PrivacyGroupMessage struct {
 	PrivacyGroupMessageInput
 }
 
+type PrivacyGroupMessageStats struct {
+	Domain              string             `docstruct:"PrivacyGroupMessageStats" json:"domain"`
+	Group               tktypes.HexBytes   `docstruct:"PrivacyGroupMessageStats" json:"group"`
+	Count               int64              `docstruct:"PrivacyGroupMessageStats" json:"count"`
+	LatestLocalSequence *uint64            `docstruct:"PrivacyGroupMessageStats" json:"latestLocalSequence,omitempty"`
+	LatestSent          *tktypes.Timestamp `docstruct:"PrivacyGroupMessageStats" json:"latestSent,omitempty"`
+}
+
 type PrivacyGroupMessageInput struct {
 	CorrelationID *uuid.UUID       `docstruct:"PrivacyGroupMessage" json:"correlationId,omitempty"`
 	Domain        string           `docstruct:"PrivacyGroupMessage" json:"domain"`
@@ -60,6 +68,13 @@ type PrivacyGroupMessageInput struct {
 	Data          tktypes.RawJSON  `docstruct:"PrivacyGroupMessage" json:"data,omitempty"`
 }
 
+type PrivacyGroupMessageSearch struct {
+	Domain string           `docstruct:"PrivacyGroupMessageSearch" json:"domain,omitempty"`
+	Group  tktypes.HexBytes `docstruct:"PrivacyGroupMessageSearch" json:"group,omitempty"`
+	Text   string           `docstruct:"PrivacyGroupMessageSearch" json:"text"`
+	Limit  *int             `docstruct:"PrivacyGroupMessageSearch" json:"limit,omitempty"`
+}
+
 type PrivacyGroupInput struct {
 	Domain             string                 `docstruct:"PrivacyGroup" json:"domain"`
 	Members            []string               `docstruct:"PrivacyGroup" json:"members"`
@@ -40,10 +40,45 @@ type PublicTxGasPricing struct {
 	GasPrice             *tktypes.HexUint256 `docstruct:"PublicTxGasPricing" json:"gasPrice,omitempty"`
 }
 
+// Aggregate of the gas price samples recorded within one bucket of a time range.
+// The price of each sample is the legacy gas price, or the EIP-1559 max fee per gas.
+type GasPriceHistoryEntry struct {
+	Start   tktypes.Timestamp   `docstruct:"GasPriceHistoryEntry" json:"start"`
+	End     tktypes.Timestamp   `docstruct:"GasPriceHistoryEntry" json:"end"`
+	Samples int                 `docstruct:"GasPriceHistoryEntry" json:"samples"`
+	Min     *tktypes.HexUint256 `docstruct:"GasPriceHistoryEntry" json:"min,omitempty"`
+	Max     *tktypes.HexUint256 `docstruct:"GasPriceHistoryEntry" json:"max,omitempty"`
+	Avg     *tktypes.HexUint256 `docstruct:"GasPriceHistoryEntry" json:"avg,omitempty"`
+}
+
+// Diagnostics of the orchestrators the public transaction engine currently has in flight, one per signing address
+type PublicTxEngineStatus struct {
+	MaxInFlightOrchestrators int                           `docstruct:"PublicTxEngineStatus" json:"maxInFlightOrchestrators"`
+	Orchestrators            []*PublicTxOrchestratorStatus `docstruct:"PublicTxEngineStatus" json:"orchestrators"`
+}
+
+// A point-in-time view of the orchestrator for one signing address. A nonce gap means a nonce between the
+// completed watermark and the highest in-flight nonce is not in flight, so the transactions after it cannot be mined.
+type PublicTxOrchestratorStatus struct {
+	SigningAddress          tktypes.EthAddress `docstruct:"PublicTxOrchestratorStatus" json:"signingAddress"`
+	State                   string             `docstruct:"PublicTxOrchestratorStatus" json:"state"`
+	StateEntryTime          tktypes.Timestamp  `docstruct:"PublicTxOrchestratorStatus" json:"stateEntryTime"`
+	InFlightCount           int                `docstruct:"PublicTxOrchestratorStatus" json:"inFlightCount"`
+	TotalCompleted          int64              `docstruct:"PublicTxOrchestratorStatus" json:"totalCompleted"`
+	GasPriceMultiplier      float64            `docstruct:"PublicTxOrchestratorStatus" json:"gasPriceMultiplier"`
+	GasPriceOverridden      bool               `docstruct:"PublicTxOrchestratorStatus" json:"gasPriceOverridden"`
+	LowestInFlightNonce     *tktypes.HexUint64 `docstruct:"PublicTxOrchestratorStatus" json:"lowestInFlightNonce,omitempty"`
+	HighestInFlightNonce    *tktypes.HexUint64 `docstruct:"PublicTxOrchestratorStatus" json:"highestInFlightNonce,omitempty"`
+	CompletedNonceWatermark *tktypes.HexUint64 `docstruct:"PublicTxOrchestratorStatus" json:"completedNonceWatermark,omitempty"` // nonces at or below this are known to be mined
+	NonceGap                bool               `docstruct:"PublicTxOrchestratorStatus" json:"nonceGap"`
+}
+
 type PublicTxInput struct {
-	From *tktypes.EthAddress `docstruct:"PublicTxInput" json:"from"`           // resolved signing account
-	To   *tktypes.EthAddress `docstruct:"PublicTxInput" json:"to,omitempty"`   // target contract address, or nil for deploy
-	Data tktypes.HexBytes    `docstruct:"PublicTxInput" json:"data,omitempty"` // the pre-encoded calldata
+	From  *tktypes.EthAddress `docstruct:"PublicTxInput" json:"from"`            // resolved signing account
+	To    *tktypes.EthAddress `docstruct:"PublicTxInput" json:"to,omitempty"`    // target contract address, or nil for deploy
+	Data  tktypes.HexBytes    `docstruct:"PublicTxInput" json:"data,omitempty"`  // the pre-encoded calldata
+	Label string              `docstruct:"PublicTxInput" json:"label,omitempty"` // free-text label for searching, with no effect on submission
+	Nonce *tktypes.HexUint64  `docstruct:"PublicTxInput" json:"nonce,omitempty"` // explicit nonce for recovery, such as filling a nonce gap - bypasses automatic assignment
 	PublicTxOptions
 }
 
@@ -66,15 +101,49 @@ type PublicTx struct {
 	From            tktypes.EthAddress          `docstruct:"PublicTx" json:"from"`
 	Nonce           *tktypes.HexUint64          `docstruct:"PublicTx" json:"nonce"`
 	Created         tktypes.Timestamp           `docstruct:"PublicTx" json:"created"`
-	CompletedAt     *tktypes.Timestamp          `docstruct:"PublicTx" json:"completedAt,omitempty"` // only once confirmed
-	TransactionHash *tktypes.Bytes32            `docstruct:"PublicTx" json:"transactionHash"`       // only once confirmed
-	Success         *bool                       `docstruct:"PublicTx" json:"success,omitempty"`     // only once confirmed
-	RevertData      tktypes.HexBytes            `docstruct:"PublicTx" json:"revertData,omitempty"`  // only once confirmed, if available
+	Updated         tktypes.Timestamp           `docstruct:"PublicTx" json:"updated"`                // last change to the transaction, its submissions or its completion
+	CompletedAt     *tktypes.Timestamp          `docstruct:"PublicTx" json:"completedAt,omitempty"`  // only once confirmed
+	TransactionHash *tktypes.Bytes32            `docstruct:"PublicTx" json:"transactionHash"`        // only once confirmed
+	Success         *bool                       `docstruct:"PublicTx" json:"success,omitempty"`      // only once confirmed
+	RevertData      tktypes.HexBytes            `docstruct:"PublicTx" json:"revertData,omitempty"`   // only once confirmed, if available
+	RevertReason    string                      `docstruct:"PublicTx" json:"revertReason,omitempty"` // only once confirmed, if the revert data is a standard error
 	Submissions     []*PublicTxSubmissionData   `docstruct:"PublicTx" json:"submissions,omitempty"`
 	Activity        []TransactionActivityRecord `docstruct:"PublicTx" json:"activity,omitempty"`
+	Label           string                      `docstruct:"PublicTx" json:"label,omitempty"`
+	FailureCategory PublicTxFailureCategory     `docstruct:"PublicTx" json:"failureCategory,omitempty"` // the last failure, until the transaction succeeds
 	PublicTxOptions
 }
 
+// The category of a failure to sign, submit or confirm a public transaction,
+// so that clients can branch on the cause without parsing node error messages
+type PublicTxFailureCategory string
+
+const (
+	PublicTxFailureInsufficientFunds PublicTxFailureCategory = "insufficient_funds"
+	PublicTxFailureNonceTooLow       PublicTxFailureCategory = "nonce_too_low"
+	PublicTxFailureUnderpriced       PublicTxFailureCategory = "underpriced"
+	PublicTxFailureReverted          PublicTxFailureCategory = "reverted"
+	PublicTxFailureTimeout           PublicTxFailureCategory = "timeout"
+	PublicTxFailureSignerUnavailable PublicTxFailureCategory = "signer_unavailable"
+	PublicTxFailureSignerKeyLost     PublicTxFailureCategory = "signer_key_lost"
+)
+
+func (fc PublicTxFailureCategory) Enum() tktypes.Enum[PublicTxFailureCategory] {
+	return tktypes.Enum[PublicTxFailureCategory](fc)
+}
+
+func (fc PublicTxFailureCategory) Options() []string {
+	return []string{
+		string(PublicTxFailureInsufficientFunds),
+		string(PublicTxFailureNonceTooLow),
+		string(PublicTxFailureUnderpriced),
+		string(PublicTxFailureReverted),
+		string(PublicTxFailureTimeout),
+		string(PublicTxFailureSignerUnavailable),
+		string(PublicTxFailureSignerKeyLost),
+	}
+}
+
 type PublicTxBinding struct {
 	Transaction     uuid.UUID                     `docstruct:"PublicTxBinding" json:"transaction"`
 	TransactionType tktypes.Enum[TransactionType] `docstruct:"PublicTxBinding" json:"transactionType"`
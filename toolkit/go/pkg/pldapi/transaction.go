@@ -56,6 +56,20 @@ func (tt PTXEventType) Options() []string {
 	}
 }
 
+// Sent in a final ptx_subscriptionClosed notification when the server closes a subscription
+type PTXSubscriptionCloseReason string
+
+const (
+	PTXSubscriptionCloseReasonServerShutdown  PTXSubscriptionCloseReason = "server_shutdown"
+	PTXSubscriptionCloseReasonListenerDeleted PTXSubscriptionCloseReason = "listener_deleted"
+	PTXSubscriptionCloseReasonNackedOut       PTXSubscriptionCloseReason = "nacked_out"
+	PTXSubscriptionCloseReasonSuperseded      PTXSubscriptionCloseReason = "superseded"
+)
+
+type PTXSubscriptionClosed struct {
+	Reason PTXSubscriptionCloseReason `docstruct:"PTXSubscriptionClosed" json:"reason"`
+}
+
 type SubmitMode string
 
 const (
@@ -107,9 +121,10 @@ type Transaction struct {
 // The input structure, containing the base input/output fields, along with some convenience fields resolved on input
 type TransactionInput struct {
 	TransactionBase
-	DependsOn []uuid.UUID      `docstruct:"TransactionInput" json:"dependsOn,omitempty"` // these transactions must be mined on the blockchain successfully (or deleted) before this transaction submits. Failure of pre-reqs results in failure of this TX
-	ABI       abi.ABI          `docstruct:"TransactionInput" json:"abi,omitempty"`       // required if abiReference not supplied
-	Bytecode  tktypes.HexBytes `docstruct:"TransactionInput" json:"bytecode,omitempty"`  // for deploy this is prepended to the encoded data inputs
+	DependsOn []uuid.UUID        `docstruct:"TransactionInput" json:"dependsOn,omitempty"` // these transactions must be mined on the blockchain successfully (or deleted) before this transaction submits. Failure of pre-reqs results in failure of this TX
+	ABI       abi.ABI            `docstruct:"TransactionInput" json:"abi,omitempty"`       // required if abiReference not supplied
+	Bytecode  tktypes.HexBytes   `docstruct:"TransactionInput" json:"bytecode,omitempty"`  // for deploy this is prepended to the encoded data inputs
+	Nonce     *tktypes.HexUint64 `docstruct:"TransactionInput" json:"nonce,omitempty"`     // public only - explicit nonce for recovery, such as filling a nonce gap. Bypasses automatic assignment
 }
 
 // Call also provides some options on how to execute the call
@@ -152,6 +167,24 @@ type TransactionReceiptBatch struct {
 	Receipts []*TransactionReceiptFull `docstruct:"TransactionReceiptBatch" json:"receipts,omitempty"`
 }
 
+// Optional final parameter on ptx_subscribe for receipts
+type TransactionReceiptSubscriptionOptions struct {
+	MaxBatchSize *int     `docstruct:"TransactionReceiptSubscriptionOptions" json:"maxBatchSize,omitempty"` // capped at the server's receipt read page size
+	Fields       []string `docstruct:"TransactionReceiptSubscriptionOptions" json:"fields,omitempty"`       // only these receipt fields are delivered, with dots to select nested fields such as "states.confirmed"
+	Resume       bool     `docstruct:"TransactionReceiptSubscriptionOptions" json:"resume,omitempty"`       // take over the listener from any other subscription, with any unacknowledged batch redelivered immediately
+	BatchTimeout *string  `docstruct:"TransactionReceiptSubscriptionOptions" json:"batchTimeout,omitempty"` // wait up to this long after new receipts before reading them, so receipts in quick succession coalesce into one batch
+}
+
+// Snapshot of a receipt subscription that is active on a JSON/RPC connection to this node
+type TransactionReceiptSubscriptionStatus struct {
+	ID              string            `docstruct:"TransactionReceiptSubscriptionStatus" json:"id"`
+	Listener        string            `docstruct:"TransactionReceiptSubscriptionStatus" json:"listener"`
+	Created         tktypes.Timestamp `docstruct:"TransactionReceiptSubscriptionStatus" json:"created"`
+	Age             string            `docstruct:"TransactionReceiptSubscriptionStatus" json:"age"`                       // how long the subscription has been connected
+	InFlightBatchID *uint64           `docstruct:"TransactionReceiptSubscriptionStatus" json:"inFlightBatchId,omitempty"` // set while a batch is awaiting an ack/nack
+	AckLag          string            `docstruct:"TransactionReceiptSubscriptionStatus" json:"ackLag,omitempty"`          // how long the in-flight batch has been awaiting an ack/nack
+}
+
 type TransactionReceiptDataOnchain struct {
 	TransactionHash  *tktypes.Bytes32 `docstruct:"TransactionReceiptDataOnchain" json:"transactionHash,omitempty"`
 	BlockNumber      int64            `docstruct:"TransactionReceiptDataOnchain" json:"blockNumber,omitempty"`
@@ -78,3 +78,15 @@ type ReliableMessageAck struct {
 func (rma ReliableMessageAck) TableName() string {
 	return "reliable_msg_acks"
 }
+
+type ReliableMessageRetryStatus struct {
+	MessageID     uuid.UUID          `docstruct:"ReliableMessageRetryStatus" json:"messageId"`
+	Node          string             `docstruct:"ReliableMessageRetryStatus" json:"node"`
+	Acknowledged  bool               `docstruct:"ReliableMessageRetryStatus" json:"acknowledged"`
+	AckError      string             `docstruct:"ReliableMessageRetryStatus" json:"ackError,omitempty"`
+	PeerActive    bool               `docstruct:"ReliableMessageRetryStatus" json:"peerActive"`
+	Attempts      int                `docstruct:"ReliableMessageRetryStatus" json:"attempts"`
+	LastAttempt   *tktypes.Timestamp `docstruct:"ReliableMessageRetryStatus" json:"lastAttempt,omitempty"`
+	NextAttempt   *tktypes.Timestamp `docstruct:"ReliableMessageRetryStatus" json:"nextAttempt,omitempty"`
+	RetryFailures int                `docstruct:"ReliableMessageRetryStatus" json:"retryFailures"`
+}
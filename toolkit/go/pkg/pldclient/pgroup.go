@@ -39,6 +39,10 @@ type PrivacyGroups interface {
 	SendMessage(ctx context.Context, msg *pldapi.PrivacyGroupMessageInput) (msgID uuid.UUID, err error)
 	GetMessageById(ctx context.Context, id uuid.UUID) (msg *pldapi.PrivacyGroupMessage, err error)
 	QueryMessages(ctx context.Context, q *query.QueryJSON) (msgs []*pldapi.PrivacyGroupMessage, err error)
+	ConsumeMessages(ctx context.Context, consumerID string, limit int) (msgs []*pldapi.PrivacyGroupMessage, err error)
+	AckConsumedMessages(ctx context.Context, consumerID string, upToLocalSeq uint64) (success bool, err error)
+	GetMessageDistributionStatus(ctx context.Context, id uuid.UUID) (statuses []*pldapi.ReliableMessageRetryStatus, err error)
+	RetryMessageDistribution(ctx context.Context, id uuid.UUID) (success bool, err error)
 
 	CreateMessageListener(ctx context.Context, listener *pldapi.PrivacyGroupMessageListener) (success bool, err error)
 	QueryMessageListeners(ctx context.Context, jq *query.QueryJSON) (listeners []*pldapi.PrivacyGroupMessageListener, err error)
@@ -102,6 +106,22 @@ var privacyGroupsInfo = &rpcModuleInfo{
 			Inputs: []string{"query"},
 			Output: "msgs",
 		},
+		"pgroup_consumeMessages": {
+			Inputs: []string{"consumerId", "limit"},
+			Output: "msgs",
+		},
+		"pgroup_ackConsumedMessages": {
+			Inputs: []string{"consumerId", "upToLocalSequence"},
+			Output: "success",
+		},
+		"pgroup_getMessageDistributionStatus": {
+			Inputs: []string{"id"},
+			Output: "statuses",
+		},
+		"pgroup_retryMessageDistribution": {
+			Inputs: []string{"id"},
+			Output: "success",
+		},
 		"pgroup_createMessageListener": {
 			Inputs: []string{"listener"},
 			Output: "success",
@@ -195,6 +215,26 @@ func (r *pgroup) QueryMessages(ctx context.Context, jq *query.QueryJSON) (msgs [
 	return
 }
 
+func (r *pgroup) ConsumeMessages(ctx context.Context, consumerID string, limit int) (msgs []*pldapi.PrivacyGroupMessage, err error) {
+	err = r.c.CallRPC(ctx, &msgs, "pgroup_consumeMessages", consumerID, limit)
+	return
+}
+
+func (r *pgroup) AckConsumedMessages(ctx context.Context, consumerID string, upToLocalSeq uint64) (success bool, err error) {
+	err = r.c.CallRPC(ctx, &success, "pgroup_ackConsumedMessages", consumerID, upToLocalSeq)
+	return
+}
+
+func (r *pgroup) GetMessageDistributionStatus(ctx context.Context, id uuid.UUID) (statuses []*pldapi.ReliableMessageRetryStatus, err error) {
+	err = r.c.CallRPC(ctx, &statuses, "pgroup_getMessageDistributionStatus", id)
+	return
+}
+
+func (r *pgroup) RetryMessageDistribution(ctx context.Context, id uuid.UUID) (success bool, err error) {
+	err = r.c.CallRPC(ctx, &success, "pgroup_retryMessageDistribution", id)
+	return
+}
+
 func (r *pgroup) CreateMessageListener(ctx context.Context, listener *pldapi.PrivacyGroupMessageListener) (success bool, err error) {
 	err = r.c.CallRPC(ctx, &success, "pgroup_createMessageListener", listener)
 	return
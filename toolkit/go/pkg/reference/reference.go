@@ -120,6 +120,7 @@ var allTypes = []interface{}{
 	pldapi.KeyMappingAndVerifier{},
 	pldapi.ReliableMessageAck{},
 	pldapi.ReliableMessage{},
+	pldapi.ReliableMessageRetryStatus{},
 	pldapi.PrivacyGroup{},
 	pldapi.PrivacyGroupEVMCall{},
 	pldapi.PrivacyGroupEVMTXInput{},
@@ -60,6 +60,12 @@ func NewRetryLimited(conf *pldconf.RetryConfigWithMax, defaults ...*pldconf.Retr
 // This simple interface doesn't pass through errors or return values, on the basis
 // you'll be using a closure for that.
 func (r *Retry) Do(ctx context.Context, do func(attempt int) (retryable bool, err error)) error {
+	return r.DoWakeable(ctx, nil, do)
+}
+
+// DoWakeable is the same as Do, except a signal on the wake channel cuts short the wait
+// before the next attempt, so it is made immediately.
+func (r *Retry) DoWakeable(ctx context.Context, wake <-chan bool, do func(attempt int) (retryable bool, err error)) error {
 	attempt := 0
 	for {
 		attempt++
@@ -70,25 +76,40 @@ func (r *Retry) Do(ctx context.Context, do func(attempt int) (retryable bool, er
 		if !retry || err == nil || (r.maxAttempts > 0 && attempt >= r.maxAttempts) {
 			return err
 		}
-		if err := r.WaitDelay(ctx, attempt); err != nil {
+		if err := r.waitDelay(ctx, attempt, wake); err != nil {
 			return err
 		}
 	}
 }
 
+// Delay returns the backoff delay that applies after the given number of failures
+func (r *Retry) Delay(failureCount int) time.Duration {
+	if failureCount <= 0 {
+		return 0
+	}
+	retryDelay := r.initialDelay
+	for i := 0; i < (failureCount - 1); i++ {
+		retryDelay = time.Duration(float64(retryDelay) * r.factor)
+		if retryDelay > r.maxDelay {
+			retryDelay = r.maxDelay
+			break
+		}
+	}
+	return retryDelay
+}
+
 func (r *Retry) WaitDelay(ctx context.Context, failureCount int) error {
+	return r.waitDelay(ctx, failureCount, nil)
+}
+
+func (r *Retry) waitDelay(ctx context.Context, failureCount int, wake <-chan bool) error {
 	if failureCount > 0 {
-		retryDelay := r.initialDelay
-		for i := 0; i < (failureCount - 1); i++ {
-			retryDelay = time.Duration(float64(retryDelay) * r.factor)
-			if retryDelay > r.maxDelay {
-				retryDelay = r.maxDelay
-				break
-			}
-		}
+		retryDelay := r.Delay(failureCount)
 		log.L(ctx).Debugf("Retrying after %.2f (failures=%d)", retryDelay.Seconds(), failureCount)
 		select {
 		case <-time.After(retryDelay):
+		case <-wake:
+			log.L(ctx).Debugf("Retrying immediately on wake (failures=%d)", failureCount)
 		case <-ctx.Done():
 			return i18n.NewError(ctx, tkmsgs.MsgContextCanceled)
 		}
@@ -55,6 +55,23 @@ func TestRetryDeadlineTimeout(t *testing.T) {
 	assert.Regexp(t, "PD020000", err)
 }
 
+func TestRetryWakeable(t *testing.T) {
+	r := NewRetryIndefinite(&pldconf.RetryConfig{
+		InitialDelay: confutil.P("1h"),
+		MaxDelay:     confutil.P("1h"),
+	})
+	wake := make(chan bool, 1)
+	err := r.DoWakeable(context.Background(), wake, func(i int) (retry bool, err error) {
+		if i < 3 {
+			// The wait of an hour would hang the test, unless woken
+			wake <- true
+			err = fmt.Errorf("pop")
+		}
+		return true, err
+	})
+	require.NoError(t, err)
+}
+
 func TestRetryContextCanceled(t *testing.T) {
 	r := NewRetryIndefinite(&pldconf.RetryConfig{
 		InitialDelay: confutil.P("1s"),
@@ -115,3 +132,17 @@ func TestDefaultsOverride(t *testing.T) {
 	assert.Equal(t, 42, r.maxAttempts)
 
 }
+
+func TestRetryDelay(t *testing.T) {
+	r := NewRetryIndefinite(&pldconf.RetryConfig{
+		InitialDelay: confutil.P("10ms"),
+		MaxDelay:     confutil.P("50ms"),
+		Factor:       confutil.P(2.0),
+	})
+	assert.Equal(t, time.Duration(0), r.Delay(0))
+	assert.Equal(t, 10*time.Millisecond, r.Delay(1))
+	assert.Equal(t, 20*time.Millisecond, r.Delay(2))
+	assert.Equal(t, 40*time.Millisecond, r.Delay(3))
+	assert.Equal(t, 50*time.Millisecond, r.Delay(4))
+	assert.Equal(t, 50*time.Millisecond, r.Delay(100))
+}
@@ -78,21 +78,25 @@ func (sm *signingModule[C]) initHDWallet(ctx context.Context, conf *pldconf.KeyD
 	keyHandle, seedResolve := configToKeyResolutionRequest(&seedKeyPath)
 	if keyHandle != "" {
 		// We have been provided a pre-resolved key handle
-		seed, err = sm.keyStore.LoadKeyMaterial(ctx, keyHandle)
+		seed, err = sm.loadKeyMaterial(ctx, keyHandle)
 	} else {
 		// We need to call resolve to resolve the key material
-		seed, _, err = sm.keyStore.FindOrCreateLoadableKey(ctx, seedResolve, sm.new32ByteRandomSeed)
+		seed, _, err = sm.findOrCreateLoadableKey(ctx, seedResolve, sm.new32ByteRandomSeed)
 	}
 	if err != nil {
 		return err
 	}
+	// The master key retains what it needs, so the seed is not needed after this function
+	defer sm.releaseKeyMaterial(seed)
 	// Now we might have a 32byte value, or something like a BIP-39 mnemonic that has been saved
 	// by a human/automation into a secrets repository
 	if len(seed) != 32 {
-		seed, err = bip39.NewSeedWithErrorChecking(string(seed), "")
+		mnemonicSeed, err := bip39.NewSeedWithErrorChecking(string(seed), "")
 		if err != nil {
 			return i18n.NewError(ctx, tkmsgs.MsgSigningHDSeedMustBe32BytesOrMnemonic)
 		}
+		defer sm.releaseKeyMaterial(mnemonicSeed)
+		seed = mnemonicSeed
 	}
 	sm.hd.hdKeyChain, err = hdkeychain.NewMaster(seed, &chaincfg.MainNetParams)
 	return err
@@ -156,6 +160,7 @@ func (hd *hdDerivation[C]) resolveHDWalletKey(ctx context.Context, req *signerap
 		keyHandle += fmt.Sprintf("/%d%s", derivation, hardenedFlag)
 	}
 	privateKey, err := hd.loadHDWalletPrivateKey(ctx, keyHandle)
+	defer hd.sm.releaseKeyMaterial(privateKey)
 	if err != nil {
 		return nil, err
 	}
@@ -196,6 +201,7 @@ func (hd *hdDerivation[C]) loadHDWalletPrivateKey(ctx context.Context, keyHandle
 
 func (hd *hdDerivation[C]) signHDWalletKey(ctx context.Context, req *signerapi.SignRequest) (res *signerapi.SignResponse, err error) {
 	privateKey, err := hd.loadHDWalletPrivateKey(ctx, req.KeyHandle)
+	defer hd.sm.releaseKeyMaterial(privateKey)
 	if err != nil {
 		return nil, err
 	}
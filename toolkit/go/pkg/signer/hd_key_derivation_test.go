@@ -381,3 +381,49 @@ func TestHDInitGenSeed(t *testing.T) {
 	assert.Len(t, generatedSeed, 32)
 	assert.NotEqual(t, make([]byte, 32), generatedSeed) // not zero
 }
+
+func TestHDSigningZeroKeyMaterial(t *testing.T) {
+
+	ctx := context.Background()
+	mnemonic := "extra monster happy tone improve slight duck equal sponsor fruit sister rate very bulb reopen mammal venture pull just motion faculty grab tenant kind"
+	sm, err := NewSigningModule(ctx, &signerapi.ConfigNoExt{
+		KeyDerivation: pldconf.KeyDerivationConfig{
+			Type:                  pldconf.KeyDerivationTypeBIP32,
+			BIP44Prefix:           confutil.P("m/44'/60'/0'/0"),
+			BIP44HardenedSegments: confutil.P(0),
+		},
+		KeyStore: pldconf.KeyStoreConfig{
+			Type:            pldconf.KeyStoreTypeStatic,
+			ZeroKeyMaterial: true,
+			Static: pldconf.StaticKeyStoreConfig{
+				Keys: map[string]pldconf.StaticKeyEntryConfig{
+					"seed": {
+						Encoding: "none",
+						Inline:   mnemonic,
+					},
+				},
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	// The derived keys are zeroed after each use, without affecting the keys derived next time
+	for i := 0; i < 2; i++ {
+		res, err := sm.Resolve(ctx, &signerapi.ResolveKeyRequest{
+			RequiredIdentifiers: []*signerapi.PublicKeyIdentifierType{{Algorithm: algorithms.ECDSA_SECP256K1, VerifierType: verifiers.ETH_ADDRESS}},
+			Name:                "key1",
+			Index:               0,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "0x6331ccb948aaf903a69d6054fd718062bd0d535c", res.Identifiers[0].Verifier)
+
+		_, err = sm.Sign(ctx, &signerapi.SignRequest{
+			KeyHandle:   res.KeyHandle,
+			Algorithm:   algorithms.ECDSA_SECP256K1,
+			PayloadType: signpayloads.OPAQUE_TO_RSV,
+			Payload:     ([]byte)("some data"),
+		})
+		require.NoError(t, err)
+	}
+
+}
@@ -17,13 +17,17 @@ package keystores
 
 import (
 	"context"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"io/fs"
 	"net/url"
 	"os"
 	"path"
 	"path/filepath"
+	"slices"
 	"strings"
+	"sync"
 
 	"github.com/hyperledger/firefly-signer/pkg/keystorev3"
 	"github.com/kaleido-io/paladin/config/pkg/confutil"
@@ -37,11 +41,45 @@ import (
 
 type filesystemStoreFactory[C signerapi.ExtensibleConfig] struct{}
 
+// Metadata property in the wallet file, recording the resolution path of the key
+const walletMetadataDerivationPath = "derivationPath"
+
+// Metadata property in the wallet file, recording the algorithms the key was created for
+const walletMetadataAlgorithms = "algorithms"
+
+// Metadata property in the wallet file, recording the master key wrapper applied to the password file
+const walletMetadataMasterKeyWrapper = "masterKeyWrapper"
+
+// Separates the version from the name in the key handle of rotated key material. The name is path
+// escaped in the key handle, so this can never clash with a name that contains the character.
+const keyVersionSeparator = ";"
+
+// Written alongside the original key file once a key has been rotated, to record the active version
+type keyAlias struct {
+	Version int `json:"version"`
+}
+
+type walletPathEntry struct {
+	Name  string            `json:"name"`
+	Index tktypes.HexUint64 `json:"index"` // string encoded to avoid loss of precision when read back from JSON
+}
+
 type filesystemStore struct {
 	cache    cache.Cache[string, keystorev3.WalletFile]
 	path     string
 	fileMode os.FileMode
 	dirMode  os.FileMode
+	kdf      *walletKDF
+	wrapper  signerapi.MasterKeyWrapper // nil if passwords are stored unwrapped
+
+	// serializes creation of each key, so concurrent resolvers of the same key all get the same material
+	keyLocksMux sync.Mutex
+	keyLocks    map[string]*keyLock
+}
+
+type keyLock struct {
+	sync.Mutex
+	refCount int
 }
 
 func NewFilesystemStoreFactory[C signerapi.ExtensibleConfig]() signerapi.KeyStoreFactory[C] {
@@ -60,14 +98,48 @@ func (fsf *filesystemStoreFactory[C]) NewKeyStore(ctx context.Context, eConf C)
 	if err != nil || !pathInfo.IsDir() {
 		return nil, i18n.WrapError(ctx, err, tkmsgs.MsgSigningModuleBadPathError, *pldconf.FileSystemDefaults.Path)
 	}
+	kdf, err := newWalletKDF(ctx, &conf.KDF)
+	if err != nil {
+		return nil, err
+	}
+	wrapper, err := newMasterKeyWrapper(ctx, &conf.MasterKeyWrapper)
+	if err != nil {
+		return nil, err
+	}
 	return &filesystemStore{
 		cache:    cache.NewCache[string, keystorev3.WalletFile](&conf.Cache, &pldconf.FileSystemDefaults.Cache),
 		fileMode: confutil.UnixFileMode(conf.FileMode, *pldconf.FileSystemDefaults.FileMode),
 		dirMode:  confutil.UnixFileMode(conf.DirMode, *pldconf.FileSystemDefaults.DirMode),
 		path:     path,
+		kdf:      kdf,
+		wrapper:  wrapper,
+		keyLocks: make(map[string]*keyLock),
 	}, nil
 }
 
+// Returns with the lock held for the key handle, until the returned function is called
+func (fss *filesystemStore) lockKey(keyHandle string) (unlock func()) {
+	fss.keyLocksMux.Lock()
+	kl := fss.keyLocks[keyHandle]
+	if kl == nil {
+		kl = &keyLock{}
+		fss.keyLocks[keyHandle] = kl
+	}
+	kl.refCount++
+	fss.keyLocksMux.Unlock()
+
+	kl.Lock()
+	return func() {
+		kl.Unlock()
+		fss.keyLocksMux.Lock()
+		defer fss.keyLocksMux.Unlock()
+		kl.refCount--
+		if kl.refCount == 0 {
+			delete(fss.keyLocks, keyHandle)
+		}
+	}
+}
+
 func (fss *filesystemStore) validateFilePathKeyHandle(ctx context.Context, keyHandle string, forCreate bool) (absPath string, err error) {
 
 	fullPath := fss.path
@@ -106,14 +178,17 @@ func (fss *filesystemStore) validateFilePathKeyHandle(ctx context.Context, keyHa
 
 }
 
-func (fss *filesystemStore) createWalletFile(ctx context.Context, keyFilePath, passwordFilePath string, newKeyMaterial func() ([]byte, error)) (keystorev3.WalletFile, error) {
+func (fss *filesystemStore) createWalletFile(ctx context.Context, keyFilePath, passwordFilePath string, derivationPath []*walletPathEntry, algorithms []string, newKeyMaterial func() ([]byte, error)) (keystorev3.WalletFile, error) {
 
 	privateKey, err := newKeyMaterial()
 	if err != nil {
 		return nil, err
 	}
 	password := tktypes.RandHex(32)
-	wf := keystorev3.NewWalletFileCustomBytesStandard(password, privateKey)
+	wf, err := fss.kdf.newWalletFile(ctx, password, privateKey)
+	if err != nil {
+		return nil, err
+	}
 
 	// Address is not part of the V3 standard, per
 	// https://github.com/ethereum/wiki/wiki/Web3-Secret-Storage-Definition#alterations-from-version-1
@@ -124,8 +199,20 @@ func (fss *filesystemStore) createWalletFile(ctx context.Context, keyFilePath, p
 	//
 	// So we use the feature from https://github.com/hyperledger/firefly-signer/pull/70 to remove it entirely
 	wf.Metadata()["address"] = nil
+	wf.Metadata()[walletMetadataDerivationPath] = derivationPath
+	wf.Metadata()[walletMetadataAlgorithms] = algorithms
 
-	err = os.WriteFile(passwordFilePath, []byte(password), fss.fileMode)
+	passwordData := []byte(password)
+	if fss.wrapper != nil {
+		wrapped, err := fss.wrapper.Wrap(ctx, passwordData)
+		if err != nil {
+			return nil, err
+		}
+		passwordData = []byte(hex.EncodeToString(wrapped))
+		wf.Metadata()[walletMetadataMasterKeyWrapper] = fss.wrapper.Type()
+	}
+
+	err = os.WriteFile(passwordFilePath, passwordData, fss.fileMode)
 	if err == nil {
 		err = os.WriteFile(keyFilePath, wf.JSON(), fss.fileMode)
 	}
@@ -135,7 +222,7 @@ func (fss *filesystemStore) createWalletFile(ctx context.Context, keyFilePath, p
 	return wf, nil
 }
 
-func (fss *filesystemStore) getOrCreateWalletFile(ctx context.Context, keyHandle string, newKeyMaterialFactory func() ([]byte, error)) (keystorev3.WalletFile, error) {
+func (fss *filesystemStore) getOrCreateWalletFile(ctx context.Context, keyHandle string, derivationPath []*walletPathEntry, algorithms []string, newKeyMaterialFactory func() ([]byte, error)) (keystorev3.WalletFile, error) {
 
 	absPathPrefix, err := fss.validateFilePathKeyHandle(ctx, keyHandle, newKeyMaterialFactory != nil)
 	if err != nil {
@@ -146,6 +233,16 @@ func (fss *filesystemStore) getOrCreateWalletFile(ctx context.Context, keyHandle
 	if cached != nil {
 		return cached, nil
 	}
+	if newKeyMaterialFactory != nil {
+		// We might create the key, so we need to ensure any concurrent creation of the
+		// same key has completed, and check again under the lock
+		unlock := fss.lockKey(keyHandle)
+		defer unlock()
+		cached, _ := fss.cache.Get(keyHandle)
+		if cached != nil {
+			return cached, nil
+		}
+	}
 	keyFilePath := fmt.Sprintf("%s.key", absPathPrefix)
 	passwordFilePath := fmt.Sprintf("%s.pwd", absPathPrefix)
 
@@ -153,7 +250,7 @@ func (fss *filesystemStore) getOrCreateWalletFile(ctx context.Context, keyHandle
 	if os.IsNotExist(checkNotExist) {
 		if newKeyMaterialFactory != nil {
 			// We need to create it
-			wf, err := fss.createWalletFile(ctx, keyFilePath, passwordFilePath, newKeyMaterialFactory)
+			wf, err := fss.createWalletFile(ctx, keyFilePath, passwordFilePath, derivationPath, algorithms, newKeyMaterialFactory)
 			if err == nil {
 				fss.cache.Set(keyHandle, wf)
 			}
@@ -182,34 +279,334 @@ func (fss *filesystemStore) readWalletFile(ctx context.Context, keyFilePath, pas
 		return nil, i18n.WrapError(ctx, err, tkmsgs.MsgSigningModuleBadPassFile, passwordFilePath)
 	}
 
+	passData, err = fss.unwrapPassword(ctx, keyFilePath, keyData, passData)
+	if err != nil {
+		return nil, err
+	}
+
 	return keystorev3.ReadWalletFile(keyData, passData)
 }
 
-func (fss *filesystemStore) FindOrCreateLoadableKey(ctx context.Context, req *signerapi.ResolveKeyRequest, newKeyMaterial func() ([]byte, error)) (keyMaterial []byte, keyHandle string, err error) {
+// Key files written before a wrapper was configured have no wrapper recorded, and their
+// passwords are used as-is
+func (fss *filesystemStore) unwrapPassword(ctx context.Context, keyFilePath string, keyData, passData []byte) ([]byte, error) {
+	var md struct {
+		MasterKeyWrapper string `json:"masterKeyWrapper"`
+	}
+	if err := json.Unmarshal(keyData, &md); err != nil {
+		return nil, i18n.WrapError(ctx, err, tkmsgs.MsgSigningModuleBadKeyFile, keyFilePath)
+	}
+	if md.MasterKeyWrapper == "" {
+		return passData, nil
+	}
+	configured := pldconf.MasterKeyWrapperNone
+	if fss.wrapper != nil {
+		configured = fss.wrapper.Type()
+	}
+	if md.MasterKeyWrapper != configured {
+		return nil, i18n.NewError(ctx, tkmsgs.MsgSigningModuleMasterKeyWrapperMismatch, keyFilePath, md.MasterKeyWrapper, configured)
+	}
+	wrapped, err := hex.DecodeString(string(passData))
+	if err != nil {
+		return nil, i18n.WrapError(ctx, err, tkmsgs.MsgSigningModuleMasterKeyUnwrapFailed, configured)
+	}
+	return fss.wrapper.Unwrap(ctx, wrapped)
+}
+
+func (fss *filesystemStore) resolveKeyRequest(ctx context.Context, req *signerapi.ResolveKeyRequest) (keyHandle string, derivationPath []*walletPathEntry, algorithms []string, err error) {
+	derivationPath = make([]*walletPathEntry, 0, len(req.Path)+1)
 	for _, segment := range req.Path {
 		if len(segment.Name) == 0 {
-			return nil, "", i18n.NewError(ctx, tkmsgs.MsgSigningModuleBadKeyHandle)
+			return "", nil, nil, i18n.NewError(ctx, tkmsgs.MsgSigningModuleBadKeyHandle)
 		}
 		keyHandle += url.PathEscape(segment.Name)
 		keyHandle += "/"
+		derivationPath = append(derivationPath, &walletPathEntry{Name: segment.Name, Index: tktypes.HexUint64(segment.Index)})
 	}
 	if len(req.Name) == 0 {
-		return nil, "", i18n.NewError(ctx, tkmsgs.MsgSigningModuleBadKeyHandle)
+		return "", nil, nil, i18n.NewError(ctx, tkmsgs.MsgSigningModuleBadKeyHandle)
 	}
 	keyHandle += url.PathEscape(req.Name)
-	wf, err := fss.getOrCreateWalletFile(ctx, keyHandle, newKeyMaterial)
+	derivationPath = append(derivationPath, &walletPathEntry{Name: req.Name, Index: tktypes.HexUint64(req.Index)})
+	algorithms = make([]string, 0, len(req.RequiredIdentifiers))
+	for _, ri := range req.RequiredIdentifiers {
+		if !slices.Contains(algorithms, ri.Algorithm) {
+			algorithms = append(algorithms, ri.Algorithm)
+		}
+	}
+	return keyHandle, derivationPath, algorithms, nil
+}
+
+// The original key material keeps the unversioned key handle, so keys that have never been rotated are unaffected
+func versionedKeyHandle(keyHandle string, version int) string {
+	if version == 0 {
+		return keyHandle
+	}
+	return fmt.Sprintf("%s%s%d", keyHandle, keyVersionSeparator, version)
+}
+
+// Reads the alias under the lock for the key handle, so it is not read part way through a rotation of the key
+func (fss *filesystemStore) currentKeyAliasVersion(ctx context.Context, keyHandle string) (int, error) {
+	unlock := fss.lockKey(keyHandle)
+	defer unlock()
+	return fss.readKeyAliasVersion(ctx, keyHandle)
+}
+
+// Must be called holding the lock for the key handle
+func (fss *filesystemStore) readKeyAliasVersion(ctx context.Context, keyHandle string) (int, error) {
+	absPathPrefix, err := fss.validateFilePathKeyHandle(ctx, keyHandle, false)
+	if err != nil {
+		return -1, err
+	}
+	aliasFilePath := fmt.Sprintf("%s.alias", absPathPrefix)
+	aliasData, err := os.ReadFile(aliasFilePath)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	var alias keyAlias
+	if err == nil {
+		err = json.Unmarshal(aliasData, &alias)
+	}
+	if err != nil || alias.Version < 0 {
+		return -1, i18n.WrapError(ctx, err, tkmsgs.MsgSigningModuleBadKeyAliasFile, aliasFilePath)
+	}
+	return alias.Version, nil
+}
+
+func (fss *filesystemStore) writeKeyAliasVersion(ctx context.Context, keyHandle string, version int) error {
+	absPathPrefix, err := fss.validateFilePathKeyHandle(ctx, keyHandle, false)
+	if err == nil {
+		aliasData, _ := json.Marshal(&keyAlias{Version: version})
+		err = fss.writeFileAtomic(fmt.Sprintf("%s.alias", absPathPrefix), aliasData)
+	}
+	if err != nil {
+		return i18n.WrapError(ctx, err, tkmsgs.MsgSigningModuleFSError)
+	}
+	return nil
+}
+
+func (fss *filesystemStore) FindOrCreateLoadableKey(ctx context.Context, req *signerapi.ResolveKeyRequest, newKeyMaterial func() ([]byte, error)) (keyMaterial []byte, keyHandle string, err error) {
+	keyHandle, derivationPath, algorithms, err := fss.resolveKeyRequest(ctx, req)
+	if err != nil {
+		return nil, "", err
+	}
+	// If the key has been rotated, the name is an alias for the latest version
+	version, err := fss.currentKeyAliasVersion(ctx, keyHandle)
+	if err != nil {
+		return nil, "", err
+	}
+	keyHandle = versionedKeyHandle(keyHandle, version)
+	wf, err := fss.getOrCreateWalletFile(ctx, keyHandle, derivationPath, algorithms, newKeyMaterial)
+	if err != nil {
+		return nil, "", err
+	}
+	return ownedKeyMaterial(wf), keyHandle, nil
+}
+
+func (fss *filesystemStore) KeyExists(ctx context.Context, req *signerapi.ResolveKeyRequest) (bool, string, error) {
+	keyHandle, _, _, err := fss.resolveKeyRequest(ctx, req)
+	if err != nil {
+		return false, "", err
+	}
+	version, err := fss.currentKeyAliasVersion(ctx, keyHandle)
+	if err != nil {
+		return false, "", err
+	}
+	keyHandle = versionedKeyHandle(keyHandle, version)
+	if _, cached := fss.cache.Get(keyHandle); cached {
+		return true, keyHandle, nil
+	}
+	absPathPrefix, err := fss.validateFilePathKeyHandle(ctx, keyHandle, false)
+	if err != nil {
+		return false, "", err
+	}
+	if _, err := os.Stat(fmt.Sprintf("%s.key", absPathPrefix)); err != nil {
+		if os.IsNotExist(err) {
+			return false, "", nil
+		}
+		return false, "", i18n.WrapError(ctx, err, tkmsgs.MsgSigningModuleFSError)
+	}
+	return true, keyHandle, nil
+}
+
+// Creates new key material for an existing key, and repoints the name of the key to it.
+// The previous key material is retained, and remains loadable by its key handle.
+func (fss *filesystemStore) RotateKey(ctx context.Context, req *signerapi.ResolveKeyRequest, newKeyMaterial func() ([]byte, error)) (keyMaterial []byte, keyHandle string, err error) {
+	keyHandle, derivationPath, algorithms, err := fss.resolveKeyRequest(ctx, req)
 	if err != nil {
 		return nil, "", err
 	}
-	return wf.PrivateKey(), keyHandle, nil
+	// Rotations of the same key are serialized on the unversioned key handle
+	unlock := fss.lockKey(keyHandle)
+	defer unlock()
+	version, err := fss.readKeyAliasVersion(ctx, keyHandle)
+	if err != nil {
+		return nil, "", err
+	}
+	// Only a key that exists can be rotated
+	if _, err := fss.getOrCreateWalletFile(ctx, versionedKeyHandle(keyHandle, version), nil, nil, nil); err != nil {
+		return nil, "", err
+	}
+	newKeyHandle := versionedKeyHandle(keyHandle, version+1)
+	wf, err := fss.getOrCreateWalletFile(ctx, newKeyHandle, derivationPath, algorithms, newKeyMaterial)
+	if err == nil {
+		err = fss.writeKeyAliasVersion(ctx, keyHandle, version+1)
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	return ownedKeyMaterial(wf), newKeyHandle, nil
+}
+
+func (fss *filesystemStore) ListKeyVersions(ctx context.Context, req *signerapi.ResolveKeyRequest) ([]string, error) {
+	keyHandle, _, _, err := fss.resolveKeyRequest(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	version, err := fss.currentKeyAliasVersion(ctx, keyHandle)
+	if err != nil {
+		return nil, err
+	}
+	absPathPrefix, err := fss.validateFilePathKeyHandle(ctx, versionedKeyHandle(keyHandle, version), false)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(fmt.Sprintf("%s.key", absPathPrefix)); err != nil {
+		return nil, i18n.NewError(ctx, tkmsgs.MsgSigningModuleKeyNotExist, keyHandle)
+	}
+	keyHandles := make([]string, version+1)
+	for v := range keyHandles {
+		keyHandles[v] = versionedKeyHandle(keyHandle, v)
+	}
+	return keyHandles, nil
 }
 
 func (fss *filesystemStore) LoadKeyMaterial(ctx context.Context, keyHandle string) ([]byte, error) {
-	wf, err := fss.getOrCreateWalletFile(ctx, keyHandle, nil)
+	wf, err := fss.getOrCreateWalletFile(ctx, keyHandle, nil, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	return ownedKeyMaterial(wf), nil
+}
+
+// The wallet file is held in the cache, so the caller gets its own copy of the key material that it can zero
+func ownedKeyMaterial(wf keystorev3.WalletFile) []byte {
+	return append([]byte{}, wf.PrivateKey()...)
+}
+
+func (fss *filesystemStore) LoadKeyDerivationPath(ctx context.Context, keyHandle string) ([]*signerapi.ResolveKeyPathSegment, error) {
+	wf, err := fss.getOrCreateWalletFile(ctx, keyHandle, nil, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	var derivationPath []*walletPathEntry
+	if md := wf.Metadata()[walletMetadataDerivationPath]; md != nil {
+		// Round-trip through JSON, as the metadata is a generic map when read back from the file
+		b, err := json.Marshal(md)
+		if err == nil {
+			err = json.Unmarshal(b, &derivationPath)
+		}
+		if err != nil {
+			return nil, i18n.WrapError(ctx, err, tkmsgs.MsgSigningKeyDerivationPathInvalid, keyHandle)
+		}
+	} else {
+		// Key files written before the path was recorded - the names can be recovered from the handle, but not the indexes
+		for _, segment := range strings.Split(keyHandle, "/") {
+			name, err := url.PathUnescape(segment)
+			if err != nil {
+				return nil, i18n.WrapError(ctx, err, tkmsgs.MsgSigningModuleBadKeyHandle)
+			}
+			derivationPath = append(derivationPath, &walletPathEntry{Name: name})
+		}
+	}
+	path := make([]*signerapi.ResolveKeyPathSegment, len(derivationPath))
+	for i, p := range derivationPath {
+		path[i] = &signerapi.ResolveKeyPathSegment{Name: p.Name, Index: p.Index.Uint64()}
+	}
+	return path, nil
+}
+
+func (fss *filesystemStore) LoadKeyAlgorithms(ctx context.Context, keyHandle string) ([]string, error) {
+	wf, err := fss.getOrCreateWalletFile(ctx, keyHandle, nil, nil, nil)
 	if err != nil {
 		return nil, err
 	}
-	return wf.PrivateKey(), nil
+	return walletFileAlgorithms(ctx, keyHandle, wf)
+}
+
+// Rewrites the key file with the additional algorithms. The wallet file is read afresh rather than updated in
+// the cache, as the cached wallet file can be read concurrently
+func (fss *filesystemStore) AddKeyAlgorithms(ctx context.Context, keyHandle string, algorithms []string) error {
+	absPathPrefix, err := fss.validateFilePathKeyHandle(ctx, keyHandle, false)
+	if err != nil {
+		return err
+	}
+	unlock := fss.lockKey(keyHandle)
+	defer unlock()
+	keyFilePath := fmt.Sprintf("%s.key", absPathPrefix)
+	wf, err := fss.readWalletFile(ctx, keyFilePath, fmt.Sprintf("%s.pwd", absPathPrefix))
+	if err != nil {
+		return err
+	}
+	keyAlgorithms, err := walletFileAlgorithms(ctx, keyHandle, wf)
+	if err != nil {
+		return err
+	}
+	for _, algorithm := range algorithms {
+		if !slices.Contains(keyAlgorithms, algorithm) {
+			keyAlgorithms = append(keyAlgorithms, algorithm)
+		}
+	}
+	wf.Metadata()[walletMetadataAlgorithms] = keyAlgorithms
+	if err := fss.writeFileAtomic(keyFilePath, wf.JSON()); err != nil {
+		return i18n.WrapError(ctx, err, tkmsgs.MsgSigningModuleFSError)
+	}
+	fss.cache.Set(keyHandle, wf)
+	return nil
+}
+
+func walletFileAlgorithms(ctx context.Context, keyHandle string, wf keystorev3.WalletFile) ([]string, error) {
+	algorithms := []string{}
+	if md := wf.Metadata()[walletMetadataAlgorithms]; md != nil {
+		// Round-trip through JSON, as the metadata is a generic list when read back from the file
+		b, err := json.Marshal(md)
+		if err == nil {
+			err = json.Unmarshal(b, &algorithms)
+		}
+		if err != nil {
+			return nil, i18n.WrapError(ctx, err, tkmsgs.MsgSigningKeyAlgorithmsInvalid, keyHandle)
+		}
+	}
+	return algorithms, nil
+}
+
+// Writes to a temporary file that is renamed over the target, so a concurrent reader never sees a partial file
+func (fss *filesystemStore) writeFileAtomic(filePath string, data []byte) error {
+	tmpFile, err := os.CreateTemp(filepath.Dir(filePath), filepath.Base(filePath)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmpFile.Name()) // no-op after a successful rename
+	_, err = tmpFile.Write(data)
+	if closeErr := tmpFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tmpFile.Name(), fss.fileMode)
+	}
+	if err == nil {
+		err = os.Rename(tmpFile.Name(), filePath)
+	}
+	return err
+}
+
+// The store is unhealthy if its directory is no longer available, such as when a volume is unmounted
+func (fss *filesystemStore) HealthCheck(ctx context.Context) error {
+	pathInfo, err := os.Stat(fss.path)
+	if err != nil || !pathInfo.IsDir() {
+		return i18n.WrapError(ctx, err, tkmsgs.MsgSigningModuleUnhealthy, fss.path)
+	}
+	return nil
 }
 
 func (fss *filesystemStore) Close() {
@@ -21,12 +21,17 @@ import (
 	"fmt"
 	"os"
 	"path"
+	"path/filepath"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/hyperledger/firefly-signer/pkg/secp256k1"
 	"github.com/kaleido-io/paladin/config/pkg/confutil"
 	"github.com/kaleido-io/paladin/config/pkg/pldconf"
+	"github.com/kaleido-io/paladin/toolkit/pkg/algorithms"
 	"github.com/kaleido-io/paladin/toolkit/pkg/signerapi"
+	"github.com/kaleido-io/paladin/toolkit/pkg/tktypes"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -100,6 +105,12 @@ func TestFileSystemStoreCreate(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, keyBytes, key0.PrivateKeyBytes())
 
+	// The key material is a copy, so zeroing it does not affect the cached wallet file
+	signerapi.ZeroKeyMaterial(keyBytes)
+	keyBytes, err = fs.LoadKeyMaterial(ctx, keyHandle)
+	require.NoError(t, err)
+	assert.Equal(t, keyBytes, key0.PrivateKeyBytes())
+
 	fs.cache.Delete(keyHandle)
 
 	keyBytes, err = fs.LoadKeyMaterial(ctx, keyHandle)
@@ -158,6 +169,70 @@ func TestFileSystemStoreBadSegments(t *testing.T) {
 	assert.Regexp(t, "PD020803", err)
 }
 
+func TestFileSystemStoreKeyExists(t *testing.T) {
+	ctx, fs := newTestFilesystemStore(t)
+	var checker signerapi.KeyStoreKeyExistenceChecker = fs
+
+	req := &signerapi.ResolveKeyRequest{
+		Name: "42",
+		Path: []*signerapi.ResolveKeyPathSegment{{Name: "bob"}, {Name: "blue"}},
+	}
+
+	// A key that does not exist is reported without anything being created
+	exists, keyHandle, err := checker.KeyExists(ctx, req)
+	require.NoError(t, err)
+	assert.False(t, exists)
+	assert.Empty(t, keyHandle)
+	assert.NoDirExists(t, path.Join(fs.path, "_bob"))
+
+	key0 := tktypes.RandBytes(32)
+	_, createdKeyHandle, err := fs.FindOrCreateLoadableKey(ctx, req, func() ([]byte, error) { return key0, nil })
+	require.NoError(t, err)
+
+	exists, keyHandle, err = checker.KeyExists(ctx, req)
+	require.NoError(t, err)
+	assert.True(t, exists)
+	assert.Equal(t, createdKeyHandle, keyHandle)
+
+	// Also found on disk when not cached
+	fs.cache.Delete(keyHandle)
+	exists, keyHandle, err = checker.KeyExists(ctx, req)
+	require.NoError(t, err)
+	assert.True(t, exists)
+	assert.Equal(t, createdKeyHandle, keyHandle)
+	_, cached := fs.cache.Get(keyHandle)
+	assert.False(t, cached) // the key was not loaded
+
+	// After rotation the active version is returned
+	_, rotatedKeyHandle, err := fs.RotateKey(ctx, req, func() ([]byte, error) { return tktypes.RandBytes(32), nil })
+	require.NoError(t, err)
+	exists, keyHandle, err = checker.KeyExists(ctx, req)
+	require.NoError(t, err)
+	assert.True(t, exists)
+	assert.Equal(t, rotatedKeyHandle, keyHandle)
+}
+
+func TestFileSystemStoreKeyExistsErrors(t *testing.T) {
+	ctx, fs := newTestFilesystemStore(t)
+
+	_, _, err := fs.KeyExists(ctx, &signerapi.ResolveKeyRequest{})
+	assert.Regexp(t, "PD020803", err)
+
+	err = os.WriteFile(path.Join(fs.path, "-badalias.alias"), []byte("!json"), 0644)
+	require.NoError(t, err)
+	_, _, err = fs.KeyExists(ctx, &signerapi.ResolveKeyRequest{Name: "badalias"})
+	assert.Regexp(t, "PD020840", err)
+
+	// A file where a directory is expected
+	err = os.WriteFile(path.Join(fs.path, "_bob"), []byte{}, 0644)
+	require.NoError(t, err)
+	_, _, err = fs.KeyExists(ctx, &signerapi.ResolveKeyRequest{
+		Name: "42",
+		Path: []*signerapi.ResolveKeyPathSegment{{Name: "bob"}},
+	})
+	assert.Regexp(t, "PD020840", err)
+}
+
 func TestFileSystemClashes(t *testing.T) {
 	ctx, fs := newTestFilesystemStore(t)
 
@@ -177,11 +252,11 @@ func TestCreateWalletFileFail(t *testing.T) {
 	err := os.MkdirAll(path.Join(fs.path, "clash.key"), fs.dirMode)
 	require.NoError(t, err)
 
-	_, err = fs.createWalletFile(ctx, path.Join(fs.path, "clash.key"), path.Join(fs.path, "clash.pwd"),
+	_, err = fs.createWalletFile(ctx, path.Join(fs.path, "clash.key"), path.Join(fs.path, "clash.pwd"), nil, nil,
 		func() ([]byte, error) { return []byte{}, nil })
 	assert.Regexp(t, "PD020804", err)
 
-	_, err = fs.createWalletFile(ctx, path.Join(fs.path, "ok.key"), path.Join(fs.path, "ok.pwd"),
+	_, err = fs.createWalletFile(ctx, path.Join(fs.path, "ok.key"), path.Join(fs.path, "ok.pwd"), nil, nil,
 		func() ([]byte, error) { return nil, fmt.Errorf("pop") })
 	assert.Regexp(t, "pop", err)
 
@@ -203,7 +278,7 @@ func TestReadPassFileFail(t *testing.T) {
 
 	keyFilePath, passwordFilePath := path.Join(fs.path, "ok.key"), path.Join(fs.path, "fail.pass")
 
-	_, err := fs.createWalletFile(ctx, keyFilePath, passwordFilePath,
+	_, err := fs.createWalletFile(ctx, keyFilePath, passwordFilePath, nil, nil,
 		func() ([]byte, error) { return []byte{0x01}, nil })
 	require.NoError(t, err)
 
@@ -220,3 +295,428 @@ func TestLoadKeyFail(t *testing.T) {
 	_, err := fs.LoadKeyMaterial(ctx, "wrong")
 	assert.Regexp(t, "PD020806", err)
 }
+
+func TestFileSystemStoreDerivationPathSurvivesReopen(t *testing.T) {
+	ctx, fs := newTestFilesystemStore(t)
+
+	key0, err := secp256k1.GenerateSecp256k1KeyPair()
+	require.NoError(t, err)
+
+	keyBytes, keyHandle, err := fs.FindOrCreateLoadableKey(ctx, &signerapi.ResolveKeyRequest{
+		Name:  "key/one",
+		Index: 12345678901234567890,
+		Path:  []*signerapi.ResolveKeyPathSegment{{Name: "bob", Index: 1}, {Name: "blue", Index: 2}},
+	}, func() ([]byte, error) { return key0.PrivateKeyBytes(), nil })
+	require.NoError(t, err)
+	assert.Equal(t, key0.PrivateKeyBytes(), keyBytes)
+	assert.Equal(t, "bob/blue/key%2Fone", keyHandle)
+
+	expectedPath := []*signerapi.ResolveKeyPathSegment{
+		{Name: "bob", Index: 1},
+		{Name: "blue", Index: 2},
+		{Name: "key/one", Index: 12345678901234567890},
+	}
+	var pathAware signerapi.KeyStoreDerivationPathAware = fs
+	derivationPath, err := pathAware.LoadKeyDerivationPath(ctx, keyHandle)
+	require.NoError(t, err)
+	assert.Equal(t, expectedPath, derivationPath)
+
+	// Open a new store over the same directory, with nothing cached
+	store, err := NewFilesystemStoreFactory[*signerapi.ConfigNoExt]().NewKeyStore(ctx, &signerapi.ConfigNoExt{
+		KeyStore: pldconf.KeyStoreConfig{
+			Type: pldconf.KeyStoreTypeFilesystem,
+			FileSystem: pldconf.FileSystemKeyStoreConfig{
+				Path: confutil.P(fs.path),
+			},
+		},
+	})
+	require.NoError(t, err)
+	defer store.Close()
+
+	keyBytes, err = store.LoadKeyMaterial(ctx, keyHandle)
+	require.NoError(t, err)
+	assert.Equal(t, key0.PrivateKeyBytes(), keyBytes)
+
+	derivationPath, err = store.(signerapi.KeyStoreDerivationPathAware).LoadKeyDerivationPath(ctx, keyHandle)
+	require.NoError(t, err)
+	assert.Equal(t, expectedPath, derivationPath)
+}
+
+func TestFileSystemStoreDerivationPathLegacyKeyFile(t *testing.T) {
+	ctx, fs := newTestFilesystemStore(t)
+
+	_, keyHandle, err := fs.FindOrCreateLoadableKey(ctx, &signerapi.ResolveKeyRequest{
+		Name:  "42",
+		Index: 5,
+		Path:  []*signerapi.ResolveKeyPathSegment{{Name: "bob", Index: 1}},
+	}, func() ([]byte, error) { return []byte("some key material"), nil })
+	require.NoError(t, err)
+
+	// Simulate a key file written before the path was recorded
+	wf, _ := fs.cache.Get(keyHandle)
+	delete(wf.Metadata(), walletMetadataDerivationPath)
+
+	derivationPath, err := fs.LoadKeyDerivationPath(ctx, keyHandle)
+	require.NoError(t, err)
+	assert.Equal(t, []*signerapi.ResolveKeyPathSegment{{Name: "bob"}, {Name: "42"}}, derivationPath)
+}
+
+func TestFileSystemStoreDerivationPathErrors(t *testing.T) {
+	ctx, fs := newTestFilesystemStore(t)
+
+	_, err := fs.LoadKeyDerivationPath(ctx, "missing")
+	assert.Regexp(t, "PD020806", err)
+
+	_, keyHandle, err := fs.FindOrCreateLoadableKey(ctx, &signerapi.ResolveKeyRequest{
+		Name: "42",
+	}, func() ([]byte, error) { return []byte("some key material"), nil })
+	require.NoError(t, err)
+
+	wf, _ := fs.cache.Get(keyHandle)
+	wf.Metadata()[walletMetadataDerivationPath] = "wrong"
+	_, err = fs.LoadKeyDerivationPath(ctx, keyHandle)
+	assert.Regexp(t, "PD020828", err)
+}
+
+func TestFileSystemStoreConcurrentCreateSameKey(t *testing.T) {
+	ctx, fs := newTestFilesystemStore(t)
+
+	const callers = 50
+	var generated atomic.Int32
+	newKeyMaterial := func() ([]byte, error) {
+		generated.Add(1)
+		key, err := secp256k1.GenerateSecp256k1KeyPair()
+		if err != nil {
+			return nil, err
+		}
+		return key.PrivateKeyBytes(), nil
+	}
+
+	type result struct {
+		keyMaterial []byte
+		keyHandle   string
+		err         error
+	}
+	start := make(chan struct{})
+	results := make(chan *result, callers)
+	for i := 0; i < callers; i++ {
+		go func() {
+			<-start
+			keyMaterial, keyHandle, err := fs.FindOrCreateLoadableKey(ctx, &signerapi.ResolveKeyRequest{
+				Name: "contended",
+				Path: []*signerapi.ResolveKeyPathSegment{{Name: "shared"}},
+			}, newKeyMaterial)
+			results <- &result{keyMaterial, keyHandle, err}
+		}()
+	}
+	close(start)
+
+	var first *result
+	for i := 0; i < callers; i++ {
+		r := <-results
+		require.NoError(t, r.err)
+		if first == nil {
+			first = r
+		}
+		assert.Equal(t, first.keyMaterial, r.keyMaterial)
+		assert.Equal(t, first.keyHandle, r.keyHandle)
+	}
+	assert.Equal(t, int32(1), generated.Load())
+	assert.Empty(t, fs.keyLocks)
+
+	// The material on disk is the one every caller received
+	fs.cache.Delete(first.keyHandle)
+	keyMaterial, err := fs.LoadKeyMaterial(ctx, first.keyHandle)
+	require.NoError(t, err)
+	assert.Equal(t, first.keyMaterial, keyMaterial)
+}
+
+func TestFileSystemStoreAlgorithmsRecordedAtCreation(t *testing.T) {
+	ctx, fs := newTestFilesystemStore(t)
+
+	for _, algorithm := range []string{algorithms.ECDSA_SECP256K1, "domain:zeto:snark:babyjubjub"} {
+		_, keyHandle, err := fs.FindOrCreateLoadableKey(ctx, &signerapi.ResolveKeyRequest{
+			Name: algorithm,
+			RequiredIdentifiers: []*signerapi.PublicKeyIdentifierType{
+				{Algorithm: algorithm, VerifierType: "type1"},
+				{Algorithm: algorithm, VerifierType: "type2"},
+			},
+		}, func() ([]byte, error) { return tktypes.RandBytes(32), nil })
+		require.NoError(t, err)
+
+		var algorithmAware signerapi.KeyStoreAlgorithmAware = fs
+		keyAlgorithms, err := algorithmAware.LoadKeyAlgorithms(ctx, keyHandle)
+		require.NoError(t, err)
+		assert.Equal(t, []string{algorithm}, keyAlgorithms)
+
+		// Check it survives a reload from disk
+		fs.cache.Delete(keyHandle)
+		keyAlgorithms, err = algorithmAware.LoadKeyAlgorithms(ctx, keyHandle)
+		require.NoError(t, err)
+		assert.Equal(t, []string{algorithm}, keyAlgorithms)
+	}
+}
+
+func TestFileSystemStoreAddKeyAlgorithms(t *testing.T) {
+	ctx, fs := newTestFilesystemStore(t)
+
+	_, keyHandle, err := fs.FindOrCreateLoadableKey(ctx, &signerapi.ResolveKeyRequest{
+		Name:                "key1",
+		RequiredIdentifiers: []*signerapi.PublicKeyIdentifierType{{Algorithm: algorithms.ECDSA_SECP256K1, VerifierType: "type1"}},
+	}, func() ([]byte, error) { return tktypes.RandBytes(32), nil })
+	require.NoError(t, err)
+
+	err = fs.AddKeyAlgorithms(ctx, keyHandle, []string{"domain:zeto:snark:babyjubjub", algorithms.ECDSA_SECP256K1})
+	require.NoError(t, err)
+	keyAlgorithms, err := fs.LoadKeyAlgorithms(ctx, keyHandle)
+	require.NoError(t, err)
+	assert.Equal(t, []string{algorithms.ECDSA_SECP256K1, "domain:zeto:snark:babyjubjub"}, keyAlgorithms)
+
+	// Check it survives a reload from disk, with the key material intact
+	keyMaterial, err := fs.LoadKeyMaterial(ctx, keyHandle)
+	require.NoError(t, err)
+	fs.cache.Delete(keyHandle)
+	keyAlgorithms, err = fs.LoadKeyAlgorithms(ctx, keyHandle)
+	require.NoError(t, err)
+	assert.Equal(t, []string{algorithms.ECDSA_SECP256K1, "domain:zeto:snark:babyjubjub"}, keyAlgorithms)
+	reloaded, err := fs.LoadKeyMaterial(ctx, keyHandle)
+	require.NoError(t, err)
+	assert.Equal(t, keyMaterial, reloaded)
+
+	err = fs.AddKeyAlgorithms(ctx, "missing", []string{algorithms.ECDSA_SECP256K1})
+	assert.Regexp(t, "PD020801", err)
+}
+
+func TestFileSystemStoreAlgorithmsLegacyAndErrors(t *testing.T) {
+	ctx, fs := newTestFilesystemStore(t)
+
+	_, err := fs.LoadKeyAlgorithms(ctx, "missing")
+	assert.Regexp(t, "PD020806", err)
+
+	_, keyHandle, err := fs.FindOrCreateLoadableKey(ctx, &signerapi.ResolveKeyRequest{
+		Name: "42",
+	}, func() ([]byte, error) { return []byte("some key material"), nil })
+	require.NoError(t, err)
+
+	// Simulate a key file written before the algorithms were recorded
+	wf, _ := fs.cache.Get(keyHandle)
+	delete(wf.Metadata(), walletMetadataAlgorithms)
+	keyAlgorithms, err := fs.LoadKeyAlgorithms(ctx, keyHandle)
+	require.NoError(t, err)
+	assert.Empty(t, keyAlgorithms)
+
+	wf.Metadata()[walletMetadataAlgorithms] = "wrong"
+	_, err = fs.LoadKeyAlgorithms(ctx, keyHandle)
+	assert.Regexp(t, "PD020829", err)
+}
+
+func TestFileSystemStoreRotateKey(t *testing.T) {
+	ctx, fs := newTestFilesystemStore(t)
+	var rotatable signerapi.KeyStoreRotatable = fs
+
+	req := &signerapi.ResolveKeyRequest{
+		Name: "signer;1", // the separator is escaped in a name, so cannot clash with a version
+		Path: []*signerapi.ResolveKeyPathSegment{{Name: "bob"}},
+	}
+	_, err := rotatable.ListKeyVersions(ctx, req)
+	assert.Regexp(t, "PD020806", err)
+	_, _, err = rotatable.RotateKey(ctx, req, func() ([]byte, error) { return tktypes.RandBytes(32), nil })
+	assert.Regexp(t, "PD020806", err)
+
+	key0 := tktypes.RandBytes(32)
+	keyBytes, keyHandle0, err := fs.FindOrCreateLoadableKey(ctx, req, func() ([]byte, error) { return key0, nil })
+	require.NoError(t, err)
+	assert.Equal(t, key0, keyBytes)
+	assert.Equal(t, "bob/signer%3B1", keyHandle0)
+
+	// Rotate twice
+	key1 := tktypes.RandBytes(32)
+	keyBytes, keyHandle1, err := rotatable.RotateKey(ctx, req, func() ([]byte, error) { return key1, nil })
+	require.NoError(t, err)
+	assert.Equal(t, key1, keyBytes)
+	assert.Equal(t, "bob/signer%3B1;1", keyHandle1)
+	key2 := tktypes.RandBytes(32)
+	_, keyHandle2, err := rotatable.RotateKey(ctx, req, func() ([]byte, error) { return key2, nil })
+	require.NoError(t, err)
+	assert.Equal(t, "bob/signer%3B1;2", keyHandle2)
+
+	// The name now resolves to the active key, even after a reload from disk
+	fs.cache.Delete(keyHandle2)
+	keyBytes, keyHandle, err := fs.FindOrCreateLoadableKey(ctx, req, func() ([]byte, error) { panic("should not be called") })
+	require.NoError(t, err)
+	assert.Equal(t, key2, keyBytes)
+	assert.Equal(t, keyHandle2, keyHandle)
+
+	// And the prior material is still loadable
+	keyHandles, err := rotatable.ListKeyVersions(ctx, req)
+	require.NoError(t, err)
+	assert.Equal(t, []string{keyHandle0, keyHandle1, keyHandle2}, keyHandles)
+	for i, expected := range [][]byte{key0, key1, key2} {
+		fs.cache.Delete(keyHandles[i])
+		keyBytes, err := fs.LoadKeyMaterial(ctx, keyHandles[i])
+		require.NoError(t, err)
+		assert.Equal(t, expected, keyBytes)
+	}
+
+	// The derivation path is unchanged by rotation
+	derivationPath, err := fs.LoadKeyDerivationPath(ctx, keyHandle2)
+	require.NoError(t, err)
+	assert.Equal(t, []*signerapi.ResolveKeyPathSegment{{Name: "bob"}, {Name: "signer;1"}}, derivationPath)
+}
+
+func TestFileSystemStoreRotateKeyErrors(t *testing.T) {
+	ctx, fs := newTestFilesystemStore(t)
+
+	_, _, err := fs.RotateKey(ctx, &signerapi.ResolveKeyRequest{}, nil)
+	assert.Regexp(t, "PD020803", err)
+	_, err = fs.ListKeyVersions(ctx, &signerapi.ResolveKeyRequest{})
+	assert.Regexp(t, "PD020803", err)
+
+	req := &signerapi.ResolveKeyRequest{Name: "42"}
+	_, _, err = fs.FindOrCreateLoadableKey(ctx, req, func() ([]byte, error) { return tktypes.RandBytes(32), nil })
+	require.NoError(t, err)
+
+	_, _, err = fs.RotateKey(ctx, req, func() ([]byte, error) { return nil, fmt.Errorf("pop") })
+	assert.Regexp(t, "pop", err)
+
+	err = os.WriteFile(path.Join(fs.path, "-42.alias"), []byte("!json"), 0644)
+	require.NoError(t, err)
+	_, _, err = fs.FindOrCreateLoadableKey(ctx, req, nil)
+	assert.Regexp(t, "PD020840", err)
+	_, _, err = fs.RotateKey(ctx, req, nil)
+	assert.Regexp(t, "PD020840", err)
+	_, err = fs.ListKeyVersions(ctx, req)
+	assert.Regexp(t, "PD020840", err)
+}
+
+func TestFileSystemStoreResolveWaitsForRotation(t *testing.T) {
+	ctx, fs := newTestFilesystemStore(t)
+
+	req := &signerapi.ResolveKeyRequest{Name: "42"}
+	_, keyHandle, err := fs.FindOrCreateLoadableKey(ctx, req, func() ([]byte, error) { return tktypes.RandBytes(32), nil })
+	require.NoError(t, err)
+
+	// Hold the key as a rotation would, while it writes the new alias
+	unlock := fs.lockKey(keyHandle)
+	resolved := make(chan string)
+	go func() {
+		_, keyHandle, err := fs.FindOrCreateLoadableKey(ctx, req, nil)
+		assert.NoError(t, err)
+		resolved <- keyHandle
+	}()
+	select {
+	case <-resolved:
+		assert.Fail(t, "resolved during rotation")
+	case <-time.After(50 * time.Millisecond):
+	}
+	_, err = fs.getOrCreateWalletFile(ctx, versionedKeyHandle(keyHandle, 1), nil, nil, func() ([]byte, error) { return tktypes.RandBytes(32), nil })
+	require.NoError(t, err)
+	err = fs.writeKeyAliasVersion(ctx, keyHandle, 1)
+	require.NoError(t, err)
+	unlock()
+
+	// The resolution sees the rotated key
+	assert.Equal(t, versionedKeyHandle(keyHandle, 1), <-resolved)
+
+	// and the alias is written without leaving temporary files behind
+	tmpFiles, err := filepath.Glob(path.Join(fs.path, "*.tmp"))
+	require.NoError(t, err)
+	assert.Empty(t, tmpFiles)
+}
+
+func newTestFilesystemStoreKDF(t *testing.T, dir string, kdf pldconf.KeyStoreKDFConfig) (*filesystemStore, error) {
+	sf := NewFilesystemStoreFactory[*signerapi.ConfigNoExt]()
+	store, err := sf.NewKeyStore(context.Background(), &signerapi.ConfigNoExt{
+		KeyStore: pldconf.KeyStoreConfig{
+			Type: pldconf.KeyStoreTypeFilesystem,
+			FileSystem: pldconf.FileSystemKeyStoreConfig{
+				Path: confutil.P(dir),
+				KDF:  kdf,
+			},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return store.(*filesystemStore), nil
+}
+
+func TestFileSystemStoreKDFRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	kdfConfigs := map[string]pldconf.KeyStoreKDFConfig{
+		"scrypt": {
+			Type:    confutil.P(pldconf.KeyStoreKDFScrypt),
+			ScryptN: confutil.P(2048),
+			ScryptR: confutil.P(4),
+			ScryptP: confutil.P(2),
+		},
+		"pbkdf2": {
+			Type:             confutil.P(pldconf.KeyStoreKDFPBKDF2),
+			PBKDF2Iterations: confutil.P(1000),
+		},
+	}
+	keys := map[string][]byte{}
+	for name, kdf := range kdfConfigs {
+		fs, err := newTestFilesystemStoreKDF(t, dir, kdf)
+		require.NoError(t, err)
+
+		keyBytes := tktypes.RandBytes(32)
+		_, keyHandle, err := fs.FindOrCreateLoadableKey(ctx, &signerapi.ResolveKeyRequest{Name: name},
+			func() ([]byte, error) { return keyBytes, nil })
+		require.NoError(t, err)
+		keys[keyHandle] = keyBytes
+	}
+
+	// Check the KDF parameters were recorded in each file
+	var scryptWallet, pbkdf2Wallet map[string]any
+	b, err := os.ReadFile(path.Join(dir, "-scrypt.key"))
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(b, &scryptWallet))
+	scryptCrypto := scryptWallet["crypto"].(map[string]any)
+	assert.Equal(t, "scrypt", scryptCrypto["kdf"])
+	assert.Equal(t, float64(2048), scryptCrypto["kdfparams"].(map[string]any)["n"])
+	assert.Equal(t, float64(4), scryptCrypto["kdfparams"].(map[string]any)["r"])
+	assert.Equal(t, float64(2), scryptCrypto["kdfparams"].(map[string]any)["p"])
+	b, err = os.ReadFile(path.Join(dir, "-pbkdf2.key"))
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(b, &pbkdf2Wallet))
+	pbkdf2Crypto := pbkdf2Wallet["crypto"].(map[string]any)
+	assert.Equal(t, "pbkdf2", pbkdf2Crypto["kdf"])
+	assert.Equal(t, float64(1000), pbkdf2Crypto["kdfparams"].(map[string]any)["c"])
+	_, hasAddressProperty := pbkdf2Wallet["address"]
+	assert.False(t, hasAddressProperty)
+
+	// A store with the default KDF config loads all the keys, using the parameters from each file
+	fs, err := newTestFilesystemStoreKDF(t, dir, pldconf.KeyStoreKDFConfig{})
+	require.NoError(t, err)
+	for keyHandle, keyBytes := range keys {
+		loaded, err := fs.LoadKeyMaterial(ctx, keyHandle)
+		require.NoError(t, err)
+		assert.Equal(t, keyBytes, loaded)
+	}
+}
+
+func TestFileSystemStoreKDFConfigErrors(t *testing.T) {
+	_, err := newTestFilesystemStoreKDF(t, t.TempDir(), pldconf.KeyStoreKDFConfig{
+		Type: confutil.P("argon2"),
+	})
+	assert.Regexp(t, "PD020831.*argon2", err)
+
+	_, err = newTestFilesystemStoreKDF(t, t.TempDir(), pldconf.KeyStoreKDFConfig{
+		ScryptN: confutil.P(1000),
+	})
+	assert.Regexp(t, "PD020832.*scryptN=1000", err)
+
+	_, err = newTestFilesystemStoreKDF(t, t.TempDir(), pldconf.KeyStoreKDFConfig{
+		ScryptP: confutil.P(0),
+	})
+	assert.Regexp(t, "PD020832.*scryptP=0", err)
+
+	_, err = newTestFilesystemStoreKDF(t, t.TempDir(), pldconf.KeyStoreKDFConfig{
+		Type:             confutil.P(pldconf.KeyStoreKDFPBKDF2),
+		PBKDF2Iterations: confutil.P(-1),
+	})
+	assert.Regexp(t, "PD020832.*pbkdf2Iterations=-1", err)
+}
@@ -0,0 +1,96 @@
+/*
+ * Copyright © 2024 Kaleido, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with
+ * the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package keystores
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/hex"
+	"os"
+	"strings"
+
+	"github.com/kaleido-io/paladin/config/pkg/confutil"
+	"github.com/kaleido-io/paladin/config/pkg/pldconf"
+	"github.com/kaleido-io/paladin/toolkit/pkg/i18n"
+	"github.com/kaleido-io/paladin/toolkit/pkg/signerapi"
+	"github.com/kaleido-io/paladin/toolkit/pkg/tkmsgs"
+)
+
+// Returns nil when no wrapper is configured, in which case key file passwords are stored in the clear
+func newMasterKeyWrapper(ctx context.Context, conf *pldconf.MasterKeyWrapperConfig) (signerapi.MasterKeyWrapper, error) {
+	wrapperType := confutil.StringNotEmpty(conf.Type, *pldconf.FileSystemDefaults.MasterKeyWrapper.Type)
+	switch wrapperType {
+	case pldconf.MasterKeyWrapperNone:
+		return nil, nil
+	case pldconf.MasterKeyWrapperAES:
+		return newAESMasterKeyWrapper(ctx, &conf.AES)
+	case pldconf.MasterKeyWrapperAWSKMS:
+		return newAWSKMSMasterKeyWrapper(ctx, &conf.AWSKMS)
+	case pldconf.MasterKeyWrapperVaultTransit:
+		return newVaultTransitMasterKeyWrapper(ctx, &conf.VaultTransit)
+	default:
+		return nil, i18n.NewError(ctx, tkmsgs.MsgSigningModuleBadMasterKeyWrapperType, wrapperType)
+	}
+}
+
+// AES-256-GCM with a master key held locally, with the random nonce prefixed to the sealed data
+type aesMasterKeyWrapper struct {
+	aead cipher.AEAD
+}
+
+func newAESMasterKeyWrapper(ctx context.Context, conf *pldconf.AESMasterKeyWrapperConfig) (*aesMasterKeyWrapper, error) {
+	keyHex := conf.Key
+	if conf.KeyFile != "" {
+		keyData, err := os.ReadFile(conf.KeyFile)
+		if err != nil {
+			return nil, i18n.WrapError(ctx, err, tkmsgs.MsgSigningModuleBadMasterKeyWrapperConfig, pldconf.MasterKeyWrapperAES, conf.KeyFile)
+		}
+		keyHex = string(keyData)
+	}
+	key, err := hex.DecodeString(strings.TrimPrefix(strings.TrimSpace(keyHex), "0x"))
+	if err != nil || len(key) != 32 {
+		return nil, i18n.NewError(ctx, tkmsgs.MsgSigningModuleBadMasterKeyWrapperConfig, pldconf.MasterKeyWrapperAES, "key must be a hex encoded 32 byte value")
+	}
+	block, _ := aes.NewCipher(key) // cannot fail with a 32 byte key
+	aead, _ := cipher.NewGCM(block)
+	return &aesMasterKeyWrapper{aead: aead}, nil
+}
+
+func (w *aesMasterKeyWrapper) Type() string {
+	return pldconf.MasterKeyWrapperAES
+}
+
+func (w *aesMasterKeyWrapper) Wrap(ctx context.Context, plaintext []byte) ([]byte, error) {
+	nonce := make([]byte, w.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, i18n.WrapError(ctx, err, tkmsgs.MsgSigningModuleMasterKeyWrapFailed, w.Type())
+	}
+	return w.aead.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+func (w *aesMasterKeyWrapper) Unwrap(ctx context.Context, wrapped []byte) ([]byte, error) {
+	nonceSize := w.aead.NonceSize()
+	if len(wrapped) < nonceSize {
+		return nil, i18n.NewError(ctx, tkmsgs.MsgSigningModuleMasterKeyUnwrapFailed, w.Type())
+	}
+	plaintext, err := w.aead.Open(nil, wrapped[:nonceSize], wrapped[nonceSize:], nil)
+	if err != nil {
+		return nil, i18n.WrapError(ctx, err, tkmsgs.MsgSigningModuleMasterKeyUnwrapFailed, w.Type())
+	}
+	return plaintext, nil
+}
@@ -0,0 +1,190 @@
+/*
+ * Copyright © 2024 Kaleido, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with
+ * the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package keystores
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/kaleido-io/paladin/config/pkg/pldconf"
+	"github.com/kaleido-io/paladin/toolkit/pkg/i18n"
+	"github.com/kaleido-io/paladin/toolkit/pkg/rpcclient"
+	"github.com/kaleido-io/paladin/toolkit/pkg/tkmsgs"
+)
+
+// Calls the AWS KMS JSON API directly, signing each request with AWS Signature Version 4,
+// so no AWS SDK dependency is required for the two operations we use.
+type awsKMSMasterKeyWrapper struct {
+	client          *resty.Client
+	host            string
+	path            string // canonical URI for signing, matching the base URL joined with "/"
+	region          string
+	keyID           string
+	accessKeyID     string
+	secretAccessKey string
+	sessionToken    string
+	now             func() time.Time
+}
+
+type awsKMSEncryptRequest struct {
+	KeyID     string `json:"KeyId"`
+	Plaintext []byte `json:"Plaintext"` // blobs are base64 encoded, as is the default for []byte
+}
+
+type awsKMSEncryptResponse struct {
+	CiphertextBlob []byte `json:"CiphertextBlob"`
+}
+
+type awsKMSDecryptRequest struct {
+	KeyID          string `json:"KeyId"`
+	CiphertextBlob []byte `json:"CiphertextBlob"`
+}
+
+type awsKMSDecryptResponse struct {
+	Plaintext []byte `json:"Plaintext"`
+}
+
+func newAWSKMSMasterKeyWrapper(ctx context.Context, conf *pldconf.AWSKMSMasterKeyWrapperConfig) (*awsKMSMasterKeyWrapper, error) {
+	if conf.Region == "" || conf.KeyID == "" || conf.AccessKeyID == "" || conf.SecretAccessKey == "" {
+		return nil, i18n.NewError(ctx, tkmsgs.MsgSigningModuleBadMasterKeyWrapperConfig, pldconf.MasterKeyWrapperAWSKMS, "region, keyId, accessKeyId and secretAccessKey are required")
+	}
+	client, err := rpcclient.ParseHTTPConfig(ctx, &conf.HTTPClientConfig)
+	if err != nil {
+		return nil, err
+	}
+	u, _ := url.Parse(conf.URL) // already validated
+	return &awsKMSMasterKeyWrapper{
+		client:          client,
+		host:            u.Host,
+		path:            strings.TrimSuffix(u.Path, "/") + "/",
+		region:          conf.Region,
+		keyID:           conf.KeyID,
+		accessKeyID:     conf.AccessKeyID,
+		secretAccessKey: conf.SecretAccessKey,
+		sessionToken:    conf.SessionToken,
+		now:             time.Now,
+	}, nil
+}
+
+func (w *awsKMSMasterKeyWrapper) Type() string {
+	return pldconf.MasterKeyWrapperAWSKMS
+}
+
+func (w *awsKMSMasterKeyWrapper) Wrap(ctx context.Context, plaintext []byte) ([]byte, error) {
+	var res awsKMSEncryptResponse
+	err := w.invoke(ctx, "TrentService.Encrypt", &awsKMSEncryptRequest{KeyID: w.keyID, Plaintext: plaintext}, &res)
+	if err != nil {
+		return nil, i18n.WrapError(ctx, err, tkmsgs.MsgSigningModuleMasterKeyWrapFailed, w.Type())
+	}
+	return res.CiphertextBlob, nil
+}
+
+func (w *awsKMSMasterKeyWrapper) Unwrap(ctx context.Context, wrapped []byte) ([]byte, error) {
+	var res awsKMSDecryptResponse
+	err := w.invoke(ctx, "TrentService.Decrypt", &awsKMSDecryptRequest{KeyID: w.keyID, CiphertextBlob: wrapped}, &res)
+	if err != nil {
+		return nil, i18n.WrapError(ctx, err, tkmsgs.MsgSigningModuleMasterKeyUnwrapFailed, w.Type())
+	}
+	return res.Plaintext, nil
+}
+
+func (w *awsKMSMasterKeyWrapper) invoke(ctx context.Context, target string, req, res any) error {
+	body, _ := json.Marshal(req)
+	r, err := w.client.R().
+		SetContext(ctx).
+		SetHeaders(w.signedHeaders(target, body, w.now().UTC())).
+		SetBody(body).
+		Post("/")
+	if err != nil {
+		return err
+	}
+	if r.IsError() {
+		return i18n.NewError(ctx, tkmsgs.MsgSigningModuleMasterKeyWrapperHTTPError, w.Type(), r.StatusCode(), r.String())
+	}
+	return json.Unmarshal(r.Body(), res)
+}
+
+// Builds the headers for a KMS request, including the SigV4 Authorization header
+func (w *awsKMSMasterKeyWrapper) signedHeaders(target string, body []byte, now time.Time) map[string]string {
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	headers := map[string]string{
+		"content-type": "application/x-amz-json-1.1",
+		"host":         w.host,
+		"x-amz-date":   amzDate,
+		"x-amz-target": target,
+	}
+	if w.sessionToken != "" {
+		headers["x-amz-security-token"] = w.sessionToken
+	}
+
+	headerNames := make([]string, 0, len(headers))
+	for name := range headers {
+		headerNames = append(headerNames, name)
+	}
+	sort.Strings(headerNames)
+	canonicalHeaders := new(strings.Builder)
+	for _, name := range headerNames {
+		fmt.Fprintf(canonicalHeaders, "%s:%s\n", name, headers[name])
+	}
+	signedHeaderNames := strings.Join(headerNames, ";")
+
+	bodyHash := sha256.Sum256(body)
+	canonicalRequest := strings.Join([]string{
+		"POST",
+		w.path,
+		"", // no query string
+		canonicalHeaders.String(),
+		signedHeaderNames,
+		hex.EncodeToString(bodyHash[:]),
+	}, "\n")
+
+	scope := fmt.Sprintf("%s/%s/kms/aws4_request", date, w.region)
+	canonicalRequestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		hex.EncodeToString(canonicalRequestHash[:]),
+	}, "\n")
+
+	signingKey := hmacSHA256([]byte("AWS4"+w.secretAccessKey), date)
+	signingKey = hmacSHA256(signingKey, w.region)
+	signingKey = hmacSHA256(signingKey, "kms")
+	signingKey = hmacSHA256(signingKey, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(signingKey, stringToSign))
+
+	// The host header is set by the HTTP client from the URL
+	delete(headers, "host")
+	headers["authorization"] = fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		w.accessKeyID, scope, signedHeaderNames, signature)
+	return headers
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
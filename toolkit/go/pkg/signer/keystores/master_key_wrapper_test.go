@@ -0,0 +1,360 @@
+/*
+ * Copyright © 2024 Kaleido, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with
+ * the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package keystores
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kaleido-io/paladin/config/pkg/confutil"
+	"github.com/kaleido-io/paladin/config/pkg/pldconf"
+	"github.com/kaleido-io/paladin/toolkit/pkg/signerapi"
+	"github.com/kaleido-io/paladin/toolkit/pkg/tktypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// A reversible transform standing in for the remote encryption
+func fakeRemoteCipher(data []byte) []byte {
+	out := make([]byte, len(data))
+	for i, b := range data {
+		out[i] = b ^ 0x5a
+	}
+	return out
+}
+
+func newFakeAWSKMS(t *testing.T, conf *pldconf.AWSKMSMasterKeyWrapperConfig) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+
+		// Check the signature covers exactly what was sent
+		verifier, err := newAWSKMSMasterKeyWrapper(context.Background(), conf)
+		require.NoError(t, err)
+		verifier.host = r.Host
+		now, err := time.Parse("20060102T150405Z", r.Header.Get("X-Amz-Date"))
+		require.NoError(t, err)
+		expected := verifier.signedHeaders(r.Header.Get("X-Amz-Target"), body, now)
+		if r.Header.Get("Authorization") != expected["authorization"] {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		var res any
+		switch r.Header.Get("X-Amz-Target") {
+		case "TrentService.Encrypt":
+			var req awsKMSEncryptRequest
+			require.NoError(t, json.Unmarshal(body, &req))
+			assert.Equal(t, conf.KeyID, req.KeyID)
+			res = &awsKMSEncryptResponse{CiphertextBlob: fakeRemoteCipher(req.Plaintext)}
+		case "TrentService.Decrypt":
+			var req awsKMSDecryptRequest
+			require.NoError(t, json.Unmarshal(body, &req))
+			res = &awsKMSDecryptResponse{Plaintext: fakeRemoteCipher(req.CiphertextBlob)}
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+		_ = json.NewEncoder(w).Encode(res)
+	}))
+	conf.URL = server.URL
+	return server
+}
+
+func newFakeVaultTransit(t *testing.T, conf *pldconf.VaultTransitMasterKeyWrapperConfig) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != conf.Token {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		var req vaultTransitRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		var res vaultTransitResponse
+		switch r.URL.Path {
+		case "/v1/transit/encrypt/" + conf.KeyName:
+			res.Data.Ciphertext = "vault:v1:" + hex.EncodeToString(fakeRemoteCipher(req.Plaintext))
+		case "/v1/transit/decrypt/" + conf.KeyName:
+			b, err := hex.DecodeString(strings.TrimPrefix(req.Ciphertext, "vault:v1:"))
+			require.NoError(t, err)
+			res.Data.Plaintext = fakeRemoteCipher(b)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(&res)
+	}))
+	conf.URL = server.URL
+	return server
+}
+
+func newTestFilesystemStoreWithWrapper(t *testing.T, dir string, conf pldconf.MasterKeyWrapperConfig) (*filesystemStore, error) {
+	sf := NewFilesystemStoreFactory[*signerapi.ConfigNoExt]()
+	store, err := sf.NewKeyStore(context.Background(), &signerapi.ConfigNoExt{
+		KeyStore: pldconf.KeyStoreConfig{
+			Type: pldconf.KeyStoreTypeFilesystem,
+			FileSystem: pldconf.FileSystemKeyStoreConfig{
+				Path:             confutil.P(dir),
+				MasterKeyWrapper: conf,
+			},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return store.(*filesystemStore), nil
+}
+
+func TestFilesystemStoreMasterKeyWrappers(t *testing.T) {
+	awsConf := pldconf.AWSKMSMasterKeyWrapperConfig{
+		Region:          "us-east-1",
+		KeyID:           "alias/paladin",
+		AccessKeyID:     "AKIDEXAMPLE",
+		SecretAccessKey: "secret",
+		SessionToken:    "session",
+	}
+	awsServer := newFakeAWSKMS(t, &awsConf)
+	defer awsServer.Close()
+
+	vaultConf := pldconf.VaultTransitMasterKeyWrapperConfig{
+		KeyName: "paladin",
+		Token:   "vault-token",
+	}
+	vaultServer := newFakeVaultTransit(t, &vaultConf)
+	defer vaultServer.Close()
+
+	aesKeyFile := path.Join(t.TempDir(), "master.key")
+	err := os.WriteFile(aesKeyFile, []byte(tktypes.RandHex(32)), 0600)
+	require.NoError(t, err)
+
+	for _, conf := range []pldconf.MasterKeyWrapperConfig{
+		{},
+		{Type: confutil.P(pldconf.MasterKeyWrapperAES), AES: pldconf.AESMasterKeyWrapperConfig{KeyFile: aesKeyFile}},
+		{Type: confutil.P(pldconf.MasterKeyWrapperAWSKMS), AWSKMS: awsConf},
+		{Type: confutil.P(pldconf.MasterKeyWrapperVaultTransit), VaultTransit: vaultConf},
+	} {
+		wrapperType := confutil.StringNotEmpty(conf.Type, pldconf.MasterKeyWrapperNone)
+		t.Run(wrapperType, func(t *testing.T) {
+			ctx := context.Background()
+			dir := t.TempDir()
+
+			store, err := newTestFilesystemStoreWithWrapper(t, dir, conf)
+			require.NoError(t, err)
+			keyMaterial := tktypes.RandBytes(32)
+			_, keyHandle, err := store.FindOrCreateLoadableKey(ctx, &signerapi.ResolveKeyRequest{Name: "key1"},
+				func() ([]byte, error) { return keyMaterial, nil })
+			require.NoError(t, err)
+
+			keyData, err := os.ReadFile(path.Join(dir, "-key1.key"))
+			require.NoError(t, err)
+			var md map[string]any
+			require.NoError(t, json.Unmarshal(keyData, &md))
+			passData, err := os.ReadFile(path.Join(dir, "-key1.pwd"))
+			require.NoError(t, err)
+			if wrapperType == pldconf.MasterKeyWrapperNone {
+				assert.Nil(t, md[walletMetadataMasterKeyWrapper])
+			} else {
+				assert.Equal(t, wrapperType, md[walletMetadataMasterKeyWrapper])
+				// The password on disk is the wrapped form, which the store unwraps to load the key
+				wrapped, err := hex.DecodeString(string(passData))
+				require.NoError(t, err)
+				password, err := store.wrapper.Unwrap(ctx, wrapped)
+				require.NoError(t, err)
+				assert.NotEqual(t, string(passData), string(password))
+			}
+
+			// A new store over the same files, with an empty cache, loads the same key
+			store2, err := newTestFilesystemStoreWithWrapper(t, dir, conf)
+			require.NoError(t, err)
+			loaded, err := store2.LoadKeyMaterial(ctx, keyHandle)
+			require.NoError(t, err)
+			assert.Equal(t, keyMaterial, loaded)
+		})
+	}
+}
+
+func TestFilesystemStoreMasterKeyWrapperAddedLater(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	aesConf := pldconf.MasterKeyWrapperConfig{
+		Type: confutil.P(pldconf.MasterKeyWrapperAES),
+		AES:  pldconf.AESMasterKeyWrapperConfig{Key: tktypes.RandHex(32)},
+	}
+
+	unwrappedStore, err := newTestFilesystemStoreWithWrapper(t, dir, pldconf.MasterKeyWrapperConfig{})
+	require.NoError(t, err)
+	legacyKey, _, err := unwrappedStore.FindOrCreateLoadableKey(ctx, &signerapi.ResolveKeyRequest{Name: "legacy"},
+		func() ([]byte, error) { return tktypes.RandBytes(32), nil })
+	require.NoError(t, err)
+
+	// Keys written before the wrapper was configured remain readable
+	wrappedStore, err := newTestFilesystemStoreWithWrapper(t, dir, aesConf)
+	require.NoError(t, err)
+	loaded, err := wrappedStore.LoadKeyMaterial(ctx, "legacy")
+	require.NoError(t, err)
+	assert.Equal(t, legacyKey, loaded)
+
+	_, _, err = wrappedStore.FindOrCreateLoadableKey(ctx, &signerapi.ResolveKeyRequest{Name: "wrapped"},
+		func() ([]byte, error) { return tktypes.RandBytes(32), nil })
+	require.NoError(t, err)
+
+	// But wrapped keys cannot be read without the wrapper
+	unwrappedStore, err = newTestFilesystemStoreWithWrapper(t, dir, pldconf.MasterKeyWrapperConfig{})
+	require.NoError(t, err)
+	_, err = unwrappedStore.LoadKeyMaterial(ctx, "wrapped")
+	assert.Regexp(t, "PD020837.*aes.*none", err)
+
+	// Or with a different master key
+	otherKeyStore, err := newTestFilesystemStoreWithWrapper(t, dir, pldconf.MasterKeyWrapperConfig{
+		Type: confutil.P(pldconf.MasterKeyWrapperAES),
+		AES:  pldconf.AESMasterKeyWrapperConfig{Key: tktypes.RandHex(32)},
+	})
+	require.NoError(t, err)
+	_, err = otherKeyStore.LoadKeyMaterial(ctx, "wrapped")
+	assert.Regexp(t, "PD020836", err)
+}
+
+func TestFilesystemStoreMasterKeyWrapperBadPasswordFile(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	store, err := newTestFilesystemStoreWithWrapper(t, dir, pldconf.MasterKeyWrapperConfig{
+		Type: confutil.P(pldconf.MasterKeyWrapperAES),
+		AES:  pldconf.AESMasterKeyWrapperConfig{Key: tktypes.RandHex(32)},
+	})
+	require.NoError(t, err)
+	_, _, err = store.FindOrCreateLoadableKey(ctx, &signerapi.ResolveKeyRequest{Name: "key1"},
+		func() ([]byte, error) { return tktypes.RandBytes(32), nil })
+	require.NoError(t, err)
+
+	_, err = store.readWalletFile(ctx, path.Join(dir, "-key1.key"), path.Join(dir, "-key1.pwd"))
+	require.NoError(t, err)
+
+	err = os.WriteFile(path.Join(dir, "-key1.pwd"), []byte("not hex"), 0600)
+	require.NoError(t, err)
+	_, err = store.readWalletFile(ctx, path.Join(dir, "-key1.key"), path.Join(dir, "-key1.pwd"))
+	assert.Regexp(t, "PD020836", err)
+
+	err = os.WriteFile(path.Join(dir, "-key1.key"), []byte("{ not json"), 0600)
+	require.NoError(t, err)
+	_, err = store.readWalletFile(ctx, path.Join(dir, "-key1.key"), path.Join(dir, "-key1.pwd"))
+	assert.Regexp(t, "PD020801", err)
+}
+
+func TestMasterKeyWrapperBadConfig(t *testing.T) {
+	ctx := context.Background()
+
+	_, err := newMasterKeyWrapper(ctx, &pldconf.MasterKeyWrapperConfig{Type: confutil.P("wrong")})
+	assert.Regexp(t, "PD020833.*wrong", err)
+
+	_, err = newMasterKeyWrapper(ctx, &pldconf.MasterKeyWrapperConfig{Type: confutil.P(pldconf.MasterKeyWrapperAES)})
+	assert.Regexp(t, "PD020834.*aes", err)
+
+	_, err = newMasterKeyWrapper(ctx, &pldconf.MasterKeyWrapperConfig{
+		Type: confutil.P(pldconf.MasterKeyWrapperAES),
+		AES:  pldconf.AESMasterKeyWrapperConfig{KeyFile: path.Join(t.TempDir(), "missing")},
+	})
+	assert.Regexp(t, "PD020834.*aes", err)
+
+	_, err = newMasterKeyWrapper(ctx, &pldconf.MasterKeyWrapperConfig{Type: confutil.P(pldconf.MasterKeyWrapperAWSKMS)})
+	assert.Regexp(t, "PD020834.*awskms", err)
+
+	_, err = newMasterKeyWrapper(ctx, &pldconf.MasterKeyWrapperConfig{
+		Type: confutil.P(pldconf.MasterKeyWrapperAWSKMS),
+		AWSKMS: pldconf.AWSKMSMasterKeyWrapperConfig{
+			HTTPClientConfig: pldconf.HTTPClientConfig{URL: "wrong://"},
+			Region:           "us-east-1",
+			KeyID:            "key1",
+			AccessKeyID:      "id",
+			SecretAccessKey:  "secret",
+		},
+	})
+	assert.Regexp(t, "PD020501", err)
+
+	_, err = newMasterKeyWrapper(ctx, &pldconf.MasterKeyWrapperConfig{Type: confutil.P(pldconf.MasterKeyWrapperVaultTransit)})
+	assert.Regexp(t, "PD020834.*vaulttransit", err)
+
+	_, err = newMasterKeyWrapper(ctx, &pldconf.MasterKeyWrapperConfig{
+		Type: confutil.P(pldconf.MasterKeyWrapperVaultTransit),
+		VaultTransit: pldconf.VaultTransitMasterKeyWrapperConfig{
+			HTTPClientConfig: pldconf.HTTPClientConfig{URL: "wrong://"},
+			KeyName:          "key1",
+			Token:            "token",
+		},
+	})
+	assert.Regexp(t, "PD020501", err)
+
+	_, err = newTestFilesystemStoreWithWrapper(t, t.TempDir(), pldconf.MasterKeyWrapperConfig{Type: confutil.P("wrong")})
+	assert.Regexp(t, "PD020833", err)
+}
+
+func TestMasterKeyWrapperRemoteErrors(t *testing.T) {
+	ctx := context.Background()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte("pop"))
+	}))
+	defer server.Close()
+
+	awsWrapper, err := newAWSKMSMasterKeyWrapper(ctx, &pldconf.AWSKMSMasterKeyWrapperConfig{
+		HTTPClientConfig: pldconf.HTTPClientConfig{URL: server.URL},
+		Region:           "us-east-1",
+		KeyID:            "key1",
+		AccessKeyID:      "id",
+		SecretAccessKey:  "secret",
+	})
+	require.NoError(t, err)
+	_, err = awsWrapper.Wrap(ctx, []byte("data"))
+	assert.Regexp(t, "PD020835.*PD020838.*500.*pop", err)
+	_, err = awsWrapper.Unwrap(ctx, []byte("data"))
+	assert.Regexp(t, "PD020836.*PD020838.*500.*pop", err)
+
+	vaultWrapper, err := newVaultTransitMasterKeyWrapper(ctx, &pldconf.VaultTransitMasterKeyWrapperConfig{
+		HTTPClientConfig: pldconf.HTTPClientConfig{URL: server.URL},
+		KeyName:          "key1",
+		Token:            "token",
+	})
+	require.NoError(t, err)
+	_, err = vaultWrapper.Wrap(ctx, []byte("data"))
+	assert.Regexp(t, "PD020835.*PD020838.*500.*pop", err)
+	_, err = vaultWrapper.Unwrap(ctx, []byte("data"))
+	assert.Regexp(t, "PD020836.*PD020838.*500.*pop", err)
+
+	// Unreachable once the server is closed
+	server.Close()
+	_, err = awsWrapper.Wrap(ctx, []byte("data"))
+	assert.Regexp(t, "PD020835", err)
+	_, err = vaultWrapper.Wrap(ctx, []byte("data"))
+	assert.Regexp(t, "PD020835", err)
+}
+
+func TestAESMasterKeyWrapperUnwrapShort(t *testing.T) {
+	ctx := context.Background()
+	w, err := newAESMasterKeyWrapper(ctx, &pldconf.AESMasterKeyWrapperConfig{Key: "0x" + tktypes.RandHex(32)})
+	require.NoError(t, err)
+	_, err = w.Unwrap(ctx, []byte{0x01})
+	assert.Regexp(t, "PD020836", err)
+}
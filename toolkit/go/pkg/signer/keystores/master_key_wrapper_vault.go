@@ -0,0 +1,109 @@
+/*
+ * Copyright © 2024 Kaleido, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with
+ * the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package keystores
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/kaleido-io/paladin/config/pkg/confutil"
+	"github.com/kaleido-io/paladin/config/pkg/pldconf"
+	"github.com/kaleido-io/paladin/toolkit/pkg/i18n"
+	"github.com/kaleido-io/paladin/toolkit/pkg/rpcclient"
+	"github.com/kaleido-io/paladin/toolkit/pkg/tkmsgs"
+)
+
+// Uses the encrypt/decrypt endpoints of the HashiCorp Vault Transit secrets engine
+type vaultTransitMasterKeyWrapper struct {
+	client      *resty.Client
+	encryptPath string
+	decryptPath string
+	token       string
+}
+
+type vaultTransitRequest struct {
+	Plaintext  []byte `json:"plaintext,omitempty"` // base64 encoded, as is the default for []byte
+	Ciphertext string `json:"ciphertext,omitempty"`
+}
+
+type vaultTransitResponse struct {
+	Data struct {
+		Plaintext  []byte `json:"plaintext,omitempty"`
+		Ciphertext string `json:"ciphertext,omitempty"`
+	} `json:"data"`
+}
+
+func newVaultTransitMasterKeyWrapper(ctx context.Context, conf *pldconf.VaultTransitMasterKeyWrapperConfig) (*vaultTransitMasterKeyWrapper, error) {
+	if conf.KeyName == "" || conf.Token == "" {
+		return nil, i18n.NewError(ctx, tkmsgs.MsgSigningModuleBadMasterKeyWrapperConfig, pldconf.MasterKeyWrapperVaultTransit, "keyName and token are required")
+	}
+	client, err := rpcclient.ParseHTTPConfig(ctx, &conf.HTTPClientConfig)
+	if err != nil {
+		return nil, err
+	}
+	mountPath := strings.Trim(confutil.StringNotEmpty(conf.MountPath, *pldconf.FileSystemDefaults.MasterKeyWrapper.VaultTransit.MountPath), "/")
+	keyName := url.PathEscape(conf.KeyName)
+	return &vaultTransitMasterKeyWrapper{
+		client:      client,
+		encryptPath: fmt.Sprintf("/v1/%s/encrypt/%s", mountPath, keyName),
+		decryptPath: fmt.Sprintf("/v1/%s/decrypt/%s", mountPath, keyName),
+		token:       conf.Token,
+	}, nil
+}
+
+func (w *vaultTransitMasterKeyWrapper) Type() string {
+	return pldconf.MasterKeyWrapperVaultTransit
+}
+
+func (w *vaultTransitMasterKeyWrapper) Wrap(ctx context.Context, plaintext []byte) ([]byte, error) {
+	res, err := w.invoke(ctx, w.encryptPath, &vaultTransitRequest{Plaintext: plaintext})
+	if err != nil {
+		return nil, i18n.WrapError(ctx, err, tkmsgs.MsgSigningModuleMasterKeyWrapFailed, w.Type())
+	}
+	// Vault returns a self-describing string, such as "vault:v1:...", which we store as-is
+	return []byte(res.Data.Ciphertext), nil
+}
+
+func (w *vaultTransitMasterKeyWrapper) Unwrap(ctx context.Context, wrapped []byte) ([]byte, error) {
+	res, err := w.invoke(ctx, w.decryptPath, &vaultTransitRequest{Ciphertext: string(wrapped)})
+	if err != nil {
+		return nil, i18n.WrapError(ctx, err, tkmsgs.MsgSigningModuleMasterKeyUnwrapFailed, w.Type())
+	}
+	return res.Data.Plaintext, nil
+}
+
+func (w *vaultTransitMasterKeyWrapper) invoke(ctx context.Context, path string, req *vaultTransitRequest) (*vaultTransitResponse, error) {
+	r, err := w.client.R().
+		SetContext(ctx).
+		SetHeader("X-Vault-Token", w.token).
+		SetBody(req).
+		Post(path)
+	if err != nil {
+		return nil, err
+	}
+	if r.IsError() {
+		return nil, i18n.NewError(ctx, tkmsgs.MsgSigningModuleMasterKeyWrapperHTTPError, w.Type(), r.StatusCode(), r.String())
+	}
+	var res vaultTransitResponse
+	if err := json.Unmarshal(r.Body(), &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
@@ -120,18 +120,26 @@ func (ils *staticStore) loadFileIntoKeyMap(ctx context.Context, filename string,
 	return nil
 }
 
-func (ils *staticStore) FindOrCreateLoadableKey(ctx context.Context, req *signerapi.ResolveKeyRequest, newKeyMaterial func() ([]byte, error)) (keyMaterial []byte, keyHandle string, err error) {
+func (ils *staticStore) resolveKeyHandle(ctx context.Context, req *signerapi.ResolveKeyRequest) (keyHandle string, err error) {
 	for _, segment := range req.Path {
 		if len(segment.Name) == 0 {
-			return nil, "", i18n.NewError(ctx, tkmsgs.MsgSigningModuleBadKeyHandle)
+			return "", i18n.NewError(ctx, tkmsgs.MsgSigningModuleBadKeyHandle)
 		}
 		keyHandle += url.PathEscape(segment.Name)
 		keyHandle += "."
 	}
 	if len(req.Name) == 0 {
-		return nil, "", i18n.NewError(ctx, tkmsgs.MsgSigningModuleBadKeyHandle)
+		return "", i18n.NewError(ctx, tkmsgs.MsgSigningModuleBadKeyHandle)
 	}
 	keyHandle += url.PathEscape(req.Name)
+	return keyHandle, nil
+}
+
+func (ils *staticStore) FindOrCreateLoadableKey(ctx context.Context, req *signerapi.ResolveKeyRequest, newKeyMaterial func() ([]byte, error)) (keyMaterial []byte, keyHandle string, err error) {
+	keyHandle, err = ils.resolveKeyHandle(ctx, req)
+	if err != nil {
+		return nil, "", err
+	}
 	key, err := ils.LoadKeyMaterial(ctx, keyHandle)
 	if err != nil {
 		return nil, "", err
@@ -139,13 +147,25 @@ func (ils *staticStore) FindOrCreateLoadableKey(ctx context.Context, req *signer
 	return key, keyHandle, nil
 }
 
+func (ils *staticStore) KeyExists(ctx context.Context, req *signerapi.ResolveKeyRequest) (bool, string, error) {
+	keyHandle, err := ils.resolveKeyHandle(ctx, req)
+	if err != nil {
+		return false, "", err
+	}
+	if _, ok := ils.keys[keyHandle]; !ok {
+		return false, "", nil
+	}
+	return true, keyHandle, nil
+}
+
 func (ils *staticStore) LoadKeyMaterial(ctx context.Context, keyHandle string) ([]byte, error) {
 	log.L(ctx).Debugf("Resolving key %s", keyHandle)
 	key, ok := ils.keys[keyHandle]
 	if !ok {
 		return nil, i18n.NewError(ctx, tkmsgs.MsgSigningKeyCannotBeResolved)
 	}
-	return key, nil
+	// The caller owns the key material it is given, so it can zero it without affecting the store
+	return append([]byte{}, key...), nil
 }
 
 func (ils *staticStore) Close() {
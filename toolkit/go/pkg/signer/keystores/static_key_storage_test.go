@@ -110,6 +110,12 @@ func TestStaticStoreBase64InConf(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, keyData, loadedKey)
 
+	// The caller owns the key material, so zeroing it does not affect the store
+	signerapi.ZeroKeyMaterial(loadedKey)
+	loadedKey, err = store.LoadKeyMaterial(ctx, "myKey")
+	require.NoError(t, err)
+	assert.Equal(t, keyData, loadedKey)
+
 }
 
 func TestStaticStoreLoadFileFail(t *testing.T) {
@@ -258,6 +264,33 @@ func TestStaticStoreResolveBadPath(t *testing.T) {
 
 }
 
+func TestStaticStoreKeyExists(t *testing.T) {
+
+	ctx, store := newTestStaticStore(t, map[string]pldconf.StaticKeyEntryConfig{
+		"my.shiny.key%20ten": {
+			Encoding: "none",
+			Inline:   "my key",
+		},
+	})
+	var checker signerapi.KeyStoreKeyExistenceChecker = store
+
+	path := []*signerapi.ResolveKeyPathSegment{{Name: "my"}, {Name: "shiny"}}
+	exists, keyHandle, err := checker.KeyExists(ctx, &signerapi.ResolveKeyRequest{Name: "key ten", Path: path})
+	require.NoError(t, err)
+	assert.True(t, exists)
+	assert.Equal(t, "my.shiny.key%20ten", keyHandle)
+
+	exists, keyHandle, err = checker.KeyExists(ctx, &signerapi.ResolveKeyRequest{Name: "key eleven", Path: path})
+	require.NoError(t, err)
+	assert.False(t, exists)
+	assert.Empty(t, keyHandle)
+	assert.Len(t, store.keys, 1)
+
+	_, _, err = checker.KeyExists(ctx, &signerapi.ResolveKeyRequest{})
+	assert.Regexp(t, "PD020803", err)
+
+}
+
 func TestStaticStoreResolveNotFound(t *testing.T) {
 
 	ctx, store := newTestStaticStore(t, map[string]pldconf.StaticKeyEntryConfig{
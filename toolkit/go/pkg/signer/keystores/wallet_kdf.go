@@ -0,0 +1,143 @@
+/*
+ * Copyright © 2024 Kaleido, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with
+ * the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package keystores
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/hyperledger/firefly-signer/pkg/keystorev3"
+	"github.com/kaleido-io/paladin/config/pkg/confutil"
+	"github.com/kaleido-io/paladin/config/pkg/pldconf"
+	"github.com/kaleido-io/paladin/toolkit/pkg/i18n"
+	"github.com/kaleido-io/paladin/toolkit/pkg/tkmsgs"
+	"golang.org/x/crypto/pbkdf2"
+	"golang.org/x/crypto/scrypt"
+	"golang.org/x/crypto/sha3"
+)
+
+// Key files are written in the Web3 Secret Storage (keystore V3) format, which records the KDF and its
+// parameters in the file itself. keystorev3 only creates files with fixed scrypt parameters, so we
+// write the file here and use keystorev3 to read it. Because the parameters travel with the file,
+// keys remain readable regardless of how the store is configured when they are loaded.
+type walletKDF struct {
+	kdfType          string
+	scryptN          int
+	scryptR          int
+	scryptP          int
+	pbkdf2Iterations int
+}
+
+const walletKDFDerivedKeyLen = 32
+
+func newWalletKDF(ctx context.Context, conf *pldconf.KeyStoreKDFConfig) (*walletKDF, error) {
+	defs := &pldconf.FileSystemDefaults.KDF
+	kdf := &walletKDF{
+		kdfType:          confutil.StringNotEmpty(conf.Type, *defs.Type),
+		scryptN:          confutil.Int(conf.ScryptN, *defs.ScryptN),
+		scryptR:          confutil.Int(conf.ScryptR, *defs.ScryptR),
+		scryptP:          confutil.Int(conf.ScryptP, *defs.ScryptP),
+		pbkdf2Iterations: confutil.Int(conf.PBKDF2Iterations, *defs.PBKDF2Iterations),
+	}
+	switch kdf.kdfType {
+	case pldconf.KeyStoreKDFScrypt:
+		if kdf.scryptN <= 1 || kdf.scryptN&(kdf.scryptN-1) != 0 || kdf.scryptR <= 0 || kdf.scryptP <= 0 {
+			return nil, i18n.NewError(ctx, tkmsgs.MsgSigningModuleBadKDFParams, fmt.Sprintf("scryptN=%d scryptR=%d scryptP=%d", kdf.scryptN, kdf.scryptR, kdf.scryptP))
+		}
+	case pldconf.KeyStoreKDFPBKDF2:
+		if kdf.pbkdf2Iterations <= 0 {
+			return nil, i18n.NewError(ctx, tkmsgs.MsgSigningModuleBadKDFParams, fmt.Sprintf("pbkdf2Iterations=%d", kdf.pbkdf2Iterations))
+		}
+	default:
+		return nil, i18n.NewError(ctx, tkmsgs.MsgSigningModuleBadKDFType, kdf.kdfType)
+	}
+	return kdf, nil
+}
+
+func (kdf *walletKDF) deriveKey(password, salt []byte) (derivedKey []byte, kdfParams map[string]any, err error) {
+	switch kdf.kdfType {
+	case pldconf.KeyStoreKDFPBKDF2:
+		derivedKey = pbkdf2.Key(password, salt, kdf.pbkdf2Iterations, walletKDFDerivedKeyLen, sha256.New)
+		kdfParams = map[string]any{
+			"dklen": walletKDFDerivedKeyLen,
+			"c":     kdf.pbkdf2Iterations,
+			"prf":   "hmac-sha256",
+			"salt":  hex.EncodeToString(salt),
+		}
+	default:
+		derivedKey, err = scrypt.Key(password, salt, kdf.scryptN, kdf.scryptR, kdf.scryptP, walletKDFDerivedKeyLen)
+		kdfParams = map[string]any{
+			"dklen": walletKDFDerivedKeyLen,
+			"n":     kdf.scryptN,
+			"r":     kdf.scryptR,
+			"p":     kdf.scryptP,
+			"salt":  hex.EncodeToString(salt),
+		}
+	}
+	return derivedKey, kdfParams, err
+}
+
+func (kdf *walletKDF) newWalletFile(ctx context.Context, password string, privateKey []byte) (keystorev3.WalletFile, error) {
+	salt := make([]byte, 32)
+	iv := make([]byte, 16 /* 128bit */)
+	_, err := rand.Read(salt)
+	if err == nil {
+		_, err = rand.Read(iv)
+	}
+	var derivedKey []byte
+	var kdfParams map[string]any
+	if err == nil {
+		derivedKey, kdfParams, err = kdf.deriveKey([]byte(password), salt)
+	}
+	var block cipher.Block
+	if err == nil {
+		// First 16 bytes of derived key are used as the AES-128-CTR encryption key
+		block, err = aes.NewCipher(derivedKey[0:16])
+	}
+	if err != nil {
+		return nil, i18n.WrapError(ctx, err, tkmsgs.MsgSigningModuleBadKDFParams, kdf.kdfType)
+	}
+	cipherText := make([]byte, len(privateKey))
+	cipher.NewCTR(block, iv).XORKeyStream(cipherText, privateKey)
+
+	// Last 16 bytes of derived key are used for the MAC
+	hash := sha3.NewLegacyKeccak256()
+	hash.Write(derivedKey[16:32])
+	hash.Write(cipherText)
+
+	walletJSON, _ := json.Marshal(map[string]any{
+		"id":      uuid.New().String(),
+		"version": 3,
+		"crypto": map[string]any{
+			"cipher":     "aes-128-ctr",
+			"ciphertext": hex.EncodeToString(cipherText),
+			"cipherparams": map[string]any{
+				"iv": hex.EncodeToString(iv),
+			},
+			"kdf":       kdf.kdfType,
+			"kdfparams": kdfParams,
+			"mac":       hex.EncodeToString(hash.Sum(nil)),
+		},
+	})
+	return keystorev3.ReadWalletFile(walletJSON, []byte(password))
+}
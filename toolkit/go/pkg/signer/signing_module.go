@@ -28,6 +28,7 @@ import (
 	"github.com/kaleido-io/paladin/toolkit/pkg/signer/signers"
 	"github.com/kaleido-io/paladin/toolkit/pkg/signerapi"
 	"github.com/kaleido-io/paladin/toolkit/pkg/tkmsgs"
+	"github.com/kaleido-io/paladin/toolkit/pkg/tktypes"
 )
 
 // SigningModule provides functions for the signerapi request/reply functions from the signerapil interface defined
@@ -39,6 +40,7 @@ type SigningModule interface {
 	Resolve(ctx context.Context, req *signerapi.ResolveKeyRequest) (res *signerapi.ResolveKeyResponse, err error)
 	Sign(ctx context.Context, req *signerapi.SignRequest) (res *signerapi.SignResponse, err error)
 	List(ctx context.Context, req *signerapi.ListKeysRequest) (res *signerapi.ListKeysResponse, err error)
+	HealthCheck(ctx context.Context) error
 	Close()
 }
 
@@ -54,8 +56,11 @@ type signingModule[C signerapi.ExtensibleConfig] struct {
 	keyStore               signerapi.KeyStore
 	keyStoreSigner         signerapi.KeyStoreSigner
 	disableKeyListing      bool
+	readOnly               bool
+	zeroKeyMaterial        bool
 	hd                     *hdDerivation[C]
 	signingImplementations map[string]signerapi.InMemorySigner
+	auditHook              signerapi.KeyAuditHook
 }
 
 // We allow this same code to be used (un-modified) with set of initialization functions passed
@@ -106,6 +111,9 @@ func NewSigningModule[C signerapi.ExtensibleConfig](ctx context.Context, conf C,
 		for name, ksf := range e.KeyStoreFactories {
 			keyStoreImplementations[name] = ksf
 		}
+		if e.KeyAuditHook != nil {
+			sm.auditHook = e.KeyAuditHook
+		}
 	}
 
 	// Now we have all the possible factories mapped, we load the one keystore type we actually use
@@ -130,6 +138,16 @@ func NewSigningModule[C signerapi.ExtensibleConfig](ctx context.Context, conf C,
 		}
 	}
 
+	// Set before we initialize any HD wallet, as that might otherwise create the seed
+	sm.readOnly = ksConf.ReadOnly
+	if sm.readOnly && sm.keyStoreSigner != nil {
+		// The key store creates keys itself, so we must be able to check a key exists before asking it to resolve one
+		if _, ok := sm.keyStore.(signerapi.KeyStoreKeyExistenceChecker); !ok {
+			return nil, i18n.NewError(ctx, tkmsgs.MsgSigningModuleReadOnlyNoExistenceCheck, ksConf.Type)
+		}
+	}
+	sm.zeroKeyMaterial = ksConf.ZeroKeyMaterial
+
 	kdConf := conf.KeyDerivationConfig()
 	switch kdConf.Type {
 	case "", pldconf.KeyDerivationTypeDirect:
@@ -212,6 +230,15 @@ func (sm *signingModule[C]) Resolve(ctx context.Context, req *signerapi.ResolveK
 	// If we are delegating resolution to the keystore (hence all our in memory signers are disabled)
 	// then that's what we do in all cases. An individual signer works in one mode or the other
 	if sm.keyStoreSigner != nil {
+		if sm.readOnly {
+			exists, _, err := sm.keyStore.(signerapi.KeyStoreKeyExistenceChecker).KeyExists(ctx, req)
+			if err != nil {
+				return nil, err
+			}
+			if !exists {
+				return nil, i18n.NewError(ctx, tkmsgs.MsgSigningModuleKeyStoreReadOnly, req.Name)
+			}
+		}
 		return sm.keyStoreSigner.FindOrCreateInStoreSigningKey(ctx, req)
 	}
 	// If we have HD wallet derivation, then that is where we do the resolution
@@ -219,15 +246,113 @@ func (sm *signingModule[C]) Resolve(ctx context.Context, req *signerapi.ResolveK
 		return sm.hd.resolveHDWalletKey(ctx, req)
 	}
 	// Otherwise load up the key from the keystore into memory and build the verifiers
-	privateKey, keyHandle, err := sm.keyStore.FindOrCreateLoadableKey(ctx, req, func() ([]byte, error) {
+	privateKey, keyHandle, err := sm.findOrCreateLoadableKey(ctx, req, func() ([]byte, error) {
 		return sm.newKeyForAlgorithms(ctx, req.RequiredIdentifiers)
 	})
+	defer sm.releaseKeyMaterial(privateKey)
+	if err == nil {
+		err = sm.recordKeyAlgorithms(ctx, keyHandle, req.RequiredIdentifiers)
+	}
 	if err != nil {
 		return nil, err
 	}
 	return sm.buildResolveResponseWithIdentifiers(ctx, keyHandle, privateKey, req.RequiredIdentifiers)
 }
 
+// All loads of key material from the key store go through these functions, so they are audited
+func (sm *signingModule[C]) findOrCreateLoadableKey(ctx context.Context, req *signerapi.ResolveKeyRequest, newKeyMaterial func() ([]byte, error)) ([]byte, string, error) {
+	if sm.readOnly {
+		// The key store only asks for new key material when the key does not exist
+		newKeyMaterial = func() ([]byte, error) {
+			return nil, i18n.NewError(ctx, tkmsgs.MsgSigningModuleKeyStoreReadOnly, req.Name)
+		}
+	}
+	keyMaterial, keyHandle, err := sm.keyStore.FindOrCreateLoadableKey(ctx, req, newKeyMaterial)
+	sm.auditKeyAccess(ctx, signerapi.KeyAuditOpFindOrCreate, req.Name, keyHandle, err)
+	return keyMaterial, keyHandle, err
+}
+
+func (sm *signingModule[C]) loadKeyMaterial(ctx context.Context, keyHandle string) ([]byte, error) {
+	keyMaterial, err := sm.keyStore.LoadKeyMaterial(ctx, keyHandle)
+	sm.auditKeyAccess(ctx, signerapi.KeyAuditOpLoad, "", keyHandle, err)
+	return keyMaterial, err
+}
+
+// Called when an operation has finished with key material it loaded into memory
+func (sm *signingModule[C]) releaseKeyMaterial(keyMaterial []byte) {
+	if sm.zeroKeyMaterial {
+		signerapi.ZeroKeyMaterial(keyMaterial)
+	}
+}
+
+func (sm *signingModule[C]) auditKeyAccess(ctx context.Context, operation, keyName, keyHandle string, err error) {
+	if sm.auditHook == nil {
+		return
+	}
+	record := &signerapi.KeyAuditRecord{
+		Operation: operation,
+		KeyHandle: keyHandle,
+		KeyName:   keyName,
+		Timestamp: tktypes.TimestampNow(),
+	}
+	if err != nil {
+		record.Error = err.Error()
+	}
+	sm.auditHook(ctx, record)
+}
+
+// If the key store records the algorithms each key is used with, we add any the resolve request requires
+// that are not yet recorded. Keys created before the algorithms were recorded are left unrestricted.
+func (sm *signingModule[C]) recordKeyAlgorithms(ctx context.Context, keyHandle string, requiredIdentifiers []*signerapi.PublicKeyIdentifierType) error {
+	algorithmAware, isAlgorithmAware := sm.keyStore.(signerapi.KeyStoreAlgorithmAware)
+	if !isAlgorithmAware {
+		return nil
+	}
+	keyAlgorithms, err := algorithmAware.LoadKeyAlgorithms(ctx, keyHandle)
+	if err != nil || len(keyAlgorithms) == 0 {
+		return err
+	}
+	newAlgorithms := []string{}
+	for _, required := range requiredIdentifiers {
+		if !containsAlgorithm(keyAlgorithms, required.Algorithm) && !containsAlgorithm(newAlgorithms, required.Algorithm) {
+			newAlgorithms = append(newAlgorithms, required.Algorithm)
+		}
+	}
+	if len(newAlgorithms) == 0 {
+		return nil
+	}
+	return algorithmAware.AddKeyAlgorithms(ctx, keyHandle, newAlgorithms)
+}
+
+// If the key store records the algorithms each key is used with, we reject signing with any other algorithm
+func (sm *signingModule[C]) checkKeyAlgorithm(ctx context.Context, keyHandle, algorithm string) error {
+	algorithmAware, isAlgorithmAware := sm.keyStore.(signerapi.KeyStoreAlgorithmAware)
+	if !isAlgorithmAware {
+		return nil
+	}
+	keyAlgorithms, err := algorithmAware.LoadKeyAlgorithms(ctx, keyHandle)
+	if err != nil {
+		return err
+	}
+	if len(keyAlgorithms) == 0 {
+		// the key was created before algorithms were recorded
+		return nil
+	}
+	if containsAlgorithm(keyAlgorithms, algorithm) {
+		return nil
+	}
+	return i18n.NewError(ctx, tkmsgs.MsgSigningKeyAlgorithmMismatch, keyHandle, keyAlgorithms, algorithm)
+}
+
+func containsAlgorithm(keyAlgorithms []string, algorithm string) bool {
+	for _, keyAlgorithm := range keyAlgorithms {
+		if strings.EqualFold(keyAlgorithm, algorithm) {
+			return true
+		}
+	}
+	return false
+}
+
 func (sm *signingModule[C]) buildResolveResponseWithIdentifiers(ctx context.Context, keyHandle string, privateKey []byte, requiredIdentifiers []*signerapi.PublicKeyIdentifierType) (*signerapi.ResolveKeyResponse, error) {
 	identifiers := make([]*signerapi.PublicKeyIdentifier, len(requiredIdentifiers))
 	for i, required := range requiredIdentifiers {
@@ -261,7 +386,12 @@ func (sm *signingModule[C]) Sign(ctx context.Context, req *signerapi.SignRequest
 		return sm.hd.signHDWalletKey(ctx, req)
 	}
 	// Otherwise load up the key from the keystore into memory and do the signing
-	privateKey, err := sm.keyStore.LoadKeyMaterial(ctx, req.KeyHandle)
+	err = sm.checkKeyAlgorithm(ctx, req.KeyHandle, req.Algorithm)
+	if err != nil {
+		return nil, err
+	}
+	privateKey, err := sm.loadKeyMaterial(ctx, req.KeyHandle)
+	defer sm.releaseKeyMaterial(privateKey)
 	if err != nil {
 		return nil, err
 	}
@@ -273,7 +403,46 @@ func (sm *signingModule[C]) List(ctx context.Context, req *signerapi.ListKeysReq
 	if !isListable || sm.disableKeyListing {
 		return nil, i18n.NewError(ctx, tkmsgs.MsgSigningKeyListingNotSupported)
 	}
-	return listableStore.ListKeys(ctx, req)
+	prefixStore, isPrefixListable := listableStore.(signerapi.KeyStoreListablePrefix)
+	if req.Prefix == "" || (isPrefixListable && prefixStore.ListKeysSupportsPrefix()) {
+		return listableStore.ListKeys(ctx, req)
+	}
+	return sm.listKeysFilterPrefix(ctx, listableStore, req)
+}
+
+// Pages through the natural order of the store, keeping only the keys that match the prefix.
+// Each page is requested with a limit of the remaining number of matches we need, so we never
+// over-read a page and the cursor returned is always safe to continue from.
+func (sm *signingModule[C]) listKeysFilterPrefix(ctx context.Context, listableStore signerapi.KeyStoreListable, req *signerapi.ListKeysRequest) (*signerapi.ListKeysResponse, error) {
+	res := &signerapi.ListKeysResponse{Items: []*signerapi.ListKeyEntry{}}
+	next := req.Continue
+	for {
+		pageReq := &signerapi.ListKeysRequest{Continue: next}
+		if req.Limit > 0 {
+			pageReq.Limit = req.Limit - len(res.Items)
+		}
+		page, err := listableStore.ListKeys(ctx, pageReq)
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range page.Items {
+			if strings.HasPrefix(item.KeyHandle, req.Prefix) {
+				res.Items = append(res.Items, item)
+			}
+		}
+		next = page.Next
+		if next == "" || (req.Limit > 0 && len(res.Items) >= req.Limit) {
+			res.Next = next
+			return res, nil
+		}
+	}
+}
+
+func (sm *signingModule[C]) HealthCheck(ctx context.Context) error {
+	if healthChecker, ok := sm.keyStore.(signerapi.KeyStoreHealthChecker); ok {
+		return healthChecker.HealthCheck(ctx)
+	}
+	return nil
 }
 
 func (sm *signingModule[C]) Close() {
@@ -16,16 +16,23 @@
 package signer
 
 import (
+	"bytes"
 	"context"
+	"encoding/base64"
 	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"math/big"
+	"os"
+	"path"
+	"strings"
 	"testing"
 
 	"github.com/hyperledger/firefly-signer/pkg/secp256k1"
 	"github.com/kaleido-io/paladin/config/pkg/confutil"
 	"github.com/kaleido-io/paladin/config/pkg/pldconf"
 	"github.com/kaleido-io/paladin/toolkit/pkg/algorithms"
+	"github.com/kaleido-io/paladin/toolkit/pkg/signer/keystores"
 	"github.com/kaleido-io/paladin/toolkit/pkg/signerapi"
 	"github.com/kaleido-io/paladin/toolkit/pkg/signpayloads"
 	"github.com/kaleido-io/paladin/toolkit/pkg/tktypes"
@@ -62,6 +69,27 @@ type testKeyStoreAll struct {
 	findOrCreateInStoreSigningKey func(ctx context.Context, req *signerapi.ResolveKeyRequest) (res *signerapi.ResolveKeyResponse, err error)
 	signWithinKeystore            func(ctx context.Context, req *signerapi.SignRequest) (res *signerapi.SignResponse, err error)
 	listKeys                      func(ctx context.Context, req *signerapi.ListKeysRequest) (res *signerapi.ListKeysResponse, err error)
+	listKeysSupportsPrefix        bool
+	keyExists                     func(ctx context.Context, req *signerapi.ResolveKeyRequest) (exists bool, keyHandle string, err error)
+}
+
+// A key store that signs in store, but cannot check for a key without creating it
+type testKeyStoreSignerOnly struct {
+	testKeyStoreBase
+}
+
+func (tk *testKeyStoreSignerOnly) FindOrCreateInStoreSigningKey(ctx context.Context, req *signerapi.ResolveKeyRequest) (res *signerapi.ResolveKeyResponse, err error) {
+	panic("should not be called")
+}
+
+func (tk *testKeyStoreSignerOnly) SignWithinKeystore(ctx context.Context, req *signerapi.SignRequest) (res *signerapi.SignResponse, err error) {
+	panic("should not be called")
+}
+
+type testKeyStoreSignerOnlyFactory struct{}
+
+func (tf *testKeyStoreSignerOnlyFactory) NewKeyStore(ctx context.Context, conf *signerapi.ConfigNoExt) (signerapi.KeyStore, error) {
+	return &testKeyStoreSignerOnly{}, nil
 }
 
 func (tk *testKeyStoreBase) FindOrCreateLoadableKey(ctx context.Context, req *signerapi.ResolveKeyRequest, newKeyMaterial func() ([]byte, error)) (keyMaterial []byte, keyHandle string, err error) {
@@ -86,6 +114,14 @@ func (tk *testKeyStoreAll) ListKeys(ctx context.Context, req *signerapi.ListKeys
 	return tk.listKeys(ctx, req)
 }
 
+func (tk *testKeyStoreAll) ListKeysSupportsPrefix() bool {
+	return tk.listKeysSupportsPrefix
+}
+
+func (tk *testKeyStoreAll) KeyExists(ctx context.Context, req *signerapi.ResolveKeyRequest) (bool, string, error) {
+	return tk.keyExists(ctx, req)
+}
+
 type testInMemorySignerFactory struct {
 	signer *testMemSigner
 	err    error
@@ -271,6 +307,129 @@ func TestExtensionKeyStoreListFail(t *testing.T) {
 
 }
 
+// lists the sorted key handles in natural order, using the last key handle returned as the cursor
+func testListSortedKeys(keyHandles []string, applyPrefix bool) func(ctx context.Context, req *signerapi.ListKeysRequest) (res *signerapi.ListKeysResponse, err error) {
+	return func(ctx context.Context, req *signerapi.ListKeysRequest) (res *signerapi.ListKeysResponse, err error) {
+		res = &signerapi.ListKeysResponse{}
+		for i, keyHandle := range keyHandles {
+			if keyHandle <= req.Continue || (applyPrefix && !strings.HasPrefix(keyHandle, req.Prefix)) {
+				continue
+			}
+			if req.Limit > 0 && len(res.Items) == req.Limit {
+				res.Next = keyHandles[i-1]
+				break
+			}
+			res.Items = append(res.Items, &signerapi.ListKeyEntry{Name: keyHandle, KeyHandle: keyHandle})
+		}
+		return res, nil
+	}
+}
+
+func newTestPrefixListSigningModule(t *testing.T, tk *testKeyStoreAll) SigningModule {
+	sm, err := NewSigningModule(context.Background(), &signerapi.ConfigNoExt{
+		KeyStore: pldconf.KeyStoreConfig{
+			Type: "ext-store",
+		},
+	}, &signerapi.Extensions[*signerapi.ConfigNoExt]{
+		KeyStoreFactories: map[string]signerapi.KeyStoreFactory[*signerapi.ConfigNoExt]{
+			"ext-store": &testKeyStoreAllFactory{keyStore: tk},
+		},
+	})
+	require.NoError(t, err)
+	return sm
+}
+
+func testListAllPages(t *testing.T, sm SigningModule, prefix string, limit int) (keyHandles []string, pages int) {
+	next := ""
+	for {
+		res, err := sm.List(context.Background(), &signerapi.ListKeysRequest{
+			Limit:    limit,
+			Continue: next,
+			Prefix:   prefix,
+		})
+		require.NoError(t, err)
+		pages++
+		require.LessOrEqual(t, len(res.Items), limit)
+		for _, item := range res.Items {
+			keyHandles = append(keyHandles, item.KeyHandle)
+		}
+		if res.Next == "" {
+			return keyHandles, pages
+		}
+		require.Less(t, pages, 100)
+		next = res.Next
+	}
+}
+
+var testPrefixListKeyHandles = []string{
+	"alice/key1", "alice/key2", "bob/key1", "bob/key2", "bob/key3", "bob/key4", "bob/key5", "carol/key1", "carol/key2",
+}
+
+func TestListKeysPrefixClientSideFilter(t *testing.T) {
+	listCalls := 0
+	listSorted := testListSortedKeys(testPrefixListKeyHandles, false)
+	tk := &testKeyStoreAll{
+		listKeys: func(ctx context.Context, req *signerapi.ListKeysRequest) (res *signerapi.ListKeysResponse, err error) {
+			// the prefix is not passed to a store that does not support it
+			assert.Empty(t, req.Prefix)
+			listCalls++
+			return listSorted(ctx, req)
+		},
+	}
+	sm := newTestPrefixListSigningModule(t, tk)
+	defer sm.Close()
+
+	keyHandles, pages := testListAllPages(t, sm, "bob/", 2)
+	assert.Equal(t, []string{"bob/key1", "bob/key2", "bob/key3", "bob/key4", "bob/key5"}, keyHandles)
+	assert.Equal(t, 3, pages)
+	assert.Greater(t, listCalls, pages)
+
+	keyHandles, _ = testListAllPages(t, sm, "dave/", 2)
+	assert.Empty(t, keyHandles)
+
+	// no prefix lists everything
+	keyHandles, _ = testListAllPages(t, sm, "", 4)
+	assert.Equal(t, testPrefixListKeyHandles, keyHandles)
+
+	// no limit still terminates
+	res, err := sm.List(context.Background(), &signerapi.ListKeysRequest{Prefix: "carol/"})
+	require.NoError(t, err)
+	assert.Len(t, res.Items, 2)
+	assert.Empty(t, res.Next)
+}
+
+func TestListKeysPrefixServerSideFilter(t *testing.T) {
+	listCalls := 0
+	listSorted := testListSortedKeys(testPrefixListKeyHandles, true)
+	tk := &testKeyStoreAll{
+		listKeysSupportsPrefix: true,
+		listKeys: func(ctx context.Context, req *signerapi.ListKeysRequest) (res *signerapi.ListKeysResponse, err error) {
+			assert.Equal(t, "bob/", req.Prefix)
+			listCalls++
+			return listSorted(ctx, req)
+		},
+	}
+	sm := newTestPrefixListSigningModule(t, tk)
+	defer sm.Close()
+
+	keyHandles, pages := testListAllPages(t, sm, "bob/", 2)
+	assert.Equal(t, []string{"bob/key1", "bob/key2", "bob/key3", "bob/key4", "bob/key5"}, keyHandles)
+	assert.Equal(t, pages, listCalls)
+}
+
+func TestListKeysPrefixClientSideFilterFail(t *testing.T) {
+	tk := &testKeyStoreAll{
+		listKeys: func(ctx context.Context, req *signerapi.ListKeysRequest) (res *signerapi.ListKeysResponse, err error) {
+			return nil, fmt.Errorf("pop")
+		},
+	}
+	sm := newTestPrefixListSigningModule(t, tk)
+	defer sm.Close()
+
+	_, err := sm.List(context.Background(), &signerapi.ListKeysRequest{Limit: 10, Prefix: "bob/"})
+	assert.Regexp(t, "pop", err)
+}
+
 func TestExtensionKeyStoreResolveSignSECP256K1OK(t *testing.T) {
 
 	tk := &testKeyStoreAll{
@@ -442,6 +601,320 @@ func TestResolveSignWithNewKeyCreation(t *testing.T) {
 
 }
 
+func TestReadOnlyKeyStore(t *testing.T) {
+	ctx := context.Background()
+	keyStoreConf := pldconf.KeyStoreConfig{
+		Type: pldconf.KeyStoreTypeFilesystem,
+		FileSystem: pldconf.FileSystemKeyStoreConfig{
+			Path: confutil.P(t.TempDir()),
+		},
+	}
+	resolveReq := func(name string) *signerapi.ResolveKeyRequest {
+		return &signerapi.ResolveKeyRequest{
+			RequiredIdentifiers: []*signerapi.PublicKeyIdentifierType{{Algorithm: algorithms.ECDSA_SECP256K1, VerifierType: verifiers.ETH_ADDRESS}},
+			Name:                name,
+		}
+	}
+
+	// Create a key while writable
+	sm, err := NewSigningModule(ctx, &signerapi.ConfigNoExt{KeyStore: keyStoreConf})
+	require.NoError(t, err)
+	created, err := sm.Resolve(ctx, resolveReq("key1"))
+	require.NoError(t, err)
+	sm.Close()
+
+	keyStoreConf.ReadOnly = true
+	sm, err = NewSigningModule(ctx, &signerapi.ConfigNoExt{KeyStore: keyStoreConf})
+	require.NoError(t, err)
+	defer sm.Close()
+
+	// The existing key resolves to the same verifier, and can be used to sign
+	resolved, err := sm.Resolve(ctx, resolveReq("key1"))
+	require.NoError(t, err)
+	assert.Equal(t, created.Identifiers[0].Verifier, resolved.Identifiers[0].Verifier)
+	_, err = sm.Sign(ctx, &signerapi.SignRequest{
+		KeyHandle:   resolved.KeyHandle,
+		Algorithm:   algorithms.ECDSA_SECP256K1,
+		PayloadType: signpayloads.OPAQUE_TO_RSV,
+		Payload:     ([]byte)("sign me"),
+	})
+	require.NoError(t, err)
+
+	// But a new key is not created
+	_, err = sm.Resolve(ctx, resolveReq("key2"))
+	assert.Regexp(t, "PD020841.*key2", err)
+	assert.NoFileExists(t, path.Join(*keyStoreConf.FileSystem.Path, "key2.key"))
+}
+
+func TestReadOnlyKeyStoreInStoreSigning(t *testing.T) {
+	ctx := context.Background()
+	created := &signerapi.ResolveKeyResponse{KeyHandle: "key1"}
+	tk := &testKeyStoreAll{
+		keyExists: func(ctx context.Context, req *signerapi.ResolveKeyRequest) (bool, string, error) {
+			if req.Name == "pop" {
+				return false, "", fmt.Errorf("pop")
+			}
+			return req.Name == "key1", req.Name, nil
+		},
+		findOrCreateInStoreSigningKey: func(ctx context.Context, req *signerapi.ResolveKeyRequest) (*signerapi.ResolveKeyResponse, error) {
+			require.Equal(t, "key1", req.Name)
+			return created, nil
+		},
+	}
+	sm, err := NewSigningModule(ctx, &signerapi.ConfigNoExt{
+		KeyStore: pldconf.KeyStoreConfig{
+			Type:            "ext-store",
+			KeyStoreSigning: true,
+			ReadOnly:        true,
+		},
+	}, &signerapi.Extensions[*signerapi.ConfigNoExt]{
+		KeyStoreFactories: map[string]signerapi.KeyStoreFactory[*signerapi.ConfigNoExt]{
+			"ext-store": &testKeyStoreAllFactory{keyStore: tk},
+		},
+	})
+	require.NoError(t, err)
+	defer sm.Close()
+
+	// An existing key is resolved by the store
+	res, err := sm.Resolve(ctx, &signerapi.ResolveKeyRequest{Name: "key1"})
+	require.NoError(t, err)
+	assert.Same(t, created, res)
+
+	// But the store is not asked to resolve a key it would have to create
+	_, err = sm.Resolve(ctx, &signerapi.ResolveKeyRequest{Name: "key2"})
+	assert.Regexp(t, "PD020841.*key2", err)
+	_, err = sm.Resolve(ctx, &signerapi.ResolveKeyRequest{Name: "pop"})
+	assert.Regexp(t, "pop", err)
+}
+
+func TestReadOnlyKeyStoreInStoreSigningNoExistenceCheck(t *testing.T) {
+	_, err := NewSigningModule(context.Background(), &signerapi.ConfigNoExt{
+		KeyStore: pldconf.KeyStoreConfig{
+			Type:            "ext-store",
+			KeyStoreSigning: true,
+			ReadOnly:        true,
+		},
+	}, &signerapi.Extensions[*signerapi.ConfigNoExt]{
+		KeyStoreFactories: map[string]signerapi.KeyStoreFactory[*signerapi.ConfigNoExt]{
+			"ext-store": &testKeyStoreSignerOnlyFactory{},
+		},
+	})
+	assert.Regexp(t, "PD020842.*ext-store", err)
+}
+
+func TestReadOnlyKeyStoreListKeys(t *testing.T) {
+	testRes := &signerapi.ListKeysResponse{
+		Items: []*signerapi.ListKeyEntry{{Name: "key1", KeyHandle: "key1"}},
+	}
+	tk := &testKeyStoreAll{
+		testKeyStoreBase: testKeyStoreBase{
+			findOrCreateLoadableKey: func(ctx context.Context, req *signerapi.ResolveKeyRequest, newKeyMaterial func() ([]byte, error)) (keyMaterial []byte, keyHandle string, err error) {
+				_, err = newKeyMaterial()
+				return nil, "", err
+			},
+		},
+		listKeys: func(ctx context.Context, req *signerapi.ListKeysRequest) (res *signerapi.ListKeysResponse, err error) {
+			return testRes, nil
+		},
+	}
+	te := &signerapi.Extensions[*signerapi.ConfigNoExt]{
+		KeyStoreFactories: map[string]signerapi.KeyStoreFactory[*signerapi.ConfigNoExt]{
+			"ext-store": &testKeyStoreAllFactory{keyStore: tk},
+		},
+	}
+
+	sm, err := NewSigningModule(context.Background(), &signerapi.ConfigNoExt{
+		KeyStore: pldconf.KeyStoreConfig{
+			Type:     "ext-store",
+			ReadOnly: true,
+		},
+	}, te)
+	require.NoError(t, err)
+	defer sm.Close()
+
+	res, err := sm.List(context.Background(), &signerapi.ListKeysRequest{Limit: 10})
+	require.NoError(t, err)
+	assert.Equal(t, testRes, res)
+
+	_, err = sm.Resolve(context.Background(), &signerapi.ResolveKeyRequest{
+		RequiredIdentifiers: []*signerapi.PublicKeyIdentifierType{{Algorithm: algorithms.ECDSA_SECP256K1, VerifierType: verifiers.ETH_ADDRESS}},
+		Name:                "key2",
+	})
+	assert.Regexp(t, "PD020841", err)
+}
+
+func TestKeyAuditHook(t *testing.T) {
+
+	var records []*signerapi.KeyAuditRecord
+	type ctxKey struct{}
+	sm, err := NewSigningModule(context.Background(), &signerapi.ConfigNoExt{
+		KeyStore: pldconf.KeyStoreConfig{
+			Type: pldconf.KeyStoreTypeFilesystem,
+			FileSystem: pldconf.FileSystemKeyStoreConfig{
+				Path: confutil.P(t.TempDir()),
+			},
+		},
+	}, &signerapi.Extensions[*signerapi.ConfigNoExt]{
+		KeyAuditHook: func(ctx context.Context, record *signerapi.KeyAuditRecord) {
+			assert.Equal(t, "caller1", ctx.Value(ctxKey{}))
+			records = append(records, record)
+		},
+	})
+	require.NoError(t, err)
+
+	ctx := context.WithValue(context.Background(), ctxKey{}, "caller1")
+	resolveRes, err := sm.Resolve(ctx, &signerapi.ResolveKeyRequest{
+		RequiredIdentifiers: []*signerapi.PublicKeyIdentifierType{{Algorithm: algorithms.ECDSA_SECP256K1, VerifierType: verifiers.ETH_ADDRESS}},
+		Name:                "key1",
+	})
+	require.NoError(t, err)
+
+	_, err = sm.Sign(ctx, &signerapi.SignRequest{
+		KeyHandle:   resolveRes.KeyHandle,
+		Algorithm:   algorithms.ECDSA_SECP256K1,
+		PayloadType: signpayloads.OPAQUE_TO_RSV,
+		Payload:     ([]byte)("sign me"),
+	})
+	require.NoError(t, err)
+
+	require.Len(t, records, 2)
+	assert.Equal(t, signerapi.KeyAuditOpFindOrCreate, records[0].Operation)
+	assert.Equal(t, "key1", records[0].KeyName)
+	assert.Equal(t, "key1", records[0].KeyHandle)
+	assert.Empty(t, records[0].Error)
+	assert.Equal(t, signerapi.KeyAuditOpLoad, records[1].Operation)
+	assert.Equal(t, "key1", records[1].KeyHandle)
+	assert.Empty(t, records[1].Error)
+
+	// The records identify the key, but never carry the key material
+	keyMaterial, err := sm.(*signingModule[*signerapi.ConfigNoExt]).keyStore.LoadKeyMaterial(ctx, resolveRes.KeyHandle)
+	require.NoError(t, err)
+	for _, r := range records {
+		assert.NotZero(t, r.Timestamp)
+		recordJSON, err := json.Marshal(r)
+		require.NoError(t, err)
+		assert.NotContains(t, string(recordJSON), hex.EncodeToString(keyMaterial))
+		assert.NotContains(t, string(recordJSON), base64.StdEncoding.EncodeToString(keyMaterial))
+	}
+
+}
+
+func TestKeyAuditHookLoadFail(t *testing.T) {
+
+	var records []*signerapi.KeyAuditRecord
+	sm, err := NewSigningModule(context.Background(), &signerapi.ConfigNoExt{
+		KeyStore: pldconf.KeyStoreConfig{
+			Type: pldconf.KeyStoreTypeStatic,
+		},
+	}, &signerapi.Extensions[*signerapi.ConfigNoExt]{
+		KeyAuditHook: func(ctx context.Context, record *signerapi.KeyAuditRecord) {
+			records = append(records, record)
+		},
+	})
+	require.NoError(t, err)
+
+	_, err = sm.Sign(context.Background(), &signerapi.SignRequest{
+		KeyHandle:   "missing",
+		Algorithm:   algorithms.ECDSA_SECP256K1,
+		PayloadType: signpayloads.OPAQUE_TO_RSV,
+		Payload:     ([]byte)("sign me"),
+	})
+	require.Error(t, err)
+
+	require.Len(t, records, 1)
+	assert.Equal(t, signerapi.KeyAuditOpLoad, records[0].Operation)
+	assert.Equal(t, "missing", records[0].KeyHandle)
+	assert.Equal(t, err.Error(), records[0].Error)
+
+}
+
+func TestResolveSignRejectsAlgorithmMismatch(t *testing.T) {
+
+	sm, err := NewSigningModule(context.Background(), &signerapi.ConfigNoExt{
+		KeyStore: pldconf.KeyStoreConfig{
+			Type: pldconf.KeyStoreTypeFilesystem,
+			FileSystem: pldconf.FileSystemKeyStoreConfig{
+				Path: confutil.P(t.TempDir()),
+			},
+		},
+	})
+	require.NoError(t, err)
+	sm.AddInMemorySigner("test1", &testMemSigner{
+		getMinimumKeyLen: func(ctx context.Context, algorithm string) (int, error) { return 32, nil },
+		getVerifier: func(ctx context.Context, algorithm, verifierType string, privateKey []byte) (string, error) {
+			return "verifier1", nil
+		},
+		sign: func(ctx context.Context, algorithm, payloadType string, privateKey, payload []byte) ([]byte, error) {
+			return []byte("signed"), nil
+		},
+	})
+
+	// Create a key for each algorithm
+	resolveRes, err := sm.Resolve(context.Background(), &signerapi.ResolveKeyRequest{
+		RequiredIdentifiers: []*signerapi.PublicKeyIdentifierType{{Algorithm: algorithms.ECDSA_SECP256K1, VerifierType: verifiers.ETH_ADDRESS}},
+		Name:                "key1",
+	})
+	require.NoError(t, err)
+	resolveRes2, err := sm.Resolve(context.Background(), &signerapi.ResolveKeyRequest{
+		RequiredIdentifiers: []*signerapi.PublicKeyIdentifierType{{Algorithm: "test1:any", VerifierType: "any"}},
+		Name:                "key2",
+	})
+	require.NoError(t, err)
+
+	// Re-resolving with the same algorithm is fine
+	_, err = sm.Resolve(context.Background(), &signerapi.ResolveKeyRequest{
+		RequiredIdentifiers: []*signerapi.PublicKeyIdentifierType{{Algorithm: algorithms.ECDSA_SECP256K1, VerifierType: verifiers.ETH_ADDRESS_CHECKSUM}},
+		Name:                "key1",
+	})
+	require.NoError(t, err)
+
+	// A key cannot sign with an algorithm it has not been resolved for
+	_, err = sm.Sign(context.Background(), &signerapi.SignRequest{
+		KeyHandle:   resolveRes.KeyHandle,
+		Algorithm:   "test1:any",
+		PayloadType: "any",
+		Payload:     ([]byte)("sign me"),
+	})
+	assert.Regexp(t, "PD020830.*key1.*test1:any", err)
+	_, err = sm.Sign(context.Background(), &signerapi.SignRequest{
+		KeyHandle:   resolveRes2.KeyHandle,
+		Algorithm:   algorithms.ECDSA_SECP256K1,
+		PayloadType: signpayloads.OPAQUE_TO_RSV,
+		Payload:     ([]byte)("sign me"),
+	})
+	assert.Regexp(t, "PD020830.*key2", err)
+
+	// Resolving an existing key for a further algorithm records it, so the key can sign with it
+	_, err = sm.Resolve(context.Background(), &signerapi.ResolveKeyRequest{
+		RequiredIdentifiers: []*signerapi.PublicKeyIdentifierType{{Algorithm: "test1:any", VerifierType: "any"}},
+		Name:                "key1",
+	})
+	require.NoError(t, err)
+	_, err = sm.Sign(context.Background(), &signerapi.SignRequest{
+		KeyHandle:   resolveRes.KeyHandle,
+		Algorithm:   algorithms.ECDSA_SECP256K1,
+		PayloadType: signpayloads.OPAQUE_TO_RSV,
+		Payload:     ([]byte)("sign me"),
+	})
+	require.NoError(t, err)
+	_, err = sm.Sign(context.Background(), &signerapi.SignRequest{
+		KeyHandle:   resolveRes.KeyHandle,
+		Algorithm:   "test1:any",
+		PayloadType: "any",
+		Payload:     ([]byte)("sign me"),
+	})
+	require.NoError(t, err)
+
+	_, err = sm.Sign(context.Background(), &signerapi.SignRequest{
+		KeyHandle:   "missing",
+		Algorithm:   algorithms.ECDSA_SECP256K1,
+		PayloadType: signpayloads.OPAQUE_TO_RSV,
+		Payload:     ([]byte)("sign me"),
+	})
+	assert.Regexp(t, "PD020806", err)
+
+}
+
 func TestResolveUnsupportedAlgo(t *testing.T) {
 
 	sm, err := NewSigningModule(context.Background(), &signerapi.ConfigNoExt{
@@ -551,3 +1024,120 @@ func TestInMemorySignFailures(t *testing.T) {
 	assert.Regexp(t, "PD020810", err)
 
 }
+
+func TestHealthCheck(t *testing.T) {
+
+	keyStoreDir := path.Join(t.TempDir(), "keystore")
+	err := os.Mkdir(keyStoreDir, 0700)
+	require.NoError(t, err)
+
+	sm, err := NewSigningModule(context.Background(), &signerapi.ConfigNoExt{
+		KeyStore: pldconf.KeyStoreConfig{
+			Type: pldconf.KeyStoreTypeFilesystem,
+			FileSystem: pldconf.FileSystemKeyStoreConfig{
+				Path: confutil.P(keyStoreDir),
+			},
+		},
+	})
+	require.NoError(t, err)
+	require.NoError(t, sm.HealthCheck(context.Background()))
+
+	// Simulate the volume going away
+	err = os.Remove(keyStoreDir)
+	require.NoError(t, err)
+	assert.Regexp(t, "PD020839", sm.HealthCheck(context.Background()))
+
+	// Stores without a health check are always healthy
+	sm, err = NewSigningModule(context.Background(), &signerapi.ConfigNoExt{
+		KeyStore: pldconf.KeyStoreConfig{
+			Type: pldconf.KeyStoreTypeStatic,
+		},
+	})
+	require.NoError(t, err)
+	require.NoError(t, sm.HealthCheck(context.Background()))
+
+}
+
+// Wraps a real key store, recording the key material it hands out so we can inspect it after use
+func newKeyMaterialRecordingStore(t *testing.T, loaded *[][]byte) *testKeyStoreBase {
+	fs, err := keystores.NewFilesystemStoreFactory[*signerapi.ConfigNoExt]().NewKeyStore(context.Background(), &signerapi.ConfigNoExt{
+		KeyStore: pldconf.KeyStoreConfig{
+			FileSystem: pldconf.FileSystemKeyStoreConfig{
+				Path: confutil.P(t.TempDir()),
+			},
+		},
+	})
+	require.NoError(t, err)
+	return &testKeyStoreBase{
+		findOrCreateLoadableKey: func(ctx context.Context, req *signerapi.ResolveKeyRequest, newKeyMaterial func() ([]byte, error)) ([]byte, string, error) {
+			keyMaterial, keyHandle, err := fs.FindOrCreateLoadableKey(ctx, req, newKeyMaterial)
+			*loaded = append(*loaded, keyMaterial)
+			return keyMaterial, keyHandle, err
+		},
+		loadKeyMaterial: func(ctx context.Context, keyHandle string) ([]byte, error) {
+			keyMaterial, err := fs.LoadKeyMaterial(ctx, keyHandle)
+			*loaded = append(*loaded, keyMaterial)
+			return keyMaterial, err
+		},
+	}
+}
+
+func TestZeroKeyMaterialAfterUse(t *testing.T) {
+	ctx := context.Background()
+
+	for _, zeroKeyMaterial := range []bool{true, false} {
+		var loaded [][]byte
+		te := &signerapi.Extensions[*signerapi.ConfigNoExt]{
+			KeyStoreFactories: map[string]signerapi.KeyStoreFactory[*signerapi.ConfigNoExt]{
+				"ext-store": &testKeyStoreBaseFactory{keyStore: newKeyMaterialRecordingStore(t, &loaded)},
+			},
+		}
+		sm, err := NewSigningModule(ctx, &signerapi.ConfigNoExt{
+			KeyStore: pldconf.KeyStoreConfig{
+				Type:            "ext-store",
+				ZeroKeyMaterial: zeroKeyMaterial,
+			},
+		}, te)
+		require.NoError(t, err)
+
+		resolveRes, err := sm.Resolve(ctx, &signerapi.ResolveKeyRequest{
+			RequiredIdentifiers: []*signerapi.PublicKeyIdentifierType{{Algorithm: algorithms.ECDSA_SECP256K1, VerifierType: verifiers.ETH_ADDRESS}},
+			Name:                "key1",
+		})
+		require.NoError(t, err)
+
+		// The key store is unaffected by the zeroing, so we can sign repeatedly
+		for i := 0; i < 2; i++ {
+			_, err = sm.Sign(ctx, &signerapi.SignRequest{
+				KeyHandle:   resolveRes.KeyHandle,
+				Algorithm:   algorithms.ECDSA_SECP256K1,
+				PayloadType: signpayloads.OPAQUE_TO_RSV,
+				Payload:     ([]byte)("sign me"),
+			})
+			require.NoError(t, err)
+		}
+
+		// Including when the signing fails after the key material is loaded
+		_, err = sm.Sign(ctx, &signerapi.SignRequest{
+			KeyHandle:   resolveRes.KeyHandle,
+			Algorithm:   algorithms.ECDSA_SECP256K1,
+			PayloadType: "wrong",
+			Payload:     ([]byte)("sign me"),
+		})
+		require.Error(t, err)
+
+		require.Len(t, loaded, 4)
+		for _, keyMaterial := range loaded {
+			require.Len(t, keyMaterial, 32)
+			assert.Equal(t, zeroKeyMaterial, bytes.Equal(make([]byte, 32), keyMaterial), "zeroKeyMaterial=%t", zeroKeyMaterial)
+		}
+		sm.Close()
+	}
+}
+
+func TestZeroKeyMaterialHelper(t *testing.T) {
+	keyMaterial := []byte{1, 2, 3}
+	signerapi.ZeroKeyMaterial(keyMaterial)
+	assert.Equal(t, []byte{0, 0, 0}, keyMaterial)
+	signerapi.ZeroKeyMaterial(nil)
+}
@@ -39,4 +39,5 @@ type ExtensibleConfig interface {
 type Extensions[C ExtensibleConfig] struct {
 	KeyStoreFactories       map[string]KeyStoreFactory[C]
 	InMemorySignerFactories map[string]InMemorySignerFactory[C]
+	KeyAuditHook            KeyAuditHook
 }
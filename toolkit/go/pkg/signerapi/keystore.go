@@ -17,6 +17,8 @@ package signerapi
 
 import (
 	"context"
+
+	"github.com/kaleido-io/paladin/toolkit/pkg/tktypes"
 )
 
 type KeyStoreFactory[C ExtensibleConfig] interface {
@@ -30,12 +32,106 @@ type KeyStoreFactory[C ExtensibleConfig] interface {
 // to securely store and retrieve it using only the information contained in the returned
 // keyHandle. If the implementation finds it does not exist, it can invoke the callback function to generate
 // a new suitable random string to encrypt and store.
+//
+// The key material returned is owned by the caller, which might zero it after use (see ZeroKeyMaterial),
+// so an implementation must not return a slice that it holds on to itself, such as in a cache.
 type KeyStore interface {
 	FindOrCreateLoadableKey(ctx context.Context, req *ResolveKeyRequest, newKeyMaterial func() ([]byte, error)) (keyMaterial []byte, keyHandle string, err error)
 	LoadKeyMaterial(ctx context.Context, keyHandle string) ([]byte, error)
 	Close()
 }
 
+// Overwrites key material with zeros, so the secret does not linger in memory after it has been used.
+// The signing module calls this on the key material returned by the key store when configured to.
+func ZeroKeyMaterial(keyMaterial []byte) {
+	for i := range keyMaterial {
+		keyMaterial[i] = 0
+	}
+}
+
+const (
+	KeyAuditOpFindOrCreate = "find_or_create"
+	KeyAuditOpLoad         = "load"
+)
+
+// A record of a single load of key material from the key store by the signing module, for compliance
+// audit trails. It identifies the key and the outcome, and never contains the key material itself.
+type KeyAuditRecord struct {
+	Operation string            `json:"operation"`
+	KeyHandle string            `json:"keyHandle,omitempty"` // empty if a find-or-create failed before a key handle was known
+	KeyName   string            `json:"keyName,omitempty"`   // the name from the resolve request, for find-or-create
+	Timestamp tktypes.Timestamp `json:"timestamp"`
+	Error     string            `json:"error,omitempty"`
+}
+
+// Supplied as an extension to the signing module, and invoked synchronously after every key material load.
+// The context is that of the caller, so any correlation it carries (such as a request ID) can be
+// recorded alongside the record when routing it to an external system.
+type KeyAuditHook func(ctx context.Context, record *KeyAuditRecord)
+
+// Key stores that hold loadable key material delegate the encryption of the secret protecting
+// each key to a master key wrapper, so an external KMS can hold the master key without the
+// storage and listing logic of the key store being reimplemented for each KMS.
+//
+// Type is recorded alongside each wrapped secret, so the store can detect a mismatch on load.
+type MasterKeyWrapper interface {
+	Type() string
+	Wrap(ctx context.Context, plaintext []byte) ([]byte, error)
+	Unwrap(ctx context.Context, wrapped []byte) ([]byte, error)
+}
+
+// Some cryptographic stores persist the path used to resolve a key (including the indexes used for
+// HD derivation) alongside the key material. This allows the full derivation path to be recovered
+// from the key handle alone after a restart, while the key handle remains an opaque string to callers.
+//
+// The returned path includes the key itself as the final segment.
+type KeyStoreDerivationPathAware interface {
+	LoadKeyDerivationPath(ctx context.Context, keyHandle string) ([]*ResolveKeyPathSegment, error)
+}
+
+// Some cryptographic stores record the algorithms a key is used with (taken from the required
+// identifiers of the resolve requests for it), so the signing module does not have to infer them
+// from the key material. The algorithms of the request that created the key are recorded with it,
+// and the signing module adds any further algorithms a later resolve request requires - as one key
+// can resolve verifiers for several algorithms. Sign requests for an algorithm the key has never
+// been resolved for are rejected.
+//
+// An empty list is returned for keys created before the algorithms were recorded, in which
+// case no check is performed.
+type KeyStoreAlgorithmAware interface {
+	LoadKeyAlgorithms(ctx context.Context, keyHandle string) ([]string, error)
+	AddKeyAlgorithms(ctx context.Context, keyHandle string, algorithms []string) error
+}
+
+// Some cryptographic stores support rotating a key, such that the name (and path) it is resolved by
+// becomes a stable alias for the latest key material, and references to that name are not broken.
+//
+// After a rotation FindOrCreateLoadableKey returns the new key material and its key handle. The key
+// handles of earlier material (returned oldest first by ListKeyVersions) remain loadable with
+// LoadKeyMaterial, for verification or decryption of historical data.
+type KeyStoreRotatable interface {
+	RotateKey(ctx context.Context, req *ResolveKeyRequest, newKeyMaterial func() ([]byte, error)) (keyMaterial []byte, keyHandle string, err error)
+	ListKeyVersions(ctx context.Context, req *ResolveKeyRequest) (keyHandles []string, err error)
+}
+
+// Some cryptographic stores can report whether a key exists, without loading the key material or creating
+// the key when it does not exist. This allows a key to be checked for in validation flows, and for a
+// signing module in read-only mode, with no side effects on the store.
+//
+// The key handle is only returned when the key exists.
+type KeyStoreKeyExistenceChecker interface {
+	KeyExists(ctx context.Context, req *ResolveKeyRequest) (exists bool, keyHandle string, err error)
+}
+
+// Some cryptographic stores depend on a backend that can become unavailable at runtime, such as a
+// mounted volume or a remote service. Those stores report whether they are currently able to load keys
+// and sign, so callers can hold back work rather than failing each attempt while the backend is down.
+//
+// Stores that do not implement this interface are always considered healthy.
+type KeyStoreHealthChecker interface {
+	HealthCheck(ctx context.Context) error
+}
+
 // Some cryptographic stores are capable of listing their contents in a natural order.
 //
 // It is a friendly behavior particularly at development/exploration time to be able to present
@@ -54,6 +150,14 @@ type KeyStoreListable interface {
 	ListKeys(ctx context.Context, req *ListKeysRequest) (res *ListKeysResponse, err error)
 }
 
+// Listable stores that can apply the Prefix of a ListKeysRequest in the backend implement this interface,
+// returning true. For all other stores the signing module filters the listing itself, paging through the
+// full listing from the store until it has enough matching keys.
+type KeyStoreListablePrefix interface {
+	KeyStoreListable
+	ListKeysSupportsPrefix() bool
+}
+
 // Some cryptographic storage systems, in particular Hardware Security Modules (HSMs) and Cloud HSM systems,
 // support signing directly with certain curves.
 //
@@ -69,6 +69,9 @@ type ListKeysRequest struct {
 
 	// the "next" string from a previous call, or empty
 	Continue string `json:"continue,omitempty"`
+
+	// if set, only keys with a key handle starting with this prefix are returned
+	Prefix string `json:"prefix,omitempty"`
 }
 
 type ListKeysResponse struct {
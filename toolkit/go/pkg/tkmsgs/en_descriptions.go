@@ -80,6 +80,8 @@ var (
 	PublicTxInputFrom                      = pdm("PublicTxInput.from", "The resolved signing account")
 	PublicTxInputTo                        = pdm("PublicTxInput.to", "The target contract address (optional)")
 	PublicTxInputData                      = pdm("PublicTxInput.data", "The pre-encoded calldata (optional)")
+	PublicTxInputLabel                     = pdm("PublicTxInput.label", "A free-text label that can be used to search for the transaction (optional)")
+	PublicTxInputNonce                     = pdm("PublicTxInput.nonce", "An explicit nonce to use instead of automatic assignment, for recovery such as filling a nonce gap (optional)")
 	PublicTxSubmissionFrom                 = pdm("PublicTxSubmission.from", "The sender's Ethereum address")
 	PublicTxSubmissionNonce                = pdm("PublicTxSubmission.nonce", "The transaction nonce")
 	PublicTxSubmissionDataTime             = pdm("PublicTxSubmissionData.time", "The submission time")
@@ -90,12 +92,16 @@ var (
 	PublicTxFrom                           = pdm("PublicTx.from", "The sender's Ethereum address")
 	PublicTxNonce                          = pdm("PublicTx.nonce", "The transaction nonce")
 	PublicTxCreated                        = pdm("PublicTx.created", "The creation time")
+	PublicTxUpdated                        = pdm("PublicTx.updated", "The time of the last update to the transaction, its submissions or its completion")
 	PublicTxCompletedAt                    = pdm("PublicTx.completedAt", "The completion time (optional)")
 	PublicTxTransactionHash                = pdm("PublicTx.transactionHash", "The transaction hash (optional)")
 	PublicTxSuccess                        = pdm("PublicTx.success", "The transaction success status (optional)")
 	PublicTxRevertData                     = pdm("PublicTx.revertData", "The revert data (optional)")
+	PublicTxRevertReason                   = pdm("PublicTx.revertReason", "The revert reason decoded from the revert data, when it is a standard Error(string) (optional)")
 	PublicTxSubmissions                    = pdm("PublicTx.submissions", "The submission data (optional)")
 	PublicTxActivity                       = pdm("PublicTx.activity", "The transaction activity records (optional)")
+	PublicTxLabel                          = pdm("PublicTx.label", "The free-text label supplied on submission (optional)")
+	PublicTxFailureCategory                = pdm("PublicTx.failureCategory", "The category of the last failure to sign, submit or confirm the transaction, cleared once it succeeds (optional)")
 	PublicTxBindingTransaction             = pdm("PublicTxBinding.transaction", "The transaction ID")
 	PublicTxBindingTransactionType         = pdm("PublicTxBinding.transactionType", "The transaction type")
 )
@@ -122,6 +128,7 @@ var (
 	TransactionInputDependsOn                               = pdm("TransactionInput.dependsOn", "Transactions that must be mined on the blockchain successfully before this transaction submits")
 	TransactionInputABI                                     = pdm("TransactionInput.abi", "Application Binary Interface (ABI) definition - required if abiReference not supplied")
 	TransactionInputBytecode                                = pdm("TransactionInput.bytecode", "Bytecode prepended to encoded data inputs for deploy transactions")
+	TransactionInputNonce                                   = pdm("TransactionInput.nonce", "An explicit nonce for a public transaction, for recovery such as filling a nonce gap (optional)")
 	TransactionCallDataFormat                               = pdm("TransactionCall.dataFormat", "How call data should be serialized into JSON once decoded using the ABI function definition")
 	TransactionFullDependsOn                                = pdm("TransactionFull.dependsOn", "Transactions registered as dependencies when the transaction was created")
 	TransactionFullReceipt                                  = pdm("TransactionFull.receipt", "Transaction receipt data - available if the transaction has reached a final state")
@@ -284,6 +291,16 @@ var (
 	ReliableMessageAckMessageID    = pdm("ReliableMessageAck.messageId", "ID of the reliable message delivery that this ack is associated with")
 	ReliableMessageAckMessageTime  = pdm("ReliableMessageAck.time", "Time the ack was received (or generated if it is local failure that stops a delivery being attempted)")
 	ReliableMessageAckMessageError = pdm("ReliableMessageAck.error", "A permanent failure (a 'nack') that will stop any further attempts to deliver this message")
+
+	ReliableMessageRetryStatusMessageID     = pdm("ReliableMessageRetryStatus.messageId", "ID of the reliable message delivery")
+	ReliableMessageRetryStatusNode          = pdm("ReliableMessageRetryStatus.node", "The node the message is being delivered to")
+	ReliableMessageRetryStatusAcknowledged  = pdm("ReliableMessageRetryStatus.acknowledged", "True if an ack (or nack) has been received, and no further attempts will be made")
+	ReliableMessageRetryStatusAckError      = pdm("ReliableMessageRetryStatus.ackError", "The permanent error recorded with a nack")
+	ReliableMessageRetryStatusPeerActive    = pdm("ReliableMessageRetryStatus.peerActive", "True if there is an active connection to the peer node in this process")
+	ReliableMessageRetryStatusAttempts      = pdm("ReliableMessageRetryStatus.attempts", "Number of send attempts made for this message since the peer was activated")
+	ReliableMessageRetryStatusLastAttempt   = pdm("ReliableMessageRetryStatus.lastAttempt", "Time of the last send attempt")
+	ReliableMessageRetryStatusNextAttempt   = pdm("ReliableMessageRetryStatus.nextAttempt", "Earliest time of the next send attempt, if the message is still outstanding")
+	ReliableMessageRetryStatusRetryFailures = pdm("ReliableMessageRetryStatus.retryFailures", "Number of consecutive failures sending to the peer, which determines the current retry backoff")
 )
 
 // pldclient/privacygroups.go
@@ -336,4 +353,10 @@ var (
 	PrivacyGroupMessageLocalGroup         = pdm("PrivacyGroupMessage.group", "Group ID of the privacy group. All members in the group will receive a copy of the message (no guarantee of order)")
 	PrivacyGroupMessageTopic              = pdm("PrivacyGroupMessage.topic", "A topic for the message, which by convention should be a dot or slash separated string instructing the receiver how the message should be processed")
 	PrivacyGroupMessageData               = pdm("PrivacyGroupMessage.data", "Application defined JSON payload for the message. Can be any JSON type including as an object, array, hex string, other string, or number")
+
+	PrivacyGroupMessageStatsDomain              = pdm("PrivacyGroupMessageStats.domain", "Domain of the privacy group")
+	PrivacyGroupMessageStatsGroup               = pdm("PrivacyGroupMessageStats.group", "Group ID of the privacy group")
+	PrivacyGroupMessageStatsCount               = pdm("PrivacyGroupMessageStats.count", "Total number of messages stored in the local database for the group")
+	PrivacyGroupMessageStatsLatestLocalSequence = pdm("PrivacyGroupMessageStats.latestLocalSequence", "The highest local sequence of any message in the group. Omitted if there are no messages")
+	PrivacyGroupMessageStatsLatestSent          = pdm("PrivacyGroupMessageStats.latestSent", "The latest sent time of any message in the group. Omitted if there are no messages")
 )
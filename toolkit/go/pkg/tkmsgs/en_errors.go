@@ -80,6 +80,7 @@ var (
 	MsgPluginUnexpectedResponse   = pde("PD020301", "Unexpected response %T (expected %T)")
 	MsgPluginUnimplementedRequest = pde("PD020302", "Unimplemented plugin request %T")
 	MsgPluginErrorFromServerNoMsg = pde("PD020303", "Error from server (no detailed message in response)")
+	MsgPluginEndorsementInvalid   = pde("PD020304", "Endorsement is no longer valid")
 
 	// TLS PD0204XX
 	MsgTLSInvalidCAFile             = pde("PD020400", "Invalid CA certificates file")
@@ -143,6 +144,21 @@ var (
 	MsgSigningEmptyPayload                      = pde("PD020825", "No payload supplied for signing")
 	MsgSigningInvalidDomainAlgorithmNoPrefix    = pde("PD020826", "Invalid domain algorithm (no 'domain:' prefix): %s")
 	MsgSigningNoDomainRegisteredWithModule      = pde("PD020827", "Domain '%s' has not been registered in this signing module")
+	MsgSigningKeyDerivationPathInvalid          = pde("PD020828", "Invalid derivation path stored with key '%s'")
+	MsgSigningKeyAlgorithmsInvalid              = pde("PD020829", "Invalid algorithms stored with key '%s'")
+	MsgSigningKeyAlgorithmMismatch              = pde("PD020830", "Key '%s' has only been resolved for algorithms %v, and cannot be used with algorithm '%s'")
+	MsgSigningModuleBadKDFType                  = pde("PD020831", "Unsupported key derivation function '%s' for filesystem key store")
+	MsgSigningModuleBadKDFParams                = pde("PD020832", "Invalid key derivation function parameters: %s")
+	MsgSigningModuleBadMasterKeyWrapperType     = pde("PD020833", "Unsupported master key wrapper type '%s' for filesystem key store")
+	MsgSigningModuleBadMasterKeyWrapperConfig   = pde("PD020834", "Invalid configuration for master key wrapper '%s': %s")
+	MsgSigningModuleMasterKeyWrapFailed         = pde("PD020835", "Master key wrapper '%s' failed to wrap key file password")
+	MsgSigningModuleMasterKeyUnwrapFailed       = pde("PD020836", "Master key wrapper '%s' failed to unwrap key file password")
+	MsgSigningModuleMasterKeyWrapperMismatch    = pde("PD020837", "Key file '%s' was written with master key wrapper '%s', but the store is configured with '%s'")
+	MsgSigningModuleMasterKeyWrapperHTTPError   = pde("PD020838", "Master key wrapper '%s' request failed with status %d: %s")
+	MsgSigningModuleUnhealthy                   = pde("PD020839", "Key store directory '%s' is not available")
+	MsgSigningModuleBadKeyAliasFile             = pde("PD020840", "Invalid key alias file '%s'")
+	MsgSigningModuleKeyStoreReadOnly            = pde("PD020841", "Key '%s' cannot be created as the key store is read-only")
+	MsgSigningModuleReadOnlyNoExistenceCheck    = pde("PD020842", "Key store type '%s' cannot be read-only with in-store signing, as it cannot check a key exists without creating it")
 
 	// Reference markdown PD0209XX
 	MsgReferenceMarkdownMissing = pde("PD020900", "Reference markdown file missing: '%s'")